@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// confirmWord is what must be typed to confirm deleting history.
+const confirmWord = "DELETE"
+
+// cmdClear deletes the unpinned entries, or with purge every entry, once
+// confirmed as [cli] confirm requires.
+func cmdClear(m *history.Manager, purge bool, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	name, what := "clear", "unpinned entries"
+	if purge {
+		name, what = "purge", "entries, pinned ones too"
+	}
+	yes := false
+	for _, arg := range args {
+		if arg != "--yes" && arg != "-y" {
+			fmt.Fprintf(stderr, "usage: clippy %s [--yes]\n", name)
+			return 2
+		}
+		yes = true
+	}
+
+	count := 0
+	for _, item := range m.GetItems() {
+		if purge || !item.Pinned {
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Fprint(stdout, "Nothing to delete\n")
+		return 0
+	}
+
+	cfg, _ := loadConfig()
+	prompt := fmt.Sprintf("This deletes %d %s and can't be undone.", count, what)
+	if !confirmDestructive(cfg.CLI.Confirm, yes, prompt, stdin, stderr) {
+		fmt.Fprint(stderr, "Aborted; nothing was deleted\n")
+		return 1
+	}
+
+	deleteEntries := m.Clear
+	if purge {
+		deleteEntries = m.Purge
+	}
+	deleted, err := deleteEntries()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to delete entries: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Deleted %d entries\n", deleted)
+	return 0
+}
+
+// confirmDestructive reports whether an action described by prompt may go
+// ahead under policy, a [cli] confirm value, given whether --yes was passed.
+// Unknown policies ask like "type".
+func confirmDestructive(policy string, yes bool, prompt string, stdin io.Reader, stderr io.Writer) bool {
+	switch {
+	case policy == config.ConfirmOff || yes:
+		return true
+	case policy == config.ConfirmFlag:
+		fmt.Fprintf(stderr, "%s Pass --yes to confirm.\n", prompt)
+		return false
+	}
+	fmt.Fprintf(stderr, "%s Type %s to confirm: ", prompt, confirmWord)
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(stderr)
+		return false
+	}
+	return strings.TrimSpace(line) == confirmWord
+}
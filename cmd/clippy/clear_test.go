@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// useStdin feeds input to commands reading stdin.
+func useStdin(t *testing.T, input string) {
+	t.Helper()
+	orig := stdin
+	t.Cleanup(func() { stdin = orig })
+	stdin = strings.NewReader(input)
+}
+
+// countEntries returns how many entries the database at dbPath holds.
+func countEntries(t *testing.T, dbPath string) int {
+	t.Helper()
+	m, err := history.NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Logf("close manager: %v", err)
+		}
+	}()
+	if err := m.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	return m.Count()
+}
+
+func TestClearRequiresTypedConfirmation(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "one", "two")
+
+	useStdin(t, "yes\n")
+	if code, _, errOut := run("clear"); code != 1 || !strings.Contains(errOut, "Type DELETE") {
+		t.Fatalf("clear with the wrong word: code %d, stderr %q", code, errOut)
+	}
+	if n := countEntries(t, dbPath); n != 2 {
+		t.Fatalf("%d entries left after aborting, want 2", n)
+	}
+
+	useStdin(t, "DELETE\n")
+	if code, out, errOut := run("clear"); code != 0 || !strings.Contains(out, "Deleted 2") {
+		t.Fatalf("clear: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if n := countEntries(t, dbPath); n != 0 {
+		t.Errorf("%d entries left after clear, want 0", n)
+	}
+}
+
+func TestClearKeepsPinnedAndPurgeDoesNot(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "one", "two")
+	m, err := history.NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	if err := m.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if err := m.TogglePin(0); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if code, _, errOut := run("clear", "--yes"); code != 0 {
+		t.Fatalf("clear --yes: code %d, stderr %q", code, errOut)
+	}
+	if n := countEntries(t, dbPath); n != 1 {
+		t.Fatalf("%d entries left after clear, want the pinned one", n)
+	}
+	if code, _, errOut := run("purge", "--yes"); code != 0 {
+		t.Fatalf("purge --yes: code %d, stderr %q", code, errOut)
+	}
+	if n := countEntries(t, dbPath); n != 0 {
+		t.Errorf("%d entries left after purge, want 0", n)
+	}
+}
+
+func TestClearConfirmPolicy(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "one")
+	policy := config.ConfirmFlag
+	loadConfig = func() (config.Config, error) {
+		cfg := config.Default()
+		cfg.CLI.Confirm = policy
+		return cfg, nil
+	}
+
+	// Automation never waits for input that won't come
+	useStdin(t, "DELETE\n")
+	if code, _, errOut := run("clear"); code != 1 || !strings.Contains(errOut, "--yes") {
+		t.Fatalf("clear under the flag policy: code %d, stderr %q", code, errOut)
+	}
+	policy = config.ConfirmOff
+	if code, _, errOut := run("clear"); code != 0 {
+		t.Fatalf("clear with confirmation off: code %d, stderr %q", code, errOut)
+	}
+	if n := countEntries(t, dbPath); n != 0 {
+		t.Errorf("%d entries left, want 0", n)
+	}
+}
+
+func TestClearUsage(t *testing.T) {
+	useTestDB(t)
+	if code, _, errOut := run("purge", "--force"); code != 2 || !strings.Contains(errOut, "usage: clippy purge") {
+		t.Errorf("purge --force: code %d, stderr %q", code, errOut)
+	}
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/clipimage"
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/hooks"
+	"github.com/bvdwalt/clippy/internal/search"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Overridable for tests.
+var (
+	openManager              = history.NewManager
+	writeClipboard           = sysclip.WriteAll
+	writePrimary             = sysclip.WritePrimary
+	writeImage               = clipimage.Write
+	loadConfig               = config.Load
+	checkConfig              = checkConfigFile
+	stdin          io.Reader = os.Stdin
+)
+
+const usage = `Usage:
+  clippy                       Start the interactive history browser
+  clippy --once                Start it to copy one entry, quitting once it is copied
+  clippy add [<text>...]       Add text (or stdin when no text is given) to history
+  clippy copy <alias>          Copy the entry with the given alias to the clipboard
+  clippy copy <id>             Copy the entry with the given short ID, e.g. k3f
+  clippy copy --primary <alias>
+                               Place it in the primary selection (middle-click paste)
+  clippy copy --register <a-z> Copy the entry stored in a register
+  clippy alias list            List all aliases
+  clippy alias set <name> <#>  Assign an alias to the entry with table number #
+  clippy alias rm <name>       Remove an alias
+  clippy register list         List the registers a-z holding entries
+  clippy register set <r> <#>  Store the entry with table number # in register r
+  clippy register rm <r>       Clear a register
+  clippy merge <db-path>       Import entries from another clippy database
+  clippy archive run [<age>]   Archive entries older than age (e.g. 720h)
+  clippy archive list          List archived entries
+  clippy archive search <q>    Search archived entries
+  clippy archive restore <id>  Move an archived entry back into history
+  clippy compact run [--top <n>] [<months>]
+                               Collapse entries older than months into daily digests
+  clippy compact list          List the daily digests
+  clippy clear [--yes]         Delete all unpinned entries, after typing DELETE to confirm
+  clippy purge [--yes]         Delete all entries, pinned ones too
+  clippy uninstall-data [--shred] [--yes]
+                               Delete the history, its files and the config, overwriting them first with --shred
+  clippy incognito [on|off]    Show or switch incognito mode, in which new entries aren't saved
+  clippy migrate status|up     Show or apply pending database schema migrations
+  clippy doctor                Show the clipboard backend in use, its fallbacks and the history database
+  clippy simulate [--app <name>] [--input <file>]
+                               Show what capturing the file (or stdin) copied from app would do, without recording it
+  clippy record <file>         Start the interactive browser, recording keys and captures (without their text) for a bug report
+  clippy replay [--print] [--speed <factor>] <file>
+                               Play a recorded session back against an empty history and a fake clipboard
+  clippy trace [reset]         Summarize timings recorded with CLIPPY_TRACE=1, or delete them
+  clippy daemon                Capture the clipboard in the background; the TUI then only views history
+  clippy daemon --log-format text|json
+                               Also log captures, deletions and syncs in that format
+  clippy install-service [--print]
+                               Write systemd user units that start the daemon on demand
+  clippy follow                Browse history read-only, showing what the daemon captures
+  clippy pick                  Choose an entry interactively and print it
+  clippy shell-init <shell>    Print a zsh, bash or fish key binding for pick
+  clippy help                  Show this help
+`
+
+// runCommand dispatches a CLI subcommand and returns the process exit code.
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "add":
+		return withManager(stderr, func(m *history.Manager) int { return cmdAdd(m, args[1:], stdin, stdout, stderr) })
+	case "copy":
+		return withManagerAndHooks(stderr, func(m *history.Manager, runner *hooks.Runner) int {
+			return cmdCopy(m, runner, args[1:], stdout, stderr)
+		})
+	case "alias":
+		return withManager(stderr, func(m *history.Manager) int { return cmdAlias(m, args[1:], stdout, stderr) })
+	case "register":
+		return withManager(stderr, func(m *history.Manager) int { return cmdRegister(m, args[1:], stdout, stderr) })
+	case "merge":
+		return withManager(stderr, func(m *history.Manager) int { return cmdMerge(m, args[1:], stdout, stderr) })
+	case "archive":
+		return withManager(stderr, func(m *history.Manager) int { return cmdArchive(m, args[1:], stdout, stderr) })
+	case "compact":
+		return withManager(stderr, func(m *history.Manager) int { return cmdCompact(m, args[1:], stdout, stderr) })
+	case "clear", "purge":
+		return withManager(stderr, func(m *history.Manager) int {
+			return cmdClear(m, args[0] == "purge", args[1:], stdin, stdout, stderr)
+		})
+	case "incognito":
+		return withManager(stderr, func(m *history.Manager) int { return cmdIncognito(m, args[1:], stdout, stderr) })
+	case "uninstall-data":
+		return cmdUninstallData(args[1:], stdout, stderr)
+	case "migrate":
+		return cmdMigrate(args[1:], stdout, stderr)
+	case "doctor":
+		return cmdDoctor(args[1:], stdout, stderr)
+	case "simulate":
+		return cmdSimulate(args[1:], stdout, stderr)
+	case "record":
+		return cmdRecord(args[1:], stdout, stderr)
+	case "replay":
+		return cmdReplay(args[1:], stdout, stderr)
+	case "trace":
+		return cmdTrace(args[1:], stdout, stderr)
+	case "daemon":
+		return cmdDaemon(args[1:], stdout, stderr)
+	case "install-service":
+		return cmdInstallService(args[1:], stdout, stderr)
+	case "--once":
+		return cmdOnce(args[1:], stderr)
+	case "follow":
+		return cmdFollow(args[1:], stderr)
+	case "pick":
+		return cmdPick(stdout, stderr)
+	case "shell-init":
+		return cmdShellInit(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	case "version", "--version":
+		fmt.Fprintln(stdout, version)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// withManager opens and loads the history database for the duration of fn.
+func withManager(stderr io.Writer, fn func(*history.Manager) int) int {
+	return withManagerAndHooks(stderr, func(m *history.Manager, _ *hooks.Runner) int { return fn(m) })
+}
+
+// withManagerAndHooks is withManager also passing fn the configured hooks,
+// which run for entries fn adds. It returns once they have finished.
+func withManagerAndHooks(stderr io.Writer, fn func(*history.Manager, *hooks.Runner) int) int {
+	m, err := openManager()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create history manager: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			fmt.Fprintf(stderr, "Failed to close history manager: %v\n", err)
+		}
+	}()
+
+	runner := hooks.NewRunner(nil)
+	if cfg, err := loadConfig(); err == nil {
+		m.SetOverflowThreshold(cfg.History.OverflowBytes)
+		m.SetCaptureLimit(cfg.History.MaxCaptureBytes, cfg.History.Oversized == config.OversizedTruncate)
+		applyClipboardBackend(cfg)
+		runner = hookRunner(cfg)
+	}
+	defer runner.Wait()
+	runner.Attach(m)
+	if err := m.LoadFromDB(); err != nil {
+		fmt.Fprintf(stderr, "Could not load history: %v\n", err)
+		return 1
+	}
+	return fn(m, runner)
+}
+
+func cmdAdd(m *history.Manager, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var content string
+	if len(args) == 0 || (len(args) == 1 && args[0] == "-") {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read stdin: %v\n", err)
+			return 1
+		}
+		// Piped commands end their output with a newline that was never
+		// part of what the user wants to paste
+		content = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	} else {
+		content = strings.Join(args, " ")
+	}
+	if strings.TrimSpace(content) == "" {
+		fmt.Fprint(stderr, "nothing to add\n")
+		return 2
+	}
+	if m.Incognito() {
+		// The entry would only live as long as this process
+		fmt.Fprint(stderr, "not added: incognito mode is on\n")
+		return 1
+	}
+	if m.OverCaptureLimit(len(content)) {
+		fmt.Fprintf(stderr, "not added: %s is over the capture limit\n", history.FormatSize(len(content)))
+		return 1
+	}
+	added, err := m.AddText(content, history.SelectionClipboard, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to add: %v\n", err)
+		return 1
+	}
+	if !added {
+		fmt.Fprintf(stdout, "Already in history: %s\n", preview(content))
+		return 0
+	}
+	fmt.Fprintf(stdout, "Added %s\n", preview(content))
+	return 0
+}
+
+func cmdCopy(m *history.Manager, runner *hooks.Runner, args []string, stdout, stderr io.Writer) int {
+	write, target := writeClipboard, "clipboard"
+	register := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		switch args[0] {
+		case "--primary":
+			write, target = writePrimary, "primary selection"
+		case "--register":
+			register = true
+		default:
+			fmt.Fprint(stderr, "usage: clippy copy [--primary] [--register] <alias|id>\n")
+			return 2
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		fmt.Fprint(stderr, "usage: clippy copy [--primary] [--register] <alias|id>\n")
+		return 2
+	}
+	item, ok := m.FindByAlias(args[0])
+	if !ok {
+		item, ok = m.FindByShortID(args[0])
+	}
+	if register {
+		item, ok = m.FindByRegister(args[0])
+	}
+	if !ok {
+		if register {
+			fmt.Fprintf(stderr, "register %q is empty\n", args[0])
+		} else {
+			fmt.Fprintf(stderr, "no entry with alias or ID %q\n", args[0])
+		}
+		return 1
+	}
+	if item.IsBinary() {
+		return copyImage(m, item, args[0], target, stdout, stderr)
+	}
+	text, err := m.Text(item)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read entry: %v\n", err)
+		return 1
+	}
+	if err := write(text); err != nil {
+		fmt.Fprintf(stderr, "Failed to write to %s: %v\n", target, err)
+		return 1
+	}
+	runner.Copied(item, text)
+	fmt.Fprintf(stdout, "Copied %q to %s\n", args[0], target)
+	return 0
+}
+
+// copyImage copies an image entry's data to the clipboard; the primary
+// selection only holds text
+func copyImage(m *history.Manager, item history.ClipboardHistory, name, target string, stdout, stderr io.Writer) int {
+	if target != "clipboard" {
+		fmt.Fprintf(stderr, "%q is an image, which can't be placed in the %s\n", name, target)
+		return 1
+	}
+	data, err := m.GetData(item)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read image: %v\n", err)
+		return 1
+	}
+	if err := writeImage(data, item.MimeType); err != nil {
+		fmt.Fprintf(stderr, "Failed to write to %s: %v\n", target, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Copied %q to %s\n", name, target)
+	return 0
+}
+
+func cmdAlias(m *history.Manager, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, "usage: clippy alias list|set|rm\n")
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		for _, item := range m.GetItems() {
+			if item.Alias != "" {
+				fmt.Fprintf(stdout, "%s\t%s\n", item.Alias, preview(item.Item))
+			}
+		}
+		return 0
+	case "set":
+		if len(args) != 3 {
+			fmt.Fprint(stderr, "usage: clippy alias set <name> <#>\n")
+			return 2
+		}
+		n, err := strconv.Atoi(args[2])
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid entry number %q\n", args[2])
+			return 2
+		}
+		if err := m.SetAlias(n-1, args[1]); err != nil {
+			fmt.Fprintf(stderr, "Failed to set alias: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Alias %q set on entry %d\n", args[1], n)
+		return 0
+	case "rm":
+		if len(args) != 2 {
+			fmt.Fprint(stderr, "usage: clippy alias rm <name>\n")
+			return 2
+		}
+		for i, item := range m.GetItems() {
+			if item.Alias == args[1] {
+				if err := m.SetAlias(i, ""); err != nil {
+					fmt.Fprintf(stderr, "Failed to remove alias: %v\n", err)
+					return 1
+				}
+				fmt.Fprintf(stdout, "Alias %q removed\n", args[1])
+				return 0
+			}
+		}
+		fmt.Fprintf(stderr, "no entry with alias %q\n", args[1])
+		return 1
+	default:
+		fmt.Fprintf(stderr, "unknown alias subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+func cmdRegister(m *history.Manager, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, "usage: clippy register list|set|rm\n")
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		for _, name := range "abcdefghijklmnopqrstuvwxyz" {
+			if item, ok := m.FindByRegister(string(name)); ok {
+				fmt.Fprintf(stdout, "%c\t%s\n", name, preview(item.Item))
+			}
+		}
+		return 0
+	case "set":
+		if len(args) != 3 {
+			fmt.Fprint(stderr, "usage: clippy register set <a-z> <#>\n")
+			return 2
+		}
+		n, err := strconv.Atoi(args[2])
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid entry number %q\n", args[2])
+			return 2
+		}
+		if err := m.SetRegister(args[1], n-1); err != nil {
+			fmt.Fprintf(stderr, "Failed to set register: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Entry %d stored in register %q\n", n, args[1])
+		return 0
+	case "rm":
+		if len(args) != 2 {
+			fmt.Fprint(stderr, "usage: clippy register rm <a-z>\n")
+			return 2
+		}
+		if err := m.ClearRegister(args[1]); err != nil {
+			fmt.Fprintf(stderr, "Failed to clear register: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Register %q cleared\n", args[1])
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown register subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+func cmdIncognito(m *history.Manager, args []string, stdout, stderr io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprint(stderr, "usage: clippy incognito [on|off]\n")
+		return 2
+	}
+	if len(args) == 1 {
+		var enabled bool
+		switch args[0] {
+		case "on":
+			enabled = true
+		case "off":
+		default:
+			fmt.Fprint(stderr, "usage: clippy incognito [on|off]\n")
+			return 2
+		}
+		if err := m.SetIncognito(enabled); err != nil {
+			fmt.Fprintf(stderr, "Failed to switch incognito mode: %v\n", err)
+			return 1
+		}
+	}
+	if m.Incognito() {
+		fmt.Fprint(stdout, "Incognito mode is on: new entries are kept in memory and not saved\n")
+	} else {
+		fmt.Fprint(stdout, "Incognito mode is off\n")
+	}
+	return 0
+}
+
+func cmdMerge(m *history.Manager, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, "usage: clippy merge <db-path>\n")
+		return 2
+	}
+	stats, err := m.MergeFrom(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to merge: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Merged %s: %d added, %d combined, %d aliases imported\n", args[0], stats.Added, stats.Merged, stats.Aliases)
+	return 0
+}
+
+func cmdArchive(m *history.Manager, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, "usage: clippy archive run|list|search|restore\n")
+		return 2
+	}
+
+	switch args[0] {
+	case "run":
+		if len(args) > 2 {
+			fmt.Fprint(stderr, "usage: clippy archive run [<age>]\n")
+			return 2
+		}
+		age, ok := archiveAge(args[1:], stderr)
+		if !ok {
+			return 2
+		}
+		moved, err := m.ArchiveOlderThan(time.Now().Add(-age))
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to archive: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Archived %d entries older than %s\n", moved, age)
+		return 0
+	case "list", "search":
+		if args[0] == "search" && len(args) < 2 {
+			fmt.Fprint(stderr, "usage: clippy archive search <query>\n")
+			return 2
+		}
+		items, err := m.ArchivedItems()
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read archive: %v\n", err)
+			return 1
+		}
+		if args[0] == "search" {
+			items = search.NewFuzzyMatcher().Search(items, strings.Join(args[1:], " "))
+		}
+		for _, item := range items {
+			fmt.Fprintf(stdout, "%s\t%s\t%s\n", item.Hash[:12], item.TimeStamp.Format("2006-01-02 15:04"), preview(item.Item))
+		}
+		return 0
+	case "restore":
+		if len(args) != 2 {
+			fmt.Fprint(stderr, "usage: clippy archive restore <id>\n")
+			return 2
+		}
+		item, err := m.RestoreArchived(args[1])
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to restore: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Restored %s\n", preview(item.Item))
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown archive subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+// archiveAge returns the age given on the command line, falling back to
+// archive.after from the config.
+func archiveAge(args []string, stderr io.Writer) (time.Duration, bool) {
+	if len(args) == 1 {
+		age, err := time.ParseDuration(args[0])
+		if err != nil || age <= 0 {
+			fmt.Fprintf(stderr, "invalid age %q (use a duration such as 720h)\n", args[0])
+			return 0, false
+		}
+		return age, true
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+	if cfg.Archive.After <= 0 {
+		fmt.Fprint(stderr, "no age given and archive.after is not configured\n")
+		return 0, false
+	}
+	return cfg.Archive.After, true
+}
+
+// preview returns a single-line rendering of content for CLI listings, cut
+// to 60 terminal cells.
+func preview(content string) string {
+	return ansi.Truncate(strings.Join(strings.Fields(content), " "), 60, "...")
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// useTestDB points openManager at an isolated database and stubs clipboard
+// writes, returning the path and a pointer to the last written content.
+func useTestDB(t *testing.T) (string, *string) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	origOpen, origWrite, origConfig, origCheck := openManager, writeClipboard, loadConfig, checkConfig
+	var written string
+	openManager = func() (*history.Manager, error) { return history.NewManagerWithPath(dbPath) }
+	loadConfig = func() (config.Config, error) { return config.Default(), nil }
+	checkConfig = func() error { return nil }
+	writeClipboard = func(s string) error {
+		written = s
+		return nil
+	}
+	t.Cleanup(func() {
+		openManager, writeClipboard, loadConfig, checkConfig = origOpen, origWrite, origConfig, origCheck
+	})
+	return dbPath, &written
+}
+
+// seedDB adds contents to the database at dbPath.
+func seedDB(t *testing.T, dbPath string, contents ...string) {
+	t.Helper()
+
+	m, err := history.NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Logf("close manager: %v", err)
+		}
+	}()
+	for _, c := range contents {
+		m.AddItem(c)
+	}
+}
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := runCommand(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunCommandHelp(t *testing.T) {
+	code, out, _ := run("help")
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if !strings.Contains(out, "clippy copy <alias>") {
+		t.Errorf("help output missing copy usage: %q", out)
+	}
+}
+
+func TestRunCommandUnknown(t *testing.T) {
+	code, _, errOut := run("bogus")
+	if code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+	if !strings.Contains(errOut, "unknown command") {
+		t.Errorf("stderr = %q", errOut)
+	}
+}
+
+func TestAliasSetAndCopy(t *testing.T) {
+	dbPath, written := useTestDB(t)
+	seedDB(t, dbPath, "ssh deploy@prod", "other")
+
+	if code, _, errOut := run("alias", "set", "ssh-prod", "1"); code != 0 {
+		t.Fatalf("alias set exit = %d, stderr = %q", code, errOut)
+	}
+
+	code, _, errOut := run("copy", "ssh-prod")
+	if code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+	if *written != "ssh deploy@prod" {
+		t.Errorf("clipboard = %q, want %q", *written, "ssh deploy@prod")
+	}
+
+	_, out, _ := run("alias", "list")
+	if !strings.Contains(out, "ssh-prod") {
+		t.Errorf("alias list = %q, want ssh-prod", out)
+	}
+}
+
+func TestCopyToPrimary(t *testing.T) {
+	dbPath, written := useTestDB(t)
+	seedDB(t, dbPath, "middle-click me")
+	var selected string
+	orig := writePrimary
+	t.Cleanup(func() { writePrimary = orig })
+	writePrimary = func(s string) error {
+		selected = s
+		return nil
+	}
+
+	run("alias", "set", "mc", "1")
+	code, out, errOut := run("copy", "--primary", "mc")
+	if code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+	if selected != "middle-click me" || *written != "" {
+		t.Errorf("primary = %q, clipboard = %q; want only the primary selection written", selected, *written)
+	}
+	if !strings.Contains(out, "primary selection") {
+		t.Errorf("stdout = %q", out)
+	}
+}
+
+func TestCopyImage(t *testing.T) {
+	dbPath, written := useTestDB(t)
+	m, err := history.NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	data := []byte("\x89PNG\r\n\x1a\nnot really")
+	m.AddImage(data, "image/png")
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var image []byte
+	var mimeType string
+	orig := writeImage
+	t.Cleanup(func() { writeImage = orig })
+	writeImage = func(d []byte, mt string) error {
+		image, mimeType = d, mt
+		return nil
+	}
+
+	code, _, errOut := run("copy", "1")
+	if code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+	if !bytes.Equal(image, data) || mimeType != "image/png" || *written != "" {
+		t.Errorf("image = %q (%s), clipboard text = %q; want the image data written", image, mimeType, *written)
+	}
+
+	image = nil
+	if code, _, errOut := run("copy", "--primary", "1"); code != 1 || !strings.Contains(errOut, "image") || image != nil {
+		t.Errorf("copy --primary of an image = %d, %q; want it refused", code, errOut)
+	}
+}
+
+func TestCopyByID(t *testing.T) {
+	dbPath, written := useTestDB(t)
+	seedDB(t, dbPath, "first", "second")
+
+	// IDs are given in the order entries were saved, whatever the table shows
+	if code, _, errOut := run("copy", "2"); code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+	if *written != "second" {
+		t.Errorf("clipboard = %q, want second", *written)
+	}
+
+	// An alias wins over an ID it looks like
+	if code, _, errOut := run("alias", "set", "1", "2"); code != 0 {
+		t.Fatalf("alias set exit = %d, stderr = %q", code, errOut)
+	}
+	_, aliases, _ := run("alias", "list")
+	if code, _, errOut := run("copy", "1"); code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+	if aliases != "1\t"+*written+"\n" {
+		t.Errorf("clipboard = %q, want the entry aliased 1 in %q", *written, aliases)
+	}
+
+	if code, _, errOut := run("copy", "zz"); code != 1 || !strings.Contains(errOut, "alias or ID") {
+		t.Errorf("copy of unknown ID = %d, %q; want not found", code, errOut)
+	}
+}
+
+func TestRegisterSetAndCopy(t *testing.T) {
+	dbPath, written := useTestDB(t)
+	seedDB(t, dbPath, "kubectl get pods", "other")
+
+	if code, _, errOut := run("register", "set", "k", "1"); code != 0 {
+		t.Fatalf("register set exit = %d, stderr = %q", code, errOut)
+	}
+	code, _, errOut := run("copy", "--register", "k")
+	if code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+	if *written != "kubectl get pods" {
+		t.Errorf("clipboard = %q, want %q", *written, "kubectl get pods")
+	}
+
+	_, out, _ := run("register", "list")
+	if !strings.HasPrefix(out, "k\t") {
+		t.Errorf("register list = %q, want register k", out)
+	}
+
+	if code, _, _ := run("register", "rm", "k"); code != 0 {
+		t.Fatalf("register rm exit = %d", code)
+	}
+	if code, _, errOut := run("copy", "--register", "k"); code != 1 || !strings.Contains(errOut, "empty") {
+		t.Errorf("copy of cleared register = %d, %q; want an empty register error", code, errOut)
+	}
+	if code, _, _ := run("register", "set", "K", "1"); code != 1 {
+		t.Errorf("register set K exit = %d, want 1", code)
+	}
+}
+
+func TestAliasSetDuplicate(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "one", "two")
+
+	if code, _, _ := run("alias", "set", "x", "1"); code != 0 {
+		t.Fatalf("first alias set failed")
+	}
+	code, _, errOut := run("alias", "set", "x", "2")
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(errOut, "already in use") {
+		t.Errorf("stderr = %q", errOut)
+	}
+}
+
+func TestAliasRemove(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "one")
+
+	run("alias", "set", "x", "1")
+	if code, _, errOut := run("alias", "rm", "x"); code != 0 {
+		t.Fatalf("alias rm exit = %d, stderr = %q", code, errOut)
+	}
+	if code, _, _ := run("copy", "x"); code != 1 {
+		t.Errorf("copy after rm exit = %d, want 1", code)
+	}
+}
+
+func TestCopyUnknownAlias(t *testing.T) {
+	useTestDB(t)
+
+	code, _, errOut := run("copy", "nope")
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(errOut, "no entry with alias") {
+		t.Errorf("stderr = %q", errOut)
+	}
+}
+
+func TestCommandUsageErrors(t *testing.T) {
+	useTestDB(t)
+
+	cases := [][]string{
+		{"copy"},
+		{"alias"},
+		{"alias", "set", "x"},
+		{"alias", "set", "x", "notanumber"},
+		{"alias", "rm"},
+		{"alias", "bogus"},
+		{"incognito", "maybe"},
+	}
+	for _, args := range cases {
+		if code, _, _ := run(args...); code != 2 {
+			t.Errorf("run(%v) exit = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestMergeCommand(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "shared", "local")
+
+	otherPath := filepath.Join(t.TempDir(), "other.db")
+	seedDB(t, otherPath, "shared", "remote")
+
+	code, out, errOut := run("merge", otherPath)
+	if code != 0 {
+		t.Fatalf("merge exit = %d, stderr = %q", code, errOut)
+	}
+	if !strings.Contains(out, "1 added, 1 combined") {
+		t.Errorf("stdout = %q", out)
+	}
+
+	m, err := history.NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Logf("close manager: %v", err)
+		}
+	}()
+	if err := m.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if m.Count() != 3 {
+		t.Errorf("Count = %d, want 3", m.Count())
+	}
+}
+
+func TestMergeCommandErrors(t *testing.T) {
+	useTestDB(t)
+
+	if code, _, _ := run("merge"); code != 2 {
+		t.Errorf("merge without path exit = %d, want 2", code)
+	}
+	if code, _, _ := run("merge", filepath.Join(t.TempDir(), "missing.db")); code != 1 {
+		t.Errorf("merge missing file exit = %d, want 1", code)
+	}
+}
+
+func TestArchiveCommands(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "old deploy command", "old grocery list")
+
+	if code, _, errOut := run("archive", "run"); code != 2 || !strings.Contains(errOut, "archive.after") {
+		t.Errorf("archive run without age: code %d, stderr %q", code, errOut)
+	}
+	code, out, errOut := run("archive", "run", "1ns")
+	if code != 0 || !strings.Contains(out, "Archived 2 entries") {
+		t.Fatalf("archive run: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+
+	code, out, _ = run("archive", "search", "deploy")
+	if code != 0 || !strings.Contains(out, "old deploy command") || strings.Contains(out, "grocery") {
+		t.Fatalf("archive search: code %d, stdout %q", code, out)
+	}
+	id := strings.Fields(out)[0]
+
+	code, out, errOut = run("archive", "restore", id)
+	if code != 0 || !strings.Contains(out, "Restored old deploy command") {
+		t.Fatalf("archive restore: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+
+	code, out, _ = run("archive", "list")
+	if code != 0 || strings.Contains(out, "deploy") || !strings.Contains(out, "grocery") {
+		t.Errorf("archive list after restore: code %d, stdout %q", code, out)
+	}
+}
+
+func TestArchiveRunInvalidAge(t *testing.T) {
+	useTestDB(t)
+
+	if code, _, _ := run("archive", "run", "soon"); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
+
+func TestAddCommand(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+
+	code, out, _ := run("add", "deploy", "--prod")
+	if code != 0 || !strings.Contains(out, "Added deploy --prod") {
+		t.Fatalf("add args: code %d, stdout %q", code, out)
+	}
+
+	origStdin := stdin
+	t.Cleanup(func() { stdin = origStdin })
+	stdin = strings.NewReader("from a pipe\n")
+	if code, out, _ := run("add"); code != 0 || !strings.Contains(out, "Added from a pipe") {
+		t.Fatalf("add stdin: code %d, stdout %q", code, out)
+	}
+
+	if code, out, _ := run("add", "deploy", "--prod"); code != 0 || !strings.Contains(out, "Already in history") {
+		t.Errorf("add duplicate: code %d, stdout %q", code, out)
+	}
+
+	m, err := history.NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Logf("close manager: %v", err)
+		}
+	}()
+	if err := m.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if m.Count() != 2 {
+		t.Errorf("Count = %d, want 2", m.Count())
+	}
+}
+
+func TestCommandsRunHooks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	useTestDB(t)
+	out := filepath.Join(t.TempDir(), "hooks.log")
+	loadConfig = func() (config.Config, error) {
+		cfg := config.Default()
+		for _, event := range []string{"capture", "copy"} {
+			cfg.Hooks = append(cfg.Hooks, config.HookConfig{
+				Event:   event,
+				Command: []string{"sh", "-c", `{ printf '%s ' "$CLIPPY_EVENT"; cat; echo; } >> "$0"`, out},
+			})
+		}
+		return cfg, nil
+	}
+
+	run("add", "ssh deploy@prod")
+	run("alias", "set", "prod", "1")
+	if code, _, errOut := run("copy", "prod"); code != 0 {
+		t.Fatalf("copy exit = %d, stderr = %q", code, errOut)
+	}
+
+	// Each command waits for its hooks before exiting
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "capture ssh deploy@prod\ncopy ssh deploy@prod\n"; string(data) != want {
+		t.Errorf("hooks wrote %q, want %q", data, want)
+	}
+}
+
+func TestAddCommandEmpty(t *testing.T) {
+	useTestDB(t)
+
+	origStdin := stdin
+	t.Cleanup(func() { stdin = origStdin })
+	stdin = strings.NewReader("  \n")
+	if code, _, _ := run("add"); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
+
+func TestIncognitoCommand(t *testing.T) {
+	useTestDB(t)
+
+	if code, out, _ := run("incognito"); code != 0 || !strings.Contains(out, "off") {
+		t.Fatalf("incognito: code %d, stdout %q", code, out)
+	}
+	if code, out, _ := run("incognito", "on"); code != 0 || !strings.Contains(out, "on") {
+		t.Fatalf("incognito on: code %d, stdout %q", code, out)
+	}
+	// Added entries would be lost when the command exits
+	if code, _, errOut := run("add", "secret"); code != 1 || !strings.Contains(errOut, "incognito") {
+		t.Errorf("add while incognito: code %d, stderr %q", code, errOut)
+	}
+	if code, out, _ := run("incognito", "off"); code != 0 || !strings.Contains(out, "off") {
+		t.Fatalf("incognito off: code %d, stdout %q", code, out)
+	}
+	if code, _, _ := run("add", "public"); code != 0 {
+		t.Errorf("add after incognito: code %d", code)
+	}
+}
+
+func TestPreviewCutsByWidth(t *testing.T) {
+	if got := preview("line one\n\tline two"); got != "line one line two" {
+		t.Errorf("preview = %q, want whitespace collapsed", got)
+	}
+	// Multi-byte runes and wide characters are never split
+	for _, content := range []string{strings.Repeat("é", 80), strings.Repeat("測", 80)} {
+		got := preview(content)
+		if !utf8.ValidString(got) || !strings.HasSuffix(got, "...") || ansi.StringWidth(got) > 60 {
+			t.Errorf("preview(%q...) = %q, %d cells; want valid text cut to 60 cells", content[:4], got, ansi.StringWidth(got))
+		}
+	}
+}
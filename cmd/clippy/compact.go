@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+const compactUsage = "usage: clippy compact run [--top <n>] [<months>] | list\n"
+
+func cmdCompact(m *history.Manager, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, compactUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "run":
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+		months, top := cfg.Compact.AfterMonths, cfg.Compact.Top
+		for rest := args[1:]; len(rest) > 0; {
+			switch {
+			case rest[0] == "--top" && len(rest) > 1:
+				n, err := strconv.Atoi(rest[1])
+				if err != nil || n < 0 {
+					fmt.Fprintf(stderr, "invalid --top %q (use a number of entries, 0 for none)\n", rest[1])
+					return 2
+				}
+				top, rest = n, rest[2:]
+			case len(rest) == 1 && !strings.HasPrefix(rest[0], "-"):
+				n, err := strconv.Atoi(rest[0])
+				if err != nil || n <= 0 {
+					fmt.Fprintf(stderr, "invalid age %q (use a number of months, such as 6)\n", rest[0])
+					return 2
+				}
+				months, rest = n, nil
+			default:
+				fmt.Fprint(stderr, compactUsage)
+				return 2
+			}
+		}
+		if months <= 0 {
+			fmt.Fprint(stderr, "no age given and compact.after_months is not configured\n")
+			return 2
+		}
+		stats, err := m.CompactOlderThan(compactCutoff(time.Now(), months), top)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to compact: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Compacted %d entries older than %d months into %d daily digests\n", stats.Entries, months, stats.Days)
+		return 0
+	case "list":
+		if len(args) != 1 {
+			fmt.Fprint(stderr, "usage: clippy compact list\n")
+			return 2
+		}
+		digests, err := m.Digests()
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read digests: %v\n", err)
+			return 1
+		}
+		for _, d := range digests {
+			fmt.Fprintf(stdout, "%s\t%d entries\t%d copies\t%s\t%s\n",
+				d.Day.Format("2006-01-02"), d.Entries, d.Copies, history.FormatSize(d.Bytes), digestTypes(d.Types))
+			for _, item := range d.Top {
+				fmt.Fprintf(stdout, "\t%d×\t%s\n", item.Count, item.Preview)
+			}
+		}
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown compact subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+// compactCutoff is the start of the day months before now, so only whole
+// days are compacted
+func compactCutoff(now time.Time, months int) time.Time {
+	y, m, d := now.AddDate(0, -months, 0).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+// digestTypes lists a digest's types by count, e.g. "text 5, url 2"
+func digestTypes(types map[detect.Type]int) string {
+	var names []detect.Type
+	for t := range types {
+		names = append(names, t)
+	}
+	slices.SortFunc(names, func(a, b detect.Type) int {
+		if n := types[b] - types[a]; n != 0 {
+			return n
+		}
+		return strings.Compare(string(a), string(b))
+	})
+	parts := make([]string, len(names))
+	for i, t := range names {
+		parts[i] = fmt.Sprintf("%s %d", t, types[t])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compactOld collapses entries older than the configured age into daily
+// digests
+func compactOld(historyManager *history.Manager, cfg config.Config) {
+	if cfg.Compact.AfterMonths <= 0 {
+		return
+	}
+	cutoff := compactCutoff(time.Now(), cfg.Compact.AfterMonths)
+	if _, err := historyManager.CompactOlderThan(cutoff, cfg.Compact.Top); err != nil {
+		log.Printf("Warning: Could not compact old entries: %v", err)
+	}
+}
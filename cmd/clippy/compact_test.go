@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/db"
+)
+
+// seedOldDB stores contents in the database as last copied at
+func seedOldDB(t *testing.T, dbPath string, at time.Time, contents ...string) {
+	t.Helper()
+
+	client, err := db.New(dbPath)
+	if err != nil {
+		t.Fatalf("db.New: %v", err)
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			t.Logf("close db: %v", err)
+		}
+	}()
+	for _, c := range contents {
+		entry := db.ClipboardEntry{Content: c, Hash: fmt.Sprintf("%x", sha256.Sum256([]byte(c))), Timestamp: at, Count: 1}
+		if err := client.Insert(entry); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+}
+
+func TestCompactCommands(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	old := time.Now().AddDate(-1, 0, 0)
+	seedOldDB(t, dbPath, old, "old deploy command", "https://example.com")
+	seedDB(t, dbPath, "recent note")
+
+	if code, _, errOut := run("compact", "run"); code != 2 || !strings.Contains(errOut, "compact.after_months") {
+		t.Errorf("compact run without age: code %d, stderr %q", code, errOut)
+	}
+	code, out, errOut := run("compact", "run", "--top", "1", "6")
+	if code != 0 || !strings.Contains(out, "Compacted 2 entries older than 6 months into 1 daily digests") {
+		t.Fatalf("compact run: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+
+	code, out, _ = run("compact", "list")
+	if code != 0 || !strings.Contains(out, old.Format("2006-01-02")+"\t2 entries\t2 copies\t37 B\ttext 1, url 1") {
+		t.Fatalf("compact list: code %d, stdout %q", code, out)
+	}
+	if n := strings.Count(out, "1×"); n != 1 {
+		t.Errorf("compact list shows %d top items, want 1:\n%s", n, out)
+	}
+
+	if n := countEntries(t, dbPath); n != 1 {
+		t.Errorf("%d entries left after compaction, want the recent one", n)
+	}
+}
+
+func TestCompactRunUsesConfig(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedOldDB(t, dbPath, time.Now().AddDate(0, -3, -1), "three months old")
+	loadConfig = func() (config.Config, error) {
+		cfg := config.Default()
+		cfg.Compact.AfterMonths = 3
+		return cfg, nil
+	}
+
+	code, out, errOut := run("compact", "run")
+	if code != 0 || !strings.Contains(out, "Compacted 1 entries older than 3 months") {
+		t.Fatalf("compact run: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+}
+
+func TestCompactUsage(t *testing.T) {
+	useTestDB(t)
+
+	for _, args := range [][]string{
+		{"compact"},
+		{"compact", "run", "soon"},
+		{"compact", "run", "--top", "-1", "6"},
+		{"compact", "run", "6", "7"},
+		{"compact", "list", "x"},
+		{"compact", "squash"},
+	} {
+		if code, _, _ := run(args...); code != 2 {
+			t.Errorf("%v: exit code = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestCompactCutoff(t *testing.T) {
+	now := time.Date(2024, 8, 31, 15, 4, 0, 0, time.UTC)
+	// AddDate normalizes February 31st to March 2nd
+	if got, want := compactCutoff(now, 6), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("compactCutoff = %v, want %v", got, want)
+	}
+}
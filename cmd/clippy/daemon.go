@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/bvdwalt/clippy/internal/daemon"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+)
+
+// cmdDaemon captures the clipboard in the foreground until interrupted.
+// While it runs, the TUI only displays what the daemon records. Started by
+// systemd socket activation, it also answers on the socket it was passed.
+// On Linux it serves its D-Bus interface on the session bus.
+func cmdDaemon(args []string, stdout, stderr io.Writer) int {
+	logger, ok := daemonLogger(args, stderr)
+	if !ok {
+		fmt.Fprint(stderr, "usage: clippy daemon [--log-format text|json]\n")
+		return 2
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: %v; using default settings\n", err)
+	} else if err := checkConfig(); err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+	applyClipboardBackend(cfg)
+
+	importer := bufferImporter(cfg)
+	if !sysclip.Available() && importer == nil {
+		fmt.Fprint(stderr, "no clipboard backend available; nothing to capture\n")
+		return 1
+	}
+
+	historyManager, err := openConfiguredManager(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := historyManager.Close(); err != nil {
+			fmt.Fprintf(stderr, "Failed to close history manager: %v\n", err)
+		}
+	}()
+	compactOld(historyManager, cfg)
+	archiveOld(historyManager, cfg)
+
+	runner := hookRunner(cfg)
+	defer runner.Wait()
+	runner.Attach(historyManager)
+
+	d := daemon.New(historyManager, historyManager.DataDir())
+	listener, err := daemon.ActivationListener()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if listener != nil {
+		d.SetListener(listener)
+	}
+	if targetOS == "linux" {
+		// Widgets and scripts can use the daemon without it
+		if conn, err := daemon.ConnectBus(); err != nil {
+			fmt.Fprintf(stderr, "Warning: D-Bus interface unavailable: %v\n", err)
+		} else {
+			defer conn.Close()
+			d.SetBus(conn)
+		}
+	}
+	if logger != nil {
+		d.SetLogger(logger)
+	}
+	d.SetPollInterval(cfg.Clipboard.Interval)
+	list := captureSources(cfg)
+	d.SetSources(list)
+	d.SetDebounce(cfg.Clipboard.Debounce())
+	d.SetRestoreClipboard(cfg.Clipboard.RestoreOnStart)
+	d.SetPersistSelection(cfg.Clipboard.Persist && sysclip.OwnerServed())
+	if guard := captureGuard(cfg); guard != nil {
+		d.SetCaptureGuard(guard)
+	}
+	if importer != nil {
+		d.SetBufferImporter(importer)
+	}
+	if watcher := clipboardWatcher(cfg); watcher != nil {
+		defer closeWatcher(watcher)
+		d.SetWatcher(watcher)
+		fmt.Fprintf(stdout, "Watching for clipboard changes with %s\n", watcher.Name())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(stdout, "clippy daemon capturing clipboard (pid %d); press Ctrl+C to stop\n", os.Getpid())
+	for _, s := range list {
+		fmt.Fprintf(stdout, "Also capturing %s\n", s.Name)
+	}
+	if err := d.Run(ctx); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// daemonLogger returns the logger selected by the daemon's --log-format
+// flag, or nil without one. It also becomes the default logger, so the
+// daemon's warnings and errors share its format.
+func daemonLogger(args []string, stderr io.Writer) (*slog.Logger, bool) {
+	var format string
+	switch {
+	case len(args) == 0:
+		return nil, true
+	case len(args) == 2 && args[0] == "--log-format":
+		format = args[1]
+	case len(args) == 1 && strings.HasPrefix(args[0], "--log-format="):
+		format = strings.TrimPrefix(args[0], "--log-format=")
+	default:
+		return nil, false
+	}
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(stderr, nil)
+	case "text":
+		handler = slog.NewTextHandler(stderr, nil)
+	default:
+		return nil, false
+	}
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger, true
+}
+
+// cmdFollow opens the browser as a read-only follower, e.g. to keep a
+// history pane on another monitor: it shows what the daemon (or another
+// clippy) records without capturing the clipboard or changing history.
+func cmdFollow(args []string, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(stderr, "usage: clippy follow\n")
+		return 2
+	}
+	if _, err := runTUI(followMode); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/instance"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+)
+
+// cmdDoctor reports how clippy reaches the clipboard and its history, to
+// diagnose captures that never show up. It exits 1 when the history can't
+// be read.
+func cmdDoctor(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(stderr, "usage: clippy doctor\n")
+		return 2
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stdout, "Config:            %v; using default settings\n", err)
+	} else if err := checkConfig(); err != nil {
+		fmt.Fprintf(stdout, "Config:            %v\n", err)
+	} else {
+		fmt.Fprint(stdout, "Config:            ok\n")
+	}
+	applyClipboardBackend(cfg)
+
+	code := 0
+	switch backend := sysclip.Current(); {
+	case backend == nil:
+		fmt.Fprint(stdout, "Clipboard backend: none (headless)\n")
+	case sysclip.UsingOSC52():
+		fmt.Fprint(stdout, "Clipboard backend: osc52 (copies reach the terminal; nothing is captured)\n")
+	default:
+		fmt.Fprintf(stdout, "Clipboard backend: %s\n", backend.Name())
+	}
+	if fallbacks := sysclip.Fallbacks(); len(fallbacks) > 0 {
+		fmt.Fprintf(stdout, "Fallbacks:         %s\n", strings.Join(fallbacks, ", "))
+	}
+	primary := "unavailable"
+	if sysclip.PrimaryAvailable() {
+		primary = "available"
+	}
+	fmt.Fprintf(stdout, "Primary selection: %s\n", primary)
+
+	m, err := openManager()
+	if err != nil {
+		fmt.Fprintf(stdout, "History:           %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			fmt.Fprintf(stderr, "Failed to close history manager: %v\n", err)
+		}
+	}()
+	if err := m.LoadFromDB(); err != nil {
+		fmt.Fprintf(stdout, "History:           %s: %v\n", m.DBPath(), err)
+		code = 1
+	} else {
+		fmt.Fprintf(stdout, "History:           %s (%d entries)\n", m.DBPath(), m.Count())
+	}
+	if owner, ok := instance.Holder(m.DataDir()); ok {
+		fmt.Fprintf(stdout, "Capturing:         %s (pid %d)\n", owner.Role, owner.PID)
+	} else {
+		fmt.Fprint(stdout, "Capturing:         nothing is running\n")
+	}
+	return code
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	seedDB(t, dbPath, "one", "two")
+	orig := sysclip.Current()
+	t.Cleanup(func() { sysclip.Use(orig) })
+
+	code, out, errOut := run("doctor")
+	if code != 0 || !strings.Contains(out, "Clipboard backend:") || !strings.Contains(out, "Primary selection:") {
+		t.Fatalf("doctor: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if !strings.Contains(out, dbPath+" (2 entries)") || !strings.Contains(out, "Capturing:         nothing is running") {
+		t.Errorf("expected the history and capture state, got %q", out)
+	}
+	if !strings.Contains(out, "Config:            ok") {
+		t.Errorf("expected the config to be reported ok, got %q", out)
+	}
+
+	checkConfig = func() error {
+		return &config.ValidationError{Path: "config.toml", Problems: []config.Problem{
+			{Line: 3, Key: "search.algorithm", Message: `unknown value "fuzy"`},
+		}}
+	}
+	if _, out, _ := run("doctor"); !strings.Contains(out, "Config:            config config.toml has 1 problem:\n  line 3: search.algorithm: ") {
+		t.Errorf("expected the config problems, got %q", out)
+	}
+
+	if code, _, errOut := run("doctor", "now"); code != 2 || !strings.Contains(errOut, "usage: clippy doctor") {
+		t.Errorf("doctor now: code %d, stderr %q", code, errOut)
+	}
+}
@@ -1,36 +1,476 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/actions"
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/daemon"
+	"github.com/bvdwalt/clippy/internal/detect"
 	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/hooks"
+	"github.com/bvdwalt/clippy/internal/instance"
+	"github.com/bvdwalt/clippy/internal/privacy"
+	"github.com/bvdwalt/clippy/internal/search"
+	"github.com/bvdwalt/clippy/internal/sources"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+	"github.com/bvdwalt/clippy/internal/tmux"
 	"github.com/bvdwalt/clippy/internal/ui"
+	"github.com/bvdwalt/clippy/internal/ui/styles"
+	"github.com/bvdwalt/clippy/internal/watch"
 )
 
 var version = "dev"
 
 func main() {
-	// Create history manager
-	historyManager, err := history.NewManager()
+	enableTracing(os.Stderr)
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	if _, err := runTUI(browseMode); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// cmdOnce opens the browser to copy a single entry, quitting once it is
+// copied, e.g. from a desktop hotkey.
+func cmdOnce(args []string, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprint(stderr, "usage: clippy --once\n")
+		return 2
+	}
+	if _, err := runTUI(onceMode); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// tuiMode is how the interactive browser is used
+type tuiMode int
+
+const (
+	browseMode tuiMode = iota
+	pickMode           // Enter selects an entry instead of copying it
+	onceMode           // copying an entry quits
+	followMode         // read-only history pane that never captures or changes history
+)
+
+// runTUI loads the config and history, runs the interactive browser in mode
+// with opts and returns its final model.
+func runTUI(mode tuiMode, opts ...tea.ProgramOption) (ui.Model, error) {
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to create history manager: %v", err)
+		log.Printf("Warning: %v; using default settings", err)
+	} else if err := checkConfig(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	applyClipboardBackend(cfg)
+
+	historyManager, unsaved := openConfiguredManager(cfg)
+	if unsaved != nil {
+		// Better a session that isn't saved than no clippy at all
+		log.Printf("Warning: %v; history will not be saved until it can be opened", unsaved)
+		historyManager = history.NewInMemoryManager()
+		configureManager(historyManager, cfg)
 	}
 	defer func() {
 		if err := historyManager.Close(); err != nil {
 			log.Printf("Failed to close history manager: %v", err)
 		}
 	}()
+	if mode != followMode {
+		compactOld(historyManager, cfg)
+		archiveOld(historyManager, cfg)
+	}
 
+	runner := hookRunner(cfg)
+	defer runner.Wait()
+	runner.Attach(historyManager)
+
+	initialModel := ui.NewModel(historyManager, version)
+	var lock *instance.Lock
+	defer func() {
+		if lock != nil {
+			lock.Release()
+		}
+	}()
+	if unsaved != nil {
+		// Without a path the database is never retried
+		path, _ := history.DefaultDBPath()
+		initialModel.SetUnsaved(path, unsaved)
+		if mode != followMode {
+			initialModel.SetCaptureLocker(func() (ui.Heartbeat, error) {
+				l, err := instance.Acquire(historyManager.DataDir(), instance.TUI)
+				if err != nil {
+					return nil, err
+				}
+				lock = l
+				return l, nil
+			})
+		}
+	}
+	initialModel.SetCopyHook(runner)
+	matcher, err := search.NewMatcher(cfg.Search.Algorithm)
+	if err != nil {
+		log.Printf("Warning: %v; using fuzzy search", err)
+	} else {
+		initialModel.SetMatcher(matcher)
+	}
+	initialModel.SetSearchDebounce(cfg.Search.Debounce())
+	theme, tableTheme := loadTheme()
+	initialModel.SetTheme(theme)
+	if cfg.UI.ZebraStripes && tableTheme.StripeBg == "" {
+		tableTheme.StripeBg = styles.DefaultStripeBg
+	}
+	switch indicator := cfg.UI.CursorIndicator; {
+	case indicator == "" || slices.Contains(styles.CursorIndicators, indicator):
+		tableTheme.CursorIndicator = indicator
+	default:
+		log.Printf("Warning: unknown cursor indicator %q (want one of %s); using color alone",
+			indicator, strings.Join(styles.CursorIndicators, ", "))
+	}
+	initialModel.SetTableTheme(tableTheme)
+	initialModel.SetRawMarkdown(cfg.UI.RawMarkdown)
+	initialModel.SetSyntaxHighlight(cfg.UI.SyntaxHighlight)
+	initialModel.SetRelativeTime(cfg.UI.RelativeTime)
+	initialModel.SetMasked(cfg.UI.HideContent)
+	initialModel.SetShowIDs(cfg.UI.ShowIDs)
+	initialModel.SetInstantDelete(cfg.UI.InstantDelete)
+	initialModel.SetQuickSelectQuit(cfg.UI.QuickSelectQuit)
+	initialModel.SetQuitOnCopy(mode == onceMode || (mode == browseMode && cfg.UI.QuitOnCopy))
+	initialModel.SetMouse(cfg.UI.Mouse)
+	initialModel.SetHeadless(!sysclip.Available())
+	initialModel.SetPickMode(mode == pickMode)
+	initialModel.SetCapturePrimary(capturePrimary(cfg))
+	initialModel.SetCaptureDebounce(cfg.Clipboard.Debounce())
+	initialModel.SetPollInterval(cfg.Clipboard.Interval)
+	initialModel.SetClearSensitiveAfter(cfg.Privacy.ClearSensitiveAfter)
+	initialModel.SetActionConfig(actions.Config{
+		CommitURL:   cfg.Actions.CommitURL,
+		BranchURL:   cfg.Actions.BranchURL,
+		IssueURL:    cfg.Actions.IssueURL,
+		JiraURL:     cfg.Actions.JiraURL,
+		PhoneRegion: cfg.Actions.PhoneRegion,
+		Custom:      customActions(cfg),
+	})
+	if path, err := config.Path(); err == nil {
+		// The rules editor changes the guard's excluded apps, so there is
+		// always one, allowing everything when nothing is configured
+		guard := privacy.NewGuard(cfg.Privacy.RespectHints, cfg.Privacy.ExcludedApps)
+		initialModel.SetCaptureGuard(guard)
+		initialModel.SetRuleStore(ruleStore{
+			path:            path,
+			manager:         historyManager,
+			guard:           guard,
+			recordSourceApp: cfg.Privacy.RecordSourceApp,
+		}, captureRules(cfg))
+	} else if guard := captureGuard(cfg); guard != nil {
+		initialModel.SetCaptureGuard(guard)
+	}
+	dataDir := historyManager.DataDir()
+	var owner instance.Owner
+	var captured bool
+	if dataDir != "" {
+		owner, captured = captureOwner(dataDir)
+	}
+	if mode != followMode && !captured && dataDir != "" {
+		// Only one clippy captures into a history
+		lock, err = instance.Acquire(dataDir, instance.TUI)
+		var held *instance.HeldError
+		if errors.As(err, &held) {
+			owner, captured = held.Owner, true
+		} else if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	switch {
+	case mode == followMode:
+		initialModel.SetFollower(true)
+	case captured && owner.Role == instance.Daemon:
+		initialModel.SetViewer(true)
+	case captured:
+		initialModel.SetAttached(owner.PID)
+	default:
+		if lock != nil {
+			initialModel.SetHeartbeat(lock)
+		}
+		if importer := bufferImporter(cfg); importer != nil {
+			initialModel.SetBufferImporter(importer)
+		}
+		if watcher := clipboardWatcher(cfg); watcher != nil {
+			defer closeWatcher(watcher)
+			initialModel.SetClipboardWatcher(watcher)
+		}
+		if list := captureSources(cfg); len(list) > 0 {
+			ctx, stop := context.WithCancel(context.Background())
+			defer stop()
+			initialModel.SetSourceCaptures(sources.Watch(ctx, list))
+		}
+	}
+	if sessionRecorder != nil {
+		initialModel.SetRecorder(sessionRecorder)
+	}
+	program := tea.NewProgram(initialModel, opts...)
+
+	final, err := program.Run()
+	if err != nil {
+		return ui.Model{}, err
+	}
+	return final.(ui.Model), nil
+}
+
+// captureOwner returns the process capturing into the history in dir,
+// starting the daemon first if systemd listens on its socket
+func captureOwner(dir string) (instance.Owner, bool) {
+	if owner, ok := instance.Holder(dir); ok {
+		return owner, true
+	}
+	if pid, ok := daemon.Wake(dir); ok {
+		return instance.Owner{PID: pid, Role: instance.Daemon}, true
+	}
+	return instance.Owner{}, false
+}
+
+// configChoices lists the values accepted for settings whose options are
+// defined outside the config package
+func configChoices() config.Choices {
+	types := make([]string, len(detect.Types))
+	for i, t := range detect.Types {
+		types[i] = string(t)
+	}
+	return config.Choices{
+		Algorithms:       search.Algorithms,
+		Backends:         sysclip.Backends,
+		CursorIndicators: styles.CursorIndicators,
+		Types:            types,
+		HookEvents:       []string{string(hooks.Capture), string(hooks.Copy)},
+	}
+}
+
+// checkConfigFile reports the problems in the config file, if any
+func checkConfigFile() error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	return config.Check(path, configChoices())
+}
+
+// applyClipboardBackend selects the clipboard backend named in the config
+func applyClipboardBackend(cfg config.Config) {
+	switch cfg.Clipboard.Backend {
+	case config.BackendAuto, "":
+		if sysclip.PreferOSC52() {
+			sysclip.UseOSC52()
+		}
+	case config.BackendNone:
+		sysclip.Disable()
+	default:
+		backend, err := sysclip.Select(cfg.Clipboard.Backend)
+		if err != nil {
+			log.Printf("Warning: %v; using %q", err, config.BackendAuto)
+			return
+		}
+		sysclip.Use(backend)
+	}
+}
+
+// loadTheme loads the theme file from the config directory, falling back
+// to the default theme when it is missing or can't be used
+func loadTheme() (styles.Theme, styles.TableTheme) {
+	dir, err := config.Dir()
+	if err != nil {
+		log.Printf("Warning: %v; using the default theme", err)
+		return styles.DefaultTheme(), styles.DefaultTableTheme()
+	}
+	theme, tableTheme, err := styles.LoadTheme(filepath.Join(dir, styles.ThemeFileName))
+	if err != nil {
+		log.Printf("Warning: %v; using the default theme", err)
+	}
+	return theme, tableTheme
+}
+
+// openConfiguredManager opens the history database with the configured
+// settings and loads it.
+func openConfiguredManager(cfg config.Config) (*history.Manager, error) {
+	historyManager, err := history.NewManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history manager: %w", err)
+	}
+	configureManager(historyManager, cfg)
 	if err := historyManager.LoadFromDB(); err != nil {
 		log.Printf("Warning: Could not load history: %v", err)
 	}
+	return historyManager, nil
+}
 
-	initialModel := ui.NewModel(historyManager, version)
-	program := tea.NewProgram(initialModel)
+// configureManager applies the configured history settings
+func configureManager(historyManager *history.Manager, cfg config.Config) {
+	historyManager.SetBumpDuplicates(cfg.History.BumpDuplicates)
+	historyManager.SetExpiryRules(expiryRules(cfg.Expiry.Rules))
+	historyManager.SetOverflowThreshold(cfg.History.OverflowBytes)
+	historyManager.SetCaptureLimit(cfg.History.MaxCaptureBytes, cfg.History.Oversized == config.OversizedTruncate)
+	historyManager.SetSkipSensitive(cfg.Privacy.SkipSensitive)
+	historyManager.SetPolicies(appPolicies(cfg.Privacy.Policies))
+	if cfg.Privacy.RecordSourceApp || len(cfg.Privacy.Policies) > 0 {
+		historyManager.SetSourceApp(privacy.SourceApp)
+	}
+}
+
+// appPolicies compiles the configured per-app policies, leaving out the
+// parts that are invalid
+func appPolicies(configured []config.AppPolicy) []history.AppPolicy {
+	policies := make([]history.AppPolicy, 0, len(configured))
+	for _, p := range configured {
+		policy, err := history.NewAppPolicy(p.App, p.MaxBytes, p.Types, p.Tags)
+		if err != nil {
+			log.Printf("Warning: policy for %q: %v", p.App, err)
+		}
+		if policy.App == "" {
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// archiveOld moves entries older than the configured age to the archive
+func archiveOld(historyManager *history.Manager, cfg config.Config) {
+	if cfg.Archive.After <= 0 {
+		return
+	}
+	if _, err := historyManager.ArchiveOlderThan(time.Now().Add(-cfg.Archive.After)); err != nil {
+		log.Printf("Warning: Could not archive old entries: %v", err)
+	}
+}
+
+// bufferImporter returns the tmux importer if enabled in the config and
+// tmux is available, otherwise nil
+func bufferImporter(cfg config.Config) *tmux.Importer {
+	if !cfg.Tmux.Enabled {
+		return nil
+	}
+	if !tmux.Available() {
+		log.Printf("Warning: tmux import enabled but tmux was not found")
+		return nil
+	}
+	return tmux.NewImporter()
+}
 
-	_, err = program.Run()
+// clipboardWatcher starts a clipboard change watcher if enabled in the
+// config and supported here, otherwise returns nil and the clipboard is
+// polled
+func clipboardWatcher(cfg config.Config) *watch.Watcher {
+	if !cfg.Clipboard.Watch || !sysclip.Available() {
+		return nil
+	}
+	// The primary selection is read on its own schedule, as a source
+	watcher, err := sysclip.Current().Watch(false)
 	if err != nil {
-		log.Fatal(err)
+		return nil
+	}
+	return watcher
+}
+
+// capturePrimary reports whether the primary selection should be captured
+func capturePrimary(cfg config.Config) bool {
+	return cfg.Clipboard.Primary && sysclip.PrimaryAvailable()
+}
+
+// captureSources lists what is captured besides the clipboard, each read
+// in its own goroutine: the primary selection if enabled, then the sources
+// configured. Sources without a command or with a name that is built in or
+// already taken are left out, as clippy config check reports.
+func captureSources(cfg config.Config) []sources.Source {
+	var list []sources.Source
+	if capturePrimary(cfg) {
+		list = append(list, sources.Source{
+			Name:     string(history.SelectionPrimary),
+			Interval: cmp.Or(cfg.Clipboard.PrimaryInterval, daemon.PollInterval),
+			Read:     sysclip.ReadPrimary,
+		})
+	}
+	taken := slices.Clone(config.ReservedSources)
+	for _, s := range cfg.Clipboard.Sources {
+		name := strings.TrimSpace(s.Name)
+		if !s.On() || name == "" || len(s.Command) == 0 || s.Command[0] == "" ||
+			slices.Contains(taken, strings.ToLower(name)) {
+			continue
+		}
+		taken = append(taken, strings.ToLower(name))
+		list = append(list, sources.Source{Name: name, Interval: s.Interval, Read: sources.Command(s.Command)})
+	}
+	return list
+}
+
+// captureGuard returns the configured privacy filter, or nil when it
+// would allow everything
+func captureGuard(cfg config.Config) *privacy.Guard {
+	if !cfg.Privacy.RespectHints && len(cfg.Privacy.ExcludedApps) == 0 {
+		return nil
+	}
+	return privacy.NewGuard(cfg.Privacy.RespectHints, cfg.Privacy.ExcludedApps)
+}
+
+// closeWatcher stops watcher, logging any error
+func closeWatcher(watcher *watch.Watcher) {
+	if err := watcher.Close(); err != nil {
+		log.Printf("Failed to stop clipboard watcher: %v", err)
+	}
+}
+
+// expiryRules compiles the configured expiry rules, skipping invalid ones
+func expiryRules(configured []config.ExpiryRule) []history.ExpiryRule {
+	rules := make([]history.ExpiryRule, 0, len(configured))
+	for _, r := range configured {
+		rule, err := history.NewExpiryRule(r.Pattern, r.TTL)
+		if err != nil {
+			log.Printf("Warning: skipping expiry rule: %v", err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// customActions compiles the configured custom actions, skipping invalid
+// ones
+func customActions(cfg config.Config) []actions.Custom {
+	configured := make([]actions.Custom, 0, len(cfg.Actions.Custom))
+	for _, a := range cfg.Actions.Custom {
+		custom, err := actions.NewCustom(a.Name, a.Pattern, a.Command)
+		if err != nil {
+			log.Printf("Warning: skipping action: %v", err)
+			continue
+		}
+		configured = append(configured, custom)
+	}
+	return configured
+}
+
+// hookRunner compiles the configured hooks, skipping invalid ones
+func hookRunner(cfg config.Config) *hooks.Runner {
+	configured := make([]hooks.Hook, 0, len(cfg.Hooks))
+	for _, h := range cfg.Hooks {
+		hook, err := hooks.New(h.Event, h.Command, h.Pattern)
+		if err != nil {
+			log.Printf("Warning: skipping hook: %v", err)
+			continue
+		}
+		configured = append(configured, hook)
 	}
+	return hooks.NewRunner(configured)
 }
@@ -7,8 +7,10 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/config"
 	"github.com/bvdwalt/clippy/internal/history"
 	"github.com/bvdwalt/clippy/internal/ui"
 )
@@ -491,3 +493,60 @@ func BenchmarkFullApplicationCycle(b *testing.B) {
 		}
 	}
 }
+
+func TestExpiryRulesSkipsInvalid(t *testing.T) {
+	rules := expiryRules([]config.ExpiryRule{
+		{Pattern: `^\d{6}$`, TTL: 5 * time.Minute},
+		{Pattern: "(", TTL: time.Minute},
+		{Pattern: "token", TTL: 0},
+	})
+	if len(rules) != 1 || rules[0].TTL != 5*time.Minute {
+		t.Errorf("expected only the valid rule, got %+v", rules)
+	}
+}
+
+func TestCaptureSourcesSkipsInvalid(t *testing.T) {
+	off := false
+	cfg := config.Default()
+	cfg.Clipboard.Sources = []config.SourceConfig{
+		{Name: "secondary", Command: []string{"echo", "yanked"}, Interval: time.Second},
+		{Name: "remote", Command: []string{"true"}, Enabled: &off},
+		{Name: "Primary", Command: []string{"true"}},
+		{Name: "SECONDARY", Command: []string{"true"}},
+		{Name: "nothing"},
+	}
+	list := captureSources(cfg)
+	if len(list) != 1 || list[0].Name != "secondary" || list[0].Interval != time.Second {
+		t.Fatalf("sources = %+v, want only secondary", list)
+	}
+	if text, err := list[0].Read(); err != nil || text != "yanked" {
+		t.Errorf("Read = %q, %v; want the command's output", text, err)
+	}
+}
+
+func TestFollowUsage(t *testing.T) {
+	code, _, errOut := run("follow", "extra")
+	if code != 2 || !strings.Contains(errOut, "usage: clippy follow") {
+		t.Errorf("follow extra = %d, %q; want usage error", code, errOut)
+	}
+}
+
+func TestOnceUsage(t *testing.T) {
+	code, _, errOut := run("--once", "extra")
+	if code != 2 || !strings.Contains(errOut, "usage: clippy --once") {
+		t.Errorf("--once extra = %d, %q; want usage error", code, errOut)
+	}
+}
+
+func TestDaemonLogFormatUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"daemon", "--log-format", "xml"},
+		{"daemon", "--log-format"},
+		{"daemon", "--verbose"},
+	} {
+		code, _, errOut := run(args...)
+		if code != 2 || !strings.Contains(errOut, "--log-format text|json") {
+			t.Errorf("%v = %d, %q; want usage error", args, code, errOut)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// historyDBPath locates the history database. Overridable for tests.
+var historyDBPath = history.DefaultDBPath
+
+// schemaDB is a database whose schema clippy migrates
+type schemaDB struct {
+	name   string
+	path   string
+	schema db.Schema
+}
+
+// schemaDBs returns the history database and the archive kept next to it
+func schemaDBs() ([]schemaDB, error) {
+	path, err := historyDBPath()
+	if err != nil {
+		return nil, err
+	}
+	return []schemaDB{
+		{"history", path, db.HistorySchema},
+		{"archive", filepath.Join(filepath.Dir(path), history.ArchiveFileName), db.ArchiveSchema},
+	}, nil
+}
+
+// cmdMigrate shows or applies pending schema migrations. Opening a database
+// applies them too; this lets them be inspected and run explicitly, without
+// opening the history or archive.
+func cmdMigrate(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 || (args[0] != "status" && args[0] != "up") {
+		fmt.Fprint(stderr, "usage: clippy migrate status|up\n")
+		return 2
+	}
+	dbs, err := schemaDBs()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	code := 0
+	for _, d := range dbs {
+		var err error
+		if args[0] == "status" {
+			err = printSchemaStatus(d, stdout)
+		} else {
+			err = applyMigrations(d, stdout)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(stdout, "%s database %s: not created yet\n", d.name, d.path)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s database: %v\n", d.name, err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// printSchemaStatus lists d's migrations and whether each has been applied
+func printSchemaStatus(d schemaDB, stdout io.Writer) error {
+	statuses, err := db.SchemaStatus(d.path, d.schema)
+	if err != nil {
+		return err
+	}
+	version, pending := 0, 0
+	for _, s := range statuses {
+		if s.Pending() {
+			pending++
+		} else {
+			version = max(version, s.Version)
+		}
+	}
+	fmt.Fprintf(stdout, "%s database %s: schema version %d of %d, %d pending\n",
+		d.name, d.path, version, d.schema.Latest(), pending)
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Version > d.schema.Latest():
+			state = "applied by a newer clippy " + s.AppliedAt.Format("2006-01-02 15:04")
+		case !s.Pending():
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(stdout, "  %3d  %-22s %s\n", s.Version, s.Name, state)
+	}
+	return nil
+}
+
+// applyMigrations runs d's pending migrations, listing those applied
+func applyMigrations(d schemaDB, stdout io.Writer) error {
+	if _, err := db.SchemaStatus(d.path, d.schema); err != nil {
+		// Databases are only created when first used
+		return err
+	}
+	ran, err := db.ApplyMigrations(d.path, d.schema)
+	if err != nil {
+		return err
+	}
+	if len(ran) == 0 {
+		fmt.Fprintf(stdout, "%s database: up to date (schema version %d)\n", d.name, d.schema.Latest())
+		return nil
+	}
+	for _, s := range ran {
+		fmt.Fprintf(stdout, "%s database: applied %d (%s)\n", d.name, s.Version, s.Name)
+	}
+	return nil
+}
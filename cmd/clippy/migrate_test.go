@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	dbPath, _ := useTestDB(t)
+	orig := historyDBPath
+	historyDBPath = func() (string, error) { return dbPath, nil }
+	t.Cleanup(func() { historyDBPath = orig })
+
+	if code, out, _ := run("migrate", "status"); code != 0 || strings.Count(out, "not created yet") != 2 {
+		t.Errorf("status without databases: code %d, stdout %q", code, out)
+	}
+
+	seedDB(t, dbPath, "entry")
+	code, out, errOut := run("migrate", "status")
+	if code != 0 || !strings.Contains(out, "history database "+dbPath) || !strings.Contains(out, "0 pending") {
+		t.Errorf("status: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if !strings.Contains(out, "create tables") || !strings.Contains(out, "applied ") {
+		t.Errorf("expected each migration listed, got %q", out)
+	}
+	if code, out, _ := run("migrate", "up"); code != 0 || !strings.Contains(out, "history database: up to date") {
+		t.Errorf("up: code %d, stdout %q", code, out)
+	}
+	// The archive is left to be created when first used
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dbPath), history.ArchiveFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no archive database created, got %v", err)
+	}
+
+	if code, _, _ := run("migrate", "down"); code != 2 {
+		t.Errorf("unknown subcommand: code %d, want 2", code)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/session"
+	"github.com/bvdwalt/clippy/internal/ui"
+)
+
+const replayUsage = "usage: clippy replay [--print] [--speed <factor>] <file>\n"
+
+// maxReplayPause is the longest replay waits between events, so idle time
+// in a recording is skipped.
+const maxReplayPause = 2 * time.Second
+
+// captureTimeout is how long replay waits for the TUI to read a copy, in
+// case it doesn't capture it at all, e.g. when paused.
+const captureTimeout = time.Second
+
+// sessionRecorder records the TUI session when set by clippy record.
+var sessionRecorder ui.Recorder
+
+// cmdRecord runs the TUI, recording the session to a file for clippy
+// replay.
+func cmdRecord(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, "usage: clippy record <file>\n")
+		return 2
+	}
+	f, err := os.OpenFile(args[0], os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create recording: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	recorder := session.NewRecorder(f)
+	sessionRecorder = recorder
+	defer func() { sessionRecorder = nil }()
+	if _, err := runTUI(browseMode); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if err := recorder.Err(); err != nil {
+		fmt.Fprintf(stderr, "Failed to write recording: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Recorded session to %s; replay it with clippy replay %s\n", args[0], args[0])
+	return 0
+}
+
+// cmdReplay plays a recorded session back in the TUI against an empty
+// in-memory history and a fake clipboard, so neither is touched. With
+// --print nothing is drawn and the final screen is printed instead.
+func cmdReplay(args []string, stdout, stderr io.Writer) int {
+	printScreen, speed := false, 1.0
+	var path string
+	for len(args) > 0 {
+		switch {
+		case args[0] == "--print":
+			printScreen = true
+			args = args[1:]
+		case args[0] == "--speed" && len(args) > 1:
+			factor, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || factor < 0 {
+				fmt.Fprintf(stderr, "invalid speed %q: want a factor such as 2, or 0 for no pauses\n", args[1])
+				return 2
+			}
+			speed = factor
+			args = args[2:]
+		case path == "":
+			path = args[0]
+			args = args[1:]
+		default:
+			fmt.Fprint(stderr, replayUsage)
+			return 2
+		}
+	}
+	if path == "" {
+		fmt.Fprint(stderr, replayUsage)
+		return 2
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to open recording: %v\n", err)
+		return 1
+	}
+	events, err := session.Read(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read recording %s: %v\n", path, err)
+		return 1
+	}
+
+	clipboard := newReplayClipboard()
+	model := ui.NewModel(history.NewInMemoryManager(), version)
+	model.SetClipboard(clipboard)
+	model.SetClipboardWatcher(clipboard)
+	var opts []tea.ProgramOption
+	if printScreen {
+		width, height := recordedSize(events)
+		opts = append(opts, tea.WithoutRenderer(), tea.WithInput(nil), tea.WithOutput(io.Discard),
+			tea.WithWindowSize(width, height))
+	}
+	program := tea.NewProgram(model, opts...)
+	go func() {
+		playEvents(program, clipboard, events, speed)
+		if printScreen {
+			program.Quit()
+		}
+	}()
+	final, err := program.Run()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to replay: %v\n", err)
+		return 1
+	}
+	if printScreen {
+		fmt.Fprintln(stdout, final.(ui.Model).View().Content)
+	}
+	return 0
+}
+
+// recordedSize is the terminal size a recording started at, for printing
+// its final screen without a terminal
+func recordedSize(events []session.Event) (width, height int) {
+	for _, e := range events {
+		if e.Kind == session.Resize {
+			return e.Width, e.Height
+		}
+	}
+	return 80, 24
+}
+
+// playEvents sends events to program with the pauses between them scaled
+// by speed, placing made-up text on clipboard for each capture
+func playEvents(program *tea.Program, clipboard *replayClipboard, events []session.Event, speed float64) {
+	var last time.Duration
+	captures := 0
+	for _, e := range events {
+		time.Sleep(min(time.Duration(float64(e.At-last)*speed), maxReplayPause))
+		last = e.At
+		if e.Kind == session.Capture {
+			captures++
+			// Wait for the capture to be recorded before the next event acts
+			// on it; messages sent after are handled once it is
+			select {
+			case <-clipboard.copied(e.Placeholder(captures)):
+			case <-time.After(captureTimeout):
+			}
+		}
+		if msg := e.Msg(); msg != nil {
+			program.Send(msg)
+		}
+	}
+}
+
+// replayClipboard stands in for the system clipboard while replaying,
+// reporting each placeholder copied to it as a change
+type replayClipboard struct {
+	mu      sync.Mutex
+	text    string
+	read    chan struct{} // closed once the text copied last is read
+	changes chan struct{}
+}
+
+func newReplayClipboard() *replayClipboard {
+	return &replayClipboard{changes: make(chan struct{}, 1)}
+}
+
+func (c *replayClipboard) Read() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.read != nil {
+		close(c.read)
+		c.read = nil
+	}
+	return c.text, nil
+}
+
+func (c *replayClipboard) Write(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.text = text
+	return nil
+}
+
+func (c *replayClipboard) Changes() <-chan struct{} {
+	return c.changes
+}
+
+// copied places text on the clipboard as if copied by another app,
+// returning a channel closed once it is read
+func (c *replayClipboard) copied(text string) <-chan struct{} {
+	c.mu.Lock()
+	c.text = text
+	read := make(chan struct{})
+	c.read = read
+	c.mu.Unlock()
+	select {
+	case c.changes <- struct{}{}:
+	default:
+	}
+	return read
+}
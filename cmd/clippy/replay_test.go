@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayPrintsFinalScreen(t *testing.T) {
+	recording := filepath.Join(t.TempDir(), "session.jsonl")
+	events := `{"at":0,"kind":"resize","width":100,"height":30}
+{"at":1000000000,"kind":"capture","size":40,"type":"url"}
+{"at":2000000000,"kind":"capture","size":30,"type":"text"}
+{"at":3000000000,"kind":"key","code":112,"text":"p"}
+`
+	if err := os.WriteFile(recording, []byte(events), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, errOut := run("replay", "--print", "--speed", "0", recording)
+	if code != 0 {
+		t.Fatalf("replay: code %d, stderr %q", code, errOut)
+	}
+	for _, want := range []string{"https://example.com/clip/1", "clip 2 x x", "📌"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the final screen:\n%s", want, out)
+		}
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	if code, _, errOut := run("replay"); code != 2 || !strings.Contains(errOut, "usage: clippy replay") {
+		t.Errorf("no file: code %d, stderr %q", code, errOut)
+	}
+	if code, _, errOut := run("replay", "--speed", "fast", "x"); code != 2 || !strings.Contains(errOut, "invalid speed") {
+		t.Errorf("bad speed: code %d, stderr %q", code, errOut)
+	}
+	bad := filepath.Join(t.TempDir(), "bad.jsonl")
+	if err := os.WriteFile(bad, []byte("nope\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, errOut := run("replay", bad); code != 1 || !strings.Contains(errOut, "line 1") {
+		t.Errorf("malformed recording: code %d, stderr %q", code, errOut)
+	}
+	if code, _, _ := run("record"); code != 2 {
+		t.Errorf("record without a file: code %d, want 2", code)
+	}
+}
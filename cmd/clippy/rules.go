@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/privacy"
+	"github.com/bvdwalt/clippy/internal/ui"
+)
+
+// ruleStore saves the capture rules edited in the TUI to the config file
+// and applies them to the running TUI. The daemon picks them up when it is
+// restarted.
+type ruleStore struct {
+	path            string
+	manager         *history.Manager
+	guard           *privacy.Guard
+	recordSourceApp bool
+}
+
+// SaveRules writes rules to the config file, then applies them.
+func (s ruleStore) SaveRules(rules ui.CaptureRules) error {
+	policies := make([]config.AppPolicy, len(rules.Policies))
+	for i, p := range rules.Policies {
+		policies[i] = config.AppPolicy{App: p.App, MaxBytes: p.MaxBytes, Tags: p.Tags}
+		for _, t := range p.Types {
+			policies[i].Types = append(policies[i].Types, string(t))
+		}
+	}
+	if err := config.SaveRules(s.path, rules.ExcludedApps, policies); err != nil {
+		return err
+	}
+
+	s.guard.SetExcludedApps(rules.ExcludedApps)
+	s.manager.SetPolicies(rules.Policies)
+	if s.recordSourceApp || len(rules.Policies) > 0 {
+		s.manager.SetSourceApp(privacy.SourceApp)
+	} else {
+		s.manager.SetSourceApp(nil)
+	}
+	return nil
+}
+
+// captureRules returns the configured rules, as the TUI edits them
+func captureRules(cfg config.Config) ui.CaptureRules {
+	return ui.CaptureRules{
+		ExcludedApps: cfg.Privacy.ExcludedApps,
+		Policies:     appPolicies(cfg.Privacy.Policies),
+	}
+}
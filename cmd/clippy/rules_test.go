@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/privacy"
+	"github.com/bvdwalt/clippy/internal/ui"
+)
+
+func TestRuleStoreSavesRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	store := ruleStore{path: path, manager: history.NewInMemoryManager(), guard: privacy.NewGuard(false, nil)}
+
+	policy, err := history.NewAppPolicy("Slack", 4096, []string{"url"}, []string{"chat"})
+	if err != nil {
+		t.Fatalf("NewAppPolicy: %v", err)
+	}
+	rules := ui.CaptureRules{ExcludedApps: []string{"KeePassXC"}, Policies: []history.AppPolicy{policy}}
+	if err := store.SaveRules(rules); err != nil {
+		t.Fatalf("SaveRules: %v", err)
+	}
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if got := captureRules(cfg); !reflect.DeepEqual(got, rules) {
+		t.Errorf("rules read back = %+v, want %+v", got, rules)
+	}
+}
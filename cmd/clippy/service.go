@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/bvdwalt/clippy/internal/daemon"
+)
+
+// Overridable for tests.
+var (
+	executable = os.Executable
+	unitDir    = systemdUserDir
+	targetOS   = runtime.GOOS
+)
+
+const socketUnit = `[Unit]
+Description=clippy clipboard history daemon socket
+
+[Socket]
+ListenStream=%s
+SocketMode=0600
+
+[Install]
+WantedBy=sockets.target
+`
+
+const serviceUnit = `[Unit]
+Description=clippy clipboard history daemon
+Requires=clippy.socket
+After=clippy.socket graphical-session.target
+PartOf=graphical-session.target
+
+[Service]
+ExecStart="%s" daemon
+Restart=on-failure
+
+[Install]
+WantedBy=graphical-session.target
+`
+
+// systemdUserDir returns where systemd looks for the user's own units
+func systemdUserDir() (string, error) {
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %w", err)
+		}
+		config = filepath.Join(home, ".config")
+	}
+	return filepath.Join(config, "systemd", "user"), nil
+}
+
+// cmdInstallService writes systemd user units that run the daemon when
+// something connects to its socket, which the TUI does when it starts, so
+// the daemon needn't be started by hand. With --print the units are
+// printed instead.
+func cmdInstallService(args []string, stdout, stderr io.Writer) int {
+	printOnly := len(args) == 1 && args[0] == "--print"
+	if len(args) > 0 && !printOnly {
+		fmt.Fprint(stderr, "usage: clippy install-service [--print]\n")
+		return 2
+	}
+	if targetOS != "linux" {
+		fmt.Fprint(stderr, "systemd user services are only available on Linux\n")
+		return 1
+	}
+
+	exe, err := executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to locate clippy: %v\n", err)
+		return 1
+	}
+	dbPath, err := historyDBPath()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	units := []struct{ name, content string }{
+		{"clippy.socket", fmt.Sprintf(socketUnit, filepath.Join(filepath.Dir(dbPath), daemon.SocketFileName))},
+		{"clippy.service", fmt.Sprintf(serviceUnit, exe)},
+	}
+	if printOnly {
+		for _, unit := range units {
+			fmt.Fprintf(stdout, "# %s\n%s\n", unit.name, unit.content)
+		}
+		return 0
+	}
+
+	dir, err := unitDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(stderr, "Failed to create %s: %v\n", dir, err)
+		return 1
+	}
+	for _, unit := range units {
+		path := filepath.Join(dir, unit.name)
+		if err := os.WriteFile(path, []byte(unit.content), 0644); err != nil {
+			fmt.Fprintf(stderr, "Failed to write %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Wrote %s\n", path)
+	}
+	fmt.Fprint(stdout, `
+Enable the socket, so the daemon starts when clippy first opens:
+  systemctl --user daemon-reload
+  systemctl --user enable --now clippy.socket
+To capture the clipboard from login instead, also run:
+  systemctl --user enable clippy.service
+`)
+	return 0
+}
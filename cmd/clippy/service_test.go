@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallService(t *testing.T) {
+	dir := t.TempDir()
+	origExe, origDir, origOS, origPath := executable, unitDir, targetOS, historyDBPath
+	t.Cleanup(func() { executable, unitDir, targetOS, historyDBPath = origExe, origDir, origOS, origPath })
+	executable = func() (string, error) { return "/usr/local/bin/clippy", nil }
+	unitDir = func() (string, error) { return dir, nil }
+	historyDBPath = func() (string, error) { return "/home/me/.clippy/clippy.db", nil }
+	targetOS = "linux"
+
+	code, out, errOut := run("install-service")
+	if code != 0 {
+		t.Fatalf("install-service exit = %d, stderr = %q", code, errOut)
+	}
+	if !strings.Contains(out, "enable --now clippy.socket") {
+		t.Errorf("expected instructions to enable the socket, got %q", out)
+	}
+	socket, err := os.ReadFile(filepath.Join(dir, "clippy.socket"))
+	if err != nil || !strings.Contains(string(socket), "ListenStream=/home/me/.clippy/daemon.sock") {
+		t.Errorf("clippy.socket = %q, %v", socket, err)
+	}
+	service, err := os.ReadFile(filepath.Join(dir, "clippy.service"))
+	if err != nil || !strings.Contains(string(service), `ExecStart="/usr/local/bin/clippy" daemon`) {
+		t.Errorf("clippy.service = %q, %v", service, err)
+	}
+
+	if _, out, _ := run("install-service", "--print"); !strings.Contains(out, "# clippy.socket") {
+		t.Errorf("--print = %q, want the units", out)
+	}
+	targetOS = "darwin"
+	if code, _, _ := run("install-service"); code != 1 {
+		t.Errorf("install-service on macOS exit = %d, want 1", code)
+	}
+}
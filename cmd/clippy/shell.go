@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// pickEntry runs the browser in pick mode, drawing it on tty so stdout only
+// carries the selection. Overridable for tests.
+var pickEntry = func(tty io.Writer) (history.ClipboardHistory, bool, error) {
+	final, err := runTUI(pickMode, tea.WithOutput(tty))
+	if err != nil {
+		return history.ClipboardHistory{}, false, err
+	}
+	item, ok := final.Picked()
+	return item, ok, nil
+}
+
+// shellWidgets bind Alt-V to insert the entry picked with `clippy pick` at
+// the cursor, without touching the clipboard.
+var shellWidgets = map[string]string{
+	"zsh": `# clippy: Alt-V inserts a history entry at the cursor
+_clippy_insert() {
+  local selected
+  selected="$(clippy pick </dev/tty)" && LBUFFER+="$selected"
+  zle reset-prompt
+}
+zle -N _clippy_insert
+bindkey '\ev' _clippy_insert
+`,
+	"bash": `# clippy: Alt-V inserts a history entry at the cursor
+_clippy_insert() {
+  local selected
+  # The trailing x keeps command substitution from eating final newlines
+  selected="$(clippy pick; printf x)"
+  selected="${selected%x}"
+  [ -n "$selected" ] || return
+  READLINE_LINE="${READLINE_LINE:0:READLINE_POINT}${selected}${READLINE_LINE:READLINE_POINT}"
+  READLINE_POINT=$((READLINE_POINT + ${#selected}))
+}
+bind -x '"\ev": _clippy_insert'
+`,
+	"fish": `# clippy: Alt-V inserts a history entry at the cursor
+function _clippy_insert
+    set -l selected (clippy pick | string collect)
+    and commandline --insert -- $selected
+    commandline --function repaint
+end
+bind \ev _clippy_insert
+`,
+}
+
+func cmdShellInit(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, "usage: clippy shell-init zsh|bash|fish\n")
+		return 2
+	}
+	script, ok := shellWidgets[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unsupported shell %q (use zsh, bash or fish)\n", args[0])
+		return 2
+	}
+	fmt.Fprint(stdout, script)
+	return 0
+}
+
+func cmdPick(stdout, stderr io.Writer) int {
+	item, ok, err := pickEntry(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to run picker: %v\n", err)
+		return 1
+	}
+	if !ok {
+		return 1
+	}
+	if item.IsBinary() {
+		fmt.Fprintf(stderr, "cannot insert %s at the prompt\n", item.Item)
+		return 1
+	}
+	fmt.Fprint(stdout, item.Item)
+	return 0
+}
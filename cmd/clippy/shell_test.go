@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func usePicker(t *testing.T, item history.ClipboardHistory, ok bool, err error) {
+	t.Helper()
+	orig := pickEntry
+	pickEntry = func(io.Writer) (history.ClipboardHistory, bool, error) { return item, ok, err }
+	t.Cleanup(func() { pickEntry = orig })
+}
+
+func TestShellInit(t *testing.T) {
+	tests := map[string]string{
+		"zsh":  "zle -N _clippy_insert",
+		"bash": "READLINE_LINE=",
+		"fish": "commandline --insert",
+	}
+	for shell, want := range tests {
+		code, out, _ := run("shell-init", shell)
+		if code != 0 || !strings.Contains(out, want) || !strings.Contains(out, "clippy pick") {
+			t.Errorf("shell-init %s: code %d, output %q", shell, code, out)
+		}
+	}
+
+	if code, _, _ := run("shell-init", "tcsh"); code != 2 {
+		t.Errorf("shell-init tcsh: exit code = %d, want 2", code)
+	}
+	if code, _, _ := run("shell-init"); code != 2 {
+		t.Errorf("shell-init without shell: exit code = %d, want 2", code)
+	}
+}
+
+func TestPickPrintsSelection(t *testing.T) {
+	usePicker(t, history.ClipboardHistory{Item: "git push --force-with-lease\n"}, true, nil)
+
+	code, out, _ := run("pick")
+	if code != 0 || out != "git push --force-with-lease\n" {
+		t.Errorf("pick: code %d, stdout %q", code, out)
+	}
+}
+
+func TestPickCancelled(t *testing.T) {
+	usePicker(t, history.ClipboardHistory{}, false, nil)
+
+	if code, out, _ := run("pick"); code != 1 || out != "" {
+		t.Errorf("pick cancelled: code %d, stdout %q", code, out)
+	}
+}
+
+func TestPickRejectsImages(t *testing.T) {
+	usePicker(t, history.ClipboardHistory{Item: "[image/png 1 KB]", Kind: history.KindImage}, true, nil)
+
+	if code, out, _ := run("pick"); code != 1 || out != "" {
+		t.Errorf("pick image: code %d, stdout %q", code, out)
+	}
+}
+
+func TestPickError(t *testing.T) {
+	usePicker(t, history.ClipboardHistory{}, false, errors.New("no tty"))
+
+	if code, _, errOut := run("pick"); code != 1 || !strings.Contains(errOut, "no tty") {
+		t.Errorf("pick error: code %d, stderr %q", code, errOut)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/hooks"
+	"github.com/bvdwalt/clippy/internal/privacy"
+)
+
+const simulateUsage = "usage: clippy simulate [--app <name>] [--input <file>]\n"
+
+// cmdSimulate reports what capturing the content of a file, or stdin, as
+// if copied from an app would do under the configured rules: whether it is
+// recorded, as what, and which hooks run. History is never opened.
+func cmdSimulate(args []string, stdout, stderr io.Writer) int {
+	var app, input string
+	for len(args) > 0 {
+		if len(args) < 2 {
+			fmt.Fprint(stderr, simulateUsage)
+			return 2
+		}
+		switch args[0] {
+		case "--app":
+			app = args[1]
+		case "--input":
+			input = args[1]
+		default:
+			fmt.Fprint(stderr, simulateUsage)
+			return 2
+		}
+		args = args[2:]
+	}
+
+	var data []byte
+	var err error
+	if input == "" || input == "-" {
+		input = "stdin"
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read input: %v\n", err)
+		return 1
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: %v; using default settings\n", err)
+	}
+	m := history.NewInMemoryManager()
+	configureManager(m, cfg)
+	sim := m.Simulate(string(data), app)
+	item := sim.Item
+
+	fmt.Fprintf(stdout, "Input:      %s (%s)\n", input, history.FormatSize(len(data)))
+	if app != "" {
+		fmt.Fprintf(stdout, "Source app: %s\n", app)
+	}
+	fmt.Fprintf(stdout, "Type:       %s\n", item.Type)
+	if item.Language != "" {
+		fmt.Fprintf(stdout, "Language:   %s\n", item.Language)
+	}
+	if detect.IsMarkdown(item.Item) {
+		fmt.Fprint(stdout, "Markdown:   yes, rendered in the preview\n")
+	}
+	if item.Sensitive != "" {
+		fmt.Fprintf(stdout, "Secret:     %s, masked in the table\n", item.Sensitive)
+	}
+	if sim.Policy != nil {
+		fmt.Fprintf(stdout, "Policy:     %s\n", sim.Policy.App)
+	}
+
+	skipped := sim.Skipped
+	if app != "" && privacy.NewGuard(cfg.Privacy.RespectHints, cfg.Privacy.ExcludedApps).Excludes(app) {
+		skipped = app + " is an excluded app"
+	}
+	if skipped != "" {
+		fmt.Fprintf(stdout, "Result:     skipped, as %s\n", skipped)
+		return 0
+	}
+	fmt.Fprint(stdout, "Result:     recorded\n")
+	if sim.Truncated {
+		fmt.Fprintf(stdout, "Truncated:  to %s, over the capture limit\n", history.FormatSize(len(item.Item)))
+	}
+	if len(item.Tags) > 0 {
+		fmt.Fprintf(stdout, "Tags:       %s\n", strings.Join(item.Tags, ", "))
+	}
+	if sim.TTL > 0 {
+		fmt.Fprintf(stdout, "Expires:    after %s\n", sim.TTL)
+	}
+	if sim.Overflow {
+		fmt.Fprint(stdout, "Stored:     in an overflow file, with a preview in the database\n")
+	}
+
+	matching := hookRunner(cfg).Matching(hooks.Capture, item, item.Item)
+	if len(matching) == 0 {
+		fmt.Fprint(stdout, "Hooks:      none\n")
+	}
+	for i, hook := range matching {
+		label := "Hooks:     "
+		if i > 0 {
+			label = "           "
+		}
+		fmt.Fprintf(stdout, "%s %s\n", label, strings.Join(hook.Command, " "))
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/config"
+)
+
+func TestSimulateCommand(t *testing.T) {
+	useTestDB(t)
+	loadConfig = func() (config.Config, error) {
+		cfg := config.Default()
+		cfg.Privacy.ExcludedApps = []string{"keepassxc"}
+		cfg.Privacy.Policies = []config.AppPolicy{{App: "slack", Types: []string{"url"}, Tags: []string{"chat"}}}
+		cfg.Expiry.Rules = []config.ExpiryRule{{Pattern: "^https://", TTL: 5 * time.Minute}}
+		cfg.Hooks = []config.HookConfig{{Event: "capture", Command: []string{"notify-send", "copied"}}}
+		return cfg, nil
+	}
+	input := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(input, []byte("https://example.com"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, errOut := run("simulate", "--app", "slack", "--input", input)
+	if code != 0 {
+		t.Fatalf("simulate: code %d, stderr %q", code, errOut)
+	}
+	for _, want := range []string{"Type:       url", "Policy:     slack", "Result:     recorded", "Tags:       chat", "Expires:    after 5m0s", "Hooks:      notify-send copied"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+
+	if _, out, _ := run("simulate", "--app", "KeePassXC", "--input", input); !strings.Contains(out, "skipped, as KeePassXC is an excluded app") {
+		t.Errorf("excluded app: %q", out)
+	}
+	useStdin(t, "just chatting")
+	if _, out, _ := run("simulate", "--app", "slack"); !strings.Contains(out, "Input:      stdin") || !strings.Contains(out, "skipped, as the policy for slack refuses it") {
+		t.Errorf("stdin: %q", out)
+	}
+	if code, _, errOut := run("simulate", "--app"); code != 2 || !strings.Contains(errOut, "usage: clippy simulate") {
+		t.Errorf("simulate --app: code %d, stderr %q", code, errOut)
+	}
+	if code, _, _ := run("simulate", "--input", filepath.Join(t.TempDir(), "missing")); code != 1 {
+		t.Errorf("missing input: code %d, want 1", code)
+	}
+}
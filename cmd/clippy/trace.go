@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/trace"
+)
+
+// traceEnv turns on tracing for the process when set.
+const traceEnv = "CLIPPY_TRACE"
+
+// slowestShown is how many of the longest spans clippy trace lists.
+const slowestShown = 10
+
+// tracePath returns the trace file, kept next to the history database
+func tracePath() (string, error) {
+	path, err := historyDBPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), trace.FileName), nil
+}
+
+// enableTracing records spans for clippy trace when CLIPPY_TRACE is set
+func enableTracing(stderr io.Writer) {
+	if os.Getenv(traceEnv) == "" {
+		return
+	}
+	path, err := tracePath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = trace.Enable(path)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: tracing disabled: %v\n", err)
+	}
+}
+
+// cmdTrace summarizes the spans recorded by processes run with
+// CLIPPY_TRACE set, or with reset deletes them.
+func cmdTrace(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "reset") {
+		fmt.Fprint(stderr, "usage: clippy trace [reset]\n")
+		return 2
+	}
+	path, err := tracePath()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	if len(args) == 1 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(stderr, "Failed to delete trace: %v\n", err)
+			return 1
+		}
+		fmt.Fprint(stdout, "Trace deleted\n")
+		return 0
+	}
+
+	records, err := trace.Load(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if len(records) == 0 {
+		fmt.Fprintf(stdout, "No spans recorded; run clippy with %s=1 to trace it\n", traceEnv)
+		return 0
+	}
+	fmt.Fprintf(stdout, "%-8s %7s %10s %10s %10s %10s\n", "span", "count", "p50", "p95", "max", "total")
+	for _, s := range trace.Summarize(records) {
+		fmt.Fprintf(stdout, "%-8s %7d %10s %10s %10s %10s\n",
+			s.Name, s.Count, roundDuration(s.P50), roundDuration(s.P95), roundDuration(s.Max), roundDuration(s.Total))
+	}
+	fmt.Fprint(stdout, "\nSlowest:\n")
+	for _, r := range trace.Slowest(records, slowestShown) {
+		fmt.Fprintf(stdout, "  %s  %-8s %10s  pid %d\n",
+			r.Start.Local().Format("2006-01-02 15:04:05.000"), r.Name, roundDuration(r.Duration), r.PID)
+	}
+	return 0
+}
+
+// roundDuration shortens d for display, e.g. "1.23ms"
+func roundDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/trace"
+)
+
+func TestTraceCommand(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "clippy.db")
+	orig := historyDBPath
+	historyDBPath = func() (string, error) { return dbPath, nil }
+	t.Cleanup(func() { historyDBPath = orig })
+
+	if code, out, _ := run("trace"); code != 0 || !strings.Contains(out, "No spans recorded") {
+		t.Fatalf("trace without spans: code %d, stdout %q", code, out)
+	}
+
+	t.Setenv(traceEnv, "1")
+	enableTracing(os.Stderr)
+	trace.Start(trace.Search).End()
+	trace.Start(trace.Render).End()
+	trace.Disable()
+
+	code, out, errOut := run("trace")
+	if code != 0 || !strings.Contains(out, "search") || !strings.Contains(out, "render") || !strings.Contains(out, "Slowest:") {
+		t.Fatalf("trace: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+
+	if code, _, _ := run("trace", "reset"); code != 0 {
+		t.Fatalf("trace reset: code %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dbPath), trace.FileName)); !os.IsNotExist(err) {
+		t.Errorf("expected the trace file deleted, got %v", err)
+	}
+	if code, _, errOut := run("trace", "everything"); code != 2 || !strings.Contains(errOut, "usage: clippy trace") {
+		t.Errorf("trace everything: code %d, stderr %q", code, errOut)
+	}
+}
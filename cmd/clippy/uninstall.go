@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/daemon"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/instance"
+	"github.com/bvdwalt/clippy/internal/trace"
+	"github.com/bvdwalt/clippy/internal/ui/styles"
+)
+
+const uninstallUsage = "usage: clippy uninstall-data [--shred] [--yes]\n"
+
+// sqliteSuffixes name the files SQLite keeps next to a database
+var sqliteSuffixes = []string{"", "-wal", "-shm", "-journal"}
+
+// dataPath is a file or directory clippy keeps data in
+type dataPath struct {
+	path string
+	// shared directories are removed only once empty, keeping files that
+	// clippy didn't create
+	shared bool
+}
+
+// dataPaths lists everything clippy stores: the history and archive
+// databases, their companion files, the daemon's socket, traces, the
+// config and the theme. Directories come after what they hold.
+func dataPaths() ([]dataPath, error) {
+	dbPath, err := historyDBPath()
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	dataDir := filepath.Dir(dbPath)
+	var paths []dataPath
+	for _, db := range []string{dbPath, filepath.Join(dataDir, history.ArchiveFileName)} {
+		for _, suffix := range sqliteSuffixes {
+			paths = append(paths, dataPath{path: db + suffix})
+		}
+	}
+	for _, name := range []string{
+		history.MediaDirName, history.OverflowDirName, history.IncognitoFileName,
+		trace.FileName, instance.FileName, daemon.SocketFileName,
+	} {
+		paths = append(paths, dataPath{path: filepath.Join(dataDir, name)})
+	}
+	return append(paths,
+		dataPath{path: dataDir, shared: true},
+		dataPath{path: filepath.Join(configDir, config.FileName)},
+		dataPath{path: filepath.Join(configDir, styles.ThemeFileName)},
+		dataPath{path: configDir, shared: true},
+	), nil
+}
+
+// cmdUninstallData deletes everything clippy stores, once confirmed as
+// [cli] confirm requires, so no trace of what was copied is left. With
+// --shred files are overwritten with random data before being deleted.
+func cmdUninstallData(args []string, stdout, stderr io.Writer) int {
+	shred, yes := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--shred":
+			shred = true
+		case "--yes", "-y":
+			yes = true
+		default:
+			fmt.Fprint(stderr, uninstallUsage)
+			return 2
+		}
+	}
+
+	dbPath, err := historyDBPath()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	// A running clippy would write its history back
+	if owner, ok := instance.Holder(filepath.Dir(dbPath)); ok {
+		fmt.Fprintf(stderr, "clippy is still capturing (%s, pid %d); quit it before deleting its data\n", owner.Role, owner.PID)
+		return 1
+	}
+	paths, err := dataPaths()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	var existing []dataPath
+	for _, p := range paths {
+		if _, err := os.Lstat(p.path); err == nil {
+			existing = append(existing, p)
+		}
+	}
+	if len(existing) == 0 {
+		fmt.Fprint(stdout, "Nothing to delete\n")
+		return 0
+	}
+
+	fmt.Fprint(stdout, "clippy's data:\n")
+	for _, p := range existing {
+		fmt.Fprintf(stdout, "  %s\n", p.path)
+	}
+	cfg, _ := loadConfig()
+	prompt := "This deletes clippy's history, config and all its other data, and can't be undone."
+	if !confirmDestructive(cfg.CLI.Confirm, yes, prompt, stdin, stderr) {
+		fmt.Fprint(stderr, "Aborted; nothing was deleted\n")
+		return 1
+	}
+
+	failed := false
+	for _, p := range existing {
+		if p.shared {
+			if entries, err := os.ReadDir(p.path); err == nil && len(entries) > 0 {
+				fmt.Fprintf(stdout, "Kept %s, which holds files clippy didn't create\n", p.path)
+				continue
+			}
+		}
+		if err := removeData(p.path, shred); err != nil {
+			fmt.Fprintf(stderr, "Failed to delete %s: %v\n", p.path, err)
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+	fmt.Fprint(stdout, "Deleted clippy's data\n")
+	return 0
+}
+
+// removeData deletes the file or directory at path, first shredding every
+// file in it when shred is set
+func removeData(path string, shred bool) error {
+	if shred {
+		err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			return shredFile(path)
+		})
+		if err != nil {
+			return fmt.Errorf("error shredding: %w", err)
+		}
+	}
+	return os.RemoveAll(path)
+}
+
+// shredFile overwrites the file at path with random data and flushes it to
+// disk. Copy-on-write filesystems and SSDs may still keep the old content
+// elsewhere.
+func shredFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(f, rand.Reader, info.Size())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
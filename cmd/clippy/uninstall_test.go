@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/config"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/instance"
+)
+
+// useTestData points the history database and config at a temporary
+// directory, returning the data and config directories.
+func useTestData(t *testing.T) (string, string) {
+	t.Helper()
+	useTestDB(t)
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	orig := historyDBPath
+	historyDBPath = func() (string, error) { return filepath.Join(dataDir, history.DBFileName), nil }
+	t.Cleanup(func() { historyDBPath = orig })
+	t.Setenv("XDG_CONFIG_HOME", root)
+	if err := os.MkdirAll(filepath.Join(dataDir, history.MediaDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, history.MediaDirName, "image.png"), []byte("png"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	seedDB(t, filepath.Join(dataDir, history.DBFileName), "secret")
+	return dataDir, filepath.Join(root, config.DirName)
+}
+
+func TestUninstallDataDeletesEverything(t *testing.T) {
+	dataDir, configDir := useTestData(t)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, config.FileName), []byte("[ui]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Not clippy's, so its directory is kept
+	other := filepath.Join(configDir, "notes.txt")
+	if err := os.WriteFile(other, []byte("mine"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	useStdin(t, "DELETE\n")
+	code, out, errOut := run("uninstall-data", "--shred")
+	if code != 0 || !strings.Contains(out, "Deleted clippy's data") {
+		t.Fatalf("uninstall-data: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if !strings.Contains(out, filepath.Join(dataDir, history.DBFileName)) {
+		t.Errorf("expected the database listed, got %q", out)
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Errorf("expected data directory deleted, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, config.FileName)); !os.IsNotExist(err) {
+		t.Errorf("expected config deleted, got %v", err)
+	}
+	if _, err := os.Stat(other); err != nil || !strings.Contains(out, "Kept "+configDir) {
+		t.Errorf("expected %s kept and reported: %v, stdout %q", other, err, out)
+	}
+
+	if code, out, errOut := run("uninstall-data", "--yes"); code != 0 {
+		t.Errorf("second run: code %d, stdout %q, stderr %q", code, out, errOut)
+	}
+}
+
+func TestUninstallDataRequiresConfirmation(t *testing.T) {
+	dataDir, _ := useTestData(t)
+
+	useStdin(t, "yes\n")
+	if code, _, errOut := run("uninstall-data"); code != 1 || !strings.Contains(errOut, "Aborted") {
+		t.Fatalf("wrong word: code %d, stderr %q", code, errOut)
+	}
+	if n := countEntries(t, filepath.Join(dataDir, history.DBFileName)); n != 1 {
+		t.Errorf("%d entries left after aborting, want 1", n)
+	}
+	if code, _, _ := run("uninstall-data", "--force"); code != 2 {
+		t.Errorf("unknown flag: code %d, want 2", code)
+	}
+}
+
+func TestUninstallDataRefusesWhileCapturing(t *testing.T) {
+	dataDir, _ := useTestData(t)
+	lock, err := instance.Acquire(dataDir, instance.Daemon)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer lock.Release()
+
+	code, _, errOut := run("uninstall-data", "--yes")
+	if code != 1 || !strings.Contains(errOut, "still capturing") {
+		t.Errorf("code %d, stderr %q; want a refusal", code, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, history.DBFileName)); err != nil {
+		t.Errorf("database touched while clippy runs: %v", err)
+	}
+}
+
+func TestShredFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip")
+	content := bytes.Repeat([]byte("secret "), 1000)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := shredFile(path); err != nil {
+		t.Fatalf("shredFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != len(content) || bytes.Contains(data, []byte("secret")) {
+		t.Errorf("shredded file is %d bytes, still holding the secret: %v", len(data), bytes.Contains(data, []byte("secret")))
+	}
+}
@@ -0,0 +1,103 @@
+// Package actions offers quick actions for entries whose content is
+// recognised, such as a git commit SHA or an IP address: copying it
+// reshaped as a command, opening its web page, or looking it up.
+package actions
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// Action is one thing that can be done with an entry: placing Copy on the
+// clipboard, opening URL in the browser, running Command (a program and
+// its arguments) with its output shown in a pager and Input, if any, on
+// its stdin, or copying the entry back in the format MimeType it was
+// stored with.
+type Action struct {
+	Label    string
+	Copy     string
+	URL      string
+	Command  []string
+	Input    string
+	MimeType string
+}
+
+// Config holds the settings actions depend on. Actions needing an unset
+// template are not offered.
+type Config struct {
+	// CommitURL is the web page of a commit, with {sha} standing for its
+	// SHA, e.g. "https://github.com/owner/repo/commit/{sha}".
+	CommitURL string
+	// BranchURL is the web page of a branch, with {branch} standing for its
+	// name, e.g. "https://github.com/owner/repo/tree/{branch}".
+	BranchURL string
+	// IssueURL is the page of a GitHub-style issue, with {number} standing
+	// for the number in "#5678", e.g.
+	// "https://github.com/owner/repo/issues/{number}".
+	IssueURL string
+	// JiraURL is the page of a Jira-style issue, with {key} standing for a
+	// key such as "PROJ-1234", e.g. "https://example.atlassian.net/browse/{key}".
+	JiraURL string
+	// PhoneRegion is the ISO country code, e.g. "US", assumed for phone
+	// numbers written without one; when unset only numbers starting with a
+	// country code are recognised.
+	PhoneRegion string
+	// Custom are the user-defined actions, offered after the built-in ones
+	// for the entries they match.
+	Custom []Custom
+}
+
+// For returns the actions available for content, most useful first, or nil
+// when nothing in it is recognised and no custom action matches.
+func For(content string, cfg Config) []Action {
+	s := strings.TrimSpace(content)
+	if s == "" {
+		return nil
+	}
+	return append(recognised(s, cfg), customActions(content, cfg)...)
+}
+
+// recognised returns the built-in actions for the trimmed content s
+func recognised(s string, cfg Config) []Action {
+	var actions []Action
+	if len(s) <= maxRefLength && !strings.ContainsAny(s, " \t\r\n") {
+		if addr, err := netip.ParseAddr(s); err == nil {
+			actions = ipActions(addr)
+		} else if prefix, err := netip.ParsePrefix(s); err == nil {
+			actions = prefixActions(prefix)
+		} else {
+			switch {
+			case IsCommitSHA(s):
+				actions = commitActions(s, cfg)
+			case IsBranch(s):
+				actions = branchActions(s, cfg)
+			case IsDomain(s):
+				actions = domainActions(s)
+			}
+		}
+	}
+	if actions == nil {
+		if table, ok := detect.ParseTable(s); ok {
+			return tableActions(table)
+		}
+		if number, ok := ParsePhone(s, cfg.PhoneRegion); ok {
+			return phoneActions(number)
+		}
+		if parts, ok := AddressParts(s); ok {
+			// A flat number such as "Apt #5" is not an issue
+			return addressActions(s, parts)
+		}
+	}
+	return append(actions, issueActions(s, cfg)...)
+}
+
+// expand replaces placeholder in template with value, returning "" for an
+// unset template
+func expand(template, placeholder, value string) string {
+	if template == "" {
+		return ""
+	}
+	return strings.ReplaceAll(template, placeholder, value)
+}
@@ -0,0 +1,121 @@
+package actions
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on what is considered a postal address.
+const (
+	maxAddressParts    = 6
+	maxAddressPartLen  = 80
+	maxAddressLength   = 300
+	addressMapTemplate = "https://www.openstreetmap.org/search?query={address}"
+)
+
+var (
+	// streetPatterns match the line of an address holding the street: a
+	// house number before an English street type, a number after a
+	// continental one or a bare street name, or a PO box
+	streetPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\d+[a-z]?(?:[-/]\d+)?\s+\S.*\b(?:street|st|avenue|ave|road|rd|lane|ln|boulevard|blvd|drive|dr|way|court|ct|place|pl|square|sq|terrace|parkway|pkwy|highway|hwy|crescent|close|circle|cir)\b\.?(?:\s.*)?$`),
+		regexp.MustCompile(`(?i)^\S.*(?:straße|strasse|str\.|weg|gasse|platz|allee|straat|laan|gade|vej|gatan|vägen)\s+\d+[a-z]?\b`),
+		regexp.MustCompile(`^\p{L}[\p{L} .'-]+\s\d+[a-z]?$`),
+		regexp.MustCompile(`(?i)^(?:rue|via|calle|avenida|rua)\s+\S.*`),
+		regexp.MustCompile(`(?i)^p\.?\s?o\.?\s+box\s+\d+`),
+	}
+	// postcodePatterns match a line holding a postcode: US state and ZIP,
+	// UK, Canadian, Dutch, and the number-then-town form used across
+	// Europe
+	postcodePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b[A-Z]{2}\s+(\d{5}(?:-\d{4})?)$`),
+		regexp.MustCompile(`\b([A-Z]{1,2}\d[A-Z\d]?\s*\d[A-Z]{2})$`),
+		regexp.MustCompile(`\b([A-Z]\d[A-Z]\s?\d[A-Z]\d)$`),
+		regexp.MustCompile(`^(\d{4}\s?[A-Z]{2})\s+\p{L}`),
+		regexp.MustCompile(`^(?:[A-Z]{1,2}-)?(\d{4,5})\s+\p{L}`),
+	}
+)
+
+// AddressParts splits s into the lines of a postal address, such as
+// "1 Main Street", "Springfield, IL 62701", if it looks like one. An
+// address on one line is split at its commas.
+func AddressParts(s string) ([]string, bool) {
+	if len(s) > maxAddressLength {
+		return nil, false
+	}
+	separator := "\n"
+	if !strings.Contains(s, "\n") {
+		separator = ","
+	}
+	var parts []string
+	for _, part := range strings.Split(s, separator) {
+		part = strings.TrimRight(strings.Join(strings.Fields(part), " "), ",")
+		if part == "" {
+			continue
+		}
+		if utf8.RuneCountInString(part) > maxAddressPartLen {
+			return nil, false
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) < 2 || len(parts) > maxAddressParts {
+		return nil, false
+	}
+	street := -1
+	for i, part := range parts {
+		if matchesAny(streetPatterns, part) {
+			street = i
+			break
+		}
+	}
+	if street < 0 || postcode(parts[street:]) == "" {
+		return nil, false
+	}
+	return parts, true
+}
+
+// postcode returns the first postcode found in parts, or ""
+func postcode(parts []string) string {
+	for _, part := range parts {
+		for _, pattern := range postcodePatterns {
+			if m := pattern.FindStringSubmatch(part); m != nil {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressActions are the actions offered for a postal address: copying it
+// reflowed onto one line or one part per line, and finding it on a map
+func addressActions(s string, parts []string) []Action {
+	line := strings.Join(parts, ", ")
+	var actions []Action
+	if strings.Contains(s, "\n") {
+		actions = append(actions, Action{Label: "copy as single line", Copy: line})
+	} else {
+		actions = append(actions, Action{Label: "copy as multiple lines", Copy: strings.Join(parts, "\n")})
+	}
+	return append(actions, Action{
+		Label: "open in OpenStreetMap",
+		URL:   expand(addressMapTemplate, "{address}", url.QueryEscape(line)),
+	})
+}
+
+// describeAddress summarises an address, e.g. "postal address • 3 lines •
+// postcode 62701"
+func describeAddress(parts []string) string {
+	return fmt.Sprintf("postal address • %d lines • postcode %s", len(parts), postcode(parts))
+}
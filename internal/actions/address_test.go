@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddressParts(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string // parts joined with " | ", "" when not an address
+	}{
+		{"1600 Amphitheatre Parkway\nMountain View, CA 94043\nUSA", "1600 Amphitheatre Parkway | Mountain View, CA 94043 | USA"},
+		{"Jane Doe\n221B Baker Street,\nLondon NW1 6XE", "Jane Doe | 221B Baker Street | London NW1 6XE"},
+		{"Unter den Linden 77\n10117 Berlin", "Unter den Linden 77 | 10117 Berlin"},
+		{"Invalidenstraße 116, 10115 Berlin, Germany", "Invalidenstraße 116 | 10115 Berlin | Germany"},
+		{"PO Box 1234\nSpringfield, IL 62701", "PO Box 1234 | Springfield, IL 62701"},
+		{"12 Main Street\nno postcode here", ""},
+		{"Hello, world", ""},
+		{"func main() {\n\tfmt.Println(1, 2)\n}", ""},
+	}
+	for _, tt := range tests {
+		parts, ok := AddressParts(tt.s)
+		got := ""
+		if ok {
+			got = strings.Join(parts, " | ")
+		}
+		if got != tt.want {
+			t.Errorf("AddressParts(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAddressActions(t *testing.T) {
+	got := For("1600 Amphitheatre Parkway\nMountain View, CA 94043", Config{IssueURL: "https://git.test/issues/{number}"})
+	if len(got) != 2 {
+		t.Fatalf("For() = %+v, want 2 actions", got)
+	}
+	if got[0].Copy != "1600 Amphitheatre Parkway, Mountain View, CA 94043" {
+		t.Errorf("single line = %q", got[0].Copy)
+	}
+	if got[1].URL != "https://www.openstreetmap.org/search?query=1600+Amphitheatre+Parkway%2C+Mountain+View%2C+CA+94043" {
+		t.Errorf("map URL = %q", got[1].URL)
+	}
+
+	got = For("Unit #5, 10 Downing Street, London SW1A 2AA", Config{IssueURL: "https://git.test/issues/{number}"})
+	if len(got) != 2 || got[0].Copy != "Unit #5\n10 Downing Street\nLondon SW1A 2AA" {
+		t.Errorf("For() = %+v, want the multi-line address and no issue actions", got)
+	}
+	if d := Describe("Unter den Linden 77\n10117 Berlin", Config{}); d != "postal address • 2 lines • postcode 10117" {
+		t.Errorf("Describe() = %q", d)
+	}
+}
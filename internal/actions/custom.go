@@ -0,0 +1,59 @@
+package actions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Custom is a user-defined action, offered for entries matching Pattern
+// (all of them when nil). Its Command, a program and its arguments, gets
+// the entry on stdin, with {content} in an argument standing for the
+// trimmed entry and {match} for the text Pattern matched.
+type Custom struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Command []string
+}
+
+// NewCustom checks and compiles a custom action from its configured form.
+func NewCustom(name, pattern string, command []string) (Custom, error) {
+	custom := Custom{Name: strings.TrimSpace(name), Command: command}
+	if custom.Name == "" {
+		return Custom{}, fmt.Errorf("custom action running %q has no name", strings.Join(command, " "))
+	}
+	if len(command) == 0 || command[0] == "" {
+		return Custom{}, fmt.Errorf("custom action %q has no command", custom.Name)
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Custom{}, fmt.Errorf("invalid pattern for custom action %q: %w", custom.Name, err)
+		}
+		custom.Pattern = re
+	}
+	return custom, nil
+}
+
+// customActions returns the configured custom actions matching content
+func customActions(content string, cfg Config) []Action {
+	var actions []Action
+	s := strings.TrimSpace(content)
+	for _, custom := range cfg.Custom {
+		match := s
+		if custom.Pattern != nil {
+			loc := custom.Pattern.FindStringIndex(content)
+			if loc == nil {
+				continue
+			}
+			match = content[loc[0]:loc[1]]
+		}
+		replacer := strings.NewReplacer("{content}", s, "{match}", match)
+		command := make([]string, len(custom.Command))
+		for i, arg := range custom.Command {
+			command[i] = replacer.Replace(arg)
+		}
+		actions = append(actions, Action{Label: custom.Name, Command: command, Input: content})
+	}
+	return actions
+}
@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewCustom(t *testing.T) {
+	if _, err := NewCustom("ping", "(unclosed", []string{"ping"}); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+	if _, err := NewCustom(" ", "", []string{"ping"}); err == nil {
+		t.Error("expected a missing name to be rejected")
+	}
+	if _, err := NewCustom("ping", "", nil); err == nil {
+		t.Error("expected a missing command to be rejected")
+	}
+}
+
+func TestCustomActions(t *testing.T) {
+	ping, err := NewCustom("ping this host", `\b[a-z]+\.example\.com\b`, []string{"ping", "-c", "3", "{match}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc, err := NewCustom("count words", "", []string{"wc", "-w"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Custom: []Custom{ping, wc}}
+
+	got := For("  see db.example.com now\n", cfg)
+	if len(got) != 2 {
+		t.Fatalf("For() = %+v, want both custom actions", got)
+	}
+	if got[0].Label != "ping this host" || !slices.Equal(got[0].Command, []string{"ping", "-c", "3", "db.example.com"}) {
+		t.Errorf("ping action = %+v", got[0])
+	}
+	if got[1].Input != "  see db.example.com now\n" {
+		t.Errorf("count words input = %q, want the entry", got[1].Input)
+	}
+
+	got = For("nothing to ping", cfg)
+	if len(got) != 1 || got[0].Label != "count words" {
+		t.Errorf("For(unmatched) = %+v, want only the action without a pattern", got)
+	}
+
+	// Custom actions come after the built-in ones
+	echo, _ := NewCustom("echo", "", []string{"echo", "{content}"})
+	got = For("3f9c2a1", Config{Custom: []Custom{echo}})
+	if len(got) != 4 || !slices.Equal(got[3].Command, []string{"echo", "3f9c2a1"}) {
+		t.Errorf("For(sha) = %+v, want the custom action last", got)
+	}
+}
@@ -0,0 +1,93 @@
+package actions
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxRefLength bounds what is considered a SHA or branch name; longer
+// single words are more likely tokens or encoded data.
+const maxRefLength = 255
+
+var (
+	shaPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	// refPattern allows the characters git permits in a branch name
+	refPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+)
+
+// branchPrefixes are the conventional prefixes that mark a word as a branch
+// name rather than a path or identifier.
+var branchPrefixes = []string{
+	"feature/", "feat/", "fix/", "bugfix/", "hotfix/", "release/", "chore/",
+	"refactor/", "docs/", "dependabot/", "renovate/", "origin/", "refs/heads/",
+}
+
+// IsCommitSHA reports whether s looks like an abbreviated or full git
+// commit SHA. Short hex words must mix digits and letters, so plain numbers
+// and words like "decade" are not mistaken for one.
+func IsCommitSHA(s string) bool {
+	if !shaPattern.MatchString(s) {
+		return false
+	}
+	if len(s) == 40 {
+		return true
+	}
+	return strings.ContainsAny(s, "0123456789") && strings.ContainsAny(s, "abcdef")
+}
+
+// IsBranch reports whether s looks like a git branch name: a valid ref name
+// with a conventional prefix such as "feature/".
+func IsBranch(s string) bool {
+	if !refPattern.MatchString(s) || strings.Contains(s, "..") || strings.Contains(s, "//") ||
+		strings.HasSuffix(s, "/") || strings.HasSuffix(s, ".lock") || strings.HasSuffix(s, ".") {
+		return false
+	}
+	for _, prefix := range branchPrefixes {
+		if strings.HasPrefix(s, prefix) && len(s) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitActions are the actions offered for a commit SHA
+func commitActions(sha string, cfg Config) []Action {
+	actions := []Action{
+		{Label: "copy as git checkout", Copy: "git checkout " + sha},
+		{Label: "copy as git show", Copy: "git show " + sha},
+		{Label: "copy as git cherry-pick", Copy: "git cherry-pick " + sha},
+	}
+	if u := expand(cfg.CommitURL, "{sha}", sha); u != "" {
+		actions = append(actions,
+			Action{Label: "open commit", URL: u},
+			Action{Label: "copy commit URL", Copy: u},
+		)
+	}
+	return actions
+}
+
+// branchActions are the actions offered for a branch name
+func branchActions(ref string, cfg Config) []Action {
+	branch := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "origin/")
+	actions := []Action{
+		{Label: "copy as git checkout", Copy: "git checkout " + branch},
+		{Label: "copy as git pull", Copy: "git pull origin " + branch},
+	}
+	if u := expand(cfg.BranchURL, "{branch}", escapePath(branch)); u != "" {
+		actions = append(actions,
+			Action{Label: "open branch", URL: u},
+			Action{Label: "copy branch URL", Copy: u},
+		)
+	}
+	return actions
+}
+
+// escapePath escapes each segment of a slash-separated name for a URL path
+func escapePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
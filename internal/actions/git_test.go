@@ -0,0 +1,86 @@
+package actions
+
+import "testing"
+
+func TestIsCommitSHA(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"3f9c2a1", true},
+		{"bb45c6e0d2a7", true},
+		{"da39a3ee5e6b4b0d3255bfef95601890afd80709", true},
+		{"1234567", false},     // only digits
+		{"deadbeef", false},    // only letters
+		{"3f9c2a", false},      // too short
+		{"3F9C2A1", false},     // git prints lowercase
+		{"3f9c2a1z", false},    // not hex
+		{"0000000000", false},  // only digits
+		{"cafe1234cafe", true}, // mixed
+		{"feature/login", false},
+	}
+	for _, tt := range tests {
+		if got := IsCommitSHA(tt.s); got != tt.want {
+			t.Errorf("IsCommitSHA(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestIsBranch(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"feature/login-form", true},
+		{"release/1.2", true},
+		{"origin/fix/crash", true},
+		{"dependabot/go_modules/golang.org/x/net-0.23.0", true},
+		{"feature/", false},
+		{"feature/a..b", false},
+		{"feature/x.lock", false},
+		{"src/main.go", false},
+		{"main", false},
+	}
+	for _, tt := range tests {
+		if got := IsBranch(tt.s); got != tt.want {
+			t.Errorf("IsBranch(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	cfg := Config{
+		CommitURL: "https://github.com/o/r/commit/{sha}",
+		BranchURL: "https://github.com/o/r/tree/{branch}",
+	}
+
+	commit := For("  3f9c2a1\n", cfg)
+	if len(commit) != 5 {
+		t.Fatalf("For(sha) returned %d actions, want 5: %+v", len(commit), commit)
+	}
+	if commit[0].Copy != "git checkout 3f9c2a1" {
+		t.Errorf("first action copies %q, want %q", commit[0].Copy, "git checkout 3f9c2a1")
+	}
+	if commit[3].URL != "https://github.com/o/r/commit/3f9c2a1" {
+		t.Errorf("open commit URL = %q", commit[3].URL)
+	}
+
+	branch := For("origin/feature/a#1", cfg)
+	if len(branch) != 0 {
+		t.Errorf("For(invalid branch) = %+v, want none", branch)
+	}
+	branch = For("origin/feature/login", cfg)
+	if len(branch) != 4 || branch[0].Copy != "git checkout feature/login" {
+		t.Fatalf("For(branch) = %+v", branch)
+	}
+	if branch[2].URL != "https://github.com/o/r/tree/feature/login" {
+		t.Errorf("open branch URL = %q", branch[2].URL)
+	}
+
+	if got := For("3f9c2a1", Config{}); len(got) != 3 {
+		t.Errorf("without URL templates got %d actions, want 3", len(got))
+	}
+	if got := For("see 3f9c2a1", cfg); got != nil {
+		t.Errorf("For(prose) = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,92 @@
+package actions
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxIssues is how many distinct issue IDs in one entry get actions.
+	maxIssues = 3
+	// maxIssueScan bounds how much of an entry is searched for issue IDs.
+	maxIssueScan = 4096
+)
+
+var (
+	jiraKeyPattern     = regexp.MustCompile(`\b([A-Z][A-Z0-9]{1,9})-[1-9][0-9]{0,6}\b`)
+	issueNumberPattern = regexp.MustCompile(`(?:^|[\s(\[,;:])#([1-9][0-9]{0,6})\b`)
+)
+
+// notJiraProjects are prefixes of common identifiers shaped like Jira keys,
+// such as UTF-8 or SHA-256.
+var notJiraProjects = map[string]bool{
+	"AES": true, "CVE": true, "COVID": true, "ECMA": true, "ES": true,
+	"GPT": true, "HTTP": true, "ISO": true, "MD": true, "PEP": true,
+	"RFC": true, "RSA": true, "SHA": true, "SSL": true, "TLS": true,
+	"UTF": true,
+}
+
+// JiraKeys returns the distinct Jira-style issue keys in s, such as
+// "PROJ-1234", in order of appearance.
+func JiraKeys(s string) []string {
+	var keys []string
+	for _, m := range jiraKeyPattern.FindAllStringSubmatch(s, -1) {
+		if !notJiraProjects[m[1]] {
+			keys = appendUnique(keys, m[0])
+		}
+	}
+	return keys
+}
+
+// IssueNumbers returns the distinct GitHub-style issue numbers written as
+// "#5678" in s, without the "#", in order of appearance.
+func IssueNumbers(s string) []string {
+	var numbers []string
+	for _, m := range issueNumberPattern.FindAllStringSubmatch(s, -1) {
+		numbers = appendUnique(numbers, m[1])
+	}
+	return numbers
+}
+
+// issueActions are the actions offered for the issue IDs in s whose URL
+// template is configured
+func issueActions(s string, cfg Config) []Action {
+	if cfg.IssueURL == "" && cfg.JiraURL == "" {
+		return nil
+	}
+	if len(s) > maxIssueScan {
+		s = s[:maxIssueScan]
+	}
+
+	type issue struct{ id, url string }
+	var issues []issue
+	if cfg.JiraURL != "" {
+		for _, key := range JiraKeys(s) {
+			issues = append(issues, issue{key, expand(cfg.JiraURL, "{key}", key)})
+		}
+	}
+	if cfg.IssueURL != "" {
+		for _, number := range IssueNumbers(s) {
+			issues = append(issues, issue{"#" + number, expand(cfg.IssueURL, "{number}", number)})
+		}
+	}
+
+	var actions []Action
+	for _, is := range issues[:min(len(issues), maxIssues)] {
+		actions = append(actions,
+			Action{Label: "open issue " + is.id, URL: is.url},
+			Action{Label: "copy issue " + is.id + " URL", Copy: is.url},
+		)
+	}
+	return actions
+}
+
+// appendUnique appends s to list unless it is already there
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if strings.EqualFold(existing, s) {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJiraKeys(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"PROJ-1234", []string{"PROJ-1234"}},
+		{"Fix OPS-7 and PROJ-12, see OPS-7 again", []string{"OPS-7", "PROJ-12"}},
+		{"feature/PROJ-88-login", []string{"PROJ-88"}},
+		{"encode as UTF-8 and hash with SHA-256", nil},
+		{"proj-12 X-1 PROJ-0", nil},
+	}
+	for _, tt := range tests {
+		if got := JiraKeys(tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("JiraKeys(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestIssueNumbers(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"#5678", []string{"5678"}},
+		{"Fix crash (#12), closes #34; refs #12", []string{"12", "34"}},
+		{"color: #fff", nil},
+		{"item#5 and #0", nil},
+	}
+	for _, tt := range tests {
+		if got := IssueNumbers(tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("IssueNumbers(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestIssueActions(t *testing.T) {
+	cfg := Config{
+		IssueURL: "https://github.com/o/r/issues/{number}",
+		JiraURL:  "https://x.atlassian.net/browse/{key}",
+	}
+
+	got := For("PROJ-12: fix login (#34)", cfg)
+	want := []Action{
+		{Label: "open issue PROJ-12", URL: "https://x.atlassian.net/browse/PROJ-12"},
+		{Label: "copy issue PROJ-12 URL", Copy: "https://x.atlassian.net/browse/PROJ-12"},
+		{Label: "open issue #34", URL: "https://github.com/o/r/issues/34"},
+		{Label: "copy issue #34 URL", Copy: "https://github.com/o/r/issues/34"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("For() = %+v, want %+v", got, want)
+	}
+
+	if got := For("PROJ-12 #34", Config{}); got != nil {
+		t.Errorf("without URL templates For() = %+v, want nil", got)
+	}
+	if got := For("A-1 B-2 C-3 D-4 AB-1 AB-2 AB-3 AB-4", cfg); len(got) != 2*maxIssues {
+		t.Errorf("For(many issues) returned %d actions, want %d", len(got), 2*maxIssues)
+	}
+}
@@ -0,0 +1,213 @@
+package actions
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var domainPattern = regexp.MustCompile(`^(?i:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+(?i:[a-z]{2,63})$`)
+
+// fileExtensions are endings that make a dotted word a file name rather
+// than a domain, even where they are also top-level domains (.md, .sh).
+var fileExtensions = map[string]bool{
+	"c": true, "cc": true, "conf": true, "cpp": true, "css": true, "csv": true,
+	"dll": true, "exe": true, "gif": true, "go": true, "gz": true, "h": true,
+	"html": true, "ini": true, "java": true, "jpeg": true, "jpg": true,
+	"js": true, "json": true, "jsx": true, "lock": true, "log": true,
+	"md": true, "mod": true, "pdf": true, "png": true, "py": true, "rb": true,
+	"rs": true, "sh": true, "sql": true, "sum": true, "svg": true, "tar": true,
+	"toml": true, "ts": true, "tsx": true, "txt": true, "xml": true,
+	"yaml": true, "yml": true, "zip": true,
+}
+
+// IsDomain reports whether s looks like a domain name such as
+// "example.com", rather than a file name such as "main.go".
+func IsDomain(s string) bool {
+	if len(s) > 253 || !domainPattern.MatchString(s) {
+		return false
+	}
+	tld := strings.ToLower(s[strings.LastIndex(s, ".")+1:])
+	return !fileExtensions[tld]
+}
+
+// Describe returns a one-line summary of the IP address, network, domain,
+// phone number or postal address in content, e.g. "IPv4 network • 256
+// addresses (10.0.0.0 - 10.0.0.255) • private", or "" when content is none
+// of those.
+func Describe(content string, cfg Config) string {
+	s := strings.TrimSpace(content)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return fmt.Sprintf("%s address • %s", ipVersion(addr), scope(addr))
+	}
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		prefix = prefix.Masked()
+		first, last := prefix.Addr(), lastAddr(prefix)
+		return fmt.Sprintf("%s network • %s (%s - %s) • %s",
+			ipVersion(first), addressCount(prefix), first, last, scope(first))
+	}
+	if IsDomain(s) {
+		labels := strings.Split(strings.ToLower(s), ".")
+		return fmt.Sprintf("domain • top-level .%s • %d labels", labels[len(labels)-1], len(labels))
+	}
+	if number, ok := ParsePhone(s, cfg.PhoneRegion); ok {
+		return describePhone(number)
+	}
+	if parts, ok := AddressParts(s); ok {
+		return describeAddress(parts)
+	}
+	return ""
+}
+
+// PTRName returns the reverse DNS name looked up for addr, e.g.
+// "4.3.2.1.in-addr.arpa" for 1.2.3.4.
+func PTRName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		b := addr.As4()
+		return reverseLabels(b[:], 8) + ".in-addr.arpa"
+	}
+	b := addr.As16()
+	return reverseLabels(b[:], 32) + ".ip6.arpa"
+}
+
+// reverseLabels writes the first n labels of an address in reverse order:
+// octets in decimal for IPv4 (n up to 4), nibbles in hex for IPv6 (n up
+// to 32)
+func reverseLabels(b []byte, n int) string {
+	var labels []string
+	if len(b) == 4 {
+		for i := range min(n, 4) {
+			labels = append(labels, strconv.Itoa(int(b[i])))
+		}
+	} else {
+		for i := range min(n, 32) {
+			nibble := b[i/2] >> 4
+			if i%2 == 1 {
+				nibble = b[i/2] & 0x0f
+			}
+			labels = append(labels, strconv.FormatUint(uint64(nibble), 16))
+		}
+	}
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.Join(labels, ".")
+}
+
+// ipActions are the actions offered for a single address
+func ipActions(addr netip.Addr) []Action {
+	s := addr.String()
+	actions := []Action{
+		{Label: "copy PTR name", Copy: PTRName(addr)},
+		{Label: "copy as ssh command", Copy: "ssh " + s},
+	}
+	actions = appendLookup(actions, "dig", "-x", s)
+	return appendLookup(actions, "whois", s)
+}
+
+// prefixActions are the actions offered for a network in CIDR notation
+func prefixActions(prefix netip.Prefix) []Action {
+	prefix = prefix.Masked()
+	first := prefix.Addr()
+	actions := []Action{
+		{Label: "copy network address", Copy: first.String()},
+		{Label: "copy last address", Copy: lastAddr(prefix).String()},
+	}
+	if first.Is4() {
+		mask := net.IP(net.CIDRMask(prefix.Bits(), 32)).String()
+		actions = append(actions, Action{Label: "copy netmask", Copy: mask})
+	}
+	if zone := ptrZone(prefix); zone != "" {
+		actions = append(actions, Action{Label: "copy PTR zone", Copy: zone})
+	}
+	return appendLookup(actions, "whois", first.String())
+}
+
+// domainActions are the actions offered for a domain name
+func domainActions(domain string) []Action {
+	actions := []Action{
+		{Label: "copy as ssh command", Copy: "ssh " + domain},
+		{Label: "copy as URL", Copy: "https://" + domain},
+	}
+	actions = appendLookup(actions, "dig", domain)
+	return appendLookup(actions, "whois", domain)
+}
+
+// appendLookup appends an action running the lookup command args in a
+// pager, if its program is installed
+func appendLookup(actions []Action, args ...string) []Action {
+	if _, err := lookPath(args[0]); err != nil {
+		return actions
+	}
+	return append(actions, Action{Label: "run " + strings.Join(args, " "), Command: args})
+}
+
+// ptrZone returns the reverse DNS zone of prefix when its length falls on
+// a label boundary (whole octets for IPv4, nibbles for IPv6), otherwise ""
+func ptrZone(prefix netip.Prefix) string {
+	bits := prefix.Bits()
+	addr := prefix.Addr()
+	switch {
+	case bits == 0:
+		return ""
+	case addr.Is4() && bits%8 == 0:
+		b := addr.As4()
+		return reverseLabels(b[:], bits/8) + ".in-addr.arpa"
+	case addr.Is6() && bits%4 == 0:
+		b := addr.As16()
+		return reverseLabels(b[:], bits/4) + ".ip6.arpa"
+	}
+	return ""
+}
+
+// lastAddr returns the highest address in prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Masked().Addr()
+	b := addr.AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	last, _ := netip.AddrFromSlice(b)
+	return last
+}
+
+// addressCount describes how many addresses prefix holds
+func addressCount(prefix netip.Prefix) string {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	switch {
+	case hostBits == 0:
+		return "1 address"
+	case hostBits > 32:
+		return fmt.Sprintf("2^%d addresses", hostBits)
+	}
+	return fmt.Sprintf("%d addresses", uint64(1)<<hostBits)
+}
+
+func ipVersion(addr netip.Addr) string {
+	if addr.Is4() || addr.Is4In6() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// scope names the kind of address, e.g. "private" or "loopback"
+func scope(addr netip.Addr) string {
+	addr = addr.Unmap()
+	switch {
+	case addr.IsUnspecified():
+		return "unspecified"
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.IsPrivate():
+		return "private"
+	case addr.IsLinkLocalUnicast():
+		return "link-local"
+	case addr.IsMulticast():
+		return "multicast"
+	}
+	return "public"
+}
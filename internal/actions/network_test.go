@@ -0,0 +1,109 @@
+package actions
+
+import (
+	"net/netip"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// useInstalled stubs lookPath with only the given programs installed.
+func useInstalled(t *testing.T, installed ...string) {
+	t.Helper()
+	origLookPath := lookPath
+	t.Cleanup(func() { lookPath = origLookPath })
+	lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestIsDomain(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"example.com", true},
+		{"api.eu-west-1.example.co.uk", true},
+		{"EXAMPLE.ORG", true},
+		{"main.go", false},
+		{"README.md", false},
+		{"localhost", false},
+		{"-bad.com", false},
+		{"1.2.3.4", false},
+	}
+	for _, tt := range tests {
+		if got := IsDomain(tt.s); got != tt.want {
+			t.Errorf("IsDomain(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPTRName(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"192.0.2.10", "10.2.0.192.in-addr.arpa"},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+	}
+	for _, tt := range tests {
+		if got := PTRName(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("PTRName(%s) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"10.1.2.3", "IPv4 address • private"},
+		{"8.8.8.8", "IPv4 address • public"},
+		{"::1", "IPv6 address • loopback"},
+		{"192.168.1.77/24", "IPv4 network • 256 addresses (192.168.1.0 - 192.168.1.255) • private"},
+		{"2001:db8::/32", "IPv6 network • 2^96 addresses (2001:db8:: - 2001:db8:ffff:ffff:ffff:ffff:ffff:ffff) • public"},
+		{"Example.com", "domain • top-level .com • 2 labels"},
+		{"hello", ""},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.s, Config{}); got != tt.want {
+			t.Errorf("Describe(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkActions(t *testing.T) {
+	useInstalled(t, "dig")
+
+	got := For("192.0.2.10", Config{})
+	want := []Action{
+		{Label: "copy PTR name", Copy: "10.2.0.192.in-addr.arpa"},
+		{Label: "copy as ssh command", Copy: "ssh 192.0.2.10"},
+		{Label: "run dig -x 192.0.2.10", Command: []string{"dig", "-x", "192.0.2.10"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("For(ip) = %+v, want %+v", got, want)
+	}
+
+	got = For("10.20.30.40/16", Config{})
+	want = []Action{
+		{Label: "copy network address", Copy: "10.20.0.0"},
+		{Label: "copy last address", Copy: "10.20.255.255"},
+		{Label: "copy netmask", Copy: "255.255.0.0"},
+		{Label: "copy PTR zone", Copy: "20.10.in-addr.arpa"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("For(cidr) = %+v, want %+v", got, want)
+	}
+
+	got = For("example.com", Config{})
+	if len(got) != 3 || got[2].Label != "run dig example.com" {
+		t.Errorf("For(domain) = %+v", got)
+	}
+}
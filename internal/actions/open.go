@@ -0,0 +1,52 @@
+package actions
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ErrNoOpener is returned by Open when no way to open a URL is found.
+var ErrNoOpener = errors.New("no command found to open URLs (install xdg-open)")
+
+// Overridable for tests.
+var (
+	goos     = runtime.GOOS
+	lookPath = exec.LookPath
+	start    = func(name string, args ...string) error {
+		return exec.Command(name, args...).Start()
+	}
+)
+
+// Open opens url in the default browser without waiting for it.
+func Open(url string) error {
+	switch goos {
+	case "darwin":
+		return start("open", url)
+	case "windows":
+		return start("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	// wslview opens URLs in the Windows browser from inside WSL
+	for _, opener := range []string{"xdg-open", "wslview"} {
+		if _, err := lookPath(opener); err == nil {
+			return start(opener, url)
+		}
+	}
+	return ErrNoOpener
+}
+
+// PagerCommand returns a command running args with its output, including
+// errors, shown in $PAGER (less by default), or in more on Windows.
+func PagerCommand(args []string) *exec.Cmd {
+	if goos == "windows" {
+		return exec.Command("cmd", append(append([]string{"/c"}, args...), "|", "more")...)
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	// The program and its arguments are passed as positional parameters, so
+	// they are never parsed by the shell
+	return exec.Command("sh", append([]string{"-c", `"$0" "$@" 2>&1 | ` + pager}, args...)...)
+}
@@ -0,0 +1,73 @@
+package actions
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useOpeners stubs the platform with only the given commands installed and
+// records the commands started.
+func useOpeners(t *testing.T, platform string, installed ...string) *[]string {
+	t.Helper()
+	origGOOS, origLookPath, origStart := goos, lookPath, start
+	t.Cleanup(func() { goos, lookPath, start = origGOOS, origLookPath, origStart })
+	goos = platform
+	lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	var started []string
+	start = func(name string, args ...string) error {
+		started = append(started, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	return &started
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		goos      string
+		installed []string
+		want      string
+	}{
+		{"macos", "darwin", nil, "open https://x.test"},
+		{"windows", "windows", nil, "rundll32 url.dll,FileProtocolHandler https://x.test"},
+		{"linux", "linux", []string{"xdg-open", "wslview"}, "xdg-open https://x.test"},
+		{"wsl", "linux", []string{"wslview"}, "wslview https://x.test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := useOpeners(t, tt.goos, tt.installed...)
+			if err := Open("https://x.test"); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if len(*started) != 1 || (*started)[0] != tt.want {
+				t.Errorf("started %v, want [%s]", *started, tt.want)
+			}
+		})
+	}
+
+	useOpeners(t, "linux")
+	if err := Open("https://x.test"); !errors.Is(err, ErrNoOpener) {
+		t.Errorf("Open without an opener = %v, want ErrNoOpener", err)
+	}
+}
+
+func TestPagerCommand(t *testing.T) {
+	useOpeners(t, "linux")
+	t.Setenv("PAGER", "more")
+
+	cmd := PagerCommand([]string{"dig", "example.com"})
+	want := []string{"sh", "-c", `"$0" "$@" 2>&1 | more`, "dig", "example.com"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Args = %q, want %q", cmd.Args, want)
+	}
+}
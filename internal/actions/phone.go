@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// phonePattern admits text made only of the characters phone numbers are
+// written with, before the stricter parse
+var phonePattern = regexp.MustCompile(`^(?:\+|00)?[\d \t().\-/]{6,24}$`)
+
+var datePattern = regexp.MustCompile(`^\d{4}[-/.]\d{1,2}[-/.]\d{1,2}$|^\d{1,2}[-/.]\d{1,2}[-/.]\d{4}$`)
+
+// ParsePhone parses s as a valid phone number. Numbers without a country
+// code are read as numbers in region, an ISO country code such as "US";
+// with no region only international numbers are recognised.
+func ParsePhone(s, region string) (*phonenumbers.PhoneNumber, bool) {
+	if !phonePattern.MatchString(s) || datePattern.MatchString(s) {
+		return nil, false
+	}
+	if region == "" {
+		region = "ZZ" // unknown
+	}
+	number, err := phonenumbers.Parse(s, strings.ToUpper(region))
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		return nil, false
+	}
+	return number, true
+}
+
+// phoneActions are the actions offered for a phone number
+func phoneActions(number *phonenumbers.PhoneNumber) []Action {
+	e164 := phonenumbers.Format(number, phonenumbers.E164)
+	return []Action{
+		{Label: "copy as E.164", Copy: e164},
+		{Label: "copy international format", Copy: phonenumbers.Format(number, phonenumbers.INTERNATIONAL)},
+		{Label: "copy national format", Copy: phonenumbers.Format(number, phonenumbers.NATIONAL)},
+		{Label: "copy as tel: link", Copy: phonenumbers.Format(number, phonenumbers.RFC3966)},
+	}
+}
+
+// describePhone summarises a phone number, e.g. "phone number • US •
+// mobile"
+func describePhone(number *phonenumbers.PhoneNumber) string {
+	region := phonenumbers.GetRegionCodeForNumber(number)
+	kind := phoneTypes[phonenumbers.GetNumberType(number)]
+	if kind == "" {
+		return fmt.Sprintf("phone number • %s", region)
+	}
+	return fmt.Sprintf("phone number • %s • %s", region, kind)
+}
+
+var phoneTypes = map[phonenumbers.PhoneNumberType]string{
+	phonenumbers.FIXED_LINE:           "landline",
+	phonenumbers.MOBILE:               "mobile",
+	phonenumbers.FIXED_LINE_OR_MOBILE: "landline or mobile",
+	phonenumbers.TOLL_FREE:            "toll-free",
+	phonenumbers.PREMIUM_RATE:         "premium rate",
+	phonenumbers.SHARED_COST:          "shared cost",
+	phonenumbers.VOIP:                 "VoIP",
+	phonenumbers.PERSONAL_NUMBER:      "personal",
+	phonenumbers.PAGER:                "pager",
+	phonenumbers.UAN:                  "UAN",
+	phonenumbers.VOICEMAIL:            "voicemail",
+}
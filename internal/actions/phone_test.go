@@ -0,0 +1,48 @@
+package actions
+
+import "testing"
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		s      string
+		region string
+		want   string // E.164, "" when not a phone number
+	}{
+		{"+1 415-555-2671", "", "+14155552671"},
+		{"+44 20 7946 0958", "", "+442079460958"},
+		{"0044 20 7946 0958", "GB", "+442079460958"},
+		{"(415) 555-2671", "US", "+14155552671"},
+		{"(415) 555-2671", "", ""}, // no country code or region
+		{"030 901820", "de", "+4930901820"},
+		{"2024-01-15", "DE", ""},
+		{"1234", "US", ""},
+		{"call me maybe", "US", ""},
+		{"10.0.0.1", "US", ""},
+	}
+	for _, tt := range tests {
+		number, ok := ParsePhone(tt.s, tt.region)
+		got := ""
+		if ok {
+			got = phoneActions(number)[0].Copy
+		}
+		if got != tt.want {
+			t.Errorf("ParsePhone(%q, %q) = %q, want %q", tt.s, tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneActions(t *testing.T) {
+	got := For("+1 415 555 2671", Config{})
+	want := []string{"+14155552671", "+1 415-555-2671", "(415) 555-2671", "tel:+1-415-555-2671"}
+	if len(got) != len(want) {
+		t.Fatalf("For() = %+v, want %d actions", got, len(want))
+	}
+	for i, action := range got {
+		if action.Copy != want[i] {
+			t.Errorf("%s = %q, want %q", action.Label, action.Copy, want[i])
+		}
+	}
+	if d := Describe("+1 415 555 2671", Config{}); d != "phone number • US • landline or mobile" {
+		t.Errorf("Describe() = %q", d)
+	}
+}
@@ -0,0 +1,45 @@
+package actions
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// tableActions are the actions offered for CSV or TSV content: copying it
+// as a markdown table, or converted to the other delimiter
+func tableActions(table detect.TableData) []Action {
+	actions := []Action{{Label: "copy as markdown table", Copy: markdownTable(table.Rows)}}
+	if table.Delimiter == '\t' {
+		return append(actions, Action{Label: "copy as CSV", Copy: delimited(table.Rows, ',')})
+	}
+	return append(actions, Action{Label: "copy as TSV", Copy: delimited(table.Rows, '\t')})
+}
+
+// markdownTable renders rows as a markdown table with the first row as its
+// header
+func markdownTable(rows [][]string) string {
+	var b strings.Builder
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = strings.ReplaceAll(strings.TrimSpace(cell), "|", `\|`)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// delimited writes rows separated by delimiter, quoting fields as CSV does
+func delimited(rows [][]string, delimiter rune) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma = delimiter
+	// Writing to a strings.Builder can't fail
+	_ = w.WriteAll(rows)
+	return b.String()
+}
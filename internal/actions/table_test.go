@@ -0,0 +1,22 @@
+package actions
+
+import "testing"
+
+func TestTableActions(t *testing.T) {
+	got := For("name,role\nSmith,a|b\n\"Lee, K\",user", Config{})
+	if len(got) != 2 {
+		t.Fatalf("For() = %+v, want 2 actions", got)
+	}
+	markdown := "| name | role |\n| --- | --- |\n| Smith | a\\|b |\n| Lee, K | user |\n"
+	if got[0].Label != "copy as markdown table" || got[0].Copy != markdown {
+		t.Errorf("%s = %q, want %q", got[0].Label, got[0].Copy, markdown)
+	}
+	if got[1].Label != "copy as TSV" || got[1].Copy != "name\trole\nSmith\ta|b\nLee, K\tuser\n" {
+		t.Errorf("%s = %q", got[1].Label, got[1].Copy)
+	}
+
+	got = For("id\tnote\n1\tsays, hi", Config{})
+	if len(got) != 2 || got[1].Copy != "id,note\n1,\"says, hi\"\n" {
+		t.Errorf("For(tsv) = %+v, want a quoted CSV copy", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package clipformat reads and writes the richer formats a clipboard can
+// hold alongside plain text, such as the HTML of a web page selection or
+// the file list copied in a file manager, by shelling out to the platform
+// clipboard tools (wl-clipboard, xclip, osascript).
+package clipformat
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Formats captured with text entries.
+const (
+	HTML    = "text/html"
+	URIList = "text/uri-list"
+)
+
+// Types lists the formats Read captures.
+var Types = []string{HTML, URIList}
+
+// ErrUnsupported is returned when no clipboard tool able to handle formats
+// other than plain text is available.
+var ErrUnsupported = errors.New("clipboard formats not supported on this system")
+
+// Overridable for tests.
+var (
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+	lookPath = exec.LookPath
+	run      = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		cmd := exec.Command(name, args...)
+		if stdin != nil {
+			// wl-copy and xclip stay behind to serve the clipboard, holding
+			// any output pipe open, so don't capture it
+			cmd.Stdin = bytes.NewReader(stdin)
+			return nil, cmd.Run()
+		}
+		return cmd.Output()
+	}
+)
+
+// Name describes a format for people, e.g. "HTML".
+func Name(mimeType string) string {
+	switch mimeType {
+	case HTML:
+		return "HTML"
+	case URIList:
+		return "file list"
+	}
+	return mimeType
+}
+
+// Read returns the formats in Types the clipboard currently offers, keyed
+// by MIME type; none is not an error.
+func Read() (map[string][]byte, error) {
+	var list []string
+	var get func(mimeType string) []string
+	switch detectTool() {
+	case "wl-paste":
+		list = []string{"wl-paste", "--list-types"}
+		get = func(t string) []string { return []string{"wl-paste", "--no-newline", "--type", t} }
+	case "xclip":
+		list = []string{"xclip", "-selection", "clipboard", "-t", "TARGETS", "-o"}
+		get = func(t string) []string { return []string{"xclip", "-selection", "clipboard", "-t", t, "-o"} }
+	case "osascript":
+		return readDarwin()
+	default:
+		return nil, ErrUnsupported
+	}
+
+	out, err := run(nil, list[0], list[1:]...)
+	if err != nil {
+		// Nothing on the clipboard
+		return nil, nil
+	}
+	offered := strings.Fields(string(out))
+	formats := make(map[string][]byte)
+	for _, t := range Types {
+		if !slices.Contains(offered, t) {
+			continue
+		}
+		args := get(t)
+		if data, err := run(nil, args[0], args[1:]...); err == nil && len(data) > 0 {
+			formats[t] = data
+		}
+	}
+	return formats, nil
+}
+
+// Write places data on the clipboard as mimeType. With wl-clipboard and
+// xclip it replaces the plain text, so only applications accepting
+// mimeType can paste it; on macOS text is kept as the plain-text version.
+func Write(text, mimeType string, data []byte) error {
+	switch detectTool() {
+	case "wl-paste":
+		_, err := run(data, "wl-copy", "--type", mimeType)
+		return err
+	case "xclip":
+		_, err := run(data, "xclip", "-selection", "clipboard", "-t", mimeType, "-i")
+		return err
+	case "osascript":
+		return writeDarwin(text, mimeType, data)
+	default:
+		return ErrUnsupported
+	}
+}
+
+// detectTool picks the clipboard tool to use on this system, or "" if none.
+func detectTool() string {
+	switch goos {
+	case "darwin":
+		return "osascript"
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := lookPath("wl-paste"); err == nil {
+				return "wl-paste"
+			}
+		}
+		if _, err := lookPath("xclip"); err == nil {
+			return "xclip"
+		}
+	}
+	return ""
+}
+
+// readDarwin reads the HTML flavor of the macOS pasteboard, which AppleScript
+// prints as «data HTML3C68746D6C3E...»
+func readDarwin() (map[string][]byte, error) {
+	out, err := run(nil, "osascript", "-e", "the clipboard as «class HTML»")
+	if err != nil {
+		// The pasteboard holds no HTML
+		return nil, nil
+	}
+	encoded := strings.TrimSpace(string(out))
+	encoded = strings.TrimPrefix(encoded, "«data HTML")
+	encoded = strings.TrimSuffix(encoded, "»")
+	data, err := hex.DecodeString(encoded)
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	return map[string][]byte{HTML: data}, nil
+}
+
+// writeDarwin sets the pasteboard to both the HTML and its plain text
+func writeDarwin(text, mimeType string, data []byte) error {
+	if mimeType != HTML {
+		return fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+	script := fmt.Sprintf("set the clipboard to {«class HTML»:«data HTML%X», «class utf8»:«data utf8%X»}", data, []byte(text))
+	_, err := run(nil, "osascript", "-e", script)
+	return err
+}
@@ -0,0 +1,130 @@
+package clipformat
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeSystem replaces the exec hooks with an in-memory clipboard.
+type fakeSystem struct {
+	types   string
+	data    map[string]string
+	written []byte
+	calls   []string
+}
+
+func useFake(t *testing.T, os, wayland string, tools ...string) *fakeSystem {
+	t.Helper()
+	f := &fakeSystem{}
+	origGOOS, origGetenv, origLook, origRun := goos, getenv, lookPath, run
+	goos = os
+	getenv = func(key string) string {
+		if key == "WAYLAND_DISPLAY" {
+			return wayland
+		}
+		return ""
+	}
+	lookPath = func(name string) (string, error) {
+		for _, tool := range tools {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		f.calls = append(f.calls, call)
+		switch {
+		case strings.Contains(call, "--list-types"), strings.Contains(call, "TARGETS"):
+			return []byte(f.types), nil
+		case stdin != nil:
+			f.written = stdin
+			return nil, nil
+		case strings.Contains(call, "set the clipboard"):
+			return nil, nil
+		}
+		for mimeType, data := range f.data {
+			if strings.Contains(call, mimeType) {
+				return []byte(data), nil
+			}
+		}
+		return nil, errors.New("exit status 1")
+	}
+	t.Cleanup(func() { goos, getenv, lookPath, run = origGOOS, origGetenv, origLook, origRun })
+	return f
+}
+
+func TestReadWayland(t *testing.T) {
+	f := useFake(t, "linux", "wayland-0", "wl-paste")
+	f.types = "text/html\ntext/plain;charset=utf-8\nUTF8_STRING\n"
+	f.data = map[string]string{HTML: "<b>hi</b>"}
+
+	formats, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(formats) != 1 || string(formats[HTML]) != "<b>hi</b>" {
+		t.Errorf("Read() = %q, want only the HTML", formats)
+	}
+	if f.calls[1] != "wl-paste --no-newline --type text/html" {
+		t.Errorf("read with %q", f.calls[1])
+	}
+}
+
+func TestReadXclipURIList(t *testing.T) {
+	f := useFake(t, "linux", "", "xclip")
+	f.types = "TARGETS\ntext/uri-list\nUTF8_STRING\n"
+	f.data = map[string]string{URIList: "file:///tmp/report.pdf\r\n"}
+
+	formats, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(formats[URIList]) != "file:///tmp/report.pdf\r\n" {
+		t.Errorf("Read() = %q, want the URI list", formats)
+	}
+}
+
+func TestReadDarwin(t *testing.T) {
+	f := useFake(t, "darwin", "")
+	f.data = map[string]string{"«class HTML»": "«data HTML3C623E68693C2F623E»\n"}
+
+	formats, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(formats[HTML]) != "<b>hi</b>" {
+		t.Errorf("Read() = %q, want the decoded HTML", formats)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	f := useFake(t, "linux", "", "xclip")
+	if err := Write("hi", HTML, []byte("<b>hi</b>")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if string(f.written) != "<b>hi</b>" || f.calls[0] != "xclip -selection clipboard -t text/html -i" {
+		t.Errorf("wrote %q with %q", f.written, f.calls[0])
+	}
+
+	f = useFake(t, "darwin", "")
+	if err := Write("hi", HTML, []byte("<b>hi</b>")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(f.calls[0], "«data HTML3C623E68693C2F623E»") || !strings.Contains(f.calls[0], "«data utf86869»") {
+		t.Errorf("ran %q, want both the HTML and the text", f.calls[0])
+	}
+	if err := Write("/tmp/a", URIList, []byte("file:///tmp/a")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Write(uri-list) on macOS = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	useFake(t, "windows", "")
+	if _, err := Read(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Read() = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package clipimage reads and writes image data on the system clipboard by
+// shelling out to the platform clipboard tools (wl-clipboard, xclip, osascript),
+// since atotto/clipboard only handles text.
+package clipimage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrNoImage is returned by Read when the clipboard does not hold an image.
+var ErrNoImage = errors.New("no image on clipboard")
+
+// ErrUnsupported is returned when no image-capable clipboard tool is available.
+var ErrUnsupported = errors.New("image clipboard not supported on this system")
+
+// preferredTypes are tried in order when the clipboard offers several image formats.
+var preferredTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "image/bmp"}
+
+// Overridable for tests.
+var (
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+	lookPath = exec.LookPath
+	run      = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		cmd := exec.Command(name, args...)
+		if stdin != nil {
+			cmd.Stdin = bytes.NewReader(stdin)
+		}
+		return cmd.Output()
+	}
+)
+
+// Read returns the image currently on the clipboard and its MIME type.
+func Read() ([]byte, string, error) {
+	switch tool := detectTool(); tool {
+	case "wl-paste":
+		types, err := run(nil, "wl-paste", "--list-types")
+		if err != nil {
+			return nil, "", ErrNoImage
+		}
+		mimeType := pickImageType(strings.Fields(string(types)))
+		if mimeType == "" {
+			return nil, "", ErrNoImage
+		}
+		data, err := run(nil, "wl-paste", "--no-newline", "--type", mimeType)
+		return nonEmpty(data, mimeType, err)
+	case "xclip":
+		types, err := run(nil, "xclip", "-selection", "clipboard", "-t", "TARGETS", "-o")
+		if err != nil {
+			return nil, "", ErrNoImage
+		}
+		mimeType := pickImageType(strings.Fields(string(types)))
+		if mimeType == "" {
+			return nil, "", ErrNoImage
+		}
+		data, err := run(nil, "xclip", "-selection", "clipboard", "-t", mimeType, "-o")
+		return nonEmpty(data, mimeType, err)
+	case "osascript":
+		return readDarwin()
+	default:
+		return nil, "", ErrUnsupported
+	}
+}
+
+// Write places data of the given MIME type on the clipboard.
+func Write(data []byte, mimeType string) error {
+	switch tool := detectTool(); tool {
+	case "wl-paste":
+		_, err := run(data, "wl-copy", "--type", mimeType)
+		return err
+	case "xclip":
+		_, err := run(data, "xclip", "-selection", "clipboard", "-t", mimeType, "-i")
+		return err
+	case "osascript":
+		return writeDarwin(data, mimeType)
+	default:
+		return ErrUnsupported
+	}
+}
+
+// detectTool picks the clipboard tool to use on this system, or "" if none.
+func detectTool() string {
+	switch goos {
+	case "darwin":
+		return "osascript"
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := lookPath("wl-paste"); err == nil {
+				return "wl-paste"
+			}
+		}
+		if _, err := lookPath("xclip"); err == nil {
+			return "xclip"
+		}
+	}
+	return ""
+}
+
+// pickImageType returns the most preferred image MIME type in offered, or "".
+func pickImageType(offered []string) string {
+	for _, want := range preferredTypes {
+		for _, t := range offered {
+			if t == want {
+				return t
+			}
+		}
+	}
+	for _, t := range offered {
+		if strings.HasPrefix(t, "image/") {
+			return t
+		}
+	}
+	return ""
+}
+
+func nonEmpty(data []byte, mimeType string, err error) ([]byte, string, error) {
+	if err != nil || len(data) == 0 {
+		return nil, "", ErrNoImage
+	}
+	return data, mimeType, nil
+}
+
+// darwinClasses maps MIME types to the AppleScript clipboard classes.
+var darwinClasses = map[string]string{
+	"image/png":  "«class PNGf»",
+	"image/jpeg": "«class JPEG»",
+	"image/gif":  "«class GIFf»",
+}
+
+func readDarwin() ([]byte, string, error) {
+	tmp, err := os.MkdirTemp("", "clippy-image-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	path := filepath.Join(tmp, "clipboard.png")
+	script := fmt.Sprintf(`set img to (the clipboard as «class PNGf»)
+set f to open for access POSIX file %q with write permission
+write img to f
+close access f`, path)
+	if _, err := run(nil, "osascript", "-e", script); err != nil {
+		return nil, "", ErrNoImage
+	}
+	data, err := os.ReadFile(path)
+	return nonEmpty(data, "image/png", err)
+}
+
+func writeDarwin(data []byte, mimeType string) error {
+	class, ok := darwinClasses[mimeType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+	tmp, err := os.MkdirTemp("", "clippy-image-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	path := filepath.Join(tmp, "clipboard")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`set the clipboard to (read (POSIX file %q) as %s)`, path, class)
+	_, err = run(nil, "osascript", "-e", script)
+	return err
+}
@@ -0,0 +1,121 @@
+package clipimage
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeSystem replaces the exec hooks with an in-memory clipboard.
+type fakeSystem struct {
+	types   string
+	data    []byte
+	written []byte
+	calls   []string
+}
+
+func useFake(t *testing.T, os, wayland string, tools ...string) *fakeSystem {
+	t.Helper()
+	f := &fakeSystem{}
+	origGOOS, origGetenv, origLook, origRun := goos, getenv, lookPath, run
+	goos = os
+	getenv = func(key string) string {
+		if key == "WAYLAND_DISPLAY" {
+			return wayland
+		}
+		return ""
+	}
+	lookPath = func(name string) (string, error) {
+		for _, tool := range tools {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		f.calls = append(f.calls, call)
+		switch {
+		case strings.Contains(call, "--list-types"), strings.Contains(call, "TARGETS"):
+			return []byte(f.types), nil
+		case stdin != nil:
+			f.written = stdin
+			return nil, nil
+		default:
+			return f.data, nil
+		}
+	}
+	t.Cleanup(func() { goos, getenv, lookPath, run = origGOOS, origGetenv, origLook, origRun })
+	return f
+}
+
+func TestPickImageType(t *testing.T) {
+	tests := []struct {
+		offered []string
+		want    string
+	}{
+		{[]string{"text/plain", "image/jpeg", "image/png"}, "image/png"},
+		{[]string{"image/jpeg"}, "image/jpeg"},
+		{[]string{"image/x-custom"}, "image/x-custom"},
+		{[]string{"text/plain", "UTF8_STRING"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := pickImageType(tt.offered); got != tt.want {
+			t.Errorf("pickImageType(%v) = %q, want %q", tt.offered, got, tt.want)
+		}
+	}
+}
+
+func TestReadWayland(t *testing.T) {
+	f := useFake(t, "linux", "wayland-0", "wl-paste", "xclip")
+	f.types = "text/plain\nimage/png\n"
+	f.data = []byte{0x89, 'P', 'N', 'G'}
+
+	data, mimeType, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if mimeType != "image/png" || string(data) != string(f.data) {
+		t.Errorf("Read = %q, %q", data, mimeType)
+	}
+	if !strings.HasPrefix(f.calls[0], "wl-paste") {
+		t.Errorf("expected wl-paste to be used, calls: %v", f.calls)
+	}
+}
+
+func TestReadX11NoImage(t *testing.T) {
+	f := useFake(t, "linux", "", "xclip")
+	f.types = "TARGETS UTF8_STRING text/plain"
+
+	if _, _, err := Read(); !errors.Is(err, ErrNoImage) {
+		t.Errorf("Read = %v, want ErrNoImage", err)
+	}
+}
+
+func TestReadUnsupported(t *testing.T) {
+	useFake(t, "linux", "")
+
+	if _, _, err := Read(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Read = %v, want ErrUnsupported", err)
+	}
+	if err := Write([]byte("x"), "image/png"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Write = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestWriteX11(t *testing.T) {
+	f := useFake(t, "linux", "", "xclip")
+
+	if err := Write([]byte("img"), "image/png"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if string(f.written) != "img" {
+		t.Errorf("written = %q", f.written)
+	}
+	if f.calls[0] != "xclip -selection clipboard -t image/png -i" {
+		t.Errorf("unexpected call %q", f.calls[0])
+	}
+}
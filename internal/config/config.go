@@ -0,0 +1,407 @@
+// Package config loads user settings from ~/.config/clippy/config.toml.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	DirName  = "clippy"
+	FileName = "config.toml"
+)
+
+// Config holds all user-configurable settings. Zero values are never used
+// directly; Load starts from Default and overlays the file's contents.
+type Config struct {
+	History   HistoryConfig   `toml:"history"`
+	Search    SearchConfig    `toml:"search"`
+	Tmux      TmuxConfig      `toml:"tmux"`
+	Expiry    ExpiryConfig    `toml:"expiry"`
+	Archive   ArchiveConfig   `toml:"archive"`
+	Compact   CompactConfig   `toml:"compact"`
+	Clipboard ClipboardConfig `toml:"clipboard"`
+	UI        UIConfig        `toml:"ui"`
+	Privacy   PrivacyConfig   `toml:"privacy"`
+	Actions   ActionsConfig   `toml:"actions"`
+	CLI       CLIConfig       `toml:"cli"`
+	Hooks     []HookConfig    `toml:"hooks"`
+}
+
+// HistoryConfig controls how captured items are recorded.
+type HistoryConfig struct {
+	// BumpDuplicates moves re-copied items to the most recent position and
+	// increments their copy count instead of ignoring them.
+	BumpDuplicates bool `toml:"bump_duplicates"`
+	// OverflowBytes is the size above which text is stored in a file under
+	// the config directory, keeping only a preview in the database. 0
+	// stores everything in the database.
+	OverflowBytes int `toml:"overflow_bytes"`
+	// MaxCaptureBytes is the largest content recorded, so an accidental
+	// copy of a huge file can't wedge the TUI and database. 0 records
+	// content of any size.
+	MaxCaptureBytes int `toml:"max_capture_bytes"`
+	// Oversized is what happens to text over MaxCaptureBytes: "skip" (the
+	// default) leaves it out, "truncate" keeps its start with a note that
+	// it was cut. Oversized images are always skipped.
+	Oversized string `toml:"oversized"`
+}
+
+// SearchConfig controls the TUI search.
+type SearchConfig struct {
+	// Algorithm selects the matcher: "fuzzy", "smith-waterman", "trigram",
+	// "library" or "exact" (see search.NewMatcher).
+	Algorithm string `toml:"algorithm"`
+	// DebounceMS is how long live search waits after the last keystroke
+	// before filtering a large history, in milliseconds. Small histories,
+	// and any with 0, filter on every keystroke; values are clamped to
+	// MaxDebounceMS.
+	DebounceMS int `toml:"debounce_ms"`
+}
+
+// TmuxConfig controls importing tmux paste buffers.
+type TmuxConfig struct {
+	// Enabled imports new tmux paste buffers into history, capturing text
+	// yanked in tmux copy mode that never reaches the system clipboard.
+	Enabled bool `toml:"enabled"`
+}
+
+// ExpiryConfig controls automatic expiry of captured items.
+type ExpiryConfig struct {
+	// Rules give newly captured items matching a pattern a time-to-live.
+	Rules []ExpiryRule `toml:"rules"`
+}
+
+// ExpiryRule expires items whose content matches the regular expression
+// Pattern after TTL, written as a duration string such as "5m".
+type ExpiryRule struct {
+	Pattern string        `toml:"pattern"`
+	TTL     time.Duration `toml:"ttl"`
+}
+
+// HookConfig runs Command, a program and its arguments, with a text
+// entry's content on stdin whenever Event happens: "capture" when a new
+// entry is recorded, or "copy" when one is copied back from history. Only
+// entries whose content matches the regular expression Pattern are passed
+// on; an empty Pattern matches all.
+type HookConfig struct {
+	Event   string   `toml:"event"`
+	Command []string `toml:"command"`
+	Pattern string   `toml:"pattern"`
+}
+
+// ArchiveConfig controls moving old entries to the archive database.
+type ArchiveConfig struct {
+	// After archives unpinned entries not copied for this long, written as
+	// a duration string such as "2160h". Zero disables automatic archiving.
+	After time.Duration `toml:"after"`
+}
+
+// CompactConfig controls collapsing old entries into daily digests.
+type CompactConfig struct {
+	// AfterMonths compacts unpinned entries not copied for this many
+	// months into one digest per day, keeping their counts, types and
+	// sizes but not their content. Zero disables automatic compaction.
+	AfterMonths int `toml:"after_months"`
+	// Top keeps previews of this many of each day's most copied entries
+	// in its digest. Zero keeps none.
+	Top int `toml:"top"`
+}
+
+// ClipboardConfig selects how the system clipboard is accessed.
+type ClipboardConfig struct {
+	// Backend is "auto" (the best platform clipboard found; over SSH without
+	// a forwarded display, copies go to the local terminal with OSC 52;
+	// headless when none is found), "none" (always headless: history is only
+	// fed via the CLI), or one of "wl-clipboard", "xclip", "xsel", "pbcopy",
+	// "windows", "wsl", "atotto" and "osc52" (always copy through the
+	// terminal) to force that backend.
+	Backend string `toml:"backend"`
+	// Watch reads the clipboard when the platform reports a change
+	// (wl-paste --watch, clipnotify or the Windows sequence number)
+	// instead of polling it. Polling is used when no notification exists.
+	Watch bool `toml:"watch"`
+	// Interval is how often the clipboard is read when it isn't watched
+	// for changes. Zero reads it every 500ms.
+	Interval time.Duration `toml:"interval"`
+	// Primary also captures the X11/Wayland primary selection (highlighted
+	// text), which can then be pasted back with middle-click.
+	Primary bool `toml:"primary"`
+	// PrimaryInterval is how often the primary selection is read, on its
+	// own schedule apart from the clipboard. Zero reads it every 500ms.
+	PrimaryInterval time.Duration `toml:"primary_interval"`
+	// Sources are further selections or registers captured alongside the
+	// clipboard, each read by a command at its own interval.
+	Sources []SourceConfig `toml:"sources"`
+	// DebounceMS is how long new clipboard content must stay unchanged
+	// before it is recorded, in milliseconds, so a tool rewriting the
+	// clipboard rapidly (e.g. progress text) leaves only its final value.
+	// 0 records every change; values are clamped to MaxCaptureDebounceMS.
+	DebounceMS int `toml:"debounce_ms"`
+	// RestoreOnStart makes the daemon put the most recently copied entry
+	// back on the clipboard when it starts and finds it empty, as Wayland
+	// clipboards are after a reboot. Secrets are not restored.
+	RestoreOnStart bool `toml:"restore_on_start"`
+	// Persist makes the daemon take over serving each text copy on Wayland
+	// and X11, where the clipboard is emptied when the application copied
+	// from exits, so it can still be pasted afterwards.
+	Persist bool `toml:"persist"`
+}
+
+// SourceConfig captures the text Command, a program and its arguments,
+// prints whenever it changes, reading it every Interval (every second when
+// zero). Entries are labelled with Name, e.g. "secondary", in the preview.
+// Enabled switches the source off when false.
+type SourceConfig struct {
+	Name     string        `toml:"name"`
+	Command  []string      `toml:"command"`
+	Interval time.Duration `toml:"interval"`
+	Enabled  *bool         `toml:"enabled"`
+}
+
+// On reports whether the source is captured: unless Enabled is false.
+func (s SourceConfig) On() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// PrivacyConfig keeps secrets out of history.
+type PrivacyConfig struct {
+	// RespectHints skips content that password managers mark as concealed
+	// (x-kde-passwordManagerHint, org.nspasteboard.ConcealedType).
+	RespectHints bool `toml:"respect_hints"`
+	// ExcludedApps skips anything copied while one of these applications
+	// is focused, matched case-insensitively on its name or window class.
+	ExcludedApps []string `toml:"excluded_apps"`
+	// SkipSensitive refuses to record content that looks like a secret (AWS
+	// keys, JWTs, card numbers, private keys). Otherwise such entries are
+	// recorded but masked in the table.
+	SkipSensitive bool `toml:"skip_sensitive"`
+	// RecordSourceApp stores the name of the application focused when an
+	// entry was copied, for filtering with "app:" in search.
+	RecordSourceApp bool `toml:"record_source_app"`
+	// ClearSensitiveAfter clears the clipboard this long after an entry
+	// that looks like a secret is copied from the TUI, unless something
+	// else was copied meanwhile. 0 leaves it on the clipboard.
+	ClearSensitiveAfter time.Duration `toml:"clear_sensitive_after"`
+	// Policies limit and tag what is recorded from particular applications.
+	// They need the source app, which is detected for them even when
+	// RecordSourceApp is off.
+	Policies []AppPolicy `toml:"policies"`
+}
+
+// AppPolicy applies to entries copied from App, matched case-insensitively
+// on its name: entries over MaxBytes (0 for no limit) or of a type not in
+// Types (empty for any, e.g. ["url", "text"]) are not recorded, and the
+// rest are given Tags.
+type AppPolicy struct {
+	App      string   `toml:"app"`
+	MaxBytes int      `toml:"max_bytes,omitempty"`
+	Types    []string `toml:"types,omitempty"`
+	Tags     []string `toml:"tags,omitempty"`
+}
+
+// ActionsConfig holds the URL templates opened by quick actions and the
+// user's own actions.
+type ActionsConfig struct {
+	// CommitURL is the web page of a commit, with {sha} standing for the
+	// SHA, e.g. "https://github.com/owner/repo/commit/{sha}".
+	CommitURL string `toml:"commit_url"`
+	// BranchURL is the web page of a branch, with {branch} standing for its
+	// name, e.g. "https://github.com/owner/repo/tree/{branch}".
+	BranchURL string `toml:"branch_url"`
+	// IssueURL is the page of a GitHub-style issue, with {number} standing
+	// for the number in "#5678".
+	IssueURL string `toml:"issue_url"`
+	// JiraURL is the page of a Jira-style issue, with {key} standing for a
+	// key such as "PROJ-1234".
+	JiraURL string `toml:"jira_url"`
+	// PhoneRegion is the ISO country code, e.g. "US", assumed for phone
+	// numbers copied without a country code.
+	PhoneRegion string `toml:"phone_region"`
+	// Custom are actions of the user's own, listed in the action menu of
+	// the entries they match.
+	Custom []CustomActionConfig `toml:"custom"`
+}
+
+// CustomActionConfig is an action named Name offered for text entries
+// matching the regular expression Pattern (all of them when empty). It
+// runs Command, a program and its arguments, with the entry on stdin and
+// its output shown in a pager; in the arguments {content} stands for the
+// trimmed entry and {match} for the text Pattern matched.
+type CustomActionConfig struct {
+	Name    string   `toml:"name"`
+	Pattern string   `toml:"pattern"`
+	Command []string `toml:"command"`
+}
+
+// UIConfig controls the look of the TUI.
+type UIConfig struct {
+	// ZebraStripes shades every other table row.
+	ZebraStripes bool `toml:"zebra_stripes"`
+	// RawMarkdown previews markdown entries as their source instead of
+	// rendering them; R switches between the two.
+	RawMarkdown bool `toml:"raw_markdown"`
+	// SyntaxHighlight colors code and JSON entries in the preview, in the
+	// language detected on capture or guessed from the code; S switches it.
+	SyntaxHighlight bool `toml:"syntax_highlight"`
+	// RelativeTime shows when entries were copied as "2m ago" or
+	// "yesterday 14:03" instead of the full timestamp; T switches it.
+	RelativeTime bool `toml:"relative_time"`
+	// HideContent starts the TUI with entries' content masked, for
+	// screen sharing; h switches it.
+	HideContent bool `toml:"hide_content"`
+	// CursorIndicator marks the selected row without relying on color:
+	// "bar" (bold with a "▌" in the margin) or "reverse" (reverse video).
+	// Empty uses color alone.
+	CursorIndicator string `toml:"cursor_indicator"`
+	// ShowIDs adds an ID column to the table, showing the short ID each
+	// entry keeps however the table is sorted, for clippy copy <id>.
+	ShowIDs bool `toml:"show_ids"`
+	// InstantDelete deletes unpinned entries on d without asking first.
+	InstantDelete bool `toml:"instant_delete"`
+	// QuickSelectQuit quits the TUI after 1-9 copy a visible row, as
+	// launcher-style clipboard pickers do.
+	QuickSelectQuit bool `toml:"quick_select_quit"`
+	// QuitOnCopy quits the TUI whenever an entry is copied, as
+	// clippy --once does, so it can be bound to a hotkey as a picker.
+	QuitOnCopy bool `toml:"quit_on_copy"`
+	// Mouse lets the table be clicked and scrolled with the mouse, which
+	// stops the terminal selecting text itself while clippy runs.
+	Mouse bool `toml:"mouse"`
+}
+
+// CLIConfig controls the command line.
+type CLIConfig struct {
+	// Confirm is how commands that delete history, clippy clear, purge and
+	// uninstall-data, are confirmed: "type" (--yes, or typing DELETE at a
+	// prompt), "flag" (only --yes; never prompts, for scripts and other
+	// automation) or "off" (no confirmation).
+	Confirm string `toml:"confirm"`
+}
+
+// Clipboard backends.
+const (
+	BackendAuto = "auto"
+	BackendNone = "none"
+)
+
+// ReservedSources are the names of the built-in capture sources, which
+// [[clipboard.sources]] can't use.
+var ReservedSources = []string{"clipboard", "primary", "tmux"}
+
+// Values of [history] oversized.
+const (
+	OversizedSkip     = "skip"
+	OversizedTruncate = "truncate"
+)
+
+// Values of [cli] confirm.
+const (
+	ConfirmType = "type"
+	ConfirmFlag = "flag"
+	ConfirmOff  = "off"
+)
+
+// MaxDebounceMS is the longest search debounce accepted from the config.
+const MaxDebounceMS = 1000
+
+// Debounce returns the search debounce as a duration, clamped to
+// [0, MaxDebounceMS].
+func (s SearchConfig) Debounce() time.Duration {
+	return time.Duration(min(max(s.DebounceMS, 0), MaxDebounceMS)) * time.Millisecond
+}
+
+// MaxCaptureDebounceMS is the longest clipboard debounce accepted from the
+// config.
+const MaxCaptureDebounceMS = 10000
+
+// Debounce returns the clipboard capture debounce as a duration, clamped
+// to [0, MaxCaptureDebounceMS].
+func (c ClipboardConfig) Debounce() time.Duration {
+	return time.Duration(min(max(c.DebounceMS, 0), MaxCaptureDebounceMS)) * time.Millisecond
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		History: HistoryConfig{
+			BumpDuplicates:  false,
+			OverflowBytes:   1 << 20,
+			MaxCaptureBytes: 64 << 20,
+			Oversized:       OversizedSkip,
+		},
+		Search: SearchConfig{
+			Algorithm:  "fuzzy",
+			DebounceMS: 100,
+		},
+		Clipboard: ClipboardConfig{
+			Backend: BackendAuto,
+			Watch:   true,
+		},
+		Tmux: TmuxConfig{
+			Enabled: false,
+		},
+		Privacy: PrivacyConfig{
+			RespectHints:        true,
+			RecordSourceApp:     true,
+			ClearSensitiveAfter: 30 * time.Second,
+		},
+		UI: UIConfig{
+			SyntaxHighlight: true,
+			RelativeTime:    true,
+			Mouse:           true,
+		},
+		CLI: CLIConfig{
+			Confirm: ConfirmType,
+		},
+	}
+}
+
+// Dir returns the clippy config directory: $XDG_CONFIG_HOME/clippy, or
+// ~/.config/clippy when XDG_CONFIG_HOME is unset.
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, DirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", DirName), nil
+}
+
+// Path returns the location of the config file.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the config file, returning Default if it does not exist.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads the config at path, returning Default if it does not exist.
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Default(), nil
+		}
+		return Default(), fmt.Errorf("error reading config %s: %w", path, err)
+	}
+	return cfg, nil
+}
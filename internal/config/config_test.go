@@ -0,0 +1,289 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults for missing file, got %+v", cfg)
+	}
+}
+
+func TestLoadFileOverridesDefaults(t *testing.T) {
+	path := writeConfig(t, "[history]\nbump_duplicates = true\n\n[search]\nalgorithm = \"trigram\"\n\n[tmux]\nenabled = true\n\n[archive]\nafter = \"720h\"\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !cfg.History.BumpDuplicates {
+		t.Error("expected bump_duplicates to be enabled")
+	}
+	if cfg.Search.Algorithm != "trigram" {
+		t.Errorf("search.algorithm = %q, want %q", cfg.Search.Algorithm, "trigram")
+	}
+	if !cfg.Tmux.Enabled {
+		t.Error("expected tmux import to be enabled")
+	}
+	if cfg.Archive.After != 720*time.Hour {
+		t.Errorf("archive.after = %v, want 720h", cfg.Archive.After)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	path := writeConfig(t, "[history\nbump_duplicates = ")
+
+	cfg, err := LoadFile(path)
+	if err == nil {
+		t.Fatal("expected error for malformed config")
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults on error, got %+v", cfg)
+	}
+}
+
+func TestDirUsesXDGConfigHome(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	dir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir: %v", err)
+	}
+	if dir != filepath.Join("/tmp/xdg", DirName) {
+		t.Errorf("Dir = %q", dir)
+	}
+
+	path, err := Path()
+	if err != nil {
+		t.Fatalf("Path: %v", err)
+	}
+	if path != filepath.Join("/tmp/xdg", DirName, FileName) {
+		t.Errorf("Path = %q", path)
+	}
+}
+
+func TestDirDefaultsToHome(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/tmp/home")
+	dir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir: %v", err)
+	}
+	if dir != filepath.Join("/tmp/home", ".config", DirName) {
+		t.Errorf("Dir = %q", dir)
+	}
+}
+
+func TestLoadFilePartialKeepsDefaults(t *testing.T) {
+	path := writeConfig(t, "[history]\nbump_duplicates = true\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Search.Algorithm != Default().Search.Algorithm {
+		t.Errorf("expected default algorithm, got %q", cfg.Search.Algorithm)
+	}
+}
+
+func TestSearchDebounce(t *testing.T) {
+	tests := []struct {
+		ms   int
+		want time.Duration
+	}{
+		{0, 0},
+		{150, 150 * time.Millisecond},
+		{-5, 0},
+		{5000, MaxDebounceMS * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := (SearchConfig{DebounceMS: tt.ms}).Debounce(); got != tt.want {
+			t.Errorf("Debounce() with %dms = %v, want %v", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestClipboardDebounce(t *testing.T) {
+	tests := []struct {
+		ms   int
+		want time.Duration
+	}{
+		{0, 0},
+		{750, 750 * time.Millisecond},
+		{-5, 0},
+		{60000, MaxCaptureDebounceMS * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := (ClipboardConfig{DebounceMS: tt.ms}).Debounce(); got != tt.want {
+			t.Errorf("Debounce() with %dms = %v, want %v", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFileDebounce(t *testing.T) {
+	cfg, err := LoadFile(writeConfig(t, "[search]\ndebounce_ms = 250\n"))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if got := cfg.Search.Debounce(); got != 250*time.Millisecond {
+		t.Errorf("Debounce() = %v, want 250ms", got)
+	}
+	if cfg.Search.Algorithm != "fuzzy" {
+		t.Errorf("search.algorithm = %q, want default %q", cfg.Search.Algorithm, "fuzzy")
+	}
+}
+
+func TestLoadFileCaptureLimit(t *testing.T) {
+	if d := Default().History; d.MaxCaptureBytes != 64<<20 || d.Oversized != OversizedSkip {
+		t.Errorf("default capture limit = %d, %q; want 64 MiB, skip", d.MaxCaptureBytes, d.Oversized)
+	}
+	path := writeConfig(t, "[history]\nmax_capture_bytes = 1000\noversized = \"truncate\"\n")
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.History.MaxCaptureBytes != 1000 || cfg.History.Oversized != OversizedTruncate {
+		t.Errorf("capture limit = %d, %q; want 1000, truncate", cfg.History.MaxCaptureBytes, cfg.History.Oversized)
+	}
+}
+
+func TestLoadFileConfirm(t *testing.T) {
+	if d := Default().CLI; d.Confirm != ConfirmType {
+		t.Errorf("default confirm = %q, want %q", d.Confirm, ConfirmType)
+	}
+	path := writeConfig(t, "[cli]\nconfirm = \"flag\"\n")
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.CLI.Confirm != ConfirmFlag {
+		t.Errorf("confirm = %q, want %q", cfg.CLI.Confirm, ConfirmFlag)
+	}
+}
+
+func TestLoadFileCompact(t *testing.T) {
+	path := writeConfig(t, "[compact]\nafter_months = 6\ntop = 3\n")
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if want := (CompactConfig{AfterMonths: 6, Top: 3}); cfg.Compact != want {
+		t.Errorf("compact = %+v, want %+v", cfg.Compact, want)
+	}
+	if err := Check(writeConfig(t, "[compact]\nafter_months = -1\n"), testChoices); err == nil ||
+		!strings.Contains(err.Error(), "compact.after_months") {
+		t.Errorf("Check with negative after_months: %v", err)
+	}
+}
+
+func TestLoadFileSources(t *testing.T) {
+	path := writeConfig(t, `[clipboard]
+interval = "2s"
+primary = true
+primary_interval = "250ms"
+
+[[clipboard.sources]]
+name = "secondary"
+command = ["xclip", "-selection", "secondary", "-o"]
+interval = "3s"
+
+[[clipboard.sources]]
+name = "remote"
+command = ["ssh", "box", "cat", "/tmp/clip"]
+enabled = false
+`)
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Clipboard.Interval != 2*time.Second || cfg.Clipboard.PrimaryInterval != 250*time.Millisecond {
+		t.Errorf("intervals = %v, %v; want 2s and 250ms", cfg.Clipboard.Interval, cfg.Clipboard.PrimaryInterval)
+	}
+	sources := cfg.Clipboard.Sources
+	if len(sources) != 2 || sources[0].Name != "secondary" || sources[0].Interval != 3*time.Second ||
+		!slices.Equal(sources[0].Command, []string{"xclip", "-selection", "secondary", "-o"}) {
+		t.Fatalf("sources = %+v", sources)
+	}
+	if !sources[0].On() || sources[1].On() {
+		t.Errorf("On() = %v, %v; want sources on unless enabled = false", sources[0].On(), sources[1].On())
+	}
+}
+
+func TestLoadFileExpiryRules(t *testing.T) {
+	path := writeConfig(t, "[[expiry.rules]]\npattern = '^\\d{6}$'\nttl = \"5m\"\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := []ExpiryRule{{Pattern: `^\d{6}$`, TTL: 5 * time.Minute}}
+	if !reflect.DeepEqual(cfg.Expiry.Rules, want) {
+		t.Errorf("expiry.rules = %+v, want %+v", cfg.Expiry.Rules, want)
+	}
+}
+
+func TestLoadFilePrivacy(t *testing.T) {
+	path := writeConfig(t, "[privacy]\nrespect_hints = false\nexcluded_apps = [\"KeePassXC\", \"Bitwarden\"]\nskip_sensitive = true\nrecord_source_app = false\nclear_sensitive_after = \"10s\"\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := PrivacyConfig{RespectHints: false, ExcludedApps: []string{"KeePassXC", "Bitwarden"}, SkipSensitive: true, ClearSensitiveAfter: 10 * time.Second}
+	if !reflect.DeepEqual(cfg.Privacy, want) {
+		t.Errorf("privacy = %+v, want %+v", cfg.Privacy, want)
+	}
+}
+
+func TestLoadFileActions(t *testing.T) {
+	path := writeConfig(t, "[actions]\ncommit_url = \"https://github.com/o/r/commit/{sha}\"\njira_url = \"https://x.atlassian.net/browse/{key}\"\nphone_region = \"GB\"\n\n[[actions.custom]]\nname = \"ping this host\"\npattern = '^[a-z.]+$'\ncommand = [\"ping\", \"-c\", \"3\", \"{match}\"]\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := ActionsConfig{
+		CommitURL:   "https://github.com/o/r/commit/{sha}",
+		JiraURL:     "https://x.atlassian.net/browse/{key}",
+		PhoneRegion: "GB",
+		Custom:      []CustomActionConfig{{Name: "ping this host", Pattern: "^[a-z.]+$", Command: []string{"ping", "-c", "3", "{match}"}}},
+	}
+	if !reflect.DeepEqual(cfg.Actions, want) {
+		t.Errorf("actions = %+v, want %+v", cfg.Actions, want)
+	}
+}
+
+func TestLoadFileHooks(t *testing.T) {
+	path := writeConfig(t, "[[hooks]]\nevent = \"capture\"\ncommand = [\"yt-dlp\", \"-a\", \"-\"]\npattern = '^https://'\n\n[[hooks]]\nevent = \"copy\"\ncommand = [\"notes-log\"]\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := []HookConfig{
+		{Event: "capture", Command: []string{"yt-dlp", "-a", "-"}, Pattern: "^https://"},
+		{Event: "copy", Command: []string{"notes-log"}},
+	}
+	if !reflect.DeepEqual(cfg.Hooks, want) {
+		t.Errorf("hooks = %+v, want %+v", cfg.Hooks, want)
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SaveRules replaces the privacy excluded_apps and policies in the config
+// file at path, creating it if needed. Only the lines holding them are
+// rewritten; the rest of the file, comments included, is kept as it is.
+func SaveRules(path string, excludedApps []string, policies []AppPolicy) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+	updated, err := replaceRules(string(data), excludedApps, policies)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	// The file is edited line by line rather than parsed, so make sure it
+	// reads back with the new rules before replacing it
+	var cfg Config
+	if _, err := toml.Decode(updated, &cfg); err != nil ||
+		!slices.Equal(cfg.Privacy.ExcludedApps, excludedApps) || !samePolicies(cfg.Privacy.Policies, policies) {
+		return fmt.Errorf("can't update the rules in %s; edit [privacy] there by hand", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+	// Write beside the config and rename, so it is never left half written
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.WriteString(updated); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	return nil
+}
+
+// replaceRules returns the TOML document data with its privacy
+// excluded_apps and policies replaced. The new excluded_apps goes where
+// the old one was, or straight after the [privacy] header, and the
+// policies where the first [[privacy.policies]] was, or after the rest of
+// [privacy]. Comments stay where they are.
+func replaceRules(data string, excludedApps []string, policies []AppPolicy) (string, error) {
+	apps, err := encodeRules(struct {
+		ExcludedApps []string `toml:"excluded_apps"`
+	}{append([]string{}, excludedApps...)})
+	if err != nil {
+		return "", err
+	}
+	var blocks []string
+	for _, p := range policies {
+		block, err := encodeRules(p)
+		if err != nil {
+			return "", err
+		}
+		blocks = append(blocks, "[[privacy.policies]]\n"+block)
+	}
+	policyLines := strings.Join(blocks, "\n")
+
+	if data != "" && !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	lines := strings.SplitAfter(data, "\n")
+	lines = lines[:len(lines)-1] // after the final newline
+
+	var out []string
+	table := ""
+	privacy := false       // whether [privacy] was seen
+	appsAt := -1           // where in out the new excluded_apps goes
+	policiesAt := -1       // where the new policies go
+	afterPolicies := false // whether policies replace old ones, rather than follow a setting
+	privacyEnd := -1       // the end of the last setting in [privacy]
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			if table != "privacy.policies" || strings.HasPrefix(line, "#") {
+				out = append(out, lines[i])
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = tableName(line)
+			switch table {
+			case "privacy":
+				privacy = true
+				privacyEnd = len(out) + 1
+			case "privacy.policies":
+				if policiesAt < 0 {
+					policiesAt, afterPolicies = len(out), true
+				}
+				continue
+			}
+			out = append(out, lines[i])
+			continue
+		}
+
+		// A setting, perhaps with an array continued on the lines below
+		end := i
+		before, after, _ := strings.Cut(line, "=")
+		for _, quote := range []string{`"""`, `'''`} {
+			if strings.Count(after, quote)%2 == 1 {
+				for end+1 < len(lines) {
+					end++
+					if strings.Count(lines[end], quote)%2 == 1 {
+						break
+					}
+				}
+			}
+		}
+		for depth := nesting(after); depth > 0 && end+1 < len(lines); {
+			end++
+			depth += nesting(lines[end])
+		}
+		key := keyName(before)
+		switch {
+		case table == "privacy.policies":
+		case table == "privacy" && key == "excluded_apps":
+			appsAt = len(out)
+			privacyEnd = len(out)
+		case table == "privacy" && key == "policies":
+		default:
+			out = append(out, lines[i:end+1]...)
+			if table == "privacy" {
+				privacyEnd = len(out)
+			}
+		}
+		i = end
+	}
+
+	if !privacy {
+		if len(out) > 0 {
+			out = append(out, "\n")
+		}
+		out = append(out, "[privacy]\n")
+		privacyEnd = len(out)
+	}
+	if appsAt < 0 {
+		appsAt = privacyEnd
+	}
+	// Insert the later of the two first, so appsAt still holds
+	if policyLines != "" {
+		if policiesAt < 0 {
+			policiesAt = privacyEnd
+			policyLines = "\n" + policyLines
+		}
+		if policiesAt < len(out) && strings.TrimSpace(out[policiesAt]) != "" {
+			policyLines += "\n"
+		}
+		out = slices.Insert(out, policiesAt, policyLines)
+		if policiesAt < appsAt {
+			appsAt++
+		}
+	} else if afterPolicies && policiesAt > 0 && policiesAt < len(out) &&
+		strings.TrimSpace(out[policiesAt]) != "" && strings.TrimSpace(out[policiesAt-1]) != "" {
+		// Keep the table that followed the policies apart from what came before
+		out = slices.Insert(out, policiesAt, "\n")
+		if policiesAt < appsAt {
+			appsAt++
+		}
+	}
+	out = slices.Insert(out, appsAt, apps)
+	return strings.Join(out, ""), nil
+}
+
+// encodeRules encodes v as TOML settings without indenting them
+func encodeRules(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// tableName returns the name of the table or array of tables whose header
+// is line
+func tableName(line string) string {
+	line, _, _ = strings.Cut(line, "#")
+	return keyName(strings.Trim(strings.TrimSpace(line), "[]"))
+}
+
+// nesting returns how many more arrays and inline tables s opens than it
+// closes, ignoring brackets in strings and comments
+func nesting(s string) int {
+	depth := 0
+	quote := rune(0)
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return depth
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// samePolicies reports whether a and b hold the same policies, an empty
+// list being the same as none
+func samePolicies(a, b []AppPolicy) bool {
+	return slices.EqualFunc(a, b, func(x, y AppPolicy) bool {
+		return x.App == y.App && x.MaxBytes == y.MaxBytes && slices.Equal(x.Types, y.Types) && slices.Equal(x.Tags, y.Tags)
+	})
+}
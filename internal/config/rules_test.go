@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveRules(t *testing.T) {
+	path := writeConfig(t, "[history]\nbump_duplicates = true\n\n[privacy]\nexcluded_apps = [\"KeePassXC\"]\nclear_sensitive_after = \"10s\"\n")
+
+	policies := []AppPolicy{{App: "Slack", MaxBytes: 1024, Types: []string{"url", "text"}, Tags: []string{"chat"}}}
+	if err := SaveRules(path, []string{"Bitwarden"}, policies); err != nil {
+		t.Fatalf("SaveRules: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Privacy.ExcludedApps, []string{"Bitwarden"}) {
+		t.Errorf("excluded_apps = %v", cfg.Privacy.ExcludedApps)
+	}
+	if !reflect.DeepEqual(cfg.Privacy.Policies, policies) {
+		t.Errorf("policies = %+v", cfg.Privacy.Policies)
+	}
+	if !cfg.History.BumpDuplicates || cfg.Privacy.ClearSensitiveAfter != 10*time.Second {
+		t.Errorf("expected other settings to be kept, got %+v", cfg)
+	}
+
+	// Saving no rules clears them
+	if err := SaveRules(path, nil, nil); err != nil {
+		t.Fatalf("SaveRules: %v", err)
+	}
+	if cfg, _ = LoadFile(path); len(cfg.Privacy.ExcludedApps) != 0 || len(cfg.Privacy.Policies) != 0 {
+		t.Errorf("expected rules to be cleared, got %+v", cfg.Privacy)
+	}
+}
+
+func TestSaveRulesCreatesConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clippy", FileName)
+	if err := SaveRules(path, []string{"KeePassXC"}, nil); err != nil {
+		t.Fatalf("SaveRules: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil || !reflect.DeepEqual(cfg.Privacy.ExcludedApps, []string{"KeePassXC"}) {
+		t.Errorf("LoadFile = %+v, %v", cfg.Privacy, err)
+	}
+	if !cfg.Privacy.RespectHints {
+		t.Error("expected unset settings to keep their defaults")
+	}
+}
+
+func TestSaveRulesKeepsTheRestOfTheFile(t *testing.T) {
+	path := writeConfig(t, `# My settings
+[history]
+bump_duplicates = true # like this
+
+[privacy]
+# Password managers
+excluded_apps = [
+  "KeePassXC",
+  "Bitwarden",
+]
+clear_sensitive_after = "10s"
+
+# Chat apps
+[[privacy.policies]]
+app = "Slack"
+tags = ["chat"]
+
+[[privacy.policies]]
+app = "Discord"
+
+[actions]
+# Our repo
+commit_url = "https://example.com/{sha}"
+`)
+	policies := []AppPolicy{{App: "Slack", Types: []string{"url"}}}
+	if err := SaveRules(path, []string{"KeePassXC"}, policies); err != nil {
+		t.Fatalf("SaveRules: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# My settings
+[history]
+bump_duplicates = true # like this
+
+[privacy]
+# Password managers
+excluded_apps = ["KeePassXC"]
+clear_sensitive_after = "10s"
+
+# Chat apps
+[[privacy.policies]]
+app = "Slack"
+max_bytes = 0
+types = ["url"]
+
+[actions]
+# Our repo
+commit_url = "https://example.com/{sha}"
+`
+	if string(data) != want {
+		t.Errorf("config =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestSaveRulesRefusesWhatItCantEdit(t *testing.T) {
+	// Dotted keys outside [privacy] aren't found line by line
+	original := "privacy.excluded_apps = [\"KeePassXC\"]\n"
+	path := writeConfig(t, original)
+	err := SaveRules(path, []string{"Bitwarden"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "by hand") {
+		t.Errorf("SaveRules = %v, want it refused", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("config = %q, want it untouched", data)
+	}
+}
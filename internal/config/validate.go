@@ -0,0 +1,383 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Choices lists the accepted values of settings whose options are defined
+// by other packages, such as search algorithms and clipboard backends.
+type Choices struct {
+	Algorithms       []string
+	Backends         []string
+	CursorIndicators []string
+	Types            []string
+	HookEvents       []string
+}
+
+// Problem is one setting in the config file that is wrong.
+type Problem struct {
+	Line    int    // line of the setting, 0 when it can't be found
+	Key     string // dotted name, e.g. "search.algorithm" or "hooks[1].pattern"
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Key, p.Message)
+}
+
+// ValidationError reports every problem found in a config file.
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "config %s has %d problem", e.Path, len(e.Problems))
+	if len(e.Problems) != 1 {
+		b.WriteString("s")
+	}
+	b.WriteString(":")
+	for _, p := range e.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+// Check validates the config file at path: unknown settings, values that
+// aren't among the choices, invalid regular expressions and out of range
+// numbers. It returns a *ValidationError listing them, the file's parse
+// error if it can't be read, or nil when the file is fine or missing.
+// Problems don't stop the config loading; the settings affected are
+// ignored or clamped as documented.
+func Check(path string, choices Choices) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+	cfg := Default()
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+
+	lines := keyLines(string(data))
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Line: lines.find(key), Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	known := settingNames(reflect.TypeOf(Config{}), "")
+	for _, k := range md.Undecoded() {
+		name := k.String()
+		message := "unknown setting"
+		if guess := closest(name, known); guess != "" {
+			message += fmt.Sprintf("; did you mean %q?", guess)
+		}
+		found := lines.all(name)
+		if len(found) == 0 {
+			add(name, "%s", message)
+		}
+		for _, line := range found {
+			problems = append(problems, Problem{Line: line, Key: name, Message: message})
+		}
+	}
+
+	oneOf := func(key, value string, allowed []string) {
+		if value == "" || slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, value) }) {
+			return
+		}
+		message := fmt.Sprintf("unknown value %q (want one of %s)", value, strings.Join(allowed, ", "))
+		if guess := closest(value, allowed); guess != "" {
+			message += fmt.Sprintf("; did you mean %q?", guess)
+		}
+		add(key, "%s", message)
+	}
+	pattern := func(key, value string) {
+		if _, err := regexp.Compile(value); err != nil {
+			add(key, "invalid regular expression: %v", err)
+		}
+	}
+	atLeastZero := func(key string, value int) {
+		if value < 0 {
+			add(key, "must not be negative, got %d", value)
+		}
+	}
+
+	atLeastZero("history.overflow_bytes", cfg.History.OverflowBytes)
+	atLeastZero("history.max_capture_bytes", cfg.History.MaxCaptureBytes)
+	oneOf("history.oversized", cfg.History.Oversized, []string{OversizedSkip, OversizedTruncate})
+	oneOf("search.algorithm", cfg.Search.Algorithm, choices.Algorithms)
+	if d := cfg.Search.DebounceMS; d < 0 || d > MaxDebounceMS {
+		add("search.debounce_ms", "must be between 0 and %d, got %d", MaxDebounceMS, d)
+	}
+	oneOf("clipboard.backend", cfg.Clipboard.Backend, append([]string{BackendAuto, BackendNone}, choices.Backends...))
+	if d := cfg.Clipboard.DebounceMS; d < 0 || d > MaxCaptureDebounceMS {
+		add("clipboard.debounce_ms", "must be between 0 and %d, got %d", MaxCaptureDebounceMS, d)
+	}
+	if cfg.Clipboard.Interval < 0 {
+		add("clipboard.interval", "must not be negative, got %v", cfg.Clipboard.Interval)
+	}
+	if cfg.Clipboard.PrimaryInterval < 0 {
+		add("clipboard.primary_interval", "must not be negative, got %v", cfg.Clipboard.PrimaryInterval)
+	}
+	oneOf("ui.cursor_indicator", cfg.UI.CursorIndicator, choices.CursorIndicators)
+	oneOf("cli.confirm", cfg.CLI.Confirm, []string{ConfirmType, ConfirmFlag, ConfirmOff})
+	if cfg.Archive.After < 0 {
+		add("archive.after", "must not be negative, got %v", cfg.Archive.After)
+	}
+	atLeastZero("compact.after_months", cfg.Compact.AfterMonths)
+	atLeastZero("compact.top", cfg.Compact.Top)
+	if cfg.Privacy.ClearSensitiveAfter < 0 {
+		add("privacy.clear_sensitive_after", "must not be negative, got %v", cfg.Privacy.ClearSensitiveAfter)
+	}
+
+	for i, r := range cfg.Expiry.Rules {
+		key := fmt.Sprintf("expiry.rules[%d]", i)
+		pattern(key+".pattern", r.Pattern)
+		if r.TTL <= 0 {
+			add(key+".ttl", "must be a positive duration such as \"5m\", got %v", r.TTL)
+		}
+	}
+	for i, h := range cfg.Hooks {
+		key := fmt.Sprintf("hooks[%d]", i)
+		if h.Event == "" {
+			add(key+".event", "missing (want one of %s)", strings.Join(choices.HookEvents, ", "))
+		} else {
+			oneOf(key+".event", h.Event, choices.HookEvents)
+		}
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			add(key+".command", "missing; give the program and its arguments, e.g. [\"notify-send\", \"copied\"]")
+		}
+		pattern(key+".pattern", h.Pattern)
+	}
+	for i, a := range cfg.Actions.Custom {
+		key := fmt.Sprintf("actions.custom[%d]", i)
+		if strings.TrimSpace(a.Name) == "" {
+			add(key+".name", "missing; name the action as it is listed in the action menu")
+		}
+		if len(a.Command) == 0 || a.Command[0] == "" {
+			add(key+".command", "missing; give the program and its arguments, e.g. [\"ping\", \"-c\", \"3\", \"{match}\"]")
+		}
+		pattern(key+".pattern", a.Pattern)
+	}
+	names := map[string]bool{}
+	for i, s := range cfg.Clipboard.Sources {
+		key := fmt.Sprintf("clipboard.sources[%d]", i)
+		switch name := strings.TrimSpace(s.Name); {
+		case name == "":
+			add(key+".name", "missing; name the source its entries are labelled with")
+		case slices.Contains(ReservedSources, strings.ToLower(name)):
+			add(key+".name", "%q is built in; choose another name", name)
+		case names[strings.ToLower(name)]:
+			add(key+".name", "%q is used by another source", name)
+		}
+		names[strings.ToLower(strings.TrimSpace(s.Name))] = true
+		if len(s.Command) == 0 || s.Command[0] == "" {
+			add(key+".command", "missing; give the program and its arguments, e.g. [\"xclip\", \"-selection\", \"secondary\", \"-o\"]")
+		}
+		if s.Interval < 0 {
+			add(key+".interval", "must not be negative, got %v", s.Interval)
+		}
+	}
+	for i, p := range cfg.Privacy.Policies {
+		key := fmt.Sprintf("privacy.policies[%d]", i)
+		if strings.TrimSpace(p.App) == "" {
+			add(key+".app", "missing; name the application the policy applies to")
+		}
+		atLeastZero(key+".max_bytes", p.MaxBytes)
+		for _, t := range p.Types {
+			oneOf(key+".types", t, choices.Types)
+		}
+		for _, tag := range p.Tags {
+			if tag = strings.TrimSpace(tag); tag == "" || strings.Contains(tag, ",") {
+				add(key+".tags", "invalid tag %q: tags must be non-empty and contain no commas", tag)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(problems, func(a, b Problem) int { return a.Line - b.Line })
+	return &ValidationError{Path: path, Problems: problems}
+}
+
+// settingNames lists the dotted names of every setting in t, a struct
+// decoded from the config, for suggesting one in place of an unknown key
+func settingNames(t reflect.Type, prefix string) []string {
+	var names []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if name == "" {
+			continue
+		}
+		name = prefix + name
+		names = append(names, name)
+		ft := f.Type
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			names = append(names, settingNames(ft, name+".")...)
+		}
+	}
+	return names
+}
+
+// closest returns the name in names nearest to s, if it is close enough to
+// be a likely typo
+func closest(s string, names []string) string {
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// lineIndex maps the dotted names of the keys and tables in a TOML file,
+// with the index of array tables, e.g. "hooks[1].pattern", to their lines
+type lineIndex map[string]int
+
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+// keyLines finds where each key and table is written in a TOML document.
+// It reads the file line by line rather than parsing it, so it only knows
+// keys written one per line, which is what clippy's settings need.
+func keyLines(data string) lineIndex {
+	index := lineIndex{}
+	table := ""
+	tables := map[string]int{} // count of each array table seen
+	multiline := ""            // the quotes closing an open multi-line string
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if multiline != "" {
+			if strings.Count(line, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[["):
+			name := keyName(strings.TrimSuffix(strings.TrimSpace(strings.Trim(line, "[]")), "]"))
+			table = fmt.Sprintf("%s[%d]", name, tables[name])
+			tables[name]++
+			index.add(table, n+1)
+			continue
+		case strings.HasPrefix(line, "["):
+			table = keyName(strings.Trim(line, "[] "))
+			index.add(table, n+1)
+			continue
+		}
+		before, after, ok := strings.Cut(line, "=")
+		if !ok || !validKey(before) {
+			continue
+		}
+		name := keyName(before)
+		if table != "" {
+			name = table + "." + name
+		}
+		index.add(name, n+1)
+		for _, quote := range []string{`"""`, `'''`} {
+			if strings.Count(after, quote)%2 == 1 {
+				multiline = quote
+			}
+		}
+	}
+	return index
+}
+
+// validKey reports whether s, the text before an = sign, is a TOML key
+// rather than part of a value continued from an earlier line
+func validKey(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Count(s, `"`)%2 == 1 || strings.Count(s, "'")%2 == 1 {
+		return false
+	}
+	return !strings.ContainsAny(s, "[]{},")
+}
+
+// keyName normalises a dotted TOML key, dropping quotes and spaces
+func keyName(s string) string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (x lineIndex) add(name string, line int) {
+	if _, ok := x[name]; !ok {
+		x[name] = line
+	}
+}
+
+// find returns the line of name, or of the nearest table holding it when
+// the key itself isn't written on a line of its own
+func (x lineIndex) find(name string) int {
+	for {
+		if line, ok := x[name]; ok {
+			return line
+		}
+		i := max(strings.LastIndexAny(name, ".["), 0)
+		if i == 0 {
+			return 0
+		}
+		name = name[:i]
+	}
+}
+
+// all returns the lines of name in every array table, as decoded keys
+// don't say which table of an array they came from
+func (x lineIndex) all(name string) []int {
+	var lines []int
+	for k, line := range x {
+		if arrayIndex.ReplaceAllString(k, "") == name {
+			lines = append(lines, line)
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testChoices = Choices{
+	Algorithms:       []string{"fuzzy", "trigram"},
+	Backends:         []string{"xclip"},
+	CursorIndicators: []string{"bar", "reverse"},
+	Types:            []string{"text", "url"},
+	HookEvents:       []string{"capture", "copy"},
+}
+
+func TestCheckValidConfig(t *testing.T) {
+	path := writeConfig(t, `# comment = ignored
+[search]
+algorithm = "Trigram"
+
+[[hooks]]
+event = "copy"
+command = ["notify-send", "a=b"]
+pattern = '^https?://'
+
+[[privacy.policies]]
+app = "slack"
+types = ["url"]
+tags = ["chat"]
+`)
+	if err := Check(path, testChoices); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if err := Check(filepath.Join(t.TempDir(), "missing.toml"), testChoices); err != nil {
+		t.Errorf("Check missing file: %v", err)
+	}
+}
+
+func TestCheckReportsProblems(t *testing.T) {
+	path := writeConfig(t, `[search]
+algoritm = "fuzzy"
+debounce_ms = 5000
+
+[ui]
+cursor_indicator = "bra"
+
+[[hooks]]
+event = "copy"
+command = ["true"]
+
+[[hooks]]
+event = "paste"
+command = [
+  "sh", "-c", "x=1",
+]
+pattern = "(unclosed"
+
+[[privacy.policies]]
+app = "slack"
+types = ["url", "video"]
+colour = "red"
+`)
+	err := Check(path, testChoices)
+	var report *ValidationError
+	if !errors.As(err, &report) {
+		t.Fatalf("Check: %v, want a *ValidationError", err)
+	}
+	want := []string{
+		`line 2: search.algoritm: unknown setting; did you mean "search.algorithm"?`,
+		`line 3: search.debounce_ms: must be between 0 and 1000, got 5000`,
+		`line 6: ui.cursor_indicator: unknown value "bra" (want one of bar, reverse); did you mean "bar"?`,
+		`line 13: hooks[1].event: unknown value "paste" (want one of capture, copy)`,
+		`line 17: hooks[1].pattern: invalid regular expression`,
+		`line 21: privacy.policies[0].types: unknown value "video"`,
+		`line 22: privacy.policies.colour: unknown setting`,
+	}
+	if len(report.Problems) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%v", len(report.Problems), len(want), err)
+	}
+	for i, w := range want {
+		if i < len(report.Problems) && !strings.HasPrefix(report.Problems[i].String(), w) {
+			t.Errorf("problem %d = %q, want it to start with %q", i, report.Problems[i], w)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "config "+path+" has 7 problems:\n  line 2: ") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestCheckParseError(t *testing.T) {
+	path := writeConfig(t, "[history\nbump_duplicates = ")
+	err := Check(path, testChoices)
+	var report *ValidationError
+	if err == nil || errors.As(err, &report) {
+		t.Errorf("Check: %v, want the parse error", err)
+	}
+}
+
+func TestCheckSources(t *testing.T) {
+	path := writeConfig(t, `[clipboard]
+primary_interval = "-1s"
+
+[[clipboard.sources]]
+name = "secondary"
+command = ["xclip", "-selection", "secondary", "-o"]
+
+[[clipboard.sources]]
+name = "Secondary"
+interval = "-5s"
+
+[[clipboard.sources]]
+name = "primary"
+command = ["true"]
+`)
+	err := Check(path, testChoices)
+	var report *ValidationError
+	if !errors.As(err, &report) {
+		t.Fatalf("Check: %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"line 2: clipboard.primary_interval: must not be negative",
+		"line 8: clipboard.sources[1].command: missing",
+		`line 9: clipboard.sources[1].name: "Secondary" is used by another source`,
+		"line 10: clipboard.sources[1].interval: must not be negative",
+		`line 13: clipboard.sources[2].name: "primary" is built in`,
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(report.Problems), len(want), err)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(report.Problems[i].String(), w) {
+			t.Errorf("problem %d = %q, want it to start with %q", i, report.Problems[i], w)
+		}
+	}
+}
+
+func TestCheckCustomActions(t *testing.T) {
+	path := writeConfig(t, `[[actions.custom]]
+name = "decode JWT"
+pattern = '^eyJ'
+command = ["jwt", "decode", "-"]
+
+[[actions.custom]]
+pattern = "[unclosed"
+`)
+	err := Check(path, testChoices)
+	var report *ValidationError
+	if !errors.As(err, &report) {
+		t.Fatalf("Check: %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"line 6: actions.custom[1].name: missing",
+		"line 6: actions.custom[1].command: missing",
+		"line 7: actions.custom[1].pattern: invalid regular expression",
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(report.Problems), len(want), err)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(report.Problems[i].String(), w) {
+			t.Errorf("problem %d = %q, want it to start with %q", i, report.Problems[i], w)
+		}
+	}
+}
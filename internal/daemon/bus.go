@@ -0,0 +1,214 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+const (
+	// BusName is the name the daemon owns on the D-Bus session bus.
+	BusName = "io.github.bvdwalt.Clippy"
+	// BusPath is the object the daemon exports its interface on.
+	BusPath dbus.ObjectPath = "/io/github/bvdwalt/Clippy"
+	// BusInterface holds the daemon's methods: GetHistory, CopyItem, Pause,
+	// Resume, Paused and Clear.
+	BusInterface = "io.github.bvdwalt.Clippy1"
+)
+
+// errStopped is returned by bus calls arriving once the daemon has stopped.
+var errStopped = errors.New("clippy daemon is stopping")
+
+// ConnectBus connects to the session bus and claims BusName, failing if
+// another daemon already owns it.
+func ConnectBus() (*dbus.Conn, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the session bus: %w", err)
+	}
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = fmt.Errorf("%s is already owned", BusName)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error claiming bus name: %w", err)
+	}
+	return conn, nil
+}
+
+// SetBus exports the daemon's D-Bus interface on conn while it runs, so
+// desktop widgets and scripts can list and copy entries, pause capturing
+// and clear history without the TUI.
+func (d *Daemon) SetBus(conn *dbus.Conn) {
+	d.bus = conn
+}
+
+// exportBus publishes the bus interface, returning a func withdrawing it
+func (d *Daemon) exportBus() (func(), error) {
+	service := busService{d}
+	node := &introspect.Node{
+		Name: string(BusPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			{Name: BusInterface, Methods: introspect.Methods(service)},
+		},
+	}
+	if err := d.bus.Export(service, BusPath, BusInterface); err != nil {
+		return nil, fmt.Errorf("error exporting bus interface: %w", err)
+	}
+	if err := d.bus.Export(introspect.NewIntrospectable(node), BusPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, fmt.Errorf("error exporting bus interface: %w", err)
+	}
+	return func() {
+		for _, iface := range []string{BusInterface, "org.freedesktop.DBus.Introspectable"} {
+			if err := d.bus.Export(nil, BusPath, iface); err != nil {
+				log.Printf("Failed to withdraw bus interface: %v", err)
+			}
+		}
+	}, nil
+}
+
+// do runs fn in the daemon's loop, between captures, as the history
+// manager is only used from there. It reports false once the daemon has
+// stopped.
+func (d *Daemon) do(fn func()) bool {
+	done := make(chan struct{})
+	select {
+	case d.calls <- func() { fn(); close(done) }:
+	case <-d.stopped:
+		return false
+	}
+	<-done
+	return true
+}
+
+// BusEntry is a history entry as GetHistory returns it, the D-Bus struct
+// (sssbx).
+type BusEntry struct {
+	Hash    string
+	Type    string // e.g. "url", or "image" for images
+	Preview string // the text, or a description of binary and secret entries
+	Pinned  bool
+	Time    int64 // when last copied, in Unix seconds
+}
+
+// busService holds the methods of BusInterface.
+type busService struct {
+	d *Daemon
+}
+
+// GetHistory returns the pinned entries followed by up to limit of the
+// newest others, in history order; 0 returns them all.
+func (s busService) GetHistory(limit uint32) ([]BusEntry, *dbus.Error) {
+	var entries []BusEntry
+	ok := s.d.do(func() {
+		items := s.d.manager.GetItems()
+		// Pinned items sort first, the others oldest first
+		pinned := 0
+		for pinned < len(items) && items[pinned].Pinned {
+			pinned++
+		}
+		if unpinned := len(items) - pinned; limit > 0 && int(limit) < unpinned {
+			items = append(items[:pinned:pinned], items[len(items)-int(limit):]...)
+		}
+		entries = make([]BusEntry, 0, len(items))
+		for _, item := range items {
+			entries = append(entries, busEntry(item))
+		}
+	})
+	if !ok {
+		return nil, dbus.MakeFailedError(errStopped)
+	}
+	return entries, nil
+}
+
+// CopyItem places the entry with hash on the clipboard.
+func (s busService) CopyItem(hash string) *dbus.Error {
+	var err error
+	ok := s.d.do(func() {
+		err = s.d.copyItem(hash)
+	})
+	if !ok {
+		err = errStopped
+	}
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Pause stops recording clipboard changes until Resume.
+func (s busService) Pause() *dbus.Error {
+	if !s.d.do(func() { s.d.paused = true }) {
+		return dbus.MakeFailedError(errStopped)
+	}
+	return nil
+}
+
+// Resume records clipboard changes again after Pause. What was copied
+// meanwhile is not recorded.
+func (s busService) Resume() *dbus.Error {
+	if !s.d.do(func() { s.d.paused = false }) {
+		return dbus.MakeFailedError(errStopped)
+	}
+	return nil
+}
+
+// Paused reports whether recording is paused.
+func (s busService) Paused() (bool, *dbus.Error) {
+	var paused bool
+	if !s.d.do(func() { paused = s.d.paused }) {
+		return false, dbus.MakeFailedError(errStopped)
+	}
+	return paused, nil
+}
+
+// Clear deletes every unpinned entry and returns how many were deleted.
+func (s busService) Clear() (uint32, *dbus.Error) {
+	var cleared int
+	var err error
+	if !s.d.do(func() {
+		cleared, err = s.d.manager.Clear()
+		s.d.deleted("clear", cleared)
+	}) {
+		err = errStopped
+	}
+	if err != nil {
+		return uint32(cleared), dbus.MakeFailedError(err)
+	}
+	return uint32(cleared), nil
+}
+
+// copyItem writes the entry with hash to the clipboard
+func (d *Daemon) copyItem(hash string) error {
+	for _, item := range d.manager.GetItems() {
+		if item.Hash == hash {
+			return d.writeItem(item)
+		}
+	}
+	return fmt.Errorf("no entry with hash %q", hash)
+}
+
+// busEntry describes item for GetHistory, never exposing a secret's text
+func busEntry(item history.ClipboardHistory) BusEntry {
+	entry := BusEntry{
+		Hash:    item.Hash,
+		Type:    string(item.Type),
+		Preview: item.Item,
+		Pinned:  item.Pinned,
+		Time:    item.TimeStamp.Unix(),
+	}
+	if item.IsBinary() {
+		entry.Type = string(item.Kind)
+	}
+	if item.Sensitive != "" {
+		entry.Preview = "hidden " + string(item.Sensitive)
+	}
+	return entry
+}
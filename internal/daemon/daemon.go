@@ -0,0 +1,351 @@
+// Package daemon captures clipboard changes in the background, writing them
+// to the shared history database so the TUI can run as a pure viewer.
+package daemon
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/bvdwalt/clippy/internal/clipformat"
+	"github.com/bvdwalt/clippy/internal/clipimage"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/instance"
+	"github.com/bvdwalt/clippy/internal/sources"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+	"github.com/bvdwalt/clippy/internal/trace"
+)
+
+const (
+	// PollInterval is how often the clipboard is checked, unless set with
+	// SetPollInterval, and the database checked for changes.
+	PollInterval = 500 * time.Millisecond
+	// BufferPollInterval is how often tmux paste buffers are checked.
+	BufferPollInterval = 2 * time.Second
+)
+
+// Overridable for tests.
+var (
+	readStream  = sysclip.Stream
+	readFormats = clipformat.Read
+	readImage   = clipimage.Read
+	writeText   = sysclip.WriteAll
+	writeImage  = clipimage.Write
+)
+
+// BufferImporter supplies content captured outside the system clipboard,
+// such as tmux paste buffers.
+type BufferImporter interface {
+	Poll() ([]string, error)
+}
+
+// ChangeWatcher notifies of clipboard changes, replacing polling.
+type ChangeWatcher interface {
+	Changes() <-chan struct{}
+}
+
+// CaptureGuard decides whether new clipboard content may be recorded, e.g.
+// refusing passwords.
+type CaptureGuard interface {
+	Allow() bool
+}
+
+// Daemon polls the clipboard and records new content in history.
+type Daemon struct {
+	manager       *history.Manager
+	dir           string // holds the capture lock
+	importer      BufferImporter
+	watcher       ChangeWatcher
+	guard         CaptureGuard
+	listener      net.Listener     // answered while running; nil for none
+	bus           *dbus.Conn       // exports the bus interface while running; nil for none
+	calls         chan func()      // bus calls for the loop to run
+	stopped       chan struct{}    // closed once Run returns
+	paused        bool             // recording paused over the bus
+	restore       bool             // put the newest entry back on an empty clipboard at start
+	persist       bool             // take over serving recorded text from the app that copied it
+	logger        *slog.Logger     // logs events; nil for none
+	wrote         bool             // changed the database since it was last reloaded
+	sources       []sources.Source // read alongside the clipboard while running
+	interval      time.Duration    // how often the clipboard is polled; 0 for PollInterval
+	lastClipboard string
+	lastImageHash string
+	debounce      time.Duration
+	pending       string    // new clipboard content waiting to settle
+	pendingSince  time.Time // when pending was first seen
+}
+
+// New creates a daemon recording into manager, keeping its status file in dir.
+func New(manager *history.Manager, dir string) *Daemon {
+	// The first reload is of what the caller loaded, not a change
+	return &Daemon{manager: manager, dir: dir, calls: make(chan func()), stopped: make(chan struct{}), wrote: true}
+}
+
+// SetBufferImporter enables polling importer alongside the clipboard.
+func (d *Daemon) SetBufferImporter(importer BufferImporter) {
+	d.importer = importer
+}
+
+// SetWatcher reads the clipboard when watcher reports a change instead of
+// on every poll.
+func (d *Daemon) SetWatcher(watcher ChangeWatcher) {
+	d.watcher = watcher
+}
+
+// SetCaptureGuard consults guard before recording each new clipboard text.
+func (d *Daemon) SetCaptureGuard(guard CaptureGuard) {
+	d.guard = guard
+}
+
+// SetSources records what the sources capture, e.g. the primary
+// selection, each read on its own schedule while the daemon runs.
+func (d *Daemon) SetSources(sources []sources.Source) {
+	d.sources = sources
+}
+
+// SetPollInterval reads the clipboard every interval when there is no
+// watcher; zero reads it every PollInterval.
+func (d *Daemon) SetPollInterval(interval time.Duration) {
+	d.interval = max(interval, 0)
+}
+
+// SetDebounce only records new clipboard content once it has stayed
+// unchanged for d, so rapid rewrites of the clipboard leave only their final
+// value. 0 records every change.
+func (d *Daemon) SetDebounce(debounce time.Duration) {
+	d.debounce = max(debounce, 0)
+}
+
+// Poll records the clipboard content if it changed since the last poll and
+// purges expired entries.
+func (d *Daemon) Poll(now time.Time) {
+	d.refresh(now)
+	d.captureClipboard(now)
+}
+
+// refresh picks up changes made by other processes and purges expired entries
+func (d *Daemon) refresh(now time.Time) {
+	// Pick up pins, deletions and aliases made in the TUI
+	if changed, err := d.manager.ReloadIfChanged(); err != nil {
+		log.Printf("Failed to reload history: %v", err)
+	} else if changed {
+		d.synced()
+	}
+	d.deleted("expired", d.manager.PurgeExpired(now))
+	d.manager.RefreshIncognito()
+}
+
+// captureClipboard records the clipboard content if it changed. Text is
+// spooled as it is read, so a huge copy goes straight to an overflow file.
+func (d *Daemon) captureClipboard(now time.Time) {
+	defer trace.Start(trace.Capture).End()
+	if text, ok := d.readClipboard(); ok {
+		// Spooled text is only known by its hash
+		key := text.Content
+		if text.InFile() {
+			key = text.Hash
+		}
+		if key == d.lastClipboard {
+			d.pending = ""
+			text.Discard()
+			return
+		}
+		if !d.settled(key, now) {
+			text.Discard()
+			return
+		}
+		switch {
+		case !d.recording() || (d.guard != nil && !d.guard.Allow()):
+			text.Discard()
+		case text.InFile() || text.Oversized:
+			// Huge or truncated text is recorded without its formats
+			d.captured(d.manager.AddSpooled(text, history.SelectionClipboard), sourceClipboard)
+		default:
+			// The formats are extras; the text is recorded without them
+			formats, _ := readFormats()
+			d.captured(d.manager.AddItemWithFormats(text.Content, formats), sourceClipboard)
+			if d.persist && len(formats) == 0 {
+				d.own(text.Content)
+			}
+		}
+		d.lastClipboard = key
+		return
+	}
+
+	// No text on the clipboard; it may hold an image instead
+	data, mimeType, err := readImage()
+	if err != nil {
+		return
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	if hash == d.lastImageHash {
+		return
+	}
+	if d.recording() {
+		d.captured(d.manager.AddImage(data, mimeType), sourceClipboard)
+	}
+	d.lastImageHash = hash
+}
+
+// settled reports whether new clipboard content key has stayed unchanged
+// for the debounce, noting it as pending otherwise
+func (d *Daemon) settled(key string, now time.Time) bool {
+	if d.debounce == 0 {
+		return true
+	}
+	if key != d.pending {
+		d.pending, d.pendingSince = key, now
+		return false
+	}
+	if now.Sub(d.pendingSince) < d.debounce {
+		return false
+	}
+	d.pending = ""
+	return true
+}
+
+// readClipboard reads the text on the clipboard, reporting false if there
+// is none
+func (d *Daemon) readClipboard() (history.Spooled, bool) {
+	r, err := readStream()
+	if err != nil {
+		return history.Spooled{}, false
+	}
+	defer r.Close()
+	text, err := d.manager.Spool(r)
+	if err != nil || text.Size == 0 {
+		return history.Spooled{}, false
+	}
+	return text, true
+}
+
+// captureSource records text a source captured, labelled with the source
+func (d *Daemon) captureSource(c sources.Capture) {
+	if d.recording() {
+		d.captured(d.manager.AddItemFrom(c.Text, history.Selection(c.Source)), c.Source)
+	}
+}
+
+// importBuffers records content from the buffer importer
+func (d *Daemon) importBuffers() {
+	contents, err := d.importer.Poll()
+	if err != nil {
+		log.Printf("Failed to import buffers: %v", err)
+		return
+	}
+	if !d.recording() {
+		return
+	}
+	for _, content := range contents {
+		d.captured(d.manager.AddItem(content), sourceTmux)
+	}
+}
+
+// recording reports whether captured content is recorded. In incognito mode
+// the TUI captures instead, keeping the items in its own memory; the content
+// is still noted as seen so it isn't recorded once incognito mode ends, or
+// recording resumes after a pause.
+func (d *Daemon) recording() bool {
+	return !d.paused && !d.manager.Incognito()
+}
+
+// Run polls until ctx is cancelled. It holds the capture lock meanwhile,
+// refusing to start while another daemon or a TUI is capturing into the
+// same history.
+func (d *Daemon) Run(ctx context.Context) error {
+	lock, err := instance.Acquire(d.dir, instance.Daemon)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	defer close(d.stopped)
+
+	if d.restore {
+		d.restoreClipboard()
+	}
+
+	if d.bus != nil {
+		withdraw, err := d.exportBus()
+		if err != nil {
+			return err
+		}
+		defer withdraw()
+	}
+	if d.listener != nil {
+		go serve(d.listener)
+		defer func() {
+			if err := d.listener.Close(); err != nil {
+				log.Printf("Failed to close daemon socket: %v", err)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	// The clipboard is polled on its own schedule when it isn't watched
+	var polls <-chan time.Time
+	if d.watcher == nil {
+		pollTicker := time.NewTicker(cmp.Or(d.interval, PollInterval))
+		defer pollTicker.Stop()
+		polls = pollTicker.C
+	}
+	var captures <-chan sources.Capture
+	if len(d.sources) > 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		captures = sources.Watch(ctx, d.sources)
+	}
+	var buffers <-chan time.Time
+	if d.importer != nil {
+		bufferTicker := time.NewTicker(BufferPollInterval)
+		defer bufferTicker.Stop()
+		buffers = bufferTicker.C
+	}
+	var changes <-chan struct{}
+	if d.watcher != nil {
+		changes = d.watcher.Changes()
+	}
+	// Fires once debounced content may have settled, when no poll would
+	// look at it again
+	var settle <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+			now := time.Now()
+			d.refresh(now)
+			d.captureClipboard(now)
+			if d.pending != "" {
+				settle = time.After(d.debounce)
+			}
+		case now := <-settle:
+			d.captureClipboard(now)
+		case now := <-polls:
+			d.captureClipboard(now)
+		case c, ok := <-captures:
+			if !ok {
+				captures = nil
+				continue
+			}
+			d.captureSource(c)
+		case now := <-ticker.C:
+			d.refresh(now)
+			if err := lock.Refresh(now); err != nil {
+				log.Printf("Failed to update daemon heartbeat: %v", err)
+			}
+		case <-buffers:
+			d.importBuffers()
+		case call := <-d.calls:
+			call()
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/instance"
+	"github.com/bvdwalt/clippy/internal/sources"
+)
+
+// useClipboard stubs the clipboard readers with text (or an image when
+// text is empty) and no richer formats.
+func useClipboard(t *testing.T, text *string, image *[]byte) {
+	t.Helper()
+	origStream, origFormats, origImage := readStream, readFormats, readImage
+	t.Cleanup(func() { readStream, readFormats, readImage = origStream, origFormats, origImage })
+	readStream = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(*text)), nil }
+	readFormats = func() (map[string][]byte, error) { return nil, nil }
+	readImage = func() ([]byte, string, error) {
+		if len(*image) == 0 {
+			return nil, "", errors.New("no image")
+		}
+		return *image, "image/png", nil
+	}
+}
+
+func newManager(t *testing.T) *history.Manager {
+	t.Helper()
+	manager, err := history.NewManagerWithPath(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := manager.Close(); err != nil {
+			t.Logf("close: %v", err)
+		}
+	})
+	return manager
+}
+
+type fakeImporter []string
+
+func (f fakeImporter) Poll() ([]string, error) { return f, nil }
+
+func TestPollRecordsChanges(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+
+	text = "first"
+	d.Poll(time.Now())
+	d.Poll(time.Now())
+	text = "second"
+	d.Poll(time.Now())
+	text, image = "", []byte("\x89PNG\r\n\x1a\nimage")
+	d.Poll(time.Now())
+	d.Poll(time.Now())
+
+	if manager.Count() != 3 {
+		t.Fatalf("Count = %d, want 3", manager.Count())
+	}
+	if last, _ := manager.GetItem(2); !last.IsBinary() {
+		t.Errorf("expected the image to be recorded, got %+v", last)
+	}
+}
+
+func TestPollRecordsFormats(t *testing.T) {
+	manager := newManager(t)
+	text := "bold text"
+	var image []byte
+	useClipboard(t, &text, &image)
+	readFormats = func() (map[string][]byte, error) {
+		return map[string][]byte{"text/html": []byte("<b>bold</b> text")}, nil
+	}
+	d := New(manager, manager.DataDir())
+
+	d.Poll(time.Now())
+	item, _ := manager.GetItem(0)
+	if data, err := manager.Format(item, "text/html"); err != nil || string(data) != "<b>bold</b> text" {
+		t.Errorf("Format = %q, %v; want the HTML copied with the text", data, err)
+	}
+}
+
+func TestPollSpoolsLargeText(t *testing.T) {
+	manager := newManager(t)
+	manager.SetOverflowThreshold(100)
+	text := strings.Repeat("build output\n", 1000)
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+
+	d.Poll(time.Now())
+	d.Poll(time.Now())
+	if manager.Count() != 1 {
+		t.Fatalf("Count = %d, want 1", manager.Count())
+	}
+	item, _ := manager.GetItem(0)
+	if !item.Overflow {
+		t.Fatalf("expected the large text to overflow, got %+v", item)
+	}
+	if full, err := manager.Text(item); err != nil || full != text {
+		t.Errorf("Text = %d bytes, %v; want the full %d bytes", len(full), err, len(text))
+	}
+}
+
+func TestPollDebouncesRapidChanges(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+	d.SetDebounce(time.Second)
+
+	start := time.Now()
+	for i := range 5 {
+		text = "progress " + strconv.Itoa(i*25) + "%"
+		d.Poll(start.Add(time.Duration(i) * 500 * time.Millisecond))
+	}
+	if manager.Count() != 0 {
+		t.Fatalf("Count = %d, want nothing recorded while the clipboard keeps changing", manager.Count())
+	}
+	d.Poll(start.Add(2500 * time.Millisecond))
+	if manager.Count() != 0 {
+		t.Fatalf("Count = %d, want the final value to wait for the debounce", manager.Count())
+	}
+	d.Poll(start.Add(3 * time.Second))
+	if manager.Count() != 1 {
+		t.Fatalf("Count = %d, want only the settled value", manager.Count())
+	}
+	if item, _ := manager.GetItem(0); item.Item != "progress 100%" {
+		t.Errorf("recorded %q, want the final value", item.Item)
+	}
+}
+
+func TestPollSeesChangesFromOtherProcesses(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+
+	text = "copied"
+	d.Poll(time.Now())
+
+	// The TUI deletes the item through its own connection
+	viewer, err := history.NewManagerWithPath(filepath.Join(manager.DataDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open viewer: %v", err)
+	}
+	defer func() {
+		if err := viewer.Close(); err != nil {
+			t.Logf("close viewer: %v", err)
+		}
+	}()
+	if err := viewer.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	viewer.DeleteItem(0)
+
+	text = "next"
+	d.Poll(time.Now())
+	if manager.Count() != 1 {
+		t.Fatalf("Count = %d, want 1 (deleted item gone, new one added)", manager.Count())
+	}
+	if item, _ := manager.GetItem(0); item.Item != "next" {
+		t.Errorf("item = %q, want %q", item.Item, "next")
+	}
+}
+
+type fakeWatcher chan struct{}
+
+func (f fakeWatcher) Changes() <-chan struct{} { return f }
+
+func TestRunCapturesOnChange(t *testing.T) {
+	manager := newManager(t)
+	text := "watched"
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+	watcher := make(fakeWatcher)
+	d.SetWatcher(watcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	// The second send can only be received once the first was handled
+	watcher <- struct{}{}
+	watcher <- struct{}{}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if manager.Count() != 1 {
+		t.Fatalf("Count = %d, want 1", manager.Count())
+	}
+	if item, _ := manager.GetItem(0); item.Item != "watched" {
+		t.Errorf("item = %q, want %q", item.Item, "watched")
+	}
+}
+
+func TestRunCapturesDebouncedChangeOnceSettled(t *testing.T) {
+	manager := newManager(t)
+	text := "watched"
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+	watcher := make(fakeWatcher)
+	d.SetWatcher(watcher)
+	d.SetDebounce(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	// No further change arrives; the settle timer records the content
+	watcher <- struct{}{}
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if manager.Count() != 1 {
+		t.Fatalf("Count = %d, want the settled content recorded", manager.Count())
+	}
+}
+
+func TestRunCapturesSources(t *testing.T) {
+	manager := newManager(t)
+	text := "copied"
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+	d.SetWatcher(make(fakeWatcher))
+	d.SetSources([]sources.Source{
+		{Name: "primary", Interval: 5 * time.Millisecond, Read: func() (string, error) { return "highlighted", nil }},
+		{Name: "secondary", Interval: 5 * time.Millisecond, Read: func() (string, error) { return "yanked", nil }},
+		{Name: "broken", Interval: 5 * time.Millisecond, Read: func() (string, error) { return "", errors.New("gone") }},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := map[string]history.Selection{}
+	for _, item := range manager.GetItems() {
+		got[item.Item] = item.Selection
+	}
+	want := map[string]history.Selection{"highlighted": history.SelectionPrimary, "yanked": "secondary"}
+	if !maps.Equal(got, want) {
+		t.Errorf("captured %v, want %v", got, want)
+	}
+}
+
+type denyAll struct{ calls int }
+
+func (g *denyAll) Allow() bool {
+	g.calls++
+	return false
+}
+
+func TestPollSkipsGuardedContent(t *testing.T) {
+	manager := newManager(t)
+	text := "hunter2"
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+	guard := &denyAll{}
+	d.SetCaptureGuard(guard)
+
+	d.Poll(time.Now())
+	d.Poll(time.Now())
+	if manager.Count() != 0 {
+		t.Errorf("Count = %d, want 0", manager.Count())
+	}
+	if guard.calls != 1 {
+		t.Errorf("guard consulted %d times, want once per change", guard.calls)
+	}
+}
+
+func TestPollSkipsCaptureInIncognitoMode(t *testing.T) {
+	manager := newManager(t)
+	text := "secret"
+	var image []byte
+	useClipboard(t, &text, &image)
+	d := New(manager, manager.DataDir())
+
+	// Switched on by another process, e.g. the TUI
+	other, err := history.NewManagerWithPath(filepath.Join(manager.DataDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer other.Close()
+	if err := other.SetIncognito(true); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	d.Poll(time.Now())
+	if manager.Count() != 0 {
+		t.Errorf("Count = %d, want 0 in incognito mode", manager.Count())
+	}
+
+	// Content seen while incognito isn't recorded once it ends
+	if err := other.SetIncognito(false); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	d.Poll(time.Now())
+	if manager.Count() != 0 {
+		t.Errorf("Count = %d, want 0 after incognito mode", manager.Count())
+	}
+	text = "public"
+	d.Poll(time.Now())
+	if manager.Count() != 1 {
+		t.Errorf("Count = %d, want 1", manager.Count())
+	}
+}
+
+func TestRunWritesStatusUntilCancelled(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	dir := manager.DataDir()
+	d := New(manager, dir)
+	d.SetBufferImporter(fakeImporter{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if owner, ok := instance.Holder(dir); ok {
+			if owner.PID != os.Getpid() || owner.Role != instance.Daemon {
+				t.Errorf("holder = %+v, want the daemon with pid %d", owner, os.Getpid())
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("daemon never reported running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := New(manager, dir).Run(context.Background()); err == nil {
+		t.Error("expected a second daemon to refuse to start")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, ok := instance.Holder(dir); ok {
+		t.Error("expected the capture lock released on exit")
+	}
+}
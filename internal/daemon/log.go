@@ -0,0 +1,82 @@
+package daemon
+
+import "log/slog"
+
+// Events the daemon logs with SetLogger, named in each record's "event"
+// field. They and their fields are stable for log collectors.
+const (
+	// EventCapture is an entry recorded, or bumped by copying it again:
+	// source ("clipboard", "tmux" or the name of a source set with
+	// SetSources, such as "primary"), hash, type, bytes and count. Content is never logged.
+	EventCapture = "capture"
+	// EventDelete is entries removed by the daemon: reason ("expired" or
+	// "clear") and count.
+	EventDelete = "delete"
+	// EventSync is changes made by other processes, such as the TUI,
+	// picked up from the database: entries, the number now in history.
+	EventSync = "sync"
+)
+
+// Sources of captured entries.
+const (
+	sourceClipboard = "clipboard"
+	sourceTmux      = "tmux"
+)
+
+// SetLogger logs the daemon's events (captures, deletions and syncs) to
+// logger, e.g. as JSON for journald or a log collector.
+func (d *Daemon) SetLogger(logger *slog.Logger) {
+	d.logger = logger
+}
+
+// captured notes that an entry was just recorded from source, if it was,
+// and logs it
+func (d *Daemon) captured(recorded bool, source string) {
+	if !recorded {
+		return
+	}
+	d.wrote = true
+	if d.logger == nil {
+		return
+	}
+	item, ok := d.manager.Latest()
+	if !ok {
+		return
+	}
+	size := item.Size
+	if size == 0 {
+		size = len(item.Item)
+	}
+	d.logger.Info("captured",
+		"event", EventCapture,
+		"source", source,
+		"hash", item.Hash,
+		"type", string(item.Type),
+		"bytes", size,
+		"count", max(item.Count, 1),
+	)
+}
+
+// deleted notes that count entries were deleted for reason, if any were,
+// and logs it
+func (d *Daemon) deleted(reason string, count int) {
+	if count == 0 {
+		return
+	}
+	d.wrote = true
+	if d.logger == nil {
+		return
+	}
+	d.logger.Info("deleted", "event", EventDelete, "reason", reason, "count", count)
+}
+
+// synced logs picking up changes from the database, unless the daemon's own
+// writes may explain them
+func (d *Daemon) synced() {
+	own := d.wrote
+	d.wrote = false
+	if d.logger == nil || own {
+		return
+	}
+	d.logger.Info("synced", "event", EventSync, "entries", d.manager.Count())
+}
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// records decodes the JSON log records in buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q isn't JSON: %v", line, err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestLoggerRecordsEvents(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	var buf bytes.Buffer
+	d := New(manager, manager.DataDir())
+	d.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	text = "a secret-free copy"
+	d.Poll(time.Now())
+	if err := manager.SetExpiry(0, time.Millisecond); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	d.Poll(time.Now().Add(time.Second))
+
+	got := records(t, &buf)
+	if len(got) != 2 {
+		t.Fatalf("logged %d records, want a capture and a delete: %s", len(got), buf.String())
+	}
+	capture, deleted := got[0], got[1]
+	if capture["event"] != EventCapture || capture["source"] != "clipboard" || capture["hash"] == "" || capture["bytes"] != float64(len(text)) {
+		t.Errorf("capture record = %v", capture)
+	}
+	if strings.Contains(buf.String(), text) {
+		t.Error("expected the content kept out of the log")
+	}
+	if deleted["event"] != EventDelete || deleted["reason"] != "expired" || deleted["count"] != float64(1) {
+		t.Errorf("delete record = %v", deleted)
+	}
+}
+
+func TestLoggerRecordsSyncFromOtherProcesses(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	var buf bytes.Buffer
+	d := New(manager, manager.DataDir())
+	d.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	d.Poll(time.Now())
+
+	// Another process, such as the TUI, records an entry
+	other, err := history.NewManagerWithPath(manager.DBPath())
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer func() {
+		if err := other.Close(); err != nil {
+			t.Logf("close: %v", err)
+		}
+	}()
+	other.AddItem("added elsewhere")
+	d.Poll(time.Now())
+
+	got := records(t, &buf)
+	if len(got) != 1 || got[0]["event"] != EventSync || got[0]["entries"] != float64(1) {
+		t.Errorf("records = %v, want one sync", got)
+	}
+}
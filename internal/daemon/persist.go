@@ -0,0 +1,28 @@
+package daemon
+
+import (
+	"log"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// SetPersistSelection makes the daemon take over each recorded text copy,
+// writing it back to the clipboard so the clipboard tool serves it and it
+// can still be pasted once the application it was copied from exits. Only
+// worthwhile where that application serves the clipboard itself (see
+// sysclip.OwnerServed). Copies offering richer formats such as HTML are
+// left with their application, as writing them back would keep only the
+// text, and so are secrets, whose application may mean to let them go.
+func (d *Daemon) SetPersistSelection(enabled bool) {
+	d.persist = enabled
+}
+
+// own writes text, just recorded from the clipboard, back to it
+func (d *Daemon) own(text string) {
+	if detect.Sensitive(text) != "" {
+		return
+	}
+	if err := writeText(text); err != nil {
+		log.Printf("Failed to take over the clipboard: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// SetRestoreClipboard puts the most recently copied entry back on the
+// clipboard when the daemon starts and finds it empty, as Wayland
+// clipboards are after a reboot or logout.
+func (d *Daemon) SetRestoreClipboard(enabled bool) {
+	d.restore = enabled
+}
+
+// restoreClipboard writes the newest entry to the clipboard if it holds
+// nothing, noting it as seen so it isn't recorded again. Secrets are left
+// off the clipboard.
+func (d *Daemon) restoreClipboard() {
+	if text, ok := d.readClipboard(); ok {
+		text.Discard()
+		return
+	}
+	if _, _, err := readImage(); err == nil {
+		return
+	}
+	item, ok := newest(d.manager.GetItems(), time.Now())
+	if !ok || item.Sensitive != "" {
+		return
+	}
+	if err := d.writeItem(item); err != nil {
+		log.Printf("Failed to restore the clipboard: %v", err)
+		return
+	}
+	switch {
+	case item.IsBinary():
+		d.lastImageHash = item.Hash
+	case item.Overflow:
+		// Spooled text is only known by its hash
+		d.lastClipboard = item.Hash
+	default:
+		d.lastClipboard = item.Item
+	}
+}
+
+// writeItem places item on the clipboard
+func (d *Daemon) writeItem(item history.ClipboardHistory) error {
+	if item.IsBinary() {
+		data, err := d.manager.GetData(item)
+		if err != nil {
+			return fmt.Errorf("error reading image: %w", err)
+		}
+		return writeImage(data, item.MimeType)
+	}
+	text, err := d.manager.Text(item)
+	if err != nil {
+		return err
+	}
+	return writeText(text)
+}
+
+// newest returns the most recently copied of items not expired by now
+func newest(items []history.ClipboardHistory, now time.Time) (history.ClipboardHistory, bool) {
+	var latest history.ClipboardHistory
+	found := false
+	for _, item := range items {
+		if item.IsExpired(now) {
+			continue
+		}
+		if !found || item.TimeStamp.After(latest.TimeStamp) {
+			latest, found = item, true
+		}
+	}
+	return latest, found
+}
@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SocketFileName is the Unix socket systemd listens on for the daemon
+	// when it is socket activated (see clippy install-service).
+	SocketFileName = "daemon.sock"
+	// wakeTimeout is how long Wake waits for a socket activated daemon to
+	// start and answer.
+	wakeTimeout = 3 * time.Second
+	// listenFDsStart is the first file descriptor systemd passes.
+	listenFDsStart = 3
+)
+
+// ActivationListener returns the socket passed by systemd socket
+// activation, or nil when the daemon wasn't started that way. The
+// activation variables are removed from the environment so commands the
+// daemon runs, such as hooks, don't mistake the socket for their own.
+func ActivationListener() (net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	fds, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		os.Unsetenv(name)
+	}
+	if pid != os.Getpid() || fds < 1 {
+		return nil, nil
+	}
+
+	f := os.NewFile(listenFDsStart, SocketFileName)
+	// FileListener duplicates the descriptor, close-on-exec
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("error using activation socket: %w", err)
+	}
+	return l, nil
+}
+
+// SetListener answers connections on l, such as the systemd activation
+// socket, with the daemon's pid while it runs. Connecting is what starts a
+// socket activated daemon; see Wake.
+func (d *Daemon) SetListener(l net.Listener) {
+	d.listener = l
+}
+
+// serve greets each connection on l until it is closed
+func serve(l net.Listener) {
+	greeting := fmt.Sprintf("clippy %d\n", os.Getpid())
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte(greeting)); err != nil {
+			log.Printf("Failed to answer on daemon socket: %v", err)
+		}
+		if err := conn.Close(); err != nil {
+			log.Printf("Failed to close daemon connection: %v", err)
+		}
+	}
+}
+
+// Wake connects to the daemon socket in dir, which starts a socket
+// activated daemon if it isn't running yet, and returns the pid it answers
+// with. It reports false when nothing listens on the socket.
+func Wake(dir string) (int, bool) {
+	conn, err := net.DialTimeout("unix", filepath.Join(dir, SocketFileName), wakeTimeout)
+	if err != nil {
+		return 0, false
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(wakeTimeout)); err != nil {
+		return 0, false
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(line), "clippy "))
+	if err != nil {
+		return 0, false
+	}
+	return pid, true
+}
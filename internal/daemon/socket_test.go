@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestWakeGetsPidFromDaemonSocket(t *testing.T) {
+	manager := newManager(t)
+	var text string
+	var image []byte
+	useClipboard(t, &text, &image)
+	dir := manager.DataDir()
+
+	if _, ok := Wake(dir); ok {
+		t.Fatal("expected no daemon to answer without a socket")
+	}
+
+	// Stands in for the socket systemd passes
+	l, err := net.Listen("unix", filepath.Join(dir, SocketFileName))
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	d := New(manager, dir)
+	d.SetListener(l)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	// Connections made before Run serves are queued, as under systemd
+	if pid, ok := Wake(dir); !ok || pid != os.Getpid() {
+		t.Errorf("Wake = %d, %v; want %d, true", pid, ok, os.Getpid())
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, ok := Wake(dir); ok {
+		t.Error("expected the socket closed once the daemon stops")
+	}
+}
+
+func TestActivationListenerWithoutSystemd(t *testing.T) {
+	// Variables meant for another process, e.g. the daemon's parent
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	l, err := ActivationListener()
+	if l != nil || err != nil {
+		t.Errorf("ActivationListener = %v, %v; want nil, nil", l, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("expected the activation variables removed from the environment")
+	}
+}
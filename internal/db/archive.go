@@ -0,0 +1,158 @@
+package db
+
+import (
+	"bytes"
+	"compress/zlib"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// Archive is a secondary database holding entries moved out of the main
+// history. Content and binary payloads are zlib-compressed, since archived
+// entries are rarely read.
+type Archive struct {
+	db *sql.DB
+}
+
+// OpenArchive opens (creating if needed) the archive database at path
+func OpenArchive(path string) (*Archive, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %w", err)
+	}
+
+	if err := migrate(db, archiveMigrations); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			return nil, fmt.Errorf("error initializing archive: %w (also failed to close db: %v)", err, closeErr)
+		}
+		return nil, fmt.Errorf("error initializing archive: %w", err)
+	}
+	return &Archive{db: db}, nil
+}
+
+// Close closes the archive database
+func (a *Archive) Close() error {
+	if a.db != nil {
+		return a.db.Close()
+	}
+	return nil
+}
+
+// Store writes entry, including its Data, to the archive, replacing any
+// archived entry with the same hash
+func (a *Archive) Store(entry ClipboardEntry, archivedAt time.Time) error {
+	content, err := compress([]byte(entry.Content))
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if entry.Data != nil {
+		if data, err = compress(entry.Data); err != nil {
+			return err
+		}
+	}
+	kind := entry.Kind
+	if kind == "" {
+		kind = "text"
+	}
+	_, err = a.db.Exec(
+		`INSERT OR REPLACE INTO archived_entries
+			(hash, content, timestamp, content_type, kind, mime_type, data, size, count, alias, archived_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Hash, content, entry.Timestamp, entry.Type, kind, entry.MimeType, data, len(entry.Data),
+		max(entry.Count, 1), entry.Alias, archivedAt,
+	)
+	return err
+}
+
+// LoadAll returns all archived entries ordered by timestamp ascending.
+// Binary payloads are not loaded; use LoadData to fetch them on demand.
+func (a *Archive) LoadAll() ([]ClipboardEntry, error) {
+	rows, err := a.db.Query(`
+		SELECT hash, content, timestamp, content_type, kind, mime_type, size, count, alias
+		FROM archived_entries
+		ORDER BY timestamp ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying archive: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+
+	entries := make([]ClipboardEntry, 0)
+	for rows.Next() {
+		var entry ClipboardEntry
+		var content []byte
+		if err := rows.Scan(&entry.Hash, &content, &entry.Timestamp, &entry.Type, &entry.Kind, &entry.MimeType, &entry.Size, &entry.Count, &entry.Alias); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		decoded, err := decompress(content)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing clip %s: %w", entry.Hash, err)
+		}
+		entry.Content = string(decoded)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// LoadData returns the binary payload archived for the entry with the given hash
+func (a *Archive) LoadData(hash string) ([]byte, error) {
+	var data []byte
+	err := a.db.QueryRow("SELECT data FROM archived_entries WHERE hash = ?", hash).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("archived clip with hash %s not found", hash)
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decompress(data)
+}
+
+// Delete removes an archived entry by hash
+func (a *Archive) Delete(hash string) error {
+	res, err := a.db.Exec("DELETE FROM archived_entries WHERE hash = ?", hash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("archived clip with hash %s not found", hash)
+	}
+	return nil
+}
+
+func compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, fmt.Errorf("error compressing: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(b []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Printf("Failed to close decompressor: %v", err)
+		}
+	}()
+	return io.ReadAll(r)
+}
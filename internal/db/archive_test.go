@@ -0,0 +1,80 @@
+package db
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveStoreLoadDelete(t *testing.T) {
+	archive, err := OpenArchive(filepath.Join(t.TempDir(), "archive.db"))
+	if err != nil {
+		t.Fatalf("OpenArchive: %v", err)
+	}
+	defer func() {
+		if err := archive.Close(); err != nil {
+			t.Logf("close archive: %v", err)
+		}
+	}()
+
+	text := makeEntry("archived text")
+	text.Alias = "old"
+	text.Count = 3
+	image := makeEntry("[image/png 4 B]")
+	image.Kind = "image"
+	image.MimeType = "image/png"
+	image.Data = []byte{1, 2, 3, 4}
+	image.Timestamp = text.Timestamp.Add(time.Minute)
+
+	for _, e := range []ClipboardEntry{text, image} {
+		if err := archive.Store(e, time.Now()); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+	}
+
+	entries, err := archive.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("LoadAll returned %d entries, want 2", len(entries))
+	}
+	got := entries[0]
+	if got.Content != text.Content || got.Alias != "old" || got.Count != 3 {
+		t.Errorf("archived text = %+v", got)
+	}
+	if entries[1].Size != 4 || entries[1].Data != nil {
+		t.Errorf("expected image size without data, got size %d data %v", entries[1].Size, entries[1].Data)
+	}
+
+	data, err := archive.LoadData(image.Hash)
+	if err != nil || !bytes.Equal(data, image.Data) {
+		t.Errorf("LoadData = %v, %v", data, err)
+	}
+	if data, err := archive.LoadData(text.Hash); err != nil || data != nil {
+		t.Errorf("expected no data for text entry, got %v, %v", data, err)
+	}
+
+	if err := archive.Delete(text.Hash); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := archive.Delete(text.Hash); err == nil {
+		t.Error("expected error deleting missing entry")
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	in := bytes.Repeat([]byte("clipboard "), 100)
+	packed, err := compress(in)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if len(packed) >= len(in) {
+		t.Errorf("expected repetitive content to shrink, %d >= %d", len(packed), len(in))
+	}
+	out, err := decompress(packed)
+	if err != nil || !bytes.Equal(out, in) {
+		t.Errorf("round trip failed: %v", err)
+	}
+}
@@ -1,9 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -15,30 +19,127 @@ type ClipboardEntry struct {
 	Hash      string
 	Timestamp time.Time
 	Pinned    bool
+	Alias     string
+	Type      string
+	Kind      string    // "text" or "image"
+	MimeType  string    // MIME type of Data for binary entries
+	Data      []byte    // binary payload; only set on Insert, see LoadData
+	Size      int       // length of Data, populated by LoadAll
+	Count     int       // number of times the content has been copied
+	ExpiresAt time.Time // when the entry should be purged; zero means never
+	// OverflowSize is the length of content too large to keep in the
+	// database. When set, Content only holds a preview and the full text is
+	// stored in a file by the history package.
+	OverflowSize int
+	// Selection is the X11 selection the entry was captured from:
+	// "clipboard" (the default) or "primary".
+	Selection string
+	// SourceApp names the application focused when the entry was copied,
+	// e.g. "firefox"; empty when unknown.
+	SourceApp string
+	// Length is the length of the content the hash was computed over: the
+	// full text, or Data for binary entries. Dedupe compares it as well as
+	// the hash. Insert works it out when unset.
+	Length int
+	// Position is the entry's place in a manually ordered collection, from
+	// 1; zero means unordered (see SetPositions).
+	Position int
+	// Width and Height are an image's dimensions in pixels, zero when
+	// unknown.
+	Width, Height int
+	// Registers are the names of the registers holding the entry, e.g.
+	// "ac" (see SetRegister), populated by LoadAll.
+	Registers string
+	// Formats are the MIME types of alternate representations stored with
+	// a text entry (see SetFormats), populated by LoadAll.
+	Formats []string
+	// FormatData are alternate representations keyed by MIME type, stored
+	// by Insert together with the entry.
+	FormatData map[string][]byte
+	// Tags label the entry, e.g. "chat" for everything copied from a chat
+	// app. They may not contain commas.
+	Tags []string
+	// ID numbers the entry for good, unlike its place in the table: it is
+	// given by Insert, never reused and populated by LoadAll and Query. An
+	// ID set on Insert is kept if no other entry has it, e.g. when
+	// restoring a deleted entry.
+	ID int64
 }
 
+// ErrAliasExists is returned when an alias is already assigned to another entry.
+var ErrAliasExists = errors.New("alias already in use")
+
 // DBClient is the interface implemented by all persistence backends.
 type DBClient interface {
 	Insert(entry ClipboardEntry) error
 	Delete(hash string) error
+	Clear(keepPinned bool) error
 	LoadAll() ([]ClipboardEntry, error)
 	SetPinned(hash string, pinned bool) error
+	SetPositions(hashes []string) error
+	SetAlias(hash, alias string) error
+	SetRegister(name, hash string) error
+	EntryID(hash string) (int64, error)
+	LoadData(hash string) ([]byte, error)
+	Bump(hash string, timestamp time.Time) error
+	Update(entry ClipboardEntry) error
+	SetExpiry(hash string, expiresAt time.Time) error
+	SetFormats(hash string, formats map[string][]byte) error
+	LoadFormat(hash, mimeType string) ([]byte, error)
+	Query(filter Filter) ([]ClipboardEntry, error)
+	DataVersion() (int64, error)
+	Transaction(fn func(tx DBClient) error) error
+	SaveDigest(digest Digest) error
+	Digests() ([]Digest, error)
 	Close() error
 }
 
+// Filter restricts the entries returned by Query. Zero fields don't filter.
+type Filter struct {
+	Substring string    // case-insensitive (for ASCII) substring of the content
+	Types     []string  // content types; entries without a stored type always match
+	Since     time.Time // timestamp at or after
+	Until     time.Time // timestamp before
+	App       string    // case-insensitive (for ASCII) substring of the source app
+	Tag       string    // one of the entry's tags, ignoring case (for ASCII)
+}
+
 // Client handles database operations for clipboard history
 type Client struct {
-	db *sql.DB
+	db    *sql.DB
+	q     querier   // db, or tx within Transaction
+	tx    *sql.Tx   // the transaction Transaction runs in, if any
+	watch *sql.Conn // dedicated connection for DataVersion
+}
+
+// querier runs statements on the database or within a transaction
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// txn is a transaction started by begin
+type txn interface {
+	querier
+	Prepare(query string) (*sql.Stmt, error)
+	Commit() error
+	Rollback() error
 }
 
+// busyTimeout is how long a write waits for another process (e.g. the
+// daemon and the TUI) to release the database lock before failing.
+const busyTimeout = 5 * time.Second
+
 // New creates a new database client with the given database path
 func New(dbPath string) (*Client, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)", dbPath, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
-	client := &Client{db: db}
+	client := &Client{db: db, q: db}
 
 	if err := client.initialize(); err != nil {
 		if closeErr := db.Close(); closeErr != nil {
@@ -50,81 +151,210 @@ func New(dbPath string) (*Client, error) {
 	return client, nil
 }
 
-// initialize creates the necessary tables and runs migrations
+// OpenReadOnly opens the database at dbPath for reading only, e.g. to
+// import from it. Unlike New it never changes the file, so it refuses a
+// database whose schema isn't the one this version of clippy writes.
+func OpenReadOnly(dbPath string) (*Client, error) {
+	dsn := fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(%d)", dbPath, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	current, err := schemaVersion(db)
+	latest := historyMigrations[len(historyMigrations)-1].version
+	switch {
+	case err != nil:
+	case current < latest:
+		err = fmt.Errorf("database schema version %d is older than this version of clippy uses (%d); open it with clippy once to upgrade it", current, latest)
+	case current > latest:
+		err = fmt.Errorf("database schema version %d is newer than this version of clippy supports (%d)", current, latest)
+	}
+	if err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Failed to close database: %v", closeErr)
+		}
+		return nil, err
+	}
+	return &Client{db: db, q: db}, nil
+}
+
+// initialize brings the schema up to date
 func (c *Client) initialize() error {
-	if err := c.migrate(); err != nil {
+	if err := migrate(c.db, historyMigrations); err != nil {
 		return fmt.Errorf("error migrating schema: %w", err)
 	}
-
-	schema := `
-	CREATE TABLE IF NOT EXISTS clipboard_history (
-		hash TEXT PRIMARY KEY,
-		content TEXT NOT NULL,
-		timestamp DATETIME NOT NULL,
-		pinned INTEGER NOT NULL DEFAULT 0
-	);
-	CREATE INDEX IF NOT EXISTS idx_timestamp ON clipboard_history(timestamp ASC);
-	`
-
-	_, err := c.db.Exec(schema)
-	return err
+	return nil
 }
 
-// migrate handles schema migrations for existing databases
-func (c *Client) migrate() error {
-	// Check if table exists
-	var tableExists bool
-	row := c.db.QueryRow(`
-		SELECT COUNT(*) > 0
-		FROM sqlite_master
-		WHERE type='table' AND name='clipboard_history'
-	`)
-	if err := row.Scan(&tableExists); err != nil || !tableExists {
-		return nil
+// Close closes the database connection
+func (c *Client) Close() error {
+	if c.watch != nil {
+		if err := c.watch.Close(); err != nil {
+			log.Printf("Failed to close watch connection: %v", err)
+		}
 	}
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
 
-	// Add pinned column if missing (migration from count-based schema)
-	var hasPinned bool
-	row = c.db.QueryRow(`
-		SELECT COUNT(*) > 0
-		FROM pragma_table_info('clipboard_history')
-		WHERE name = 'pinned'
-	`)
-	if err := row.Scan(&hasPinned); err != nil {
+// Transaction runs fn with a client whose changes are all committed once
+// fn returns nil, or all rolled back if it returns an error.
+func (c *Client) Transaction(fn func(tx DBClient) error) error {
+	if c.tx != nil {
+		return fn(c)
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
 		return err
 	}
-	if !hasPinned {
-		_, err := c.db.Exec(`ALTER TABLE clipboard_history ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`)
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back transaction: %v", err)
+		}
+	}()
+	if err := fn(&Client{db: c.db, q: tx, tx: tx}); err != nil {
 		return err
 	}
+	return tx.Commit()
+}
 
-	return nil
+// begin starts a transaction, or a savepoint within the one Transaction
+// runs in, so a failed statement still undoes only its own changes
+func (c *Client) begin() (txn, error) {
+	if c.tx == nil {
+		return c.db.Begin()
+	}
+	if _, err := c.tx.Exec("SAVEPOINT nested"); err != nil {
+		return nil, err
+	}
+	return &savepoint{Tx: c.tx}, nil
 }
 
-// Close closes the database connection
-func (c *Client) Close() error {
-	if c.db != nil {
-		return c.db.Close()
+// savepoint is a transaction nested in another
+type savepoint struct {
+	*sql.Tx
+	done bool
+}
+
+func (s *savepoint) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
 	}
-	return nil
+	s.done = true
+	_, err := s.Exec("RELEASE nested")
+	return err
+}
+
+func (s *savepoint) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	if _, err := s.Exec("ROLLBACK TO nested"); err != nil {
+		return err
+	}
+	_, err := s.Exec("RELEASE nested")
+	return err
+}
+
+// DataVersion returns a number that changes whenever another connection,
+// such as another clippy process, commits to the database.
+func (c *Client) DataVersion() (int64, error) {
+	// data_version is per connection, so always ask the same one
+	if c.watch == nil {
+		conn, err := c.db.Conn(context.Background())
+		if err != nil {
+			return 0, fmt.Errorf("error opening watch connection: %w", err)
+		}
+		c.watch = conn
+	}
+	var version int64
+	if err := c.watch.QueryRowContext(context.Background(), `PRAGMA data_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading data version: %w", err)
+	}
+	return version, nil
 }
 
-// Insert adds a new clipboard entry to the database
+// Insert adds a new clipboard entry, with its FormatData, to the database.
+// Nothing is stored unless all of it is.
 func (c *Client) Insert(entry ClipboardEntry) error {
 	pinned := 0
 	if entry.Pinned {
 		pinned = 1
 	}
-	_, err := c.db.Exec(
-		"INSERT INTO clipboard_history (hash, content, timestamp, pinned) VALUES (?, ?, ?, ?)",
-		entry.Hash, entry.Content, entry.Timestamp, pinned,
-	)
-	return err
+	kind := entry.Kind
+	if kind == "" {
+		kind = "text"
+	}
+	count := max(entry.Count, 1)
+	selection := entry.Selection
+	if selection == "" {
+		selection = "clipboard"
+	}
+	length := entry.Length
+	if length == 0 {
+		length = contentLength(entry)
+	}
+
+	tx, err := c.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back insert: %v", err)
+		}
+	}()
+	if _, err := tx.Exec(
+		"INSERT INTO clipboard_history (hash, content, timestamp, pinned, content_type, kind, mime_type, data, count, expires_at, overflow_size, selection, source_app, content_length, width, height, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.Hash, entry.Content, entry.Timestamp, pinned, entry.Type, kind, entry.MimeType, entry.Data, count, nullTime(entry.ExpiresAt), entry.OverflowSize, selection, entry.SourceApp, length, entry.Width, entry.Height, strings.Join(entry.Tags, ","),
+	); err != nil {
+		return err
+	}
+	// A restored entry gets its old ID back unless it has been taken
+	if entry.ID != 0 {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO entry_ids (id, hash) VALUES (?, ?)", entry.ID, entry.Hash); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("INSERT OR IGNORE INTO entry_ids (hash) VALUES (?)", entry.Hash); err != nil {
+		return err
+	}
+	for mimeType, data := range entry.FormatData {
+		if _, err := tx.Exec("INSERT INTO formats (hash, mime_type, data) VALUES (?, ?, ?)", entry.Hash, mimeType, data); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-// Delete removes a clipboard entry by hash
+// contentLength is the length of entry's hashed content as stored
+func contentLength(entry ClipboardEntry) int {
+	switch {
+	case entry.Data != nil:
+		return len(entry.Data)
+	case entry.OverflowSize > 0:
+		return entry.OverflowSize
+	}
+	return len(entry.Content)
+}
+
+// Delete removes a clipboard entry by hash, along with its ID, any alias
+// or register pointing at it and its alternate formats, in a single
+// transaction
 func (c *Client) Delete(hash string) error {
-	res, err := c.db.Exec("DELETE FROM clipboard_history WHERE hash = ?", hash)
+	tx, err := c.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back delete: %v", err)
+		}
+	}()
+	res, err := tx.Exec("DELETE FROM clipboard_history WHERE hash = ?", hash)
 	if err != nil {
 		return err
 	}
@@ -135,15 +365,63 @@ func (c *Client) Delete(hash string) error {
 	if n == 0 {
 		return fmt.Errorf("clip with hash %s not found", hash)
 	}
-	return nil
+	for _, table := range []string{"aliases", "registers", "entry_ids", "formats"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE hash = ?", hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-// LoadAll retrieves all clipboard entries ordered by timestamp ascending
+// Clear removes every entry, or every unpinned one if keepPinned is set,
+// with their IDs, aliases, registers and formats, in a single transaction.
+func (c *Client) Clear(keepPinned bool) error {
+	cleared := "SELECT hash FROM clipboard_history"
+	if keepPinned {
+		cleared += " WHERE pinned = 0"
+	}
+	tx, err := c.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back clear: %v", err)
+		}
+	}()
+	for _, table := range []string{"aliases", "registers", "entry_ids", "formats", "clipboard_history"} {
+		if _, err := tx.Exec("DELETE FROM " + table + " WHERE hash IN (" + cleared + ")"); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// selectEntries selects the columns read by scanEntries
+const selectEntries = `
+		SELECT h.content, h.hash, h.timestamp, h.pinned, h.content_type, h.kind, h.mime_type,
+			COALESCE(LENGTH(h.data), 0), h.count, COALESCE(a.alias, ''), h.expires_at, h.overflow_size, h.selection, h.source_app, h.content_length, h.position, h.width, h.height, h.tags,
+			COALESCE((SELECT GROUP_CONCAT(f.mime_type, ' ') FROM formats f WHERE f.hash = h.hash), ''),
+			COALESCE((SELECT GROUP_CONCAT(r.name, '') FROM registers r WHERE r.hash = h.hash), ''),
+			COALESCE(e.id, 0)
+		FROM clipboard_history h
+		LEFT JOIN aliases a ON a.hash = h.hash
+		LEFT JOIN entry_ids e ON e.hash = h.hash`
+
+// LoadAll retrieves all clipboard entries ordered by timestamp ascending.
+// Binary payloads are not loaded; use LoadData to fetch them on demand.
 func (c *Client) LoadAll() ([]ClipboardEntry, error) {
-	rows, err := c.db.Query("SELECT content, hash, timestamp, pinned FROM clipboard_history ORDER BY timestamp ASC")
+	rows, err := c.q.Query(selectEntries + `
+		ORDER BY h.timestamp ASC
+	`)
 	if err != nil {
 		return nil, fmt.Errorf("error querying history: %w", err)
 	}
+	return scanEntries(rows)
+}
+
+// scanEntries reads rows selected by LoadAll or Query and closes them
+func scanEntries(rows *sql.Rows) ([]ClipboardEntry, error) {
 	defer func() {
 		if err := rows.Close(); err != nil {
 			log.Printf("Failed to close rows: %v", err)
@@ -154,23 +432,199 @@ func (c *Client) LoadAll() ([]ClipboardEntry, error) {
 	for rows.Next() {
 		var entry ClipboardEntry
 		var pinnedInt int
-		if err := rows.Scan(&entry.Content, &entry.Hash, &entry.Timestamp, &pinnedInt); err != nil {
+		var expiresAt sql.NullTime
+		var tags, formats, registers string
+		if err := rows.Scan(&entry.Content, &entry.Hash, &entry.Timestamp, &pinnedInt, &entry.Type, &entry.Kind, &entry.MimeType, &entry.Size, &entry.Count, &entry.Alias, &expiresAt, &entry.OverflowSize, &entry.Selection, &entry.SourceApp, &entry.Length, &entry.Position, &entry.Width, &entry.Height, &tags, &formats, &registers, &entry.ID); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
+		entry.ExpiresAt = expiresAt.Time
+		entry.Count = max(entry.Count, 1)
 		entry.Pinned = pinnedInt != 0
+		if tags != "" {
+			entry.Tags = strings.Split(tags, ",")
+		}
+		if formats != "" {
+			entry.Formats = strings.Fields(formats)
+			slices.Sort(entry.Formats)
+		}
+		names := []byte(registers)
+		slices.Sort(names)
+		entry.Registers = string(names)
 		entries = append(entries, entry)
 	}
 
 	return entries, rows.Err()
 }
 
-// SetPinned updates the pinned state for a clipboard entry
+// Query returns the entries passing filter, pinned first, then by position
+// for entries that have one and by timestamp ascending for the rest. Like LoadAll it does not load binary payloads.
+// Entries stored before content types were recorded have an empty Type and
+// are returned for any type filter, so callers can classify them.
+func (c *Client) Query(filter Filter) ([]ClipboardEntry, error) {
+	var where []string
+	var args []any
+	if filter.Substring != "" {
+		where = append(where, `h.content LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.Substring)+"%")
+	}
+	if filter.App != "" {
+		where = append(where, `h.source_app LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.App)+"%")
+	}
+	if filter.Tag != "" {
+		where = append(where, `(',' || h.tags || ',') LIKE ? ESCAPE '\'`)
+		args = append(args, "%,"+escapeLike(filter.Tag)+",%")
+	}
+	if len(filter.Types) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Types)), ", ")
+		where = append(where, "(h.content_type IN ("+placeholders+") OR h.content_type = '')")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	// Timestamps are stored as text in the zone they were captured in
+	// ("2006-01-02 15:04:05 -0700 MST"), which SQLite's date functions can't
+	// parse. Compare the wall-clock prefix against bounds widened by the
+	// largest UTC offset, then apply the exact bounds after scanning.
+	if !filter.Since.IsZero() {
+		where = append(where, "substr(h.timestamp, 1, 19) >= ?")
+		args = append(args, filter.Since.UTC().Add(-maxZoneOffset).Format(wallClockLayout))
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "substr(h.timestamp, 1, 19) <= ?")
+		args = append(args, filter.Until.UTC().Add(maxZoneOffset).Format(wallClockLayout))
+	}
+
+	query := selectEntries
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n\t\tORDER BY h.pinned DESC, h.position = 0, h.position, h.timestamp ASC"
+
+	rows, err := c.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying history: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil || (filter.Since.IsZero() && filter.Until.IsZero()) {
+		return entries, err
+	}
+
+	inRange := entries[:0]
+	for _, e := range entries {
+		if !filter.Since.IsZero() && e.Timestamp.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !e.Timestamp.Before(filter.Until) {
+			continue
+		}
+		inRange = append(inRange, e)
+	}
+	return inRange, nil
+}
+
+// Bounds for the coarse timestamp comparison in Query
+const (
+	wallClockLayout = "2006-01-02 15:04:05"
+	maxZoneOffset   = 14 * time.Hour
+)
+
+// escapeLike escapes LIKE wildcards so s matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// LoadData returns the binary payload stored for the entry with the given hash
+func (c *Client) LoadData(hash string) ([]byte, error) {
+	var data []byte
+	err := c.q.QueryRow("SELECT data FROM clipboard_history WHERE hash = ?", hash).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("clip with hash %s not found", hash)
+	}
+	return data, err
+}
+
+// EntryID returns the ID Insert gave the entry with hash.
+func (c *Client) EntryID(hash string) (int64, error) {
+	var id int64
+	err := c.q.QueryRow("SELECT id FROM entry_ids WHERE hash = ?", hash).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("clip with hash %s not found", hash)
+	}
+	return id, err
+}
+
+// Bump records another copy of an existing entry: its timestamp is moved to
+// timestamp and its count incremented
+func (c *Client) Bump(hash string, timestamp time.Time) error {
+	res, err := c.q.Exec("UPDATE clipboard_history SET timestamp = ?, count = MAX(count, 1) + 1 WHERE hash = ?", timestamp, hash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("clip with hash %s not found", hash)
+	}
+	return nil
+}
+
+// Update overwrites the timestamp, pinned state and count of an existing entry
+func (c *Client) Update(entry ClipboardEntry) error {
+	pinned := 0
+	if entry.Pinned {
+		pinned = 1
+	}
+	res, err := c.q.Exec(
+		"UPDATE clipboard_history SET timestamp = ?, pinned = ?, count = ? WHERE hash = ?",
+		entry.Timestamp, pinned, max(entry.Count, 1), entry.Hash,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("clip with hash %s not found", entry.Hash)
+	}
+	return nil
+}
+
+// SetExpiry sets when the entry with the given hash expires. A zero
+// expiresAt clears the expiry.
+func (c *Client) SetExpiry(hash string, expiresAt time.Time) error {
+	res, err := c.q.Exec("UPDATE clipboard_history SET expires_at = ? WHERE hash = ?", nullTime(expiresAt), hash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("clip with hash %s not found", hash)
+	}
+	return nil
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// SetPinned updates the pinned state for a clipboard entry. Unpinning
+// clears its position.
 func (c *Client) SetPinned(hash string, pinned bool) error {
 	pinnedInt := 0
 	if pinned {
 		pinnedInt = 1
 	}
-	res, err := c.db.Exec("UPDATE clipboard_history SET pinned = ? WHERE hash = ?", pinnedInt, hash)
+	// An unpinned entry leaves the pinned order
+	res, err := c.q.Exec("UPDATE clipboard_history SET pinned = ?, position = CASE WHEN ? THEN position ELSE 0 END WHERE hash = ?", pinnedInt, pinnedInt, hash)
 	if err != nil {
 		return err
 	}
@@ -183,3 +637,100 @@ func (c *Client) SetPinned(hash string, pinned bool) error {
 	}
 	return nil
 }
+
+// SetPositions orders the entries with the given hashes as listed,
+// numbering their positions from 1 in a single transaction. Hashes not
+// found are skipped.
+func (c *Client) SetPositions(hashes []string) error {
+	tx, err := c.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back reindex: %v", err)
+		}
+	}()
+	stmt, err := tx.Prepare("UPDATE clipboard_history SET position = ? WHERE hash = ?")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			log.Printf("Failed to close statement: %v", err)
+		}
+	}()
+	for i, hash := range hashes {
+		if _, err := stmt.Exec(i+1, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetRegister stores the entry with the given hash in the named register,
+// replacing whatever it held. An empty hash clears the register.
+func (c *Client) SetRegister(name, hash string) error {
+	if hash == "" {
+		_, err := c.q.Exec("DELETE FROM registers WHERE name = ?", name)
+		return err
+	}
+	res, err := c.q.Exec(`
+		INSERT OR REPLACE INTO registers (name, hash)
+		SELECT ?, hash FROM clipboard_history WHERE hash = ?`, name, hash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("clip with hash %s not found", hash)
+	}
+	return nil
+}
+
+// SetAlias assigns an alias to the entry with the given hash, replacing any
+// alias it already had. An empty alias removes the entry's alias.
+// Returns ErrAliasExists if the alias belongs to a different entry.
+func (c *Client) SetAlias(hash, alias string) error {
+	tx, err := c.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back alias update: %v", err)
+		}
+	}()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT COUNT(*) > 0 FROM clipboard_history WHERE hash = ?", hash).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("clip with hash %s not found", hash)
+	}
+
+	if alias != "" {
+		var owner string
+		err := tx.QueryRow("SELECT hash FROM aliases WHERE alias = ?", alias).Scan(&owner)
+		switch {
+		case err == nil && owner != hash:
+			return fmt.Errorf("%w: %s", ErrAliasExists, alias)
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM aliases WHERE hash = ?", hash); err != nil {
+		return err
+	}
+	if alias != "" {
+		if _, err := tx.Exec("INSERT INTO aliases (alias, hash) VALUES (?, ?)", alias, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -2,8 +2,10 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -215,6 +217,45 @@ func TestSetPinned_NotFound(t *testing.T) {
 	}
 }
 
+func TestSetPositions(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	for _, content := range []string{"a", "b", "c"} {
+		entry := makeEntry(content)
+		entry.Pinned = true
+		if err := client.Insert(entry); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	if err := client.SetPositions([]string{"c-hash", "a-hash", "b-hash"}); err != nil {
+		t.Fatalf("SetPositions: %v", err)
+	}
+	entries, err := client.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var order []string
+	for _, e := range entries {
+		order = append(order, e.Content)
+	}
+	if strings.Join(order, "") != "cab" {
+		t.Errorf("expected entries in position order cab, got %v", order)
+	}
+	if entries[0].Position != 1 || entries[2].Position != 3 {
+		t.Errorf("expected positions numbered from 1, got %+v", entries)
+	}
+
+	// Unpinning drops the entry from the order
+	if err := client.SetPinned("c-hash", false); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	entries, _ = client.Query(Filter{})
+	if last := entries[len(entries)-1]; last.Content != "c" || last.Position != 0 {
+		t.Errorf("expected the unpinned entry last without a position, got %+v", last)
+	}
+}
+
 func TestMigrate_AddsPinnedColumn(t *testing.T) {
 	dir, err := os.MkdirTemp("", "clippy_db_migrate_test")
 	if err != nil {
@@ -275,4 +316,583 @@ func TestMigrate_AddsPinnedColumn(t *testing.T) {
 	if entries[0].Pinned {
 		t.Error("expected Pinned=false for migrated entry")
 	}
+	if entries[0].Type != "" {
+		t.Errorf("expected empty Type for migrated entry, got %q", entries[0].Type)
+	}
+	if entries[0].Count != 1 {
+		t.Errorf("expected legacy count 0 to load as 1, got %d", entries[0].Count)
+	}
+}
+
+func TestSetAlias(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.Insert(makeEntry("a")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := client.Insert(makeEntry("b")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	if err := client.SetAlias("a-hash", "first"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if err := client.SetAlias("b-hash", "first"); !errors.Is(err, ErrAliasExists) {
+		t.Errorf("SetAlias duplicate = %v, want ErrAliasExists", err)
+	}
+
+	// Renaming replaces the previous alias
+	if err := client.SetAlias("a-hash", "renamed"); err != nil {
+		t.Fatalf("SetAlias rename: %v", err)
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if loaded[0].Alias != "renamed" {
+		t.Errorf("alias = %q, want %q", loaded[0].Alias, "renamed")
+	}
+	if loaded[1].Alias != "" {
+		t.Errorf("alias = %q, want empty", loaded[1].Alias)
+	}
+
+	// The old alias is free again
+	if err := client.SetAlias("b-hash", "first"); err != nil {
+		t.Errorf("SetAlias freed alias: %v", err)
+	}
+}
+
+func TestSetAlias_NotFound(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.SetAlias("nonexistent-hash", "x"); err == nil {
+		t.Error("expected error setting alias on nonexistent hash, got nil")
+	}
+}
+
+func TestDelete_RemovesAlias(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.Insert(makeEntry("a")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := client.SetAlias("a-hash", "gone"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if err := client.Delete("a-hash"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := client.Insert(makeEntry("b")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := client.SetAlias("b-hash", "gone"); err != nil {
+		t.Errorf("expected alias to be released on delete: %v", err)
+	}
+}
+
+func TestInsert_StoresType(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	entry := makeEntry("https://example.com")
+	entry.Type = "url"
+	if err := client.Insert(entry); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if loaded[0].Type != "url" {
+		t.Errorf("type = %q, want %q", loaded[0].Type, "url")
+	}
+}
+
+func TestInsert_StoresSelection(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.Insert(makeEntry("copied")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	selected := makeEntry("highlighted")
+	selected.Selection = "primary"
+	if err := client.Insert(selected); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	got := map[string]string{}
+	for _, e := range loaded {
+		got[e.Content] = e.Selection
+	}
+	if got["copied"] != "clipboard" || got["highlighted"] != "primary" {
+		t.Errorf("selections = %v, want copied=clipboard, highlighted=primary", got)
+	}
+}
+
+func TestInsert_StoresContentLength(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	text := makeEntry("héllo")
+	image := makeEntry("PNG image")
+	image.Kind, image.Data = "image", []byte{1, 2, 3}
+	large := makeEntry("preview")
+	large.OverflowSize = 5000
+	given := makeEntry("given")
+	given.Length = 42
+	for _, entry := range []ClipboardEntry{text, image, large, given} {
+		if err := client.Insert(entry); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	got := map[string]int{}
+	for _, e := range loaded {
+		got[e.Content] = e.Length
+	}
+	want := map[string]int{"héllo": 6, "PNG image": 3, "preview": 5000, "given": 42}
+	for content, length := range want {
+		if got[content] != length {
+			t.Errorf("length of %q = %d, want %d", content, got[content], length)
+		}
+	}
+}
+
+func TestInsertBinaryAndLoadData(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	entry := makeEntry("[image/png 3 B]")
+	entry.Kind = "image"
+	entry.MimeType = "image/png"
+	entry.Data = []byte{1, 2, 3}
+	if err := client.Insert(entry); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := client.Insert(makeEntry("text")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if loaded[0].Kind != "image" || loaded[0].MimeType != "image/png" || loaded[0].Size != 3 {
+		t.Errorf("unexpected binary entry: %+v", loaded[0])
+	}
+	if loaded[0].Data != nil {
+		t.Error("expected LoadAll not to load binary payloads")
+	}
+	if loaded[1].Kind != "text" || loaded[1].Size != 0 {
+		t.Errorf("unexpected text entry: %+v", loaded[1])
+	}
+
+	data, err := client.LoadData(entry.Hash)
+	if err != nil {
+		t.Fatalf("LoadData: %v", err)
+	}
+	if string(data) != string(entry.Data) {
+		t.Errorf("LoadData = %v, want %v", data, entry.Data)
+	}
+
+	if _, err := client.LoadData("missing"); err == nil {
+		t.Error("expected error loading data for missing hash")
+	}
+}
+
+func TestBump(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.Insert(makeEntry("a")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	later := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := client.Bump("a-hash", later); err != nil {
+		t.Fatalf("Bump: %v", err)
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if loaded[0].Count != 2 {
+		t.Errorf("Count = %d, want 2", loaded[0].Count)
+	}
+	if !loaded[0].Timestamp.Equal(later) {
+		t.Errorf("Timestamp = %v, want %v", loaded[0].Timestamp, later)
+	}
+
+	if err := client.Bump("missing", later); err == nil {
+		t.Error("expected error bumping missing hash")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.Insert(makeEntry("a")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	update := makeEntry("a")
+	update.Timestamp = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	update.Pinned = true
+	update.Count = 5
+	if err := client.Update(update); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	loaded, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if !loaded[0].Timestamp.Equal(update.Timestamp) || !loaded[0].Pinned || loaded[0].Count != 5 {
+		t.Errorf("unexpected entry after update: %+v", loaded[0])
+	}
+
+	if err := client.Update(makeEntry("missing")); err == nil {
+		t.Error("expected error updating missing entry")
+	}
+}
+
+func TestSetExpiry(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	entry := makeEntry("otp")
+	if err := client.Insert(entry); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	entries, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if !entries[0].ExpiresAt.IsZero() {
+		t.Errorf("expected no expiry by default, got %v", entries[0].ExpiresAt)
+	}
+
+	expiresAt := time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC)
+	if err := client.SetExpiry(entry.Hash, expiresAt); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	entries, _ = client.LoadAll()
+	if !entries[0].ExpiresAt.Equal(expiresAt) {
+		t.Errorf("ExpiresAt = %v, want %v", entries[0].ExpiresAt, expiresAt)
+	}
+
+	if err := client.SetExpiry(entry.Hash, time.Time{}); err != nil {
+		t.Fatalf("SetExpiry clear: %v", err)
+	}
+	entries, _ = client.LoadAll()
+	if !entries[0].ExpiresAt.IsZero() {
+		t.Errorf("expected expiry to be cleared, got %v", entries[0].ExpiresAt)
+	}
+
+	if err := client.SetExpiry("missing", expiresAt); err == nil {
+		t.Error("expected error for missing hash")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cet := time.FixedZone("CET", 3600)
+	entries := []ClipboardEntry{
+		{Content: "https://example.com/100%_done", Hash: "url", Timestamp: base, Type: "url", SourceApp: "firefox"},
+		{Content: "Deploy notes", Hash: "text", Timestamp: base.Add(24 * time.Hour).In(cet), Type: "text", Tags: []string{"chat", "work_notes"}},
+		{Content: "legacy deploy", Hash: "legacy", Timestamp: base.Add(48 * time.Hour), Type: ""},
+		{Content: "pinned deploy", Hash: "pinned", Timestamp: base.Add(72 * time.Hour), Type: "text", Pinned: true},
+	}
+	for _, e := range entries {
+		if err := client.Insert(e); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	hashes := func(filter Filter) []string {
+		t.Helper()
+		got, err := client.Query(filter)
+		if err != nil {
+			t.Fatalf("Query(%+v): %v", filter, err)
+		}
+		var out []string
+		for _, e := range got {
+			out = append(out, e.Hash)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all, pinned first", Filter{}, []string{"pinned", "url", "text", "legacy"}},
+		{"substring is case-insensitive", Filter{Substring: "DEPLOY"}, []string{"pinned", "text", "legacy"}},
+		{"wildcards match literally", Filter{Substring: "100%_"}, []string{"url"}},
+		{"percent alone is literal", Filter{Substring: "%x"}, nil},
+		{"type includes unclassified", Filter{Types: []string{"url"}}, []string{"url", "legacy"}},
+		{"date range across zones", Filter{Since: base.Add(time.Hour), Until: base.Add(49 * time.Hour)}, []string{"text", "legacy"}},
+		{"source app is a case-insensitive substring", Filter{App: "FireF"}, []string{"url"}},
+		{"tag matches a whole tag ignoring case", Filter{Tag: "CHAT"}, []string{"text"}},
+		{"tag is not a substring", Filter{Tag: "cha"}, nil},
+		{"tag wildcards match literally", Filter{Tag: "work%"}, nil},
+		{"combined", Filter{Substring: "deploy", Types: []string{"text"}, Since: base.Add(60 * time.Hour)}, []string{"pinned"}},
+	}
+	for _, tt := range tests {
+		got := hashes(tt.filter)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDataVersion_ChangesOnOtherWrites(t *testing.T) {
+	client, path, cleanup := setupClient(t)
+	defer cleanup()
+
+	before, err := client.DataVersion()
+	if err != nil {
+		t.Fatalf("DataVersion: %v", err)
+	}
+	if again, _ := client.DataVersion(); again != before {
+		t.Errorf("DataVersion changed without writes: %d -> %d", before, again)
+	}
+
+	other, err := New(path)
+	if err != nil {
+		t.Fatalf("open second client: %v", err)
+	}
+	defer func() {
+		if err := other.Close(); err != nil {
+			t.Logf("close second client: %v", err)
+		}
+	}()
+	if err := other.Insert(makeEntry("from another process")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if after, _ := client.DataVersion(); after == before {
+		t.Error("expected DataVersion to change after another connection wrote")
+	}
+}
+
+func TestSetRegister(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	for _, content := range []string{"one", "two"} {
+		if err := client.Insert(makeEntry(content)); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	for _, name := range []string{"c", "a"} {
+		if err := client.SetRegister(name, "one-hash"); err != nil {
+			t.Fatalf("SetRegister: %v", err)
+		}
+	}
+	entries, _ := client.LoadAll()
+	if entries[0].Registers != "ac" || entries[1].Registers != "" {
+		t.Errorf("registers = %q, %q; want \"ac\" and none", entries[0].Registers, entries[1].Registers)
+	}
+
+	// Storing another entry replaces the register's content
+	if err := client.SetRegister("a", "two-hash"); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	if err := client.SetRegister("c", ""); err != nil {
+		t.Fatalf("clear register: %v", err)
+	}
+	entries, _ = client.LoadAll()
+	if entries[0].Registers != "" || entries[1].Registers != "a" {
+		t.Errorf("registers = %q, %q; want none and \"a\"", entries[0].Registers, entries[1].Registers)
+	}
+
+	if err := client.SetRegister("b", "missing-hash"); err == nil {
+		t.Error("expected an error storing a missing entry")
+	}
+	if err := client.Delete("two-hash"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := client.SetRegister("z", "one-hash"); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	entries, _ = client.LoadAll()
+	if len(entries) != 1 || entries[0].Registers != "z" {
+		t.Errorf("expected the deleted entry's register dropped, got %+v", entries)
+	}
+}
+
+func TestEntryIDs(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	for _, content := range []string{"a", "b"} {
+		if err := client.Insert(makeEntry(content)); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	a, err := client.EntryID("a-hash")
+	if err != nil {
+		t.Fatalf("EntryID: %v", err)
+	}
+	b, err := client.EntryID("b-hash")
+	if err != nil || b <= a {
+		t.Fatalf("EntryID(b) = %d, %v; want an ID after a's %d", b, err, a)
+	}
+
+	// The newest entry's ID isn't handed out again once it's deleted
+	if err := client.Delete("b-hash"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := client.EntryID("b-hash"); err == nil {
+		t.Error("expected no ID for a deleted entry")
+	}
+	if err := client.Insert(makeEntry("c")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	entries, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	ids := map[string]int64{}
+	for _, e := range entries {
+		ids[e.Content] = e.ID
+	}
+	if ids["a"] != a || ids["c"] <= b {
+		t.Errorf("IDs = %v, want a = %d and c after %d", ids, a, b)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	failed := errors.New("failed halfway")
+	err := client.Transaction(func(tx DBClient) error {
+		if err := tx.Insert(makeEntry("first")); err != nil {
+			return err
+		}
+		if err := tx.SetAlias("first-hash", "one"); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Transaction = %v, want fn's error", err)
+	}
+	if entries, _ := client.LoadAll(); len(entries) != 0 {
+		t.Fatalf("expected the insert rolled back, got %d entries", len(entries))
+	}
+
+	err = client.Transaction(func(tx DBClient) error {
+		if err := tx.Insert(makeEntry("first")); err != nil {
+			return err
+		}
+		// A failed statement only undoes its own changes
+		if err := tx.Insert(makeEntry("first")); err == nil {
+			t.Error("expected a duplicate insert to fail")
+		}
+		return tx.Insert(makeEntry("second"))
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	if entries, _ := client.LoadAll(); len(entries) != 2 {
+		t.Errorf("got %d entries, want both committed", len(entries))
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	client, path, cleanup := setupClient(t)
+	defer cleanup()
+	if err := client.Insert(makeEntry("stored")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	ro, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	if entries, err := ro.LoadAll(); err != nil || len(entries) != 1 {
+		t.Errorf("LoadAll = %d entries, %v; want 1", len(entries), err)
+	}
+	if err := ro.Insert(makeEntry("new")); err == nil {
+		t.Error("expected a write to a read-only database to fail")
+	}
+	if err := ro.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A database from an older clippy is refused rather than migrated
+	if _, err := client.db.Exec("DELETE FROM schema_migrations WHERE version = (SELECT MAX(version) FROM schema_migrations)"); err != nil {
+		t.Fatal(err)
+	}
+	before, err := schemaVersion(client.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenReadOnly(path); err == nil || !strings.Contains(err.Error(), "older") {
+		t.Errorf("OpenReadOnly = %v, want the old schema refused", err)
+	}
+	if after, _ := schemaVersion(client.db); after != before {
+		t.Errorf("schema version changed from %d to %d", before, after)
+	}
+}
+
+func TestClear(t *testing.T) {
+	for _, keepPinned := range []bool{true, false} {
+		client, _, cleanup := setupClient(t)
+		for _, content := range []string{"pinned", "plain"} {
+			if err := client.Insert(makeEntry(content)); err != nil {
+				t.Fatalf("Insert: %v", err)
+			}
+			if err := client.SetAlias(content+"-hash", content); err != nil {
+				t.Fatalf("SetAlias: %v", err)
+			}
+		}
+		if err := client.SetPinned("pinned-hash", true); err != nil {
+			t.Fatalf("SetPinned: %v", err)
+		}
+
+		if err := client.Clear(keepPinned); err != nil {
+			t.Fatalf("Clear(%v): %v", keepPinned, err)
+		}
+		entries, err := client.LoadAll()
+		if err != nil {
+			t.Fatalf("LoadAll: %v", err)
+		}
+		want := 0
+		if keepPinned {
+			want = 1
+		}
+		if len(entries) != want {
+			t.Errorf("Clear(%v) left %d entries, want %d", keepPinned, len(entries), want)
+		}
+		if keepPinned && entries[0].Alias != "pinned" {
+			t.Errorf("Clear(true) left %+v, want the pinned entry with its alias", entries[0])
+		}
+		var aliases int
+		if err := client.db.QueryRow("SELECT COUNT(*) FROM aliases").Scan(&aliases); err != nil || aliases != want {
+			t.Errorf("%d aliases left, %v; want %d", aliases, err, want)
+		}
+		cleanup()
+	}
 }
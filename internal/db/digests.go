@@ -0,0 +1,70 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Digest summarizes the entries of one day that were compacted out of
+// history.
+type Digest struct {
+	Day     string         // local date, e.g. "2024-03-01"
+	Entries int            // entries compacted
+	Copies  int            // times they were copied
+	Bytes   int            // their combined content length
+	Types   map[string]int // entries by content type
+	Top     []DigestItem   // the most copied entries, most first; may be empty
+}
+
+// DigestItem is one of the most copied entries of a digest's day.
+type DigestItem struct {
+	Preview string `json:"preview"`
+	Count   int    `json:"count"`
+}
+
+// SaveDigest stores digest, replacing any digest for the same day.
+func (c *Client) SaveDigest(digest Digest) error {
+	types, err := json.Marshal(digest.Types)
+	if err != nil {
+		return err
+	}
+	top, err := json.Marshal(digest.Top)
+	if err != nil {
+		return err
+	}
+	_, err = c.q.Exec(`
+		INSERT OR REPLACE INTO digests (day, entries, copies, bytes, types, top)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		digest.Day, digest.Entries, digest.Copies, digest.Bytes, string(types), string(top))
+	return err
+}
+
+// Digests returns every digest, oldest day first.
+func (c *Client) Digests() ([]Digest, error) {
+	rows, err := c.q.Query("SELECT day, entries, copies, bytes, types, top FROM digests ORDER BY day")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Failed to close rows: %v", err)
+		}
+	}()
+	var digests []Digest
+	for rows.Next() {
+		var digest Digest
+		var types, top string
+		if err := rows.Scan(&digest.Day, &digest.Entries, &digest.Copies, &digest.Bytes, &types, &top); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(types), &digest.Types); err != nil {
+			return nil, fmt.Errorf("digest %s: types: %w", digest.Day, err)
+		}
+		if err := json.Unmarshal([]byte(top), &digest.Top); err != nil {
+			return nil, fmt.Errorf("digest %s: top: %w", digest.Day, err)
+		}
+		digests = append(digests, digest)
+	}
+	return digests, rows.Err()
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSaveDigest(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if digests, err := client.Digests(); err != nil || len(digests) != 0 {
+		t.Fatalf("Digests on a new database = %v, %v; want none", digests, err)
+	}
+
+	later := Digest{Day: "2024-03-02", Entries: 1, Copies: 1, Bytes: 5, Types: map[string]int{"text": 1}, Top: []DigestItem{}}
+	first := Digest{Day: "2024-03-01", Entries: 2, Copies: 5, Bytes: 40, Types: map[string]int{"url": 2}}
+	for _, d := range []Digest{later, first} {
+		if err := client.SaveDigest(d); err != nil {
+			t.Fatalf("SaveDigest: %v", err)
+		}
+	}
+	// Saving a day again replaces it
+	first.Entries, first.Top = 3, []DigestItem{{Preview: "https://example.com", Count: 4}}
+	if err := client.SaveDigest(first); err != nil {
+		t.Fatalf("SaveDigest: %v", err)
+	}
+
+	digests, err := client.Digests()
+	if err != nil {
+		t.Fatalf("Digests: %v", err)
+	}
+	if want := []Digest{first, later}; !reflect.DeepEqual(digests, want) {
+		t.Errorf("Digests = %+v, want %+v", digests, want)
+	}
+}
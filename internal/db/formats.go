@@ -0,0 +1,51 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// SetFormats replaces the alternate representations stored for the entry
+// with the given hash, keyed by MIME type, such as the HTML a text entry
+// was copied with.
+func (c *Client) SetFormats(hash string, formats map[string][]byte) error {
+	tx, err := c.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Failed to roll back formats update: %v", err)
+		}
+	}()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT COUNT(*) > 0 FROM clipboard_history WHERE hash = ?", hash).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("clip with hash %s not found", hash)
+	}
+	if _, err := tx.Exec("DELETE FROM formats WHERE hash = ?", hash); err != nil {
+		return err
+	}
+	for mimeType, data := range formats {
+		if _, err := tx.Exec("INSERT INTO formats (hash, mime_type, data) VALUES (?, ?, ?)", hash, mimeType, data); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadFormat returns the representation of the entry with the given hash
+// stored as mimeType.
+func (c *Client) LoadFormat(hash, mimeType string) ([]byte, error) {
+	var data []byte
+	err := c.q.QueryRow("SELECT data FROM formats WHERE hash = ? AND mime_type = ?", hash, mimeType).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("clip with hash %s has no %s format", hash, mimeType)
+	}
+	return data, err
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetFormats(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	if err := client.Insert(makeEntry("a")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	formats := map[string][]byte{
+		"text/uri-list": []byte("file:///tmp/a"),
+		"text/html":     []byte("<b>a</b>"),
+	}
+	if err := client.SetFormats("a-hash", formats); err != nil {
+		t.Fatalf("SetFormats: %v", err)
+	}
+
+	entries, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if want := []string{"text/html", "text/uri-list"}; !slices.Equal(entries[0].Formats, want) {
+		t.Errorf("Formats = %v, want %v", entries[0].Formats, want)
+	}
+	data, err := client.LoadFormat("a-hash", "text/html")
+	if err != nil || string(data) != "<b>a</b>" {
+		t.Errorf("LoadFormat = %q, %v", data, err)
+	}
+	if _, err := client.LoadFormat("a-hash", "image/png"); err == nil {
+		t.Error("expected an error loading a format that isn't stored")
+	}
+	if err := client.SetFormats("missing-hash", formats); err == nil {
+		t.Error("expected an error setting formats of a missing entry")
+	}
+
+	if err := client.Delete("a-hash"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := client.LoadFormat("a-hash", "text/html"); err == nil {
+		t.Error("expected formats to be deleted with their entry")
+	}
+}
+
+func TestInsertStoresFormatsAtomically(t *testing.T) {
+	client, _, cleanup := setupClient(t)
+	defer cleanup()
+
+	entry := makeEntry("a")
+	entry.FormatData = map[string][]byte{"text/html": []byte("<b>a</b>")}
+	if err := client.Insert(entry); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if data, err := client.LoadFormat("a-hash", "text/html"); err != nil || string(data) != "<b>a</b>" {
+		t.Errorf("LoadFormat = %q, %v; want the format inserted with the entry", data, err)
+	}
+
+	// A failed insert stores none of its formats
+	entry.FormatData = map[string][]byte{"text/uri-list": []byte("file:///tmp/a")}
+	if err := client.Insert(entry); err == nil {
+		t.Fatal("expected inserting the same hash again to fail")
+	}
+	if _, err := client.LoadFormat("a-hash", "text/uri-list"); err == nil {
+		t.Error("expected the failed insert's format to be rolled back")
+	}
+}
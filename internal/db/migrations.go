@@ -0,0 +1,308 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migration is one versioned schema change. Migrations are append-only:
+// once released, a migration must never be edited or reordered, since
+// databases record which versions they have applied.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// historyMigrations builds the clipboard history schema. Databases created
+// before versioning was introduced may already have any of the columns
+// added by versions 2-7, so those migrations tolerate existing columns;
+// later ones can assume every earlier version has run.
+var historyMigrations = []migration{
+	{1, "create tables", execSQL(`
+		CREATE TABLE IF NOT EXISTS clipboard_history (
+			hash TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			timestamp DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_timestamp ON clipboard_history(timestamp ASC);
+		CREATE TABLE IF NOT EXISTS aliases (
+			alias TEXT PRIMARY KEY,
+			hash TEXT NOT NULL UNIQUE
+		);
+	`)},
+	{2, "add pinned", addColumn("clipboard_history", "pinned", "INTEGER NOT NULL DEFAULT 0")},
+	// Existing rows are classified on load
+	{3, "add content_type", addColumn("clipboard_history", "content_type", "TEXT NOT NULL DEFAULT ''")},
+	{4, "add binary entries", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "clipboard_history", "kind", "TEXT NOT NULL DEFAULT 'text'"); err != nil {
+			return err
+		}
+		if err := addColumnIfMissing(tx, "clipboard_history", "mime_type", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return addColumnIfMissing(tx, "clipboard_history", "data", "BLOB")
+	}},
+	// Legacy count-based databases already have count (defaulting to 0);
+	// LoadAll treats anything below 1 as a single copy
+	{5, "add count", addColumn("clipboard_history", "count", "INTEGER NOT NULL DEFAULT 1")},
+	// NULL means the entry never expires
+	{6, "add expires_at", addColumn("clipboard_history", "expires_at", "DATETIME")},
+	// 0 means the content is stored inline
+	{7, "add overflow_size", addColumn("clipboard_history", "overflow_size", "INTEGER NOT NULL DEFAULT 0")},
+	{8, "add selection", addColumn("clipboard_history", "selection", "TEXT NOT NULL DEFAULT 'clipboard'")},
+	{9, "create formats", execSQL(`
+		CREATE TABLE IF NOT EXISTS formats (
+			hash TEXT NOT NULL,
+			mime_type TEXT NOT NULL,
+			data BLOB NOT NULL,
+			PRIMARY KEY (hash, mime_type)
+		);
+	`)},
+	{10, "add source_app", addColumn("clipboard_history", "source_app", "TEXT NOT NULL DEFAULT ''")},
+	// Existing rows get the length of what they hold; a row cut short by an
+	// old version then no longer matches its full content
+	{11, "add content_length", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "clipboard_history", "content_length", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			UPDATE clipboard_history SET content_length = CASE
+				WHEN data IS NOT NULL THEN LENGTH(data)
+				WHEN overflow_size > 0 THEN overflow_size
+				ELSE LENGTH(CAST(content AS BLOB))
+			END`)
+		return err
+	}},
+	{12, "add position", addColumn("clipboard_history", "position", "INTEGER NOT NULL DEFAULT 0")},
+	{13, "add image dimensions", execSQL(`
+		ALTER TABLE clipboard_history ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE clipboard_history ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+	`)},
+	{14, "create registers", execSQL(`
+		CREATE TABLE IF NOT EXISTS registers (
+			name TEXT PRIMARY KEY,
+			hash TEXT NOT NULL
+		);
+	`)},
+	{15, "unify clipboard_history layout", unifyHistoryLayout},
+	// Once created an api_tokens table that nothing used, before any
+	// release; it now does nothing, keeping the numbers of later versions
+	{16, "no-op", func(*sql.Tx) error { return nil }},
+	// Comma-separated, e.g. "chat,work"
+	{17, "add tags", addColumn("clipboard_history", "tags", "TEXT NOT NULL DEFAULT ''")},
+	// types and top are JSON; day is a local date such as 2024-03-01
+	{18, "create digests", execSQL(`
+		CREATE TABLE IF NOT EXISTS digests (
+			day TEXT PRIMARY KEY,
+			entries INTEGER NOT NULL,
+			copies INTEGER NOT NULL,
+			bytes INTEGER NOT NULL,
+			types TEXT NOT NULL DEFAULT '{}',
+			top TEXT NOT NULL DEFAULT '[]'
+		);
+	`)},
+	// AUTOINCREMENT never hands out the ID of a deleted entry again.
+	// Existing entries are numbered oldest first
+	{19, "create entry_ids", execSQL(`
+		CREATE TABLE IF NOT EXISTS entry_ids (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			hash TEXT NOT NULL UNIQUE
+		);
+		INSERT OR IGNORE INTO entry_ids (hash)
+			SELECT hash FROM clipboard_history ORDER BY timestamp ASC, rowid ASC;
+	`)},
+}
+
+// historyColumns are the clipboard_history columns after version 14, in
+// the order of the canonical layout.
+var historyColumns = []string{
+	"hash", "content", "timestamp", "pinned", "content_type", "kind", "mime_type", "data", "count",
+	"expires_at", "overflow_size", "selection", "source_app", "content_length", "position", "width", "height",
+}
+
+// unifyHistoryLayout rebuilds a clipboard_history table that isn't keyed
+// by hash, such as one with an id primary key and a separately unique (or
+// not unique at all) hash, into the canonical layout. Rows sharing a hash
+// are merged into one: the newest copy's content is kept, counts are
+// summed and it stays pinned if any copy was.
+func unifyHistoryLayout(tx *sql.Tx) error {
+	var keyedByHash bool
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) = 1 AND SUM(name = 'hash') = 1
+		FROM pragma_table_info('clipboard_history')
+		WHERE pk > 0
+	`).Scan(&keyedByHash); err != nil {
+		return err
+	}
+	if keyedByHash {
+		return nil
+	}
+
+	columns := strings.Join(historyColumns, ", ")
+	updates := make([]string, 0, len(historyColumns))
+	for _, column := range historyColumns[1:] {
+		switch column {
+		case "count":
+			updates = append(updates, "count = MAX(count, 1) + MAX(excluded.count, 1)")
+		case "pinned":
+			updates = append(updates, "pinned = MAX(pinned, excluded.pinned)")
+		default:
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+	_, err := tx.Exec(fmt.Sprintf(`
+		CREATE TABLE clipboard_history_unified (
+			hash TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			timestamp DATETIME NOT NULL,
+			pinned INTEGER NOT NULL DEFAULT 0,
+			content_type TEXT NOT NULL DEFAULT '',
+			kind TEXT NOT NULL DEFAULT 'text',
+			mime_type TEXT NOT NULL DEFAULT '',
+			data BLOB,
+			count INTEGER NOT NULL DEFAULT 1,
+			expires_at DATETIME,
+			overflow_size INTEGER NOT NULL DEFAULT 0,
+			selection TEXT NOT NULL DEFAULT 'clipboard',
+			source_app TEXT NOT NULL DEFAULT '',
+			content_length INTEGER NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL DEFAULT 0,
+			width INTEGER NOT NULL DEFAULT 0,
+			height INTEGER NOT NULL DEFAULT 0
+		);
+		-- Oldest first, so the newest copy of a hash is the one kept
+		INSERT INTO clipboard_history_unified (%[1]s)
+			SELECT %[1]s FROM clipboard_history
+			WHERE hash IS NOT NULL
+			ORDER BY timestamp ASC, rowid ASC
+			ON CONFLICT(hash) DO UPDATE SET %[2]s;
+		DROP TABLE clipboard_history;
+		ALTER TABLE clipboard_history_unified RENAME TO clipboard_history;
+		CREATE INDEX IF NOT EXISTS idx_timestamp ON clipboard_history(timestamp ASC);
+	`, columns, strings.Join(updates, ", ")))
+	return err
+}
+
+// archiveMigrations builds the archive database schema.
+var archiveMigrations = []migration{
+	{1, "create tables", execSQL(`
+		CREATE TABLE IF NOT EXISTS archived_entries (
+			hash TEXT PRIMARY KEY,
+			content BLOB NOT NULL,
+			timestamp DATETIME NOT NULL,
+			content_type TEXT NOT NULL DEFAULT '',
+			kind TEXT NOT NULL DEFAULT 'text',
+			mime_type TEXT NOT NULL DEFAULT '',
+			data BLOB,
+			size INTEGER NOT NULL DEFAULT 0,
+			count INTEGER NOT NULL DEFAULT 1,
+			alias TEXT NOT NULL DEFAULT '',
+			archived_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_archived_timestamp ON archived_entries(timestamp ASC);
+	`)},
+}
+
+// migrate applies the migrations db has not seen yet, in order, each in its
+// own transaction together with its record in schema_migrations.
+func migrate(db *sql.DB, migrations []migration) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("error creating migrations table: %w", err)
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this version of clippy supports (%d)", current, latest)
+	}
+
+	for _, mig := range migrations {
+		if mig.version <= current {
+			continue
+		}
+		if err := apply(db, mig); err != nil {
+			return fmt.Errorf("error applying migration %d (%s): %w", mig.version, mig.name, err)
+		}
+	}
+	return nil
+}
+
+// apply runs a single migration and records it
+func apply(db *sql.DB, mig migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := mig.up(tx); err != nil {
+		return rollback(tx, err)
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		mig.version, mig.name, time.Now(),
+	); err != nil {
+		return rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+// rollback aborts tx, keeping err as the primary error
+func rollback(tx *sql.Tx, err error) error {
+	if rbErr := tx.Rollback(); rbErr != nil {
+		return fmt.Errorf("%w (also failed to roll back: %v)", err, rbErr)
+	}
+	return err
+}
+
+// schemaVersion returns the highest migration applied to db, 0 if none
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// execSQL returns a migration step running statements
+func execSQL(statements string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(statements)
+		return err
+	}
+}
+
+// addColumn returns a migration step adding a column unless it already exists
+func addColumn(table, name, definition string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, table, name, definition)
+	}
+}
+
+// addColumnIfMissing adds a column to table unless it already exists
+func addColumnIfMissing(tx *sql.Tx, table, name, definition string) error {
+	var exists bool
+	row := tx.QueryRow(`
+		SELECT COUNT(*) > 0
+		FROM pragma_table_info(?)
+		WHERE name = ?
+	`, table, name)
+	if err := row.Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition))
+	return err
+}
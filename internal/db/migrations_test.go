@@ -0,0 +1,251 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openRawDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "raw.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Logf("close: %v", err)
+		}
+	})
+	return db
+}
+
+func TestMigrate_RecordsVersions(t *testing.T) {
+	client, path, cleanup := setupClient(t)
+	defer cleanup()
+
+	latest := historyMigrations[len(historyMigrations)-1].version
+	if version, err := schemaVersion(client.db); err != nil || version != latest {
+		t.Fatalf("schemaVersion = %d, %v; want %d", version, err, latest)
+	}
+
+	// Reopening applies nothing twice
+	if err := client.Insert(makeEntry("kept")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	reopened, err := New(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() {
+		if err := reopened.Close(); err != nil {
+			t.Logf("close: %v", err)
+		}
+	}()
+	var applied int
+	if err := reopened.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("count migrations: %v", err)
+	}
+	if applied != len(historyMigrations) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(historyMigrations))
+	}
+	if entries, err := reopened.LoadAll(); err != nil || len(entries) != 1 {
+		t.Errorf("LoadAll after reopen = %d entries, %v", len(entries), err)
+	}
+}
+
+func TestMigrate_NumbersExistingEntries(t *testing.T) {
+	db := openRawDB(t)
+	if err := migrate(db, historyMigrations[:18]); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO clipboard_history (hash, content, timestamp) VALUES
+			('new', 'new', '2024-02-01 00:00:00'),
+			('old', 'old', '2024-01-01 00:00:00')
+	`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := migrate(db, historyMigrations); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	client := &Client{db: db, q: db}
+	old, err := client.EntryID("old")
+	if err != nil {
+		t.Fatalf("EntryID: %v", err)
+	}
+	if newer, err := client.EntryID("new"); err != nil || newer <= old {
+		t.Errorf("IDs old %d, new %d (%v); want them numbered oldest first", old, newer, err)
+	}
+}
+
+func TestMigrate_RunsPendingInOrder(t *testing.T) {
+	db := openRawDB(t)
+	var ran []int
+	step := func(version int) migration {
+		return migration{version, "step", func(tx *sql.Tx) error {
+			ran = append(ran, version)
+			return nil
+		}}
+	}
+
+	if err := migrate(db, []migration{step(1), step(2)}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := migrate(db, []migration{step(1), step(2), step(3)}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(ran) != 3 || ran[0] != 1 || ran[1] != 2 || ran[2] != 3 {
+		t.Errorf("ran %v, want [1 2 3]", ran)
+	}
+}
+
+func TestMigrate_FailureRollsBack(t *testing.T) {
+	db := openRawDB(t)
+	boom := errors.New("boom")
+	migrations := []migration{
+		{1, "create", execSQL(`CREATE TABLE t (id INTEGER)`)},
+		{2, "half done", func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`ALTER TABLE t ADD COLUMN name TEXT`); err != nil {
+				return err
+			}
+			return boom
+		}},
+	}
+
+	if err := migrate(db, migrations); !errors.Is(err, boom) {
+		t.Fatalf("migrate error = %v, want %v", err, boom)
+	}
+	if version, _ := schemaVersion(db); version != 1 {
+		t.Errorf("schemaVersion = %d, want 1", version)
+	}
+	var columns int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('t')`).Scan(&columns); err != nil {
+		t.Fatalf("table info: %v", err)
+	}
+	if columns != 1 {
+		t.Errorf("failed migration left %d columns, want 1", columns)
+	}
+}
+
+func TestMigrate_RejectsNewerSchema(t *testing.T) {
+	db := openRawDB(t)
+	create := migration{1, "create", execSQL(`CREATE TABLE t (id INTEGER)`)}
+	if err := migrate(db, []migration{create, {2, "newer", execSQL(`SELECT 1`)}}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	err := migrate(db, []migration{create})
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("migrate with an older binary = %v, want schema too new error", err)
+	}
+}
+
+func TestSchemaStatusAndApplyMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	if _, err := SchemaStatus(path, HistorySchema); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("SchemaStatus of a missing database = %v, want ErrNotExist", err)
+	}
+
+	// A database left at version 3 by an older clippy
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := migrate(old, historyMigrations[:3]); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := old.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	statuses, err := SchemaStatus(path, HistorySchema)
+	if err != nil {
+		t.Fatalf("SchemaStatus: %v", err)
+	}
+	if len(statuses) != len(historyMigrations) {
+		t.Fatalf("%d statuses, want one per migration", len(statuses))
+	}
+	if statuses[2].Pending() || !statuses[3].Pending() {
+		t.Errorf("expected versions 1-3 applied and 4 pending, got %+v", statuses[2:4])
+	}
+	if statuses[3].Version != 4 || statuses[3].Name != historyMigrations[3].name {
+		t.Errorf("unexpected status %+v", statuses[3])
+	}
+
+	ran, err := ApplyMigrations(path, HistorySchema)
+	if err != nil {
+		t.Fatalf("ApplyMigrations: %v", err)
+	}
+	if len(ran) != len(historyMigrations)-3 || ran[0].Version != 4 || ran[0].Pending() {
+		t.Errorf("ApplyMigrations ran %+v, want versions 4 onwards", ran)
+	}
+	if ran, err := ApplyMigrations(path, HistorySchema); err != nil || len(ran) != 0 {
+		t.Errorf("second ApplyMigrations = %+v, %v; want nothing to apply", ran, err)
+	}
+	statuses, _ = SchemaStatus(path, HistorySchema)
+	if last := statuses[len(statuses)-1]; last.Pending() || last.Version != HistorySchema.Latest() {
+		t.Errorf("expected every migration applied, last is %+v", last)
+	}
+}
+
+func TestMigrate_UnifiesIDKeyedLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id-keyed.db")
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// An id-keyed table whose hash wasn't unique, holding two copies of h1
+	if _, err := legacy.Exec(`
+		CREATE TABLE clipboard_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			hash TEXT,
+			content TEXT NOT NULL,
+			timestamp DATETIME NOT NULL,
+			pinned INTEGER NOT NULL DEFAULT 0
+		);
+		INSERT INTO clipboard_history (hash, content, timestamp, pinned) VALUES
+			('h1', 'first copy', '2024-01-01T00:00:00Z', 1),
+			('h2', 'other', '2024-01-02T00:00:00Z', 0),
+			('h1', 'second copy', '2024-01-03T00:00:00Z', 0);
+	`); err != nil {
+		t.Fatalf("create id-keyed schema: %v", err)
+	}
+	if err := legacy.Close(); err != nil {
+		t.Logf("close: %v", err)
+	}
+
+	client, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			t.Logf("close: %v", err)
+		}
+	}()
+	var hasID bool
+	if err := client.db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('clipboard_history') WHERE name = 'id'`).Scan(&hasID); err != nil || hasID {
+		t.Errorf("id column still present: %v, %v", hasID, err)
+	}
+	entries, err := client.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the copies of h1 merged into 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Hash == "h1" && (e.Content != "second copy" || e.Count != 2 || !e.Pinned) {
+			t.Errorf("merged h1 = %+v, want the newest content, both copies counted and still pinned", e)
+		}
+	}
+	var hashKey bool
+	if err := client.db.QueryRow(`SELECT pk = 1 FROM pragma_table_info('clipboard_history') WHERE name = 'hash'`).Scan(&hashKey); err != nil || !hashKey {
+		t.Errorf("expected hash to be the primary key: %v, %v", hashKey, err)
+	}
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// Schema selects the migrations of one kind of database.
+type Schema int
+
+const (
+	HistorySchema Schema = iota // the clipboard history database
+	ArchiveSchema               // the archive database
+)
+
+// migrations returns the migrations that build the schema
+func (s Schema) migrations() []migration {
+	if s == ArchiveSchema {
+		return archiveMigrations
+	}
+	return historyMigrations
+}
+
+// Latest returns the version the schema's newest migration brings a
+// database to.
+func (s Schema) Latest() int {
+	migrations := s.migrations()
+	return migrations[len(migrations)-1].version
+}
+
+// MigrationStatus describes one migration of a database.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time // zero while pending
+}
+
+// Pending reports whether the migration is yet to be applied.
+func (s MigrationStatus) Pending() bool {
+	return s.AppliedAt.IsZero()
+}
+
+// SchemaStatus lists the migrations of the database at path, applied and
+// pending, without changing it. Versions recorded by a newer clippy are
+// listed after the known ones.
+func SchemaStatus(path string, schema Schema) ([]MigrationStatus, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+	}()
+	return migrationStatus(db, schema.migrations())
+}
+
+// ApplyMigrations brings the database at path, created if missing, up to
+// date and returns the migrations it applied.
+func ApplyMigrations(path string, schema Schema) ([]MigrationStatus, error) {
+	before, err := SchemaStatus(path, schema)
+	if errors.Is(err, fs.ErrNotExist) {
+		before, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool)
+	for _, s := range before {
+		applied[s.Version] = !s.Pending()
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+	}()
+	if err := migrate(db, schema.migrations()); err != nil {
+		return nil, fmt.Errorf("error migrating schema: %w", err)
+	}
+	after, err := migrationStatus(db, schema.migrations())
+	if err != nil {
+		return nil, err
+	}
+	var ran []MigrationStatus
+	for _, s := range after {
+		if !applied[s.Version] {
+			ran = append(ran, s)
+		}
+	}
+	return ran, nil
+}
+
+// migrationStatus lists migrations with when db recorded applying them,
+// followed by recorded versions not among migrations
+func migrationStatus(db *sql.DB, migrations []migration) ([]MigrationStatus, error) {
+	var exists bool
+	if err := db.QueryRow(`
+		SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'
+	`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	var recorded []MigrationStatus
+	if exists {
+		rows, err := db.Query(`SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+		if err != nil {
+			return nil, fmt.Errorf("error reading schema version: %w", err)
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				log.Printf("Failed to close rows: %v", err)
+			}
+		}()
+		for rows.Next() {
+			var s MigrationStatus
+			if err := rows.Scan(&s.Version, &s.Name, &s.AppliedAt); err != nil {
+				return nil, fmt.Errorf("error scanning migration: %w", err)
+			}
+			recorded = append(recorded, s)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	appliedAt := make(map[int]time.Time, len(recorded))
+	for _, s := range recorded {
+		appliedAt[s.Version] = s.AppliedAt
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, mig := range migrations {
+		statuses = append(statuses, MigrationStatus{Version: mig.version, Name: mig.name, AppliedAt: appliedAt[mig.version]})
+	}
+	latest := migrations[len(migrations)-1].version
+	for _, s := range recorded {
+		if s.Version > latest {
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,148 @@
+// Package detect classifies clipboard content into coarse content types.
+package detect
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Type identifies the kind of content held in a clipboard entry.
+type Type string
+
+const (
+	Text  Type = "text"
+	URL   Type = "url"
+	Email Type = "email"
+	Path  Type = "path"
+	JSON  Type = "json"
+	Color Type = "color"
+	Code  Type = "code"
+	// Table is comma- or tab-separated values; see ParseTable.
+	Table Type = "table"
+	// Image marks binary image entries; Detect never returns it for text.
+	Image Type = "image"
+)
+
+// Types lists every known content type, in display order.
+var Types = []Type{Text, URL, Email, Path, JSON, Color, Code, Table, Image}
+
+// ParseType returns the Type named by s (case-insensitive).
+func ParseType(s string) (Type, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, t := range Types {
+		if string(t) == s {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	emailPattern    = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[A-Za-z]{2,}$`)
+	windowsPath     = regexp.MustCompile(`^[A-Za-z]:\\`)
+)
+
+// urlSchemes are the schemes recognised as URLs.
+var urlSchemes = map[string]bool{
+	"http": true, "https": true, "ftp": true, "ftps": true,
+	"ssh": true, "git": true, "file": true, "ws": true, "wss": true,
+}
+
+// codeSignals are substrings that suggest source code rather than prose.
+var codeSignals = []string{
+	"func ", "def ", "class ", "import ", "package ", "return ", "#include",
+	"=>", ":=", "==", "!=", "&&", "||", "();", "){", ") {", "var ", "let ", "const ",
+	"SELECT ", "INSERT ", "UPDATE ", "#!/",
+}
+
+// Detect classifies content, returning Text when nothing more specific fits.
+func Detect(content string) Type {
+	s := strings.TrimSpace(content)
+	if s == "" {
+		return Text
+	}
+	singleLine := !strings.ContainsAny(s, "\n\r")
+
+	switch {
+	case singleLine && hexColorPattern.MatchString(s):
+		return Color
+	case singleLine && isURL(s):
+		return URL
+	case singleLine && emailPattern.MatchString(s):
+		return Email
+	case isJSON(s):
+		return JSON
+	case singleLine && isPath(s):
+		return Path
+	case isCode(s):
+		return Code
+	case isTable(s):
+		return Table
+	}
+	return Text
+}
+
+func isURL(s string) bool {
+	if strings.ContainsAny(s, " \t") {
+		return false
+	}
+	if strings.HasPrefix(s, "www.") && strings.Contains(s[4:], ".") {
+		return true
+	}
+	u, err := url.Parse(s)
+	if err != nil || !urlSchemes[strings.ToLower(u.Scheme)] {
+		return false
+	}
+	return u.Host != "" || u.Scheme == "file"
+}
+
+func isJSON(s string) bool {
+	if !(strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
+
+func isPath(s string) bool {
+	if len(s) > 4096 {
+		return false
+	}
+	for _, prefix := range []string{"/", "~/", "./", "../"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return windowsPath.MatchString(s)
+}
+
+func isCode(s string) bool {
+	score := 0
+	for _, signal := range codeSignals {
+		if strings.Contains(s, signal) {
+			score++
+		}
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) > 1 {
+		indented, terminated := 0, 0
+		for _, line := range lines {
+			if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+				indented++
+			}
+			trimmed := strings.TrimSpace(line)
+			if strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, "}") {
+				terminated++
+			}
+		}
+		if indented > 0 {
+			score++
+		}
+		if terminated*2 >= len(lines) {
+			score++
+		}
+	}
+	return score >= 2
+}
@@ -0,0 +1,61 @@
+package detect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Type
+	}{
+		{"empty", "", Text},
+		{"prose", "Hello, World!", Text},
+		{"sentence with url", "see https://example.com for more", Text},
+		{"https url", "https://github.com/charmbracelet/bubbletea", URL},
+		{"www url", "www.example.com/path", URL},
+		{"ssh url", "ssh://git@github.com/org/repo.git", URL},
+		{"email", "someone@example.com", Email},
+		{"not email", "@handle", Text},
+		{"json object", `{"name": "clippy", "tags": [1, 2]}`, JSON},
+		{"json array", `[1, 2, 3]`, JSON},
+		{"invalid json", `{not json}`, Text},
+		{"hex color", "#ff00aa", Color},
+		{"short hex color", "#fff", Color},
+		{"not a color", "#12345", Text},
+		{"unix path", "/etc/passwd", Path},
+		{"home path", "~/.clippy/clippy.db", Path},
+		{"relative path", "./cmd/clippy", Path},
+		{"windows path", `C:\Users\me\file.txt`, Path},
+		{"go code", "func main() {\n\tfmt.Println(\"Hello\")\n}", Code},
+		{"sql", "SELECT * FROM users WHERE active = 1 AND id != 2;", Code},
+		{"shell pipeline", "cat /etc/passwd | grep root", Text},
+		{"multi line prose", "Multi\nline\ntext\nentry", Text},
+		{"csv", "name,age\nalice,30\nbob,25", Table},
+		{"tsv", "name\tage\nalice\t30", Table},
+		{"two lines with commas", "Hi Bob, thanks\nSee you, Al", Text},
+		{"ragged csv", "a,b\nc,d,e\nf,g", Text},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.content); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for _, typ := range Types {
+		got, ok := ParseType(string(typ))
+		if !ok || got != typ {
+			t.Errorf("ParseType(%q) = %q, %v", typ, got, ok)
+		}
+	}
+	if got, ok := ParseType("URL"); !ok || got != URL {
+		t.Errorf("ParseType is not case-insensitive: %q, %v", got, ok)
+	}
+	if _, ok := ParseType("bogus"); ok {
+		t.Error("ParseType(bogus) should fail")
+	}
+}
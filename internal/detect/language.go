@@ -0,0 +1,198 @@
+package detect
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Language identifies the programming language of a code entry, using
+// the short names shown as a badge in the table.
+type Language string
+
+const (
+	Go         Language = "go"
+	Python     Language = "py"
+	JavaScript Language = "js"
+	TypeScript Language = "ts"
+	SQL        Language = "sql"
+	Shell      Language = "sh"
+	Rust       Language = "rs"
+	Java       Language = "java"
+	C          Language = "c"
+	Ruby       Language = "rb"
+)
+
+// Languages lists every detected language. On equal scores the earlier
+// language wins, so TypeScript follows JavaScript and only wins with a
+// TypeScript-only construct.
+var Languages = []Language{Go, Python, JavaScript, TypeScript, SQL, Shell, Rust, Java, C, Ruby}
+
+// languageNames maps the long names accepted by ParseLanguage.
+var languageNames = map[string]Language{
+	"golang": Go, "python": Python, "javascript": JavaScript,
+	"typescript": TypeScript, "bash": Shell, "shell": Shell,
+	"rust": Rust, "ruby": Ruby,
+}
+
+// ParseLanguage returns the Language named by s, by its short or long name
+// (case-insensitive).
+func ParseLanguage(s string) (Language, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, l := range Languages {
+		if string(l) == s {
+			return l, true
+		}
+	}
+	l, ok := languageNames[s]
+	return l, ok
+}
+
+// minLanguageScore is how many of a language's patterns must match before
+// content is attributed to it.
+const minLanguageScore = 2
+
+var jsPatterns = []string{
+	`\b(?:const|let) \w+ = `,
+	`\bfunction\s*\w*\s*\(`,
+	`\) => `,
+	`\bconsole\.log\(`,
+	`\brequire\(['"]`,
+	`===|!==`,
+	`(?m)^import .* from ['"]`,
+	`(?m)^export (?:default |const |function )`,
+}
+
+// languagePatterns are the signals scored for each language.
+var languagePatterns = map[Language][]*regexp.Regexp{
+	Go: compileAll(
+		`(?m)^package \w+\s*$`,
+		`\bfunc (?:\(\w+ \*?\w+\) )?\w+\(`,
+		`\w+ := `,
+		`\bfmt\.\w+\(`,
+		`\bif err != nil\b`,
+		`(?m)^import \($`,
+	),
+	Python: compileAll(
+		`(?m)^\s*def \w+\(.*\)(?:\s*->\s*[\w\[\], ]+)?:\s*$`,
+		`(?m)^from [\w.]+ import `,
+		`(?m)^\s*(?:if|elif|for|while|with|class|try|except)\b.*:\s*$`,
+		`\bself\.\w+`,
+		`\bprint\(`,
+		`\b(?:None|True|False)\b`,
+		`__name__|__init__`,
+	),
+	JavaScript: compileAll(jsPatterns...),
+	TypeScript: compileAll(append(jsPatterns,
+		`:\s*(?:string|number|boolean|any|unknown|void)\b(?:\[\])?`,
+		`\binterface \w+ \{`,
+		`(?m)^(?:export )?type \w+ = `,
+	)...),
+	SQL: compileAll(
+		`(?i)^\s*(?:SELECT|INSERT INTO|UPDATE|DELETE FROM|CREATE (?:TABLE|INDEX|VIEW)|ALTER TABLE|DROP TABLE|WITH \w+ AS)\b`,
+		`(?i)\bFROM\s+\w+`,
+		`(?i)\bWHERE\s`,
+		`(?i)\b(?:(?:INNER |LEFT |RIGHT |OUTER )?JOIN|GROUP BY|ORDER BY|VALUES)\b`,
+	),
+	Shell: compileAll(
+		`(?m)^\s*(?:sudo|echo|export|cd|apt(?:-get)?|brew|grep|ls|mkdir|rm|cp|mv|chmod|curl|wget|git|docker|kubectl|npm|go|make) `,
+		`\$\{?\w+\}?`,
+		`\s\|\s*\w`,
+		`(?m)^\s*(?:fi|done|esac|then|do)\s*$`,
+		`\bif \[\[? `,
+		` --?[a-zA-Z][\w-]*`,
+	),
+	Rust: compileAll(
+		`\bfn \w+(?:<[^>]*>)?\(`,
+		`\blet mut\b`,
+		`(?m)^\s*(?:pub )?(?:impl|struct|enum|trait|mod)\b`,
+		`\b\w+!\(`,
+		`(?m)^use \w+(?:::\w+)+`,
+		`&(?:mut |'\w+ )?(?:str|self)\b`,
+		`\) -> \w+`,
+	),
+	Java: compileAll(
+		`\b(?:public|private|protected) (?:static |final |abstract )*(?:class|interface|enum|void|[A-Z]\w*(?:<[^>]*>)?|int|boolean|long) \w+`,
+		`\bSystem\.out\.print`,
+		`@Override\b`,
+		`\bnew [A-Z]\w*(?:<[^>]*>)?\(`,
+		`String\[\]`,
+		`(?m)^import (?:static )?[\w.]+\*?;`,
+	),
+	C: compileAll(
+		`(?m)^#include\s*[<"]`,
+		`\bint main\(`,
+		`\bprintf\(`,
+		`\b(?:malloc|free|sizeof|memcpy|strlen)\(`,
+		`\w->\w`,
+		`\b(?:struct|typedef) \w+`,
+	),
+	Ruby: compileAll(
+		`(?m)^\s*def \w+[?!]?(?:\(.*\))?\s*$`,
+		`(?m)^\s*end\s*$`,
+		`\bputs\b`,
+		`(?m)^require(?:_relative)? ['"]`,
+		`\.each(?:_with_index)? do\b`,
+		`\|\w+(?:, ?\w+)*\|`,
+		`\battr_(?:accessor|reader|writer)\b`,
+	),
+}
+
+// shebangs maps interpreters named on a #! line to their language.
+var shebangs = map[string]Language{
+	"sh": Shell, "bash": Shell, "zsh": Shell, "dash": Shell,
+	"python": Python, "python3": Python,
+	"node": JavaScript, "deno": TypeScript,
+	"ruby": Ruby,
+}
+
+func compileAll(patterns ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		compiled[i] = regexp.MustCompile(p)
+	}
+	return compiled
+}
+
+// DetectLanguage guesses the programming language of code, returning ""
+// when no language scores well enough. A #! line decides on its own;
+// otherwise each language scores one point per pattern that matches.
+func DetectLanguage(code string) Language {
+	s := strings.TrimSpace(code)
+	if l, ok := shebangLanguage(s); ok {
+		return l
+	}
+
+	best, bestScore := Language(""), minLanguageScore-1
+	for _, l := range Languages {
+		score := 0
+		for _, pattern := range languagePatterns[l] {
+			if pattern.MatchString(s) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = l, score
+		}
+	}
+	return best
+}
+
+// shebangLanguage reads the interpreter from a leading #! line, e.g.
+// "#!/bin/bash" or "#!/usr/bin/env python3".
+func shebangLanguage(s string) (Language, bool) {
+	if !strings.HasPrefix(s, "#!") {
+		return "", false
+	}
+	line, _, _ := strings.Cut(s[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	interpreter := fields[0]
+	if strings.HasSuffix(interpreter, "/env") && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+	interpreter = interpreter[strings.LastIndex(interpreter, "/")+1:]
+	l, ok := shebangs[interpreter]
+	return l, ok
+}
@@ -0,0 +1,54 @@
+package detect
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want Language
+	}{
+		{"go", "package main\n\nfunc main() {\n\tx := 1\n\tfmt.Println(x)\n}", Go},
+		{"go error check", "v, err := parse(s)\nif err != nil {\n\treturn err\n}", Go},
+		{"python", "def greet(name):\n    if name is None:\n        return\n    print(name)", Python},
+		{"python class", "class Foo:\n    def __init__(self):\n        self.x = 1", Python},
+		{"javascript", "const total = items.map((x) => x * 2);\nconsole.log(total);", JavaScript},
+		{"typescript", "interface User {\n  name: string;\n}\nconst u: User = { name: 'a' };\nconsole.log(u);", TypeScript},
+		{"sql", "SELECT id, name FROM users WHERE active = 1 ORDER BY name;", SQL},
+		{"shell", "export PATH=$HOME/bin:$PATH\ngrep -r foo . | wc -l", Shell},
+		{"shebang", "#!/usr/bin/env python3\nx = 1", Python},
+		{"bash shebang", "#!/bin/bash\necho hi", Shell},
+		{"rust", "fn main() {\n    let mut v = Vec::new();\n    println!(\"{}\", v.len());\n}", Rust},
+		{"java", "public class Hello {\n    public static void main(String[] args) {\n        System.out.println(\"hi\");\n    }\n}", Java},
+		{"c", "#include <stdio.h>\n\nint main(void) {\n    printf(\"hi\\n\");\n}", C},
+		{"ruby", "require 'json'\n\ndef greet\n  puts 'hi'\nend", Ruby},
+		{"too little to tell", "x == y", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.code); got != tt.want {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Language
+		ok   bool
+	}{
+		{"go", Go, true},
+		{"PY", Python, true},
+		{"golang", Go, true},
+		{"typescript", TypeScript, true},
+		{"cobol", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLanguage(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseLanguage(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package detect
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxMarkdownSize is the largest content checked for being markdown, since
+// the preview checks it on every render.
+const maxMarkdownSize = 256 * 1024
+
+// markdownSignals are patterns of markdown syntax, none matching the same
+// text as another. Any one of them also turns up in plain text or code now
+// and then, e.g. a "# comment" line or a "- item" list, so IsMarkdown asks
+// for two different ones.
+var markdownSignals = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^#{1,6} +\S`),                         // heading
+	regexp.MustCompile(`(?m)^ *(?:[-*+]|\d+[.)]) +\S`),            // list item
+	regexp.MustCompile("(?m)^ *(?:```|~~~)"),                      // code fence
+	regexp.MustCompile(`(?m)^> ?\S`),                              // block quote
+	regexp.MustCompile(`(?m)^\|? *:?-{3,}:? *\|`),                 // table rule
+	regexp.MustCompile(`\[[^\]\n]+\]\([^)\s]+\)`),                 // link
+	regexp.MustCompile(`(?:\*\*|__)[^*_\n]+(?:\*\*|__)`),          // bold
+	regexp.MustCompile("(?:^|[^`])`[^`\n]+`(?:[^`]|$)"),           // inline code
+	regexp.MustCompile(`(?m)^\[[^\]\n]+\]: +\S`),                  // link reference
+	regexp.MustCompile(`(?:^|\s)\*[^*\s][^*\n]*[^*\s]\*(?:\s|$)`), // emphasis
+}
+
+// IsMarkdown reports whether content looks like markdown, such as a README
+// fragment or notes: several lines using at least two kinds of markdown
+// syntax.
+func IsMarkdown(content string) bool {
+	s := strings.TrimSpace(content)
+	if len(s) > maxMarkdownSize || !strings.Contains(s, "\n") {
+		return false
+	}
+	found := 0
+	for _, signal := range markdownSignals {
+		if signal.MatchString(s) {
+			found++
+			if found == 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
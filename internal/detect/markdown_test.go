@@ -0,0 +1,26 @@
+package detect
+
+import "testing"
+
+func TestIsMarkdown(t *testing.T) {
+	cases := []struct {
+		content string
+		want    bool
+	}{
+		{"# Install\n\nRun `make install` first.", true},
+		{"## Notes\n- buy milk\n- call Sam", true},
+		{"See [the docs](https://example.com) for **all** options.\nThanks", true},
+		{"> quoted\n\n```go\nfmt.Println()\n```", true},
+		{"| a | b |\n|---|---|\n| 1 | [x](y) |", true},
+		{"# install deps\npip install -r requirements.txt", false},
+		{"- eggs\n- flour\n- sugar", false},
+		{"just some\nplain text", false},
+		{"# Title with **bold**", false}, // single line
+		{"func main() {\n\treturn a * b * c\n}", false},
+	}
+	for _, c := range cases {
+		if got := IsMarkdown(c.content); got != c.want {
+			t.Errorf("IsMarkdown(%q) = %v, want %v", c.content, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package detect
+
+import (
+	"regexp"
+)
+
+// Secret identifies a kind of sensitive content, such as a credential.
+type Secret string
+
+const (
+	AWSKey     Secret = "aws key"
+	JWT        Secret = "jwt"
+	CardNumber Secret = "card number"
+	PrivateKey Secret = "private key"
+)
+
+var (
+	privateKeyPattern = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----`)
+	awsAccessKey      = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[0-9A-Z]{16}\b`)
+	awsSecretKey      = regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[=:]\s*["']?[A-Za-z0-9/+=]{40}\b`)
+	jwtPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)
+	// Card numbers start with an issuer digit (2-6: Mastercard, Amex,
+	// Visa, Discover), which rules out most long IDs and timestamps
+	cardCandidate = regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`)
+)
+
+// Sensitive returns the kind of secret found in content, or "" when it
+// holds none. Card numbers must pass the Luhn checksum.
+func Sensitive(content string) Secret {
+	switch {
+	case privateKeyPattern.MatchString(content):
+		return PrivateKey
+	case awsAccessKey.MatchString(content), awsSecretKey.MatchString(content):
+		return AWSKey
+	case jwtPattern.MatchString(content):
+		return JWT
+	}
+	for _, candidate := range cardCandidate.FindAllString(content, -1) {
+		if luhnValid(candidate) {
+			return CardNumber
+		}
+	}
+	return ""
+}
+
+// luhnValid reports whether the digits in s, ignoring spaces and dashes,
+// form a 13 to 19 digit number passing the Luhn checksum
+func luhnValid(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
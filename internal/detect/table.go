@@ -0,0 +1,66 @@
+package detect
+
+import (
+	"encoding/csv"
+	"strings"
+)
+
+// maxTableSize is the largest content checked for being a table, since
+// the preview parses it on every render.
+const maxTableSize = 64 * 1024
+
+// TableData is content parsed as comma- or tab-separated values.
+type TableData struct {
+	Rows      [][]string
+	Delimiter rune // ',' or '\t'
+}
+
+// Columns returns the number of columns in every row.
+func (t TableData) Columns() int {
+	return len(t.Rows[0])
+}
+
+// Format names the format, "CSV" or "TSV".
+func (t TableData) Format() string {
+	if t.Delimiter == '\t' {
+		return "TSV"
+	}
+	return "CSV"
+}
+
+// ParseTable parses content as CSV or TSV: at least two columns in every
+// row, the same number in each, with one row per line. Comma-separated
+// content needs three rows, so a couple of lines of prose with commas
+// aren't taken for a table.
+func ParseTable(content string) (TableData, bool) {
+	s := strings.TrimRight(content, "\r\n")
+	if len(s) > maxTableSize || !strings.Contains(s, "\n") {
+		return TableData{}, false
+	}
+	lines := strings.Count(s, "\n") + 1
+	delimiter := ','
+	minRows := 3
+	if strings.Contains(strings.SplitN(s, "\n", 2)[0], "\t") {
+		delimiter, minRows = '\t', 2
+	}
+	if lines < minRows {
+		return TableData{}, false
+	}
+
+	reader := csv.NewReader(strings.NewReader(s))
+	reader.Comma = delimiter
+	reader.LazyQuotes = delimiter == '\t'
+	reader.TrimLeadingSpace = delimiter == ','
+	rows, err := reader.ReadAll()
+	// A row per line rules out quoted line breaks, which the preview
+	// couldn't line up with the source
+	if err != nil || len(rows) != lines || len(rows[0]) < 2 {
+		return TableData{}, false
+	}
+	return TableData{Rows: rows, Delimiter: delimiter}, true
+}
+
+func isTable(s string) bool {
+	_, ok := ParseTable(s)
+	return ok
+}
@@ -0,0 +1,32 @@
+package detect
+
+import "testing"
+
+func TestParseTable(t *testing.T) {
+	table, ok := ParseTable("name, role\n\"Smith, Jo\", admin\nLee, user\n")
+	if !ok {
+		t.Fatal("expected a CSV table")
+	}
+	if table.Format() != "CSV" || table.Columns() != 2 || len(table.Rows) != 3 {
+		t.Errorf("got %s with %d columns and %d rows", table.Format(), table.Columns(), len(table.Rows))
+	}
+	if table.Rows[1][0] != "Smith, Jo" || table.Rows[1][1] != "admin" {
+		t.Errorf("row 1 = %q", table.Rows[1])
+	}
+
+	table, ok = ParseTable("id\tnote\n1\tsays \"hi\"")
+	if !ok || table.Format() != "TSV" || table.Rows[1][1] != `says "hi"` {
+		t.Errorf("ParseTable(tsv) = %+v, %v", table, ok)
+	}
+
+	for _, s := range []string{
+		"single line, with commas",
+		"a,b\nc,d",             // too few rows for CSV
+		"a\nb\nc",              // one column
+		"a,b\n\"c\nd\",e\nf,g", // quoted line break
+	} {
+		if _, ok := ParseTable(s); ok {
+			t.Errorf("ParseTable(%q): expected no table", s)
+		}
+	}
+}
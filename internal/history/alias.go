@@ -0,0 +1,76 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/bvdwalt/clippy/internal/db"
+)
+
+// MaxAliasLength is the longest alias accepted by ValidateAlias.
+const MaxAliasLength = 64
+
+var (
+	// ErrInvalidAlias is returned when an alias contains unsupported characters.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrAliasTaken is returned when an alias is already assigned to another entry.
+	ErrAliasTaken = errors.New("alias already in use")
+)
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateAlias checks that an alias is usable as a CLI argument: it must start
+// with a letter or digit and contain only letters, digits, '.', '_' and '-'.
+func ValidateAlias(alias string) error {
+	if len(alias) > MaxAliasLength {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidAlias, MaxAliasLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: %q (use letters, digits, '.', '_' or '-')", ErrInvalidAlias, alias)
+	}
+	return nil
+}
+
+// SetAlias assigns an alias to the item at index. An empty alias clears it.
+func (m *Manager) SetAlias(index int, alias string) error {
+	if index < 0 || index >= len(m.items) {
+		return fmt.Errorf("invalid index: %d", index)
+	}
+	if alias != "" {
+		if err := ValidateAlias(alias); err != nil {
+			return err
+		}
+	}
+
+	item := &m.items[index]
+	if alias != "" {
+		if owner, ok := m.FindByAlias(alias); ok && owner.Hash != item.Hash {
+			return fmt.Errorf("%w: %s", ErrAliasTaken, alias)
+		}
+	}
+
+	if m.persisted(*item) {
+		if err := m.dbClient.SetAlias(item.Hash, alias); err != nil {
+			if errors.Is(err, db.ErrAliasExists) {
+				return fmt.Errorf("%w: %s", ErrAliasTaken, alias)
+			}
+			return err
+		}
+	}
+	item.Alias = alias
+	return nil
+}
+
+// FindByAlias returns the item with the given alias.
+func (m *Manager) FindByAlias(alias string) (ClipboardHistory, bool) {
+	if alias == "" {
+		return ClipboardHistory{}, false
+	}
+	for _, item := range m.items {
+		if item.Alias == alias {
+			return item, true
+		}
+	}
+	return ClipboardHistory{}, false
+}
@@ -0,0 +1,141 @@
+package history
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		alias string
+		valid bool
+	}{
+		{"ssh-prod", true},
+		{"db.url", true},
+		{"a", true},
+		{"key_2", true},
+		{"", false},
+		{"-leading", false},
+		{"has space", false},
+		{"semi;colon", false},
+		{strings.Repeat("a", MaxAliasLength+1), false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateAlias(tt.alias)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateAlias(%q) = %v, want nil", tt.alias, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("ValidateAlias(%q) = %v, want ErrInvalidAlias", tt.alias, err)
+		}
+	}
+}
+
+func TestSetAliasAndFind(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("ssh user@prod.example.com")
+	manager.AddItem("other")
+
+	if err := manager.SetAlias(0, "ssh-prod"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+
+	item, ok := manager.FindByAlias("ssh-prod")
+	if !ok {
+		t.Fatal("expected alias to be found")
+	}
+	if item.Item != "ssh user@prod.example.com" {
+		t.Errorf("FindByAlias content = %q", item.Item)
+	}
+
+	if _, ok := manager.FindByAlias("missing"); ok {
+		t.Error("expected unknown alias not to be found")
+	}
+}
+
+func TestSetAliasUniqueness(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("first")
+	manager.AddItem("second")
+
+	if err := manager.SetAlias(0, "dup"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if err := manager.SetAlias(1, "dup"); !errors.Is(err, ErrAliasTaken) {
+		t.Errorf("SetAlias duplicate = %v, want ErrAliasTaken", err)
+	}
+
+	// Re-assigning the same alias to the same item is fine
+	if err := manager.SetAlias(0, "dup"); err != nil {
+		t.Errorf("SetAlias same item: %v", err)
+	}
+}
+
+func TestSetAliasClear(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("content")
+	if err := manager.SetAlias(0, "name"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if err := manager.SetAlias(0, ""); err != nil {
+		t.Fatalf("SetAlias clear: %v", err)
+	}
+	if _, ok := manager.FindByAlias("name"); ok {
+		t.Error("expected alias to be cleared")
+	}
+}
+
+func TestSetAliasInvalid(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("content")
+	if err := manager.SetAlias(0, "bad alias"); !errors.Is(err, ErrInvalidAlias) {
+		t.Errorf("SetAlias = %v, want ErrInvalidAlias", err)
+	}
+	if err := manager.SetAlias(5, "ok"); err == nil {
+		t.Error("expected error for invalid index")
+	}
+}
+
+func TestSetAliasPersists(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("persist me")
+	if err := manager.SetAlias(0, "keep"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+
+	reloaded := &Manager{
+		items:    make([]ClipboardHistory, 0),
+		hashes:   make(map[string]int),
+		dbClient: manager.dbClient,
+	}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	item, ok := reloaded.FindByAlias("keep")
+	if !ok || item.Item != "persist me" {
+		t.Errorf("expected alias to survive reload, got %+v (found=%v)", item, ok)
+	}
+}
+
+func TestInMemoryManagerSetAlias(t *testing.T) {
+	m := NewInMemoryManager()
+	m.AddItem("mem")
+	if err := m.SetAlias(0, "mem-alias"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if _, ok := m.FindByAlias("mem-alias"); !ok {
+		t.Error("expected alias on in-memory manager")
+	}
+}
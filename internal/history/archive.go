@@ -0,0 +1,192 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// ArchiveFileName is the archive database, stored next to the history database.
+const ArchiveFileName = "archive.db"
+
+// ErrNoArchive is returned by archive operations on in-memory managers.
+var ErrNoArchive = errors.New("archive requires a database-backed history")
+
+// ArchivePath returns the location of the archive database.
+func (m *Manager) ArchivePath() (string, error) {
+	if m.dbPath == "" {
+		return "", ErrNoArchive
+	}
+	return filepath.Join(filepath.Dir(m.dbPath), ArchiveFileName), nil
+}
+
+// withArchive opens the archive database for the duration of fn.
+func (m *Manager) withArchive(fn func(*db.Archive) error) error {
+	path, err := m.ArchivePath()
+	if err != nil {
+		return err
+	}
+	archive, err := db.OpenArchive(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := archive.Close(); err != nil {
+			log.Printf("Failed to close archive: %v", err)
+		}
+	}()
+	return fn(archive)
+}
+
+// ArchiveOlderThan moves unpinned items last copied before cutoff into the
+// archive database and returns how many were moved.
+func (m *Manager) ArchiveOlderThan(cutoff time.Time) (int, error) {
+	moved := 0
+	err := m.withArchive(func(archive *db.Archive) error {
+		now := time.Now()
+		for i := len(m.items) - 1; i >= 0; i-- {
+			item := m.items[i]
+			// Incognito items must never reach a database
+			if item.Pinned || item.Incognito || !item.TimeStamp.Before(cutoff) {
+				continue
+			}
+			entry := db.ClipboardEntry{
+				Content:   item.Item,
+				Hash:      item.Hash,
+				Timestamp: item.TimeStamp,
+				Alias:     item.Alias,
+				Type:      string(item.Type),
+				Kind:      string(item.Kind),
+				MimeType:  item.MimeType,
+				Count:     item.Count,
+			}
+			if item.Overflow {
+				text, err := m.Text(item)
+				if err != nil {
+					return err
+				}
+				entry.Content = text
+			}
+			if item.IsBinary() {
+				data, err := m.GetData(item)
+				if err != nil {
+					return fmt.Errorf("error loading data for clip %s: %w", item.Hash, err)
+				}
+				entry.Data = data
+			}
+			if err := archive.Store(entry, now); err != nil {
+				return fmt.Errorf("error archiving clip %s: %w", item.Hash, err)
+			}
+			if !m.DeleteItem(i) {
+				return fmt.Errorf("error removing archived clip %s", item.Hash)
+			}
+			// Unlike a deleted item, copying archived content again should
+			// capture it anew
+			if m.lastHash == item.Hash {
+				m.lastHash = ""
+			}
+			moved++
+		}
+		return nil
+	})
+	return moved, err
+}
+
+// ArchivedItems returns the entries in the archive, oldest first.
+func (m *Manager) ArchivedItems() ([]ClipboardHistory, error) {
+	var items []ClipboardHistory
+	err := m.withArchive(func(archive *db.Archive) error {
+		entries, err := archive.LoadAll()
+		if err != nil {
+			return err
+		}
+		items = make([]ClipboardHistory, 0, len(entries))
+		for _, entry := range entries {
+			item := ClipboardHistory{
+				Item:      entry.Content,
+				Hash:      entry.Hash,
+				TimeStamp: entry.Timestamp,
+				Alias:     entry.Alias,
+				Type:      detect.Type(entry.Type),
+				Kind:      Kind(entry.Kind),
+				MimeType:  entry.MimeType,
+				Size:      entry.Size,
+				Count:     entry.Count,
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+// RestoreArchived moves the archived entry whose hash starts with
+// hashPrefix back into history. If the content has been copied again since
+// it was archived, the two are combined as in MergeFrom. The entry's alias
+// is restored unless the name has been taken meanwhile.
+func (m *Manager) RestoreArchived(hashPrefix string) (ClipboardHistory, error) {
+	var restored ClipboardHistory
+	err := m.withArchive(func(archive *db.Archive) error {
+		entries, err := archive.LoadAll()
+		if err != nil {
+			return err
+		}
+		entry, err := findByHashPrefix(entries, hashPrefix)
+		if err != nil {
+			return err
+		}
+
+		index := m.indexOf(entry.Hash)
+		if index >= 0 {
+			if err := m.mergeEntry(index, entry); err != nil {
+				return err
+			}
+		} else {
+			if err := m.importEntry(archive, entry); err != nil {
+				return err
+			}
+			index = len(m.items) - 1
+		}
+		if entry.Alias != "" && m.items[index].Alias == "" {
+			if _, taken := m.FindByAlias(entry.Alias); !taken {
+				if err := m.SetAlias(index, entry.Alias); err != nil {
+					return err
+				}
+			}
+		}
+		restored = m.items[index]
+		sortItems(m.items)
+
+		return archive.Delete(entry.Hash)
+	})
+	return restored, err
+}
+
+// findByHashPrefix returns the single entry whose hash starts with prefix.
+func findByHashPrefix(entries []db.ClipboardEntry, prefix string) (db.ClipboardEntry, error) {
+	if prefix == "" {
+		return db.ClipboardEntry{}, errors.New("empty hash")
+	}
+	var match db.ClipboardEntry
+	found := 0
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Hash, prefix) {
+			match = entry
+			found++
+		}
+	}
+	switch found {
+	case 0:
+		return db.ClipboardEntry{}, fmt.Errorf("no archived entry with hash %s", prefix)
+	case 1:
+		return match, nil
+	default:
+		return db.ClipboardEntry{}, fmt.Errorf("hash %s is ambiguous (%d archived entries)", prefix, found)
+	}
+}
@@ -0,0 +1,120 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestArchiveOlderThanAndRestore(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("old note")
+	manager.AddItem("old pinned")
+	png := []byte("\x89PNG\r\n\x1a\nold image")
+	manager.AddImage(png, "image/png")
+	if err := manager.SetAlias(0, "note"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if err := manager.TogglePin(1); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	cutoff := time.Now().Add(time.Second)
+	manager.AddItem("recent")
+	manager.items[manager.indexOf(manager.lastHash)].TimeStamp = cutoff.Add(time.Minute)
+
+	moved, err := manager.ArchiveOlderThan(cutoff)
+	if err != nil {
+		t.Fatalf("ArchiveOlderThan: %v", err)
+	}
+	if moved != 2 {
+		t.Fatalf("moved = %d, want 2 (pinned and recent items stay)", moved)
+	}
+	if manager.Count() != 2 {
+		t.Errorf("Count = %d, want 2", manager.Count())
+	}
+
+	archived, err := manager.ArchivedItems()
+	if err != nil {
+		t.Fatalf("ArchivedItems: %v", err)
+	}
+	if len(archived) != 2 {
+		t.Fatalf("archived %d items, want 2", len(archived))
+	}
+	var note, image ClipboardHistory
+	for _, item := range archived {
+		if item.IsBinary() {
+			image = item
+		} else {
+			note = item
+		}
+	}
+	if note.Item != "old note" || note.Alias != "note" {
+		t.Errorf("archived note = %+v", note)
+	}
+	if image.Size != len(png) {
+		t.Errorf("archived image size = %d, want %d", image.Size, len(png))
+	}
+
+	restored, err := manager.RestoreArchived(note.Hash[:8])
+	if err != nil {
+		t.Fatalf("RestoreArchived: %v", err)
+	}
+	if restored.Item != "old note" || restored.Alias != "note" {
+		t.Errorf("restored = %+v", restored)
+	}
+	if _, err := manager.RestoreArchived(image.Hash); err != nil {
+		t.Fatalf("RestoreArchived image: %v", err)
+	}
+	data, err := manager.GetData(manager.items[manager.indexOf(image.Hash)])
+	if err != nil || !bytes.Equal(data, png) {
+		t.Errorf("restored image data = %q, %v", data, err)
+	}
+
+	if archived, _ := manager.ArchivedItems(); len(archived) != 0 {
+		t.Errorf("expected archive to be empty after restore, got %d", len(archived))
+	}
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if manager.Count() != 4 {
+		t.Errorf("Count after reload = %d, want 4", manager.Count())
+	}
+}
+
+func TestRestoreArchivedMergesRecopiedItem(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("again")
+	if _, err := manager.ArchiveOlderThan(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("ArchiveOlderThan: %v", err)
+	}
+	manager.AddItem("again")
+
+	restored, err := manager.RestoreArchived(manager.lastHash)
+	if err != nil {
+		t.Fatalf("RestoreArchived: %v", err)
+	}
+	if restored.Count != 2 || manager.Count() != 1 {
+		t.Errorf("expected the copies to be combined, got count %d with %d items", restored.Count, manager.Count())
+	}
+}
+
+func TestRestoreArchivedUnknownHash(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if _, err := manager.RestoreArchived("abc"); err == nil {
+		t.Error("expected error for unknown hash")
+	}
+}
+
+func TestArchiveInMemoryManager(t *testing.T) {
+	manager := NewInMemoryManager()
+	if _, err := manager.ArchiveOlderThan(time.Now()); !errors.Is(err, ErrNoArchive) {
+		t.Errorf("expected ErrNoArchive, got %v", err)
+	}
+}
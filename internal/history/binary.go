@@ -0,0 +1,144 @@
+package history
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// AddImage records image data copied to the clipboard, saving it as a
+// file in the media directory along with its dimensions. Like AddItem it
+// returns false if the same image is already in history.
+func (m *Manager) AddImage(data []byte, mimeType string) bool {
+	if len(data) == 0 {
+		return false
+	}
+	if m.captureLimit > 0 && len(data) > m.captureLimit {
+		log.Printf("Skipped a %s image over the capture limit", FormatSize(len(data)))
+		return false
+	}
+
+	width, height := imageSize(data)
+	item := ClipboardHistory{
+		Item:      DescribeImage(mimeType, width, height, len(data)),
+		Hash:      fmt.Sprintf("%x", sha256.Sum256(data)),
+		TimeStamp: time.Now(),
+		Type:      detect.Image,
+		Kind:      KindImage,
+		MimeType:  mimeType,
+		Size:      len(data),
+		Count:     1,
+		Incognito: m.incognito,
+		Width:     width,
+		Height:    height,
+	}
+	if m.duplicate(item.Hash, len(data)) {
+		return false
+	}
+	stale := m.stale(item.Hash, len(data))
+	if stale >= 0 {
+		if item.Incognito {
+			return false
+		}
+		m.replacing(&item, stale, len(data))
+	}
+	item.SourceApp = m.focusedApp()
+	if !m.applyPolicy(&item, len(data)) {
+		return false
+	}
+
+	if m.persisted(item) {
+		entry := db.ClipboardEntry{
+			Content:   item.Item,
+			Hash:      item.Hash,
+			Timestamp: item.TimeStamp,
+			Type:      string(item.Type),
+			Kind:      string(item.Kind),
+			MimeType:  mimeType,
+			SourceApp: item.SourceApp,
+			Length:    len(data),
+			Width:     width,
+			Height:    height,
+			Tags:      item.Tags,
+			Pinned:    item.Pinned,
+			ID:        item.ID,
+		}
+		if err := m.writeMedia(item.Hash, mimeType, data); err != nil {
+			log.Printf("Failed to save image: %v", err)
+			return false
+		}
+		id, err := m.insertReplacing(entry, stale)
+		if err != nil {
+			log.Printf("Failed to add image: %v", err)
+			m.removeMedia(item)
+			return false
+		}
+		item.ID = id
+		if stale >= 0 {
+			m.dropStale(stale, item)
+		}
+	} else {
+		if m.blobs == nil {
+			m.blobs = make(map[string][]byte)
+		}
+		m.blobs[item.Hash] = data
+	}
+
+	m.items = append(m.items, item)
+	m.lastHash, m.lastLength = item.Hash, len(data)
+	m.hashes[item.Hash] = len(data)
+	return true
+}
+
+// GetData returns the binary payload of a binary entry.
+func (m *Manager) GetData(item ClipboardHistory) ([]byte, error) {
+	if !item.IsBinary() {
+		return []byte(item.Item), nil
+	}
+	if !m.persisted(item) {
+		data, ok := m.blobs[item.Hash]
+		if !ok {
+			return nil, fmt.Errorf("no data for clip %s", item.Hash)
+		}
+		return data, nil
+	}
+	data, err := readMedia(m.mediaDir(), item.Hash, item.MimeType)
+	if err != nil || data != nil {
+		return data, err
+	}
+	// Captured before images were saved as files
+	return m.dbClient.LoadData(item.Hash)
+}
+
+// DescribeBinary returns the display text used for a binary entry,
+// e.g. "[image/png 12.3 KB]".
+func DescribeBinary(mimeType string, size int) string {
+	return fmt.Sprintf("[%s %s]", mimeType, FormatSize(size))
+}
+
+// DescribeImage returns the display text of an image entry, with its
+// dimensions when known, e.g. "[image/png 800×600 12.3 KB]".
+func DescribeImage(mimeType string, width, height, size int) string {
+	if width == 0 || height == 0 {
+		return DescribeBinary(mimeType, size)
+	}
+	return fmt.Sprintf("[%s %d×%d %s]", mimeType, width, height, FormatSize(size))
+}
+
+// FormatSize renders a byte count using binary units.
+func FormatSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
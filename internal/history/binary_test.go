@@ -0,0 +1,140 @@
+package history
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+func TestAddImage(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+	if !manager.AddImage(png, "image/png") {
+		t.Fatal("expected AddImage to succeed")
+	}
+	if manager.AddImage(png, "image/png") {
+		t.Error("expected duplicate image to be rejected")
+	}
+	if manager.AddImage(nil, "image/png") {
+		t.Error("expected empty image to be rejected")
+	}
+
+	item, _ := manager.GetItem(0)
+	if !item.IsBinary() || item.Type != detect.Image || item.MimeType != "image/png" || item.Size != len(png) {
+		t.Errorf("unexpected image item: %+v", item)
+	}
+	if item.Item != "[image/png 8 B]" {
+		t.Errorf("Item = %q", item.Item)
+	}
+
+	data, err := manager.GetData(item)
+	if err != nil {
+		t.Fatalf("GetData: %v", err)
+	}
+	if !bytes.Equal(data, png) {
+		t.Errorf("GetData = %v, want %v", data, png)
+	}
+
+	// The payload is saved as a file named after the hash
+	path := manager.MediaPath(item)
+	if path != filepath.Join(filepath.Dir(manager.dbPath), MediaDirName, item.Hash+".png") {
+		t.Errorf("MediaPath = %q", path)
+	}
+	if saved, err := os.ReadFile(path); err != nil || !bytes.Equal(saved, png) {
+		t.Errorf("media file holds %v, %v", saved, err)
+	}
+
+	// Metadata survives a reload, and the payload is read from the file
+	reloaded := &Manager{dbClient: manager.dbClient, dbPath: manager.dbPath}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	item, _ = reloaded.GetItem(0)
+	if item.Kind != KindImage || item.Size != len(png) || item.MimeType != "image/png" {
+		t.Errorf("reloaded image item: %+v", item)
+	}
+	data, err = reloaded.GetData(item)
+	if err != nil || !bytes.Equal(data, png) {
+		t.Errorf("reloaded GetData = %v, %v", data, err)
+	}
+
+	if !manager.DeleteItem(0) {
+		t.Fatal("expected the image to be deleted")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the media file removed, got %v", err)
+	}
+}
+
+func TestAddImageRecordsDimensions(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if !manager.AddImage(buf.Bytes(), "image/png") {
+		t.Fatal("expected AddImage to succeed")
+	}
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	item, _ := manager.GetItem(0)
+	if item.Width != 3 || item.Height != 2 {
+		t.Errorf("dimensions = %dx%d, want 3x2", item.Width, item.Height)
+	}
+	if want := "[image/png 3×2 " + FormatSize(buf.Len()) + "]"; item.Item != want {
+		t.Errorf("Item = %q, want %q", item.Item, want)
+	}
+}
+
+func TestInMemoryManagerAddImage(t *testing.T) {
+	m := NewInMemoryManager()
+	img := []byte("fake-jpeg")
+	if !m.AddImage(img, "image/jpeg") {
+		t.Fatal("expected AddImage to succeed")
+	}
+	item, _ := m.GetItem(0)
+	data, err := m.GetData(item)
+	if err != nil || !bytes.Equal(data, img) {
+		t.Errorf("GetData = %q, %v", data, err)
+	}
+
+	m.DeleteItem(0)
+	if _, err := m.GetData(item); err == nil {
+		t.Error("expected error after image was deleted")
+	}
+}
+
+func TestGetDataText(t *testing.T) {
+	m := NewInMemoryManager()
+	m.AddItem("plain")
+	item, _ := m.GetItem(0)
+	data, err := m.GetData(item)
+	if err != nil || string(data) != "plain" {
+		t.Errorf("GetData = %q, %v", data, err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KB",
+		1536:            "1.5 KB",
+		5 * 1024 * 1024: "5.0 MB",
+	}
+	for n, want := range tests {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
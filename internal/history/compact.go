@@ -0,0 +1,186 @@
+package history
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// digestDay is the layout of a digest's day
+const digestDay = "2006-01-02"
+
+// digestPreviewLength is the longest preview kept of a digest's top item
+const digestPreviewLength = 60
+
+// ErrNoDigests is returned by compaction on in-memory managers.
+var ErrNoDigests = errors.New("compaction requires a database-backed history")
+
+// Digest summarizes the entries of one day that were compacted out of
+// history, keeping its statistics once the entries themselves are gone.
+type Digest struct {
+	Day     time.Time // local midnight starting the day
+	Entries int
+	Copies  int
+	Bytes   int
+	Types   map[detect.Type]int
+	Top     []DigestItem // the most copied entries, most first
+}
+
+// DigestItem is one of the most copied entries of a digest's day.
+type DigestItem struct {
+	Preview string
+	Count   int
+}
+
+// CompactStats reports what CompactOlderThan did.
+type CompactStats struct {
+	Entries int // entries removed from history
+	Days    int // days whose digest was created or added to
+}
+
+// CompactOlderThan collapses unpinned items last copied before cutoff into
+// one digest per local day, recording their count, copies, size and types,
+// and with top above zero previews of that many of the day's most copied
+// entries. The items are then deleted. Items with an alias or in a
+// register are kept, as are secrets in the top previews. Digests already
+// stored for a day are added to.
+func (m *Manager) CompactOlderThan(cutoff time.Time, top int) (CompactStats, error) {
+	if m.dbClient == nil {
+		return CompactStats{}, ErrNoDigests
+	}
+	stored, err := m.dbClient.Digests()
+	if err != nil {
+		return CompactStats{}, fmt.Errorf("error loading digests: %w", err)
+	}
+	digests := make(map[string]db.Digest, len(stored))
+	for _, d := range stored {
+		digests[d.Day] = d
+	}
+
+	var compacted []ClipboardHistory
+	changed := make(map[string]bool)
+	for i := len(m.items) - 1; i >= 0; i-- {
+		item := m.items[i]
+		// Incognito items were never in the database to be summarized
+		if item.Pinned || item.Incognito || item.Alias != "" || item.Registers != "" || !item.TimeStamp.Before(cutoff) {
+			continue
+		}
+		day := item.TimeStamp.Local().Format(digestDay)
+		d := digests[day]
+		d.Day = day
+		addToDigest(&d, item, top)
+		digests[day] = d
+		changed[day] = true
+		compacted = append(compacted, item)
+	}
+	if len(compacted) == 0 {
+		return CompactStats{}, nil
+	}
+
+	// Either every item is removed and every digest saved, or nothing is
+	err = m.transaction(func() error {
+		for _, item := range compacted {
+			if err := m.dbClient.Delete(item.Hash); err != nil {
+				return fmt.Errorf("error removing compacted clip %s: %w", item.Hash, err)
+			}
+		}
+		for _, day := range slices.Sorted(maps.Keys(changed)) {
+			if err := m.dbClient.SaveDigest(digests[day]); err != nil {
+				return fmt.Errorf("error saving digest for %s: %w", day, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return CompactStats{}, err
+	}
+
+	removed := make(map[string]bool, len(compacted))
+	for _, item := range compacted {
+		removed[item.Hash] = true
+		delete(m.hashes, item.Hash)
+		delete(m.blobs, item.Hash)
+		delete(m.formats, item.Hash)
+		if item.Overflow {
+			m.removeOverflow(item.Hash)
+		}
+		if item.IsBinary() {
+			m.removeMedia(item)
+		}
+	}
+	m.items = slices.DeleteFunc(m.items, func(item ClipboardHistory) bool { return removed[item.Hash] })
+	// Copying compacted content again should capture it anew
+	if removed[m.lastHash] {
+		m.lastHash = ""
+	}
+	return CompactStats{Entries: len(compacted), Days: len(changed)}, nil
+}
+
+// addToDigest counts item into d, keeping its top most copied previews
+func addToDigest(d *db.Digest, item ClipboardHistory, top int) {
+	count := max(item.Count, 1)
+	d.Entries++
+	d.Copies += count
+	d.Bytes += item.contentLength()
+	if d.Types == nil {
+		d.Types = make(map[string]int)
+	}
+	d.Types[string(cmp.Or(item.Type, detect.Text))]++
+	if top <= 0 || item.Sensitive != "" {
+		return
+	}
+
+	preview := digestPreview(item.Item)
+	if i := slices.IndexFunc(d.Top, func(t db.DigestItem) bool { return t.Preview == preview }); i >= 0 {
+		d.Top[i].Count += count
+	} else {
+		d.Top = append(d.Top, db.DigestItem{Preview: preview, Count: count})
+	}
+	slices.SortStableFunc(d.Top, func(a, b db.DigestItem) int { return b.Count - a.Count })
+	d.Top = d.Top[:min(len(d.Top), top)]
+}
+
+// digestPreview shortens content to a line of at most digestPreviewLength
+// characters
+func digestPreview(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if runes := []rune(content); len(runes) > digestPreviewLength {
+		content = string(runes[:digestPreviewLength-3]) + "..."
+	}
+	return content
+}
+
+// Digests returns the digests of compacted days, oldest first.
+func (m *Manager) Digests() ([]Digest, error) {
+	if m.dbClient == nil {
+		return nil, ErrNoDigests
+	}
+	stored, err := m.dbClient.Digests()
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]Digest, 0, len(stored))
+	for _, d := range stored {
+		day, err := time.ParseInLocation(digestDay, d.Day, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("digest with invalid day %q: %w", d.Day, err)
+		}
+		digest := Digest{Day: day, Entries: d.Entries, Copies: d.Copies, Bytes: d.Bytes,
+			Types: make(map[detect.Type]int, len(d.Types))}
+		for t, n := range d.Types {
+			digest.Types[detect.Type(t)] = n
+		}
+		for _, t := range d.Top {
+			digest.Top = append(digest.Top, DigestItem(t))
+		}
+		digests = append(digests, digest)
+	}
+	return digests, nil
+}
@@ -0,0 +1,97 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bvdwalt/clippy/internal/db"
+)
+
+// Persist moves an in-memory manager onto the database at dbPath, e.g.
+// once a database that couldn't be opened at startup is available again.
+// Items collected in the meantime are stored, apart from incognito ones
+// and content the database already holds, and the count stored is
+// returned. Managers already backed by a database are left alone.
+func (m *Manager) Persist(dbPath string) (int, error) {
+	if m.dbClient != nil {
+		return 0, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return 0, fmt.Errorf("error creating directory: %w", err)
+	}
+	client, err := db.New(dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("error opening database: %w", err)
+	}
+
+	pending, blobs, formats := m.items, m.blobs, m.formats
+	m.dbClient, m.dbPath = client, dbPath
+	if err := m.LoadFromDB(); err != nil {
+		if err := client.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+		m.dbClient, m.dbPath = nil, ""
+		return 0, fmt.Errorf("error loading database: %w", err)
+	}
+	m.RefreshIncognito()
+
+	stored := 0
+	for _, item := range pending {
+		if _, exists := m.hashes[item.Hash]; exists || item.Incognito {
+			continue
+		}
+		entry := db.ClipboardEntry{
+			Content:   item.Item,
+			Hash:      item.Hash,
+			Timestamp: item.TimeStamp,
+			Pinned:    item.Pinned,
+			Type:      string(item.Type),
+			Kind:      string(item.Kind),
+			MimeType:  item.MimeType,
+			Data:      blobs[item.Hash],
+			Count:     item.Count,
+			ExpiresAt: item.ExpiresAt,
+			Selection: string(item.Selection),
+			SourceApp: item.SourceApp,
+			Width:     item.Width,
+			Height:    item.Height,
+			Tags:      item.Tags,
+		}
+		if err := m.importEntry(memoryData(blobs), entry); err != nil {
+			log.Printf("Failed to store clip: %v", err)
+			continue
+		}
+		index := len(m.items) - 1
+		if f := formats[item.Hash]; len(f) > 0 {
+			if err := m.setFormats(index, f); err != nil {
+				log.Printf("Failed to store clip formats: %v", err)
+			}
+		}
+		if item.Alias != "" {
+			if _, taken := m.FindByAlias(item.Alias); !taken {
+				if err := m.SetAlias(index, item.Alias); err != nil {
+					log.Printf("Failed to store alias: %v", err)
+				}
+			}
+		}
+		delete(m.blobs, item.Hash)
+		delete(m.formats, item.Hash)
+		stored++
+	}
+	sortItems(m.items)
+	return stored, nil
+}
+
+// memoryData serves the binary payloads of an in-memory manager, by hash
+type memoryData map[string][]byte
+
+// LoadData returns the payload stored for hash.
+func (d memoryData) LoadData(hash string) ([]byte, error) {
+	data, ok := d[hash]
+	if !ok {
+		return nil, fmt.Errorf("no data for clip %s", hash)
+	}
+	return data, nil
+}
@@ -0,0 +1,110 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistStoresItemsCollectedInMemory(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "clippy.db")
+	stored, err := NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	stored.AddItem("already saved")
+	if err := stored.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	m := NewInMemoryManager()
+	m.AddItem("already saved")
+	m.AddItem("copied while unsaved")
+	if err := m.SetAlias(m.indexOf(newClipboardItem("copied while unsaved").Hash), "unsaved"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if !m.AddImage([]byte("fake-png"), "image/png") {
+		t.Fatal("AddImage failed")
+	}
+
+	count, err := m.Persist(dbPath)
+	if err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	}()
+	if count != 2 || m.Count() != 3 || m.DBPath() != dbPath {
+		t.Fatalf("Persist stored %d, history has %d items at %q", count, m.Count(), m.DBPath())
+	}
+
+	reopened, err := NewManagerWithPath(dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer func() {
+		if err := reopened.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	}()
+	if err := reopened.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if reopened.Count() != 3 {
+		t.Fatalf("database holds %d items, want 3", reopened.Count())
+	}
+	if item, ok := reopened.FindByAlias("unsaved"); !ok || item.Item != "copied while unsaved" {
+		t.Errorf("FindByAlias = %+v, %v", item, ok)
+	}
+	for _, item := range reopened.GetItems() {
+		if item.IsBinary() {
+			if data, err := reopened.GetData(item); err != nil || !bytes.Equal(data, []byte("fake-png")) {
+				t.Errorf("GetData = %q, %v", data, err)
+			}
+		}
+	}
+}
+
+func TestPersistKeepsIncognitoItemsInMemory(t *testing.T) {
+	m := NewInMemoryManager()
+	if err := m.SetIncognito(true); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	m.AddItem("private")
+	if err := m.SetIncognito(false); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+
+	count, err := m.Persist(filepath.Join(t.TempDir(), "clippy.db"))
+	if err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	}()
+	if count != 0 || m.Count() != 1 {
+		t.Errorf("Persist stored %d of %d items, want the incognito item kept in memory", count, m.Count())
+	}
+}
+
+func TestPersistFailureKeepsManagerInMemory(t *testing.T) {
+	m := NewInMemoryManager()
+	m.AddItem("kept")
+	// A file where the data directory should be
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Persist(filepath.Join(blocker, "clippy.db")); err == nil {
+		t.Fatal("expected Persist to fail")
+	}
+	if m.DBPath() != "" || m.Count() != 1 {
+		t.Errorf("after a failed Persist: path %q, %d items", m.DBPath(), m.Count())
+	}
+}
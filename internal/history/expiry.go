@@ -0,0 +1,80 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ExpiryRule gives newly captured items whose content matches Pattern a
+// time-to-live of TTL, e.g. to drop one-time passwords after a few minutes.
+type ExpiryRule struct {
+	Pattern *regexp.Regexp
+	TTL     time.Duration
+}
+
+// NewExpiryRule compiles pattern into an ExpiryRule.
+func NewExpiryRule(pattern string, ttl time.Duration) (ExpiryRule, error) {
+	if ttl <= 0 {
+		return ExpiryRule{}, fmt.Errorf("invalid ttl %s for pattern %q", ttl, pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ExpiryRule{}, fmt.Errorf("invalid expiry pattern %q: %w", pattern, err)
+	}
+	return ExpiryRule{Pattern: re, TTL: ttl}, nil
+}
+
+// SetExpiryRules sets the rules applied to items added by AddItem. The first
+// matching rule wins.
+func (m *Manager) SetExpiryRules(rules []ExpiryRule) {
+	m.expiryRules = rules
+}
+
+// ruleTTL returns the TTL of the first rule matching content, or 0.
+func (m *Manager) ruleTTL(content string) time.Duration {
+	for _, rule := range m.expiryRules {
+		if rule.Pattern.MatchString(content) {
+			return rule.TTL
+		}
+	}
+	return 0
+}
+
+// SetExpiry makes the item at index expire ttl from now. A ttl of 0 clears
+// the expiry.
+func (m *Manager) SetExpiry(index int, ttl time.Duration) error {
+	if index < 0 || index >= len(m.items) {
+		return fmt.Errorf("invalid index: %d", index)
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	item := &m.items[index]
+	if m.persisted(*item) {
+		if err := m.dbClient.SetExpiry(item.Hash, expiresAt); err != nil {
+			return err
+		}
+	}
+	item.ExpiresAt = expiresAt
+	return nil
+}
+
+// PurgeExpired deletes all unpinned items that have expired by now and
+// returns how many were removed. Pinning an item keeps it past its expiry.
+func (m *Manager) PurgeExpired(now time.Time) int {
+	removed := 0
+	for i := len(m.items) - 1; i >= 0; i-- {
+		item := m.items[i]
+		if item.Pinned || !item.IsExpired(now) {
+			continue
+		}
+		if m.DeleteItem(i) {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,104 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryRuleAppliesToNewItems(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	rule, err := NewExpiryRule(`^\d{6}$`, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("NewExpiryRule: %v", err)
+	}
+	manager.SetExpiryRules([]ExpiryRule{rule})
+
+	manager.AddItem("123456")
+	manager.AddItem("regular text")
+
+	otp, _ := manager.GetItem(0)
+	if got := otp.ExpiresAt.Sub(otp.TimeStamp); got != 5*time.Minute {
+		t.Errorf("expected OTP to expire 5m after capture, got %v", got)
+	}
+	text, _ := manager.GetItem(1)
+	if !text.ExpiresAt.IsZero() {
+		t.Errorf("expected non-matching item not to expire, got %v", text.ExpiresAt)
+	}
+
+	// The expiry is persisted
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if reloaded, _ := manager.GetItem(0); reloaded.ExpiresAt.IsZero() {
+		t.Error("expected expiry to survive a reload")
+	}
+}
+
+func TestNewExpiryRuleInvalid(t *testing.T) {
+	if _, err := NewExpiryRule("(", time.Minute); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := NewExpiryRule("x", 0); err == nil {
+		t.Error("expected error for zero ttl")
+	}
+}
+
+func TestSetExpiryAndPurge(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("secret")
+	manager.AddItem("keep")
+	manager.AddItem("pinned secret")
+	if err := manager.SetExpiry(0, time.Minute); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	if err := manager.SetExpiry(2, time.Minute); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	if err := manager.TogglePin(2); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+
+	if n := manager.PurgeExpired(time.Now()); n != 0 {
+		t.Errorf("expected nothing purged before expiry, got %d", n)
+	}
+	if n := manager.PurgeExpired(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("expected 1 item purged, got %d", n)
+	}
+	if manager.Count() != 2 {
+		t.Fatalf("expected 2 items left, got %d", manager.Count())
+	}
+	for _, item := range manager.GetItems() {
+		if item.Item == "secret" {
+			t.Error("expected expired item to be purged")
+		}
+	}
+
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if manager.Count() != 2 {
+		t.Errorf("expected purge to be persisted, got %d items", manager.Count())
+	}
+}
+
+func TestSetExpiryClears(t *testing.T) {
+	manager := NewInMemoryManager()
+	manager.AddItem("temporary")
+
+	if err := manager.SetExpiry(0, time.Minute); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	if err := manager.SetExpiry(0, 0); err != nil {
+		t.Fatalf("SetExpiry clear: %v", err)
+	}
+	if n := manager.PurgeExpired(time.Now().Add(time.Hour)); n != 0 {
+		t.Errorf("expected cleared expiry not to purge, got %d", n)
+	}
+	if err := manager.SetExpiry(5, time.Minute); err == nil {
+		t.Error("expected error for invalid index")
+	}
+}
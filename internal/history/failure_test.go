@@ -0,0 +1,193 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/db"
+)
+
+// failingDB is a database whose writes fail while failing is set
+type failingDB struct {
+	db.DBClient
+	failing bool
+}
+
+var errDBDown = errors.New("database is locked")
+
+func (f *failingDB) Insert(entry db.ClipboardEntry) error {
+	if f.failing {
+		return errDBDown
+	}
+	return f.DBClient.Insert(entry)
+}
+
+func (f *failingDB) Bump(hash string, timestamp time.Time) error {
+	if f.failing {
+		return errDBDown
+	}
+	return f.DBClient.Bump(hash, timestamp)
+}
+
+func (f *failingDB) SetFormats(hash string, formats map[string][]byte) error {
+	if f.failing {
+		return errDBDown
+	}
+	return f.DBClient.SetFormats(hash, formats)
+}
+
+func (f *failingDB) Clear(keepPinned bool) error {
+	if f.failing {
+		return errDBDown
+	}
+	return f.DBClient.Clear(keepPinned)
+}
+
+func (f *failingDB) SaveDigest(d db.Digest) error {
+	if f.failing {
+		return errDBDown
+	}
+	return f.DBClient.SaveDigest(d)
+}
+
+// Transaction runs fn with a transaction that fails as f does
+func (f *failingDB) Transaction(fn func(tx db.DBClient) error) error {
+	return f.DBClient.Transaction(func(tx db.DBClient) error {
+		return fn(&failingDB{DBClient: tx, failing: f.failing})
+	})
+}
+
+func useFailingDB(t *testing.T) (*Manager, *failingDB) {
+	t.Helper()
+	manager, cleanup := setupTestManager(t)
+	t.Cleanup(cleanup)
+	failing := &failingDB{DBClient: manager.dbClient}
+	manager.dbClient = failing
+	return manager, failing
+}
+
+func TestAddTextFailureLeavesHistoryUnchanged(t *testing.T) {
+	manager, database := useFailingDB(t)
+	manager.SetOverflowThreshold(100)
+	manager.AddItem("stored")
+
+	database.failing = true
+	large := strings.Repeat("x", 1000)
+	for _, content := range []string{"new", large} {
+		added, err := manager.AddText(content, SelectionClipboard, map[string][]byte{"text/html": []byte("<b>new</b>")})
+		if added || !errors.Is(err, errDBDown) {
+			t.Errorf("AddText = %v, %v; want the database error", added, err)
+		}
+	}
+	if manager.Count() != 1 {
+		t.Fatalf("Count = %d, want only the stored item", manager.Count())
+	}
+	if _, err := os.Stat(overflowPath(manager.overflowDir(), newClipboardItem(large).Hash)); !os.IsNotExist(err) {
+		t.Errorf("expected the overflow file to be removed, got %v", err)
+	}
+
+	// Once the database recovers the same content is added, not taken for
+	// a duplicate of the failed attempt
+	database.failing = false
+	if added, err := manager.AddText("new", SelectionClipboard, nil); !added || err != nil {
+		t.Errorf("AddText after recovery = %v, %v; want added", added, err)
+	}
+	if added, err := manager.AddText("new", SelectionClipboard, nil); added || err != nil {
+		t.Errorf("AddText of a duplicate = %v, %v; want not added without error", added, err)
+	}
+}
+
+func TestBumpFailureKeepsCount(t *testing.T) {
+	manager, database := useFailingDB(t)
+	manager.SetBumpDuplicates(true)
+	manager.AddItem("again")
+
+	database.failing = true
+	if added, err := manager.AddText("again", SelectionClipboard, nil); added || !errors.Is(err, errDBDown) {
+		t.Errorf("AddText = %v, %v; want the database error", added, err)
+	}
+	if item, _ := manager.GetItem(0); item.Count != 1 {
+		t.Errorf("Count = %d, want 1 after a failed bump", item.Count)
+	}
+}
+
+func TestAddTextStoresFormatsWithItem(t *testing.T) {
+	manager, _ := useFailingDB(t)
+	formats := map[string][]byte{"text/html": []byte("<b>bold</b>")}
+	if added, err := manager.AddText("bold", SelectionClipboard, formats); !added || err != nil {
+		t.Fatalf("AddText = %v, %v", added, err)
+	}
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	item, _ := manager.GetItem(0)
+	if data, err := manager.Format(item, "text/html"); err != nil || string(data) != "<b>bold</b>" {
+		t.Errorf("Format = %q, %v; want the HTML stored with the item", data, err)
+	}
+}
+
+func TestClearAllFailureLeavesHistoryUnchanged(t *testing.T) {
+	manager, database := useFailingDB(t)
+	manager.SetOverflowThreshold(100)
+	manager.AddItem("small")
+	manager.AddItem(strings.Repeat("x", 1000))
+	large, _ := manager.Latest()
+
+	database.failing = true
+	if cleared, err := manager.ClearAll(false); cleared != 0 || !errors.Is(err, errDBDown) {
+		t.Errorf("ClearAll = %d, %v; want the database error", cleared, err)
+	}
+	if manager.Count() != 2 {
+		t.Errorf("Count = %d, want both items kept", manager.Count())
+	}
+	if text, err := manager.Text(large); err != nil || len(text) != 1000 {
+		t.Errorf("overflow text = %d bytes, %v; want it kept", len(text), err)
+	}
+
+	database.failing = false
+	if cleared, err := manager.ClearAll(false); cleared != 2 || err != nil {
+		t.Fatalf("ClearAll = %d, %v; want both cleared", cleared, err)
+	}
+	if _, err := os.Stat(overflowPath(manager.overflowDir(), large.Hash)); !os.IsNotExist(err) {
+		t.Errorf("expected the overflow file removed, got %v", err)
+	}
+	reloaded := &Manager{dbClient: manager.dbClient}
+	if err := reloaded.LoadFromDB(); err != nil || reloaded.Count() != 0 {
+		t.Errorf("stored history has %d items, %v; want none", reloaded.Count(), err)
+	}
+}
+
+func TestReplacingFailureKeepsTheStaleItem(t *testing.T) {
+	manager, database := useFailingDB(t)
+
+	// A row cut short by an old version, stored under its full content's hash
+	full := "the whole of the copied text"
+	if err := manager.dbClient.Insert(db.ClipboardEntry{
+		Content:   full[:8],
+		Hash:      newClipboardItem(full).Hash,
+		Timestamp: time.Now(),
+	}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+
+	database.failing = true
+	if added, err := manager.AddText(full, SelectionClipboard, nil); added || !errors.Is(err, errDBDown) {
+		t.Fatalf("AddText = %v, %v; want the database error", added, err)
+	}
+	if item, _ := manager.GetItem(0); manager.Count() != 1 || item.Item != full[:8] {
+		t.Errorf("history in memory changed: %d items, %q", manager.Count(), item.Item)
+	}
+	reloaded := &Manager{dbClient: manager.dbClient}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if item, _ := reloaded.GetItem(0); reloaded.Count() != 1 || item.Item != full[:8] {
+		t.Errorf("stored history changed: %d items, %q", reloaded.Count(), item.Item)
+	}
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+)
+
+// AddItemWithFormats is AddItem for text copied along with alternate
+// representations of it keyed by MIME type, such as the HTML of a web page
+// selection. They are kept with the entry, replacing those of an earlier
+// copy, so copying it back can restore the original format (see Format).
+// A new entry is stored together with its formats, or not at all.
+func (m *Manager) AddItemWithFormats(content string, formats map[string][]byte) bool {
+	added, err := m.AddText(content, SelectionClipboard, formats)
+	if err != nil {
+		log.Printf("Failed to add clip: %v", err)
+	}
+	return added
+}
+
+// setFormats replaces the formats of items[index]
+func (m *Manager) setFormats(index int, formats map[string][]byte) error {
+	item := m.items[index]
+	if m.persisted(item) {
+		if err := m.dbClient.SetFormats(item.Hash, formats); err != nil {
+			return fmt.Errorf("error storing formats: %w", err)
+		}
+	} else {
+		if m.formats == nil {
+			m.formats = make(map[string]map[string][]byte)
+		}
+		m.formats[item.Hash] = formats
+	}
+	m.items[index].Formats = slices.Sorted(maps.Keys(formats))
+	return nil
+}
+
+// Format returns the representation of item stored as mimeType, one of
+// item.Formats.
+func (m *Manager) Format(item ClipboardHistory, mimeType string) ([]byte, error) {
+	if !slices.Contains(item.Formats, mimeType) {
+		return nil, fmt.Errorf("clip %s has no %s format", item.Hash, mimeType)
+	}
+	if !m.persisted(item) {
+		return m.formats[item.Hash][mimeType], nil
+	}
+	return m.dbClient.LoadFormat(item.Hash, mimeType)
+}
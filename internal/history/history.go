@@ -3,12 +3,17 @@ package history
 import (
 	"crypto/sha256"
 	"fmt"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"time"
 
 	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/trace"
 )
 
 const (
@@ -18,36 +23,61 @@ const (
 
 // Manager handles clipboard history storage and management
 type Manager struct {
-	items    []ClipboardHistory
-	hashes   map[string]struct{}
-	lastHash string
-	dbClient db.DBClient // nil for in-memory managers
-	dbPath   string
+	items      []ClipboardHistory
+	hashes     map[string]int // content length of each item, by hash
+	lastHash   string
+	lastLength int         // content length of the item with lastHash
+	dbClient   db.DBClient // nil for in-memory managers
+	dbPath     string
+	blobs      map[string][]byte            // binary payloads of items not in the database
+	formats    map[string]map[string][]byte // alternate formats of items not in the database, by hash and MIME type
+
+	bumpDuplicates bool         // re-copied items move to the newest position
+	expiryRules    []ExpiryRule // give matching new items a TTL
+	policies       []AppPolicy  // limit and tag new items by source app
+
+	overflowThreshold int           // content larger than this is stored in a file; 0 disables
+	captureLimit      int           // content larger than this isn't recorded whole; 0 disables
+	truncateOversized bool          // content over captureLimit is truncated rather than skipped
+	skipSensitive     bool          // refuse to record content holding secrets
+	sourceApp         func() string // names the focused application for new items; nil records none
+
+	onCapture func(ClipboardHistory) // called with each new item; nil for none
+
+	dataVersion int64 // database version at the last ReloadIfChanged
+	incognito   bool  // new items are kept in memory only
 }
 
 // NewManager creates a new history manager
 func NewManager() (*Manager, error) {
-	homeDir, err := os.UserHomeDir()
+	dbPath, err := DefaultDBPath()
 	if err != nil {
-		return nil, fmt.Errorf("error getting home directory: %w", err)
+		return nil, err
 	}
-
-	configDir := filepath.Join(homeDir, ConfigDir)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("error creating config directory: %w", err)
 	}
 
-	dbPath := filepath.Join(configDir, DBFileName)
-
 	return NewManagerWithPath(dbPath)
 }
 
+// DefaultDBPath returns the location of the history database opened by
+// NewManager.
+func DefaultDBPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ConfigDir, DBFileName), nil
+}
+
 // NewInMemoryManager creates a history manager with no database backing.
 // Items are stored in memory only and are not persisted between runs.
 func NewInMemoryManager() *Manager {
 	return &Manager{
-		items:  make([]ClipboardHistory, 0),
-		hashes: make(map[string]struct{}),
+		items:        make([]ClipboardHistory, 0),
+		hashes:       make(map[string]int),
+		captureLimit: DefaultCaptureLimit,
 	}
 }
 
@@ -66,10 +96,14 @@ func NewManagerWithPath(dbPath string) (*Manager, error) {
 
 	manager := &Manager{
 		items:    make([]ClipboardHistory, 0),
-		hashes:   make(map[string]struct{}),
+		hashes:   make(map[string]int),
 		dbClient: dbClient,
 		dbPath:   dbPath,
+
+		overflowThreshold: DefaultOverflowThreshold,
+		captureLimit:      DefaultCaptureLimit,
 	}
+	manager.RefreshIncognito()
 
 	return manager, nil
 }
@@ -82,33 +116,319 @@ func (m *Manager) Close() error {
 	return m.dbClient.Close()
 }
 
-// AddItem adds a new clipboard item if it doesn't already exist
+// SetSkipSensitive makes AddItem refuse content holding a secret, such as
+// an AWS key or a private key, instead of recording it masked.
+func (m *Manager) SetSkipSensitive(enabled bool) {
+	m.skipSensitive = enabled
+}
+
+// SetSourceApp makes new items record the application focused when they
+// were copied, as named by detect (e.g. privacy.SourceApp), which returns ""
+// when it can't tell. Detection runs only for items actually added.
+func (m *Manager) SetSourceApp(detect func() string) {
+	m.sourceApp = detect
+}
+
+// focusedApp names the focused application for a new item, if enabled
+func (m *Manager) focusedApp() string {
+	if m.sourceApp == nil {
+		return ""
+	}
+	return m.sourceApp()
+}
+
+// SetOnCapture makes fn be called with each new item once it is added,
+// e.g. to run hooks. Re-copied items that are bumped or ignored don't
+// count as new.
+func (m *Manager) SetOnCapture(fn func(ClipboardHistory)) {
+	m.onCapture = fn
+}
+
+// SetBumpDuplicates controls what AddItem does with content already in
+// history: when enabled the existing item is bumped to the newest position
+// and its count incremented; otherwise the duplicate is ignored.
+func (m *Manager) SetBumpDuplicates(enabled bool) {
+	m.bumpDuplicates = enabled
+}
+
+// AddItem adds a new clipboard item if it doesn't already exist. With
+// SetBumpDuplicates enabled, re-copying an existing item bumps it instead and
+// AddItem reports true.
 func (m *Manager) AddItem(content string) bool {
+	return m.AddItemFrom(content, SelectionClipboard)
+}
+
+// AddItemFrom is AddItem for content captured from selection. An item
+// already in history keeps the selection it was first captured from.
+// Content over the capture limit is truncated or skipped. Failing to store
+// the item is logged; use AddText to handle it.
+func (m *Manager) AddItemFrom(content string, selection Selection) bool {
+	added, err := m.AddText(content, selection, nil)
+	if err != nil {
+		log.Printf("Failed to add clip: %v", err)
+	}
+	return added
+}
+
+// AddText records text captured from selection, with any alternate formats
+// it was copied with (see AddItemWithFormats), reporting whether it was
+// added or, with SetBumpDuplicates, bumped. Duplicate, sensitive or
+// oversized content is not added without that being an error. An error
+// means the item could not be stored, and history is left as it was.
+func (m *Manager) AddText(content string, selection Selection, formats map[string][]byte) (bool, error) {
+	content, ok := m.limitCapture(content)
+	if !ok {
+		return false, nil
+	}
+	return m.addText(content, selection, formats)
+}
+
+// addText is AddText for content within the capture limit
+func (m *Manager) addText(content string, selection Selection, formats map[string][]byte) (bool, error) {
 	item := newClipboardItem(content)
-	if !m.containsHash(item.Hash) {
-		if m.dbClient != nil {
-			entry := db.ClipboardEntry{
-				Content:   item.Item,
-				Hash:      item.Hash,
-				Timestamp: item.TimeStamp,
-				Pinned:    item.Pinned,
+	item.Selection = selection
+	var store func(*ClipboardHistory) error
+	if m.shouldOverflow(content) {
+		store = m.writeOverflow
+	}
+	added, err := m.addItem(item, store, formats)
+	if err != nil || added || len(formats) == 0 {
+		return added, err
+	}
+	// Already in history; the formats replace those of the earlier copy
+	if i := m.indexOf(item.Hash); i >= 0 {
+		return false, m.setFormats(i, formats)
+	}
+	return false, nil
+}
+
+// addItem records a new text item with its formats, or bumps the item it
+// duplicates. Unless the item is incognito, store (if set) moves its full
+// content to an overflow file before it is inserted. Memory is only updated
+// once the database has committed the item.
+func (m *Manager) addItem(item ClipboardHistory, store func(*ClipboardHistory) error, formats map[string][]byte) (bool, error) {
+	item.Incognito = m.incognito
+	if m.skipSensitive && item.Sensitive != "" {
+		return false, nil
+	}
+	// Bumping a stored item would record that it was copied again
+	length := item.contentLength()
+	if m.bumpDuplicates && !m.incognito {
+		if stored, exists := m.hashes[item.Hash]; exists && stored == length {
+			bumped, err := m.bump(item.Hash, item.TimeStamp)
+			if err != nil || !bumped || len(formats) == 0 {
+				return bumped, err
 			}
-			if err := m.dbClient.Insert(entry); err != nil {
-				return false
+			return true, m.setFormats(m.indexOf(item.Hash), formats)
+		}
+	}
+	if m.duplicate(item.Hash, length) {
+		return false, nil
+	}
+	stale := m.stale(item.Hash, length)
+	if stale >= 0 {
+		if item.Incognito {
+			// The stored item can't be replaced by one that isn't saved
+			return false, nil
+		}
+		m.replacing(&item, stale, length)
+	}
+
+	if ttl := m.ruleTTL(item.Item); ttl > 0 {
+		item.ExpiresAt = item.TimeStamp.Add(ttl)
+	}
+	item.SourceApp = m.focusedApp()
+	if !m.applyPolicy(&item, length) {
+		return false, nil
+	}
+	if !item.Incognito && store != nil {
+		if err := store(&item); err != nil {
+			return false, fmt.Errorf("error storing large clip: %w", err)
+		}
+	}
+	if m.persisted(item) {
+		entry := db.ClipboardEntry{
+			Content:    item.Item,
+			Hash:       item.Hash,
+			Timestamp:  item.TimeStamp,
+			Pinned:     item.Pinned,
+			Type:       string(item.Type),
+			Kind:       string(item.Kind),
+			ExpiresAt:  item.ExpiresAt,
+			Selection:  string(item.Selection),
+			SourceApp:  item.SourceApp,
+			Length:     length,
+			FormatData: formats,
+			Tags:       item.Tags,
+			ID:         item.ID,
+		}
+		if item.Overflow {
+			entry.OverflowSize = item.Size
+		}
+		id, err := m.insertReplacing(entry, stale)
+		if err != nil {
+			if item.Overflow {
+				m.removeOverflow(item.Hash)
 			}
+			return false, fmt.Errorf("error adding clip: %w", err)
 		}
+		item.ID = id
+	} else if len(formats) > 0 {
+		if m.formats == nil {
+			m.formats = make(map[string]map[string][]byte)
+		}
+		m.formats[item.Hash] = formats
+	}
 
-		m.items = append(m.items, item)
-		m.lastHash = item.Hash
-		m.hashes[item.Hash] = struct{}{}
-		return true
+	if stale >= 0 {
+		m.dropStale(stale, item)
 	}
-	return false
+	if len(formats) > 0 {
+		item.Formats = slices.Sorted(maps.Keys(formats))
+	}
+	m.items = append(m.items, item)
+	m.lastHash, m.lastLength = item.Hash, length
+	m.hashes[item.Hash] = length
+	if m.onCapture != nil {
+		m.onCapture(item)
+	}
+	return true, nil
 }
 
-func (m *Manager) containsHash(s string) bool {
-	_, contains := m.hashes[s]
-	return contains || m.lastHash == s
+// insert stores entry in the database, timed when tracing, and returns
+// the ID it was given
+func (m *Manager) insert(entry db.ClipboardEntry) (int64, error) {
+	defer trace.Start(trace.Insert).End()
+	if err := m.dbClient.Insert(entry); err != nil {
+		return 0, err
+	}
+	return m.dbClient.EntryID(entry.Hash)
+}
+
+// transaction runs fn with the database writes it makes stored together, or
+// none of them if it returns an error. Items in memory are left to fn.
+func (m *Manager) transaction(fn func() error) error {
+	if m.dbClient == nil {
+		return fn()
+	}
+	client := m.dbClient
+	defer func() { m.dbClient = client }()
+	return client.Transaction(func(tx db.DBClient) error {
+		m.dbClient = tx
+		return fn()
+	})
+}
+
+// bump moves the item with hash to timestamp and increments its count
+func (m *Manager) bump(hash string, timestamp time.Time) (bool, error) {
+	for i := range m.items {
+		if m.items[i].Hash != hash {
+			continue
+		}
+		if m.persisted(m.items[i]) {
+			if err := m.dbClient.Bump(hash, timestamp); err != nil {
+				return false, fmt.Errorf("error bumping clip: %w", err)
+			}
+		}
+		m.items[i].TimeStamp = timestamp
+		m.items[i].Count = max(m.items[i].Count, 1) + 1
+		m.lastHash, m.lastLength = hash, m.hashes[hash]
+		sortItems(m.items)
+		return true, nil
+	}
+	return false, nil
+}
+
+// duplicate reports whether the content of length with hash is already in
+// history, or was the last item added. Both must match: an item with the
+// same hash but another length holds different content, after a hash
+// collision or from a row truncated by an old version, and is replaced
+// (see stale) unless it can't be found to be.
+func (m *Manager) duplicate(hash string, length int) bool {
+	stored, exists := m.hashes[hash]
+	if !exists {
+		return m.lastHash == hash && m.lastLength == length
+	}
+	return stored == length || m.indexOf(hash) < 0
+}
+
+// stale returns the index of the item stored under hash whose content has
+// another length than length, which new content with that hash replaces,
+// or -1 if there is none
+func (m *Manager) stale(hash string, length int) int {
+	if stored, exists := m.hashes[hash]; !exists || stored == length {
+		return -1
+	}
+	return m.indexOf(hash)
+}
+
+// replacing carries over to item what the user set on the stale item at
+// index it replaces: pinning, alias, registers and ID
+func (m *Manager) replacing(item *ClipboardHistory, index, length int) {
+	old := m.items[index]
+	item.Pinned, item.Alias, item.Registers, item.ID = old.Pinned, old.Alias, old.Registers, old.ID
+	kept := ""
+	if old.Alias != "" {
+		kept = fmt.Sprintf(", keeping its alias %q", old.Alias)
+	}
+	log.Printf("Replacing clip %s: its length %d doesn't match the new content's %d%s", old.Hash, m.hashes[old.Hash], length, kept)
+}
+
+// insertReplacing inserts entry, first deleting the stale item at index
+// stale unless that is -1, all in one transaction. The alias and registers
+// of the stale item are given to the entry.
+func (m *Manager) insertReplacing(entry db.ClipboardEntry, stale int) (int64, error) {
+	if stale < 0 {
+		return m.insert(entry)
+	}
+	old := m.items[stale]
+	var id int64
+	err := m.transaction(func() error {
+		if err := m.dbClient.Delete(old.Hash); err != nil {
+			return err
+		}
+		var err error
+		if id, err = m.insert(entry); err != nil {
+			return err
+		}
+		if old.Alias != "" {
+			if err := m.dbClient.SetAlias(entry.Hash, old.Alias); err != nil {
+				return err
+			}
+		}
+		for _, name := range old.Registers {
+			if err := m.dbClient.SetRegister(string(name), entry.Hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return id, err
+}
+
+// dropStale removes the stale item at index, which replacement has
+// replaced, from memory, along with its files unless replacement was saved
+// in the same one
+func (m *Manager) dropStale(index int, replacement ClipboardHistory) {
+	old := m.items[index]
+	delete(m.blobs, old.Hash)
+	delete(m.formats, old.Hash)
+	if old.Overflow && !replacement.Overflow {
+		m.removeOverflow(old.Hash)
+	}
+	if old.IsBinary() && (!replacement.IsBinary() || replacement.MimeType != old.MimeType) {
+		m.removeMedia(old)
+	}
+	m.items = slices.Delete(m.items, index, index+1)
+}
+
+// contentLength is the length of the content item's hash covers: the data
+// of a binary item, or the full text of a text item.
+func (item ClipboardHistory) contentLength() int {
+	if item.IsBinary() || item.Overflow {
+		return item.Size
+	}
+	return len(item.Item)
 }
 
 // GetItems returns all clipboard history items
@@ -129,19 +449,87 @@ func (m *Manager) DeleteItem(index int) bool {
 	if index >= 0 && index < len(m.items) {
 		item := m.items[index]
 
-		if m.dbClient != nil {
+		if m.persisted(item) {
 			if err := m.dbClient.Delete(item.Hash); err != nil {
 				return false
 			}
 		}
 
 		delete(m.hashes, item.Hash)
+		delete(m.blobs, item.Hash)
+		delete(m.formats, item.Hash)
+		if item.Overflow {
+			m.removeOverflow(item.Hash)
+		}
+		if item.IsBinary() {
+			m.removeMedia(item)
+		}
 		m.items = append(m.items[:index], m.items[index+1:]...)
 		return true
 	}
 	return false
 }
 
+// Clear deletes every unpinned item and returns how many were deleted.
+func (m *Manager) Clear() (int, error) {
+	return m.ClearAll(true)
+}
+
+// Purge deletes every item, pinned ones too, and returns how many were
+// deleted.
+func (m *Manager) Purge() (int, error) {
+	return m.ClearAll(false)
+}
+
+// ClearAll deletes every item, keeping the pinned ones if keepPinned is
+// set, and returns how many were deleted. Unlike Remove, what it deletes
+// can't be restored. Nothing is deleted if the database can't be changed.
+func (m *Manager) ClearAll(keepPinned bool) (int, error) {
+	kept := make([]ClipboardHistory, 0, len(m.items))
+	var cleared []ClipboardHistory
+	for _, item := range m.items {
+		if item.Pinned && keepPinned {
+			kept = append(kept, item)
+		} else {
+			cleared = append(cleared, item)
+		}
+	}
+	if len(cleared) == 0 {
+		return 0, nil
+	}
+	if m.dbClient != nil {
+		if err := m.dbClient.Clear(keepPinned); err != nil {
+			return 0, fmt.Errorf("error clearing history: %w", err)
+		}
+	}
+
+	for _, item := range cleared {
+		delete(m.hashes, item.Hash)
+		delete(m.blobs, item.Hash)
+		delete(m.formats, item.Hash)
+		if item.Overflow {
+			m.removeOverflow(item.Hash)
+		}
+		if item.IsBinary() {
+			m.removeMedia(item)
+		}
+	}
+	m.items = kept
+	return len(cleared), nil
+}
+
+// Latest returns the item most recently added or bumped, if it is still in
+// history.
+func (m *Manager) Latest() (ClipboardHistory, bool) {
+	if m.lastHash == "" {
+		return ClipboardHistory{}, false
+	}
+	if i := m.indexOf(m.lastHash); i >= 0 {
+		return m.items[i], true
+	}
+	return ClipboardHistory{}, false
+}
+
 // Count returns the number of items in history
 func (m *Manager) Count() int {
 	return len(m.items)
@@ -157,42 +545,145 @@ func (m *Manager) LoadFromDB() error {
 		return err
 	}
 
-	m.items = make([]ClipboardHistory, 0, len(entries))
-	m.hashes = make(map[string]struct{})
+	// Incognito items aren't in the database; keep them
+	kept := m.memoryItems()
+	m.items = make([]ClipboardHistory, 0, len(entries)+len(kept))
+	m.hashes = make(map[string]int)
 
 	for _, entry := range entries {
-		item := ClipboardHistory{
-			Item:      entry.Content,
-			Hash:      entry.Hash,
-			TimeStamp: entry.Timestamp,
-			Pinned:    entry.Pinned,
-		}
+		item := itemFromEntry(entry)
 		m.items = append(m.items, item)
-		m.hashes[item.Hash] = struct{}{}
-		m.lastHash = item.Hash
+		m.hashes[item.Hash] = entry.Length
+		m.lastHash, m.lastLength = item.Hash, entry.Length
+	}
+	for _, item := range kept {
+		if _, stored := m.hashes[item.Hash]; !stored {
+			m.items = append(m.items, item)
+			m.hashes[item.Hash] = item.contentLength()
+		}
 	}
 
 	sortItems(m.items)
 	return nil
 }
 
-// sortItems sorts in-place: pinned first, then by timestamp ascending.
+// ReloadIfChanged reloads history from the database if another process,
+// such as the daemon, has written to it since the last call, and reports
+// whether it did.
+func (m *Manager) ReloadIfChanged() (bool, error) {
+	if m.dbClient == nil {
+		return false, nil
+	}
+	version, err := m.dbClient.DataVersion()
+	if err != nil {
+		return false, err
+	}
+	if version == m.dataVersion {
+		return false, nil
+	}
+	if err := m.LoadFromDB(); err != nil {
+		return false, err
+	}
+	m.dataVersion = version
+	return true, nil
+}
+
+// DBPath returns the location of the history database, or "" for
+// in-memory managers.
+func (m *Manager) DBPath() string {
+	return m.dbPath
+}
+
+// DataDir returns the directory holding the history database and its
+// companion files, or "" for in-memory managers.
+func (m *Manager) DataDir() string {
+	if m.dbPath == "" {
+		return ""
+	}
+	return filepath.Dir(m.dbPath)
+}
+
+// itemFromEntry converts a stored entry, classifying entries saved before
+// content types were recorded
+func itemFromEntry(entry db.ClipboardEntry) ClipboardHistory {
+	item := ClipboardHistory{
+		Item:      entry.Content,
+		Hash:      entry.Hash,
+		TimeStamp: entry.Timestamp,
+		Pinned:    entry.Pinned,
+		Alias:     entry.Alias,
+		Type:      detect.Type(entry.Type),
+		Kind:      Kind(entry.Kind),
+		MimeType:  entry.MimeType,
+		Size:      entry.Size,
+		Count:     entry.Count,
+		ExpiresAt: entry.ExpiresAt,
+		Selection: Selection(entry.Selection),
+		SourceApp: entry.SourceApp,
+		Formats:   entry.Formats,
+		Position:  entry.Position,
+		Width:     entry.Width,
+		Height:    entry.Height,
+		Registers: entry.Registers,
+		Tags:      entry.Tags,
+		ID:        entry.ID,
+	}
+	if entry.OverflowSize > 0 {
+		item.Overflow = true
+		item.Size = entry.OverflowSize
+	}
+	if item.IsBinary() && item.Size == 0 {
+		// Saved as a file rather than in the database
+		item.Size = entry.Length
+	}
+	if item.Type == "" {
+		item.Type = detect.Detect(item.Item)
+	}
+	classifyText(&item)
+	return item
+}
+
+// classifyText sets the properties detected from a text entry's content
+// rather than stored: its secrets and, for code, its language
+func classifyText(item *ClipboardHistory) {
+	if item.IsBinary() {
+		return
+	}
+	item.Sensitive = detect.Sensitive(item.Item)
+	if item.Type == detect.Code {
+		item.Language = detect.DetectLanguage(item.Item)
+	}
+}
+
+// sortItems sorts in-place: pinned first, then by position for items that
+// have one, then by timestamp ascending.
 func sortItems(items []ClipboardHistory) {
 	sort.SliceStable(items, func(i, j int) bool {
 		if items[i].Pinned != items[j].Pinned {
 			return items[i].Pinned
 		}
+		if pi, pj := items[i].Position, items[j].Position; pi != pj {
+			if pi == 0 || pj == 0 {
+				return pj == 0
+			}
+			return pi < pj
+		}
 		return items[i].TimeStamp.Before(items[j].TimeStamp)
 	})
 }
 
 // newClipboardItem creates a new clipboard history item
 func newClipboardItem(content string) ClipboardHistory {
-	return ClipboardHistory{
+	item := ClipboardHistory{
 		Item:      content,
 		Hash:      fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
 		TimeStamp: time.Now(),
+		Type:      detect.Detect(content),
+		Kind:      KindText,
+		Count:     1,
 	}
+	classifyText(&item)
+	return item
 }
 
 // TogglePin toggles the pinned state for an item by index
@@ -200,12 +691,15 @@ func (m *Manager) TogglePin(index int) error {
 	if index >= 0 && index < len(m.items) {
 		item := &m.items[index]
 		newPinned := !item.Pinned
-		if m.dbClient != nil {
+		if m.persisted(*item) {
 			if err := m.dbClient.SetPinned(item.Hash, newPinned); err != nil {
 				return err
 			}
 		}
 		item.Pinned = newPinned
+		if !newPinned {
+			item.Position = 0
+		}
 		sortItems(m.items)
 		return nil
 	}
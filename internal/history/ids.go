@@ -0,0 +1,30 @@
+package history
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ShortID is the entry's ID in base 36, e.g. "k3f", which stays the same
+// however the table is sorted or filtered. Entries not in the database
+// have none and return "".
+func (h ClipboardHistory) ShortID() string {
+	if h.ID <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(h.ID, 36)
+}
+
+// FindByShortID returns the item whose ShortID is id, ignoring case.
+func (m *Manager) FindByShortID(id string) (ClipboardHistory, bool) {
+	n, err := strconv.ParseInt(strings.ToLower(id), 36, 64)
+	if err != nil || n <= 0 {
+		return ClipboardHistory{}, false
+	}
+	for _, item := range m.items {
+		if item.ID == n {
+			return item, true
+		}
+	}
+	return ClipboardHistory{}, false
+}
@@ -0,0 +1,61 @@
+package history
+
+import "testing"
+
+func TestShortIDs(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("first")
+	manager.AddItem("second")
+	first := manager.items[manager.indexOf(newClipboardItem("first").Hash)]
+	second := manager.items[manager.indexOf(newClipboardItem("second").Hash)]
+	if first.ShortID() == "" || second.ID <= first.ID {
+		t.Fatalf("IDs %d, %d; want them given in copy order", first.ID, second.ID)
+	}
+
+	found, ok := manager.FindByShortID(first.ShortID())
+	if !ok || found.Item != "first" {
+		t.Errorf("FindByShortID(%q) = %q, %v; want first", first.ShortID(), found.Item, ok)
+	}
+	for _, id := range []string{"", "zzzz", "-1", "k!"} {
+		if _, ok := manager.FindByShortID(id); ok {
+			t.Errorf("FindByShortID(%q) found an entry", id)
+		}
+	}
+
+	// IDs survive reloading, and undoing a delete restores the same one
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if found, ok := manager.FindByShortID(second.ShortID()); !ok || found.Item != "second" {
+		t.Errorf("after reload FindByShortID(%q) = %q, %v; want second", second.ShortID(), found.Item, ok)
+	}
+	removed, err := manager.Remove(manager.indexOf(second.Hash))
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	manager.AddItem("third")
+	if err := manager.Restore(removed); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored := manager.items[manager.indexOf(second.Hash)]; restored.ID != second.ID {
+		t.Errorf("restored ID = %d, want %d", restored.ID, second.ID)
+	}
+	// Deleted IDs are never handed out again
+	index := manager.indexOf(newClipboardItem("third").Hash)
+	third := manager.items[index]
+	manager.DeleteItem(index)
+	manager.AddItem("fourth")
+	if fourth := manager.items[manager.indexOf(newClipboardItem("fourth").Hash)]; fourth.ID <= third.ID {
+		t.Errorf("fourth ID = %d, want one after the deleted %d", fourth.ID, third.ID)
+	}
+}
+
+func TestShortIDInMemory(t *testing.T) {
+	manager := NewInMemoryManager()
+	manager.AddItem("unsaved")
+	if id := manager.GetItems()[0].ShortID(); id != "" {
+		t.Errorf("ShortID = %q, want none for an entry not in the database", id)
+	}
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// IncognitoFileName marks incognito mode in the data directory, so the
+// TUI, the daemon and the CLI share it.
+const IncognitoFileName = "incognito"
+
+// SetIncognito turns incognito mode on or off. While it is on, new items
+// are kept only in memory for the rest of the session and never written to
+// the database; items already stored are left alone.
+func (m *Manager) SetIncognito(enabled bool) error {
+	if path := m.incognitoPath(); path != "" {
+		var err error
+		if enabled {
+			err = os.WriteFile(path, nil, 0600)
+		} else if err = os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("error switching incognito mode: %w", err)
+		}
+	}
+	m.incognito = enabled
+	return nil
+}
+
+// Incognito reports whether incognito mode is on.
+func (m *Manager) Incognito() bool {
+	return m.incognito
+}
+
+// RefreshIncognito picks up incognito mode switched by another process,
+// such as `clippy incognito on`, and reports whether it changed.
+func (m *Manager) RefreshIncognito() bool {
+	path := m.incognitoPath()
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	enabled := err == nil
+	changed := enabled != m.incognito
+	m.incognito = enabled
+	return changed
+}
+
+// incognitoPath is the location of the incognito marker, or "" for
+// in-memory managers
+func (m *Manager) incognitoPath() string {
+	if m.dbPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.dbPath), IncognitoFileName)
+}
+
+// persisted reports whether item is stored in the database
+func (m *Manager) persisted(item ClipboardHistory) bool {
+	return m.dbClient != nil && !item.Incognito
+}
+
+// memoryItems returns the items kept only in memory, captured in incognito
+// mode
+func (m *Manager) memoryItems() []ClipboardHistory {
+	var items []ClipboardHistory
+	for _, item := range m.items {
+		if item.Incognito {
+			items = append(items, item)
+		}
+	}
+	return items
+}
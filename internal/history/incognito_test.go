@@ -0,0 +1,106 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIncognitoKeepsItemsOutOfDatabase(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("stored")
+	if err := manager.SetIncognito(true); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	if !manager.AddItem("secret") {
+		t.Fatal("expected the item to be added")
+	}
+	manager.AddImage([]byte{1, 2, 3}, "image/png")
+	if err := manager.TogglePin(1); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+
+	// A reload keeps what is only in memory
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if manager.Count() != 3 {
+		t.Fatalf("expected 3 items after reload, got %d", manager.Count())
+	}
+	items := manager.GetItems()
+	if items[0].Item != "secret" || !items[0].Incognito || !items[0].Pinned {
+		t.Errorf("expected the pinned incognito item first, got %+v", items[0])
+	}
+	image := items[2]
+	if data, err := manager.GetData(image); err != nil || len(data) != 3 {
+		t.Errorf("GetData = %v, %v", data, err)
+	}
+	results, err := manager.Query(Filter{Text: "secret"})
+	if err != nil || len(results) != 1 {
+		t.Errorf("Query = %v, %v; want the incognito item", results, err)
+	}
+
+	// Another manager on the same database only sees the stored item, and
+	// starts in incognito mode from the marker
+	other, err := NewManagerWithPath(manager.dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer other.Close()
+	if err := other.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if other.Count() != 1 || other.GetItems()[0].Item != "stored" {
+		t.Errorf("expected only the stored item in the database, got %v", other.GetItems())
+	}
+	if !other.Incognito() {
+		t.Error("expected incognito mode to be shared through the data directory")
+	}
+
+	if !manager.DeleteItem(0) {
+		t.Error("expected the incognito item to be deleted")
+	}
+}
+
+func TestIncognitoDoesNotBumpStoredItems(t *testing.T) {
+	manager := NewInMemoryManager()
+	manager.SetBumpDuplicates(true)
+	manager.AddItem("stored")
+	if err := manager.SetIncognito(true); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	if manager.AddItem("stored") {
+		t.Error("expected a re-copied item not to be bumped in incognito mode")
+	}
+	if item, _ := manager.GetItem(0); item.Count > 1 {
+		t.Errorf("expected the count to stay 1, got %d", item.Count)
+	}
+}
+
+func TestRefreshIncognito(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	marker := filepath.Join(filepath.Dir(manager.dbPath), IncognitoFileName)
+	if err := os.WriteFile(marker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !manager.RefreshIncognito() || !manager.Incognito() {
+		t.Error("expected incognito mode switched on by another process to be picked up")
+	}
+	if manager.RefreshIncognito() {
+		t.Error("expected no change reported when the marker is unchanged")
+	}
+
+	if err := manager.SetIncognito(false); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("expected the marker to be removed, got %v", err)
+	}
+	if err := manager.SetIncognito(false); err != nil {
+		t.Errorf("expected switching off twice to succeed, got %v", err)
+	}
+}
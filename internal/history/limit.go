@@ -0,0 +1,106 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"unicode/utf8"
+)
+
+// DefaultCaptureLimit is the largest content recorded unless configured
+// otherwise.
+const DefaultCaptureLimit = 64 << 20
+
+// truncatedMarker ends content cut short at the capture limit.
+const truncatedMarker = "\n[truncated by clippy at %s]"
+
+// SetCaptureLimit sets the largest content in bytes that is recorded.
+// Larger text is cut to the limit and marked as truncated when truncate is
+// set, and skipped otherwise, as are larger images. Zero removes the limit.
+func (m *Manager) SetCaptureLimit(bytes int, truncate bool) {
+	m.captureLimit = max(bytes, 0)
+	m.truncateOversized = truncate
+}
+
+// OverCaptureLimit reports whether content of size bytes would be skipped
+// for exceeding the capture limit.
+func (m *Manager) OverCaptureLimit(size int) bool {
+	return m.captureLimit > 0 && size > m.captureLimit && !m.truncateOversized
+}
+
+// limitCapture applies the capture limit to content, reporting false if it
+// must be skipped
+func (m *Manager) limitCapture(content string) (string, bool) {
+	if m.captureLimit == 0 || len(content) <= m.captureLimit {
+		return content, true
+	}
+	if !m.truncateOversized {
+		log.Printf("Skipped a %s clip over the capture limit", FormatSize(len(content)))
+		return "", false
+	}
+	return cutAtRune(content, m.captureLimit) + m.truncationMarker(), true
+}
+
+// truncationMarker returns the note appended to truncated content
+func (m *Manager) truncationMarker() string {
+	return fmt.Sprintf(truncatedMarker, FormatSize(m.captureLimit))
+}
+
+// cutAtRune returns at most the first n bytes of content, cut at a rune
+// boundary.
+func cutAtRune(content string, n int) string {
+	if len(content) <= n {
+		return content
+	}
+	for n > 0 && !utf8.RuneStart(content[n]) {
+		n--
+	}
+	return content[:n]
+}
+
+// limitReader reads up to limit bytes from r, noting whether there was
+// more. Unlike io.LimitReader it never reads past the limit beyond the one
+// byte telling so, so the rest of a huge copy is left unread.
+type limitReader struct {
+	r        io.Reader
+	limit    int // 0 reads everything
+	read     int
+	exceeded bool
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.limit == 0 {
+		return l.r.Read(p)
+	}
+	if l.read >= l.limit {
+		return 0, io.EOF
+	}
+	if len(p) > l.limit-l.read {
+		p = p[:l.limit-l.read]
+	}
+	n, err := l.r.Read(p)
+	l.read += n
+	if l.read == l.limit && err == nil {
+		var probe [1]byte
+		if k, _ := io.ReadFull(l.r, probe[:]); k > 0 {
+			l.exceeded = true
+			// Don't end the text part way through a character
+			n = completeRunes(p[:n])
+		}
+		err = io.EOF
+	}
+	return n, err
+}
+
+// completeRunes returns the length of b without a partial rune at its end
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,90 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCaptureLimitSkipsOversizedContent(t *testing.T) {
+	manager := NewInMemoryManager()
+	manager.SetCaptureLimit(10, false)
+
+	if manager.AddItem(strings.Repeat("x", 11)) {
+		t.Error("expected text over the limit to be skipped")
+	}
+	if manager.AddImage(make([]byte, 11), "image/png") {
+		t.Error("expected an image over the limit to be skipped")
+	}
+	if !manager.AddItem("just fits") {
+		t.Error("expected text within the limit to be added")
+	}
+	if !manager.OverCaptureLimit(11) || manager.OverCaptureLimit(10) {
+		t.Error("OverCaptureLimit disagrees with the limit of 10 bytes")
+	}
+}
+
+func TestCaptureLimitTruncates(t *testing.T) {
+	manager := NewInMemoryManager()
+	manager.SetCaptureLimit(10, true)
+
+	// The limit falls inside the second "é"
+	if !manager.AddItem("abcdefghé" + strings.Repeat("z", 100)) {
+		t.Fatal("expected truncated text to be added")
+	}
+	item, _ := manager.GetItem(0)
+	if item.Item != "abcdefghé\n[truncated by clippy at 10 B]" {
+		t.Errorf("Item = %q, want the start cut at a character and marked", item.Item)
+	}
+	if manager.OverCaptureLimit(1000) {
+		t.Error("expected nothing to be skipped when truncating")
+	}
+	if manager.AddImage(make([]byte, 11), "image/png") {
+		t.Error("expected an image over the limit to be skipped, not truncated")
+	}
+}
+
+func TestSpoolStopsAtCaptureLimit(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(100)
+	manager.SetCaptureLimit(1000, false)
+
+	huge := strings.NewReader(strings.Repeat("y", 5000))
+	spooled, err := manager.Spool(huge)
+	if err != nil {
+		t.Fatalf("Spool: %v", err)
+	}
+	if !spooled.Oversized || spooled.Size != 1000 {
+		t.Fatalf("spooled = Oversized %v Size %d, want 1000 bytes read and marked", spooled.Oversized, spooled.Size)
+	}
+	if read := 5000 - huge.Len(); read > 1001 {
+		t.Errorf("read %d bytes, want no more than the limit and one more", read)
+	}
+	if manager.AddSpooled(spooled, SelectionClipboard) {
+		t.Error("expected oversized text to be refused")
+	}
+
+	manager.SetCaptureLimit(1000, true)
+	spooled, err = manager.Spool(strings.NewReader(strings.Repeat("y", 5000)))
+	if err != nil {
+		t.Fatalf("Spool: %v", err)
+	}
+	if !manager.AddSpooled(spooled, SelectionClipboard) {
+		t.Fatal("expected truncated text to be added")
+	}
+	item, _ := manager.GetItem(0)
+	text, err := manager.Text(item)
+	if err != nil || !strings.HasPrefix(text, strings.Repeat("y", 1000)+"\n[truncated by clippy at") {
+		t.Errorf("Text = %d bytes, %v; want the first 1000 bytes and the marker", len(text), err)
+	}
+}
+
+func TestLimitReaderEndsOnWholeRunes(t *testing.T) {
+	r := &limitReader{r: strings.NewReader("aé" + "bbb"), limit: 2}
+	data := make([]byte, 10)
+	n, _ := r.Read(data)
+	if !r.exceeded || string(data[:n]) != "a" || !utf8.Valid(data[:n]) {
+		t.Errorf("read %q (exceeded %v), want the partial character dropped", data[:n], r.exceeded)
+	}
+}
@@ -0,0 +1,116 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for imageSize
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// MediaDirName holds the images captured into history, next to the history
+// database, each in a file named after its hash.
+const MediaDirName = "media"
+
+// mediaExtensions are the file extensions of image types; others are
+// saved with ".img".
+var mediaExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/bmp":     ".bmp",
+	"image/tiff":    ".tiff",
+	"image/svg+xml": ".svg",
+}
+
+// mediaDir returns the directory for image files, or "" for in-memory
+// managers.
+func (m *Manager) mediaDir() string {
+	if m.dbPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.dbPath), MediaDirName)
+}
+
+// mediaPath returns the file holding the image with hash in dir, e.g.
+// "<hash>.png".
+func mediaPath(dir, hash, mimeType string) string {
+	ext, ok := mediaExtensions[mimeType]
+	if !ok {
+		ext = ".img"
+	}
+	return filepath.Join(dir, hash+ext)
+}
+
+// MediaPath returns the file an image entry is saved in, or "" when its
+// data is only in the database (entries captured by older versions) or in
+// memory.
+func (m *Manager) MediaPath(item ClipboardHistory) string {
+	dir := m.mediaDir()
+	if !item.IsBinary() || dir == "" || !m.persisted(item) {
+		return ""
+	}
+	path := mediaPath(dir, item.Hash, item.MimeType)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// writeMedia saves an image's data in its file in the media directory
+func (m *Manager) writeMedia(hash, mimeType string, data []byte) error {
+	dir := m.mediaDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("error creating media directory: %w", err)
+	}
+	path := mediaPath(dir, hash, mimeType)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing image file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing image file: %w", err)
+	}
+	return nil
+}
+
+// readMedia returns the data of the image with hash saved in dir, or nil
+// if it has no file there
+func readMedia(dir, hash, mimeType string) ([]byte, error) {
+	data, err := os.ReadFile(mediaPath(dir, hash, mimeType))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading image file: %w", err)
+	}
+	return data, nil
+}
+
+// removeMedia deletes the file of an image entry, if any
+func (m *Manager) removeMedia(item ClipboardHistory) {
+	dir := m.mediaDir()
+	if dir == "" {
+		return
+	}
+	if err := os.Remove(mediaPath(dir, item.Hash, item.MimeType)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Failed to remove image file: %v", err)
+	}
+}
+
+// imageSize returns the width and height of PNG, JPEG or GIF data, or
+// zeros for other formats
+func imageSize(data []byte) (int, int) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
@@ -0,0 +1,275 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// MergeStats reports what MergeFrom did.
+type MergeStats struct {
+	Added   int // entries new to this history
+	Merged  int // entries already present, combined with the other copy
+	Aliases int // aliases imported
+}
+
+// MergeFrom imports every entry from another clippy database. Entries whose
+// hash already exists are combined: the earliest timestamp is kept, copy
+// counts are summed and the entry stays pinned if either copy was. Aliases
+// are imported unless the name is taken or the entry already has one. The
+// other database is only read, and nothing is merged unless all of it is.
+func (m *Manager) MergeFrom(otherDBPath string) (MergeStats, error) {
+	var stats MergeStats
+
+	if _, err := os.Stat(otherDBPath); err != nil {
+		return stats, fmt.Errorf("error opening database to merge: %w", err)
+	}
+	if m.dbPath != "" && sameFile(m.dbPath, otherDBPath) {
+		return stats, fmt.Errorf("error merging %s: it is this history's database", otherDBPath)
+	}
+	other, err := db.OpenReadOnly(otherDBPath)
+	if err != nil {
+		return stats, fmt.Errorf("error opening database to merge: %w", err)
+	}
+	defer func() {
+		if err := other.Close(); err != nil {
+			log.Printf("Failed to close merged database: %v", err)
+		}
+	}()
+
+	entries, err := other.LoadAll()
+	if err != nil {
+		return stats, fmt.Errorf("error loading database to merge: %w", err)
+	}
+
+	items, hashes := slices.Clone(m.items), maps.Clone(m.hashes)
+	err = m.transaction(func() error {
+		stats, err = m.mergeEntries(other, entries, filepath.Dir(otherDBPath))
+		return err
+	})
+	if err != nil {
+		// Nothing was stored, so forget what was imported so far
+		for _, item := range m.items {
+			if _, ok := hashes[item.Hash]; ok {
+				continue
+			}
+			if item.Overflow {
+				m.removeOverflow(item.Hash)
+			}
+			if item.IsBinary() {
+				m.removeMedia(item)
+				delete(m.blobs, item.Hash)
+			}
+		}
+		m.items, m.hashes = items, hashes
+		return MergeStats{}, err
+	}
+	sortItems(m.items)
+	return stats, nil
+}
+
+// sameFile reports whether the paths name the same file
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// mergeEntries merges entries from other, whose overflow and media files
+// are kept in dir
+func (m *Manager) mergeEntries(other dataLoader, entries []db.ClipboardEntry, dir string) (MergeStats, error) {
+	var stats MergeStats
+	var err error
+	otherOverflow := filepath.Join(dir, OverflowDirName)
+	otherMedia := filepath.Join(dir, MediaDirName)
+	for _, entry := range entries {
+		index := m.indexOf(entry.Hash)
+		if index >= 0 && m.hashes[entry.Hash] != entry.Length {
+			// Different content under the same hash; keep this history's
+			log.Printf("Skipping clip %s: its length %d doesn't match the stored %d", entry.Hash, entry.Length, m.hashes[entry.Hash])
+			continue
+		}
+		if index < 0 && entry.OverflowSize > 0 {
+			if entry.Content, err = readOverflow(otherOverflow, entry.Hash); err != nil {
+				return stats, err
+			}
+		}
+		if index < 0 && entry.Kind != "" && entry.Kind != string(KindText) {
+			if entry.Data, err = readMedia(otherMedia, entry.Hash, entry.MimeType); err != nil {
+				return stats, err
+			}
+		}
+		if index >= 0 {
+			if err := m.mergeEntry(index, entry); err != nil {
+				return stats, err
+			}
+			stats.Merged++
+		} else {
+			// IDs number entries within their own database
+			entry.ID = 0
+			if err := m.importEntry(other, entry); err != nil {
+				return stats, err
+			}
+			index = len(m.items) - 1
+			stats.Added++
+		}
+
+		if entry.Alias != "" && m.items[index].Alias == "" {
+			if _, taken := m.FindByAlias(entry.Alias); !taken {
+				if err := m.SetAlias(index, entry.Alias); err != nil {
+					return stats, err
+				}
+				stats.Aliases++
+			}
+		}
+	}
+	return stats, nil
+}
+
+// indexOf returns the index of the item with hash, or -1.
+func (m *Manager) indexOf(hash string) int {
+	for i, item := range m.items {
+		if item.Hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeEntry combines an entry from another database into items[index].
+func (m *Manager) mergeEntry(index int, entry db.ClipboardEntry) error {
+	item := m.items[index]
+	if entry.Timestamp.Before(item.TimeStamp) {
+		item.TimeStamp = entry.Timestamp
+	}
+	item.Count = max(item.Count, 1) + max(entry.Count, 1)
+	item.Pinned = item.Pinned || entry.Pinned
+
+	if m.persisted(item) {
+		update := db.ClipboardEntry{
+			Hash:      item.Hash,
+			Timestamp: item.TimeStamp,
+			Pinned:    item.Pinned,
+			Count:     item.Count,
+		}
+		if err := m.dbClient.Update(update); err != nil {
+			return fmt.Errorf("error merging clip %s: %w", item.Hash, err)
+		}
+	}
+	m.items[index] = item
+	return nil
+}
+
+// dataLoader loads binary payloads from another store, e.g. a database being
+// merged or the archive.
+type dataLoader interface {
+	LoadData(hash string) ([]byte, error)
+}
+
+// importEntry copies an entry (and its binary payload) from other into
+// this history, keeping its ID if it is free.
+func (m *Manager) importEntry(other dataLoader, entry db.ClipboardEntry) error {
+	item := ClipboardHistory{
+		Item:      entry.Content,
+		Hash:      entry.Hash,
+		TimeStamp: entry.Timestamp,
+		Pinned:    entry.Pinned,
+		Type:      detect.Type(entry.Type),
+		Kind:      Kind(entry.Kind),
+		MimeType:  entry.MimeType,
+		Size:      entry.Size,
+		Count:     max(entry.Count, 1),
+		ExpiresAt: entry.ExpiresAt,
+		Selection: Selection(entry.Selection),
+		SourceApp: entry.SourceApp,
+		Width:     entry.Width,
+		Height:    entry.Height,
+		Tags:      entry.Tags,
+	}
+	if item.Type == "" {
+		item.Type = detect.Detect(item.Item)
+	}
+	classifyText(&item)
+
+	if !item.IsBinary() && m.shouldOverflow(item.Item) {
+		if err := m.writeOverflow(&item); err != nil {
+			return fmt.Errorf("error importing clip %s: %w", entry.Hash, err)
+		}
+	}
+
+	data := entry.Data
+	if item.IsBinary() && data == nil {
+		var err error
+		if data, err = other.LoadData(entry.Hash); err != nil {
+			return fmt.Errorf("error loading data for clip %s: %w", entry.Hash, err)
+		}
+	}
+	if item.IsBinary() {
+		item.Size = len(data)
+		if item.Width == 0 {
+			item.Width, item.Height = imageSize(data)
+		}
+	}
+	// Images are saved as files, not in the database
+	stored := data
+	if item.IsBinary() && m.mediaDir() != "" {
+		if err := m.writeMedia(item.Hash, item.MimeType, data); err != nil {
+			return fmt.Errorf("error importing clip %s: %w", entry.Hash, err)
+		}
+		stored = nil
+	}
+
+	if m.dbClient != nil {
+		insert := db.ClipboardEntry{
+			Content:   item.Item,
+			Hash:      item.Hash,
+			Timestamp: item.TimeStamp,
+			Pinned:    item.Pinned,
+			Type:      string(item.Type),
+			Kind:      string(item.Kind),
+			MimeType:  item.MimeType,
+			Data:      stored,
+			Count:     item.Count,
+			ExpiresAt: item.ExpiresAt,
+			Selection: string(item.Selection),
+			SourceApp: item.SourceApp,
+			Length:    item.contentLength(),
+			Width:     item.Width,
+			Height:    item.Height,
+			Tags:      item.Tags,
+			ID:        entry.ID,
+		}
+		if item.Overflow {
+			insert.OverflowSize = item.Size
+		}
+		id, err := m.insert(insert)
+		if err != nil {
+			if item.Overflow {
+				m.removeOverflow(item.Hash)
+			}
+			if stored == nil && data != nil {
+				m.removeMedia(item)
+			}
+			return fmt.Errorf("error importing clip %s: %w", item.Hash, err)
+		}
+		item.ID = id
+	} else if data != nil {
+		if m.blobs == nil {
+			m.blobs = make(map[string][]byte)
+		}
+		m.blobs[item.Hash] = data
+	}
+
+	m.items = append(m.items, item)
+	m.hashes[item.Hash] = item.contentLength()
+	return nil
+}
@@ -0,0 +1,236 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/db"
+)
+
+// newOtherDB creates a second history database in dir for merge tests
+func newOtherDB(t *testing.T, dir string, setup func(*Manager)) string {
+	t.Helper()
+	path := filepath.Join(dir, "other.db")
+	other, err := NewManagerWithPath(path)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	setup(other)
+	if err := other.Close(); err != nil {
+		t.Fatalf("close other: %v", err)
+	}
+	return path
+}
+
+func TestMergeFrom(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("shared")
+	manager.AddItem("local only")
+	localShared, _ := manager.GetItem(0)
+
+	otherPath := newOtherDB(t, t.TempDir(), func(other *Manager) {
+		other.SetBumpDuplicates(true)
+		other.AddItem("shared")
+		other.AddItem("remote only")
+		other.AddItem("shared") // count 2 on the other machine, now newest
+		if err := other.SetAlias(0, "remote"); err != nil {
+			t.Fatalf("SetAlias: %v", err)
+		}
+		// Make the other machine's copy of "shared" older
+		if err := other.TogglePin(1); err != nil { // pin "shared"
+			t.Fatalf("TogglePin: %v", err)
+		}
+	})
+
+	stats, err := manager.MergeFrom(otherPath)
+	if err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	if stats.Added != 1 || stats.Merged != 1 || stats.Aliases != 1 {
+		t.Errorf("stats = %+v, want 1 added, 1 merged, 1 alias", stats)
+	}
+	if manager.Count() != 3 {
+		t.Fatalf("Count = %d, want 3", manager.Count())
+	}
+
+	shared := manager.items[manager.indexOf(localShared.Hash)]
+	if shared.Count != 3 {
+		t.Errorf("merged count = %d, want 3", shared.Count)
+	}
+	if !shared.Pinned {
+		t.Error("expected merged entry to keep the other copy's pin")
+	}
+
+	if item, ok := manager.FindByAlias("remote"); !ok || item.Item != "remote only" {
+		t.Errorf("expected imported alias, got %+v (found=%v)", item, ok)
+	}
+
+	// The merge is persisted
+	reloaded := &Manager{dbClient: manager.dbClient}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if reloaded.Count() != 3 {
+		t.Errorf("reloaded Count = %d, want 3", reloaded.Count())
+	}
+	shared = reloaded.items[reloaded.indexOf(localShared.Hash)]
+	if shared.Count != 3 || !shared.Pinned {
+		t.Errorf("reloaded merged entry = %+v", shared)
+	}
+}
+
+func TestMergeFromKeepsEarliestTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	early := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	otherPath := newOtherDB(t, dir, func(other *Manager) {
+		other.AddItem("content")
+	})
+
+	// Rewrite the other database's timestamp directly
+	other, err := NewManagerWithPath(otherPath)
+	if err != nil {
+		t.Fatalf("open other: %v", err)
+	}
+	if err := other.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	other.items[0].TimeStamp = early
+	if err := other.mergeEntry(0, toEntry(other.items[0])); err != nil {
+		t.Fatalf("mergeEntry: %v", err)
+	}
+	if err := other.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	manager := NewInMemoryManager()
+	manager.AddItem("content")
+	if _, err := manager.MergeFrom(otherPath); err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	item, _ := manager.GetItem(0)
+	if !item.TimeStamp.Equal(early) {
+		t.Errorf("TimeStamp = %v, want %v", item.TimeStamp, early)
+	}
+}
+
+func TestMergeFromAliasConflict(t *testing.T) {
+	manager := NewInMemoryManager()
+	manager.AddItem("local")
+	if err := manager.SetAlias(0, "dup"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+
+	otherPath := newOtherDB(t, t.TempDir(), func(other *Manager) {
+		other.AddItem("remote")
+		if err := other.SetAlias(0, "dup"); err != nil {
+			t.Fatalf("SetAlias: %v", err)
+		}
+	})
+
+	stats, err := manager.MergeFrom(otherPath)
+	if err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	if stats.Aliases != 0 {
+		t.Errorf("expected conflicting alias to be skipped, stats = %+v", stats)
+	}
+	if item, _ := manager.FindByAlias("dup"); item.Item != "local" {
+		t.Errorf("expected local alias to win, got %q", item.Item)
+	}
+}
+
+func TestMergeFromImages(t *testing.T) {
+	manager := NewInMemoryManager()
+	img := []byte("png-bytes")
+
+	otherPath := newOtherDB(t, t.TempDir(), func(other *Manager) {
+		other.AddImage(img, "image/png")
+	})
+
+	if _, err := manager.MergeFrom(otherPath); err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	item, _ := manager.GetItem(0)
+	data, err := manager.GetData(item)
+	if err != nil || string(data) != string(img) {
+		t.Errorf("GetData = %q, %v", data, err)
+	}
+}
+
+func TestMergeFromMissingFile(t *testing.T) {
+	manager := NewInMemoryManager()
+	missing := filepath.Join(t.TempDir(), "nope.db")
+	if _, err := manager.MergeFrom(missing); err == nil {
+		t.Error("expected error merging a missing database")
+	}
+}
+
+// toEntry converts an item back to its persisted form
+func toEntry(item ClipboardHistory) db.ClipboardEntry {
+	return db.ClipboardEntry{
+		Content:   item.Item,
+		Hash:      item.Hash,
+		Timestamp: item.TimeStamp,
+		Pinned:    item.Pinned,
+		Count:     item.Count,
+	}
+}
+
+func TestMergeFromRefusesItsOwnDatabase(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.AddItem("only once")
+
+	// The same file under another name
+	link := filepath.Join(t.TempDir(), "link.db")
+	if err := os.Symlink(manager.DBPath(), link); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{manager.DBPath(), link} {
+		if _, err := manager.MergeFrom(path); err == nil {
+			t.Errorf("MergeFrom(%s) succeeded, want it refused", path)
+		}
+	}
+	if item, _ := manager.GetItem(0); manager.Count() != 1 || item.Count != 1 {
+		t.Errorf("history changed: %d items, count %d", manager.Count(), item.Count)
+	}
+}
+
+func TestMergeFromFailureMergesNothing(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.AddItem("shared")
+
+	dir := t.TempDir()
+	large := strings.Repeat("remote ", 50)
+	otherPath := newOtherDB(t, dir, func(other *Manager) {
+		other.SetOverflowThreshold(100)
+		other.AddItem("shared")
+		other.AddItem("remote only")
+		other.AddItem(large)
+	})
+	// The large entry can't be read, after the others were merged
+	if err := os.RemoveAll(filepath.Join(dir, OverflowDirName)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.MergeFrom(otherPath); err == nil {
+		t.Fatal("expected MergeFrom to fail")
+	}
+	if item, _ := manager.GetItem(0); manager.Count() != 1 || item.Count != 1 {
+		t.Errorf("history in memory changed: %d items, count %d", manager.Count(), item.Count)
+	}
+	reloaded := &Manager{dbClient: manager.dbClient}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if item, _ := reloaded.GetItem(0); reloaded.Count() != 1 || item.Count != 1 {
+		t.Errorf("stored history changed: %d items, count %d", reloaded.Count(), item.Count)
+	}
+}
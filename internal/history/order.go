@@ -0,0 +1,49 @@
+package history
+
+import "fmt"
+
+// MovePinned moves the pinned item at index offset places within the pinned
+// items, e.g. -1 to move it up one, and returns its new index. Moving past
+// either end stops there. The whole pinned order is stored, so items that
+// had no position yet keep the place they were shown in.
+func (m *Manager) MovePinned(index, offset int) (int, error) {
+	if index < 0 || index >= len(m.items) {
+		return index, fmt.Errorf("invalid index: %d", index)
+	}
+	if !m.items[index].Pinned {
+		return index, fmt.Errorf("item %d is not pinned", index)
+	}
+
+	// Pinned items sort first
+	pinned := 0
+	for pinned < len(m.items) && m.items[pinned].Pinned {
+		pinned++
+	}
+	target := min(max(index+offset, 0), pinned-1)
+	if target == index {
+		return index, nil
+	}
+
+	order := make([]ClipboardHistory, pinned)
+	copy(order, m.items[:pinned])
+	item := order[index]
+	order = append(order[:index], order[index+1:]...)
+	order = append(order[:target], append([]ClipboardHistory{item}, order[target:]...)...)
+
+	if m.dbClient != nil {
+		var hashes []string
+		for _, item := range order {
+			if m.persisted(item) {
+				hashes = append(hashes, item.Hash)
+			}
+		}
+		if err := m.dbClient.SetPositions(hashes); err != nil {
+			return index, fmt.Errorf("error storing pinned order: %w", err)
+		}
+	}
+	for i := range order {
+		order[i].Position = i + 1
+	}
+	copy(m.items, order)
+	return target, nil
+}
@@ -0,0 +1,55 @@
+package history
+
+import "testing"
+
+func TestMovePinned(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	for _, content := range []string{"a", "b", "c", "d"} {
+		manager.AddItem(content)
+	}
+	for range 3 {
+		// Each pin sorts the item to the end of the pinned items
+		if err := manager.TogglePin(3); err != nil {
+			t.Fatalf("TogglePin: %v", err)
+		}
+	}
+	// Pinned b, c, d, then a
+	index, err := manager.MovePinned(2, -2)
+	if err != nil {
+		t.Fatalf("MovePinned: %v", err)
+	}
+	if index != 0 {
+		t.Errorf("expected the item moved to index 0, got %d", index)
+	}
+	if index, _ := manager.MovePinned(0, -1); index != 0 {
+		t.Errorf("expected the first item to stay put, got %d", index)
+	}
+	if _, err := manager.MovePinned(3, -1); err == nil {
+		t.Error("expected an error moving an unpinned item")
+	}
+
+	// The order survives a reload, and a newly pinned item follows it
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if err := manager.TogglePin(3); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	var order string
+	for _, item := range manager.GetItems() {
+		order += item.Item
+	}
+	if order != "dbca" {
+		t.Errorf("expected order dbca, got %s", order)
+	}
+
+	// Unpinning leaves the order
+	if err := manager.TogglePin(0); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	if item, _ := manager.GetItem(3); item.Item != "d" || item.Position != 0 {
+		t.Errorf("expected the unpinned item last without a position, got %+v", item)
+	}
+}
@@ -0,0 +1,106 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// OverflowDirName holds the content of oversized entries, next to the
+	// history database.
+	OverflowDirName = "overflow"
+	// DefaultOverflowThreshold is the content size above which entries are
+	// stored in overflow files.
+	DefaultOverflowThreshold = 1 << 20
+	// overflowPreviewLen is how much of an overflowed entry is kept in the
+	// database for display and search.
+	overflowPreviewLen = 4096
+)
+
+// SetOverflowThreshold sets the content size in bytes above which new
+// entries are stored in a content-addressed file instead of the database.
+// Zero disables overflow storage. In-memory managers never overflow.
+func (m *Manager) SetOverflowThreshold(bytes int) {
+	m.overflowThreshold = max(bytes, 0)
+}
+
+// overflowDir returns the directory for overflow files, or "" for
+// in-memory managers.
+func (m *Manager) overflowDir() string {
+	if m.dbPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.dbPath), OverflowDirName)
+}
+
+// overflowPath returns the file holding the content with hash, sharded by
+// the first two hex digits to keep directories small.
+func overflowPath(dir, hash string) string {
+	return filepath.Join(dir, hash[:2], hash)
+}
+
+// shouldOverflow reports whether content is too large to store inline.
+func (m *Manager) shouldOverflow(content string) bool {
+	return m.overflowThreshold > 0 && m.overflowDir() != "" && len(content) > m.overflowThreshold
+}
+
+// writeOverflow stores item's full content in its overflow file and
+// replaces Item with a preview.
+func (m *Manager) writeOverflow(item *ClipboardHistory) error {
+	path := overflowPath(m.overflowDir(), item.Hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating overflow directory: %w", err)
+	}
+	// Content-addressed, so an existing file already holds this content
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(item.Item), 0600); err != nil {
+			return fmt.Errorf("error writing overflow file: %w", err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return fmt.Errorf("error writing overflow file: %w", err)
+		}
+	}
+	item.Size = len(item.Item)
+	item.Overflow = true
+	item.Item = overflowPreview(item.Item)
+	return nil
+}
+
+// removeOverflow deletes the overflow file for hash, if any.
+func (m *Manager) removeOverflow(hash string) {
+	dir := m.overflowDir()
+	if dir == "" {
+		return
+	}
+	if err := os.Remove(overflowPath(dir, hash)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Failed to remove overflow file: %v", err)
+	}
+}
+
+// readOverflow returns the full content stored in dir for hash.
+func readOverflow(dir, hash string) (string, error) {
+	data, err := os.ReadFile(overflowPath(dir, hash))
+	if err != nil {
+		return "", fmt.Errorf("error reading overflow content for clip %s: %w", hash, err)
+	}
+	return string(data), nil
+}
+
+// Text returns the full text of item, reading it from its overflow file if
+// it was too large to keep in the database.
+func (m *Manager) Text(item ClipboardHistory) (string, error) {
+	if !item.Overflow {
+		return item.Item, nil
+	}
+	return readOverflow(m.overflowDir(), item.Hash)
+}
+
+// overflowPreview returns the start of content, cut at a rune boundary.
+func overflowPreview(content string) string {
+	return cutAtRune(content, overflowPreviewLen)
+}
@@ -0,0 +1,116 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddItemOverflow(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(100)
+
+	large := strings.Repeat("é", 3000) // multi-byte, so the preview cut must respect runes
+	manager.AddItem("small")
+	manager.AddItem(large)
+
+	item, _ := manager.GetItem(1)
+	if !item.Overflow || item.Size != len(large) {
+		t.Fatalf("item = Overflow %v Size %d, want overflowed with size %d", item.Overflow, item.Size, len(large))
+	}
+	if len(item.Item) > overflowPreviewLen || !strings.HasPrefix(large, item.Item) {
+		t.Errorf("preview is %d bytes and not a prefix of the content", len(item.Item))
+	}
+	text, err := manager.Text(item)
+	if err != nil || text != large {
+		t.Fatalf("Text = %d bytes, %v; want full content", len(text), err)
+	}
+	if small, _ := manager.GetItem(0); small.Overflow {
+		t.Error("small item should be stored inline")
+	}
+
+	// The overflow survives a reload
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	reloaded, _ := manager.GetItem(1)
+	if !reloaded.Overflow || reloaded.Size != len(large) {
+		t.Fatalf("reloaded = Overflow %v Size %d", reloaded.Overflow, reloaded.Size)
+	}
+	if text, err := manager.Text(reloaded); err != nil || text != large {
+		t.Errorf("Text after reload = %d bytes, %v", len(text), err)
+	}
+
+	path := overflowPath(manager.overflowDir(), item.Hash)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("overflow file missing: %v", err)
+	}
+	if !manager.DeleteItem(1) {
+		t.Fatal("DeleteItem failed")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("overflow file not removed on delete: %v", err)
+	}
+}
+
+func TestOverflowDisabled(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(0)
+
+	large := strings.Repeat("x", 500)
+	manager.AddItem(large)
+	if item, _ := manager.GetItem(0); item.Overflow || item.Item != large {
+		t.Error("overflow should be disabled with a zero threshold")
+	}
+
+	inMemory := NewInMemoryManager()
+	inMemory.SetOverflowThreshold(100)
+	inMemory.AddItem(large)
+	if item, _ := inMemory.GetItem(0); item.Overflow {
+		t.Error("in-memory managers should never overflow")
+	}
+}
+
+func TestOverflowArchiveAndMerge(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(100)
+
+	large := strings.Repeat("archived ", 50)
+	manager.AddItem(large)
+	hash := manager.lastHash
+	if _, err := manager.ArchiveOlderThan(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("ArchiveOlderThan: %v", err)
+	}
+	archived, err := manager.ArchivedItems()
+	if err != nil || len(archived) != 1 || archived[0].Item != large {
+		t.Fatalf("archive should hold the full content, got %d items, %v", len(archived), err)
+	}
+	restored, err := manager.RestoreArchived(hash[:8])
+	if err != nil {
+		t.Fatalf("RestoreArchived: %v", err)
+	}
+	if text, err := manager.Text(restored); !restored.Overflow || err != nil || text != large {
+		t.Errorf("restored = Overflow %v, %d bytes, %v", restored.Overflow, len(text), err)
+	}
+
+	remote := strings.Repeat("remote ", 50)
+	otherPath := newOtherDB(t, t.TempDir(), func(other *Manager) {
+		other.SetOverflowThreshold(100)
+		other.AddItem(remote)
+	})
+	if _, err := manager.MergeFrom(otherPath); err != nil {
+		t.Fatalf("MergeFrom: %v", err)
+	}
+	merged := manager.items[manager.indexOf(newClipboardItem(remote).Hash)]
+	if text, err := manager.Text(merged); !merged.Overflow || err != nil || text != remote {
+		t.Errorf("merged = Overflow %v, %d bytes, %v", merged.Overflow, len(text), err)
+	}
+	if _, err := os.Stat(filepath.Join(manager.overflowDir(), merged.Hash[:2], merged.Hash)); err != nil {
+		t.Errorf("merged content not copied into this overflow directory: %v", err)
+	}
+}
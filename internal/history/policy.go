@@ -0,0 +1,108 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// AppPolicy limits and labels what is captured from one application, e.g.
+// keeping only URLs and text from a chat app and tagging them "chat".
+type AppPolicy struct {
+	App      string        // the source app, matched ignoring case
+	MaxBytes int           // largest copy recorded; 0 for no limit
+	Types    []detect.Type // content types recorded; empty records all
+	Tags     []string      // given to every entry recorded
+}
+
+// NewAppPolicy builds the policy for app from type names such as "url".
+// Unknown types and tags that can't be stored, being empty or holding a
+// comma, are left out and reported in the error.
+func NewAppPolicy(app string, maxBytes int, types, tags []string) (AppPolicy, error) {
+	policy := AppPolicy{App: strings.TrimSpace(app), MaxBytes: maxBytes}
+	var errs []error
+	if policy.App == "" {
+		errs = append(errs, errors.New("no app given"))
+	}
+	if maxBytes < 0 {
+		errs = append(errs, fmt.Errorf("negative max bytes %d", maxBytes))
+		policy.MaxBytes = 0
+	}
+	for _, name := range types {
+		t, ok := detect.ParseType(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown type %q", name))
+			continue
+		}
+		policy.Types = append(policy.Types, t)
+	}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.Contains(tag, ",") {
+			errs = append(errs, fmt.Errorf("invalid tag %q", tag))
+			continue
+		}
+		policy.Tags = append(policy.Tags, tag)
+	}
+	return policy, errors.Join(errs...)
+}
+
+// Refuses returns why the policy doesn't record a copy of length bytes and
+// type t, or "" when it does.
+func (p AppPolicy) Refuses(length int, t detect.Type) string {
+	if p.MaxBytes > 0 && length > p.MaxBytes {
+		return fmt.Sprintf("%s is over the %s limit", FormatSize(length), FormatSize(p.MaxBytes))
+	}
+	if len(p.Types) > 0 && !slices.Contains(p.Types, t) {
+		return fmt.Sprintf("%s isn't one of %s", t, joinTypes(p.Types))
+	}
+	return ""
+}
+
+// SetPolicies sets the policies applied to new items by the application
+// they were copied from, which SetSourceApp must be detecting. The first
+// policy for an app wins; apps without one are recorded as usual.
+func (m *Manager) SetPolicies(policies []AppPolicy) {
+	m.policies = policies
+}
+
+// policyFor returns the policy for app, if there is one
+func (m *Manager) policyFor(app string) (AppPolicy, bool) {
+	if app == "" {
+		return AppPolicy{}, false
+	}
+	for _, policy := range m.policies {
+		if strings.EqualFold(policy.App, app) {
+			return policy, true
+		}
+	}
+	return AppPolicy{}, false
+}
+
+// applyPolicy tags item, of length bytes, as the policy for its source app
+// says, or reports false when the policy refuses it for its size or type
+func (m *Manager) applyPolicy(item *ClipboardHistory, length int) bool {
+	policy, ok := m.policyFor(item.SourceApp)
+	if !ok {
+		return true
+	}
+	if reason := policy.Refuses(length, item.Type); reason != "" {
+		log.Printf("Skipped a clip from %s: %s", item.SourceApp, reason)
+		return false
+	}
+	item.Tags = slices.Clone(policy.Tags)
+	return true
+}
+
+// joinTypes lists types for messages, e.g. "url, text"
+func joinTypes(types []detect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,59 @@
+package history
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+func TestPolicies(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	app := "Slack"
+	manager.SetSourceApp(func() string { return app })
+	manager.SetPolicies([]AppPolicy{
+		{App: "slack", MaxBytes: 40, Types: []detect.Type{detect.URL, detect.Text}, Tags: []string{"chat"}},
+		{App: "slack", Tags: []string{"ignored"}},
+	})
+
+	if !manager.AddItem("https://example.com/thread") {
+		t.Fatal("expected a URL from Slack to be recorded")
+	}
+	if manager.AddItem(`{"message": "hi"}`) {
+		t.Error("expected JSON from Slack to be refused")
+	}
+	if manager.AddItem("a message far longer than the forty bytes allowed") {
+		t.Error("expected a copy over max_bytes to be refused")
+	}
+
+	app = "kitty"
+	if !manager.AddItem(`{"message": "hi"}`) {
+		t.Error("expected apps without a policy to be recorded as usual")
+	}
+
+	items := manager.GetItems()
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	url := items[indexOfText(manager, "https://example.com/thread")]
+	if !slices.Equal(url.Tags, []string{"chat"}) {
+		t.Errorf("Tags = %v, want [chat]", url.Tags)
+	}
+	if json := items[indexOfText(manager, `{"message": "hi"}`)]; len(json.Tags) != 0 {
+		t.Errorf("expected no tags without a policy, got %v", json.Tags)
+	}
+
+	// Tags are stored and can be queried
+	if err := manager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	tagged, err := manager.Query(Filter{Tag: "Chat"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].Item != "https://example.com/thread" || !slices.Equal(tagged[0].Tags, []string{"chat"}) {
+		t.Errorf("Query(tag) = %+v", tagged)
+	}
+}
@@ -0,0 +1,118 @@
+package history
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/db"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// Filter selects items by content. Zero fields don't filter.
+type Filter struct {
+	Text  string        // case-insensitive substring of the content
+	Types []detect.Type // any of these content types
+	// Languages keeps code entries in any of these languages. Languages
+	// are not stored, so they are checked after the database query.
+	Languages []detect.Language
+	Since     time.Time // last copied at or after
+	Until     time.Time // last copied before
+	App       string    // case-insensitive substring of the source app
+	Tag       string    // one of the item's tags, ignoring case
+}
+
+// IsEmpty reports whether the filter matches every item.
+func (f Filter) IsEmpty() bool {
+	return f.Text == "" && len(f.Types) == 0 && len(f.Languages) == 0 && f.Since.IsZero() && f.Until.IsZero() && f.App == "" && f.Tag == ""
+}
+
+// Matches reports whether item passes the filter.
+func (f Filter) Matches(item ClipboardHistory) bool {
+	if f.Text != "" && !strings.Contains(strings.ToLower(item.Item), strings.ToLower(f.Text)) {
+		return false
+	}
+	if f.App != "" && !strings.Contains(strings.ToLower(item.SourceApp), strings.ToLower(f.App)) {
+		return false
+	}
+	if f.Tag != "" && !slices.ContainsFunc(item.Tags, func(tag string) bool { return strings.EqualFold(tag, f.Tag) }) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsType(f.Types, item.Type) {
+		return false
+	}
+	if len(f.Languages) > 0 && !slices.Contains(f.Languages, item.Language) {
+		return false
+	}
+	if !f.Since.IsZero() && item.TimeStamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !item.TimeStamp.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func containsType(types []detect.Type, t detect.Type) bool {
+	for _, want := range types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Query returns the items passing filter, pinned first and then oldest
+// first. Database-backed managers evaluate the filter in SQL, so it does not
+// depend on which items are loaded in memory.
+func (m *Manager) Query(filter Filter) ([]ClipboardHistory, error) {
+	if m.dbClient == nil {
+		result := make([]ClipboardHistory, 0)
+		for _, item := range m.items {
+			if filter.Matches(item) {
+				result = append(result, item)
+			}
+		}
+		return result, nil
+	}
+
+	types := make([]string, len(filter.Types))
+	for i, t := range filter.Types {
+		types[i] = string(t)
+	}
+	entries, err := m.dbClient.Query(db.Filter{
+		Substring: filter.Text,
+		Types:     types,
+		Since:     filter.Since,
+		Until:     filter.Until,
+		App:       filter.App,
+		Tag:       filter.Tag,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ClipboardHistory, 0, len(entries))
+	for _, entry := range entries {
+		item := itemFromEntry(entry)
+		// Unclassified entries come back for any type filter; check them
+		// now that they have been classified
+		if len(filter.Types) > 0 && !containsType(filter.Types, item.Type) {
+			continue
+		}
+		if len(filter.Languages) > 0 && !slices.Contains(filter.Languages, item.Language) {
+			continue
+		}
+		result = append(result, item)
+	}
+	// Incognito items are only in memory
+	if kept := m.memoryItems(); len(kept) > 0 {
+		for _, item := range kept {
+			if filter.Matches(item) {
+				result = append(result, item)
+			}
+		}
+		sortItems(result)
+	}
+	return result, nil
+}
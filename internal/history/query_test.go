@@ -0,0 +1,75 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+func TestQuery(t *testing.T) {
+	dbManager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	for name, manager := range map[string]*Manager{"database": dbManager, "in-memory": NewInMemoryManager()} {
+		manager.AddItem("https://example.com/deploy")
+		manager.AddItem("Deploy checklist")
+		manager.AddItem("lunch order")
+		if err := manager.TogglePin(2); err != nil {
+			t.Fatalf("%s: TogglePin: %v", name, err)
+		}
+
+		check := func(filter Filter, want ...string) {
+			t.Helper()
+			got, err := manager.Query(filter)
+			if err != nil {
+				t.Fatalf("%s: Query: %v", name, err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%s: Query(%+v) returned %d items, want %v", name, filter, len(got), want)
+			}
+			for i := range want {
+				if got[i].Item != want[i] {
+					t.Errorf("%s: Query(%+v)[%d] = %q, want %q", name, filter, i, got[i].Item, want[i])
+				}
+			}
+		}
+
+		check(Filter{}, "lunch order", "https://example.com/deploy", "Deploy checklist")
+		check(Filter{Text: "deploy"}, "https://example.com/deploy", "Deploy checklist")
+		check(Filter{Text: "deploy", Types: []detect.Type{detect.URL}}, "https://example.com/deploy")
+		check(Filter{Since: time.Now().Add(time.Hour)})
+		check(Filter{Until: time.Now().Add(time.Hour), Types: []detect.Type{detect.Text}}, "lunch order", "Deploy checklist")
+	}
+}
+
+func TestQueryLanguages(t *testing.T) {
+	dbManager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	goCode := "func main() {\n\tx := 1\n\tfmt.Println(x)\n}"
+	sqlCode := "SELECT id FROM users WHERE id != 1;"
+	for name, manager := range map[string]*Manager{"database": dbManager, "in-memory": NewInMemoryManager()} {
+		manager.AddItem(goCode)
+		manager.AddItem(sqlCode)
+		manager.AddItem("plain words")
+
+		got, err := manager.Query(Filter{Languages: []detect.Language{detect.Go}})
+		if err != nil {
+			t.Fatalf("%s: Query: %v", name, err)
+		}
+		if len(got) != 1 || got[0].Item != goCode {
+			t.Errorf("%s: Query(lang go) = %v, want only the Go snippet", name, got)
+		}
+		if got := manager.GetItems()[1].Language; got != detect.SQL {
+			t.Errorf("%s: SQL entry language = %q, want %q", name, got, detect.SQL)
+		}
+	}
+
+	if err := dbManager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if got := dbManager.GetItems()[0].Language; got != detect.Go {
+		t.Errorf("reloaded entry language = %q, want %q", got, detect.Go)
+	}
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRegister is returned for a register name other than a-z.
+var ErrInvalidRegister = errors.New("invalid register")
+
+// IsRegister reports whether name is a register name, a single letter a-z.
+func IsRegister(name string) bool {
+	return len(name) == 1 && name[0] >= 'a' && name[0] <= 'z'
+}
+
+// SetRegister stores the item at index in the named register, vim style,
+// replacing the entry it held. An entry can be in several registers.
+// Registers of items captured in incognito mode last only as long as the
+// items.
+func (m *Manager) SetRegister(name string, index int) error {
+	if !IsRegister(name) {
+		return fmt.Errorf("%w: %q (use a-z)", ErrInvalidRegister, name)
+	}
+	if index < 0 || index >= len(m.items) {
+		return fmt.Errorf("invalid index: %d", index)
+	}
+
+	item := &m.items[index]
+	if m.dbClient != nil {
+		hash := ""
+		if m.persisted(*item) {
+			hash = item.Hash
+		}
+		if err := m.dbClient.SetRegister(name, hash); err != nil {
+			return fmt.Errorf("error storing register: %w", err)
+		}
+	}
+	m.dropRegister(name)
+	item.Registers = addRegister(item.Registers, name)
+	return nil
+}
+
+// ClearRegister empties the named register.
+func (m *Manager) ClearRegister(name string) error {
+	if !IsRegister(name) {
+		return fmt.Errorf("%w: %q (use a-z)", ErrInvalidRegister, name)
+	}
+	if m.dbClient != nil {
+		if err := m.dbClient.SetRegister(name, ""); err != nil {
+			return fmt.Errorf("error clearing register: %w", err)
+		}
+	}
+	m.dropRegister(name)
+	return nil
+}
+
+// FindByRegister returns the item held in the named register.
+func (m *Manager) FindByRegister(name string) (ClipboardHistory, bool) {
+	if !IsRegister(name) {
+		return ClipboardHistory{}, false
+	}
+	for _, item := range m.items {
+		if strings.Contains(item.Registers, name) {
+			return item, true
+		}
+	}
+	return ClipboardHistory{}, false
+}
+
+// dropRegister removes name from the item holding it, if any
+func (m *Manager) dropRegister(name string) {
+	for i := range m.items {
+		m.items[i].Registers = strings.ReplaceAll(m.items[i].Registers, name, "")
+	}
+}
+
+// addRegister adds name to registers, keeping them sorted
+func addRegister(registers, name string) string {
+	if strings.Contains(registers, name) {
+		return registers
+	}
+	for i := range len(registers) {
+		if registers[i] > name[0] {
+			return registers[:i] + name + registers[i:]
+		}
+	}
+	return registers + name
+}
@@ -0,0 +1,91 @@
+package history
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetRegisterAndFind(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("first")
+	manager.AddItem("second")
+
+	if err := manager.SetRegister("a", 0); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	if err := manager.SetRegister("c", 0); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	if item, ok := manager.FindByRegister("a"); !ok || item.Item != "first" {
+		t.Errorf("FindByRegister(a) = %q, %v; want first", item.Item, ok)
+	}
+
+	// Storing another item moves the register to it
+	if err := manager.SetRegister("a", 1); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	items := manager.GetItems()
+	if items[0].Registers != "c" || items[1].Registers != "a" {
+		t.Errorf("registers = %q, %q; want c and a", items[0].Registers, items[1].Registers)
+	}
+
+	// Registers persist with the history
+	other, err := NewManagerWithPath(manager.dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer other.Close()
+	if err := other.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if item, ok := other.FindByRegister("a"); !ok || item.Item != "second" {
+		t.Errorf("reloaded register a = %q, %v; want second", item.Item, ok)
+	}
+
+	if err := manager.ClearRegister("c"); err != nil {
+		t.Fatalf("ClearRegister: %v", err)
+	}
+	if _, ok := manager.FindByRegister("c"); ok {
+		t.Error("expected cleared register to be empty")
+	}
+
+	for _, name := range []string{"A", "ab", "1", ""} {
+		if err := manager.SetRegister(name, 0); !errors.Is(err, ErrInvalidRegister) {
+			t.Errorf("SetRegister(%q) = %v, want ErrInvalidRegister", name, err)
+		}
+	}
+}
+
+func TestSetRegisterIncognito(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.AddItem("kept")
+	if err := manager.SetRegister("a", 0); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	manager.SetIncognito(true)
+	manager.AddItem("secret")
+	if err := manager.SetRegister("a", 1); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	if item, ok := manager.FindByRegister("a"); !ok || item.Item != "secret" {
+		t.Errorf("FindByRegister(a) = %q, %v; want secret", item.Item, ok)
+	}
+
+	// The register isn't written to the database, nor left pointing at the
+	// entry it replaced
+	other, err := NewManagerWithPath(manager.dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer other.Close()
+	if err := other.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if item, ok := other.FindByRegister("a"); ok {
+		t.Errorf("expected register a empty on disk, got %q", item.Item)
+	}
+}
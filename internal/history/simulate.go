@@ -0,0 +1,71 @@
+package history
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Simulation is what capturing a piece of content would do, as worked out
+// by Simulate without recording anything.
+type Simulation struct {
+	// Item is the entry as it would be recorded, with its detected type,
+	// language and secret, source app, tags and expiry. Its content is
+	// the full text, even when it would be stored in an overflow file.
+	Item ClipboardHistory
+	// Skipped says why the content would not be recorded; empty when it
+	// would be.
+	Skipped string
+	// Truncated reports that the content would be cut to the capture
+	// limit, as Item.Item shows.
+	Truncated bool
+	// Policy is the app policy applied, if the source app has one.
+	Policy *AppPolicy
+	// TTL is the time to live given by the first matching expiry rule.
+	TTL time.Duration
+	// Overflow reports that the content is large enough to be stored in a
+	// file rather than the database.
+	Overflow bool
+}
+
+// Simulate runs content through the steps AddText takes to record text
+// copied from app (empty when unknown): the capture limit, type and secret
+// detection, skipping secrets, expiry rules and the app's policy. It
+// neither stores nor logs anything, and ignores what history already
+// holds, so content that is already there is reported as it would be
+// recorded the first time.
+func (m *Manager) Simulate(content, app string) Simulation {
+	var s Simulation
+	if m.captureLimit > 0 && len(content) > m.captureLimit {
+		if !m.truncateOversized {
+			s.Item = newClipboardItem(content)
+			s.Item.SourceApp = app
+			s.Skipped = fmt.Sprintf("%s is over the %s capture limit", FormatSize(len(content)), FormatSize(m.captureLimit))
+			return s
+		}
+		content = cutAtRune(content, m.captureLimit) + m.truncationMarker()
+		s.Truncated = true
+	}
+	item := newClipboardItem(content)
+	item.SourceApp = app
+	if ttl := m.ruleTTL(item.Item); ttl > 0 {
+		s.TTL = ttl
+		item.ExpiresAt = item.TimeStamp.Add(ttl)
+	}
+	if policy, ok := m.policyFor(app); ok {
+		s.Policy = &policy
+		item.Tags = slices.Clone(policy.Tags)
+	}
+	s.Item = item
+	s.Overflow = m.overflowThreshold > 0 && len(content) > m.overflowThreshold
+
+	switch {
+	case m.skipSensitive && item.Sensitive != "":
+		s.Skipped = fmt.Sprintf("it looks like a secret (%s) and secrets are skipped", item.Sensitive)
+	case s.Policy != nil:
+		if reason := s.Policy.Refuses(item.contentLength(), item.Type); reason != "" {
+			s.Skipped = fmt.Sprintf("the policy for %s refuses it: %s", s.Policy.App, reason)
+		}
+	}
+	return s
+}
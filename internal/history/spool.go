@@ -0,0 +1,150 @@
+package history
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Spooled is text read by Spool. Text up to the overflow threshold is held
+// in full in Content; larger text is written to a temporary file as it is
+// read, and Content only holds a preview of it.
+type Spooled struct {
+	Content string
+	Size    int    // length of the full text
+	Hash    string // SHA-256 of the full text, set for spooled text
+	// Oversized marks text over the capture limit: truncated when the
+	// manager truncates, and otherwise refused by AddSpooled.
+	Oversized bool
+	file      string // temporary file holding the full text, if spooled
+}
+
+// InFile reports whether the text was too large to hold in memory and was
+// written to a file instead.
+func (s Spooled) InFile() bool {
+	return s.file != ""
+}
+
+// Discard removes the file holding spooled text, for text that won't be
+// added with AddSpooled.
+func (s Spooled) Discard() {
+	if s.file == "" {
+		return
+	}
+	if err := os.Remove(s.file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Failed to remove spooled clip: %v", err)
+	}
+}
+
+// Spool reads text from r, such as the output of a clipboard tool. Text
+// over the overflow threshold is written straight to a file in the overflow
+// directory as it arrives, so a huge copy is never held in memory in full.
+// In-memory managers, and incognito mode, read everything into memory.
+// Reading stops at the capture limit.
+func (m *Manager) Spool(r io.Reader) (Spooled, error) {
+	limited := &limitReader{r: r, limit: m.captureLimit}
+	if m.overflowThreshold == 0 || m.overflowDir() == "" || m.incognito {
+		data, err := io.ReadAll(limited)
+		if err != nil {
+			return Spooled{}, fmt.Errorf("error reading clip: %w", err)
+		}
+		return m.spooledInMemory(data, limited.exceeded), nil
+	}
+
+	head, err := io.ReadAll(io.LimitReader(limited, int64(m.overflowThreshold)+1))
+	if err != nil {
+		return Spooled{}, fmt.Errorf("error reading clip: %w", err)
+	}
+	if len(head) <= m.overflowThreshold {
+		return m.spooledInMemory(head, limited.exceeded), nil
+	}
+
+	dir := m.overflowDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return Spooled{}, fmt.Errorf("error creating overflow directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "spool-*.tmp")
+	if err != nil {
+		return Spooled{}, fmt.Errorf("error spooling clip: %w", err)
+	}
+	spooled := Spooled{Content: overflowPreview(string(head)), file: f.Name()}
+	hash := sha256.New()
+	w := io.MultiWriter(f, hash)
+	_, err = w.Write(head)
+	var rest int64
+	if err == nil {
+		rest, err = io.Copy(w, limited)
+	}
+	if err == nil && limited.exceeded && m.truncateOversized {
+		var n int
+		n, err = io.WriteString(w, m.truncationMarker())
+		rest += int64(n)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		spooled.Discard()
+		return Spooled{}, fmt.Errorf("error spooling clip: %w", err)
+	}
+	spooled.Size = len(head) + int(rest)
+	spooled.Hash = fmt.Sprintf("%x", hash.Sum(nil))
+	spooled.Oversized = limited.exceeded
+	return spooled, nil
+}
+
+// spooledInMemory returns text read whole, marked as truncated if it went
+// past the capture limit
+func (m *Manager) spooledInMemory(data []byte, exceeded bool) Spooled {
+	content := string(data)
+	if exceeded && m.truncateOversized {
+		content += m.truncationMarker()
+	}
+	return Spooled{Content: content, Size: len(content), Oversized: exceeded}
+}
+
+// AddSpooled is AddItemFrom for text read by Spool. Spooled text becomes an
+// overflow item without being read back; its file is moved into place, or
+// removed if the text isn't added.
+func (m *Manager) AddSpooled(s Spooled, selection Selection) bool {
+	defer s.Discard()
+	if s.Oversized && !m.truncateOversized {
+		log.Printf("Skipped a clip over the capture limit of %s", FormatSize(m.captureLimit))
+		return false
+	}
+	if !s.InFile() {
+		added, err := m.addText(s.Content, selection, nil)
+		if err != nil {
+			log.Printf("Failed to add clip: %v", err)
+		}
+		return added
+	}
+	if m.incognito {
+		// Switched on since spooling; the file must not be kept
+		return false
+	}
+	item := newClipboardItem(s.Content)
+	item.Hash = s.Hash
+	item.Size = s.Size
+	item.Overflow = true
+	item.Selection = selection
+	added, err := m.addItem(item, func(item *ClipboardHistory) error {
+		path := overflowPath(m.overflowDir(), item.Hash)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("error creating overflow directory: %w", err)
+		}
+		if err := os.Rename(s.file, path); err != nil {
+			return fmt.Errorf("error storing spooled clip: %w", err)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		log.Printf("Failed to add clip: %v", err)
+	}
+	return added
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpoolWritesLargeTextToOverflow(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(100)
+
+	large := strings.Repeat("line of output\n", 1000)
+	spooled, err := manager.Spool(strings.NewReader(large))
+	if err != nil {
+		t.Fatalf("Spool: %v", err)
+	}
+	if !spooled.InFile() || spooled.Size != len(large) || len(spooled.Content) > overflowPreviewLen {
+		t.Fatalf("spooled = InFile %v Size %d preview %d bytes, want a file of %d bytes", spooled.InFile(), spooled.Size, len(spooled.Content), len(large))
+	}
+	if !manager.AddSpooled(spooled, SelectionClipboard) {
+		t.Fatal("expected the spooled text to be added")
+	}
+	item, _ := manager.GetItem(0)
+	if !item.Overflow || item.Size != len(large) {
+		t.Fatalf("item = Overflow %v Size %d", item.Overflow, item.Size)
+	}
+	if text, err := manager.Text(item); err != nil || text != large {
+		t.Errorf("Text = %d bytes, %v; want the full content", len(text), err)
+	}
+
+	// The same text added whole is a duplicate of it
+	if manager.AddItem(large) {
+		t.Error("expected the spooled text to be deduplicated by its hash")
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(manager.overflowDir(), "spool-*")); len(leftovers) != 0 {
+		t.Errorf("expected no temporary files left, got %v", leftovers)
+	}
+}
+
+func TestSpoolKeepsSmallTextInMemory(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(100)
+
+	spooled, err := manager.Spool(strings.NewReader("small"))
+	if err != nil {
+		t.Fatalf("Spool: %v", err)
+	}
+	if spooled.InFile() || spooled.Content != "small" || spooled.Size != 5 {
+		t.Fatalf("spooled = %+v, want the text in memory", spooled)
+	}
+	if !manager.AddSpooled(spooled, SelectionClipboard) {
+		t.Fatal("expected the text to be added")
+	}
+	if item, _ := manager.GetItem(0); item.Item != "small" || item.Overflow {
+		t.Errorf("item = %+v", item)
+	}
+}
+
+func TestDiscardRemovesSpooledText(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+	manager.SetOverflowThreshold(10)
+
+	spooled, err := manager.Spool(strings.NewReader(strings.Repeat("x", 100)))
+	if err != nil {
+		t.Fatalf("Spool: %v", err)
+	}
+	spooled.Discard()
+	if _, err := os.Stat(spooled.file); !os.IsNotExist(err) {
+		t.Errorf("expected the spooled file to be removed, got %v", err)
+	}
+	spooled.Discard()
+}
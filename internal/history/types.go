@@ -1,11 +1,92 @@
 package history
 
-import "time"
+import (
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// Kind distinguishes text entries from binary ones
+type Kind string
+
+const (
+	KindText  Kind = "text"
+	KindImage Kind = "image"
+)
+
+// Selection is the X11 selection an entry was captured from
+type Selection string
+
+const (
+	SelectionClipboard Selection = "clipboard"
+	// SelectionPrimary is the middle-click buffer holding highlighted text
+	SelectionPrimary Selection = "primary"
+)
 
 // ClipboardHistory represents a single clipboard entry with metadata
 type ClipboardHistory struct {
-	Item      string    `json:"item"`
-	Hash      string    `json:"hash"`
-	TimeStamp time.Time `json:"timeStamp"`
-	Pinned    bool      `json:"pinned"`
+	Item      string      `json:"item"`
+	Hash      string      `json:"hash"`
+	TimeStamp time.Time   `json:"timeStamp"`
+	Pinned    bool        `json:"pinned"`
+	Alias     string      `json:"alias,omitempty"`
+	Type      detect.Type `json:"type"`
+	Kind      Kind        `json:"kind,omitempty"`
+	MimeType  string      `json:"mimeType,omitempty"`
+	Size      int         `json:"size,omitempty"`
+	Count     int         `json:"count,omitempty"`
+	ExpiresAt time.Time   `json:"expiresAt,omitzero"`
+	// Overflow marks text too large for the database: Item holds a preview,
+	// Size the full length, and Manager.Text reads the full content.
+	Overflow bool `json:"overflow,omitempty"`
+	// Selection is where the entry was captured; empty means the clipboard.
+	Selection Selection `json:"selection,omitempty"`
+	// SourceApp names the application focused when the entry was first
+	// copied, e.g. "firefox"; empty when unknown (see SetSourceApp).
+	SourceApp string `json:"source_app,omitempty"`
+	// Sensitive names the kind of secret the text holds, e.g. a JWT; the
+	// table masks such entries. Detected on capture and load, not stored.
+	Sensitive detect.Secret `json:"sensitive,omitempty"`
+	// Formats are the MIME types of alternate representations copied with
+	// a text entry, such as "text/html"; see Manager.Format.
+	Formats []string `json:"formats,omitempty"`
+	// Incognito marks an item captured in incognito mode, kept only in
+	// memory and never written to the database.
+	Incognito bool `json:"incognito,omitempty"`
+	// Language is the programming language of a code entry, e.g. "go",
+	// shown as its type badge. Detected on capture and load, not stored.
+	Language detect.Language `json:"language,omitempty"`
+	// Position is a pinned entry's place in the order set by MovePinned,
+	// from 1; zero means it sorts by timestamp after those placed.
+	Position int `json:"position,omitempty"`
+	// Width and Height are an image's dimensions in pixels, zero when
+	// unknown.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Registers are the names of the registers holding the entry, e.g.
+	// "ac"; see Manager.SetRegister.
+	Registers string `json:"registers,omitempty"`
+	// Tags label the entry, given by the policy of the application it was
+	// copied from; see Manager.SetPolicies.
+	Tags []string `json:"tags,omitempty"`
+	// ID numbers the entry for good, unlike its place in the table; zero
+	// for entries not in the database. See ShortID.
+	ID int64 `json:"id,omitempty"`
+}
+
+// FromPrimary reports whether the entry was captured from the primary
+// selection rather than the clipboard.
+func (h ClipboardHistory) FromPrimary() bool {
+	return h.Selection == SelectionPrimary
+}
+
+// IsBinary reports whether the entry holds binary data rather than text.
+// For binary entries Item is a human-readable description of the payload.
+func (h ClipboardHistory) IsBinary() bool {
+	return h.Kind != "" && h.Kind != KindText
+}
+
+// IsExpired reports whether the entry has an expiry that has passed by now.
+func (h ClipboardHistory) IsExpired(now time.Time) bool {
+	return !h.ExpiresAt.IsZero() && !now.Before(h.ExpiresAt)
 }
@@ -0,0 +1,152 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/db"
+)
+
+// Removed is an item deleted with Remove, holding what Restore needs to put
+// it back: its full text, image data and formats, which deleting discards.
+type Removed struct {
+	Item    ClipboardHistory
+	text    string
+	data    []byte
+	formats map[string][]byte
+}
+
+// Remove deletes items[index] as DeleteItem does, first saving the item
+// with its content so that Restore can undo the delete.
+func (m *Manager) Remove(index int) (Removed, error) {
+	if index < 0 || index >= len(m.items) {
+		return Removed{}, fmt.Errorf("invalid index: %d", index)
+	}
+	item := m.items[index]
+	removed := Removed{Item: item, text: item.Item}
+	var err error
+	switch {
+	case item.IsBinary():
+		removed.data, err = m.GetData(item)
+	case item.Overflow:
+		removed.text, err = m.Text(item)
+	}
+	if err != nil {
+		return Removed{}, fmt.Errorf("error saving clip before deleting it: %w", err)
+	}
+	if len(item.Formats) > 0 {
+		removed.formats = make(map[string][]byte, len(item.Formats))
+		for _, mimeType := range item.Formats {
+			if removed.formats[mimeType], err = m.Format(item, mimeType); err != nil {
+				return Removed{}, fmt.Errorf("error saving clip before deleting it: %w", err)
+			}
+		}
+	}
+	if !m.DeleteItem(index) {
+		return Removed{}, fmt.Errorf("error deleting clip %s", item.Hash)
+	}
+	return removed, nil
+}
+
+// Restore puts an item deleted with Remove back into history as it was:
+// with its timestamp, copy count, pin and place among the pinned items,
+// and its alias and registers unless they have been given to other items
+// since. Items captured in incognito mode are restored to memory only. It
+// fails if the content is in history again, e.g. copied since.
+func (m *Manager) Restore(removed Removed) error {
+	item := removed.Item
+	if _, exists := m.hashes[item.Hash]; exists {
+		return fmt.Errorf("clip %s is already in history", item.Hash)
+	}
+
+	if item.Incognito {
+		item.Alias, item.Registers = "", ""
+		if removed.data != nil {
+			if m.blobs == nil {
+				m.blobs = make(map[string][]byte)
+			}
+			m.blobs[item.Hash] = removed.data
+		}
+		if removed.formats != nil {
+			if m.formats == nil {
+				m.formats = make(map[string]map[string][]byte)
+			}
+			m.formats[item.Hash] = removed.formats
+		}
+		m.items = append(m.items, item)
+		m.hashes[item.Hash] = item.contentLength()
+	} else {
+		entry := db.ClipboardEntry{
+			Content:   removed.text,
+			Hash:      item.Hash,
+			Timestamp: item.TimeStamp,
+			Pinned:    item.Pinned,
+			Type:      string(item.Type),
+			Kind:      string(item.Kind),
+			MimeType:  item.MimeType,
+			Data:      removed.data,
+			Count:     item.Count,
+			ExpiresAt: item.ExpiresAt,
+			Selection: string(item.Selection),
+			SourceApp: item.SourceApp,
+			Width:     item.Width,
+			Height:    item.Height,
+			Tags:      item.Tags,
+			ID:        item.ID,
+		}
+		if err := m.importEntry(memoryData{item.Hash: removed.data}, entry); err != nil {
+			return err
+		}
+		index := len(m.items) - 1
+		if len(removed.formats) > 0 {
+			if err := m.setFormats(index, removed.formats); err != nil {
+				log.Printf("Failed to restore clip formats: %v", err)
+			}
+		}
+		if item.Alias != "" {
+			if _, taken := m.FindByAlias(item.Alias); !taken {
+				if err := m.SetAlias(index, item.Alias); err != nil {
+					log.Printf("Failed to restore alias: %v", err)
+				}
+			}
+		}
+		for _, name := range strings.Split(item.Registers, "") {
+			if _, taken := m.FindByRegister(name); !taken {
+				if err := m.SetRegister(name, index); err != nil {
+					log.Printf("Failed to restore register: %v", err)
+				}
+			}
+		}
+	}
+
+	if item.Pinned && item.Position > 0 {
+		m.items[len(m.items)-1].Position = item.Position
+		sortItems(m.items)
+		return m.storePinnedOrder()
+	}
+	sortItems(m.items)
+	return nil
+}
+
+// storePinnedOrder numbers the pinned items in the order they are in and
+// saves it
+func (m *Manager) storePinnedOrder() error {
+	var hashes []string
+	for i := range m.items {
+		if !m.items[i].Pinned {
+			break
+		}
+		m.items[i].Position = i + 1
+		if m.persisted(m.items[i]) {
+			hashes = append(hashes, m.items[i].Hash)
+		}
+	}
+	if m.dbClient == nil {
+		return nil
+	}
+	if err := m.dbClient.SetPositions(hashes); err != nil {
+		return fmt.Errorf("error storing pinned order: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemoveAndRestore(t *testing.T) {
+	manager, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	manager.SetOverflowThreshold(16)
+	manager.AddItem("first pinned")
+	manager.AddItem("second pinned")
+	manager.AddItemWithFormats("styled", map[string][]byte{"text/html": []byte("<b>styled</b>")})
+	long := strings.Repeat("overflowing text ", 4)
+	manager.AddItem(long)
+	latest, _ := manager.Latest()
+	longHash := latest.Hash
+	for _, text := range []string{"first pinned", "second pinned"} {
+		if err := manager.TogglePin(indexOfText(manager, text)); err != nil {
+			t.Fatalf("TogglePin: %v", err)
+		}
+	}
+	if _, err := manager.MovePinned(indexOfText(manager, "second pinned"), -1); err != nil {
+		t.Fatalf("MovePinned: %v", err)
+	}
+	styled := indexOfText(manager, "styled")
+	if err := manager.SetAlias(styled, "bold"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if err := manager.SetRegister("s", styled); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	before := hashesOf(manager.GetItems())
+
+	var removed []Removed
+	items := manager.GetItems()
+	for _, hash := range []string{items[indexOfText(manager, "second pinned")].Hash, items[styled].Hash, longHash} {
+		r, err := manager.Remove(manager.indexOf(hash))
+		if err != nil {
+			t.Fatalf("Remove(%s): %v", hash, err)
+		}
+		removed = append(removed, r)
+	}
+	if manager.Count() != 1 {
+		t.Fatalf("Count after Remove = %d, want 1", manager.Count())
+	}
+	for _, r := range removed {
+		if err := manager.Restore(r); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+	}
+	if err := manager.Restore(removed[0]); err == nil {
+		t.Error("expected restoring an item already in history to fail")
+	}
+
+	// Reloading shows what was stored, not just what is in memory
+	reloaded, err := NewManagerWithPath(manager.dbPath)
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer reloaded.Close()
+	reloaded.SetOverflowThreshold(16)
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	for _, m := range []*Manager{manager, reloaded} {
+		items := m.GetItems()
+		if got := hashesOf(items); strings.Join(got, ",") != strings.Join(before, ",") {
+			t.Errorf("order after Restore = %v, want %v", got, before)
+		}
+		item := items[indexOfText(m, "styled")]
+		if item.Alias != "bold" || item.Registers != "s" || len(item.Formats) != 1 {
+			t.Errorf("restored %+v, want its alias, register and format back", item)
+		}
+		overflow := items[m.indexOf(longHash)]
+		if text, err := m.Text(overflow); err != nil || text != long {
+			t.Errorf("Text of restored overflow item = %q, %v", text, err)
+		}
+	}
+}
+
+// indexOfText returns the index of the item with the given content
+func indexOfText(m *Manager, text string) int {
+	for i, item := range m.GetItems() {
+		if item.Item == text {
+			return i
+		}
+	}
+	return -1
+}
+
+// hashesOf returns the hashes of items in order
+func hashesOf(items []ClipboardHistory) []string {
+	hashes := make([]string, len(items))
+	for i, item := range items {
+		hashes[i] = item.Hash
+	}
+	return hashes
+}
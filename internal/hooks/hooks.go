@@ -0,0 +1,163 @@
+// Package hooks runs user commands when an entry is captured or copied,
+// with its text on stdin, e.g. to pipe URLs into a downloader or log
+// entries to a notes app.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// Event is when a hook runs.
+type Event string
+
+const (
+	// Capture is a new entry being recorded from the clipboard or the CLI.
+	Capture Event = "capture"
+	// Copy is an entry being copied back from history.
+	Copy Event = "copy"
+)
+
+// timeout is how long a hook may run before it is killed. Overridable for
+// tests.
+var timeout = 30 * time.Second
+
+// Hook runs Command, a program and its arguments, on Event for text
+// entries matching Pattern (all of them when nil).
+type Hook struct {
+	Event   Event
+	Command []string
+	Pattern *regexp.Regexp
+}
+
+// New checks and compiles a hook from its configured form.
+func New(event string, command []string, pattern string) (Hook, error) {
+	hook := Hook{Event: Event(event), Command: command}
+	if hook.Event != Capture && hook.Event != Copy {
+		return Hook{}, fmt.Errorf("invalid hook event %q: want %q or %q", event, Capture, Copy)
+	}
+	if len(command) == 0 || command[0] == "" {
+		return Hook{}, fmt.Errorf("hook for %q has no command", event)
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Hook{}, fmt.Errorf("invalid hook pattern %q: %w", pattern, err)
+		}
+		hook.Pattern = re
+	}
+	return hook, nil
+}
+
+// Runner runs hooks in the background.
+type Runner struct {
+	hooks   []Hook
+	running sync.WaitGroup
+}
+
+// NewRunner returns a Runner for hooks.
+func NewRunner(hooks []Hook) *Runner {
+	return &Runner{hooks: hooks}
+}
+
+// has reports whether any hook runs on event
+func (r *Runner) has(event Event) bool {
+	for _, hook := range r.hooks {
+		if hook.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run starts the hooks for event whose pattern matches text, the full text
+// of item, without waiting for them. Binary entries, entries that look like
+// secrets and entries captured in incognito mode never reach a hook.
+func (r *Runner) Run(event Event, item history.ClipboardHistory, text string) {
+	for _, hook := range r.Matching(event, item, text) {
+		r.running.Go(func() {
+			if err := hook.run(item, text); err != nil {
+				log.Printf("Hook %q failed: %v", strings.Join(hook.Command, " "), err)
+			}
+		})
+	}
+}
+
+// Matching returns the hooks Run would start for event, item and text.
+func (r *Runner) Matching(event Event, item history.ClipboardHistory, text string) []Hook {
+	if item.IsBinary() || item.Sensitive != "" || item.Incognito {
+		return nil
+	}
+	var matching []Hook
+	for _, hook := range r.hooks {
+		if hook.Event == event && (hook.Pattern == nil || hook.Pattern.MatchString(text)) {
+			matching = append(matching, hook)
+		}
+	}
+	return matching
+}
+
+// Attach runs the capture hooks for each new text entry m records.
+func (r *Runner) Attach(m *history.Manager) {
+	if !r.has(Capture) {
+		return
+	}
+	m.SetOnCapture(func(item history.ClipboardHistory) {
+		if item.IsBinary() {
+			return
+		}
+		// The item may only hold a preview of large text
+		text, err := m.Text(item)
+		if err != nil {
+			log.Printf("Failed to read clip for hooks: %v", err)
+			return
+		}
+		r.Run(Capture, item, text)
+	})
+}
+
+// Copied runs the copy hooks for item, copied back from history as text.
+func (r *Runner) Copied(item history.ClipboardHistory, text string) {
+	r.Run(Copy, item, text)
+}
+
+// Wait waits for running hooks to finish.
+func (r *Runner) Wait() {
+	r.running.Wait()
+}
+
+// run runs the hook's command with text on stdin and the entry described
+// in its environment, enough to e.g. notify of a capture without showing
+// its content
+func (h Hook) run(item history.ClipboardHistory, text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	// Don't wait on children still holding its output once it's killed
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"CLIPPY_EVENT="+string(h.Event),
+		"CLIPPY_TYPE="+string(item.Type),
+		"CLIPPY_HASH="+item.Hash,
+		"CLIPPY_LENGTH="+strconv.Itoa(utf8.RuneCountInString(text)),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New("capture", []string{"cat"}, `^https?://`); err != nil {
+		t.Errorf("expected a valid hook, got %v", err)
+	}
+	for _, tc := range []struct {
+		event   string
+		command []string
+		pattern string
+	}{
+		{"paste", []string{"cat"}, ""},
+		{"copy", nil, ""},
+		{"copy", []string{"cat"}, "("},
+	} {
+		if _, err := New(tc.event, tc.command, tc.pattern); err == nil {
+			t.Errorf("New(%q, %q, %q): expected an error", tc.event, tc.command, tc.pattern)
+		}
+	}
+}
+
+func TestMatching(t *testing.T) {
+	urls, _ := New("capture", []string{"open"}, `^https?://`)
+	all, _ := New("capture", []string{"notify-send"}, "")
+	copies, _ := New("copy", []string{"cat"}, "")
+	runner := NewRunner([]Hook{urls, all, copies})
+
+	item := history.ClipboardHistory{Item: "https://example.com", Type: detect.URL}
+	if got := runner.Matching(Capture, item, item.Item); len(got) != 2 || got[0].Command[0] != "open" {
+		t.Errorf("Matching(capture, URL) = %v, want the URL and catch-all hooks", got)
+	}
+	if got := runner.Matching(Capture, history.ClipboardHistory{Item: "text"}, "text"); len(got) != 1 || got[0].Command[0] != "notify-send" {
+		t.Errorf("Matching(capture, text) = %v, want the catch-all hook", got)
+	}
+	item.Sensitive = "jwt"
+	if got := runner.Matching(Capture, item, item.Item); len(got) != 0 {
+		t.Errorf("expected secrets to reach no hook, got %v", got)
+	}
+}
+
+func TestRunPipesMatchingTextToCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	script := `{ printf '%s:%s:%s:' "$CLIPPY_EVENT" "$CLIPPY_TYPE" "$CLIPPY_LENGTH"; cat; echo; } >> "$0"`
+	urls, err := New("capture", []string{"sh", "-c", script, out}, `^https?://`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copies, err := New("copy", []string{"sh", "-c", script, out}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewRunner([]Hook{urls, copies})
+
+	url := history.ClipboardHistory{Item: "https://example.com", Type: detect.URL}
+	runner.Run(Capture, url, url.Item)
+	runner.Wait()
+	runner.Run(Capture, history.ClipboardHistory{Item: "plain"}, "plain")
+	runner.Run(Capture, history.ClipboardHistory{Item: "secret", Sensitive: "jwt"}, "secret")
+	runner.Run(Copy, history.ClipboardHistory{Item: "hidden", Incognito: true}, "hidden")
+	runner.Wait()
+	runner.Run(Copy, url, url.Item)
+	runner.Wait()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "capture:url:19:https://example.com\ncopy:url:19:https://example.com\n"
+	if string(data) != want {
+		t.Errorf("hooks wrote %q, want %q", data, want)
+	}
+}
+
+func TestRunKillsHookAfterTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	orig := timeout
+	timeout = 50 * time.Millisecond
+	defer func() { timeout = orig }()
+
+	hook, err := New("copy", []string{"sh", "-c", "exec sleep 5"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hook.run(history.ClipboardHistory{}, "text")
+	if err == nil || !strings.Contains(err.Error(), "killed") {
+		t.Errorf("expected the hook to be killed, got %v", err)
+	}
+}
+
+func TestAttachRunsCaptureHooks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	hook, err := New("capture", []string{"sh", "-c", `cat >> "$0"`, out}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewRunner([]Hook{hook})
+	manager := history.NewInMemoryManager()
+	runner.Attach(manager)
+
+	manager.AddItem("first")
+	runner.Wait()
+	manager.AddItem("first")
+	runner.Wait()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first" {
+		t.Errorf("expected the hook to run once for the new entry, got %q", data)
+	}
+}
@@ -0,0 +1,203 @@
+// Package instance keeps a single clippy capturing the clipboard into a
+// history. The capturing process, the daemon or a TUI, holds an advisory
+// lock on a file naming its pid and role for as long as it runs, so the
+// lock is released by the operating system even when the process is
+// killed. Other processes only view what it records.
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileName is the lock file in the history's directory.
+const FileName = "capture.pid"
+
+// lockAttempts is how often Acquire tries to lock the file before
+// reporting it held, riding out a Holder briefly locking it to look.
+const lockAttempts = 3
+
+// retryDelay is how long to wait between attempts, and for the holder to
+// write its pid into a file it just locked.
+const retryDelay = 10 * time.Millisecond
+
+// ErrLost is returned by Refresh once the lock file was removed or
+// replaced, e.g. by hand, so another process may be capturing.
+var ErrLost = errors.New("capture lock file was removed or replaced")
+
+// Role is the kind of process holding the lock.
+type Role string
+
+const (
+	Daemon Role = "daemon"
+	TUI    Role = "tui"
+)
+
+// Owner is the process holding the lock.
+type Owner struct {
+	PID  int
+	Role Role
+}
+
+// HeldError is returned by Acquire while another process holds the lock.
+type HeldError struct {
+	Owner Owner
+}
+
+func (e *HeldError) Error() string {
+	if e.Owner.Role == Daemon {
+		return fmt.Sprintf("clippy daemon already capturing the clipboard (pid %d)", e.Owner.PID)
+	}
+	return fmt.Sprintf("clippy already capturing the clipboard in another terminal (pid %d); close it first", e.Owner.PID)
+}
+
+// Lock is the capture lock, held by this process.
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire takes the capture lock of the history in dir for role. It fails
+// with a HeldError while another process holds it; a file left by a
+// process that is gone is taken over.
+func Acquire(dir string, role Role) (*Lock, error) {
+	path := filepath.Join(dir, FileName)
+	for range lockAttempts {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("error opening capture lock: %w", err)
+		}
+		locked, err := tryLock(f, true)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error locking capture lock: %w", err)
+		}
+		if !locked {
+			f.Close()
+			time.Sleep(retryDelay)
+			continue
+		}
+		// The file may have been removed by its previous holder between
+		// opening and locking it, leaving us the lock of a file nobody sees
+		if !samePath(f, path) {
+			unlock(f)
+			f.Close()
+			continue
+		}
+		if err := writeOwner(f, role); err != nil {
+			unlock(f)
+			f.Close()
+			return nil, fmt.Errorf("error writing capture lock: %w", err)
+		}
+		return &Lock{path: path, file: f}, nil
+	}
+	if owner, ok := Holder(dir); ok {
+		return nil, &HeldError{Owner: owner}
+	}
+	return nil, fmt.Errorf("error locking capture lock: %s is busy", path)
+}
+
+// writeOwner replaces the content of the locked file f with this process's
+// pid and role
+func writeOwner(f *os.File, role Role) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(fmt.Sprintf("%d %s\n", os.Getpid(), role)), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// samePath reports whether path still names the open file f
+func samePath(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// Refresh checks the lock file is still the one this process locked and
+// touches it, showing when the holder last checked. It returns ErrLost
+// once the file was removed or replaced.
+func (l *Lock) Refresh(now time.Time) error {
+	if !samePath(l.file, l.path) {
+		return ErrLost
+	}
+	return os.Chtimes(l.path, now, now)
+}
+
+// Release gives up the lock, removing its file unless it was replaced by
+// another process's.
+func (l *Lock) Release() {
+	if samePath(l.file, l.path) {
+		if err := os.Remove(l.path); err != nil {
+			log.Printf("Failed to remove capture lock: %v", err)
+		}
+	}
+	if err := unlock(l.file); err != nil {
+		log.Printf("Failed to unlock capture lock: %v", err)
+	}
+	l.file.Close()
+}
+
+// Holder returns the process capturing into the history in dir, if any.
+func Holder(dir string) (Owner, bool) {
+	path := filepath.Join(dir, FileName)
+	f, err := os.Open(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to read capture lock: %v", err)
+		}
+		return Owner{}, false
+	}
+	defer f.Close()
+	free, err := tryLock(f, false)
+	if err != nil {
+		log.Printf("Failed to check capture lock: %v", err)
+		return Owner{}, false
+	}
+	if free {
+		// Left by a process that is gone
+		unlock(f)
+		return Owner{}, false
+	}
+	// A holder that just locked the file may not have written it yet
+	var owner Owner
+	for range lockAttempts {
+		if owner, err = readOwner(path); err == nil {
+			break
+		}
+		time.Sleep(retryDelay)
+	}
+	return owner, true
+}
+
+// readOwner parses the pid and role in the lock file at path
+func readOwner(path string) (Owner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Owner{}, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return Owner{}, fmt.Errorf("malformed capture lock %q", data)
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Owner{}, fmt.Errorf("malformed capture lock %q", data)
+	}
+	return Owner{PID: pid, Role: Role(fields[1])}, nil
+}
@@ -0,0 +1,107 @@
+package instance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAcquireIsExclusive(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir, TUI)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if owner, ok := Holder(dir); !ok || owner != (Owner{PID: os.Getpid(), Role: TUI}) {
+		t.Errorf("Holder = %+v, %v; want this TUI", owner, ok)
+	}
+
+	_, err = Acquire(dir, Daemon)
+	var held *HeldError
+	if !errors.As(err, &held) || held.Owner.Role != TUI {
+		t.Fatalf("second Acquire = %v, want a HeldError naming the TUI", err)
+	}
+
+	lock.Release()
+	if _, ok := Holder(dir); ok {
+		t.Error("expected no holder once released")
+	}
+	lock, err = Acquire(dir, Daemon)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireTakesOverAbandonedLock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	// Left by a killed process, whose lock went with it
+	if err := os.WriteFile(path, []byte(strconv.Itoa(12345)+" daemon\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Holder(dir); ok {
+		t.Error("expected an unlocked file to be ignored")
+	}
+	lock, err := Acquire(dir, TUI)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer lock.Release()
+	if err := lock.Refresh(time.Now()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if owner, ok := Holder(dir); !ok || owner != (Owner{PID: os.Getpid(), Role: TUI}) {
+		t.Errorf("Holder = %+v, %v; want this TUI", owner, ok)
+	}
+}
+
+func TestAcquireRefusesLockNotYetWritten(t *testing.T) {
+	dir := t.TempDir()
+	// A rival that locked the file but hasn't written its pid yet
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if ok, err := tryLock(f, true); !ok || err != nil {
+		t.Fatalf("tryLock = %v, %v", ok, err)
+	}
+
+	if _, ok := Holder(dir); !ok {
+		t.Error("expected an empty locked file to be held")
+	}
+	_, err = Acquire(dir, Daemon)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Acquire = %v, want a HeldError", err)
+	}
+}
+
+func TestReleaseKeepsReplacedLock(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := Acquire(dir, TUI)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, FileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Refresh(time.Now()); !errors.Is(err, ErrLost) {
+		t.Errorf("Refresh = %v, want ErrLost", err)
+	}
+
+	other, err := Acquire(dir, Daemon)
+	if err != nil {
+		t.Fatalf("Acquire of the replaced file: %v", err)
+	}
+	defer other.Release()
+	lock.Release()
+	if owner, ok := Holder(dir); !ok || owner.Role != Daemon {
+		t.Errorf("Holder = %+v, %v; want the new owner's lock kept", owner, ok)
+	}
+}
@@ -0,0 +1,51 @@
+package instance
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errLockViolation        = syscall.Errno(33)
+)
+
+// lockOffset is the byte locked, far past the pid and role, since Windows
+// refuses to let other processes read a locked range.
+const lockOffset = 1 << 30
+
+// tryLock takes an exclusive or shared lock on f without waiting,
+// reporting false while another open file holds a conflicting lock.
+func tryLock(f *os.File, exclusive bool) (bool, error) {
+	flags := uintptr(lockfileFailImmediately)
+	if exclusive {
+		flags |= lockfileExclusiveLock
+	}
+	ol := syscall.Overlapped{Offset: lockOffset}
+	r, _, err := procLockFileEx.Call(f.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r != 0 {
+		return true, nil
+	}
+	if err == errLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+// unlock releases the lock tryLock took on f.
+func unlock(f *os.File) error {
+	ol := syscall.Overlapped{Offset: lockOffset}
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
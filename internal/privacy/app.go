@@ -0,0 +1,165 @@
+package privacy
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// ActiveApp returns the names of the focused application, e.g. its window
+// class and instance name, most descriptive first, or nil when they can't
+// be determined. This is the best available guess at which app copied to
+// the clipboard: X11, Hyprland, Sway, niri and macOS are supported; other
+// Wayland compositors don't expose it.
+func ActiveApp() []string {
+	switch goos {
+	case "darwin":
+		out, err := run("osascript", "-e",
+			`tell application "System Events" to get name of first application process whose frontmost is true`)
+		if err != nil {
+			return nil
+		}
+		return nonEmpty(strings.TrimSpace(string(out)))
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" && available("hyprctl") {
+			return hyprlandApp()
+		}
+		if getenv("SWAYSOCK") != "" && available("swaymsg") {
+			return swayApp()
+		}
+		if getenv("NIRI_SOCKET") != "" && available("niri") {
+			return niriApp()
+		}
+		if getenv("DISPLAY") != "" && available("xprop") {
+			return x11App()
+		}
+	}
+	return nil
+}
+
+// SourceApp names the focused application, e.g. "firefox", to record
+// where a copy came from, or returns "" when it can't be determined.
+func SourceApp() string {
+	names := ActiveApp()
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// hyprlandApp reads the focused window's class from hyprctl
+func hyprlandApp() []string {
+	out, err := run("hyprctl", "activewindow", "-j")
+	if err != nil {
+		return nil
+	}
+	var window struct {
+		Class        string `json:"class"`
+		InitialClass string `json:"initialClass"`
+	}
+	if err := json.Unmarshal(out, &window); err != nil {
+		return nil
+	}
+	return nonEmpty(window.Class, window.InitialClass)
+}
+
+var (
+	activeWindowID = regexp.MustCompile(`window id # (0x[0-9a-fA-F]+)`)
+	quoted         = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// swayNode is a container in the tree printed by swaymsg -t get_tree
+type swayNode struct {
+	Focused          bool   `json:"focused"`
+	AppID            string `json:"app_id"`
+	WindowProperties struct {
+		Class    string `json:"class"`
+		Instance string `json:"instance"`
+	} `json:"window_properties"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+// focused returns the focused node in the tree under n, or nil
+func (n *swayNode) focused() *swayNode {
+	if n.Focused {
+		return n
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if found := children[i].focused(); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// swayApp reads the focused window's app id from the Sway tree, or the
+// window class of an XWayland window
+func swayApp() []string {
+	out, err := run("swaymsg", "-t", "get_tree")
+	if err != nil {
+		return nil
+	}
+	var tree swayNode
+	if err := json.Unmarshal(out, &tree); err != nil {
+		return nil
+	}
+	window := tree.focused()
+	if window == nil {
+		return nil
+	}
+	return nonEmpty(window.AppID, window.WindowProperties.Class, window.WindowProperties.Instance)
+}
+
+// niriApp reads the focused window's app id from niri
+func niriApp() []string {
+	out, err := run("niri", "msg", "--json", "focused-window")
+	if err != nil {
+		return nil
+	}
+	var window struct {
+		AppID string `json:"app_id"`
+	}
+	// No focused window prints null, leaving AppID empty
+	if err := json.Unmarshal(out, &window); err != nil {
+		return nil
+	}
+	return nonEmpty(window.AppID)
+}
+
+// x11App reads the WM_CLASS class and instance names of the active window.
+// The class comes first: it names the application, e.g. "firefox" where the
+// instance is "Navigator".
+func x11App() []string {
+	out, err := run("xprop", "-root", "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return nil
+	}
+	match := activeWindowID.FindSubmatch(out)
+	if match == nil {
+		return nil
+	}
+	out, err = run("xprop", "-id", string(match[1]), "WM_CLASS")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, m := range quoted.FindAllSubmatch(out, -1) {
+		// WM_CLASS lists the instance, then the class
+		names = append([]string{string(m[1])}, names...)
+	}
+	return nonEmpty(names...)
+}
+
+// nonEmpty returns names without empty strings, or nil if none remain
+func nonEmpty(names ...string) []string {
+	var result []string
+	for _, name := range names {
+		if name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
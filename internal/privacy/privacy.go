@@ -0,0 +1,128 @@
+// Package privacy decides whether clipboard content may be recorded.
+// Password managers mark the secrets they copy with a "concealed" clipboard
+// type (x-kde-passwordManagerHint on Linux, org.nspasteboard.ConcealedType on
+// macOS), and some applications should never be recorded at all.
+package privacy
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// concealedTypes are clipboard types set by apps asking clipboard managers
+// not to record the content
+var concealedTypes = []string{
+	"x-kde-passwordManagerHint",
+	"org.nspasteboard.ConcealedType",
+	"org.nspasteboard.TransientType",
+	"ExcludeClipboardContentFromMonitorProcessing",
+}
+
+// Overridable for tests.
+var (
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+	lookPath = exec.LookPath
+	run      = func(name string, args ...string) ([]byte, error) {
+		return exec.Command(name, args...).Output()
+	}
+)
+
+// Guard filters clipboard content before it is recorded.
+type Guard struct {
+	respectHints bool
+	excluded     []string // lower-cased application names
+}
+
+// NewGuard returns a guard that, with respectHints, refuses content marked
+// concealed and refuses anything copied while one of excludedApps is the
+// focused application. Apps match case-insensitively on their name or
+// window class.
+func NewGuard(respectHints bool, excludedApps []string) *Guard {
+	g := &Guard{respectHints: respectHints}
+	g.SetExcludedApps(excludedApps)
+	return g
+}
+
+// SetExcludedApps replaces the applications nothing is recorded from.
+func (g *Guard) SetExcludedApps(apps []string) {
+	g.excluded = nil
+	for _, app := range apps {
+		if app = strings.TrimSpace(app); app != "" {
+			g.excluded = append(g.excluded, strings.ToLower(app))
+		}
+	}
+}
+
+// Excludes reports whether nothing copied while app is focused is recorded.
+func (g *Guard) Excludes(app string) bool {
+	return slices.Contains(g.excluded, strings.ToLower(strings.TrimSpace(app)))
+}
+
+// Allow reports whether the content now on the clipboard may be recorded.
+// It is called once per change, since it runs the platform clipboard tools.
+func (g *Guard) Allow() bool {
+	if g.respectHints && Concealed() {
+		return false
+	}
+	if len(g.excluded) == 0 {
+		return true
+	}
+	for _, name := range ActiveApp() {
+		for _, app := range g.excluded {
+			if strings.ToLower(name) == app {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Concealed reports whether the clipboard content is marked as a secret
+// that clipboard managers should not record.
+func Concealed() bool {
+	for _, t := range clipboardTypes() {
+		for _, concealed := range concealedTypes {
+			if strings.EqualFold(t, concealed) || strings.HasSuffix(t, "/"+concealed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clipboardTypes lists the types offered for the current clipboard content,
+// or nil when they can't be read
+func clipboardTypes() []string {
+	var out []byte
+	var err error
+	switch goos {
+	case "darwin":
+		out, err = run("osascript", "-l", "JavaScript", "-e",
+			`ObjC.import("AppKit"); $.NSPasteboard.generalPasteboard.types.js.map(t => t.js).join("\n")`)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("WAYLAND_DISPLAY") != "" && available("wl-paste") {
+			out, err = run("wl-paste", "--list-types")
+		} else if getenv("DISPLAY") != "" && available("xclip") {
+			out, err = run("xclip", "-selection", "clipboard", "-t", "TARGETS", "-o")
+		} else {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(bytes.TrimSpace(out)))
+}
+
+// available reports whether the named command is installed
+func available(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
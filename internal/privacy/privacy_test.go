@@ -0,0 +1,167 @@
+package privacy
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// useTools stubs the platform and environment, answering commands from
+// outputs keyed by the command line. Commands not listed fail.
+func useTools(t *testing.T, os string, env map[string]string, outputs map[string]string) {
+	t.Helper()
+	origGOOS, origGetenv, origLookPath, origRun := goos, getenv, lookPath, run
+	t.Cleanup(func() { goos, getenv, lookPath, run = origGOOS, origGetenv, origLookPath, origRun })
+	goos = os
+	getenv = func(key string) string { return env[key] }
+	lookPath = func(file string) (string, error) {
+		for cmd := range outputs {
+			if strings.HasPrefix(cmd, file+" ") {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	run = func(name string, args ...string) ([]byte, error) {
+		if out, ok := outputs[name+" "+strings.Join(args, " ")]; ok {
+			return []byte(out), nil
+		}
+		return nil, errors.New("exit status 1")
+	}
+}
+
+const (
+	wlTypes    = "wl-paste --list-types"
+	xclipTypes = "xclip -selection clipboard -t TARGETS -o"
+	xpropRoot  = "xprop -root _NET_ACTIVE_WINDOW"
+	xpropClass = "xprop -id 0x3a00007 WM_CLASS"
+)
+
+func TestConcealed(t *testing.T) {
+	tests := []struct {
+		name    string
+		os      string
+		env     map[string]string
+		outputs map[string]string
+		want    bool
+	}{
+		{"wayland secret", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"},
+			map[string]string{wlTypes: "text/plain\nx-kde-passwordManagerHint\nUTF8_STRING\n"}, true},
+		{"wayland plain", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"},
+			map[string]string{wlTypes: "text/plain\nUTF8_STRING\n"}, false},
+		{"x11 secret", "linux", map[string]string{"DISPLAY": ":0"},
+			map[string]string{xclipTypes: "TARGETS\nUTF8_STRING\napplication/x-kde-passwordManagerHint\n"}, true},
+		{"no tools", "linux", map[string]string{"DISPLAY": ":0"}, nil, false},
+		{"unsupported", "windows", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTools(t, tt.os, tt.env, tt.outputs)
+			if got := Concealed(); got != tt.want {
+				t.Errorf("Concealed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveApp(t *testing.T) {
+	tests := []struct {
+		name    string
+		os      string
+		env     map[string]string
+		outputs map[string]string
+		want    []string
+	}{
+		{"x11", "linux", map[string]string{"DISPLAY": ":0"}, map[string]string{
+			xpropRoot:  "_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007\n",
+			xpropClass: `WM_CLASS(STRING) = "keepassxc", "KeePassXC"` + "\n",
+		}, []string{"KeePassXC", "keepassxc"}},
+		{"hyprland", "linux", map[string]string{"HYPRLAND_INSTANCE_SIGNATURE": "abc", "DISPLAY": ":0"}, map[string]string{
+			"hyprctl activewindow -j": `{"class": "Bitwarden", "initialClass": "bitwarden"}`,
+		}, []string{"Bitwarden", "bitwarden"}},
+		{"sway", "linux", map[string]string{"SWAYSOCK": "/run/sway.sock"}, map[string]string{
+			"swaymsg -t get_tree": `{"nodes": [{"nodes": [{"app_id": "kitty"}, {"focused": true, "app_id": "firefox"}]}]}`,
+		}, []string{"firefox"}},
+		{"sway xwayland", "linux", map[string]string{"SWAYSOCK": "/run/sway.sock"}, map[string]string{
+			"swaymsg -t get_tree": `{"floating_nodes": [{"focused": true, "app_id": null, "window_properties": {"class": "Gimp", "instance": "gimp"}}]}`,
+		}, []string{"Gimp", "gimp"}},
+		{"niri", "linux", map[string]string{"NIRI_SOCKET": "/run/niri.sock"}, map[string]string{
+			"niri msg --json focused-window": `{"id": 3, "title": "Inbox", "app_id": "thunderbird"}`,
+		}, []string{"thunderbird"}},
+		{"niri without focus", "linux", map[string]string{"NIRI_SOCKET": "/run/niri.sock"}, map[string]string{
+			"niri msg --json focused-window": "null\n",
+		}, nil},
+		{"no active window", "linux", map[string]string{"DISPLAY": ":0"}, map[string]string{
+			xpropRoot: "_NET_ACTIVE_WINDOW: not found.\n",
+		}, nil},
+		{"unsupported", "windows", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTools(t, tt.os, tt.env, tt.outputs)
+			if got := ActiveApp(); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ActiveApp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardAllow(t *testing.T) {
+	x11 := map[string]string{"DISPLAY": ":0"}
+	secret := map[string]string{
+		xclipTypes: "UTF8_STRING\nx-kde-passwordManagerHint\n",
+		xpropRoot:  "_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007\n",
+		xpropClass: `WM_CLASS(STRING) = "firefox", "firefox"`,
+	}
+	fromKeePass := map[string]string{
+		xclipTypes: "UTF8_STRING\n",
+		xpropRoot:  "_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007\n",
+		xpropClass: `WM_CLASS(STRING) = "keepassxc", "KeePassXC"`,
+	}
+
+	tests := []struct {
+		name    string
+		guard   *Guard
+		outputs map[string]string
+		want    bool
+	}{
+		{"concealed", NewGuard(true, nil), secret, false},
+		{"hints ignored", NewGuard(false, nil), secret, true},
+		{"excluded app", NewGuard(true, []string{" KEEPASSXC "}), fromKeePass, false},
+		{"other app", NewGuard(true, []string{"Bitwarden"}), fromKeePass, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTools(t, "linux", x11, tt.outputs)
+			if got := tt.guard.Allow(); got != tt.want {
+				t.Errorf("Allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardExcludes(t *testing.T) {
+	guard := NewGuard(true, []string{" KeePassXC ", "bitwarden"})
+	if !guard.Excludes("keepassxc") || !guard.Excludes("Bitwarden") {
+		t.Error("expected the excluded apps to match ignoring case")
+	}
+	if guard.Excludes("firefox") || guard.Excludes("") {
+		t.Error("expected other apps not to be excluded")
+	}
+}
+
+func TestSourceApp(t *testing.T) {
+	useTools(t, "linux", map[string]string{"DISPLAY": ":0"}, map[string]string{
+		xpropRoot:  "_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007\n",
+		xpropClass: `WM_CLASS(STRING) = "Navigator", "firefox"` + "\n",
+	})
+	if got := SourceApp(); got != "firefox" {
+		t.Errorf("SourceApp() = %q, want the window class", got)
+	}
+
+	useTools(t, "windows", nil, nil)
+	if got := SourceApp(); got != "" {
+		t.Errorf("SourceApp() = %q, want none", got)
+	}
+}
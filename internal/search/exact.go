@@ -0,0 +1,27 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// ExactMatcher matches items containing the query as typed, ignoring case.
+// Every match scores the same, so results keep their history order, newest
+// first.
+type ExactMatcher struct{}
+
+// NewExactMatcher creates a new exact substring matcher
+func NewExactMatcher() *ExactMatcher {
+	return &ExactMatcher{}
+}
+
+// Search returns the items containing the query
+func (em *ExactMatcher) Search(items []history.ClipboardHistory, query string) []history.ClipboardHistory {
+	return rank(items, query, func(text, query string) int {
+		if strings.Contains(strings.ToLower(text), query) {
+			return 1
+		}
+		return 0
+	})
+}
@@ -1,7 +1,7 @@
 package search
 
 import (
-	"strings"
+	"unicode"
 
 	"github.com/bvdwalt/clippy/internal/history"
 )
@@ -20,35 +20,41 @@ type ScoredItem struct {
 	Score int
 }
 
-// Search performs fuzzy search on clipboard history items
+// Search performs fuzzy search on clipboard history items. The query may
+// contain "type:<name>" filters; with only filters, matching items are
+// returned in their original order.
 func (f *FuzzyMatcher) Search(items []history.ClipboardHistory, query string) []history.ClipboardHistory {
-	if query == "" {
-		return nil
-	}
-
-	query = strings.ToLower(query)
-
-	var matches []ScoredItem
-
-	for _, item := range items {
-		score := f.fuzzyMatch(strings.ToLower(item.Item), query)
-		if score > 0 {
-			matches = append(matches, ScoredItem{Item: item, Score: score})
-		}
-	}
-
-	f.sortByScore(matches)
-
-	result := make([]history.ClipboardHistory, len(matches))
-	for i, match := range matches {
-		result[i] = match.Item
-	}
-
-	return result
+	return rank(items, query, f.fuzzyMatch)
 }
 
-// fuzzyMatch implements fuzzy matching similar to fzf
-// Returns a score > 0 if the query matches, 0 if no match
+// Scoring weights for fuzzyMatch. Every matched query character earns
+// scoreMatch plus any bonuses; gaps between matched characters cost
+// penaltyGapStart for the first skipped character and penaltyGapExtend for
+// each further one.
+const (
+	scoreMatch        = 16
+	bonusConsecutive  = 8
+	bonusWordBoundary = 12
+	bonusCamelCase    = 10
+	bonusStartOfText  = 8
+	penaltyGapStart   = 3
+	penaltyGapExtend  = 1
+	minimumMatchScore = 1
+)
+
+// maxWindows bounds how many candidate windows fuzzyMatch scores per item,
+// keeping pathological inputs (e.g. long runs of one letter) linear.
+const maxWindows = 32
+
+// fuzzyMatch implements fuzzy matching similar to fzf, ignoring case. text
+// keeps its original case so camelCase humps can be detected. Returns a
+// score > 0 if the query matches, 0 if no match.
+//
+// The score depends only on the matched window, never on the total length
+// of text. Candidate windows are found fzf-style (a forward scan for the
+// earliest end, then a backward scan for the latest start), each is scored
+// by scoreWindow, and the best one wins. A long entry with a compact match
+// therefore ranks alongside a short entry with the same match.
 func (f *FuzzyMatcher) fuzzyMatch(text, query string) int {
 	if len(query) == 0 {
 		return 1
@@ -57,79 +63,97 @@ func (f *FuzzyMatcher) fuzzyMatch(text, query string) int {
 		return 0
 	}
 
-	score := 0
-	textIdx := 0
-	queryIdx := 0
-	lastMatchIdx := -1
-	consecutiveMatches := 0
-
-	for queryIdx < len(query) && textIdx < len(text) {
-		queryChar := query[queryIdx]
-
-		found := false
-		for textIdx < len(text) {
-			if text[textIdx] == queryChar {
-				found = true
-
-				positionScore := len(text) - textIdx
-
-				if textIdx == lastMatchIdx+1 {
-					consecutiveMatches++
-					positionScore += consecutiveMatches * 10
-				} else {
-					consecutiveMatches = 0
-				}
-
-				if textIdx == 0 || isWordBoundary(text[textIdx-1]) {
-					positionScore += 15
-				}
-
-				if textIdx > 0 && isLower(rune(text[textIdx-1])) && isUpper(rune(text[textIdx])) {
-					positionScore += 10
-				}
-
-				score += positionScore
-				lastMatchIdx = textIdx
-				queryIdx++
-				textIdx++
-				break
-			}
-			textIdx++
-		}
+	// Compare rune by rune so letters beyond ASCII match in either case
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	needle := []rune(query)
+	for i, r := range needle {
+		needle[i] = unicode.ToLower(r)
+	}
 
-		if !found {
-			return 0
+	best := 0
+	from := 0
+	for range maxWindows {
+		start, end, ok := findWindow(lower, needle, from)
+		if !ok {
+			break
 		}
+		best = max(best, scoreWindow(runes, lower, needle, start, end))
+		from = start + 1
 	}
+	return best
+}
 
-	if queryIdx == len(query) {
-		if len(text) < 50 {
-			score += (50 - len(text)) * 2
+// findWindow returns the tightest window lower[start:end+1] at or after
+// from that contains query as a subsequence.
+func findWindow(lower, query []rune, from int) (start, end int, ok bool) {
+	// Forward pass: earliest position where the whole query has matched
+	end = -1
+	queryIdx := 0
+	for textIdx := from; textIdx < len(lower) && queryIdx < len(query); textIdx++ {
+		if lower[textIdx] == query[queryIdx] {
+			queryIdx++
+			end = textIdx
 		}
-		return score
+	}
+	if queryIdx < len(query) {
+		return 0, 0, false
 	}
 
-	return 0
+	// Backward pass: latest start that still contains the query before end
+	start = end
+	queryIdx = len(query) - 1
+	for textIdx := end; textIdx >= from && queryIdx >= 0; textIdx-- {
+		if lower[textIdx] == query[queryIdx] {
+			queryIdx--
+			start = textIdx
+		}
+	}
+	return start, end, true
 }
 
-func (f *FuzzyMatcher) sortByScore(matches []ScoredItem) {
-	for i := 0; i < len(matches)-1; i++ {
-		for j := i + 1; j < len(matches); j++ {
-			if matches[j].Score > matches[i].Score {
-				matches[i], matches[j] = matches[j], matches[i]
+// scoreWindow scores the query's characters matched within
+// text[start:end+1], whose lowercase runes are lower, and scales the total
+// by match density (query length over window length), so sparse matches
+// rank below compact ones.
+func scoreWindow(text, lower, query []rune, start, end int) int {
+	score := 0
+	lastMatchIdx := -1
+	queryIdx := 0
+	for textIdx := start; textIdx <= end && queryIdx < len(query); textIdx++ {
+		if lower[textIdx] != query[queryIdx] {
+			continue
+		}
+
+		points := scoreMatch
+		if lastMatchIdx >= 0 {
+			if gap := textIdx - lastMatchIdx - 1; gap > 0 {
+				points -= penaltyGapStart + (gap-1)*penaltyGapExtend
+			} else {
+				points += bonusConsecutive
 			}
 		}
-	}
-}
+		if textIdx == 0 {
+			points += bonusStartOfText
+		}
+		if textIdx == 0 || isWordBoundary(text[textIdx-1]) {
+			points += bonusWordBoundary
+		} else if unicode.IsLower(text[textIdx-1]) && unicode.IsUpper(text[textIdx]) {
+			points += bonusCamelCase
+		}
 
-func isWordBoundary(c byte) bool {
-	return c == ' ' || c == '-' || c == '_' || c == '.' || c == '/' || c == '\\'
-}
+		score += points
+		lastMatchIdx = textIdx
+		queryIdx++
+	}
 
-func isLower(r rune) bool {
-	return r >= 'a' && r <= 'z'
+	span := end - start + 1
+	return max(score*len(query)/span, minimumMatchScore)
 }
 
-func isUpper(r rune) bool {
-	return r >= 'A' && r <= 'Z'
+func isWordBoundary(r rune) bool {
+	return r == ' ' || r == '-' || r == '_' || r == '.' || r == '/' || r == '\\'
 }
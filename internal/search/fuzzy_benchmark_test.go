@@ -3,6 +3,7 @@ package search
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -115,34 +116,6 @@ func BenchmarkFuzzyMatcher_FuzzyMatch(b *testing.B) {
 	}
 }
 
-func BenchmarkFuzzyMatcher_SortByScore(b *testing.B) {
-	matcher := NewFuzzyMatcher()
-
-	// Create scored items with different sizes
-	benchmarks := []struct {
-		name  string
-		items int
-	}{
-		{"Small_10", 10},
-		{"Medium_100", 100},
-		{"Large_1000", 1000},
-	}
-
-	for _, bm := range benchmarks {
-		b.Run(bm.name, func(b *testing.B) {
-			scoredItems := generateScoredItems(bm.items)
-
-			b.ResetTimer()
-			for i := 0; i < b.N; i++ {
-				// Create a copy for each iteration since sorting modifies the slice
-				items := make([]ScoredItem, len(scoredItems))
-				copy(items, scoredItems)
-				matcher.sortByScore(items)
-			}
-		})
-	}
-}
-
 // Helper functions for benchmarks
 
 func generateTestItems(count int) []history.ClipboardHistory {
@@ -224,20 +197,15 @@ func generateTestItemsWithMatchRatio(count int, matchWord string, matchRatio flo
 	return items
 }
 
-func generateScoredItems(count int) []ScoredItem {
-	items := make([]ScoredItem, count)
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+func BenchmarkFuzzyMatcher_FuzzyMatch_TextLength(b *testing.B) {
+	matcher := NewFuzzyMatcher()
 
-	for i := 0; i < count; i++ {
-		items[i] = ScoredItem{
-			Item: history.ClipboardHistory{
-				Item:      fmt.Sprintf("item %d", i),
-				Hash:      fmt.Sprintf("hash%d", i),
-				TimeStamp: time.Now(),
-			},
-			Score: rng.Intn(1000), // Random score 0-999
-		}
+	for _, n := range []int{10, 100, 1000, 10000} {
+		text := strings.Repeat("lorem ipsum ", n) + "deploy script"
+		b.Run(fmt.Sprintf("Filler_%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = matcher.fuzzyMatch(text, "deploy")
+			}
+		})
 	}
-
-	return items
 }
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func TestFuzzyMatch_IndependentOfTextLength(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+	short := "kubectl get pods"
+
+	base := matcher.fuzzyMatch(short, "kubectl")
+	for _, n := range []int{10, 100, 10000} {
+		long := short + strings.Repeat(" filler", n)
+		if got := matcher.fuzzyMatch(long, "kubectl"); got != base {
+			t.Errorf("score with %d filler words = %d, want %d", n, got, base)
+		}
+	}
+}
+
+func TestFuzzyMatch_DensityBeatsLength(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+
+	// A compact match in a long entry beats a scattered match in a short one
+	dense := matcher.fuzzyMatch("notes: "+strings.Repeat("lorem ipsum ", 40)+"deploy script", "deploy")
+	sparse := matcher.fuzzyMatch("d e p l o y", "deploy")
+	if dense <= sparse {
+		t.Errorf("dense score %d should exceed sparse score %d", dense, sparse)
+	}
+}
+
+func TestFuzzyMatch_FindsTightestWindow(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+
+	// Greedy left-to-right matching would start at the first 't'; the
+	// backward pass should settle on the compact "test" at the end
+	scattered := matcher.fuzzyMatch("t... e... s... t... test", "test")
+	compact := matcher.fuzzyMatch("xxxxxxxxxxxxxxxxxxxx test", "test")
+	if scattered != compact {
+		t.Errorf("scattered prefix changed score: %d vs %d", scattered, compact)
+	}
+}
+
+func TestFuzzyMatch_Bonuses(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+
+	tests := []struct {
+		name          string
+		better, worse string
+		query         string
+	}{
+		{"consecutive", "abcdef", "axbxcx", "abc"},
+		{"word boundary", "some-test-data", "contest result", "test"},
+		{"camel case", "myTestFunction", "mytestfunction", "test"},
+		{"start of text", "test example", "an test example", "test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, w := matcher.fuzzyMatch(tt.better, tt.query), matcher.fuzzyMatch(tt.worse, tt.query)
+			if b <= w {
+				t.Errorf("%q scored %d, not above %q (%d)", tt.better, b, tt.worse, w)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatcher_Search_StableAcrossLengths(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "ssh deploy@prod " + strings.Repeat("-v ", 200), Hash: "long"},
+		{Item: "ssh deploy@prod", Hash: "short"},
+		{Item: "s s h", Hash: "sparse"},
+	}
+
+	result := matcher.Search(items, "ssh")
+	if len(result) != 3 {
+		t.Fatalf("expected 3 matches, got %d", len(result))
+	}
+	// Equal-quality matches keep history order regardless of length
+	if result[0].Hash != "long" || result[1].Hash != "short" || result[2].Hash != "sparse" {
+		t.Errorf("unexpected order: %s, %s, %s", result[0].Hash, result[1].Hash, result[2].Hash)
+	}
+}
@@ -88,6 +88,26 @@ func TestFuzzyMatcher_Search_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestFuzzyMatcher_Search_CaseInsensitiveBeyondASCII(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "ÉCOLE NORMALE", Hash: "hash1", TimeStamp: time.Now()},
+		{Item: "Straße", Hash: "hash2", TimeStamp: time.Now()},
+		{Item: "ΑΘΗΝΑ", Hash: "hash3", TimeStamp: time.Now()},
+	}
+	for query, want := range map[string]string{
+		"école":  "ÉCOLE NORMALE",
+		"ÉcN":    "ÉCOLE NORMALE",
+		"STRAßE": "Straße",
+		"αθηνα":  "ΑΘΗΝΑ",
+	} {
+		result := matcher.Search(items, query)
+		if len(result) != 1 || result[0].Item != want {
+			t.Errorf("Search(%q) = %v, want only %q", query, result, want)
+		}
+	}
+}
+
 func TestFuzzyMatcher_Search_SubsequenceMatch(t *testing.T) {
 	matcher := NewFuzzyMatcher()
 	items := []history.ClipboardHistory{
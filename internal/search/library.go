@@ -0,0 +1,42 @@
+package search
+
+import (
+	"sync"
+
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/junegunn/fzf/src/algo"
+	"github.com/junegunn/fzf/src/util"
+)
+
+// Sizes of the scratch space fzf's matcher reuses between items, as fzf
+// itself allocates it. Items too long to score in it are matched with
+// fzf's faster, greedy FuzzyMatchV1 instead, as fzf does.
+const (
+	slab16Size = 100 * 1024
+	slab32Size = 2048
+)
+
+// initAlgo sets up fzf's scoring tables, once for every LibraryMatcher
+var initAlgo = sync.OnceFunc(func() { algo.Init("default") })
+
+// LibraryMatcher delegates scoring to fzf's own algorithm, FuzzyMatchV2
+// from github.com/junegunn/fzf/src/algo, so entries rank as fzf ranks them.
+type LibraryMatcher struct{}
+
+// NewLibraryMatcher creates a new library-backed matcher
+func NewLibraryMatcher() *LibraryMatcher {
+	initAlgo()
+	return &LibraryMatcher{}
+}
+
+// Search performs fuzzy search on clipboard history items using fzf's
+// algorithm, ignoring case and accents
+func (l *LibraryMatcher) Search(items []history.ClipboardHistory, query string) []history.ClipboardHistory {
+	// Each search has its own scratch space, so searches may overlap
+	slab := util.MakeSlab(slab16Size, slab32Size)
+	return rank(items, query, func(text, query string) int {
+		chars := util.ToChars([]byte(text))
+		result, _ := algo.FuzzyMatchV2(false, true, true, &chars, algo.NormalizeRunes([]rune(query)), false, slab)
+		return result.Score
+	})
+}
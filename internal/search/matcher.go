@@ -0,0 +1,97 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// Matcher ranks clipboard history items against a search query. Queries may
+// contain "type:<name>" filters (see ParseQuery); Search returns nil for an
+// empty query and otherwise the matching items, best match first.
+type Matcher interface {
+	Search(items []history.ClipboardHistory, query string) []history.ClipboardHistory
+}
+
+// Names of the available matching algorithms, as used in config.
+const (
+	AlgorithmFuzzy         = "fuzzy"
+	AlgorithmSmithWaterman = "smith-waterman"
+	AlgorithmTrigram       = "trigram"
+	AlgorithmLibrary       = "library"
+	AlgorithmExact         = "exact"
+)
+
+// Algorithms lists every algorithm name accepted by NewMatcher.
+var Algorithms = []string{AlgorithmFuzzy, AlgorithmSmithWaterman, AlgorithmTrigram, AlgorithmLibrary, AlgorithmExact}
+
+// NewMatcher returns the matcher for the named algorithm. An empty name
+// selects the default fuzzy matcher.
+func NewMatcher(algorithm string) (Matcher, error) {
+	switch strings.ToLower(algorithm) {
+	case "", AlgorithmFuzzy:
+		return NewFuzzyMatcher(), nil
+	case AlgorithmSmithWaterman:
+		return NewSmithWatermanMatcher(), nil
+	case AlgorithmTrigram:
+		return NewTrigramMatcher(), nil
+	case AlgorithmLibrary:
+		return NewLibraryMatcher(), nil
+	case AlgorithmExact:
+		return NewExactMatcher(), nil
+	default:
+		return nil, fmt.Errorf("unknown search algorithm %q (choose from %s)", algorithm, strings.Join(Algorithms, ", "))
+	}
+}
+
+// rank applies the query's filters, scores the remaining items with score
+// (given the item's original text and the lowercased query; 0 means no
+// match) and returns the matches best first. With only filters, matching
+// items keep their original order.
+func rank(items []history.ClipboardHistory, query string, score func(text, query string) int) []history.ClipboardHistory {
+	q := ParseQuery(query)
+	if q.IsEmpty() {
+		return nil
+	}
+
+	if q.Text == "" {
+		return filterOnly(items, q)
+	}
+
+	text := strings.ToLower(q.Text)
+	var matches []ScoredItem
+	for _, item := range items {
+		if !q.MatchesFilters(item) {
+			continue
+		}
+		if s := score(item.Item, text); s > 0 {
+			matches = append(matches, ScoredItem{Item: item, Score: s})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return unwrap(matches)
+}
+
+// filterOnly returns the items passing q's filters, in their original order.
+func filterOnly(items []history.ClipboardHistory, q Query) []history.ClipboardHistory {
+	result := make([]history.ClipboardHistory, 0)
+	for _, item := range items {
+		if q.MatchesFilters(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func unwrap(matches []ScoredItem) []history.ClipboardHistory {
+	result := make([]history.ClipboardHistory, len(matches))
+	for i, match := range matches {
+		result[i] = match.Item
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkMatchers compares every algorithm on the same data sets.
+func BenchmarkMatchers(b *testing.B) {
+	sizes := []int{100, 1000, 10000}
+	queries := []string{"test", "long_test_query"}
+
+	for _, name := range Algorithms {
+		matcher, err := NewMatcher(name)
+		if err != nil {
+			b.Fatalf("NewMatcher(%q): %v", name, err)
+		}
+		for _, size := range sizes {
+			items := generateTestItems(size)
+			for _, query := range queries {
+				b.Run(fmt.Sprintf("%s/items_%d/query_%s", name, size, query), func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						_ = matcher.Search(items, query)
+					}
+				})
+			}
+		}
+	}
+}
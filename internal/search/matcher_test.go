@@ -0,0 +1,135 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func allMatchers(t *testing.T) map[string]Matcher {
+	t.Helper()
+	matchers := make(map[string]Matcher, len(Algorithms))
+	for _, name := range Algorithms {
+		m, err := NewMatcher(name)
+		if err != nil {
+			t.Fatalf("NewMatcher(%q): %v", name, err)
+		}
+		matchers[name] = m
+	}
+	return matchers
+}
+
+func TestNewMatcher(t *testing.T) {
+	if m, err := NewMatcher(""); err != nil {
+		t.Errorf("NewMatcher(\"\") error: %v", err)
+	} else if _, ok := m.(*FuzzyMatcher); !ok {
+		t.Errorf("expected default matcher to be *FuzzyMatcher, got %T", m)
+	}
+
+	if _, err := NewMatcher("Smith-Waterman"); err != nil {
+		t.Errorf("expected algorithm names to be case-insensitive: %v", err)
+	}
+
+	if _, err := NewMatcher("bogus"); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
+
+func TestMatchers_CommonBehaviour(t *testing.T) {
+	items := []history.ClipboardHistory{
+		{Item: "kubectl get pods", Hash: "h1", Type: detect.Text},
+		{Item: "https://kubernetes.io/docs", Hash: "h2", Type: detect.URL},
+		{Item: "grocery list", Hash: "h3", Type: detect.Text},
+	}
+
+	for name, m := range allMatchers(t) {
+		t.Run(name, func(t *testing.T) {
+			if result := m.Search(items, ""); result != nil {
+				t.Errorf("expected nil for empty query, got %v", result)
+			}
+
+			result := m.Search(items, "kubectl")
+			if len(result) == 0 || result[0].Hash != "h1" {
+				t.Errorf("expected kubectl entry first, got %v", result)
+			}
+			for _, r := range result {
+				if r.Hash == "h3" {
+					t.Errorf("unrelated entry matched: %v", result)
+				}
+			}
+
+			result = m.Search(items, "type:url")
+			if len(result) != 1 || result[0].Hash != "h2" {
+				t.Errorf("expected only URL entry for type filter, got %v", result)
+			}
+
+			result = m.Search(items, "zzzzqqq")
+			if len(result) != 0 {
+				t.Errorf("expected no matches, got %v", result)
+			}
+		})
+	}
+}
+
+func TestSmithWaterman_ToleratesTypos(t *testing.T) {
+	m := NewSmithWatermanMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "docker compose up", Hash: "h1"},
+		{Item: "unrelated text", Hash: "h2"},
+	}
+
+	// Transposed letters still align well enough to match
+	result := m.Search(items, "dokcer")
+	if len(result) != 1 || result[0].Hash != "h1" {
+		t.Errorf("expected typo query to match docker entry, got %v", result)
+	}
+}
+
+func TestTrigram_OrderInsensitive(t *testing.T) {
+	m := NewTrigramMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "deploy production server", Hash: "h1"},
+		{Item: "something else", Hash: "h2"},
+	}
+
+	result := m.Search(items, "server deploy")
+	if len(result) != 1 || result[0].Hash != "h1" {
+		t.Errorf("expected word-order-insensitive match, got %v", result)
+	}
+
+	result = m.Search(items, "ds")
+	if len(result) != 0 {
+		t.Errorf("expected short query to use substring matching, got %v", result)
+	}
+}
+
+func TestLibraryMatcher_RanksBestFirst(t *testing.T) {
+	m := NewLibraryMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "a long line mentioning g-i-t somewhere", Hash: "h1"},
+		{Item: "git status", Hash: "h2"},
+	}
+
+	result := m.Search(items, "git")
+	if len(result) == 0 || result[0].Hash != "h2" {
+		t.Errorf("expected prefix match first, got %v", result)
+	}
+}
+
+func TestExact_MatchesOnlySubstringsInHistoryOrder(t *testing.T) {
+	m := NewExactMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "git push origin main", Hash: "h1"},
+		{Item: "kubectl get pods", Hash: "h2"},
+		{Item: "GIT PULL", Hash: "h3"},
+	}
+
+	result := m.Search(items, "git")
+	if len(result) != 2 || result[0].Hash != "h1" || result[1].Hash != "h3" {
+		t.Errorf("expected both git entries in history order, got %v", result)
+	}
+	if result := m.Search(items, "kgp"); len(result) != 0 {
+		t.Errorf("expected no fuzzy matches, got %v", result)
+	}
+}
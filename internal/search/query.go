@@ -0,0 +1,124 @@
+package search
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// Filter prefixes recognised in a search query, e.g. "type:url",
+// "lang:go", "app:firefox", "tag:chat" or "after:2024-01-31".
+const (
+	typePrefix   = "type:"
+	langPrefix   = "lang:"
+	appPrefix    = "app:"
+	tagPrefix    = "tag:"
+	afterPrefix  = "after:"
+	beforePrefix = "before:"
+)
+
+// dateLayout is the date format accepted by after: and before:, which
+// also take "today" and "yesterday".
+const dateLayout = "2006-01-02"
+
+// now is the current time, for today and yesterday; overridable for tests.
+var now = time.Now
+
+// Query is a parsed search expression: free text plus optional filters.
+type Query struct {
+	Text      string
+	Types     []detect.Type
+	Languages []detect.Language // code in any of these languages
+	App       string            // copied from an application whose name contains this
+	Tag       string            // tagged with this, e.g. by an app policy
+	After     time.Time         // copied on or after this local date
+	Before    time.Time         // copied before this local date
+}
+
+// ParseQuery splits "type:<name>", "lang:<name>", "app:<name>",
+// "tag:<name>", "after:<date>" and "before:<date>" filters out of a raw search string.
+// Tokens naming an unknown type or language or an invalid date are left in
+// the search text.
+func ParseQuery(raw string) Query {
+	var q Query
+	var rest []string
+	filters := 0
+	for _, field := range strings.Fields(raw) {
+		lower := strings.ToLower(field)
+		switch {
+		case strings.HasPrefix(lower, typePrefix):
+			if t, ok := detect.ParseType(field[len(typePrefix):]); ok {
+				q.Types = append(q.Types, t)
+				filters++
+				continue
+			}
+		case strings.HasPrefix(lower, langPrefix):
+			if l, ok := detect.ParseLanguage(field[len(langPrefix):]); ok {
+				q.Languages = append(q.Languages, l)
+				filters++
+				continue
+			}
+		case strings.HasPrefix(lower, appPrefix) && len(field) > len(appPrefix):
+			q.App = field[len(appPrefix):]
+			filters++
+			continue
+		case strings.HasPrefix(lower, tagPrefix) && len(field) > len(tagPrefix):
+			q.Tag = field[len(tagPrefix):]
+			filters++
+			continue
+		case strings.HasPrefix(lower, afterPrefix):
+			if d, err := parseDate(field[len(afterPrefix):]); err == nil {
+				q.After = d
+				filters++
+				continue
+			}
+		case strings.HasPrefix(lower, beforePrefix):
+			if d, err := parseDate(field[len(beforePrefix):]); err == nil {
+				q.Before = d
+				filters++
+				continue
+			}
+		}
+		rest = append(rest, field)
+	}
+
+	if filters == 0 {
+		q.Text = raw
+	} else {
+		q.Text = strings.Join(rest, " ")
+	}
+	return q
+}
+
+// parseDate parses a date in dateLayout, or "today" or "yesterday", as the
+// start of that local day
+func parseDate(s string) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "today", "yesterday":
+		y, m, d := now().Date()
+		if strings.EqualFold(s, "yesterday") {
+			d--
+		}
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local), nil
+	}
+	return time.ParseInLocation(dateLayout, s, time.Local)
+}
+
+// IsEmpty reports whether the query has neither text nor filters.
+func (q Query) IsEmpty() bool {
+	return q.Text == "" && q.Filter().IsEmpty()
+}
+
+// Filter returns the query's filters, without its text, for
+// history.Manager.Query. The text is left to the matcher, since fuzzy
+// matching can't be expressed as a filter.
+func (q Query) Filter() history.Filter {
+	return history.Filter{Types: q.Types, Languages: q.Languages, Since: q.After, Until: q.Before, App: q.App, Tag: q.Tag}
+}
+
+// MatchesFilters reports whether item passes the query's filters.
+func (q Query) MatchesFilters(item history.ClipboardHistory) bool {
+	return q.Filter().Matches(item)
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		raw   string
+		text  string
+		types []detect.Type
+	}{
+		{"hello world", "hello world", nil},
+		{"  spaced  ", "  spaced  ", nil},
+		{"type:url github", "github", []detect.Type{detect.URL}},
+		{"github TYPE:URL", "github", []detect.Type{detect.URL}},
+		{"type:json type:code", "", []detect.Type{detect.JSON, detect.Code}},
+		{"type:bogus x", "type:bogus x", nil},
+	}
+
+	for _, tt := range tests {
+		q := ParseQuery(tt.raw)
+		if q.Text != tt.text {
+			t.Errorf("ParseQuery(%q).Text = %q, want %q", tt.raw, q.Text, tt.text)
+		}
+		if len(q.Types) != len(tt.types) {
+			t.Errorf("ParseQuery(%q).Types = %v, want %v", tt.raw, q.Types, tt.types)
+			continue
+		}
+		for i := range tt.types {
+			if q.Types[i] != tt.types[i] {
+				t.Errorf("ParseQuery(%q).Types[%d] = %q, want %q", tt.raw, i, q.Types[i], tt.types[i])
+			}
+		}
+	}
+}
+
+func TestFuzzyMatcher_Search_TypeFilter(t *testing.T) {
+	matcher := NewFuzzyMatcher()
+	items := []history.ClipboardHistory{
+		{Item: "https://github.com", Hash: "h1", Type: detect.URL},
+		{Item: "github is great", Hash: "h2", Type: detect.Text},
+		{Item: "https://gitlab.com", Hash: "h3", Type: detect.URL},
+	}
+
+	result := matcher.Search(items, "type:url")
+	if len(result) != 2 {
+		t.Fatalf("expected 2 URL items, got %d", len(result))
+	}
+	if result[0].Hash != "h1" || result[1].Hash != "h3" {
+		t.Errorf("expected original order to be preserved, got %v", result)
+	}
+
+	result = matcher.Search(items, "type:url github")
+	if len(result) != 1 || result[0].Hash != "h1" {
+		t.Errorf("expected only the github URL, got %v", result)
+	}
+
+	result = matcher.Search(items, "type:email")
+	if result == nil || len(result) != 0 {
+		t.Errorf("expected empty non-nil result, got %v", result)
+	}
+}
+
+func TestParseQueryDates(t *testing.T) {
+	q := ParseQuery("after:2024-01-31 deploy before:2024-02-10 after:soon")
+
+	wantAfter := time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)
+	wantBefore := time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local)
+	if !q.After.Equal(wantAfter) || !q.Before.Equal(wantBefore) {
+		t.Errorf("After, Before = %v, %v; want %v, %v", q.After, q.Before, wantAfter, wantBefore)
+	}
+	if q.Text != "deploy after:soon" {
+		t.Errorf("Text = %q, want %q", q.Text, "deploy after:soon")
+	}
+
+	inRange := history.ClipboardHistory{Item: "x", TimeStamp: wantAfter.Add(time.Hour)}
+	tooLate := history.ClipboardHistory{Item: "x", TimeStamp: wantBefore}
+	if !q.MatchesFilters(inRange) || q.MatchesFilters(tooLate) {
+		t.Error("expected date range to include After and exclude Before")
+	}
+}
+
+func TestParseQueryLanguages(t *testing.T) {
+	q := ParseQuery("lang:go handler lang:cobol")
+	if len(q.Languages) != 1 || q.Languages[0] != detect.Go {
+		t.Errorf("Languages = %v, want [go]", q.Languages)
+	}
+	if q.Text != "handler lang:cobol" {
+		t.Errorf("Text = %q, want %q", q.Text, "handler lang:cobol")
+	}
+
+	goItem := history.ClipboardHistory{Item: "x", Type: detect.Code, Language: detect.Go}
+	pyItem := history.ClipboardHistory{Item: "x", Type: detect.Code, Language: detect.Python}
+	if !q.MatchesFilters(goItem) || q.MatchesFilters(pyItem) {
+		t.Error("expected lang:go to keep only Go code")
+	}
+}
+
+func TestParseQueryApp(t *testing.T) {
+	origNow := now
+	t.Cleanup(func() { now = origNow })
+	now = func() time.Time { return time.Date(2024, 3, 1, 15, 30, 0, 0, time.Local) }
+
+	q := ParseQuery("App:Firefox after:today release")
+	if q.App != "Firefox" || q.Text != "release" {
+		t.Errorf("App = %q, Text = %q", q.App, q.Text)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local); !q.After.Equal(want) {
+		t.Errorf("After = %v, want %v", q.After, want)
+	}
+	if d, err := parseDate("yesterday"); err != nil || d.Day() != 29 {
+		t.Errorf("parseDate(yesterday) = %v, %v", d, err)
+	}
+
+	fromFirefox := history.ClipboardHistory{Item: "x", SourceApp: "firefox", TimeStamp: now()}
+	fromTerminal := history.ClipboardHistory{Item: "x", SourceApp: "kitty", TimeStamp: now()}
+	if !q.MatchesFilters(fromFirefox) || q.MatchesFilters(fromTerminal) {
+		t.Error("expected app:Firefox to keep only entries copied from Firefox")
+	}
+	if q := ParseQuery("app:"); q.App != "" || q.Text != "app:" {
+		t.Errorf("expected an empty app: to stay in the text, got %+v", q)
+	}
+}
+
+func TestParseQueryTag(t *testing.T) {
+	q := ParseQuery("tag:Chat standup")
+	if q.Tag != "Chat" || q.Text != "standup" {
+		t.Errorf("Tag = %q, Text = %q", q.Tag, q.Text)
+	}
+	tagged := history.ClipboardHistory{Item: "x", Tags: []string{"chat"}}
+	if !q.MatchesFilters(tagged) || q.MatchesFilters(history.ClipboardHistory{Item: "x"}) {
+		t.Error("expected tag:Chat to keep only entries tagged chat")
+	}
+	if q := ParseQuery("tag:"); q.Tag != "" || q.Text != "tag:" {
+		t.Errorf("expected an empty tag: to stay in the text, got %+v", q)
+	}
+}
@@ -0,0 +1,62 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// Smith-Waterman scoring parameters.
+const (
+	swMatch    = 3
+	swMismatch = -1
+	swGap      = -1
+	// swMinRatio is the fraction of a perfect alignment score a candidate
+	// must reach to count as a match.
+	swMinRatio = 0.6
+)
+
+// SmithWatermanMatcher ranks items by their best local alignment with the
+// query. Unlike FuzzyMatcher it tolerates typos and transpositions, at the
+// cost of O(len(text) * len(query)) work per item.
+type SmithWatermanMatcher struct{}
+
+// NewSmithWatermanMatcher creates a new Smith-Waterman matcher
+func NewSmithWatermanMatcher() *SmithWatermanMatcher {
+	return &SmithWatermanMatcher{}
+}
+
+// Search performs local-alignment search on clipboard history items
+func (s *SmithWatermanMatcher) Search(items []history.ClipboardHistory, query string) []history.ClipboardHistory {
+	return rank(items, query, s.align)
+}
+
+// align returns the best local alignment score of query within text, or 0 if
+// it falls below swMinRatio of a perfect match.
+func (s *SmithWatermanMatcher) align(text, query string) int {
+	t, q := []rune(strings.ToLower(text)), []rune(query)
+	if len(q) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(q)+1)
+	curr := make([]int, len(q)+1)
+	best := 0
+	for i := 1; i <= len(t); i++ {
+		for j := 1; j <= len(q); j++ {
+			diag := prev[j-1] + swMismatch
+			if t[i-1] == q[j-1] {
+				diag = prev[j-1] + swMatch
+			}
+			cell := max(0, diag, prev[j]+swGap, curr[j-1]+swGap)
+			curr[j] = cell
+			best = max(best, cell)
+		}
+		prev, curr = curr, prev
+	}
+
+	if float64(best) < swMinRatio*float64(len(q)*swMatch) {
+		return 0
+	}
+	return best
+}
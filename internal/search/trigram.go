@@ -0,0 +1,67 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// trigramMinSimilarity is the fraction of the query's trigrams that must
+// appear in an item for it to match.
+const trigramMinSimilarity = 0.5
+
+// TrigramMatcher ranks items by how many of the query's character trigrams
+// they contain. It is order-insensitive and forgiving of small typos; queries
+// shorter than three characters fall back to substring matching.
+type TrigramMatcher struct{}
+
+// NewTrigramMatcher creates a new trigram matcher
+func NewTrigramMatcher() *TrigramMatcher {
+	return &TrigramMatcher{}
+}
+
+// Search performs trigram similarity search on clipboard history items
+func (tm *TrigramMatcher) Search(items []history.ClipboardHistory, query string) []history.ClipboardHistory {
+	// rank always passes the same lowercased query; build its trigrams once
+	var want map[string]struct{}
+	return rank(items, query, func(text, query string) int {
+		if want == nil {
+			want = trigrams(query)
+		}
+		return tm.similarity(strings.ToLower(text), query, want)
+	})
+}
+
+// similarity scores text by the share of the query's trigrams (want) it
+// contains, scaled to 0–1000, or 0 below trigramMinSimilarity.
+func (tm *TrigramMatcher) similarity(text, query string, want map[string]struct{}) int {
+	if len(want) == 0 {
+		if strings.Contains(text, query) {
+			return 1000
+		}
+		return 0
+	}
+
+	found := 0
+	for tri := range want {
+		if strings.Contains(text, tri) {
+			found++
+		}
+	}
+
+	ratio := float64(found) / float64(len(want))
+	if ratio < trigramMinSimilarity {
+		return 0
+	}
+	return int(ratio * 1000)
+}
+
+// trigrams returns the set of three-rune substrings of s.
+func trigrams(s string) map[string]struct{} {
+	r := []rune(s)
+	set := make(map[string]struct{}, len(r))
+	for i := 0; i+3 <= len(r); i++ {
+		set[string(r[i:i+3])] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,196 @@
+// Package session records what happens in the TUI, such as keys pressed,
+// clicks, resizes and clipboard captures, so that clippy replay can play a
+// session back against a fake clipboard and history to reproduce a bug.
+// Recordings are anonymized: captured text is kept only as its size and
+// content type, and text typed into prompts only as its length.
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// Event kinds.
+const (
+	Key     = "key"     // a key pressed
+	Release = "release" // a key released, reported while content is masked
+	Click   = "click"   // a mouse button pressed
+	Wheel   = "wheel"   // the mouse wheel turned
+	Resize  = "resize"  // the terminal resized
+	Paste   = "paste"   // text pasted into the terminal, kept as its size
+	Capture = "capture" // text copied to the clipboard and recorded
+)
+
+// redactedKey stands in for printable keys typed into prompts
+const redactedKey = 'x'
+
+// Event is one recorded happening, At after the session started.
+type Event struct {
+	At     time.Duration `json:"at"`
+	Kind   string        `json:"kind"`
+	Code   rune          `json:"code,omitempty"`
+	Text   string        `json:"text,omitempty"`
+	Mod    tea.KeyMod    `json:"mod,omitempty"`
+	X      int           `json:"x,omitempty"`
+	Y      int           `json:"y,omitempty"`
+	Button int           `json:"button,omitempty"`
+	Width  int           `json:"width,omitempty"`
+	Height int           `json:"height,omitempty"`
+	Size   int           `json:"size,omitempty"`
+	Type   detect.Type   `json:"type,omitempty"`
+}
+
+// Recorder appends events to a writer as JSON lines. Its methods are safe
+// to call from several goroutines.
+type Recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	now   func() time.Time
+	err   error
+}
+
+// NewRecorder returns a Recorder writing to w, timing events from now.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, start: time.Now(), now: time.Now}
+}
+
+// Input records msg if it is user input. Printable keys are replaced when
+// private, e.g. while typing a search, and pasted text is kept only as its
+// size.
+func (r *Recorder) Input(msg tea.Msg, private bool) {
+	var e Event
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		e = keyEvent(Key, tea.Key(msg), private)
+	case tea.KeyReleaseMsg:
+		e = keyEvent(Release, tea.Key(msg), private)
+	case tea.MouseClickMsg:
+		e = Event{Kind: Click, X: msg.X, Y: msg.Y, Button: int(msg.Button), Mod: msg.Mod}
+	case tea.MouseWheelMsg:
+		e = Event{Kind: Wheel, X: msg.X, Y: msg.Y, Button: int(msg.Button), Mod: msg.Mod}
+	case tea.WindowSizeMsg:
+		e = Event{Kind: Resize, Width: msg.Width, Height: msg.Height}
+	case tea.PasteMsg:
+		e = Event{Kind: Paste, Size: len(msg.Content)}
+	default:
+		return
+	}
+	r.write(e)
+}
+
+// Captured records text being captured from the clipboard by its size and
+// content type.
+func (r *Recorder) Captured(text string) {
+	r.write(Event{Kind: Capture, Size: len(text), Type: detect.Detect(text)})
+}
+
+// Err returns the first error writing an event; events after it are
+// dropped.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	e.At = r.now().Sub(r.start)
+	data, err := json.Marshal(e)
+	if err == nil {
+		_, err = r.w.Write(append(data, '\n'))
+	}
+	r.err = err
+}
+
+// keyEvent records key, hiding the character typed when private
+func keyEvent(kind string, key tea.Key, private bool) Event {
+	e := Event{Kind: kind, Code: key.Code, Text: key.Text, Mod: key.Mod}
+	if private && key.Text != "" {
+		e.Code = redactedKey
+		e.Text = strings.Repeat(string(redactedKey), len([]rune(key.Text)))
+	}
+	return e
+}
+
+// Read parses a recording, reporting the line of the first malformed
+// event.
+func Read(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Msg returns the message e replays as, or nil for a capture, which
+// replays through the clipboard instead.
+func (e Event) Msg() tea.Msg {
+	switch e.Kind {
+	case Key:
+		return tea.KeyPressMsg(tea.Key{Code: e.Code, Text: e.Text, Mod: e.Mod})
+	case Release:
+		return tea.KeyReleaseMsg(tea.Key{Code: e.Code, Text: e.Text, Mod: e.Mod})
+	case Click:
+		return tea.MouseClickMsg(tea.Mouse{X: e.X, Y: e.Y, Button: tea.MouseButton(e.Button), Mod: e.Mod})
+	case Wheel:
+		return tea.MouseWheelMsg(tea.Mouse{X: e.X, Y: e.Y, Button: tea.MouseButton(e.Button), Mod: e.Mod})
+	case Resize:
+		return tea.WindowSizeMsg{Width: e.Width, Height: e.Height}
+	case Paste:
+		return tea.PasteMsg{Content: strings.Repeat(string(redactedKey), e.Size)}
+	}
+	return nil
+}
+
+// Placeholder is made-up content standing in for the n-th capture of a
+// recording: text of e's type, padded to e's size where it is longer.
+// Each n gives different text, so captures are never taken for duplicates.
+func (e Event) Placeholder(n int) string {
+	var text string
+	switch e.Type {
+	case detect.URL:
+		text = fmt.Sprintf("https://example.com/clip/%d", n)
+	case detect.Email:
+		text = fmt.Sprintf("clip%d@example.com", n)
+	case detect.Path:
+		text = fmt.Sprintf("/tmp/clip/%d", n)
+	case detect.JSON:
+		text = fmt.Sprintf(`{"clip": %d}`, n)
+	case detect.Color:
+		text = fmt.Sprintf("#%06x", n%0x1000000)
+	case detect.Code:
+		text = fmt.Sprintf("func clip%d() {\n\treturn\n}", n)
+	default:
+		text = fmt.Sprintf("clip %d", n)
+	}
+	pad := e.Size - len(text)
+	switch {
+	case pad <= 0:
+	case e.Type == detect.Code:
+		text += "\n//" + strings.Repeat("x", max(pad-3, 0))
+	case e.Type == detect.Text || e.Type == "":
+		text += strings.Repeat(" x", pad/2) + strings.Repeat("x", pad%2)
+	}
+	return text
+}
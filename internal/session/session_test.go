@@ -0,0 +1,87 @@
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+func TestRecorderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	clock := recorder.start
+	recorder.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	recorder.Input(tea.WindowSizeMsg{Width: 100, Height: 30}, false)
+	recorder.Input(tea.KeyPressMsg(tea.Key{Code: '/', Text: "/"}), false)
+	recorder.Input(tea.KeyPressMsg(tea.Key{Code: 'p', Text: "pw"}), true)
+	recorder.Input(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}), true)
+	recorder.Input(tea.MouseClickMsg(tea.Mouse{X: 3, Y: 7, Button: tea.MouseLeft}), false)
+	recorder.Input(tea.PasteMsg{Content: "hunter2"}, false)
+	recorder.Input(tea.FocusMsg{}, false) // not input; dropped
+	recorder.Captured("https://example.com/secret-token")
+	if err := recorder.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"pw", "hunter2", "secret"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("recording holds %q:\n%s", secret, buf.String())
+		}
+	}
+
+	events, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 7 {
+		t.Fatalf("read %d events, want 7: %+v", len(events), events)
+	}
+	if events[0].At != time.Second || events[6].At != 7*time.Second {
+		t.Errorf("event times = %v ... %v", events[0].At, events[6].At)
+	}
+	if msg, ok := events[1].Msg().(tea.KeyPressMsg); !ok || msg.String() != "/" {
+		t.Errorf("key event replays as %v", events[1].Msg())
+	}
+	if msg := events[2].Msg().(tea.KeyPressMsg); msg.Text != "xx" {
+		t.Errorf("private key replays as %q, want it redacted to %q", msg.Text, "xx")
+	}
+	if msg := events[3].Msg().(tea.KeyPressMsg); msg.String() != "enter" {
+		t.Errorf("Enter in a prompt replays as %q", msg.String())
+	}
+	if msg := events[4].Msg().(tea.MouseClickMsg); msg.X != 3 || msg.Y != 7 || msg.Button != tea.MouseLeft {
+		t.Errorf("click replays as %+v", msg)
+	}
+	if msg := events[5].Msg().(tea.PasteMsg); len(msg.Content) != len("hunter2") {
+		t.Errorf("paste replays as %q", msg.Content)
+	}
+	if e := events[6]; e.Kind != Capture || e.Size != 32 || e.Type != detect.URL || e.Msg() != nil {
+		t.Errorf("capture event = %+v", e)
+	}
+}
+
+func TestReadReportsLine(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"kind\":\"key\"}\n\nnot json\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Errorf("Read: %v, want an error on line 3", err)
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	for _, typ := range []detect.Type{detect.Text, detect.URL, detect.Email, detect.Path, detect.JSON, detect.Color, detect.Code} {
+		e := Event{Kind: Capture, Type: typ, Size: 60}
+		text := e.Placeholder(1)
+		if got := detect.Detect(text); got != typ {
+			t.Errorf("placeholder %q detected as %s, want %s", text, got, typ)
+		}
+		if text == e.Placeholder(2) {
+			t.Errorf("placeholders for %s repeat", typ)
+		}
+	}
+	if text := (Event{Type: detect.Text, Size: 100}).Placeholder(1); len(text) != 100 {
+		t.Errorf("text placeholder is %d bytes, want 100", len(text))
+	}
+}
@@ -0,0 +1,99 @@
+// Package sources watches selections and registers besides the clipboard,
+// such as the X11 primary selection or a register printed by a command.
+// Each source is read in its own goroutine at its own interval, so a slow
+// or failing one never holds up the others, and what they capture arrives
+// on one channel labelled with the source it came from.
+package sources
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often a source without an interval is read.
+const DefaultInterval = time.Second
+
+// commandTimeout is how long a source's command may run before it is
+// killed, so a hung command can't stop its source for good
+const commandTimeout = 5 * time.Second
+
+// Source is somewhere text can be captured from besides the clipboard.
+type Source struct {
+	Name     string        // labels its captures, e.g. "primary"
+	Interval time.Duration // how often Read is called; DefaultInterval when 0
+	Read     func() (string, error)
+}
+
+// Capture is new text read from a source.
+type Capture struct {
+	Source string
+	Text   string
+}
+
+// Watch reads each source in its own goroutine until ctx is cancelled,
+// sending text that differs from what the source last held. Empty text and
+// failed reads are skipped. The channel is closed once every goroutine
+// has stopped.
+func Watch(ctx context.Context, sources []Source) <-chan Capture {
+	captures := make(chan Capture, len(sources))
+	var wg sync.WaitGroup
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watch(ctx, source, captures)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(captures)
+	}()
+	return captures
+}
+
+// watch reads source every interval, sending its changes to captures
+func watch(ctx context.Context, source Source, captures chan<- Capture) {
+	interval := source.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last string
+	for {
+		if text, err := source.Read(); err == nil && text != "" && text != last {
+			last = text
+			select {
+			case captures <- Capture{Source: source.Name, Text: text}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Command returns a Read running args, a program and its arguments, and
+// taking what it prints as the source's text, less one trailing newline.
+func Command(args []string) func() (string, error) {
+	return func() (string, error) {
+		if len(args) == 0 {
+			return "", errors.New("source has no command")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r"), nil
+	}
+}
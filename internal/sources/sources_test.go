@@ -0,0 +1,103 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// changing is a source whose text the test sets
+type changing struct {
+	mu   sync.Mutex
+	text string
+	err  error
+}
+
+func (c *changing) set(text string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.text, c.err = text, err
+}
+
+func (c *changing) read() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.text, c.err
+}
+
+// next waits for a capture, failing the test if none arrives
+func next(t *testing.T, captures <-chan Capture) Capture {
+	t.Helper()
+	select {
+	case c := <-captures:
+		return c
+	case <-time.After(time.Second):
+		t.Fatal("no capture arrived")
+		return Capture{}
+	}
+}
+
+func TestWatchLabelsCaptures(t *testing.T) {
+	primary := &changing{text: "highlighted"}
+	register := &changing{text: "yanked"}
+	ctx, cancel := context.WithCancel(context.Background())
+	captures := Watch(ctx, []Source{
+		{Name: "primary", Interval: 5 * time.Millisecond, Read: primary.read},
+		{Name: "secondary", Interval: 5 * time.Millisecond, Read: register.read},
+	})
+
+	got := map[string]string{}
+	for range 2 {
+		c := next(t, captures)
+		got[c.Source] = c.Text
+	}
+	if got["primary"] != "highlighted" || got["secondary"] != "yanked" {
+		t.Errorf("captures = %v, want one from each source", got)
+	}
+
+	// Unchanged, empty and unreadable sources send nothing; changes do
+	register.set("", nil)
+	primary.set("other", errors.New("gone"))
+	time.Sleep(30 * time.Millisecond)
+	primary.set("selected again", nil)
+	if c := next(t, captures); c != (Capture{Source: "primary", Text: "selected again"}) {
+		t.Errorf("capture = %+v, want the primary selection's change", c)
+	}
+
+	cancel()
+	for range captures {
+	}
+}
+
+func TestWatchKeepsReadingPastASlowSource(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	fast := &changing{text: "fast"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	captures := Watch(ctx, []Source{
+		{Name: "slow", Read: func() (string, error) { <-block; return "slow", nil }},
+		{Name: "fast", Interval: 5 * time.Millisecond, Read: fast.read},
+	})
+	if c := next(t, captures); c.Source != "fast" {
+		t.Errorf("capture from %q, want fast", c.Source)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	text, err := Command([]string{"echo", "register"})()
+	if err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if text != "register" {
+		t.Errorf("text = %q, want the output less its newline", text)
+	}
+	if _, err := Command([]string{"false"})(); err == nil {
+		t.Error("expected a failing command to fail the read")
+	}
+	if _, err := Command(nil)(); err == nil {
+		t.Error("expected a source without a command to fail")
+	}
+}
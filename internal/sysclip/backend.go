@@ -0,0 +1,214 @@
+package sysclip
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/bvdwalt/clippy/internal/watch"
+	"github.com/bvdwalt/clippy/internal/wsl"
+)
+
+// Backend reads, writes and watches the clipboard's text.
+type Backend interface {
+	// Name identifies the backend, as accepted by Select.
+	Name() string
+	Read() (string, error)
+	Write(text string) error
+	// Watch starts change notifications for the clipboard and, with
+	// primary, the primary selection. It returns watch.ErrUnsupported when
+	// the clipboard must be polled instead.
+	Watch(primary bool) (*watch.Watcher, error)
+}
+
+// Backends lists the names accepted by Select.
+var Backends = []string{"wl-clipboard", "xclip", "xsel", "pbcopy", "windows", "wsl", "atotto", "osc52"}
+
+// inWSL reports whether clippy runs inside WSL with the Windows interop
+// binaries available; overridable for tests.
+var inWSL = func() bool {
+	return wsl.Detect() && wsl.Available()
+}
+
+var (
+	wlClipboard = commandBackend{
+		name:  "wl-clipboard",
+		read:  []string{"wl-paste", "--no-newline"},
+		write: []string{"wl-copy"},
+		watch: watch.Wayland,
+	}
+	xclipBackend = commandBackend{
+		name:  "xclip",
+		read:  []string{"xclip", "-selection", "clipboard", "-o"},
+		write: []string{"xclip", "-selection", "clipboard", "-i"},
+		watch: clipnotify,
+	}
+	xselBackend = commandBackend{
+		name:  "xsel",
+		read:  []string{"xsel", "--clipboard", "--output"},
+		write: []string{"xsel", "--clipboard", "--input"},
+		watch: clipnotify,
+	}
+	pasteboard = commandBackend{
+		name:  "pbcopy",
+		read:  []string{"pbpaste"},
+		write: []string{"pbcopy"},
+	}
+)
+
+// Detect returns the best clipboard backend for this system, or nil when
+// there is none, e.g. on a Linux server without a display.
+func Detect() Backend {
+	if inWSL() {
+		return wslBackend{}
+	}
+	switch goos {
+	case "windows":
+		return libraryBackend{name: "windows"}
+	case "darwin":
+		if pasteboard.installed() == nil {
+			return pasteboard
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("WAYLAND_DISPLAY") != "" && wlClipboard.installed() == nil {
+			return wlClipboard
+		}
+		if getenv("DISPLAY") != "" {
+			for _, b := range []commandBackend{xclipBackend, xselBackend} {
+				if b.installed() == nil {
+					return b
+				}
+			}
+		}
+		// Without a display the clipboard tools can't reach a clipboard
+		return nil
+	}
+	if !clipboard.Unsupported {
+		return libraryBackend{name: "atotto"}
+	}
+	return nil
+}
+
+// Select returns the backend called name (see Backends), or an error if it
+// is unknown or can't be used here.
+func Select(name string) (Backend, error) {
+	var b Backend
+	var err error
+	switch name {
+	case "wl-clipboard":
+		b, err = wlClipboard, wlClipboard.installed()
+	case "xclip":
+		b, err = xclipBackend, xclipBackend.installed()
+	case "xsel":
+		b, err = xselBackend, xselBackend.installed()
+	case "pbcopy":
+		b, err = pasteboard, pasteboard.installed()
+	case "windows":
+		b = libraryBackend{name: "windows"}
+		if goos != "windows" {
+			err = fmt.Errorf("not running on Windows")
+		}
+	case "wsl":
+		b = wslBackend{}
+		if !inWSL() {
+			err = wsl.ErrUnavailable
+		}
+	case "atotto":
+		b = libraryBackend{name: "atotto"}
+		if clipboard.Unsupported {
+			err = fmt.Errorf("no clipboard tool found")
+		}
+	case "osc52":
+		b = osc52Backend{}
+	default:
+		return nil, fmt.Errorf("unknown clipboard backend %q (choose from %s)", name, strings.Join(Backends, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("clipboard backend %s is unavailable: %w", name, err)
+	}
+	return b, nil
+}
+
+// commandBackend runs command-line tools to read and write the clipboard
+type commandBackend struct {
+	name  string
+	read  []string
+	write []string
+	watch func(primary bool) (*watch.Watcher, error)
+}
+
+func (b commandBackend) Name() string { return b.name }
+
+func (b commandBackend) Read() (string, error) {
+	out, err := run(nil, b.read[0], b.read[1:]...)
+	if err != nil {
+		return "", fmt.Errorf("error reading clipboard with %s: %w", b.read[0], err)
+	}
+	return string(out), nil
+}
+
+func (b commandBackend) Stream() (io.ReadCloser, error) {
+	out, err := startOutput(b.read[0], b.read[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading clipboard with %s: %w", b.read[0], err)
+	}
+	return out, nil
+}
+
+func (b commandBackend) Write(text string) error {
+	if _, err := run([]byte(text), b.write[0], b.write[1:]...); err != nil {
+		return fmt.Errorf("error writing clipboard with %s: %w", b.write[0], err)
+	}
+	return nil
+}
+
+func (b commandBackend) Watch(primary bool) (*watch.Watcher, error) {
+	if b.watch == nil {
+		return nil, watch.ErrUnsupported
+	}
+	return b.watch(primary)
+}
+
+// installed returns an error naming the first of the backend's tools that
+// can't be found
+func (b commandBackend) installed() error {
+	for _, tool := range []string{b.read[0], b.write[0]} {
+		if _, err := lookPath(tool); err != nil {
+			return fmt.Errorf("%s not found", tool)
+		}
+	}
+	return nil
+}
+
+// clipnotify watches X11 selections, which always include the primary one
+func clipnotify(bool) (*watch.Watcher, error) {
+	return watch.Clipnotify()
+}
+
+// libraryBackend uses atotto/clipboard: the Win32 API on Windows, and
+// whichever clipboard tool it finds elsewhere
+type libraryBackend struct {
+	name string
+}
+
+func (b libraryBackend) Name() string                               { return b.name }
+func (b libraryBackend) Read() (string, error)                      { return clipboard.ReadAll() }
+func (b libraryBackend) Write(text string) error                    { return clipboard.WriteAll(text) }
+func (b libraryBackend) Watch(primary bool) (*watch.Watcher, error) { return watch.New(primary) }
+
+// wslBackend reaches the Windows clipboard from inside WSL
+type wslBackend struct{}
+
+func (wslBackend) Name() string                       { return "wsl" }
+func (wslBackend) Read() (string, error)              { return wsl.ReadAll() }
+func (wslBackend) Write(text string) error            { return wsl.WriteAll(text) }
+func (wslBackend) Watch(bool) (*watch.Watcher, error) { return nil, watch.ErrUnsupported }
+
+// osc52Backend sends copies to the terminal's clipboard; it can't read
+type osc52Backend struct{}
+
+func (osc52Backend) Name() string                       { return "osc52" }
+func (osc52Backend) Read() (string, error)              { return "", ErrNoClipboard }
+func (osc52Backend) Write(text string) error            { return WriteOSC52(text) }
+func (osc52Backend) Watch(bool) (*watch.Watcher, error) { return nil, watch.ErrUnsupported }
@@ -0,0 +1,102 @@
+package sysclip
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bvdwalt/clippy/internal/watch"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		goos      string
+		env       map[string]string
+		installed []string
+		want      string
+	}{
+		{"wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"wl-paste", "wl-copy", "xclip"}, "wl-clipboard"},
+		{"xwayland without wl-clipboard", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"xclip"}, "xclip"},
+		{"xsel", "linux", map[string]string{"DISPLAY": ":0"}, []string{"xsel"}, "xsel"},
+		{"no display", "linux", nil, []string{"xclip"}, ""},
+		{"macOS", "darwin", nil, []string{"pbcopy", "pbpaste"}, "pbcopy"},
+		{"windows", "windows", nil, nil, "windows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePrimaryTools(t, tt.env, tt.installed...)
+			goos = tt.goos
+			got := ""
+			if b := Detect(); b != nil {
+				got = b.Name()
+			}
+			if got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("wsl", func(t *testing.T) {
+		usePrimaryTools(t, nil)
+		inWSL = func() bool { return true }
+		if b := Detect(); b == nil || b.Name() != "wsl" {
+			t.Errorf("Detect() = %v, want wsl", b)
+		}
+	})
+}
+
+func TestSelect(t *testing.T) {
+	usePrimaryTools(t, nil, "xsel")
+
+	b, err := Select("xsel")
+	if err != nil {
+		t.Fatalf("Select(xsel): %v", err)
+	}
+	if b.Name() != "xsel" {
+		t.Errorf("Name() = %q, want xsel", b.Name())
+	}
+	if _, err := Select("xclip"); err == nil {
+		t.Error("expected an error selecting xclip when it isn't installed")
+	}
+	if _, err := Select("windows"); err == nil {
+		t.Error("expected an error selecting windows on linux")
+	}
+	if _, err := Select("clipboard9000"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
+
+func TestCommandBackend(t *testing.T) {
+	calls := usePrimaryTools(t, nil, "wl-paste", "wl-copy")
+	b, err := Select("wl-clipboard")
+	if err != nil {
+		t.Fatalf("Select(wl-clipboard): %v", err)
+	}
+	Use(b)
+
+	text, err := ReadAll()
+	if err != nil || text != "selected text" {
+		t.Errorf("ReadAll() = %q, %v", text, err)
+	}
+	if err := WriteAll("x"); err != nil {
+		t.Errorf("WriteAll: %v", err)
+	}
+	want := []string{"wl-paste --no-newline", "wl-copy  <x"}
+	if len(*calls) != 2 || (*calls)[0] != want[0] || (*calls)[1] != want[1] {
+		t.Errorf("ran %q, want %q", *calls, want)
+	}
+	if Current().Name() != "wl-clipboard" {
+		t.Errorf("Current() = %q, want wl-clipboard", Current().Name())
+	}
+}
+
+func TestWatchUnsupported(t *testing.T) {
+	usePrimaryTools(t, nil, "pbcopy", "pbpaste")
+	b, err := Select("pbcopy")
+	if err != nil {
+		t.Fatalf("Select(pbcopy): %v", err)
+	}
+	if _, err := b.Watch(false); !errors.Is(err, watch.ErrUnsupported) {
+		t.Errorf("Watch() = %v, want watch.ErrUnsupported", err)
+	}
+}
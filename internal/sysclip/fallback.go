@@ -0,0 +1,68 @@
+package sysclip
+
+import (
+	"log"
+)
+
+// fallbacks are the clipboard tools tried, in order, when the one in use
+// disappears; OSC 52 is the last resort.
+var fallbacks = []commandBackend{wlClipboard, xclipBackend, xselBackend}
+
+// replaced names the backend the active one took over from, or "".
+var replaced string
+
+// Fallbacks lists the backends that would take over, in order, if the one
+// in use stopped working.
+func Fallbacks() []string {
+	var names []string
+	if _, ok := active.(commandBackend); !ok {
+		return nil
+	}
+	for _, b := range fallbacks {
+		if b.name != active.Name() && b.usable() {
+			names = append(names, b.name)
+		}
+	}
+	return append(names, osc52Backend{}.Name())
+}
+
+// Replaced names the backend that stopped working and was replaced by the
+// one in use, or is "" when it is the one chosen at startup.
+func Replaced() string {
+	return replaced
+}
+
+// fallBack replaces the active clipboard tool once it has gone, e.g. when
+// wl-clipboard was uninstalled, with the next in fallbacks that works
+// here, or OSC 52, and reports whether it did. Other failures, such as an
+// empty clipboard, leave it in use.
+func fallBack() bool {
+	failed, ok := active.(commandBackend)
+	if !ok {
+		return false
+	}
+	err := failed.installed()
+	if err == nil {
+		return false
+	}
+	var next Backend = osc52Backend{}
+	for _, b := range fallbacks {
+		if b.name != failed.name && b.usable() {
+			next = b
+			break
+		}
+	}
+	log.Printf("Clipboard backend %s stopped working (%v); using %s", failed.name, err, next.Name())
+	active, replaced = next, failed.name
+	return true
+}
+
+// usable reports whether the backend's tools are installed and its display
+// is there to reach
+func (b commandBackend) usable() bool {
+	display := "DISPLAY"
+	if b.name == wlClipboard.name {
+		display = "WAYLAND_DISPLAY"
+	}
+	return getenv(display) != "" && b.installed() == nil
+}
@@ -0,0 +1,72 @@
+package sysclip
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+// failMissingTools makes run fail for tools lookPath can't find, as
+// exec would
+func failMissingTools(t *testing.T) {
+	t.Helper()
+	fake := run
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		if _, err := lookPath(name); err != nil {
+			return nil, exec.ErrNotFound
+		}
+		return fake(stdin, name, args...)
+	}
+}
+
+func TestFallBackWhenToolDisappears(t *testing.T) {
+	calls := usePrimaryTools(t, map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, "xsel")
+	failMissingTools(t)
+	active = wlClipboard
+
+	if got := Fallbacks(); !slices.Equal(got, []string{"xsel", "osc52"}) {
+		t.Errorf("Fallbacks() = %v, want xsel then osc52", got)
+	}
+	text, err := ReadAll()
+	if err != nil || text != "selected text" {
+		t.Fatalf("ReadAll = %q, %v", text, err)
+	}
+	if Current().Name() != "xsel" || Replaced() != "wl-clipboard" {
+		t.Errorf("using %s in place of %q, want xsel for wl-clipboard", Current().Name(), Replaced())
+	}
+	if err := WriteAll("copied"); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	if last := (*calls)[len(*calls)-1]; last != "xsel --clipboard --input <copied" {
+		t.Errorf("last call = %q", last)
+	}
+}
+
+func TestFallBackToOSC52(t *testing.T) {
+	usePrimaryTools(t, map[string]string{"DISPLAY": ":0"})
+	failMissingTools(t)
+	active = xclipBackend
+
+	if _, err := ReadAll(); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("ReadAll: expected ErrNoClipboard once only OSC 52 is left, got %v", err)
+	}
+	if !UsingOSC52() || Replaced() != "xclip" {
+		t.Errorf("using %s in place of %q, want osc52 for xclip", Current().Name(), Replaced())
+	}
+}
+
+func TestNoFallBackWhileToolIsInstalled(t *testing.T) {
+	usePrimaryTools(t, map[string]string{"DISPLAY": ":0"}, "xclip", "xsel")
+	run = func([]byte, string, ...string) ([]byte, error) {
+		return nil, errors.New("exit status 1")
+	}
+	active = xclipBackend
+
+	if _, err := ReadAll(); err == nil {
+		t.Fatal("expected the read error")
+	}
+	if Current().Name() != "xclip" || Replaced() != "" {
+		t.Errorf("using %s in place of %q, want xclip kept", Current().Name(), Replaced())
+	}
+}
@@ -0,0 +1,79 @@
+package sysclip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// MaxOSC52Size is the most text sent through OSC 52. Terminals drop longer
+// sequences; xterm and tmux accept about 100KB of base64.
+const MaxOSC52Size = 74994
+
+// screenChunk is the longest string GNU screen passes through in one piece.
+const screenChunk = 768
+
+// ErrTooLarge is returned by WriteOSC52 for text over MaxOSC52Size.
+var ErrTooLarge = fmt.Errorf("text is larger than %d bytes, the limit for the terminal clipboard", MaxOSC52Size)
+
+// openTerminal opens the controlling terminal for writing; overridable for
+// tests.
+var openTerminal = func() (io.WriteCloser, error) {
+	name := "/dev/tty"
+	if goos == "windows" {
+		name = "CONOUT$"
+	}
+	return os.OpenFile(name, os.O_WRONLY, 0)
+}
+
+// UseOSC52 sends copies to the clipboard of the terminal clippy runs in,
+// using the OSC 52 escape sequence, which reaches the local machine over
+// SSH. The clipboard can't be read this way, so nothing is captured.
+func UseOSC52() {
+	Use(osc52Backend{})
+}
+
+// UsingOSC52 reports whether copies go through OSC 52.
+func UsingOSC52() bool {
+	_, ok := active.(osc52Backend)
+	return ok
+}
+
+// PreferOSC52 reports whether OSC 52 should be used automatically: in an
+// SSH session without a forwarded display, where clipboard tools would
+// reach the remote machine's clipboard, if any.
+func PreferOSC52() bool {
+	remote := getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != ""
+	return remote && getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == ""
+}
+
+// OSC52 returns the escape sequence that sets the terminal's clipboard to
+// text. Inside GNU screen it is wrapped to pass through to the outer
+// terminal; tmux forwards it itself with set-clipboard enabled.
+func OSC52(text string) string {
+	seq := ansi.SetSystemClipboard(text)
+	if getenv("TMUX") == "" && strings.HasPrefix(getenv("TERM"), "screen") {
+		return ansi.ScreenPassthrough(seq, screenChunk)
+	}
+	return seq
+}
+
+// WriteOSC52 sets the terminal's clipboard to text by writing the OSC 52
+// sequence to the controlling terminal, so it works with output redirected.
+func WriteOSC52(text string) error {
+	if len(text) > MaxOSC52Size {
+		return ErrTooLarge
+	}
+	tty, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("error opening terminal: %w", err)
+	}
+	if _, err := io.WriteString(tty, OSC52(text)); err != nil {
+		_ = tty.Close()
+		return fmt.Errorf("error writing to terminal: %w", err)
+	}
+	return tty.Close()
+}
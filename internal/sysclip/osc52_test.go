@@ -0,0 +1,90 @@
+package sysclip
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeTerminal records what is written to the terminal
+type fakeTerminal struct {
+	strings.Builder
+	closed bool
+}
+
+func (f *fakeTerminal) Close() error {
+	f.closed = true
+	return nil
+}
+
+// useTerminal stubs the environment and the controlling terminal.
+func useTerminal(t *testing.T, env map[string]string) *fakeTerminal {
+	t.Helper()
+	origGetenv, origOpen, origActive := getenv, openTerminal, active
+	t.Cleanup(func() { getenv, openTerminal, active = origGetenv, origOpen, origActive })
+	getenv = func(key string) string { return env[key] }
+	tty := &fakeTerminal{}
+	openTerminal = func() (io.WriteCloser, error) { return tty, nil }
+	return tty
+}
+
+func TestOSC52(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"plain", nil, "\x1b]52;c;aGk=\x07"},
+		{"tmux forwards it itself", map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0", "TERM": "screen-256color"}, "\x1b]52;c;aGk=\x07"},
+		{"screen", map[string]string{"TERM": "screen"}, "\x1bP\x1b]52;c;aGk=\x07\x1b\\"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTerminal(t, tt.env)
+			if got := OSC52("hi"); got != tt.want {
+				t.Errorf("OSC52(hi) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferOSC52(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"local", map[string]string{"DISPLAY": ":0"}, false},
+		{"ssh", map[string]string{"SSH_TTY": "/dev/pts/1"}, true},
+		{"ssh connection", map[string]string{"SSH_CONNECTION": "10.0.0.1 5000 10.0.0.2 22"}, true},
+		{"ssh with X forwarding", map[string]string{"SSH_TTY": "/dev/pts/1", "DISPLAY": "localhost:10.0"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTerminal(t, tt.env)
+			if got := PreferOSC52(); got != tt.want {
+				t.Errorf("PreferOSC52() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAllUsesOSC52(t *testing.T) {
+	tty := useTerminal(t, nil)
+
+	UseOSC52()
+	if Available() {
+		t.Error("expected the clipboard to be unreadable with OSC 52")
+	}
+	if err := WriteAll("hi"); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	if tty.String() != "\x1b]52;c;aGk=\x07" || !tty.closed {
+		t.Errorf("terminal got %q (closed %v), want the OSC 52 sequence", tty.String(), tty.closed)
+	}
+
+	if err := WriteOSC52(strings.Repeat("x", MaxOSC52Size+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("WriteOSC52(too large) = %v, want ErrTooLarge", err)
+	}
+}
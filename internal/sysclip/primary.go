@@ -0,0 +1,104 @@
+package sysclip
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Overridable for tests.
+var (
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+	lookPath = exec.LookPath
+	run      = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		cmd := exec.Command(name, args...)
+		if stdin != nil {
+			// Writers such as xclip and wl-copy stay behind to serve the
+			// selection, holding any output pipe open, so don't capture it
+			cmd.Stdin = bytes.NewReader(stdin)
+			return nil, cmd.Run()
+		}
+		return cmd.Output()
+	}
+)
+
+// PrimaryAvailable reports whether the primary selection (the text last
+// highlighted, pasted with middle-click) can be used. It exists on X11 and
+// Wayland only.
+func PrimaryAvailable() bool {
+	return primaryReachable() && primaryTool() != ""
+}
+
+// ReadPrimary returns the text in the primary selection.
+func ReadPrimary() (string, error) {
+	if !primaryReachable() {
+		return "", ErrNoClipboard
+	}
+	var out []byte
+	var err error
+	switch primaryTool() {
+	case "wl-paste":
+		out, err = run(nil, "wl-paste", "--primary", "--no-newline")
+	case "xclip":
+		out, err = run(nil, "xclip", "-selection", "primary", "-o")
+	case "xsel":
+		out, err = run(nil, "xsel", "--primary", "--output")
+	default:
+		return "", ErrNoClipboard
+	}
+	return string(out), err
+}
+
+// WritePrimary places text in the primary selection.
+func WritePrimary(text string) error {
+	if !primaryReachable() {
+		return ErrNoClipboard
+	}
+	var err error
+	switch primaryTool() {
+	case "wl-paste":
+		_, err = run([]byte(text), "wl-copy", "--primary")
+	case "xclip":
+		_, err = run([]byte(text), "xclip", "-selection", "primary", "-i")
+	case "xsel":
+		_, err = run([]byte(text), "xsel", "--primary", "--input")
+	default:
+		return ErrNoClipboard
+	}
+	return err
+}
+
+// primaryReachable reports whether the backend in use leaves the primary
+// selection reachable: it isn't with the clipboard disabled, with OSC 52 or
+// inside WSL
+func primaryReachable() bool {
+	switch active.(type) {
+	case nil, osc52Backend, wslBackend:
+		return false
+	}
+	return true
+}
+
+// primaryTool returns the command used to reach the primary selection, or
+// "" when there is none
+func primaryTool() string {
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := lookPath("wl-paste"); err == nil {
+				return "wl-paste"
+			}
+		}
+		if getenv("DISPLAY") == "" {
+			return ""
+		}
+		for _, tool := range []string{"xclip", "xsel"} {
+			if _, err := lookPath(tool); err == nil {
+				return tool
+			}
+		}
+	}
+	return ""
+}
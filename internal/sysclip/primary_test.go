@@ -0,0 +1,92 @@
+package sysclip
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// usePrimaryTools stubs the platform and environment, with only the given
+// commands installed, and records the commands run.
+func usePrimaryTools(t *testing.T, env map[string]string, installed ...string) *[]string {
+	t.Helper()
+	origGOOS, origGetenv, origLookPath, origRun, origWSL, origActive, origReplaced := goos, getenv, lookPath, run, inWSL, active, replaced
+	t.Cleanup(func() {
+		goos, getenv, lookPath, run, inWSL, active, replaced = origGOOS, origGetenv, origLookPath, origRun, origWSL, origActive, origReplaced
+	})
+	goos = "linux"
+	inWSL = func() bool { return false }
+	active = libraryBackend{name: "atotto"}
+	getenv = func(key string) string { return env[key] }
+	lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	var calls []string
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		if stdin != nil {
+			call += " <" + string(stdin)
+		}
+		calls = append(calls, call)
+		return []byte("selected text"), nil
+	}
+	return &calls
+}
+
+func TestPrimaryTools(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		installed []string
+		wantRead  string
+		wantWrite string
+	}{
+		{"wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, []string{"wl-paste", "xclip"}, "wl-paste --primary --no-newline", "wl-copy --primary <x"},
+		{"xclip", map[string]string{"DISPLAY": ":0"}, []string{"xclip", "xsel"}, "xclip -selection primary -o", "xclip -selection primary -i <x"},
+		{"xsel", map[string]string{"DISPLAY": ":0"}, []string{"xsel"}, "xsel --primary --output", "xsel --primary --input <x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := usePrimaryTools(t, tt.env, tt.installed...)
+			if !PrimaryAvailable() {
+				t.Fatal("expected the primary selection to be available")
+			}
+			text, err := ReadPrimary()
+			if err != nil || text != "selected text" {
+				t.Errorf("ReadPrimary() = %q, %v", text, err)
+			}
+			if err := WritePrimary("x"); err != nil {
+				t.Errorf("WritePrimary: %v", err)
+			}
+			if len(*calls) != 2 || (*calls)[0] != tt.wantRead || (*calls)[1] != tt.wantWrite {
+				t.Errorf("calls = %q, want [%q %q]", *calls, tt.wantRead, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestPrimaryUnavailable(t *testing.T) {
+	usePrimaryTools(t, map[string]string{"DISPLAY": ":0"}, "xclip")
+	goos = "darwin"
+	if PrimaryAvailable() {
+		t.Error("expected no primary selection on macOS")
+	}
+	if _, err := ReadPrimary(); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("ReadPrimary: expected ErrNoClipboard, got %v", err)
+	}
+	if err := WritePrimary("x"); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("WritePrimary: expected ErrNoClipboard, got %v", err)
+	}
+
+	goos = "linux"
+	active = wslBackend{}
+	if PrimaryAvailable() {
+		t.Error("expected no primary selection under WSL")
+	}
+}
@@ -0,0 +1,86 @@
+package sysclip
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Streamer is implemented by backends that can read the clipboard as a
+// stream, without holding it all in memory.
+type Streamer interface {
+	Stream() (io.ReadCloser, error)
+}
+
+// startOutput runs a command and returns its output as it is produced;
+// overridable for tests.
+var startOutput = func(name string, args ...string) (io.ReadCloser, error) {
+	cmd := exec.Command(name, args...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandOutput{name: name, out: out, cmd: cmd}, nil
+}
+
+// commandOutput reads a running command's output. At the end of it, Read
+// reports the command's failure, if any, rather than io.EOF, so a failed
+// read isn't mistaken for an empty clipboard.
+type commandOutput struct {
+	name   string
+	out    io.ReadCloser
+	cmd    *exec.Cmd
+	done   bool
+	waited error
+}
+
+func (c *commandOutput) Read(p []byte) (int, error) {
+	n, err := c.out.Read(p)
+	if err == io.EOF {
+		if werr := c.wait(); werr != nil {
+			return n, fmt.Errorf("error reading clipboard with %s: %w", c.name, werr)
+		}
+	}
+	return n, err
+}
+
+// Close stops reading, waiting for the command to exit.
+func (c *commandOutput) Close() error {
+	_ = c.out.Close()
+	_ = c.wait()
+	return nil
+}
+
+// wait waits for the command once, returning how it exited
+func (c *commandOutput) wait() error {
+	if !c.done {
+		c.done = true
+		c.waited = c.cmd.Wait()
+	}
+	return c.waited
+}
+
+// Stream returns a reader for the text on the clipboard. Backends that are
+// Streamers pass on the clipboard tool's output as it is produced, so a
+// huge copy needn't be held in memory; others read it all first.
+func Stream() (io.ReadCloser, error) {
+	if !Available() {
+		return nil, ErrNoClipboard
+	}
+	if s, ok := active.(Streamer); ok {
+		out, err := s.Stream()
+		if err != nil && fallBack() {
+			return Stream()
+		}
+		return out, err
+	}
+	text, err := active.Read()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(text)), nil
+}
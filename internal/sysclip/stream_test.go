@@ -0,0 +1,63 @@
+package sysclip
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCommandOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+	out, err := startOutput("sh", "-c", "printf 'streamed text'")
+	if err != nil {
+		t.Fatalf("startOutput: %v", err)
+	}
+	data, err := io.ReadAll(out)
+	if err != nil || string(data) != "streamed text" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	if err := out.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// A failing tool is an error at the end of its output, not an empty read
+	out, err = startOutput("sh", "-c", "printf partial; exit 1")
+	if err != nil {
+		t.Fatalf("startOutput: %v", err)
+	}
+	defer out.Close()
+	if data, err := io.ReadAll(out); err == nil {
+		t.Errorf("read %q with no error, want the exit status", data)
+	}
+}
+
+func TestStream(t *testing.T) {
+	usePrimaryTools(t, nil, "wl-paste", "wl-copy")
+	origStart := startOutput
+	t.Cleanup(func() { startOutput = origStart })
+	var ran string
+	startOutput = func(name string, args ...string) (io.ReadCloser, error) {
+		ran = name + " " + strings.Join(args, " ")
+		return io.NopCloser(strings.NewReader("streamed text")), nil
+	}
+	b, err := Select("wl-clipboard")
+	if err != nil {
+		t.Fatalf("Select(wl-clipboard): %v", err)
+	}
+	Use(b)
+
+	r, err := Stream()
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer r.Close()
+	if data, err := io.ReadAll(r); err != nil || string(data) != "streamed text" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	if ran != "wl-paste --no-newline" {
+		t.Errorf("ran %q, want wl-paste", ran)
+	}
+}
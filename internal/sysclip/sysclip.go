@@ -0,0 +1,81 @@
+// Package sysclip reads and writes text on the system clipboard through a
+// Backend: wl-clipboard, xclip or xsel on Linux, pbcopy/pbpaste on macOS,
+// the Win32 API on Windows (via atotto/clipboard), the Windows clipboard's
+// interop binaries inside WSL, or OSC 52 to the local terminal over SSH.
+// Detect picks one at startup and Use overrides it. The X11/Wayland primary
+// selection is reached through wl-clipboard, xclip or xsel.
+package sysclip
+
+import (
+	"errors"
+)
+
+// ErrNoClipboard is returned when no clipboard backend is available or it
+// has been disabled.
+var ErrNoClipboard = errors.New("no clipboard backend available")
+
+// active is the backend in use, or nil when there is none.
+var active = Detect()
+
+// Use makes backend the clipboard read and written by ReadAll and WriteAll.
+// Should its tool disappear while clippy runs, the next that works takes
+// over (see Fallbacks).
+func Use(backend Backend) {
+	active, replaced = backend, ""
+}
+
+// Current returns the backend in use, or nil when there is none.
+func Current() Backend {
+	return active
+}
+
+// Disable turns off clipboard access, e.g. for headless servers.
+func Disable() {
+	active, replaced = nil, ""
+}
+
+// Available reports whether a clipboard backend can be used to read and
+// write the clipboard. It is false with OSC 52, which can only write.
+func Available() bool {
+	return active != nil && !UsingOSC52()
+}
+
+// ReadAll returns the text on the clipboard.
+func ReadAll() (string, error) {
+	if !Available() {
+		return "", ErrNoClipboard
+	}
+	text, err := active.Read()
+	if err != nil && fallBack() {
+		return ReadAll()
+	}
+	return text, err
+}
+
+// WriteAll places text on the clipboard, or on the terminal's clipboard
+// when using OSC 52.
+func WriteAll(text string) error {
+	if active == nil {
+		return ErrNoClipboard
+	}
+	err := active.Write(text)
+	if err != nil && fallBack() {
+		return WriteAll(text)
+	}
+	return err
+}
+
+// OwnerServed reports whether the clipboard's content is served by the
+// application that copied it, and lost when that exits, as on Wayland and
+// X11. Text written with WriteAll is then served by the clipboard tool
+// (wl-copy, xclip or xsel) instead, which stays around.
+func OwnerServed() bool {
+	if active == nil {
+		return false
+	}
+	switch active.Name() {
+	case wlClipboard.name, xclipBackend.name, xselBackend.name:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package sysclip
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDisable(t *testing.T) {
+	orig := active
+	t.Cleanup(func() { active = orig })
+
+	Disable()
+	if Available() {
+		t.Error("expected clipboard to be unavailable once disabled")
+	}
+	if _, err := ReadAll(); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("ReadAll: expected ErrNoClipboard, got %v", err)
+	}
+	if err := WriteAll("x"); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("WriteAll: expected ErrNoClipboard, got %v", err)
+	}
+}
+
+func TestOwnerServed(t *testing.T) {
+	orig := active
+	t.Cleanup(func() { active = orig })
+
+	for _, tt := range []struct {
+		backend Backend
+		want    bool
+	}{
+		{wlClipboard, true},
+		{xselBackend, true},
+		{pasteboard, false},
+		{libraryBackend{name: "windows"}, false},
+		{nil, false},
+	} {
+		Use(tt.backend)
+		if got := OwnerServed(); got != tt.want {
+			t.Errorf("OwnerServed with %v = %v, want %v", tt.backend, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package tmux imports tmux paste buffers, so text yanked in tmux copy mode
+// (which never reaches the system clipboard) can be captured into history.
+package tmux
+
+import (
+	"errors"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnavailable is returned when the tmux binary cannot be found.
+var ErrUnavailable = errors.New("tmux not found")
+
+// Overridable for tests.
+var (
+	lookPath = exec.LookPath
+	run      = func(name string, args ...string) ([]byte, error) {
+		return exec.Command(name, args...).Output()
+	}
+)
+
+// Buffer identifies a tmux paste buffer. Buffer names are reused by tmux
+// once buffers are deleted, so the creation time is part of its identity.
+type Buffer struct {
+	Name    string
+	Created int64
+}
+
+// key identifies a buffer across polls.
+func (b Buffer) key() string {
+	return b.Name + "@" + strconv.FormatInt(b.Created, 10)
+}
+
+// Available reports whether the tmux binary is on PATH.
+func Available() bool {
+	_, err := lookPath("tmux")
+	return err == nil
+}
+
+// ListBuffers returns the tmux paste buffers, oldest first. A missing tmux
+// server is not an error; it simply has no buffers.
+func ListBuffers() ([]Buffer, error) {
+	if !Available() {
+		return nil, ErrUnavailable
+	}
+	out, err := run("tmux", "list-buffers", "-F", "#{buffer_name}\t#{buffer_created}")
+	if err != nil {
+		// tmux exits non-zero when no server is running
+		return nil, nil
+	}
+
+	var buffers []Buffer
+	for line := range strings.Lines(string(out)) {
+		name, created, ok := strings.Cut(strings.TrimRight(line, "\n"), "\t")
+		if !ok || name == "" {
+			continue
+		}
+		ts, _ := strconv.ParseInt(created, 10, 64)
+		buffers = append(buffers, Buffer{Name: name, Created: ts})
+	}
+	sort.SliceStable(buffers, func(i, j int) bool {
+		return buffers[i].Created < buffers[j].Created
+	})
+	return buffers, nil
+}
+
+// ReadBuffer returns the contents of the named paste buffer.
+func ReadBuffer(name string) (string, error) {
+	out, err := run("tmux", "show-buffer", "-b", name)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Importer tracks which paste buffers have already been imported so each
+// buffer is only read once.
+type Importer struct {
+	seen map[string]bool
+}
+
+// NewImporter creates an importer that has seen no buffers yet; its first
+// Poll returns every existing buffer.
+func NewImporter() *Importer {
+	return &Importer{seen: make(map[string]bool)}
+}
+
+// Poll returns the contents of buffers created since the last call, oldest
+// first. Empty buffers are skipped.
+func (i *Importer) Poll() ([]string, error) {
+	buffers, err := ListBuffers()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rebuild the seen set from the current listing so deleted buffers
+	// don't accumulate
+	seen := make(map[string]bool, len(buffers))
+	var contents []string
+	for _, b := range buffers {
+		if i.seen[b.key()] {
+			seen[b.key()] = true
+			continue
+		}
+		content, err := ReadBuffer(b.Name)
+		if err != nil {
+			// The buffer may have been deleted since it was listed; retry next poll
+			continue
+		}
+		seen[b.key()] = true
+		if content != "" {
+			contents = append(contents, content)
+		}
+	}
+	i.seen = seen
+	return contents, nil
+}
@@ -0,0 +1,134 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeTmux replaces the exec hooks with an in-memory tmux server.
+type fakeTmux struct {
+	running bool
+	buffers []fakeBuffer
+	reads   int
+}
+
+type fakeBuffer struct {
+	name    string
+	created int64
+	content string
+}
+
+func useFake(t *testing.T, installed bool) *fakeTmux {
+	t.Helper()
+	f := &fakeTmux{running: true}
+	origLook, origRun := lookPath, run
+	lookPath = func(name string) (string, error) {
+		if installed && name == "tmux" {
+			return "/usr/bin/tmux", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	run = func(name string, args ...string) ([]byte, error) {
+		if !f.running {
+			return nil, errors.New("no server running")
+		}
+		switch args[0] {
+		case "list-buffers":
+			var out strings.Builder
+			for _, b := range f.buffers {
+				fmt.Fprintf(&out, "%s\t%d\n", b.name, b.created)
+			}
+			return []byte(out.String()), nil
+		case "show-buffer":
+			f.reads++
+			for _, b := range f.buffers {
+				if b.name == args[2] {
+					return []byte(b.content), nil
+				}
+			}
+			return nil, errors.New("no buffer " + args[2])
+		}
+		return nil, fmt.Errorf("unexpected command %v", args)
+	}
+	t.Cleanup(func() { lookPath, run = origLook, origRun })
+	return f
+}
+
+func TestListBuffersOldestFirst(t *testing.T) {
+	f := useFake(t, true)
+	f.buffers = []fakeBuffer{
+		{"buffer1", 200, "newer"},
+		{"buffer0", 100, "older"},
+	}
+
+	buffers, err := ListBuffers()
+	if err != nil {
+		t.Fatalf("ListBuffers: %v", err)
+	}
+	if len(buffers) != 2 || buffers[0].Name != "buffer0" || buffers[1].Name != "buffer1" {
+		t.Errorf("expected buffers oldest first, got %+v", buffers)
+	}
+}
+
+func TestListBuffersNoServer(t *testing.T) {
+	f := useFake(t, true)
+	f.running = false
+
+	buffers, err := ListBuffers()
+	if err != nil || buffers != nil {
+		t.Errorf("expected no buffers and no error without a server, got %v, %v", buffers, err)
+	}
+}
+
+func TestListBuffersNotInstalled(t *testing.T) {
+	useFake(t, false)
+
+	if _, err := ListBuffers(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestImporterReturnsOnlyNewBuffers(t *testing.T) {
+	f := useFake(t, true)
+	f.buffers = []fakeBuffer{{"buffer0", 100, "first"}}
+	importer := NewImporter()
+
+	got, err := importer.Poll()
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if len(got) != 1 || got[0] != "first" {
+		t.Fatalf("expected existing buffer on first poll, got %q", got)
+	}
+
+	f.buffers = append(f.buffers, fakeBuffer{"buffer1", 200, "second"}, fakeBuffer{"buffer2", 300, ""})
+	got, _ = importer.Poll()
+	if len(got) != 1 || got[0] != "second" {
+		t.Errorf("expected only the new non-empty buffer, got %q", got)
+	}
+
+	reads := f.reads
+	if got, _ := importer.Poll(); len(got) != 0 {
+		t.Errorf("expected nothing new, got %q", got)
+	}
+	if f.reads != reads {
+		t.Error("expected already imported buffers not to be read again")
+	}
+}
+
+func TestImporterReimportsReusedBufferName(t *testing.T) {
+	f := useFake(t, true)
+	f.buffers = []fakeBuffer{{"buffer0", 100, "first"}}
+	importer := NewImporter()
+	importer.Poll()
+
+	// tmux reuses the name after the buffer is deleted
+	f.buffers = []fakeBuffer{{"buffer0", 400, "replacement"}}
+	got, _ := importer.Poll()
+	if len(got) != 1 || got[0] != "replacement" {
+		t.Errorf("expected reused buffer name to be imported, got %q", got)
+	}
+}
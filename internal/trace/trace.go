@@ -0,0 +1,203 @@
+// Package trace times clippy's hot paths (capture, insert, search, render)
+// for diagnosing slowness. Tracing is off unless Enable is called, e.g.
+// with CLIPPY_TRACE=1, and a span started while it is off costs only an
+// atomic load. Spans are appended to a file as JSON lines, which clippy
+// trace summarizes.
+package trace
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FileName is the trace file, kept next to the history database.
+const FileName = "trace.jsonl"
+
+// maxFileSize is how large the trace file may grow before it is started
+// afresh. Overridable for tests.
+var maxFileSize int64 = 4 << 20
+
+// Span names.
+const (
+	Capture = "capture" // reading the clipboard and recording what changed
+	Insert  = "insert"  // storing a new entry in the database
+	Search  = "search"  // filtering history for a query
+	Render  = "render"  // drawing the TUI
+)
+
+// Record is a finished span.
+type Record struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"ns"`
+	PID      int           `json:"pid"`
+}
+
+var (
+	mu   sync.Mutex
+	file *os.File // nil while tracing is off
+	size int64    // of file, as far as this process knows
+	on   atomic.Bool
+)
+
+// Enable appends spans to the trace file at path until Disable, starting
+// the file afresh whenever it grows past a few megabytes, so tracing can
+// be left on in a long-running daemon.
+func Enable(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening trace file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("error opening trace file: %w", err)
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			log.Printf("Failed to close trace file: %v", err)
+		}
+	}
+	file, size = f, info.Size()
+	on.Store(true)
+	return nil
+}
+
+// Disable stops tracing and closes the trace file.
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	on.Store(false)
+	if file != nil {
+		if err := file.Close(); err != nil {
+			log.Printf("Failed to close trace file: %v", err)
+		}
+		file = nil
+	}
+}
+
+// Span times one operation; see Start.
+type Span struct {
+	name  string
+	start time.Time // zero when tracing is off
+}
+
+// Start begins timing the operation name; call End on the result once it
+// is done, e.g. defer trace.Start(trace.Search).End().
+func Start(name string) Span {
+	if !on.Load() {
+		return Span{}
+	}
+	return Span{name: name, start: time.Now()}
+}
+
+// End records the span, if tracing was on when it started.
+func (s Span) End() {
+	if s.start.IsZero() {
+		return
+	}
+	record := Record{Name: s.name, Start: s.start, Duration: time.Since(s.start), PID: os.Getpid()}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return
+	}
+	if size > maxFileSize {
+		if err := file.Truncate(0); err != nil {
+			log.Printf("Failed to reset trace file: %v", err)
+		}
+		size = 0
+	}
+	n, err := file.Write(append(line, '\n'))
+	if err != nil {
+		log.Printf("Failed to write trace: %v", err)
+	}
+	size += int64(n)
+}
+
+// Load reads the spans in the trace file at path, oldest first. A missing
+// file holds none.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening trace file: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		// Skip a line cut short by a crash
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trace file: %w", err)
+	}
+	return records, nil
+}
+
+// Stat summarizes the spans of one name.
+type Stat struct {
+	Name  string
+	Count int
+	Total time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+}
+
+// Summarize returns a Stat for each span name in records, by name.
+func Summarize(records []Record) []Stat {
+	byName := make(map[string][]time.Duration)
+	for _, r := range records {
+		byName[r.Name] = append(byName[r.Name], r.Duration)
+	}
+	stats := make([]Stat, 0, len(byName))
+	for name, durations := range byName {
+		slices.Sort(durations)
+		stat := Stat{Name: name, Count: len(durations), Max: durations[len(durations)-1]}
+		for _, d := range durations {
+			stat.Total += d
+		}
+		stat.P50 = percentile(durations, 50)
+		stat.P95 = percentile(durations, 95)
+		stats = append(stats, stat)
+	}
+	slices.SortFunc(stats, func(a, b Stat) int { return cmp.Compare(a.Name, b.Name) })
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted, by nearest rank
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// Slowest returns the n longest spans in records, longest first.
+func Slowest(records []Record, n int) []Record {
+	sorted := slices.Clone(records)
+	slices.SortStableFunc(sorted, func(a, b Record) int { return cmp.Compare(b.Duration, a.Duration) })
+	return sorted[:min(n, len(sorted))]
+}
@@ -0,0 +1,88 @@
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSpansRecordedOnlyWhileEnabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	Start(Search).End()
+
+	if err := Enable(path); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	span := Start(Insert)
+	time.Sleep(time.Millisecond)
+	span.End()
+	Start(Render).End()
+	Disable()
+	Start(Capture).End()
+
+	records, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(records) != 2 || records[0].Name != Insert || records[1].Name != Render {
+		t.Fatalf("records = %+v, want the insert and render spans", records)
+	}
+	if records[0].Duration < time.Millisecond || records[0].PID != os.Getpid() {
+		t.Errorf("insert span = %+v", records[0])
+	}
+}
+
+func TestTraceFileStartsAfreshWhenFull(t *testing.T) {
+	orig := maxFileSize
+	t.Cleanup(func() { maxFileSize = orig })
+	maxFileSize = 200
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Enable(path); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	for range 10 {
+		Start(Capture).End()
+	}
+	Disable()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size() > 400 {
+		t.Errorf("trace file is %d bytes, want it kept near %d", info.Size(), maxFileSize)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	records, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil || records != nil {
+		t.Errorf("Load = %v, %v; want no records", records, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var records []Record
+	for i := 1; i <= 20; i++ {
+		records = append(records, Record{Name: Search, Duration: time.Duration(i) * time.Millisecond})
+	}
+	records = append(records, Record{Name: Capture, Duration: 5 * time.Millisecond})
+
+	stats := Summarize(records)
+	if len(stats) != 2 || stats[0].Name != Capture || stats[1].Name != Search {
+		t.Fatalf("stats = %+v, want capture then search", stats)
+	}
+	search := stats[1]
+	if search.Count != 20 || search.P50 != 10*time.Millisecond || search.P95 != 19*time.Millisecond || search.Max != 20*time.Millisecond {
+		t.Errorf("search stat = %+v", search)
+	}
+	if search.Total != 210*time.Millisecond {
+		t.Errorf("search total = %v, want 210ms", search.Total)
+	}
+
+	slowest := Slowest(records, 2)
+	if len(slowest) != 2 || slowest[0].Duration != 20*time.Millisecond || slowest[1].Duration != 19*time.Millisecond {
+		t.Errorf("Slowest = %+v", slowest)
+	}
+}
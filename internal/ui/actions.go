@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/actions"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// Overridable for tests.
+var (
+	openURL      = actions.Open
+	pagerCommand = actions.PagerCommand
+)
+
+// actionMenu lists the quick actions for one item
+type actionMenu struct {
+	hash    string
+	actions []actions.Action
+	cursor  int
+}
+
+// SetActionConfig sets the URL templates used by quick actions, such as
+// the commit page of the configured repository.
+func (m *Model) SetActionConfig(cfg actions.Config) {
+	m.actionConfig = cfg
+}
+
+// itemActions returns the quick actions available for item
+func (m *Model) itemActions(item history.ClipboardHistory) []actions.Action {
+	if item.IsBinary() || item.Sensitive != "" {
+		return nil
+	}
+	return append(m.formatActions(item), actions.For(item.Item, m.actionConfig)...)
+}
+
+// openActionMenu switches to ActionView for the selected item, if it has
+// any actions
+func (m *Model) openActionMenu() {
+	selected := m.selectedItem()
+	if selected == nil {
+		return
+	}
+	available := m.itemActions(*selected)
+	if len(available) == 0 {
+		return
+	}
+	m.mode = ActionView
+	m.actionMenu = &actionMenu{hash: selected.Hash, actions: available}
+}
+
+// closeActionMenu returns to the table
+func (m *Model) closeActionMenu() {
+	m.mode = TableView
+	m.actionMenu = nil
+}
+
+// updateActionMenu handles key presses while the action menu is open
+func (m Model) updateActionMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	menu := m.actionMenu
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.ActionCancel):
+		m.closeActionMenu()
+	case key.Matches(msg, m.keys.PreviewDown):
+		menu.cursor = min(menu.cursor+1, len(menu.actions)-1)
+	case key.Matches(msg, m.keys.PreviewUp):
+		menu.cursor = max(menu.cursor-1, 0)
+	case key.Matches(msg, m.keys.ActionRun):
+		return m.runAction(menu.actions[menu.cursor])
+	default:
+		// Digits run an action by its number
+		if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= len(menu.actions) {
+			return m.runAction(menu.actions[n-1])
+		}
+	}
+	return m, nil
+}
+
+// runAction performs action and closes the menu, asking for confirmation
+// before running a command. In pick mode the text a copy action produces is
+// picked instead.
+func (m Model) runAction(action actions.Action) (tea.Model, tea.Cmd) {
+	hash := m.actionMenu.hash
+	m.closeActionMenu()
+	if action.MimeType != "" {
+		return m.copyFormat(hash, action.MimeType)
+	}
+	if len(action.Command) > 0 {
+		// Running a program needs a y/n confirmation first
+		m.confirmCommand = &action
+		return m, nil
+	}
+	if action.URL != "" {
+		if err := openURL(action.URL); err != nil {
+			log.Printf("Failed to open %s: %v", action.URL, err)
+		}
+		return m, nil
+	}
+	if m.pickMode {
+		m.picked = &history.ClipboardHistory{Item: action.Copy, Kind: history.KindText}
+		return m, tea.Quit
+	}
+	return m, m.copyText(action.Copy)
+}
+
+// actionMenuView renders the action menu box
+func (m Model) actionMenuView() string {
+	menu := m.actionMenu
+	target, info := "", ""
+	if item := m.findByHash(menu.hash); item != nil {
+		target = truncate(strings.TrimSpace(item.Item), 40)
+		if described := actions.Describe(item.Item, m.actionConfig); described != "" {
+			info = m.theme.Help.Render(described) + "\n"
+		}
+	}
+	var lines strings.Builder
+	for i, action := range menu.actions {
+		cursor := "  "
+		if i == menu.cursor {
+			cursor = "> "
+		}
+		fmt.Fprintf(&lines, "%s%d. %s\n", cursor, i+1, action.Label)
+	}
+	hint := m.theme.Help.Render(renderHelp(m.keys.actionHelp(), 0))
+	return m.theme.Search.Render(
+		fmt.Sprintf("⚡ Actions for %q:\n%s\n%s\n%s", target, info, lines.String(), hint))
+}
+
+// actionCountLabel describes how many actions item has, e.g. "3 actions
+// (x)", or "" if it has none
+func (m *Model) actionCountLabel(item history.ClipboardHistory) string {
+	n := len(m.itemActions(item))
+	if n == 0 {
+		return ""
+	}
+	noun := "actions"
+	if n == 1 {
+		noun = "action"
+	}
+	return fmt.Sprintf("%d %s (%s)", n, noun, m.keys.Actions.Help().Key)
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"os/exec"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/actions"
+)
+
+func TestActionMenuCopiesCommand(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("3f9c2a1")
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+	if !contains(model.View().Content, "3 actions (x)") {
+		t.Error("expected the number of actions in the preview label")
+	}
+
+	model = typeText(model, "x")
+	if model.mode != ActionView {
+		t.Fatal("expected the action menu to open")
+	}
+	if !contains(model.View().Content, "2. copy as git show") {
+		t.Error("expected numbered actions in the menu")
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	newModel, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected quit command")
+	}
+	if item, ok := model.Picked(); !ok || item.Item != "git show 3f9c2a1" {
+		t.Errorf("Picked() = %q, %v", item.Item, ok)
+	}
+}
+
+func TestActionMenuOpensURL(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	var opened []string
+	origOpen := openURL
+	t.Cleanup(func() { openURL = origOpen })
+	openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	historyManager.AddItem("3f9c2a1")
+	model := NewModel(historyManager)
+	model.SetActionConfig(actions.Config{CommitURL: "https://git.test/commit/{sha}"})
+
+	model = typeText(model, "x4")
+	if model.mode != TableView {
+		t.Error("expected the menu to close after running an action")
+	}
+	if len(opened) != 1 || opened[0] != "https://git.test/commit/3f9c2a1" {
+		t.Errorf("opened %v, want the commit page", opened)
+	}
+}
+
+func TestActionMenuIgnoresUnrecognisedContent(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("just some words")
+	model := NewModel(historyManager)
+
+	model = typeText(model, "x")
+	if model.mode != TableView {
+		t.Error("expected no action menu for plain text")
+	}
+
+	historyManager.AddItem("feature/login")
+	model.UpdateTable()
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = typeText(model, "x")
+	if model.mode != ActionView {
+		t.Fatal("expected the action menu for a branch name")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.mode != TableView || model.actionMenu != nil {
+		t.Error("expected Esc to close the action menu")
+	}
+}
+
+func TestActionMenuConfirmsLookup(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	var ran [][]string
+	origPager := pagerCommand
+	t.Cleanup(func() { pagerCommand = origPager })
+	pagerCommand = func(args []string) *exec.Cmd {
+		ran = append(ran, args)
+		return exec.Command("true")
+	}
+
+	historyManager.AddItem("example.com")
+	model := NewModel(historyManager)
+	model = typeText(model, "x")
+	if !contains(model.View().Content, "domain • top-level .com") {
+		t.Error("expected the parsed domain info in the menu")
+	}
+
+	// Stand in a lookup, as dig may not be installed
+	model.actionMenu.actions = []actions.Action{{Label: "run dig example.com", Command: []string{"dig", "example.com"}}}
+	model = typeText(model, "1")
+	if !contains(model.View().Content, `Run "dig example.com"? (y/n)`) {
+		t.Error("expected a confirmation before running the lookup")
+	}
+	model = typeText(model, "n")
+	if model.confirmCommand != nil || len(ran) != 0 {
+		t.Error("expected n to cancel the lookup")
+	}
+
+	model = typeText(model, "x")
+	model.actionMenu.actions = []actions.Action{{Label: "run dig example.com", Command: []string{"dig", "example.com"}}}
+	model = typeText(model, "1")
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: 'y', Text: "y"})); cmd == nil {
+		t.Fatal("expected a command running the lookup")
+	}
+	if len(ran) != 1 || ran[0][0] != "dig" {
+		t.Errorf("ran %v, want the dig lookup", ran)
+	}
+}
+
+func TestActionMenuRunsCustomAction(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	var ran *exec.Cmd
+	origPager := pagerCommand
+	t.Cleanup(func() { pagerCommand = origPager })
+	pagerCommand = func(args []string) *exec.Cmd {
+		ran = exec.Command("true", args...)
+		return ran
+	}
+
+	decode, err := actions.NewCustom("decode JWT", `^eyJ[\w-]+\.`, []string{"jwt", "decode", "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	historyManager.AddItem("hello world")
+	historyManager.AddItem("eyJhbGciOiJIUzI1NiJ9.e30.sig")
+	model := NewModel(historyManager)
+	model.SetActionConfig(actions.Config{Custom: []actions.Custom{decode}})
+
+	jwtRow := 0
+	for i, item := range model.getDisplayItems() {
+		if item.Item != "hello world" {
+			jwtRow = i
+		}
+		got := model.itemActions(item)
+		matched := len(got) > 0 && got[len(got)-1].Label == "decode JWT"
+		if matched != (item.Item != "hello world") {
+			t.Errorf("custom action offered for %q = %v", item.Item, matched)
+		}
+	}
+
+	model.moveCursor(jwtRow)
+	model = typeText(model, "x")
+	model.actionMenu.cursor = len(model.actionMenu.actions) - 1
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if !contains(model.View().Content, `Run "jwt decode -"? (y/n)`) {
+		t.Fatal("expected a confirmation before running the custom action")
+	}
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: 'y', Text: "y"})); cmd == nil {
+		t.Fatal("expected a command running the custom action")
+	}
+	if ran == nil || ran.Stdin == nil {
+		t.Fatal("expected the entry to be piped to the custom action")
+	}
+}
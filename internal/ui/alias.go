@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// openAliasPrompt switches to AliasView for the selected item, pre-filling
+// the input with its current alias
+func (m *Model) openAliasPrompt() {
+	selected := m.selectedItem()
+	if selected == nil {
+		return
+	}
+	m.mode = AliasView
+	m.aliasHash = selected.Hash
+	m.aliasInput.SetValue(selected.Alias)
+	m.aliasInput.CursorEnd()
+	m.aliasInput.Focus()
+	m.aliasErr = ""
+}
+
+// closeAliasPrompt returns to the table without changing anything
+func (m *Model) closeAliasPrompt() {
+	m.mode = TableView
+	m.aliasInput.Blur()
+	m.aliasInput.SetValue("")
+	m.aliasHash = ""
+	m.aliasErr = ""
+}
+
+// validateAlias checks the alias being typed against the alias rules and the
+// aliases already assigned to other entries. An empty alias is valid and
+// clears the entry's alias.
+func (m *Model) validateAlias(alias string) string {
+	if alias == "" {
+		return ""
+	}
+	if err := history.ValidateAlias(alias); err != nil {
+		return err.Error()
+	}
+	if owner, ok := m.historyManager.FindByAlias(alias); ok && owner.Hash != m.aliasHash {
+		return fmt.Sprintf("alias %q is already used by %s", alias, m.aliasTarget(owner, 30))
+	}
+	return ""
+}
+
+// updateAliasPrompt handles key presses while the alias prompt is open
+func (m Model) updateAliasPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.closeAliasPrompt()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		alias := strings.TrimSpace(m.aliasInput.Value())
+		if m.aliasErr = m.validateAlias(alias); m.aliasErr != "" {
+			return m, nil
+		}
+		for i, item := range m.historyManager.GetItems() {
+			if item.Hash == m.aliasHash {
+				if err := m.historyManager.SetAlias(i, alias); err != nil {
+					m.aliasErr = err.Error()
+					return m, nil
+				}
+				break
+			}
+		}
+		m.closeAliasPrompt()
+		m.updateTable()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.aliasInput, cmd = m.aliasInput.Update(msg)
+	m.aliasErr = m.validateAlias(strings.TrimSpace(m.aliasInput.Value()))
+	return m, cmd
+}
+
+// aliasPromptView renders the alias input box
+func (m Model) aliasPromptView() string {
+	target := `""`
+	if item := m.findByHash(m.aliasHash); item != nil {
+		target = m.aliasTarget(*item, 40)
+	}
+	hint := m.theme.Help.Render("Press Enter to save (empty clears), Esc to cancel")
+	if m.aliasErr != "" {
+		hint = m.theme.Help.Render("⚠ " + m.aliasErr)
+	}
+	return m.theme.Search.Render(
+		fmt.Sprintf("🏷  Alias for %s:\n\n%s\n\n%s", target, m.aliasInput.View(), hint))
+}
+
+// aliasTarget names item in the alias prompt by quoting up to n cells of
+// it, or, like deletePrompt, without its content when that is hidden or a
+// secret: by its alias if it has one, else as what kind of secret it is
+func (m *Model) aliasTarget(item history.ClipboardHistory, n int) string {
+	if item.Sensitive == "" && !m.hidden(item.Hash) {
+		return fmt.Sprintf("%q", truncate(item.Item, n))
+	}
+	if item.Alias != "" && item.Hash == m.aliasHash {
+		return fmt.Sprintf("[%s]", item.Alias)
+	}
+	if item.Sensitive != "" {
+		return fmt.Sprintf("a hidden %s", item.Sensitive)
+	}
+	return "a hidden entry"
+}
+
+// truncate shortens s to at most n terminal cells, ending it in "..." when
+// cut, without splitting a character
+func truncate(s string, n int) string {
+	return ansi.Truncate(s, n, "...")
+}
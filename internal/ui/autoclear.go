@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// autoClear is a pending clear of the clipboard after copying a sensitive
+// entry
+type autoClear struct {
+	text string // what was copied; cleared only if still on the clipboard
+	at   time.Time
+	seq  int // identifies the copy, so ticks from earlier ones are ignored
+}
+
+// clearTickMsg counts down a pending clear of the clipboard
+type clearTickMsg struct {
+	seq int
+	now time.Time
+}
+
+// clearTick returns a command that sends a clearTickMsg for seq after a
+// second
+func clearTick(seq int) tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return clearTickMsg{seq: seq, now: t}
+	})
+}
+
+// SetClearSensitiveAfter clears the clipboard d after an entry holding a
+// secret, such as a token, is copied from history, as password managers
+// do. Zero leaves it on the clipboard.
+func (m *Model) SetClearSensitiveAfter(d time.Duration) {
+	m.clearAfter = max(d, 0)
+}
+
+// scheduleClear starts the countdown to clearing item from the clipboard
+// if it is sensitive, and cancels any earlier one, since the clipboard no
+// longer holds it
+func (m *Model) scheduleClear(item history.ClipboardHistory, text string, now time.Time) tea.Cmd {
+	seq := m.pendingClear.seq + 1
+	m.pendingClear = autoClear{seq: seq}
+	if item.Sensitive == "" || m.clearAfter == 0 || m.headless {
+		return nil
+	}
+	m.pendingClear = autoClear{text: text, at: now.Add(m.clearAfter), seq: seq}
+	return clearTick(seq)
+}
+
+// updateClear clears the clipboard once its countdown ends, if it still
+// holds the sensitive entry
+func (m *Model) updateClear(msg clearTickMsg) tea.Cmd {
+	pending := m.pendingClear
+	if msg.seq != pending.seq || pending.at.IsZero() {
+		return nil
+	}
+	if msg.now.Before(pending.at) {
+		return clearTick(pending.seq)
+	}
+	m.pendingClear = autoClear{seq: pending.seq}
+	if current, err := m.clipboard.Read(); err != nil || current != pending.text {
+		// Replaced since; whatever is there now stays
+		return m.clearDone()
+	}
+	if err := m.clipboard.Write(""); err != nil {
+		log.Printf("Failed to clear clipboard: %v", err)
+	}
+	return m.clearDone()
+}
+
+// quitAfterCopy quits once cmd, which copies an entry, has run. If the
+// copy started a countdown to clearing the clipboard, clippy stays open
+// showing it until the clipboard is cleared, rather than leaving a secret
+// behind.
+func (m *Model) quitAfterCopy(cmd tea.Cmd) tea.Cmd {
+	if m.pendingClear.at.IsZero() {
+		return tea.Sequence(cmd, tea.Quit)
+	}
+	m.quitAfterClear = true
+	m.notice = "quitting once the clipboard clears"
+	return cmd
+}
+
+// clearDone quits if a copy was waiting for the clipboard to be cleared
+// to do so
+func (m *Model) clearDone() tea.Cmd {
+	if m.quitAfterClear {
+		return tea.Quit
+	}
+	return nil
+}
+
+// clearStatus shows the countdown to clearing the clipboard, e.g.
+// "clipboard clears in 12s", or "" when none is pending
+func (m *Model) clearStatus(now time.Time) string {
+	if m.pendingClear.at.IsZero() {
+		return ""
+	}
+	left := max(m.pendingClear.at.Sub(now).Round(time.Second), 0)
+	return fmt.Sprintf("clipboard clears in %ds", int(left.Seconds()))
+}
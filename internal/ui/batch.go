@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// exportDir returns where marked items are exported: the directory clippy
+// was started in. Overridable for tests.
+var exportDir = os.Getwd
+
+// deleteMarkedPrompt asks to confirm deleting the marked items
+func (m *Model) deleteMarkedPrompt() string {
+	items := m.markedItems()
+	pinned := 0
+	for _, item := range items {
+		if item.Pinned {
+			pinned++
+		}
+	}
+	prompt := fmt.Sprintf("Delete %d marked items", len(items))
+	if pinned > 0 {
+		prompt += fmt.Sprintf(", %d of them pinned", pinned)
+	}
+	return prompt + "? (y/n)"
+}
+
+// deleteMarked deletes every marked item, as one step for u to undo, and
+// clears the marks
+func (m *Model) deleteMarked() {
+	var hashes []string
+	for _, item := range m.markedItems() {
+		hashes = append(hashes, item.Hash)
+	}
+	m.deleteItems(hashes...)
+	m.marked = nil
+	m.updateTable()
+}
+
+// exportMarked writes the marked items, in marking order, to a JSON file
+// in exportDir and returns its path. Large text is exported whole; images
+// are described but not included.
+func (m *Model) exportMarked(now time.Time) (string, error) {
+	items := m.markedItems()
+	if len(items) == 0 {
+		return "", nil
+	}
+	exported := make([]history.ClipboardHistory, 0, len(items))
+	for _, item := range items {
+		if item.Overflow {
+			text, err := m.historyManager.Text(item)
+			if err != nil {
+				return "", fmt.Errorf("error exporting clip: %w", err)
+			}
+			item.Item, item.Overflow = text, false
+		}
+		exported = append(exported, item)
+	}
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error exporting clips: %w", err)
+	}
+	dir, err := exportDir()
+	if err != nil {
+		return "", fmt.Errorf("error exporting clips: %w", err)
+	}
+	path := filepath.Join(dir, "clippy-export-"+now.Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return "", fmt.Errorf("error exporting clips: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// markWithSpace marks the first n rows with Space
+func markWithSpace(model Model, n int) Model {
+	for range n {
+		model = pressKey(model, tea.Key{Code: tea.KeySpace, Text: " "})
+		model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	}
+	return model
+}
+
+func TestDeleteMarkedAfterConfirmation(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for _, text := range []string{"one", "two", "three"} {
+		historyManager.AddItem(text)
+	}
+	model := NewModel(historyManager)
+	model = markWithSpace(model, 2)
+	if !contains(model.View().Content, "2 marked") {
+		t.Fatal("expected Space to mark entries")
+	}
+
+	model = typeText(model, "D")
+	if !contains(model.View().Content, "Delete 2 marked items? (y/n)") {
+		t.Fatal("expected a confirmation prompt")
+	}
+	model = typeText(model, "n")
+	if historyManager.Count() != 3 {
+		t.Fatalf("expected nothing deleted after n, have %d items", historyManager.Count())
+	}
+
+	model = typeText(model, "D")
+	model = typeText(model, "y")
+	if historyManager.Count() != 1 || len(model.marked) != 0 {
+		t.Errorf("expected the 2 marked items deleted and marks cleared, have %d items and %d marks", historyManager.Count(), len(model.marked))
+	}
+}
+
+func TestCopyMarkedJoined(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	clipboard := &fakeClipboard{}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+	model = markWithSpace(model, 2)
+
+	model = typeText(model, "C")
+	items := historyManager.GetItems()
+	if want := items[0].Item + "\n" + items[1].Item; clipboard.text != want {
+		t.Errorf("clipboard = %q, want %q", clipboard.text, want)
+	}
+}
+
+func TestExportMarked(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	orig := exportDir
+	t.Cleanup(func() { exportDir = orig })
+	exportDir = func() (string, error) { return dir, nil }
+
+	historyManager.AddItem("exported")
+	historyManager.AddItem("left out")
+	model := NewModel(historyManager)
+	model = typeText(model, "E")
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(matches) != 0 {
+		t.Fatal("expected nothing exported without marks")
+	}
+
+	model = markWithSpace(model, 1)
+	model = typeText(model, "E")
+	if !contains(model.View().Content, "exported to") {
+		t.Error("expected the export path in the status line")
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "clippy-export-*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("exported files = %v", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []history.ClipboardHistory
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("export isn't JSON: %v", err)
+	}
+	if len(exported) != 1 || exported[0].Item != historyManager.GetItems()[0].Item {
+		t.Errorf("exported = %+v", exported)
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// chainFormat is how marked items are combined when copied together
+type chainFormat int
+
+const (
+	chainSteps    chainFormat = iota // a numbered list, e.g. reproduction steps
+	chainCommands                    // shell commands run in sequence with &&
+	chainLines                       // one after another, a line apart
+)
+
+// toggleMark marks or unmarks the item with hash for chaining. Items are
+// combined in the order they were marked.
+func (m *Model) toggleMark(hash string) {
+	for i, h := range m.marked {
+		if h == hash {
+			m.marked = append(m.marked[:i], m.marked[i+1:]...)
+			m.updateTable()
+			return
+		}
+	}
+	m.marked = append(m.marked, hash)
+	m.updateTable()
+}
+
+// markNumbers maps each marked item's hash to its position in the chain
+func (m *Model) markNumbers() map[string]int {
+	numbers := make(map[string]int, len(m.marked))
+	for i, hash := range m.marked {
+		numbers[hash] = i + 1
+	}
+	return numbers
+}
+
+// markedItems returns the marked items still in history, in marking order
+func (m *Model) markedItems() []history.ClipboardHistory {
+	items := make([]history.ClipboardHistory, 0, len(m.marked))
+	for _, hash := range m.marked {
+		if item := m.findByHash(hash); item != nil {
+			items = append(items, *item)
+		}
+	}
+	return items
+}
+
+// copyMarked copies the marked text items combined as format. With nothing
+// marked it does nothing.
+func (m *Model) copyMarked(format chainFormat) tea.Cmd {
+	var texts []string
+	for _, item := range m.markedItems() {
+		if item.IsBinary() {
+			continue
+		}
+		text, err := m.historyManager.Text(item)
+		if err != nil {
+			log.Printf("Failed to load clip: %v", err)
+			return nil
+		}
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	var combined string
+	switch format {
+	case chainSteps:
+		combined = numberedSteps(texts)
+	case chainCommands:
+		combined = joinCommands(texts)
+	case chainLines:
+		combined = strings.Join(texts, "\n")
+	}
+	if m.pickMode {
+		m.picked = &history.ClipboardHistory{Item: combined, Kind: history.KindText}
+		return tea.Quit
+	}
+	return m.copyText(combined)
+}
+
+// numberedSteps formats texts as a numbered list, indenting the
+// continuation lines of multi-line entries under their number
+func numberedSteps(texts []string) string {
+	var b strings.Builder
+	for i, text := range texts {
+		prefix := strconv.Itoa(i+1) + ". "
+		indent := strings.Repeat(" ", len(prefix))
+		for j, line := range strings.Split(strings.TrimSpace(text), "\n") {
+			if j == 0 {
+				b.WriteString(prefix)
+			} else if line != "" {
+				b.WriteString(indent)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// joinCommands joins texts into one command line that runs them in order,
+// stopping at the first failure. Multi-line entries are grouped in braces
+// so all of their lines run as one step.
+func joinCommands(texts []string) string {
+	commands := make([]string, len(texts))
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if strings.Contains(text, "\n") {
+			text = "{ " + text + "\n}"
+		}
+		commands[i] = text
+	}
+	return strings.Join(commands, " && ")
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestNumberedSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  string
+	}{
+		{"single", []string{"git pull"}, "1. git pull"},
+		{"several", []string{"cd repo\n", "make test"}, "1. cd repo\n2. make test"},
+		{"multi-line", []string{"run:\n  make\n\n  make test", "done"}, "1. run:\n     make\n\n     make test\n2. done"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := numberedSteps(tt.texts); got != tt.want {
+				t.Errorf("numberedSteps() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  string
+	}{
+		{"single", []string{"make"}, "make"},
+		{"several", []string{"cd repo\n", " make test"}, "cd repo && make test"},
+		{"multi-line", []string{"export A=1\nexport B=2", "make"}, "{ export A=1\nexport B=2\n} && make"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinCommands(tt.texts); got != tt.want {
+				t.Errorf("joinCommands() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkAndCopyChain(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("cd repo")
+	historyManager.AddItem("git status")
+	historyManager.AddItem("make test")
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+
+	// Mark the third entry, then the first: the chain follows marking order
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = typeText(model, "m")
+	model = pressKey(model, tea.Key{Code: tea.KeyUp})
+	model = pressKey(model, tea.Key{Code: tea.KeyUp})
+	model = typeText(model, "m")
+	if !contains(model.View().Content, "2 marked") {
+		t.Error("expected the number of marked entries in the status line")
+	}
+	if !contains(model.View().Content, "✓2") {
+		t.Error("expected the chain position in the table")
+	}
+
+	newModel, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: '&', Text: "&"}))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected quit command")
+	}
+	if item, ok := model.Picked(); !ok || item.Item != "make test && cd repo" {
+		t.Errorf("Picked() = %q, %v", item.Item, ok)
+	}
+}
+
+func TestUnmarkAndRefreshClearMarks(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("one")
+	historyManager.AddItem("two")
+	model := NewModel(historyManager)
+
+	model = typeText(model, "m")
+	model = typeText(model, "m")
+	if len(model.marked) != 0 {
+		t.Errorf("marked = %v, want none after toggling twice", model.marked)
+	}
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: 'M', Text: "M"})); cmd != nil {
+		t.Error("expected no copy with nothing marked")
+	}
+
+	model = typeText(model, "m")
+	model = typeText(model, "r")
+	if len(model.marked) != 0 {
+		t.Errorf("marked = %v, want none after refresh", model.marked)
+	}
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// clearConfirmWord confirms clearing history, as does typing the number of
+// items it deletes
+const clearConfirmWord = "yes"
+
+// openClearPrompt switches to ClearView to confirm clearing history,
+// keeping pinned items unless Tab is pressed
+func (m *Model) openClearPrompt() {
+	if m.historyManager.Count() == 0 {
+		m.notice = "history is already empty"
+		return
+	}
+	m.mode = ClearView
+	m.clearKeepPinned = true
+	m.clearInput.SetValue("")
+	m.clearInput.Focus()
+	m.clearErr = ""
+}
+
+// closeClearPrompt returns to the table without deleting anything
+func (m *Model) closeClearPrompt() {
+	m.mode = TableView
+	m.clearInput.Blur()
+	m.clearInput.SetValue("")
+	m.clearErr = ""
+}
+
+// clearCount is how many items clearing history would delete
+func (m *Model) clearCount() int {
+	count := 0
+	for _, item := range m.historyManager.GetItems() {
+		if !item.Pinned || !m.clearKeepPinned {
+			count++
+		}
+	}
+	return count
+}
+
+// clearConfirmed reports whether answer confirms deleting count items:
+// it is clearConfirmWord, in any case, or count itself
+func clearConfirmed(answer string, count int) bool {
+	answer = strings.TrimSpace(answer)
+	return strings.EqualFold(answer, clearConfirmWord) || answer == strconv.Itoa(count)
+}
+
+// updateClearPrompt handles key presses while the clear prompt is open
+func (m Model) updateClearPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.closeClearPrompt()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "tab":
+		m.clearKeepPinned = !m.clearKeepPinned
+		m.clearErr = ""
+		return m, nil
+	case "enter":
+		count := m.clearCount()
+		switch {
+		case count == 0:
+			m.clearErr = "only pinned items are left; Tab to delete them too"
+		case !clearConfirmed(m.clearInput.Value(), count):
+			m.clearErr = fmt.Sprintf("type %s or %d to confirm", clearConfirmWord, count)
+		default:
+			m.clearHistory()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.clearInput, cmd = m.clearInput.Update(msg)
+	m.clearErr = ""
+	return m, cmd
+}
+
+// clearHistory deletes every item, or every unpinned one, for good. Unlike
+// other deletes it can't be undone.
+func (m *Model) clearHistory() {
+	latest, hadLatest := m.historyManager.Latest()
+	cleared, err := m.historyManager.ClearAll(m.clearKeepPinned)
+	m.closeClearPrompt()
+	if err != nil {
+		m.notice = fmt.Sprintf("cleared %s, then failed: %v", plural(cleared, "item"), err)
+	} else {
+		m.notice = fmt.Sprintf("cleared %s", plural(cleared, "item"))
+	}
+	// The clipboard most likely still holds the latest item
+	if hadLatest && m.findByHash(latest.Hash) == nil {
+		m.skipRecapture(latest)
+	}
+	m.marked = nil
+	if m.filtered != nil {
+		m.filterItems(m.textInput.Value())
+	}
+	m.updateTable()
+	m.moveCursor(0)
+}
+
+// clearPromptView renders the clear history prompt
+func (m Model) clearPromptView() string {
+	count := m.clearCount()
+	pinned := "pinned items are kept"
+	if !m.clearKeepPinned {
+		pinned = "pinned items too"
+	}
+	hint := m.theme.Help.Render("Enter to clear • Tab to keep or delete pinned items • Esc to cancel")
+	if m.clearErr != "" {
+		hint = m.theme.Help.Render("⚠ " + m.clearErr)
+	}
+	return m.theme.Search.Render(fmt.Sprintf(
+		"🗑  Clear history? This deletes %s for good (%s).\nType %s or %d to confirm:\n\n%s\n\n%s",
+		plural(count, "item"), pinned, clearConfirmWord, count, m.clearInput.View(), hint))
+}
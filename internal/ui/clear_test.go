@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestClearPromptKeepsPinned(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	historyManager.AddItem("third")
+	if err := historyManager.TogglePin(2); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Text: "X"})
+	if model.mode != ClearView {
+		t.Fatalf("mode = %v, want ClearView", model.mode)
+	}
+	if !contains(model.View(), "deletes 2 items") {
+		t.Error("expected prompt to say how many items are deleted")
+	}
+
+	model = typeText(model, "no")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.mode != ClearView || historyManager.Count() != 3 {
+		t.Fatalf("a wrong answer cleared history: mode %v, %d items", model.mode, historyManager.Count())
+	}
+	if !contains(model.View(), "type yes or 2 to confirm") {
+		t.Error("expected the prompt to say what confirms it")
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	model = typeText(model, "YES")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.mode != TableView {
+		t.Errorf("mode = %v, want TableView after clearing", model.mode)
+	}
+	if item, _ := historyManager.GetItem(0); historyManager.Count() != 1 || !item.Pinned {
+		t.Errorf("left %d items, first %+v; want only the pinned one", historyManager.Count(), item)
+	}
+	if !contains(model.View(), "cleared 2 items") {
+		t.Error("expected a notice of what was cleared")
+	}
+}
+
+func TestClearPromptConfirmsByCountWithPinned(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	if err := historyManager.TogglePin(1); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Text: "X"})
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	if model.clearKeepPinned {
+		t.Fatal("expected Tab to include pinned items")
+	}
+	// 1 is the count with pinned items kept, not the count being deleted
+	model = typeText(model, "1")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if historyManager.Count() != 2 {
+		t.Fatalf("clearing was confirmed by the wrong count; %d items left", historyManager.Count())
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	model = typeText(model, "2")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if historyManager.Count() != 0 {
+		t.Errorf("expected every item deleted, %d left", historyManager.Count())
+	}
+}
+
+func TestClearPromptCancel(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("keep me")
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Text: "X"})
+	model = typeText(model, "yes")
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.mode != TableView || historyManager.Count() != 1 {
+		t.Errorf("after esc: mode %v, %d items; want TableView and 1 item", model.mode, historyManager.Count())
+	}
+}
+
+func TestClearDoesNotRecaptureClipboard(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("on the clipboard")
+	model := NewModel(historyManager)
+	model.SetClipboard(&fakeClipboard{text: "on the clipboard"})
+
+	model = pressKey(model, tea.Key{Text: "X"})
+	model = typeText(model, "yes")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.lastClipboard != "on the clipboard" {
+		t.Errorf("lastClipboard = %q, want the cleared latest item", model.lastClipboard)
+	}
+}
+
+func TestClearPromptNotOpenedWhenEmpty(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model = pressKey(model, tea.Key{Text: "X"})
+	if model.mode != TableView || !contains(model.View(), "history is already empty") {
+		t.Errorf("mode = %v; want TableView with a notice", model.mode)
+	}
+}
+
+func TestClearConfirmed(t *testing.T) {
+	tests := []struct {
+		answer string
+		count  int
+		want   bool
+	}{
+		{"yes", 3, true},
+		{" Yes ", 3, true},
+		{"3", 3, true},
+		{"y", 3, false},
+		{"4", 3, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		if got := clearConfirmed(tt.answer, tt.count); got != tt.want {
+			t.Errorf("clearConfirmed(%q, %d) = %v, want %v", tt.answer, tt.count, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
@@ -15,3 +16,81 @@ func Tick() tea.Cmd {
 		return TickMsg(t)
 	})
 }
+
+// tmuxTickMsg is sent periodically to import new tmux paste buffers
+type tmuxTickMsg time.Time
+
+// tmuxPollInterval is how often tmux paste buffers are checked. Each poll
+// runs tmux, so it is slower than the clipboard tick.
+const tmuxPollInterval = 2 * time.Second
+
+// tmuxTick returns a command that sends a tmuxTickMsg after tmuxPollInterval
+func tmuxTick() tea.Cmd {
+	return tea.Tick(tmuxPollInterval, func(t time.Time) tea.Msg {
+		return tmuxTickMsg(t)
+	})
+}
+
+// DefaultSearchDebounce is how long live search waits after the last
+// keystroke before filtering a large history.
+const DefaultSearchDebounce = 100 * time.Millisecond
+
+// debounceAbove is the history size above which live search waits for the
+// debounce; smaller histories filter on every keystroke, as that is
+// instant. Overridable for tests.
+var debounceAbove = 1000
+
+// searchDebounceMsg fires when a debounced search is due. seq identifies the
+// keystroke that scheduled it; stale messages are ignored.
+type searchDebounceMsg struct {
+	seq int
+}
+
+// debounceSearch returns a command that sends a searchDebounceMsg after d
+func debounceSearch(d time.Duration, seq int) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return searchDebounceMsg{seq: seq}
+	})
+}
+
+// clipboardChangedMsg is sent when the clipboard watcher reports a change
+type clipboardChangedMsg struct{}
+
+// clipboardSettleMsg is sent once debounced clipboard content may have
+// settled
+type clipboardSettleMsg time.Time
+
+// settleClipboard returns a command that sends a clipboardSettleMsg after d
+func settleClipboard(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clipboardSettleMsg(t)
+	})
+}
+
+// waitForChange returns a command that waits for the next clipboard change
+// notification from changes
+func waitForChange(changes <-chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		return clipboardChangedMsg{}
+	}
+}
+
+// lookupDoneMsg is sent when a lookup run from the action menu exits
+type lookupDoneMsg struct {
+	err error
+}
+
+// runLookup returns a command that suspends the UI to run args in a pager,
+// with input, if any, on its stdin
+func runLookup(args []string, input string) tea.Cmd {
+	cmd := pagerCommand(args)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return lookupDoneMsg{err: err}
+	})
+}
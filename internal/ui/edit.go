@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// defaultEditor is run to edit entries when $EDITOR is unset
+const defaultEditor = "vi"
+
+// editDoneMsg is sent when the editor opened by editSelected exits
+type editDoneMsg struct {
+	hash     string // the entry edited
+	path     string // the file it was edited in, removed once read
+	original string
+	err      error
+}
+
+// editorCommand runs $EDITOR, which may carry arguments such as
+// "code --wait", on path
+func editorCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		args = []string{defaultEditor}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// editSelected suspends the UI to edit the selected entry's text in
+// $EDITOR, in a temporary file only the user can read
+func (m *Model) editSelected() tea.Cmd {
+	selected := m.selectedItem()
+	if selected == nil {
+		return nil
+	}
+	if selected.IsBinary() {
+		m.notice = "images can't be edited as text"
+		return nil
+	}
+	text, err := m.historyManager.Text(*selected)
+	if err != nil {
+		m.notice = fmt.Sprintf("can't read entry: %v", err)
+		return nil
+	}
+	f, err := os.CreateTemp("", "clippy-edit-*.txt")
+	if err == nil {
+		_, err = f.WriteString(text)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		m.notice = fmt.Sprintf("can't edit entry: %v", err)
+		if f != nil {
+			removeEditFile(f.Name())
+		}
+		return nil
+	}
+	hash, path := selected.Hash, f.Name()
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editDoneMsg{hash: hash, path: path, original: text, err: err}
+	})
+}
+
+// removeEditFile deletes the temporary file an entry was edited in
+func removeEditFile(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Failed to remove edited file: %v", err)
+	}
+}
+
+// finishEdit saves the edited text as a new entry. It replaces the one
+// edited, which u brings back, unless that is pinned or has an alias or
+// registers, when both are kept.
+func (m *Model) finishEdit(msg editDoneMsg) {
+	defer removeEditFile(msg.path)
+	if msg.err != nil {
+		m.notice = fmt.Sprintf("editor failed: %v", msg.err)
+		return
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.notice = fmt.Sprintf("can't read edited text: %v", err)
+		return
+	}
+	edited := string(data)
+	// Most editors end the file with a newline the entry didn't have
+	if !strings.HasSuffix(msg.original, "\n") {
+		edited = strings.TrimSuffix(strings.TrimSuffix(edited, "\n"), "\r")
+	}
+	switch {
+	case edited == msg.original:
+		m.notice = "entry unchanged"
+		return
+	case strings.TrimSpace(edited) == "":
+		m.notice = "edited text is empty; nothing saved"
+		return
+	}
+
+	if !m.historyManager.AddItem(edited) {
+		m.notice = "edited text is already in history"
+		return
+	}
+	m.notice = "saved edited text as a new entry"
+	if original := m.findByHash(msg.hash); original != nil &&
+		!original.Pinned && original.Alias == "" && original.Registers == "" {
+		m.deleteItems(msg.hash)
+		m.notice = "replaced entry with edited text (u brings back the original)"
+	}
+	if m.filtered != nil {
+		m.filterItems(m.textInput.Value())
+	}
+	m.updateTable()
+	if latest, ok := m.historyManager.Latest(); ok {
+		for i, item := range m.getDisplayItems() {
+			if item.Hash == latest.Hash {
+				m.moveCursor(i)
+				break
+			}
+		}
+	}
+}
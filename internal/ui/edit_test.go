@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// editedFile writes text as if saved from the editor, returning its path
+func editedFile(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "edit.txt")
+	if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "code --wait")
+	if got := editorCommand("/tmp/x").Args; !slices.Equal(got, []string{"code", "--wait", "/tmp/x"}) {
+		t.Errorf("args = %q, want code --wait /tmp/x", got)
+	}
+	t.Setenv("EDITOR", "")
+	if got := editorCommand("/tmp/x").Args; !slices.Equal(got, []string{defaultEditor, "/tmp/x"}) {
+		t.Errorf("args = %q, want %s /tmp/x", got, defaultEditor)
+	}
+}
+
+func TestEditKeyOpensEditor(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	historyManager.AddItem("draft")
+	model := NewModel(historyManager)
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Text: "e"})); cmd == nil {
+		t.Fatal("expected e to run the editor")
+	}
+	files, _ := filepath.Glob(filepath.Join(tmp, "clippy-edit-*"))
+	if len(files) != 1 {
+		t.Fatalf("temp files = %q, want one holding the entry", files)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != "draft" {
+		t.Errorf("temp file holds %q, want the entry's text", data)
+	}
+}
+
+func TestEditDisabledWhileMasked(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	historyManager.AddItem("hunter2")
+	model := NewModel(historyManager)
+	model.SetMasked(true)
+	// The editor would show the hidden content
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Text: "e"})); cmd != nil {
+		t.Error("expected e disabled while masked")
+	}
+	if files, _ := filepath.Glob(filepath.Join(tmp, "clippy-edit-*")); len(files) != 0 {
+		t.Errorf("temp files = %q, want none", files)
+	}
+
+	model.SetMasked(false)
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Text: "e"})); cmd == nil {
+		t.Error("expected e to run the editor once unmasked")
+	}
+}
+
+func TestFinishEditReplacesEntry(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("teh draft")
+	hash := historyManager.GetItems()[0].Hash
+	model := NewModel(historyManager)
+
+	// The newline the editor adds is dropped again
+	path := editedFile(t, "the draft\n")
+	newModel, _ := model.Update(editDoneMsg{hash: hash, path: path, original: "teh draft"})
+	model = newModel.(Model)
+	items := historyManager.GetItems()
+	if len(items) != 1 || items[0].Item != "the draft" {
+		t.Fatalf("items = %+v, want only the edited text", items)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the edited file removed")
+	}
+	if selected := model.selectedItem(); selected == nil || selected.Item != "the draft" {
+		t.Error("expected the edited entry selected")
+	}
+
+	model = typeText(model, "u")
+	if historyManager.Count() != 2 || model.findByHash(hash) == nil {
+		t.Errorf("expected u to bring back the original, have %d items", historyManager.Count())
+	}
+}
+
+func TestFinishEditKeepsPinnedEntry(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("template")
+	if err := historyManager.TogglePin(0); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	hash := historyManager.GetItems()[0].Hash
+	model := NewModel(historyManager)
+
+	newModel, _ := model.Update(editDoneMsg{hash: hash, path: editedFile(t, "filled in"), original: "template"})
+	model = newModel.(Model)
+	if historyManager.Count() != 2 || model.findByHash(hash) == nil {
+		t.Errorf("expected the pinned original kept beside the edit, have %d items", historyManager.Count())
+	}
+	if !contains(model.View().Content, "new entry") {
+		t.Error("expected a notice that the edit was saved as a new entry")
+	}
+}
+
+func TestFinishEditWithoutChanges(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("same")
+	hash := historyManager.GetItems()[0].Hash
+	model := NewModel(historyManager)
+
+	for _, text := range []string{"same\n", "  \n"} {
+		newModel, _ := model.Update(editDoneMsg{hash: hash, path: editedFile(t, text), original: "same"})
+		model = newModel.(Model)
+		if historyManager.Count() != 1 || model.findByHash(hash) == nil {
+			t.Errorf("edit to %q changed history", text)
+		}
+	}
+
+	newModel, _ := model.Update(editDoneMsg{hash: hash, path: editedFile(t, "changed"), original: "same", err: os.ErrNotExist})
+	model = newModel.(Model)
+	if historyManager.Count() != 1 || !contains(model.View().Content, "editor failed") {
+		t.Error("expected a failed editor to change nothing")
+	}
+}
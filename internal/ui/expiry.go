@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// expiryPresets are the TTLs cycled through by the expiry key, shortest first
+var expiryPresets = []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour}
+
+// nextExpiry returns the TTL to apply to an item with remaining time left:
+// the preset after the one it was most likely given, or 0 (no expiry) after
+// the longest.
+func nextExpiry(remaining time.Duration, hasExpiry bool) time.Duration {
+	if !hasExpiry {
+		return expiryPresets[0]
+	}
+	for i, preset := range expiryPresets[:len(expiryPresets)-1] {
+		if remaining <= preset {
+			return expiryPresets[i+1]
+		}
+	}
+	return 0
+}
+
+// cycleExpiry advances the selected item's expiry through expiryPresets
+func (m *Model) cycleExpiry() {
+	items := m.getDisplayItems()
+	cursor := m.tableManager.GetCursor()
+	if cursor >= len(items) {
+		return
+	}
+	selected := items[cursor]
+	ttl := nextExpiry(time.Until(selected.ExpiresAt), !selected.ExpiresAt.IsZero())
+
+	for i, item := range m.historyManager.GetItems() {
+		if item.Hash != selected.Hash {
+			continue
+		}
+		if err := m.historyManager.SetExpiry(i, ttl); err != nil {
+			log.Printf("Failed to set expiry: %v", err)
+			return
+		}
+		updated, _ := m.historyManager.GetItem(i)
+		for j := range m.filtered {
+			if m.filtered[j].Hash == updated.Hash {
+				m.filtered[j].ExpiresAt = updated.ExpiresAt
+			}
+		}
+		m.updateTable()
+		return
+	}
+}
+
+// purgeExpired removes expired items from history and from any active
+// search results
+func (m *Model) purgeExpired(now time.Time) {
+	if m.historyManager.PurgeExpired(now) == 0 {
+		return
+	}
+	if m.filtered != nil {
+		kept := m.filtered[:0]
+		for _, item := range m.filtered {
+			if m.findByHash(item.Hash) != nil {
+				kept = append(kept, item)
+			}
+		}
+		m.filtered = kept
+	}
+	m.updateTable()
+}
+
+// expiryLabel describes when item expires, e.g. "expires in 4m", or "" if
+// it never does
+func expiryLabel(item history.ClipboardHistory, now time.Time) string {
+	if item.ExpiresAt.IsZero() {
+		return ""
+	}
+	remaining := item.ExpiresAt.Sub(now)
+	if item.Pinned {
+		return "expiry paused while pinned"
+	}
+	if remaining <= 0 {
+		return "expiring"
+	}
+	return fmt.Sprintf("expires in %s", formatRemaining(remaining))
+}
+
+// formatRemaining renders a duration at the coarsest useful unit
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func TestNextExpiry(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		hasExpiry bool
+		want      time.Duration
+	}{
+		{0, false, 5 * time.Minute},
+		{4 * time.Minute, true, time.Hour},
+		{59 * time.Minute, true, 24 * time.Hour},
+		{23 * time.Hour, true, 0},
+	}
+	for _, tt := range tests {
+		if got := nextExpiry(tt.remaining, tt.hasExpiry); got != tt.want {
+			t.Errorf("nextExpiry(%v, %v) = %v, want %v", tt.remaining, tt.hasExpiry, got, tt.want)
+		}
+	}
+}
+
+func TestExpiryKeySetsExpiry(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("one-time code")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = pressKey(model, tea.Key{Text: "z"})
+	item, _ := historyManager.GetItem(0)
+	if remaining := time.Until(item.ExpiresAt); remaining <= 4*time.Minute || remaining > 5*time.Minute {
+		t.Fatalf("expected item to expire in 5m, got %v", remaining)
+	}
+	if !contains(model.View().Content, "expires in 4m") {
+		t.Error("expected expiry in preview label")
+	}
+	if !contains(model.View().Content, "⏳") {
+		t.Error("expected expiry marker in table")
+	}
+}
+
+func TestTickPurgesExpiredItems(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("one-time code")
+	historyManager.AddItem("keeper")
+	if err := historyManager.SetExpiry(0, time.Minute); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	model := NewModel(historyManager)
+	model.filtered = append([]history.ClipboardHistory(nil), historyManager.GetItems()...)
+
+	model.purgeExpired(time.Now().Add(2 * time.Minute))
+	if historyManager.Count() != 1 {
+		t.Fatalf("expected expired item to be purged, got %d items", historyManager.Count())
+	}
+	if len(model.filtered) != 1 || model.filtered[0].Item != "keeper" {
+		t.Errorf("expected purged item to leave search results, got %+v", model.filtered)
+	}
+}
+
+func TestExpiryLabel(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	item := history.ClipboardHistory{Item: "code"}
+	if got := expiryLabel(item, now); got != "" {
+		t.Errorf("expected no label without expiry, got %q", got)
+	}
+	item.ExpiresAt = now.Add(90 * time.Minute)
+	if got := expiryLabel(item, now); got != "expires in 1h30m" {
+		t.Errorf("expiryLabel = %q", got)
+	}
+	item.Pinned = true
+	if got := expiryLabel(item, now); got != "expiry paused while pinned" {
+		t.Errorf("expiryLabel pinned = %q", got)
+	}
+}
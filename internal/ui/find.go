@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// previewFind is a search within the selected item's preview
+type previewFind struct {
+	hash    string // item being searched
+	query   string
+	current int // index of the match navigated to
+}
+
+// previewMatch is an occurrence of the find query in a preview row
+type previewMatch struct {
+	row        int
+	start, end int // byte offsets within the row
+}
+
+// activeFind returns the search within the selected item, or nil
+func (m *Model) activeFind() *previewFind {
+	item := m.selectedItem()
+	if m.find == nil || m.find.query == "" || item == nil || item.Hash != m.find.hash {
+		return nil
+	}
+	return m.find
+}
+
+// openFindPrompt starts searching within the selected text item
+func (m *Model) openFindPrompt() {
+	item := m.selectedItem()
+	if item == nil || item.IsBinary() || m.previewHeight <= 0 {
+		return
+	}
+	m.find = &previewFind{hash: item.Hash}
+	m.findOpen = true
+	m.findInput.SetValue("")
+	m.findInput.Focus()
+}
+
+// closeFindPrompt stops editing the query, keeping the matches unless
+// clear is set
+func (m *Model) closeFindPrompt(clear bool) {
+	m.findOpen = false
+	m.findInput.Blur()
+	if clear {
+		m.find = nil
+	}
+}
+
+// updateFindPrompt handles key presses while the find prompt is open,
+// jumping to the first match as the query is typed
+func (m Model) updateFindPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.FindCancel):
+		m.closeFindPrompt(true)
+		return m, nil
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.FindConfirm):
+		m.closeFindPrompt(m.find == nil || m.find.query == "")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.findInput, cmd = m.findInput.Update(msg)
+	if m.find != nil {
+		m.find.query = m.findInput.Value()
+		m.find.current = 0
+		m.showMatch()
+	}
+	return m, cmd
+}
+
+// findMatches returns the occurrences of the find query in the rows of
+// item's preview, ignoring case
+func (m *Model) findMatches(item history.ClipboardHistory) []previewMatch {
+	find := m.activeFind()
+	if find == nil {
+		return nil
+	}
+	rows := m.previewLines(item)
+	var matches []previewMatch
+	for i, row := range rows {
+		for _, start := range matchOffsets(row, find.query) {
+			matches = append(matches, previewMatch{row: i, start: start, end: start + len(find.query)})
+		}
+	}
+	return matches
+}
+
+// matchOffsets returns the byte offsets of non-overlapping occurrences of
+// query in s, ignoring case
+func matchOffsets(s, query string) []int {
+	var offsets []int
+	for i := 0; i+len(query) <= len(s); {
+		if strings.EqualFold(s[i:i+len(query)], query) {
+			offsets = append(offsets, i)
+			i += len(query)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return offsets
+}
+
+// nextMatch moves to the match delta positions away, wrapping around, and
+// scrolls the preview to it
+func (m *Model) nextMatch(delta int) {
+	find := m.activeFind()
+	if find == nil {
+		return
+	}
+	matches := m.findMatches(*m.selectedItem())
+	if len(matches) == 0 {
+		return
+	}
+	find.current = ((find.current+delta)%len(matches) + len(matches)) % len(matches)
+	m.showMatch()
+}
+
+// showMatch scrolls the preview to the current match
+func (m *Model) showMatch() {
+	find := m.activeFind()
+	if find == nil {
+		return
+	}
+	item := m.selectedItem()
+	matches := m.findMatches(*item)
+	if find.current < len(matches) {
+		row := matches[find.current].row
+		m.scrollPreviewTo(*item, row, row)
+	}
+}
+
+// highlightMatches styles the find query's matches in rows, the current
+// match stronger than the rest
+func (m *Model) highlightMatches(item history.ClipboardHistory, rows []string) {
+	matches := m.findMatches(item)
+	if len(matches) == 0 {
+		return
+	}
+	current := m.find.current
+
+	// Style from the end of each row so earlier offsets stay valid
+	for i := len(matches) - 1; i >= 0; i-- {
+		match := matches[i]
+		style := m.theme.PreviewMatch
+		if i == current {
+			style = m.theme.PreviewCurrentMatch
+		}
+		row := rows[match.row]
+		rows[match.row] = row[:match.start] + style.Render(row[match.start:match.end]) + row[match.end:]
+	}
+}
+
+// findLabel describes the find results, e.g. "match 2 of 5"
+func (m *Model) findLabel() string {
+	find := m.activeFind()
+	if find == nil {
+		return ""
+	}
+	matches := m.findMatches(*m.selectedItem())
+	if len(matches) == 0 {
+		return fmt.Sprintf("no matches for %q", find.query)
+	}
+	return fmt.Sprintf("match %d of %d", find.current+1, len(matches))
+}
+
+// findPromptView renders the find prompt in place of the preview label
+func (m *Model) findPromptView() string {
+	view := "Find: " + m.findInput.View()
+	if label := m.findLabel(); label != "" {
+		view += " • " + label
+	}
+	return view
+}
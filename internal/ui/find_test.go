@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestMatchOffsets(t *testing.T) {
+	tests := []struct {
+		s, query string
+		want     []int
+	}{
+		{"Error: error ERROR", "error", []int{0, 7, 13}},
+		{"aaaa", "aa", []int{0, 2}},
+		{"héllo hé", "HÉ", []int{0, 7}},
+		{"nothing", "x", nil},
+	}
+	for _, tt := range tests {
+		if got := matchOffsets(tt.s, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchOffsets(%q, %q) = %v, want %v", tt.s, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewFindNavigatesMatches(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	lines := make([]string, 40)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line-%02d", i+1)
+	}
+	lines[4] = "ERROR: disk full"
+	lines[34] = "retrying after error"
+	historyManager.AddItem(strings.Join(lines, "\n"))
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	model = typeText(model, "/")
+	if !model.findOpen {
+		t.Fatal("expected / to open the find prompt")
+	}
+	// q is part of the query, not quit
+	model = typeText(model, "q")
+	if !contains(model.View().Content, `no matches for "q"`) {
+		t.Errorf("expected no matches, got:\n%s", model.View().Content)
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	model = typeText(model, "error")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.findOpen {
+		t.Fatal("expected Enter to close the prompt")
+	}
+	if !contains(model.View().Content, "match 1 of 2") {
+		t.Errorf("expected match count, got:\n%s", model.View().Content)
+	}
+
+	// n jumps to the second match, scrolling the preview to it
+	model = typeText(model, "n")
+	view := model.View().Content
+	if !contains(view, "match 2 of 2") || !contains(view, "retrying after error") {
+		t.Errorf("expected to jump to the second match, got:\n%s", view)
+	}
+	model = typeText(model, "n")
+	if !contains(model.View().Content, "match 1 of 2") {
+		t.Error("expected n to wrap around to the first match")
+	}
+	model = typeText(model, "N")
+	if !contains(model.View().Content, "match 2 of 2") {
+		t.Error("expected N to go back to the previous match")
+	}
+
+	// Esc clears the find before leaving the preview
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.activeFind() != nil || !model.previewFocus {
+		t.Error("expected Esc to clear the find and keep the preview focused")
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("foo bar foo")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+	model.find = &previewFind{hash: model.selectedItem().Hash, query: "foo", current: 1}
+
+	rows := []string{"foo bar foo"}
+	model.highlightMatches(*model.selectedItem(), rows)
+	want := model.theme.PreviewMatch.Render("foo") + " bar " + model.theme.PreviewCurrentMatch.Render("foo")
+	if rows[0] != want {
+		t.Errorf("highlighted row = %q, want %q", rows[0], want)
+	}
+}
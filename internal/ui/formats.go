@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/actions"
+	"github.com/bvdwalt/clippy/internal/clipformat"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// Overridable for tests.
+var (
+	readFormats = clipformat.Read
+	writeFormat = clipformat.Write
+)
+
+// recordClipboard adds text copied to the clipboard to history, along with
+// any richer formats, such as HTML, the clipboard holds with it
+func (m *Model) recordClipboard(content string) {
+	// The formats are extras; the text is recorded without them
+	formats, _ := readFormats()
+	m.historyManager.AddItemWithFormats(content, formats)
+}
+
+// formatActions offers copying item back in each of its richer formats.
+// The terminal clipboard used in headless mode only takes plain text.
+func (m *Model) formatActions(item history.ClipboardHistory) []actions.Action {
+	if m.headless {
+		return nil
+	}
+	var available []actions.Action
+	for _, mimeType := range item.Formats {
+		available = append(available, actions.Action{
+			Label:    "copy as " + clipformat.Name(mimeType),
+			MimeType: mimeType,
+		})
+	}
+	return available
+}
+
+// copyFormat places the item with hash on the clipboard as mimeType, or
+// picks that representation in pick mode
+func (m Model) copyFormat(hash, mimeType string) (tea.Model, tea.Cmd) {
+	item := m.findByHash(hash)
+	if item == nil {
+		return m, nil
+	}
+	data, err := m.historyManager.Format(*item, mimeType)
+	if err != nil {
+		log.Printf("Failed to load %s: %v", mimeType, err)
+		return m, nil
+	}
+	if m.pickMode {
+		m.picked = &history.ClipboardHistory{Item: string(data), Kind: history.KindText}
+		return m, tea.Quit
+	}
+	text, err := m.historyManager.Text(*item)
+	if err != nil {
+		log.Printf("Failed to load clip: %v", err)
+		return m, nil
+	}
+	if err := writeFormat(text, mimeType, data); err != nil {
+		log.Printf("Failed to write %s to clipboard: %v", mimeType, err)
+		return m, nil
+	}
+	m.lastClipboard = text
+	return m, nil
+}
+
+// formatsLabel names the richer formats item was copied with, e.g. "also
+// HTML", or "" if none
+func formatsLabel(item history.ClipboardHistory) string {
+	if len(item.Formats) == 0 {
+		return ""
+	}
+	names := make([]string, len(item.Formats))
+	for i, mimeType := range item.Formats {
+		names[i] = clipformat.Name(mimeType)
+	}
+	return "also " + strings.Join(names, ", ")
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// useFormats stubs the clipboard's richer formats with formats, returning
+// what is written as text, MIME type and data
+func useFormats(t *testing.T, formats map[string][]byte) *[]string {
+	t.Helper()
+	origRead, origWrite := readFormats, writeFormat
+	t.Cleanup(func() { readFormats, writeFormat = origRead, origWrite })
+	readFormats = func() (map[string][]byte, error) { return formats, nil }
+	var written []string
+	writeFormat = func(text, mimeType string, data []byte) error {
+		written = append(written, text, mimeType, string(data))
+		return nil
+	}
+	return &written
+}
+
+func TestCapturedFormatsCopyBack(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	written := useFormats(t, map[string][]byte{"text/html": []byte("<b>bold</b> text")})
+
+	model := NewModel(historyManager)
+	model.SetClipboard(&fakeClipboard{text: "bold text"})
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+	newModel, _ = model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	if !contains(model.View().Content, "also HTML") {
+		t.Error("expected the preview label to list the HTML format")
+	}
+
+	model = typeText(model, "x")
+	if !contains(model.View().Content, "1. copy as HTML") {
+		t.Fatal("expected a copy as HTML action")
+	}
+	model = typeText(model, "1")
+	if w := *written; len(w) != 3 || w[0] != "bold text" || w[1] != "text/html" || w[2] != "<b>bold</b> text" {
+		t.Errorf("wrote %q, want the text with its HTML", w)
+	}
+	if model.mode != TableView {
+		t.Error("expected the menu to close")
+	}
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// highlightStyle is the chroma style code is previewed in, chosen to suit
+// the dark theme like the markdown preview
+const highlightStyle = "monokai"
+
+// highlightCache holds the last preview highlighted, shared by copies of
+// the model like markdownCache
+type highlightCache struct {
+	content string
+	lines   []string
+}
+
+// SetSyntaxHighlight colors code and JSON entries in the preview; the
+// toggle key switches it.
+func (m *Model) SetSyntaxHighlight(enabled bool) {
+	m.plainCode = !enabled
+}
+
+// highlightLexer returns the lexer to color item's preview with, or nil
+// when it isn't highlighted: only code and JSON are, with the language
+// detected on capture or, failing that, guessed by chroma.
+func (m *Model) highlightLexer(item history.ClipboardHistory) chroma.Lexer {
+	if m.plainCode || m.previewFocus || m.activeFind() != nil || item.Sensitive != "" {
+		return nil
+	}
+	switch {
+	case item.Type == detect.JSON:
+		return lexers.Get("json")
+	case item.Type != detect.Code:
+		return nil
+	case item.Language != "":
+		if lexer := lexers.Get(string(item.Language)); lexer != nil {
+			return lexer
+		}
+	}
+	return lexers.Analyse(item.Item)
+}
+
+// highlightedLines colors content with lexer, returning one styled line
+// per source line so they can be wrapped like plain text
+func (m *Model) highlightedLines(content string, lexer chroma.Lexer) ([]string, bool) {
+	if c := m.highlighted; c.lines != nil && c.content == content {
+		return c.lines, true
+	}
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		log.Printf("Failed to highlight preview: %v", err)
+		return nil, false
+	}
+	style := chromastyles.Get(highlightStyle)
+	lines := []string{""}
+	for _, token := range tokens.Tokens() {
+		render := tokenStyle(style.Get(token.Type)).Render
+		for i, part := range strings.Split(token.Value, "\n") {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			if part != "" {
+				lines[len(lines)-1] += render(part)
+			}
+		}
+	}
+	// Lexers end the text with a newline it may not have had
+	if len(lines) > strings.Count(content, "\n")+1 {
+		lines = lines[:strings.Count(content, "\n")+1]
+	}
+	*m.highlighted = highlightCache{content: content, lines: lines}
+	return lines, true
+}
+
+// tokenStyle converts a chroma style entry to lipgloss, leaving out its
+// background so the preview's shows through
+func tokenStyle(entry chroma.StyleEntry) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	if entry.Colour.IsSet() {
+		style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+	if entry.Bold == chroma.Yes {
+		style = style.Bold(true)
+	}
+	if entry.Italic == chroma.Yes {
+		style = style.Italic(true)
+	}
+	return style
+}
+
+// highlightLabel notes how a code or JSON entry is previewed, e.g. "go
+// highlighted (S for plain)", or returns "" for other content
+func (m *Model) highlightLabel(item history.ClipboardHistory) string {
+	if item.Type != detect.Code && item.Type != detect.JSON {
+		return ""
+	}
+	if m.plainCode {
+		return "plain (" + m.keys.Highlight.Help().Key + " to highlight)"
+	}
+	lexer := m.highlightLexer(item)
+	if lexer == nil {
+		return ""
+	}
+	return strings.ToLower(lexer.Config().Name) + " highlighted (" + m.keys.Highlight.Help().Key + " for plain)"
+}
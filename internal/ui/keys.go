@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// keyMap holds the key bindings of the table view. The help line is
+// generated from it, so a binding's keys and its help stay in one place.
+type keyMap struct {
+	Navigate     key.Binding // handled by the table; listed for help only
+	Top          key.Binding // pressed twice, gg; help covers Bottom too
+	Bottom       key.Binding
+	HalfPageDown key.Binding // help covers HalfPageUp too
+	HalfPageUp   key.Binding
+	NextResult   key.Binding // next entry matching the last search; help covers PrevResult too
+	PrevResult   key.Binding
+	Copy         key.Binding
+	QuickSelect  key.Binding // 1-9 copy that visible row
+	CopyPrimary  key.Binding // disabled unless the primary selection is captured
+	Pin          key.Binding
+	MoveUp       key.Binding // move a pinned item; help covers MoveDown too
+	MoveDown     key.Binding
+	Alias        key.Binding
+	Register     key.Binding // store in a register; help covers Recall too
+	Recall       key.Binding
+	Expire       key.Binding
+	Edit         key.Binding
+	Actions      key.Binding
+	Delete       key.Binding
+	Undo         key.Binding // help covers Redo too
+	Redo         key.Binding
+	Mark         key.Binding
+	CopySteps    key.Binding // copy marked items; help covers CopyChain too
+	CopyChain    key.Binding
+	CopyJoined   key.Binding
+	DeleteMarked key.Binding
+	ClearAll     key.Binding
+	ExportMarked key.Binding
+	Search       key.Binding
+	Type         key.Binding
+	Sort         key.Binding
+	Rules        key.Binding // disabled unless rules can be saved
+	Refresh      key.Binding
+	Incognito    key.Binding
+	Markdown     key.Binding
+	Highlight    key.Binding
+	TimeFormat   key.Binding
+	Mask         key.Binding
+	Peek         key.Binding // disabled unless masked
+	FocusPreview key.Binding
+	ScrollDown   key.Binding // scroll the preview; help covers ScrollUp too
+	ScrollUp     key.Binding
+	PageDown     key.Binding // page the preview; help covers PageUp too
+	PageUp       key.Binding
+	ClearSearch  key.Binding
+	Help         key.Binding
+	Palette      key.Binding
+	Quit         key.Binding
+
+	// While typing a search
+	SearchApply  key.Binding
+	SearchCancel key.Binding // handled with the global shortcuts; listed for help only
+
+	// While the help overlay is open
+	CloseHelp key.Binding
+
+	// While the preview has focus
+	PreviewDown key.Binding
+	PreviewUp   key.Binding
+	PreviewBack key.Binding
+	SelectLines key.Binding
+	PreviewFind key.Binding
+	FindNext    key.Binding // help covers FindPrev too
+	FindPrev    key.Binding
+	ClearFind   key.Binding
+
+	// While typing in the find prompt
+	FindConfirm key.Binding
+	FindCancel  key.Binding
+
+	// While selecting lines in the preview
+	CopyLines    key.Binding
+	CancelSelect key.Binding
+	ExtendSelect key.Binding // handled by PreviewDown/PreviewUp; listed for help only
+
+	// While the action menu is open
+	ActionNavigate key.Binding // handled by PreviewDown/PreviewUp; listed for help only
+	ActionRun      key.Binding
+	ActionNumber   key.Binding // handled by the menu; listed for help only
+	ActionCancel   key.Binding
+
+	// While the command palette is open
+	PaletteNavigate key.Binding // handled by PaletteDown/PaletteUp; listed for help only
+	PaletteDown     key.Binding
+	PaletteUp       key.Binding
+	PaletteRun      key.Binding
+	PaletteClose    key.Binding
+
+	// Capture rules mode
+	RuleNew    key.Binding
+	RuleEdit   key.Binding
+	RuleDelete key.Binding
+	RuleSave   key.Binding
+	RuleClose  key.Binding
+	RuleKind   key.Binding
+	FormNext   key.Binding // help covers FormPrev too
+	FormPrev   key.Binding
+	FormApply  key.Binding
+	FormCancel key.Binding
+}
+
+// defaultKeyMap returns the built-in key bindings
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Navigate:     key.NewBinding(key.WithKeys("up", "k", "down", "j"), key.WithHelp("↑/k ↓/j", "navigate")),
+		Top:          key.NewBinding(key.WithKeys("g"), key.WithHelp("gg/G", "top/bottom")),
+		Bottom:       key.NewBinding(key.WithKeys("G")),
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("Ctrl+d/u", "half page down/up")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+		NextResult:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n/N", "next/prev result")),
+		PrevResult:   key.NewBinding(key.WithKeys("N")),
+		Copy:         key.NewBinding(key.WithKeys("enter", "c"), key.WithHelp("Enter/c", "copy")),
+		QuickSelect:  key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "copy visible row")),
+		CopyPrimary:  key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "copy to selection"), key.WithDisabled()),
+		Pin:          key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pin")),
+		MoveUp:       key.NewBinding(key.WithKeys("["), key.WithHelp("[/]", "move pinned")),
+		MoveDown:     key.NewBinding(key.WithKeys("]")),
+		Alias:        key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "alias")),
+		Register:     key.NewBinding(key.WithKeys("\""), key.WithHelp("\"/'", "store/copy register")),
+		Recall:       key.NewBinding(key.WithKeys("'")),
+		Expire:       key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "expire")),
+		Edit:         key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit in $EDITOR")),
+		Actions:      key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "actions")),
+		Delete:       key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+		Undo:         key.NewBinding(key.WithKeys("u"), key.WithHelp("u/Ctrl+r", "undo/redo delete")),
+		Redo:         key.NewBinding(key.WithKeys("ctrl+r")),
+		Mark:         key.NewBinding(key.WithKeys("m", "space"), key.WithHelp("m/Space", "mark")),
+		CopySteps:    key.NewBinding(key.WithKeys("M"), key.WithHelp("M/&", "copy marked as steps/&&")),
+		CopyChain:    key.NewBinding(key.WithKeys("&")),
+		CopyJoined:   key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "copy marked joined")),
+		DeleteMarked: key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "delete marked")),
+		ClearAll:     key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "clear history")),
+		ExportMarked: key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "export marked")),
+		Search:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+		Type:         key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "type")),
+		Sort:         key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "sort A-Z")),
+		Rules:        key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "capture rules"), key.WithDisabled()),
+		Refresh:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+		Incognito:    key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "incognito")),
+		Markdown:     key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "markdown")),
+		Highlight:    key.NewBinding(key.WithKeys("S"), key.WithHelp("S", "highlight code")),
+		TimeFormat:   key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "relative/absolute time")),
+		Mask:         key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "hide content")),
+		Peek:         key.NewBinding(key.WithKeys("space"), key.WithHelp("hold Space", "peek"), key.WithDisabled()),
+		FocusPreview: key.NewBinding(key.WithKeys("tab"), key.WithHelp("Tab", "focus preview")),
+		ScrollDown:   key.NewBinding(key.WithKeys("J"), key.WithHelp("J/K", "scroll preview")),
+		ScrollUp:     key.NewBinding(key.WithKeys("K")),
+		PageDown:     key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("PgUp/PgDn", "page preview")),
+		PageUp:       key.NewBinding(key.WithKeys("pgup")),
+		ClearSearch:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear search")),
+		Help:         key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "all keys")),
+		Palette:      key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("Ctrl+p", "commands")),
+		Quit:         key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+
+		SearchApply:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "apply")),
+		SearchCancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("Esc", "cancel")),
+
+		CloseHelp: key.NewBinding(key.WithKeys("?", "esc", "q"), key.WithHelp("?/Esc/q", "close")),
+
+		PreviewDown: key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↑/k ↓/j", "scroll")),
+		PreviewUp:   key.NewBinding(key.WithKeys("up", "k")),
+		PreviewBack: key.NewBinding(key.WithKeys("tab", "esc"), key.WithHelp("Tab/Esc", "back to table")),
+		SelectLines: key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "select lines")),
+		PreviewFind: key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "find")),
+		FindNext:    key.NewBinding(key.WithKeys("n"), key.WithHelp("n/N", "next/prev match")),
+		FindPrev:    key.NewBinding(key.WithKeys("N")),
+		ClearFind:   key.NewBinding(key.WithKeys("esc"), key.WithHelp("Esc", "clear find")),
+		FindConfirm: key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "done")),
+		FindCancel:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("Esc", "cancel")),
+
+		CopyLines:    key.NewBinding(key.WithKeys("y", "enter"), key.WithHelp("y/Enter", "copy lines")),
+		CancelSelect: key.NewBinding(key.WithKeys("esc", "v"), key.WithHelp("Esc", "cancel")),
+		ExtendSelect: key.NewBinding(key.WithKeys("up", "k", "down", "j"), key.WithHelp("↑/k ↓/j", "extend")),
+
+		ActionNavigate: key.NewBinding(key.WithKeys("up", "k", "down", "j"), key.WithHelp("↑/k ↓/j", "choose")),
+		ActionRun:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "run")),
+		ActionNumber:   key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "run by number")),
+		ActionCancel:   key.NewBinding(key.WithKeys("esc", "x"), key.WithHelp("Esc", "cancel")),
+
+		PaletteNavigate: key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓", "choose")),
+		PaletteDown:     key.NewBinding(key.WithKeys("down", "ctrl+n")),
+		PaletteUp:       key.NewBinding(key.WithKeys("up")),
+		PaletteRun:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "run")),
+		PaletteClose:    key.NewBinding(key.WithKeys("esc", "ctrl+p"), key.WithHelp("Esc", "close")),
+
+		RuleNew:    key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new rule")),
+		RuleEdit:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "edit")),
+		RuleDelete: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+		RuleSave:   key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "save to config")),
+		RuleClose:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("Esc", "close")),
+		RuleKind:   key.NewBinding(key.WithKeys("left", "right", "space"), key.WithHelp("←/→", "change rule")),
+		FormNext:   key.NewBinding(key.WithKeys("tab", "down"), key.WithHelp("Tab/↑↓", "move between fields")),
+		FormPrev:   key.NewBinding(key.WithKeys("shift+tab", "up")),
+		FormApply:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "done")),
+		FormCancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("Esc", "cancel")),
+	}
+}
+
+// tableHelp lists the bindings shown in the table view's help, most
+// important first, since the end is elided on narrow terminals
+func (k keyMap) tableHelp(searching bool) []key.Binding {
+	bindings := []key.Binding{
+		k.Navigate, k.Copy, k.CopyPrimary, k.Peek, k.Search, k.Help, k.Palette, k.Quit, k.QuickSelect, k.Pin, k.Edit, k.Delete, k.Undo, k.Alias,
+		k.MoveUp, k.Register, k.Mark, k.NextResult, k.Top, k.HalfPageDown, k.CopySteps, k.CopyJoined, k.DeleteMarked, k.ExportMarked, k.ClearAll, k.Actions, k.Expire, k.Type, k.Sort, k.Rules, k.Markdown, k.Highlight, k.TimeFormat, k.Mask, k.Incognito, k.Refresh, k.FocusPreview, k.ScrollDown, k.PageDown,
+	}
+	if searching {
+		bindings = append(bindings, k.ClearSearch)
+	}
+	return bindings
+}
+
+// previewHelp lists the bindings shown while the preview has focus, with
+// match navigation while a find is active
+func (k keyMap) previewHelp(finding bool) []key.Binding {
+	if finding {
+		return []key.Binding{k.FindNext, k.PreviewFind, k.PreviewDown, k.ClearFind, k.Quit}
+	}
+	return []key.Binding{k.PreviewDown, k.PreviewFind, k.SelectLines, k.Actions, k.PageDown, k.PreviewBack, k.Help, k.Quit}
+}
+
+// searchHelp lists the bindings that work while typing a search
+func (k keyMap) searchHelp() []key.Binding {
+	return []key.Binding{k.SearchApply, k.SearchCancel}
+}
+
+// findPromptHelp lists the bindings shown while typing a find query
+func (k keyMap) findPromptHelp() []key.Binding {
+	return []key.Binding{k.FindConfirm, k.FindCancel}
+}
+
+// actionHelp lists the bindings shown in the action menu
+func (k keyMap) actionHelp() []key.Binding {
+	return []key.Binding{k.ActionNavigate, k.ActionRun, k.ActionNumber, k.ActionCancel}
+}
+
+// paletteHelp lists the bindings shown in the command palette
+func (k keyMap) paletteHelp() []key.Binding {
+	return []key.Binding{k.PaletteNavigate, k.PaletteRun, k.PaletteClose}
+}
+
+// rulesHelp lists the bindings shown in the capture rules list
+func (k keyMap) rulesHelp() []key.Binding {
+	return []key.Binding{k.ActionNavigate, k.RuleNew, k.RuleEdit, k.RuleDelete, k.RuleSave, k.RuleClose}
+}
+
+// ruleFormHelp lists the bindings shown while editing a capture rule
+func (k keyMap) ruleFormHelp() []key.Binding {
+	return []key.Binding{k.FormNext, k.RuleKind, k.FormApply, k.FormCancel}
+}
+
+// selectionHelp lists the bindings shown while selecting preview lines
+func (k keyMap) selectionHelp() []key.Binding {
+	return []key.Binding{k.ExtendSelect, k.CopyLines, k.CancelSelect, k.Quit}
+}
+
+const (
+	helpSeparator = " • "
+	helpEllipsis  = "…"
+	// maxHelpLines is how many lines the help may stack onto before the
+	// remaining bindings are elided.
+	maxHelpLines = 2
+)
+
+// renderHelp lays out bindings' help as "key desc" items, stacking them
+// onto up to maxHelpLines lines of width and eliding whatever doesn't fit.
+// A width of 0 or less keeps everything on one line.
+func renderHelp(bindings []key.Binding, width int) string {
+	items := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		if !b.Enabled() || b.Help().Key == "" {
+			continue
+		}
+		items = append(items, b.Help().Key+" "+b.Help().Desc)
+	}
+	if width <= 0 {
+		return strings.Join(items, helpSeparator)
+	}
+
+	var lines [][]string
+	var line []string
+	for _, item := range items {
+		if len(line) > 0 && lineWidth(line)+lipgloss.Width(helpSeparator+item) > width {
+			lines = append(lines, line)
+			line = nil
+		}
+		line = append(line, ansi.Truncate(item, width, helpEllipsis))
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > maxHelpLines {
+		lines = lines[:maxHelpLines]
+		last := lines[maxHelpLines-1]
+		for len(last) > 0 && lineWidth(last)+lipgloss.Width(helpSeparator+helpEllipsis) > width {
+			last = last[:len(last)-1]
+		}
+		lines[maxHelpLines-1] = append(last, helpEllipsis)
+	}
+
+	rendered := make([]string, len(lines))
+	for i, l := range lines {
+		rendered[i] = strings.Join(l, helpSeparator)
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// lineWidth is the display width of items joined into one help line
+func lineWidth(items []string) int {
+	return lipgloss.Width(strings.Join(items, helpSeparator))
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+func TestRenderHelpFitsWidth(t *testing.T) {
+	bindings := defaultKeyMap().tableHelp(false)
+	full := renderHelp(bindings, 0)
+	if strings.Contains(full, "\n") || !strings.HasPrefix(full, "↑/k ↓/j navigate • Enter/c copy") {
+		t.Fatalf("unexpected single-line help %q", full)
+	}
+	if got := renderHelp(bindings, lipgloss.Width(full)); got != full {
+		t.Errorf("help that fits should stay on one line, got %q", got)
+	}
+
+	for _, width := range []int{100, 60, 30} {
+		help := renderHelp(bindings, width)
+		lines := strings.Split(help, "\n")
+		if len(lines) > maxHelpLines {
+			t.Errorf("width %d: %d lines, want at most %d", width, len(lines), maxHelpLines)
+		}
+		for _, line := range lines {
+			if lipgloss.Width(line) > width {
+				t.Errorf("width %d: line %q is %d wide", width, line, lipgloss.Width(line))
+			}
+		}
+		if !strings.HasPrefix(help, "↑/k ↓/j navigate") {
+			t.Errorf("width %d: most important binding should come first, got %q", width, help)
+		}
+	}
+
+	if narrow := renderHelp(bindings, 30); !strings.HasSuffix(narrow, helpEllipsis) {
+		t.Errorf("expected bindings that don't fit to be elided, got %q", narrow)
+	}
+}
+
+func TestHelpFollowsKeyMap(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	historyManager.AddItem("item")
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 60, Height: 30})
+	model = newModel.(Model)
+	if view := model.View().Content; !contains(view, "Enter/c copy") || !contains(view, "…") {
+		t.Error("expected generated, elided help on a narrow terminal")
+	}
+
+	model.SetPickMode(true)
+	if !contains(model.View().Content, "Enter/c insert") {
+		t.Error("expected pick mode to relabel the copy binding")
+	}
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// lineSelection is a range of source lines selected in the preview, from
+// where the selection started (anchor) to where it was extended (cursor)
+type lineSelection struct {
+	hash   string // item the selection was made in
+	anchor int
+	cursor int
+}
+
+// bounds returns the first and last selected lines
+func (s lineSelection) bounds() (int, int) {
+	return min(s.anchor, s.cursor), max(s.anchor, s.cursor)
+}
+
+// activeSelection returns the line selection if it belongs to the selected
+// item, or nil
+func (m *Model) activeSelection() *lineSelection {
+	item := m.selectedItem()
+	if m.selection == nil || item == nil || item.Hash != m.selection.hash {
+		return nil
+	}
+	return m.selection
+}
+
+// startSelection selects the first line shown in the preview of the
+// selected text item
+func (m *Model) startSelection() {
+	item := m.selectedItem()
+	if item == nil || item.IsBinary() || m.previewHeight <= 0 {
+		return
+	}
+	rows, sources := m.previewRows(*item)
+	line := sources[m.previewScroll(*item, len(rows))]
+	m.selection = &lineSelection{hash: item.Hash, anchor: line, cursor: line}
+}
+
+// extendSelection moves the selection's cursor by delta lines, scrolling
+// the preview to keep it visible
+func (m *Model) extendSelection(delta int) {
+	sel := m.activeSelection()
+	if sel == nil {
+		return
+	}
+	item := m.selectedItem()
+	_, sources := m.previewRows(*item)
+	sel.cursor = min(max(sel.cursor+delta, 0), sources[len(sources)-1])
+
+	first, last := -1, -1
+	for i, source := range sources {
+		if source == sel.cursor {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	m.scrollPreviewTo(*item, first, last)
+}
+
+// selectionLabel describes the selected lines, e.g. "lines 2-4 selected"
+func (m *Model) selectionLabel() string {
+	sel := m.activeSelection()
+	if sel == nil {
+		return ""
+	}
+	first, last := sel.bounds()
+	if first == last {
+		return fmt.Sprintf("line %d selected", first+1)
+	}
+	return fmt.Sprintf("lines %d-%d selected", first+1, last+1)
+}
+
+// copySelection copies the selected lines of the full entry and ends the
+// selection
+func (m *Model) copySelection() tea.Cmd {
+	sel := m.activeSelection()
+	if sel == nil {
+		return nil
+	}
+	m.selection = nil
+	text, err := m.historyManager.Text(*m.selectedItem())
+	if err != nil {
+		log.Printf("Failed to load clip: %v", err)
+		return nil
+	}
+	first, last := sel.bounds()
+	selected := selectLines(text, first, last)
+	if m.pickMode {
+		m.picked = &history.ClipboardHistory{Item: selected, Kind: history.KindText}
+		return tea.Quit
+	}
+	return m.copyText(selected)
+}
+
+// selectLines returns lines first through last of text
+func selectLines(text string, first, last int) string {
+	lines := strings.Split(text, "\n")
+	first = min(first, len(lines)-1)
+	last = min(last, len(lines)-1)
+	return strings.Join(lines[first:last+1], "\n")
+}
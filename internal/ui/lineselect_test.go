@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestSelectLines(t *testing.T) {
+	text := "one\ntwo\nthree"
+	tests := []struct {
+		first, last int
+		want        string
+	}{
+		{0, 0, "one"},
+		{1, 2, "two\nthree"},
+		{2, 5, "three"},
+	}
+	for _, tt := range tests {
+		if got := selectLines(text, tt.first, tt.last); got != tt.want {
+			t.Errorf("selectLines(%d, %d) = %q, want %q", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewLineSelectionCopiesRange(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("cmd-%02d", i+1)
+	}
+	historyManager.AddItem(strings.Join(lines, "\n"))
+
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+	height := model.previewHeight
+
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = typeText(model, "v")
+	if !contains(model.View().Content, "line 2 selected") {
+		t.Fatalf("expected the first visible line to be selected, got:\n%s", model.View().Content)
+	}
+	if !contains(model.View().Content, "copy lines") {
+		t.Error("expected selection help")
+	}
+
+	// Extending past the bottom of the preview scrolls it
+	for range height + 1 {
+		model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	}
+	last := height + 3
+	view := model.View().Content
+	if !contains(view, fmt.Sprintf("lines 2-%d selected", last)) {
+		t.Fatalf("expected the selection to extend, got:\n%s", view)
+	}
+	if !contains(view, fmt.Sprintf("cmd-%02d", last)) {
+		t.Error("expected the preview to scroll to the selection cursor")
+	}
+
+	newModel, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: 'y', Text: "y"}))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected quit command")
+	}
+	want := strings.Join(lines[1:last], "\n")
+	if item, ok := model.Picked(); !ok || item.Item != want {
+		t.Errorf("Picked() = %q, want %q", item.Item, want)
+	}
+}
+
+func TestPreviewLineSelectionCancel(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first\nsecond")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	model = typeText(model, "v")
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.activeSelection() != nil {
+		t.Error("expected Esc to cancel the selection")
+	}
+	if !model.previewFocus {
+		t.Error("expected the preview to keep focus after cancelling")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.previewFocus {
+		t.Error("expected a second Esc to return to the table")
+	}
+}
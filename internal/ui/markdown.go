@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	"charm.land/glamour/v2"
+	"charm.land/glamour/v2/styles"
+	"github.com/bvdwalt/clippy/internal/detect"
+)
+
+// markdownCache holds the last markdown preview rendered, since rendering
+// is too slow to repeat on every frame
+type markdownCache struct {
+	content string
+	width   int
+	rows    []string
+}
+
+// SetRawMarkdown shows markdown entries as their source instead of
+// rendering them; the toggle key switches between the two.
+func (m *Model) SetRawMarkdown(raw bool) {
+	m.rawMarkdown = raw
+}
+
+// showsMarkdown reports whether content is previewed rendered as markdown.
+// The focused preview shows the source, so that finding and selecting work
+// on its lines.
+func (m *Model) showsMarkdown(content string) bool {
+	return !m.rawMarkdown && !m.previewFocus && m.activeFind() == nil && detect.IsMarkdown(content)
+}
+
+// markdownRows renders content as markdown wrapped to the preview width,
+// reporting false if it can't be rendered
+func (m *Model) markdownRows(content string) ([]string, bool) {
+	width := m.previewTextWidth()
+	if c := m.markdown; c.rows != nil && c.content == content && c.width == width {
+		return c.rows, true
+	}
+	rendered, err := renderMarkdown(content, width)
+	if err != nil {
+		log.Printf("Failed to render markdown: %v", err)
+		return nil, false
+	}
+	rows := strings.Split(strings.Trim(rendered, "\n"), "\n")
+	for i, row := range rows {
+		// Lines are padded with spaces to the wrap width
+		rows[i] = strings.TrimRight(row, " ")
+	}
+	*m.markdown = markdownCache{content: content, width: width, rows: rows}
+	return rows, true
+}
+
+// renderMarkdown renders content for a terminal with glamour's dark style,
+// without its document margin
+func renderMarkdown(content string, width int) (string, error) {
+	style := styles.DarkStyleConfig
+	style.Document.Margin = nil
+	style.Document.BlockPrefix = ""
+	style.Document.BlockSuffix = ""
+	renderer, err := glamour.NewTermRenderer(glamour.WithStyles(style), glamour.WithWordWrap(width))
+	if err != nil {
+		return "", err
+	}
+	return renderer.Render(content)
+}
+
+// markdownLabel notes how a markdown entry is previewed, e.g. "markdown
+// (R for source)", or returns "" for other content
+func (m *Model) markdownLabel(content string) string {
+	if !detect.IsMarkdown(content) {
+		return ""
+	}
+	if m.showsMarkdown(content) {
+		return "markdown (" + m.keys.Markdown.Help().Key + " for source)"
+	}
+	return "markdown source"
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// peekHold is how long a peek lasts after the peek key was last seen.
+// Holding the key repeats it before this runs out; where the terminal
+// reports key releases, letting go ends the peek at once.
+var peekHold = 700 * time.Millisecond
+
+// peekEndMsg ends a peek unless the key was pressed again since. seq
+// identifies the press that scheduled it.
+type peekEndMsg struct {
+	seq int
+}
+
+// SetMasked hides the content of every entry, in the table and preview, for
+// screen sharing: only types, times and lengths are shown, and holding the
+// peek key reveals the selected entry. Keys that would show content
+// elsewhere, such as the action menu or the editor, are disabled meanwhile.
+func (m *Model) SetMasked(masked bool) {
+	m.masked = masked
+	m.peekHash = ""
+	m.keys.Peek.SetEnabled(masked)
+	m.keys.Actions.SetEnabled(!masked)
+	m.keys.Alias.SetEnabled(!masked && !m.follower)
+	m.keys.Edit.SetEnabled(!masked && !m.follower)
+	m.updateTable()
+}
+
+// startPeek reveals the selected entry until the peek key is released or
+// stops repeating
+func (m *Model) startPeek() tea.Cmd {
+	selected := m.selectedItem()
+	if selected == nil {
+		return nil
+	}
+	m.peekSeq++
+	if m.peekHash != selected.Hash {
+		m.peekHash = selected.Hash
+		m.updateTable()
+	}
+	seq := m.peekSeq
+	return tea.Tick(peekHold, func(time.Time) tea.Msg {
+		return peekEndMsg{seq: seq}
+	})
+}
+
+// endPeek masks the peeked entry again
+func (m *Model) endPeek() {
+	if m.peekHash == "" {
+		return
+	}
+	m.peekHash = ""
+	m.updateTable()
+}
+
+// hidden reports whether hash's content is masked
+func (m *Model) hidden(hash string) bool {
+	return m.masked && hash != m.peekHash
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestMaskHidesContent(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("hunter2-secret-notes")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = typeText(model, "h")
+	view := model.View()
+	if contains(view.Content, "hunter2") {
+		t.Error("expected content hidden from the table and preview")
+	}
+	if !contains(view.Content, "20 chars") || !contains(view.Content, "hold Space to peek") {
+		t.Error("expected the length and a peek hint")
+	}
+	if !view.KeyboardEnhancements.ReportEventTypes {
+		t.Error("expected key releases requested, to end peeks")
+	}
+
+	// The action menu would show the content too
+	model = typeText(model, "x")
+	if model.mode == ActionView {
+		t.Error("expected actions disabled while masked")
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeySpace, Text: " "})
+	if !contains(model.View().Content, "hunter2") {
+		t.Fatal("expected holding Space to reveal the selected entry")
+	}
+	newModel, _ = model.Update(tea.KeyReleaseMsg(tea.Key{Code: tea.KeySpace, Text: " "}))
+	model = newModel.(Model)
+	if contains(model.View().Content, "hunter2") {
+		t.Error("expected releasing Space to hide it again")
+	}
+
+	model = typeText(model, "h")
+	if !contains(model.View().Content, "hunter2") {
+		t.Error("expected h to show content again")
+	}
+}
+
+func TestPeekEndsWhenKeyStopsRepeating(t *testing.T) {
+	orig := peekHold
+	t.Cleanup(func() { peekHold = orig })
+	peekHold = time.Millisecond
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("peek at me")
+	model := NewModel(historyManager)
+	model.SetMasked(true)
+
+	newModel, first := model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeySpace, Text: " "}))
+	model = newModel.(Model)
+	// Key repeat while held
+	newModel, second := model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeySpace, Text: " ", IsRepeat: true}))
+	model = newModel.(Model)
+	if first == nil || second == nil {
+		t.Fatal("expected each press to schedule the end of the peek")
+	}
+
+	// The first press's timer is superseded by the repeat
+	newModel, _ = model.Update(first())
+	model = newModel.(Model)
+	if !contains(model.View().Content, "peek at me") {
+		t.Error("expected the peek to last while the key repeats")
+	}
+	newModel, _ = model.Update(second())
+	model = newModel.(Model)
+	if contains(model.View().Content, "peek at me") {
+		t.Error("expected the peek to end once the key stopped repeating")
+	}
+}
@@ -1,43 +1,139 @@
 package ui
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"log"
 	"strings"
+	"time"
 
+	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
-	"github.com/atotto/clipboard"
+	"github.com/bvdwalt/clippy/internal/actions"
+	"github.com/bvdwalt/clippy/internal/clipimage"
+	"github.com/bvdwalt/clippy/internal/detect"
 	"github.com/bvdwalt/clippy/internal/history"
 	"github.com/bvdwalt/clippy/internal/search"
+	"github.com/bvdwalt/clippy/internal/sources"
+	"github.com/bvdwalt/clippy/internal/sysclip"
+	"github.com/bvdwalt/clippy/internal/trace"
 	"github.com/bvdwalt/clippy/internal/ui/styles"
 	"github.com/bvdwalt/clippy/internal/ui/table"
 )
 
+// BufferImporter supplies clipboard content from outside the system
+// clipboard, such as tmux paste buffers (see tmux.Importer)
+type BufferImporter interface {
+	Poll() ([]string, error)
+}
+
 // ViewMode represents the current view mode
 type ViewMode int
 
 const (
 	TableView ViewMode = iota
 	SearchView
+	AliasView
+	ActionView
+	RulesView
+	PaletteView
+	ClearView
 )
 
 // Model represents the UI state
 type Model struct {
-	historyManager *history.Manager
-	tableManager   *table.Manager
-	textInput      textinput.Model
-	fuzzyMatcher   *search.FuzzyMatcher
-	theme          styles.Theme
-	mode           ViewMode
-	filtered       []history.ClipboardHistory
-	lastClipboard  string
-	height         int
-	width          int
-	previewHeight  int
-	confirmDelete  bool   // waiting for y/n confirmation on a pinned item
-	confirmHash    string // hash of the item pending delete confirmation
-	version        string
+	historyManager  *history.Manager
+	tableManager    *table.Manager
+	textInput       textinput.Model
+	aliasInput      textinput.Model
+	findInput       textinput.Model
+	findOpen        bool         // the find prompt is being edited
+	find            *previewFind // search within the selected item's preview
+	aliasHash       string       // hash of the item whose alias is being edited
+	aliasErr        string       // inline validation error for the alias prompt
+	clearInput      textinput.Model
+	clearKeepPinned bool   // clearing history in ClearView keeps pinned items
+	clearErr        string // why the clear prompt's answer didn't confirm it
+	matcher         search.Matcher
+	keys            keyMap
+	theme           styles.Theme
+	mode            ViewMode
+	filtered        []history.ClipboardHistory
+	typeFilter      detect.Type // restricts the table to one content type; empty shows all
+	alphabetical    bool        // the table is sorted by content rather than recency
+	searchDebounce  time.Duration
+	searchSeq       int // incremented on every search keystroke to discard stale debounce ticks
+	bufferImporter  BufferImporter
+	headless        bool      // no clipboard backend; entries arrive via the CLI
+	viewer          bool      // the daemon captures the clipboard; only show its changes
+	follower        bool      // viewer that never captures or changes history
+	attachedPID     int       // another TUI capturing the clipboard, for viewers
+	unsavedPath     string    // database that couldn't be opened, retried while history is in memory
+	unsavedErr      error     // why history isn't being saved; nil while it is
+	nextPersist     time.Time // when to retry opening unsavedPath
+	heartbeat       Heartbeat
+	locker          CaptureLocker // takes the capture lock once unsaved history is stored
+	clipboard       Clipboard
+	watcher         ClipboardWatcher
+	captures        <-chan sources.Capture // text read by sources besides the clipboard
+	pollInterval    time.Duration          // how often ticks read the clipboard; 0 for every tick
+	lastPoll        time.Time
+	guard           CaptureGuard
+	copyHook        CopyHook
+	pickMode        bool // Enter selects an item and quits instead of copying
+	quickQuit       bool // quit after copying a row with 1-9
+	quitOnCopy      bool // quit after copying the selected entry, for use as a picker
+	picked          *history.ClipboardHistory
+	marked          []string // hashes of items marked for chaining, in marking order
+	actionConfig    actions.Config
+	actionMenu      *actionMenu // quick actions offered in ActionView
+	palette         *palette    // commands offered in PaletteView
+	timesRefreshed  time.Time   // when relative times in the table were last redrawn
+	recorder        Recorder    // records the session for clippy replay; nil when not recording
+	lastClipboard   string
+	clearAfter      time.Duration // how long a copied sensitive entry stays on the clipboard; zero keeps it
+	pendingClear    autoClear
+	quitAfterClear  bool          // quit once pendingClear ends, having copied with SetQuitOnCopy or SetQuickSelectQuit
+	debounce        time.Duration // how long new clipboard content must settle before it is recorded
+	pending         string        // new clipboard content waiting to settle
+	pendingSince    time.Time
+	lastPrimary     string // last text seen in the primary selection
+	lastImageHash   string // hash of the last image seen on the clipboard
+	height          int
+	width           int
+	previewHeight   int
+	previewOffset   int                 // first preview line shown, for the item with previewHash
+	previewHash     string              // item the preview was scrolled on
+	previewFocus    bool                // navigation keys scroll the preview instead of the table
+	selection       *lineSelection      // lines selected in the focused preview
+	rawMarkdown     bool                // preview markdown entries as their source
+	markdown        *markdownCache      // shared by copies of the model, so View can fill it
+	plainCode       bool                // preview code and JSON without syntax highlighting
+	highlighted     *highlightCache     // shared by copies of the model, so View can fill it
+	confirmDelete   bool                // waiting for y/n confirmation to delete an item
+	instantDelete   bool                // d deletes unpinned items without asking
+	confirmHash     string              // hash of the item pending delete confirmation
+	confirmMarked   bool                // waiting for y/n confirmation to delete the marked items
+	showHelp        bool                // the help overlay listing every key is open
+	pendingG        bool                // g was pressed, waiting for the second g of gg
+	mouse           bool                // clicks and the wheel move around the table
+	clickRow        int                 // row last clicked, for double clicks
+	clickedAt       time.Time           // when clickRow was clicked
+	lastSearch      string              // last search applied, for n and N
+	ruleStore       RuleStore           // saves the rules edited in RulesView; nil disables it
+	captureRules    CaptureRules        // rules RulesView starts from, as last saved
+	rules           *ruleEditor         // state of RulesView
+	undo            [][]history.Removed // deletes u undoes, oldest first
+	redo            [][]string          // hashes of the items each undo restored, for Ctrl+r
+	notice          string              // outcome of the last key, e.g. where marked items were exported
+	confirmCommand  *actions.Action     // command action from the action menu waiting for y/n confirmation
+	registerOp      registerOp          // waiting for the name of a register to store in or copy from
+	masked          bool                // hide entries' content while screen sharing
+	peekHash        string              // entry revealed while the peek key is held
+	peekSeq         int                 // identifies the latest peek key press
+	version         string
 }
 
 // NewModel creates a new UI model. An optional version string may be passed;
@@ -48,10 +144,23 @@ func NewModel(historyManager *history.Manager, version ...string) Model {
 	ti.CharLimit = 50
 	ti.SetWidth(50)
 
+	ai := textinput.New()
+	ai.Placeholder = "e.g. ssh-prod"
+	ai.CharLimit = history.MaxAliasLength
+	ai.SetWidth(40)
+
+	ci := textinput.New()
+	ci.Placeholder = clearConfirmWord
+	ci.SetWidth(20)
+
+	fi := textinput.New()
+	fi.Placeholder = "Find in entry..."
+	fi.Prompt = ""
+	fi.SetWidth(30)
+
 	theme := styles.DefaultTheme()
 	tableTheme := styles.DefaultTableTheme()
 	tableManager := table.NewManager(tableTheme)
-	fuzzyMatcher := search.NewFuzzyMatcher()
 
 	v := "dev"
 	if len(version) > 0 {
@@ -62,11 +171,20 @@ func NewModel(historyManager *history.Manager, version ...string) Model {
 		historyManager: historyManager,
 		tableManager:   tableManager,
 		textInput:      ti,
-		fuzzyMatcher:   fuzzyMatcher,
+		aliasInput:     ai,
+		clearInput:     ci,
+		findInput:      fi,
+		matcher:        search.NewFuzzyMatcher(),
+		keys:           defaultKeyMap(),
+		searchDebounce: DefaultSearchDebounce,
 		theme:          theme,
 		mode:           TableView,
 		version:        v,
+		clipboard:      systemClipboard{},
+		markdown:       &markdownCache{},
+		highlighted:    &highlightCache{},
 	}
+	tableManager.SetRelativeTime(true)
 
 	m.updateTable()
 	return m
@@ -82,62 +200,675 @@ func (m *Model) findByHash(hash string) *history.ClipboardHistory {
 	return nil
 }
 
-// deleteByHash removes the item with the given hash from history and refreshes the table
-func (m *Model) deleteByHash(hash string) {
+// deleteByHash removes the item with the given hash from history and
+// refreshes the table, returning what Restore needs to undo it
+func (m *Model) deleteByHash(hash string) (history.Removed, bool) {
 	allItems := m.historyManager.GetItems()
 	for i, item := range allItems {
 		if item.Hash == hash {
-			if m.historyManager.DeleteItem(i) {
-				m.lastClipboard = item.Item
-				m.updateTable()
+			removed, err := m.historyManager.Remove(i)
+			if err != nil {
+				log.Printf("Failed to delete clip: %v", err)
+				return history.Removed{}, false
+			}
+			m.skipRecapture(item)
+			m.updateTable()
+			return removed, true
+		}
+	}
+	return history.Removed{}, false
+}
+
+// skipRecapture keeps a deleted item from being captured again while it
+// is still on the clipboard
+func (m *Model) skipRecapture(item history.ClipboardHistory) {
+	if item.IsBinary() {
+		m.lastImageHash = item.Hash
+	} else if item.Overflow {
+		m.lastClipboard = item.Hash
+	} else {
+		m.lastClipboard = item.Item
+	}
+}
+
+// moveByHash moves the pinned item with the given hash offset places among
+// the pinned items, keeping the cursor on it
+func (m *Model) moveByHash(hash string, offset int) {
+	for i, item := range m.historyManager.GetItems() {
+		if item.Hash != hash {
+			continue
+		}
+		if !item.Pinned {
+			return
+		}
+		if _, err := m.historyManager.MovePinned(i, offset); err != nil {
+			log.Printf("Failed to move pinned item: %v", err)
+		}
+		m.updateTable()
+		for row, item := range m.getDisplayItems() {
+			if item.Hash == hash {
+				m.tableManager.SetCursor(row)
+				break
 			}
-			break
 		}
+		return
+	}
+}
+
+// pick selects item as the picker's result and quits
+func (m *Model) pick(item history.ClipboardHistory) tea.Cmd {
+	if item.Overflow {
+		// The manager is closed once the picker exits
+		text, err := m.historyManager.Text(item)
+		if err != nil {
+			log.Printf("Failed to load clip: %v", err)
+			return nil
+		}
+		item.Item, item.Overflow = text, false
+	}
+	m.picked = &item
+	return tea.Quit
+}
+
+// copyItem writes an item back to the system clipboard, restoring the
+// original image data for binary entries. In headless mode text is sent to
+// the terminal's clipboard instead (OSC 52), which also works over SSH.
+func (m *Model) copyItem(item history.ClipboardHistory) tea.Cmd {
+	if !item.IsBinary() {
+		text, err := m.historyManager.Text(item)
+		if err != nil {
+			log.Printf("Failed to load clip: %v", err)
+			return nil
+		}
+		m.copied(item, text)
+		return tea.Batch(m.copyText(text), m.scheduleClear(item, text, time.Now()))
+	}
+	if m.headless {
+		log.Printf("Cannot copy %s without a clipboard backend", item.Item)
+		return nil
+	}
+
+	data, err := m.historyManager.GetData(item)
+	if err != nil {
+		log.Printf("Failed to load image data: %v", err)
+		return nil
+	}
+	if err := clipimage.Write(data, item.MimeType); err != nil {
+		log.Printf("Failed to write image to clipboard: %v", err)
+		return nil
+	}
+	m.lastImageHash = item.Hash
+	return nil
+}
+
+// copySelected copies the selected item, quitting afterwards with
+// SetQuitOnCopy, or in pick mode picks it
+func (m *Model) copySelected() tea.Cmd {
+	item := m.selectedItem()
+	if item == nil {
+		return nil
+	}
+	if m.pickMode {
+		return m.pick(*item)
+	}
+	if m.quitOnCopy {
+		return m.quitAfterCopy(m.copyItem(*item))
+	}
+	return m.copyItem(*item)
+}
+
+// copyText writes text to the system clipboard, or to the terminal's
+// clipboard in headless mode
+func (m *Model) copyText(text string) tea.Cmd {
+	if m.headless {
+		if len(text) > sysclip.MaxOSC52Size {
+			log.Printf("Failed to copy: %v", sysclip.ErrTooLarge)
+			return nil
+		}
+		return tea.Raw(sysclip.OSC52(text))
+	}
+	if err := m.clipboard.Write(text); err != nil {
+		log.Printf("Failed to write to clipboard: %v", err)
 	}
+	return nil
+}
+
+// copyToPrimary places a text item in the primary selection, for pasting
+// with middle-click
+func (m *Model) copyToPrimary(item history.ClipboardHistory) {
+	if item.IsBinary() {
+		log.Printf("Cannot place %s in the primary selection", item.Item)
+		return
+	}
+	text, err := m.historyManager.Text(item)
+	if err != nil {
+		log.Printf("Failed to load clip: %v", err)
+		return
+	}
+	if err := sysclip.WritePrimary(text); err != nil {
+		log.Printf("Failed to write to primary selection: %v", err)
+		return
+	}
+	m.lastPrimary = text
+	m.copied(item, text)
+}
+
+// captureClipboard records the clipboard content if it changed. Text from
+// a clipboard that can stream it is spooled as it is read, so a huge copy
+// goes straight to an overflow file.
+func (m *Model) captureClipboard(now time.Time) {
+	defer trace.Start(trace.Capture).End()
+	if text, ok := m.readClipboard(); ok {
+		m.captureText(text, now)
+		m.updateTable()
+	} else {
+		// No text on the clipboard; it may hold an image instead
+		m.captureImage()
+	}
+}
+
+// captureText records text read from the clipboard if it is new and has
+// settled
+func (m *Model) captureText(text history.Spooled, now time.Time) {
+	// Spooled text is only known by its hash
+	key := text.Content
+	if text.InFile() {
+		key = text.Hash
+	}
+	if key == m.lastClipboard {
+		m.pending = ""
+		text.Discard()
+		return
+	}
+	if !m.settled(key, now) {
+		text.Discard()
+		return
+	}
+	switch {
+	case m.guard != nil && !m.guard.Allow():
+		text.Discard()
+	case text.InFile() || text.Oversized:
+		// Huge or truncated text is recorded without its formats
+		m.historyManager.AddSpooled(text, history.SelectionClipboard)
+	default:
+		m.recordClipboard(text.Content)
+		if m.recorder != nil {
+			m.recorder.Captured(text.Content)
+		}
+	}
+	m.lastClipboard = key
+}
+
+// SetCaptureDebounce only records new clipboard content once it has stayed
+// unchanged for d, so rapid rewrites of the clipboard leave only their final
+// value; zero records every change
+func (m *Model) SetCaptureDebounce(d time.Duration) {
+	m.debounce = max(d, 0)
+}
+
+// settled reports whether new clipboard content key has stayed unchanged
+// for the debounce, noting it as pending otherwise
+func (m *Model) settled(key string, now time.Time) bool {
+	if m.debounce == 0 {
+		return true
+	}
+	if key != m.pending {
+		m.pending, m.pendingSince = key, now
+		return false
+	}
+	if now.Sub(m.pendingSince) < m.debounce {
+		return false
+	}
+	m.pending = ""
+	return true
+}
+
+// readClipboard reads the text on the clipboard, reporting false if there
+// is none
+func (m *Model) readClipboard() (history.Spooled, bool) {
+	streamer, ok := m.clipboard.(sysclip.Streamer)
+	if !ok {
+		content, err := m.clipboard.Read()
+		if err != nil || len(content) == 0 {
+			return history.Spooled{}, false
+		}
+		return history.Spooled{Content: content, Size: len(content)}, true
+	}
+	r, err := streamer.Stream()
+	if err != nil {
+		return history.Spooled{}, false
+	}
+	defer r.Close()
+	text, err := m.historyManager.Spool(r)
+	if err != nil || text.Size == 0 {
+		return history.Spooled{}, false
+	}
+	return text, true
+}
+
+// captureImage records an image on the clipboard, if there is one and it
+// differs from the last image seen
+func (m *Model) captureImage() {
+	data, mimeType, err := clipimage.Read()
+	if err != nil {
+		return
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	if hash == m.lastImageHash {
+		return
+	}
+	m.historyManager.AddImage(data, mimeType)
+	m.lastImageHash = hash
+	m.updateTable()
 }
 
 // updateTable refreshes the table with current (filtered) history items
 func (m *Model) updateTable() {
 	items := m.getDisplayItems()
+	m.tableManager.SetMarks(m.markNumbers())
+	m.tableManager.SetMasked(m.masked, m.peekHash)
 	m.tableManager.UpdateRows(items)
 }
 
-// getDisplayItems returns the items to display (filtered or all)
+// getDisplayItems returns the items to display (filtered or all), restricted
+// to the active type filter and sorted as chosen
 func (m *Model) getDisplayItems() []history.ClipboardHistory {
+	items := m.historyManager.GetItems()
 	if m.filtered != nil {
-		return m.filtered
+		items = m.filtered
 	}
-	return m.historyManager.GetItems()
+	if m.typeFilter != "" {
+		byType := make([]history.ClipboardHistory, 0, len(items))
+		for _, item := range items {
+			if item.Type == m.typeFilter {
+				byType = append(byType, item)
+			}
+		}
+		items = byType
+	}
+	if m.alphabetical {
+		items = sortAlphabetically(items)
+	}
+	return items
+}
+
+// cycleTypeFilter advances the type filter through all content types,
+// wrapping back to showing every type
+func (m *Model) cycleTypeFilter() {
+	if m.typeFilter == "" {
+		m.typeFilter = detect.Types[0]
+		return
+	}
+	for i, t := range detect.Types {
+		if t == m.typeFilter {
+			if i+1 < len(detect.Types) {
+				m.typeFilter = detect.Types[i+1]
+			} else {
+				m.typeFilter = ""
+			}
+			return
+		}
+	}
+	m.typeFilter = ""
 }
 
 // filterItems filters history items using fuzzy finding (like fzf)
 func (m *Model) filterItems(query string) {
+	defer trace.Start(trace.Search).End()
 	if query == "" {
 		m.filtered = nil
 		return
 	}
 
-	allItems := m.historyManager.GetItems()
-	m.filtered = m.fuzzyMatcher.Search(allItems, query)
+	// Let the database apply type and date filters, leaving only the text
+	// for the matcher to rank
+	candidates := m.historyManager.GetItems()
+	if filter := search.ParseQuery(query).Filter(); !filter.IsEmpty() {
+		queried, err := m.historyManager.Query(filter)
+		if err != nil {
+			log.Printf("Failed to query history: %v", err)
+		} else {
+			candidates = queried
+		}
+	}
+	m.filtered = m.matcher.Search(candidates, query)
+}
+
+// SetSearchDebounce sets how long live search in a large history waits
+// after the last keystroke before filtering; zero filters on every keystroke
+func (m *Model) SetSearchDebounce(d time.Duration) {
+	m.searchDebounce = max(d, 0)
+}
+
+// scheduleSearch queues a live search for the current input, superseding
+// any search still waiting on its debounce. Small histories are filtered
+// right away.
+func (m *Model) scheduleSearch() tea.Cmd {
+	m.searchSeq++
+	if m.searchDebounce == 0 || m.historyManager.Count() <= debounceAbove {
+		m.filterItems(m.textInput.Value())
+		m.updateTable()
+		return nil
+	}
+	return debounceSearch(m.searchDebounce, m.searchSeq)
+}
+
+// SetPickMode makes Enter select the highlighted item and quit rather than
+// copy it, for shell widgets that insert the item at the prompt
+func (m *Model) SetPickMode(pick bool) {
+	m.pickMode = pick
+	if pick {
+		m.keys.Copy.SetHelp("Enter/c", "insert")
+	} else {
+		m.keys.Copy.SetHelp("Enter/c", "copy")
+	}
+}
+
+// Picked returns the item selected in pick mode, if any
+func (m Model) Picked() (history.ClipboardHistory, bool) {
+	if m.picked == nil {
+		return history.ClipboardHistory{}, false
+	}
+	return *m.picked, true
+}
+
+// helpWidth is the width available to the help line
+func (m Model) helpWidth() int {
+	if m.width <= 0 {
+		return 0
+	}
+	return max(m.width-m.theme.Doc.GetHorizontalFrameSize(), 20)
+}
+
+// SetHeadless runs the model without a clipboard backend: the clipboard is
+// not polled and copying uses the terminal's clipboard (OSC 52)
+func (m *Model) SetHeadless(headless bool) {
+	m.headless = headless
+}
+
+// SetViewer makes the model a pure viewer while the daemon is capturing:
+// instead of polling the clipboard it reloads history whenever the database
+// changes.
+func (m *Model) SetViewer(viewer bool) {
+	m.viewer = viewer
+}
+
+// SetAttached makes the model a viewer of the TUI with pid, which is
+// capturing the clipboard into the same history.
+func (m *Model) SetAttached(pid int) {
+	m.viewer = true
+	m.attachedPID = pid
+}
+
+// SetFollower makes the model a read-only viewer, e.g. a history pane on
+// another monitor: it shows changes to the database as a viewer does, but
+// never captures the clipboard, even in incognito mode, and the keys that
+// change history are disabled. Entries can still be copied.
+func (m *Model) SetFollower(follower bool) {
+	m.follower = follower
+	m.viewer = m.viewer || follower
+	for _, binding := range []*key.Binding{
+		&m.keys.Pin, &m.keys.MoveUp, &m.keys.MoveDown, &m.keys.Alias, &m.keys.Register,
+		&m.keys.Expire, &m.keys.Edit, &m.keys.Delete, &m.keys.DeleteMarked, &m.keys.ClearAll, &m.keys.Undo, &m.keys.Redo, &m.keys.Incognito,
+	} {
+		binding.SetEnabled(!follower)
+	}
+	m.keys.Rules.SetEnabled(m.ruleStore != nil && !follower)
+}
+
+// reloadChanged shows entries written by other processes since the last tick
+func (m *Model) reloadChanged() {
+	changed, err := m.historyManager.ReloadIfChanged()
+	if err != nil {
+		log.Printf("Failed to reload history: %v", err)
+		return
+	}
+	if !changed {
+		return
+	}
+	if m.filtered != nil {
+		m.filterItems(m.textInput.Value())
+	}
+	m.updateTable()
+}
+
+// Clipboard reads and writes the clipboard's text (see sysclip.Backend).
+type Clipboard interface {
+	Read() (string, error)
+	Write(text string) error
+}
+
+// systemClipboard uses whichever sysclip backend is active
+type systemClipboard struct{}
+
+func (systemClipboard) Read() (string, error)   { return sysclip.ReadAll() }
+func (systemClipboard) Write(text string) error { return sysclip.WriteAll(text) }
+
+func (systemClipboard) Stream() (io.ReadCloser, error) { return sysclip.Stream() }
+
+// Name names the backend in use, and any it took over from after that
+// stopped working
+func (systemClipboard) Name() string {
+	backend := sysclip.Current()
+	if backend == nil {
+		return ""
+	}
+	if replaced := sysclip.Replaced(); replaced != "" {
+		return fmt.Sprintf("%s (%s failed)", backend.Name(), replaced)
+	}
+	return backend.Name()
+}
+
+// SetClipboard reads and writes clipboard instead of the system clipboard,
+// e.g. a fake in tests.
+func (m *Model) SetClipboard(clipboard Clipboard) {
+	m.clipboard = clipboard
+}
+
+// ClipboardWatcher notifies of clipboard changes, replacing polling.
+type ClipboardWatcher interface {
+	Changes() <-chan struct{}
+}
+
+// SetClipboardWatcher reads the clipboard when watcher reports a change
+// instead of on every tick.
+func (m *Model) SetClipboardWatcher(watcher ClipboardWatcher) {
+	m.watcher = watcher
+}
+
+// CaptureGuard decides whether new clipboard content may be recorded, e.g.
+// refusing passwords.
+type CaptureGuard interface {
+	Allow() bool
+}
+
+// SetCaptureGuard consults guard before recording each new clipboard text.
+func (m *Model) SetCaptureGuard(guard CaptureGuard) {
+	m.guard = guard
+}
+
+// Heartbeat tells other clippy processes that this one is still capturing
+// the clipboard (see instance.Lock).
+type Heartbeat interface {
+	Refresh(now time.Time) error
+}
+
+// SetHeartbeat refreshes heartbeat on every tick while capturing.
+func (m *Model) SetHeartbeat(heartbeat Heartbeat) {
+	m.heartbeat = heartbeat
+}
+
+// Recorder records a session for clippy replay: user input, noting when
+// it is typed into a prompt so the text can be hidden, and the text of
+// each clipboard capture.
+type Recorder interface {
+	Input(msg tea.Msg, private bool)
+	Captured(text string)
+}
+
+// SetRecorder records the session with recorder.
+func (m *Model) SetRecorder(recorder Recorder) {
+	m.recorder = recorder
+}
+
+// typing reports whether keys go into a text prompt rather than being
+// commands
+func (m *Model) typing() bool {
+	return m.mode == SearchView || m.mode == AliasView || m.mode == ClearView || m.findOpen || (m.mode == RulesView && m.rules != nil && m.rules.form != nil)
+}
+
+// CopyHook is told the full text of each text entry copied back from
+// history, e.g. to run user commands (see hooks.Runner).
+type CopyHook interface {
+	Copied(item history.ClipboardHistory, text string)
+}
+
+// SetCopyHook calls hook whenever an entry is copied to the clipboard or
+// the primary selection.
+func (m *Model) SetCopyHook(hook CopyHook) {
+	m.copyHook = hook
+}
+
+// copied tells the copy hook, if any, that item was copied as text
+func (m *Model) copied(item history.ClipboardHistory, text string) {
+	if m.copyHook != nil {
+		m.copyHook.Copied(item, text)
+	}
+}
+
+// SetQuitOnCopy makes copying the selected entry, with Enter, 1-9 or a
+// double click, quit clippy, so it can be bound to a hotkey as a picker.
+func (m *Model) SetQuitOnCopy(quit bool) {
+	m.quitOnCopy = quit
+}
+
+// SetShowIDs shows each entry's short ID, which clippy copy accepts in
+// place of an alias, in the table.
+func (m *Model) SetShowIDs(show bool) {
+	m.tableManager.SetShowIDs(show)
+}
+
+// SetInstantDelete makes d delete unpinned items without asking first.
+// Pinned items are always confirmed.
+func (m *Model) SetInstantDelete(instant bool) {
+	m.instantDelete = instant
+}
+
+// deletePrompt asks to confirm deleting the item waiting on confirmDelete,
+// quoting the start of it unless its content is hidden or a secret
+func (m *Model) deletePrompt() string {
+	item := m.findByHash(m.confirmHash)
+	what := "item"
+	if item != nil && item.Pinned {
+		what = "pinned item"
+	}
+	if item == nil || item.Sensitive != "" || m.hidden(m.confirmHash) {
+		return fmt.Sprintf("Delete %s? (y/n)", what)
+	}
+	return fmt.Sprintf("Delete %s %q? (y/n)", what, truncate(item.Item, 40))
+}
+
+// SetCapturePrimary enables copying entries back to the primary selection
+// (highlighted text), which is captured as a source; see
+// SetSourceCaptures.
+func (m *Model) SetCapturePrimary(enabled bool) {
+	m.keys.CopyPrimary.SetEnabled(enabled)
+}
+
+// SetBufferImporter enables periodic import of text from importer, e.g.
+// tmux paste buffers, in addition to the system clipboard
+func (m *Model) SetBufferImporter(importer BufferImporter) {
+	m.bufferImporter = importer
+}
+
+// importBuffers adds any new content from the buffer importer to history
+func (m *Model) importBuffers() {
+	if m.bufferImporter == nil || m.viewer {
+		return
+	}
+	contents, err := m.bufferImporter.Poll()
+	if err != nil {
+		log.Printf("Failed to import tmux buffers: %v", err)
+		return
+	}
+	for _, content := range contents {
+		m.historyManager.AddItem(content)
+	}
+	if len(contents) > 0 {
+		m.updateTable()
+	}
+}
+
+// SetTheme replaces the styles of everything but the history table, e.g.
+// with a theme loaded by styles.LoadTheme
+func (m *Model) SetTheme(theme styles.Theme) {
+	m.theme = theme
+	m.tableManager.SetPageMargin(theme.Doc.GetHorizontalFrameSize())
+}
+
+// SetTableTheme replaces the colors used by the history table
+func (m *Model) SetTableTheme(theme styles.TableTheme) {
+	m.tableManager.SetTheme(theme)
+}
+
+// SetMatcher replaces the search algorithm used by the search view
+func (m *Model) SetMatcher(matcher search.Matcher) {
+	m.matcher = matcher
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return Tick()
+	cmds := []tea.Cmd{Tick()}
+	if m.bufferImporter != nil {
+		cmds = append(cmds, tmuxTick())
+	}
+	if m.watcher != nil {
+		cmds = append(cmds, waitForChange(m.watcher.Changes()))
+	}
+	if m.captures != nil {
+		cmds = append(cmds, waitForCapture(m.captures))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
+	if m.recorder != nil {
+		m.recorder.Input(msg, m.typing())
+	}
 
 	switch msg := msg.(type) {
+	case tea.KeyReleaseMsg:
+		// Only reported while masked, to end a peek
+		if key.Matches(msg, m.keys.Peek) {
+			m.endPeek()
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
 	case tea.KeyMsg:
+		m.notice = ""
+		pendingG := m.pendingG
+		m.pendingG = false
+		if m.confirmMarked {
+			switch msg.String() {
+			case "y":
+				m.confirmMarked = false
+				m.deleteMarked()
+			case "n", "esc":
+				m.confirmMarked = false
+			}
+			return m, cmd
+		}
 		// Handle pending delete confirmation for pinned items
 		if m.confirmDelete {
 			switch msg.String() {
 			case "y":
 				m.confirmDelete = false
-				m.deleteByHash(m.confirmHash)
+				m.deleteItems(m.confirmHash)
 				m.confirmHash = ""
 			case "n", "esc":
 				m.confirmDelete = false
@@ -145,14 +876,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, cmd
 		}
+		if m.confirmCommand != nil {
+			switch msg.String() {
+			case "y":
+				cmd = runLookup(m.confirmCommand.Command, m.confirmCommand.Input)
+				m.confirmCommand = nil
+			case "n", "esc":
+				m.confirmCommand = nil
+			}
+			return m, cmd
+		}
+		if m.registerOp != noRegister {
+			return m.updateRegisterKey(msg)
+		}
+
+		if m.mode == AliasView {
+			return m.updateAliasPrompt(msg)
+		}
+		if m.mode == ClearView {
+			return m.updateClearPrompt(msg)
+		}
+		if m.mode == ActionView {
+			return m.updateActionMenu(msg)
+		}
+		if m.mode == RulesView {
+			return m.updateRules(msg)
+		}
+		if m.mode == PaletteView {
+			return m.updatePalette(msg)
+		}
+		if m.findOpen {
+			return m.updateFindPrompt(msg)
+		}
+		if m.showHelp {
+			if key.Matches(msg, m.keys.CloseHelp) {
+				m.showHelp = false
+			}
+			return m, nil
+		}
+		if m.mode == TableView && key.Matches(msg, m.keys.Help) {
+			m.showHelp = true
+			return m, nil
+		}
+		if m.mode == TableView && key.Matches(msg, m.keys.Palette) {
+			m.openPalette()
+			return m, nil
+		}
 
 		// Global shortcuts that work in any mode
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		case "/":
-			// Toggle search mode
-			if m.mode == TableView {
+			// Toggle search mode; in the focused preview / finds within it
+			if m.mode == TableView && !m.previewFocus {
 				m.mode = SearchView
 				m.textInput.Focus()
 				return m, nil
@@ -163,42 +940,126 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.mode = TableView
 				m.textInput.Blur()
 				m.textInput.SetValue("")
+				m.searchSeq++
 				m.filtered = nil
 				m.updateTable()
 				return m, nil
 			}
 		}
 
+		if m.mode == TableView && m.previewFocus && m.activeSelection() != nil {
+			switch {
+			case key.Matches(msg, m.keys.CopyLines):
+				return m, m.copySelection()
+			case key.Matches(msg, m.keys.CancelSelect):
+				m.selection = nil
+				return m, nil
+			case key.Matches(msg, m.keys.PreviewDown):
+				m.extendSelection(1)
+				return m, nil
+			case key.Matches(msg, m.keys.PreviewUp):
+				m.extendSelection(-1)
+				return m, nil
+			}
+		}
+
+		if m.mode == TableView && m.previewFocus {
+			switch {
+			case key.Matches(msg, m.keys.SelectLines):
+				m.startSelection()
+				return m, nil
+			case key.Matches(msg, m.keys.Actions):
+				m.openActionMenu()
+				return m, nil
+			case key.Matches(msg, m.keys.PreviewFind):
+				m.openFindPrompt()
+				return m, nil
+			case key.Matches(msg, m.keys.FindNext) && m.activeFind() != nil:
+				m.nextMatch(1)
+				return m, nil
+			case key.Matches(msg, m.keys.FindPrev) && m.activeFind() != nil:
+				m.nextMatch(-1)
+				return m, nil
+			case key.Matches(msg, m.keys.ClearFind) && m.activeFind() != nil:
+				m.find = nil
+				return m, nil
+			case key.Matches(msg, m.keys.PreviewBack):
+				m.find = nil
+				m.selection = nil
+				m.previewFocus = false
+				return m, nil
+			case key.Matches(msg, m.keys.PreviewDown):
+				m.scrollPreview(1)
+				return m, nil
+			case key.Matches(msg, m.keys.PreviewUp):
+				m.scrollPreview(-1)
+				return m, nil
+			}
+		}
+
 		// Mode-specific key handling
 		switch m.mode {
 		case SearchView:
-			switch msg.String() {
-			case "enter":
-				// Apply search filter
+			switch {
+			case key.Matches(msg, m.keys.SearchApply):
+				// Apply search filter immediately, cancelling any pending debounce
+				m.searchSeq++
 				m.filterItems(m.textInput.Value())
 				m.updateTable()
+				if query := m.textInput.Value(); query != "" {
+					m.lastSearch = query
+				}
 				m.mode = TableView
 				m.textInput.Blur()
 				return m, nil
 			default:
-				// Handle text input
+				// Handle text input, re-filtering live once typing pauses
+				before := m.textInput.Value()
 				m.textInput, cmd = m.textInput.Update(msg)
+				if m.textInput.Value() != before {
+					cmd = tea.Batch(cmd, m.scheduleSearch())
+				}
 				return m, cmd
 			}
 		case TableView:
-			switch msg.String() {
-			case "enter", "c":
-				// Copy selected item
-				items := m.getDisplayItems()
-				if len(items) > 0 {
-					selectedRow := m.tableManager.GetCursor()
-					if selectedRow < len(items) {
-						if err := clipboard.WriteAll(items[selectedRow].Item); err != nil {
-							log.Printf("Failed to write to clipboard: %v", err)
-						}
+			switch {
+			case key.Matches(msg, m.keys.Copy):
+				cmd = m.copySelected()
+			case key.Matches(msg, m.keys.QuickSelect):
+				cmd = m.quickSelect(msg.String())
+			case key.Matches(msg, m.keys.CopyPrimary):
+				if item := m.selectedItem(); item != nil {
+					m.copyToPrimary(*item)
+				}
+			case key.Matches(msg, m.keys.Mark) && !key.Matches(msg, m.keys.Peek):
+				// Space peeks instead while content is hidden
+				if item := m.selectedItem(); item != nil {
+					m.toggleMark(item.Hash)
+				}
+			case key.Matches(msg, m.keys.CopySteps):
+				cmd = m.copyMarked(chainSteps)
+			case key.Matches(msg, m.keys.CopyChain):
+				cmd = m.copyMarked(chainCommands)
+			case key.Matches(msg, m.keys.CopyJoined):
+				cmd = m.copyMarked(chainLines)
+			case key.Matches(msg, m.keys.DeleteMarked):
+				m.confirmMarked = len(m.markedItems()) > 0
+			case key.Matches(msg, m.keys.ExportMarked):
+				path, err := m.exportMarked(time.Now())
+				if err != nil {
+					m.notice = err.Error()
+				} else if path != "" {
+					m.notice = "exported to " + abbreviatePath(path)
+				}
+			case key.Matches(msg, m.keys.MoveUp, m.keys.MoveDown):
+				if item := m.selectedItem(); item != nil {
+					offset := 1
+					if key.Matches(msg, m.keys.MoveUp) {
+						offset = -1
 					}
+					m.moveByHash(item.Hash, offset)
 				}
-			case "p":
+			case key.Matches(msg, m.keys.Pin):
 				// Toggle pin on selected item
 				items := m.getDisplayItems()
 				if len(items) > 0 {
@@ -216,26 +1077,118 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 				}
-			case "d":
-				// Delete selected item — ask for confirmation if pinned
+			case key.Matches(msg, m.keys.Undo):
+				m.undoDelete()
+			case key.Matches(msg, m.keys.Redo):
+				m.redoDelete()
+			case key.Matches(msg, m.keys.Delete):
+				// Delete selected item — ask for confirmation unless it's
+				// unpinned and deletes are instant
 				items := m.getDisplayItems()
 				if len(items) > 0 {
 					selectedRow := m.tableManager.GetCursor()
 					if selectedRow < len(items) {
 						itemToDelete := items[selectedRow]
-						if itemToDelete.Pinned {
+						if itemToDelete.Pinned || !m.instantDelete {
 							m.confirmDelete = true
 							m.confirmHash = itemToDelete.Hash
 						} else {
-							m.deleteByHash(itemToDelete.Hash)
+							m.deleteItems(itemToDelete.Hash)
 						}
 					}
 				}
-			case "r":
-				// Refresh/clear search and reload from database
+			case key.Matches(msg, m.keys.ClearAll):
+				// Ask to confirm deleting the whole history
+				m.openClearPrompt()
+				return m, nil
+			case key.Matches(msg, m.keys.Register):
+				// Wait for the register to store the selected item in
+				if m.selectedItem() != nil {
+					m.registerOp = storeRegister
+				}
+			case key.Matches(msg, m.keys.Recall):
+				// Wait for the register to copy
+				m.registerOp = recallRegister
+			case key.Matches(msg, m.keys.Alias):
+				// Set or edit the alias of the selected item
+				m.openAliasPrompt()
+				return m, nil
+			case key.Matches(msg, m.keys.Actions):
+				// Offer quick actions for recognised content, e.g. a commit SHA
+				m.openActionMenu()
+			case key.Matches(msg, m.keys.Expire):
+				// Cycle the selected item's expiry (5m, 1h, 24h, never)
+				m.cycleExpiry()
+			case key.Matches(msg, m.keys.Edit):
+				// Suspend the UI to edit the selected item in $EDITOR
+				cmd = m.editSelected()
+			case key.Matches(msg, m.keys.FocusPreview):
+				// Move focus to the preview, so navigation keys scroll it
+				m.previewFocus = m.previewHeight > 0
+			case key.Matches(msg, m.keys.ScrollDown):
+				m.scrollPreview(1)
+			case key.Matches(msg, m.keys.ScrollUp):
+				m.scrollPreview(-1)
+			case key.Matches(msg, m.keys.PageDown):
+				m.scrollPreview(m.previewPage())
+			case key.Matches(msg, m.keys.PageUp):
+				m.scrollPreview(-m.previewPage())
+			case key.Matches(msg, m.keys.Type):
+				// Cycle the content type filter
+				m.cycleTypeFilter()
+				m.updateTable()
+			case key.Matches(msg, m.keys.Rules):
+				m.openRules()
+			case key.Matches(msg, m.keys.Top):
+				// gg, as in vim, so a stray g doesn't lose your place
+				if pendingG {
+					m.moveCursor(0)
+				} else {
+					m.pendingG = true
+				}
+			case key.Matches(msg, m.keys.Bottom):
+				m.moveCursor(len(m.getDisplayItems()) - 1)
+			case key.Matches(msg, m.keys.HalfPageDown):
+				m.moveCursor(m.tableManager.GetCursor() + m.halfPage())
+			case key.Matches(msg, m.keys.HalfPageUp):
+				m.moveCursor(m.tableManager.GetCursor() - m.halfPage())
+			case key.Matches(msg, m.keys.NextResult):
+				m.nextResult(1)
+			case key.Matches(msg, m.keys.PrevResult):
+				m.nextResult(-1)
+			case key.Matches(msg, m.keys.Sort):
+				// Switch between newest first and A-Z
+				m.alphabetical = !m.alphabetical
+				m.updateTable()
+			case key.Matches(msg, m.keys.Mask):
+				// Hide or show entries' content, e.g. while screen sharing
+				m.SetMasked(!m.masked)
+			case key.Matches(msg, m.keys.Peek):
+				// Reveal the selected entry while the key is held
+				cmd = m.startPeek()
+			case key.Matches(msg, m.keys.Markdown):
+				// Switch markdown previews between rendered and source
+				m.rawMarkdown = !m.rawMarkdown
+				m.previewOffset = 0
+			case key.Matches(msg, m.keys.Highlight):
+				// Switch code previews between highlighted and plain
+				m.plainCode = !m.plainCode
+			case key.Matches(msg, m.keys.TimeFormat):
+				// Switch the Time column between relative and absolute
+				m.SetRelativeTime(!m.tableManager.RelativeTime())
+			case key.Matches(msg, m.keys.Incognito):
+				// Keep new items in memory only, or go back to saving them
+				incognito := !m.historyManager.Incognito()
+				if err := m.historyManager.SetIncognito(incognito); err != nil {
+					log.Printf("Failed to switch incognito mode: %v", err)
+				}
+			case key.Matches(msg, m.keys.Refresh):
+				// Refresh/clear search and marks and reload from database
 				m.mode = TableView
 				m.textInput.SetValue("")
 				m.filtered = nil
+				m.typeFilter = ""
+				m.marked = nil
 				if err := m.historyManager.LoadFromDB(); err != nil {
 					log.Printf("Failed to load from database: %v", err)
 				}
@@ -244,33 +1197,105 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Handle table navigation (arrow keys, etc.)
 				tbl := m.tableManager.GetTable()
 				updatedTable, cmd := tbl.Update(msg)
+				if updatedTable.Cursor() != tbl.Cursor() {
+					m.previewOffset = 0
+				}
 				m.tableManager.SetTable(&updatedTable)
 				return m, cmd
 			}
 		}
 
 	case TickMsg:
-		// Check for new clipboard content
-		content, err := clipboard.ReadAll()
-		if err == nil && len(content) > 0 {
-			if content != m.lastClipboard {
-				m.historyManager.AddItem(content)
-				m.lastClipboard = content
+		m.refreshTimes(time.Time(msg))
+		m.retryPersist(time.Time(msg))
+		m.historyManager.RefreshIncognito()
+		if m.viewer {
+			m.reloadChanged()
+			if m.historyManager.Incognito() && !m.follower {
+				// The daemon doesn't record in incognito mode
+				m.pollClipboard(time.Time(msg))
+			}
+			return m, Tick()
+		}
+		if m.heartbeat != nil {
+			if err := m.heartbeat.Refresh(time.Time(msg)); err != nil {
+				log.Printf("Failed to update capture heartbeat: %v", err)
 			}
-			m.updateTable()
 		}
+		m.purgeExpired(time.Time(msg))
+		if m.headless || m.watcher != nil {
+			return m, Tick()
+		}
+		m.pollClipboard(time.Time(msg))
 		return m, Tick()
 
+	case sourceCaptureMsg:
+		// Another process may have started capturing while history wasn't
+		// saved (see takeCaptureLock)
+		if !m.viewer {
+			m.captureSource(sources.Capture(msg))
+		}
+		return m, waitForCapture(m.captures)
+
+	case clipboardChangedMsg:
+		if m.viewer {
+			return m, waitForChange(m.watcher.Changes())
+		}
+		m.captureClipboard(time.Now())
+		if m.pending != "" {
+			// No tick looks at the clipboard again
+			return m, tea.Batch(waitForChange(m.watcher.Changes()), settleClipboard(m.debounce))
+		}
+		return m, waitForChange(m.watcher.Changes())
+
+	case clearTickMsg:
+		return m, m.updateClear(msg)
+
+	case clipboardSettleMsg:
+		m.captureClipboard(time.Time(msg))
+		return m, nil
+
+	case peekEndMsg:
+		if msg.seq == m.peekSeq {
+			m.endPeek()
+		}
+		return m, nil
+
+	case editDoneMsg:
+		m.finishEdit(msg)
+		return m, nil
+
+	case lookupDoneMsg:
+		if msg.err != nil {
+			log.Printf("Lookup failed: %v", msg.err)
+		}
+		return m, nil
+
+	case tmuxTickMsg:
+		m.importBuffers()
+		return m, tmuxTick()
+
+	case searchDebounceMsg:
+		if m.mode == SearchView && msg.seq == m.searchSeq {
+			m.filterItems(m.textInput.Value())
+			m.updateTable()
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
 
 		// Split available height: ~2/3 table, ~1/3 preview.
-		// Overhead: title(2) + status(1) + help(2) + preview label(1) + preview borders(2) + doc margin(2) = 10
-		available := max(msg.Height-10, 6)
+		// Overhead: title(2) + status(1) + help(2) + preview label(1), then
+		// the preview's border and the document's margin
+		overhead := 6 + m.theme.Preview.GetVerticalFrameSize() + m.theme.Doc.GetVerticalFrameSize()
+		available := max(msg.Height-overhead, 6)
 		previewH := max(available/3, 3)
 		m.previewHeight = previewH
-		m.tableManager.SetSize(msg.Width, available-previewH)
+		// The table gives up a line when the help stacks onto a second one
+		tableH := available - previewH - (strings.Count(renderHelp(m.keys.tableHelp(true), m.helpWidth()), "\n"))
+		m.tableManager.SetSize(msg.Width, max(tableH, 1))
 	}
 
 	return m, cmd
@@ -278,78 +1303,229 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View renders the UI
 func (m Model) View() tea.View {
+	defer trace.Start(trace.Render).End()
 	var content strings.Builder
 
 	// Title
-	title := m.theme.Title.Render("📋 Clippy Clipboard History") + "  " + m.theme.Help.Margin(0).Render("version: "+m.version)
-	content.WriteString(title + "\n\n")
+	content.WriteString(m.titleBar() + "\n\n")
+	if banner := m.unsavedBanner(); banner != "" {
+		content.WriteString(banner + "\n\n")
+	}
 
 	// Search mode UI
 	if m.mode == SearchView {
+		hint := "Press Enter to search, Esc to cancel"
+		if m.filtered != nil {
+			hint = fmt.Sprintf("%d matches \u2022 %s", len(m.filtered), hint)
+		}
 		searchBox := m.theme.Search.Render(
 			fmt.Sprintf("🔍 Search:\n\n%s\n\n%s",
 				m.textInput.View(),
-				m.theme.Help.Render("Press Enter to search, Esc to cancel")))
+				m.theme.Help.Render(hint)))
 		content.WriteString(searchBox + "\n")
-		v := tea.NewView(m.theme.Doc.Render(content.String()))
-		v.AltScreen = true
-		v.WindowTitle = "Clippy"
-		return v
+		// Show live results behind the input, dimmed while typing
+		if len(m.getDisplayItems()) > 0 {
+			content.WriteString(m.tableManager.Render(false) + "\n")
+		}
+		return m.screen(content.String())
+	}
+
+	if m.mode == ActionView {
+		content.WriteString(m.actionMenuView() + "\n")
+		return m.screen(content.String())
+	}
+
+	if m.mode == PaletteView {
+		content.WriteString(m.paletteView() + "\n")
+		return m.screen(content.String())
+	}
+
+	if m.mode == RulesView {
+		content.WriteString(m.rulesView() + "\n")
+		return m.screen(content.String())
+	}
+
+	if m.mode == AliasView {
+		content.WriteString(m.aliasPromptView() + "\n")
+		return m.screen(content.String())
+	}
+
+	if m.mode == ClearView {
+		content.WriteString(m.clearPromptView() + "\n")
+		return m.screen(content.String())
+	}
+
+	if m.showHelp {
+		content.WriteString(m.helpOverlay(m.helpWidth()) + "\n")
+		content.WriteString(m.theme.Help.Render(renderHelp([]key.Binding{m.keys.CloseHelp}, m.helpWidth())))
+		return m.screen(content.String())
 	}
 
 	// Table view
 	items := m.getDisplayItems()
 	if len(items) == 0 {
-		if m.filtered != nil {
+		if m.filtered != nil || m.typeFilter != "" {
 			content.WriteString("No results found for your search.\n")
 		} else {
 			content.WriteString("No clipboard history yet...\n")
 		}
 	} else {
-		content.WriteString(m.tableManager.View() + "\n")
+		content.WriteString(m.tableManager.Render(!m.previewFocus) + "\n")
 	}
 
 	// Preview pane
 	if m.previewHeight > 0 {
 		previewContent := ""
-		if selected := m.tableManager.GetSelectedItem(); selected != nil {
-			previewContent = selected.Item
+		previewLabel := "Preview"
+		if selected := m.selectedItem(); selected != nil && m.hidden(selected.Hash) {
+			previewLabel += " \u2022 hidden (hold Space to peek)"
+		} else if selected != nil {
+			var position string
+			previewContent, position = m.previewWindow(*selected)
+			if position != "" {
+				previewLabel += " \u2022 " + position
+			}
+			if label := m.selectionLabel(); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if label := m.findLabel(); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if selected.Count > 1 {
+				previewLabel += fmt.Sprintf(" (copied %d times)", selected.Count)
+			}
+			if selected.Sensitive != "" {
+				previewLabel += fmt.Sprintf(" \u2022 sensitive: %s", selected.Sensitive)
+			}
+			switch {
+			case selected.FromPrimary():
+				previewLabel += " \u2022 primary selection"
+			case selected.Selection != "" && selected.Selection != history.SelectionClipboard:
+				previewLabel += " \u2022 captured from " + string(selected.Selection)
+			}
+			if selected.SourceApp != "" {
+				previewLabel += " \u2022 from " + selected.SourceApp
+			}
+			if len(selected.Tags) > 0 {
+				previewLabel += " \u2022 tagged " + strings.Join(selected.Tags, ", ")
+			}
+			if selected.Incognito {
+				previewLabel += " \u2022 not saved"
+			}
+			if label := registersLabel(*selected); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if label := m.markdownLabel(selected.Item); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if label := m.highlightLabel(*selected); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if label := tableLabel(selected.Item); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if label := formatsLabel(*selected); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if path := m.historyManager.MediaPath(*selected); path != "" {
+				previewLabel += " \u2022 saved as " + abbreviatePath(path)
+			}
+			if selected.Overflow {
+				previewLabel += fmt.Sprintf(" \u2022 first %s of %s", history.FormatSize(len(selected.Item)), history.FormatSize(selected.Size))
+			}
+			if label := expiryLabel(*selected, time.Now()); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+			if label := m.actionCountLabel(*selected); label != "" {
+				previewLabel += " \u2022 " + label
+			}
+		}
+		if m.findOpen {
+			previewLabel = m.findPromptView()
 		}
-		previewWidth := max(m.width-8, 10) // doc margin (4 each side) + border (1 each side) + padding (1 each side)
-		content.WriteString(m.theme.Help.Render("Preview") + "\n")
-		content.WriteString(m.theme.Preview.Width(previewWidth).Height(m.previewHeight).Render(previewContent) + "\n")
+		content.WriteString(m.theme.Help.Render(previewLabel) + "\n")
+		previewStyle := m.theme.Preview
+		if m.previewFocus {
+			previewStyle = m.theme.PreviewFocused
+		}
+		content.WriteString(previewStyle.
+			Width(m.previewTextWidth()+previewStyle.GetHorizontalFrameSize()).
+			Height(m.previewHeight+previewStyle.GetVerticalFrameSize()).
+			Render(previewContent) + "\n")
 	}
 
 	// Status and help
 	var status string
-	if m.filtered != nil {
-		status = fmt.Sprintf("Showing %d of %d items", len(m.filtered), m.historyManager.Count())
+	if m.filtered != nil || m.typeFilter != "" {
+		status = fmt.Sprintf("Showing %d of %d items", len(items), m.historyManager.Count())
 	} else {
 		status = fmt.Sprintf("Total items: %d", len(items))
 	}
+	if m.typeFilter != "" {
+		status += fmt.Sprintf(" \u2022 type: %s", m.typeFilter)
+	}
+	if m.alphabetical {
+		status += " \u2022 sorted A-Z"
+	}
+	if len(m.marked) > 0 {
+		status += fmt.Sprintf(" \u2022 %d marked", len(m.marked))
+	}
+	if m.historyManager.Incognito() {
+		status += " \u2022 incognito (not saved)"
+	}
+	if clearing := m.clearStatus(time.Now()); clearing != "" {
+		status += " \u2022 " + clearing
+	}
+	if m.follower {
+		status += " \u2022 following (read-only)"
+	} else if m.attachedPID != 0 {
+		status += fmt.Sprintf(" \u2022 attached to clippy (pid %d)", m.attachedPID)
+	} else if m.viewer {
+		status += " \u2022 daemon capturing"
+	} else if m.headless {
+		status += " \u2022 headless (r to load new entries)"
+	}
+	if named, ok := m.clipboard.(interface{ Name() string }); ok && named.Name() != "" {
+		status += " \u2022 clipboard: " + named.Name()
+	}
 
+	if m.notice != "" {
+		status += " \u2022 " + m.notice
+	}
 	content.WriteString("\n" + status + "\n")
 
 	var help string
-	if m.confirmDelete {
-		item := m.findByHash(m.confirmHash)
-		preview := ""
-		if item != nil {
-			preview = item.Item
-			if len(preview) > 40 {
-				preview = preview[:40] + "..."
-			}
-		}
-		help = fmt.Sprintf("Delete pinned item %q? (y/n)", preview)
+	if m.confirmMarked {
+		help = m.deleteMarkedPrompt()
+	} else if m.confirmDelete {
+		help = m.deletePrompt()
+	} else if m.confirmCommand != nil {
+		help = fmt.Sprintf("Run %q? (y/n)", strings.Join(m.confirmCommand.Command, " "))
+	} else if m.registerOp != noRegister {
+		help = m.registerPrompt()
+	} else if m.activeSelection() != nil {
+		help = renderHelp(m.keys.selectionHelp(), m.helpWidth())
+	} else if m.findOpen {
+		help = renderHelp(m.keys.findPromptHelp(), m.helpWidth())
+	} else if m.previewFocus {
+		help = renderHelp(m.keys.previewHelp(m.activeFind() != nil), m.helpWidth())
 	} else {
-		help = "Keys: \u2191/k \u2193/j navigate \u2022 Enter/c copy \u2022 p pin \u2022 d delete \u2022 / search \u2022 r refresh \u2022 q quit"
-		if m.filtered != nil {
-			help += " \u2022 esc clear search"
-		}
+		help = renderHelp(m.keys.tableHelp(m.filtered != nil), m.helpWidth())
 	}
 	content.WriteString(m.theme.Help.Render(help))
 
-	v := tea.NewView(m.theme.Doc.Render(content.String()))
+	v := m.screen(content.String())
+	// Lets a peek end as soon as its key is released, where supported
+	v.KeyboardEnhancements.ReportEventTypes = m.masked
+	if m.mouse {
+		v.MouseMode = tea.MouseModeCellMotion
+	}
+	return v
+}
+
+// screen renders content in the document style as the full-screen view
+func (m Model) screen(content string) tea.View {
+	v := tea.NewView(m.theme.Doc.Render(content))
 	v.AltScreen = true
 	v.WindowTitle = "Clippy"
 	return v
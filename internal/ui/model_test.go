@@ -1,12 +1,20 @@
 package ui
 
 import (
+	"fmt"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/search"
+	"github.com/bvdwalt/clippy/internal/ui/styles"
 )
 
 func TestNewModel(t *testing.T) {
@@ -543,12 +551,48 @@ func TestModelDeleteKey(t *testing.T) {
 	newModel, _ := model.Update(dMsg)
 	model = newModel.(Model)
 
+	if historyManager.Count() != initialCount {
+		t.Fatal("expected 'd' to ask before deleting")
+	}
+	selected := historyManager.GetItems()[0].Item
+	if !contains(model.View(), fmt.Sprintf("Delete item %q? (y/n)", selected)) {
+		t.Error("expected the confirmation prompt to quote the item")
+	}
+
+	newModel, _ = model.Update(tea.KeyPressMsg(tea.Key{Text: "y"}))
+	model = newModel.(Model)
+
 	if historyManager.Count() != initialCount-1 {
 		t.Errorf("Expected item count to decrease by 1, got %d (was %d)", historyManager.Count(), initialCount)
 	}
 	_ = model
 }
 
+func TestModelInstantDelete(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("pinned item")
+	if err := historyManager.TogglePin(0); err != nil {
+		t.Fatalf("TogglePin: %v", err)
+	}
+	historyManager.AddItem("item to delete")
+	model := NewModel(historyManager)
+	model.SetInstantDelete(true)
+
+	// The unpinned item sorts below the pinned one
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = pressKey(model, tea.Key{Text: "d"})
+	if historyManager.Count() != 1 || model.confirmDelete {
+		t.Fatalf("expected the unpinned item deleted without asking, have %d items", historyManager.Count())
+	}
+
+	model = pressKey(model, tea.Key{Text: "d"})
+	if historyManager.Count() != 1 || !model.confirmDelete {
+		t.Error("expected pinned items to be confirmed even with instant deletes")
+	}
+}
+
 func TestModelRefreshKey(t *testing.T) {
 	historyManager, cleanup := setupTestHistoryManager(t)
 	defer cleanup()
@@ -683,6 +727,39 @@ func TestModelPinKey(t *testing.T) {
 	_ = model
 }
 
+func TestModelMovePinnedKey(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	for range 2 {
+		if err := historyManager.TogglePin(1); err != nil {
+			t.Fatalf("TogglePin: %v", err)
+		}
+	}
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	// The cursor starts on the last row, "first"; "[" moves it up and the
+	// cursor follows
+	newModel, _ = model.Update(tea.KeyPressMsg(tea.Key{Text: "["}))
+	model = newModel.(Model)
+	if item, _ := historyManager.GetItem(0); item.Item != "first" {
+		t.Errorf("expected 'first' moved above 'second', got %q at index 0", item.Item)
+	}
+	if selected := model.selectedItem(); selected == nil || selected.Item != "first" {
+		t.Errorf("expected the cursor to follow the moved item, got %v", selected)
+	}
+
+	newModel, _ = model.Update(tea.KeyPressMsg(tea.Key{Text: "]"}))
+	model = newModel.(Model)
+	if item, _ := historyManager.GetItem(1); item.Item != "first" {
+		t.Errorf("expected 'first' moved back down, got %q at index 1", item.Item)
+	}
+}
+
 func TestModelDeletePinnedItemConfirmY(t *testing.T) {
 	historyManager, cleanup := setupTestHistoryManager(t)
 	defer cleanup()
@@ -772,3 +849,712 @@ func TestModelDeletePinnedItemConfirmEsc(t *testing.T) {
 	}
 	_ = model
 }
+
+func TestModelTypeFilterKey(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("plain words")
+	historyManager.AddItem("https://example.com")
+	historyManager.AddItem("#ff00aa")
+	model := NewModel(historyManager)
+
+	tKey := tea.KeyPressMsg(tea.Key{Text: "t"})
+
+	// First press filters to the first type (text)
+	newModel, _ := model.Update(tKey)
+	model = newModel.(Model)
+	if model.typeFilter != detect.Text {
+		t.Fatalf("typeFilter = %q, want %q", model.typeFilter, detect.Text)
+	}
+	if items := model.getDisplayItems(); len(items) != 1 || items[0].Item != "plain words" {
+		t.Errorf("expected only the text item, got %v", items)
+	}
+
+	// Second press moves to URL
+	newModel, _ = model.Update(tKey)
+	model = newModel.(Model)
+	if model.typeFilter != detect.URL {
+		t.Fatalf("typeFilter = %q, want %q", model.typeFilter, detect.URL)
+	}
+	if !contains(model.View(), "type: url") {
+		t.Error("expected active type filter in status line")
+	}
+	if !contains(model.View(), "Showing 1 of 3 items") {
+		t.Error("expected filtered count in status line")
+	}
+
+	// Cycling through every type returns to unfiltered
+	for range len(detect.Types) - 1 {
+		newModel, _ = model.Update(tKey)
+		model = newModel.(Model)
+	}
+	if model.typeFilter != "" {
+		t.Errorf("expected filter to wrap to empty, got %q", model.typeFilter)
+	}
+	if len(model.getDisplayItems()) != 3 {
+		t.Errorf("expected all items after wrap, got %d", len(model.getDisplayItems()))
+	}
+}
+
+func TestModelSearchTypeFilter(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("https://github.com")
+	historyManager.AddItem("github notes")
+	model := NewModel(historyManager)
+
+	model.filterItems("type:url")
+	items := model.getDisplayItems()
+	if len(items) != 1 || items[0].Type != detect.URL {
+		t.Errorf("expected only the URL item, got %v", items)
+	}
+}
+
+func TestModelViewImageEntry(t *testing.T) {
+	historyManager := history.NewInMemoryManager()
+	historyManager.AddImage([]byte("fake-png-bytes"), "image/png")
+	model := NewModel(historyManager)
+
+	view := model.View()
+	if !contains(view, "[image/png 14 B]") {
+		t.Error("expected image description row in view")
+	}
+	if !contains(view, "image") {
+		t.Error("expected image type in view")
+	}
+}
+
+func TestModelPreviewShowsImageFile(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	historyManager.AddImage([]byte("fake-png-bytes"), "image/png")
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	model = newModel.(Model)
+
+	item, _ := historyManager.GetItem(0)
+	if !contains(model.View().Content, "saved as") || !contains(model.View().Content, item.Hash[:8]) {
+		t.Error("expected the preview label to show where the image is saved")
+	}
+}
+
+func TestModelPreviewShowsCopyCount(t *testing.T) {
+	historyManager := history.NewInMemoryManager()
+	historyManager.SetBumpDuplicates(true)
+	historyManager.AddItem("again")
+	historyManager.AddItem("again")
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	model = newModel.(Model)
+
+	if !contains(model.View(), "copied 2 times") {
+		t.Error("expected copy count in preview label")
+	}
+}
+
+func TestModelSetMatcher(t *testing.T) {
+	historyManager := history.NewInMemoryManager()
+	historyManager.AddItem("deploy production server")
+	historyManager.AddItem("unrelated")
+	model := NewModel(historyManager)
+
+	model.SetMatcher(search.NewTrigramMatcher())
+	model.filterItems("server deploy")
+
+	items := model.getDisplayItems()
+	if len(items) != 1 || items[0].Item != "deploy production server" {
+		t.Errorf("expected trigram matcher to be used, got %v", items)
+	}
+}
+
+func TestSearchDebounceAppliesLatestQuery(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	useDebounceAbove(t, 0)
+
+	historyManager.AddItem("apple pie")
+	historyManager.AddItem("banana bread")
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Text: "/"})
+	model = typeText(model, "ban")
+	if model.filtered != nil {
+		t.Fatal("expected filtering to wait for the debounce")
+	}
+
+	// A tick from an earlier keystroke is stale and must be ignored
+	newModel, _ := model.Update(searchDebounceMsg{seq: model.searchSeq - 1})
+	model = newModel.(Model)
+	if model.filtered != nil {
+		t.Fatal("expected stale debounce tick to be ignored")
+	}
+
+	newModel, _ = model.Update(searchDebounceMsg{seq: model.searchSeq})
+	model = newModel.(Model)
+	if len(model.filtered) != 1 || model.filtered[0].Item != "banana bread" {
+		t.Errorf("expected only banana bread to match, got %+v", model.filtered)
+	}
+	if model.mode != SearchView {
+		t.Error("expected to remain in search mode")
+	}
+	if !contains(model.View().Content, "1 matches") {
+		t.Error("expected match count in search box")
+	}
+}
+
+func TestSearchDebounceIgnoredAfterEscape(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	useDebounceAbove(t, 0)
+
+	historyManager.AddItem("apple pie")
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Text: "/"})
+	model = typeText(model, "app")
+	seq := model.searchSeq
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+
+	newModel, _ := model.Update(searchDebounceMsg{seq: seq})
+	model = newModel.(Model)
+	if model.filtered != nil {
+		t.Error("expected pending search to be cancelled by Esc")
+	}
+}
+
+// useDebounceAbove makes live search debounce in histories larger than n.
+func useDebounceAbove(t *testing.T, n int) {
+	t.Helper()
+	orig := debounceAbove
+	t.Cleanup(func() { debounceAbove = orig })
+	debounceAbove = n
+}
+
+func TestSearchSmallHistoryFiltersOnEveryKeystroke(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	useDebounceAbove(t, 2)
+
+	historyManager.AddItem("apple pie")
+	historyManager.AddItem("banana bread")
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Text: "/"})
+	model = typeText(model, "ban")
+	if len(model.filtered) != 1 || model.filtered[0].Item != "banana bread" {
+		t.Fatalf("expected banana bread to match without waiting, got %+v", model.filtered)
+	}
+
+	// Clearing the query would show every entry again
+	historyManager.AddItem("cherry tart")
+	for range 3 {
+		model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	}
+	if len(model.filtered) != 1 {
+		t.Errorf("expected the larger history to wait for the debounce, got %+v", model.filtered)
+	}
+}
+
+func TestSearchWithoutDebounceFiltersImmediately(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("apple pie")
+	historyManager.AddItem("banana bread")
+	model := NewModel(historyManager)
+	model.SetSearchDebounce(0)
+
+	model = pressKey(model, tea.Key{Text: "/"})
+	model = typeText(model, "apple")
+	if len(model.filtered) != 1 || model.filtered[0].Item != "apple pie" {
+		t.Errorf("expected apple pie to match immediately, got %+v", model.filtered)
+	}
+}
+
+// fakeImporter returns queued batches of buffer contents, one per Poll.
+type fakeImporter struct {
+	batches [][]string
+}
+
+func (f *fakeImporter) Poll() ([]string, error) {
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func TestTmuxTickImportsBuffers(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model.SetBufferImporter(&fakeImporter{batches: [][]string{{"yanked in tmux", "another yank"}}})
+
+	newModel, cmd := model.Update(tmuxTickMsg(time.Now()))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Error("expected next tmux poll to be scheduled")
+	}
+	if historyManager.Count() != 2 {
+		t.Fatalf("expected 2 imported items, got %d", historyManager.Count())
+	}
+	if !contains(model.View().Content, "yanked in tmux") {
+		t.Error("expected imported buffer in table")
+	}
+}
+
+func TestHeadlessCopyUsesTerminalClipboard(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("server snippet")
+	model := NewModel(historyManager)
+	model.SetHeadless(true)
+
+	newModel, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected a command to set the terminal clipboard")
+	}
+	if !contains(model.View().Content, "headless") {
+		t.Error("expected headless indicator in status line")
+	}
+
+	// Ticks keep running without reading the clipboard
+	if _, cmd := model.Update(TickMsg(time.Now())); cmd == nil {
+		t.Error("expected next tick to be scheduled")
+	}
+	if historyManager.Count() != 1 {
+		t.Errorf("expected no clipboard capture in headless mode, got %d items", historyManager.Count())
+	}
+}
+
+func TestViewerReloadsDaemonChanges(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("existing")
+	model := NewModel(historyManager)
+	model.SetViewer(true)
+	if !contains(model.View().Content, "daemon capturing") {
+		t.Error("expected daemon indicator in status line")
+	}
+
+	// The daemon records a copy through its own connection
+	daemonManager, err := history.NewManagerWithPath(filepath.Join(historyManager.DataDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open daemon manager: %v", err)
+	}
+	defer func() {
+		if err := daemonManager.Close(); err != nil {
+			t.Logf("close daemon manager: %v", err)
+		}
+	}()
+	if err := daemonManager.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	daemonManager.AddItem("from daemon")
+
+	newModel, cmd := model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Error("expected next tick to be scheduled")
+	}
+	if !contains(model.View().Content, "from daemon") {
+		t.Error("expected the viewer to show entries recorded by the daemon")
+	}
+}
+
+type fakeHeartbeat struct {
+	beats int
+}
+
+func (f *fakeHeartbeat) Refresh(time.Time) error { f.beats++; return nil }
+
+func TestHeartbeatWhileCapturing(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	useFormats(t, nil)
+	model := NewModel(historyManager)
+	model.SetClipboard(&fakeClipboard{})
+	heartbeat := &fakeHeartbeat{}
+	model.SetHeartbeat(heartbeat)
+
+	newModel, _ := model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	if heartbeat.beats != 1 {
+		t.Errorf("beats = %d, want one per tick", heartbeat.beats)
+	}
+}
+
+func TestAttachedViewerShowsCapturingTUI(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model.SetAttached(4242)
+	if !contains(model.View().Content, "attached to clippy (pid 4242)") {
+		t.Error("expected the capturing TUI named in the status line")
+	}
+}
+
+func TestFollowerIsReadOnly(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	useFormats(t, nil)
+	historyManager.AddItem("existing")
+	if err := historyManager.SetIncognito(true); err != nil {
+		t.Fatalf("SetIncognito: %v", err)
+	}
+	clipboard := &fakeClipboard{text: "not for the follower"}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+	model.SetFollower(true)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	view := model.View().Content
+	if !contains(view, "following (read-only)") {
+		t.Error("expected follower indicator in status line")
+	}
+	if contains(view, "pin") || contains(view, "delete") {
+		t.Error("expected keys that change history to be left out of the help")
+	}
+
+	// Even in incognito mode, when a viewer would capture, nothing is
+	// recorded, and keys that change history do nothing
+	newModel, _ = model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	model = typeText(model, "pdi")
+	item, _ := historyManager.GetItem(0)
+	if historyManager.Count() != 1 || item.Pinned || !historyManager.Incognito() {
+		t.Errorf("expected history unchanged, got %d items (pinned %v, incognito %v)",
+			historyManager.Count(), item.Pinned, historyManager.Incognito())
+	}
+
+	// Entries can still be copied
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if clipboard.text != "existing" {
+		t.Errorf("clipboard = %q, want existing", clipboard.text)
+	}
+}
+
+func TestPickModeSelectsAndQuits(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("ls -la")
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+
+	if _, ok := model.Picked(); ok {
+		t.Fatal("expected nothing picked yet")
+	}
+	newModel, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected quit command")
+	}
+	if item, ok := model.Picked(); !ok || item.Item != "ls -la" {
+		t.Errorf("Picked() = %+v, %v", item, ok)
+	}
+}
+
+type fakeWatcher chan struct{}
+
+func (f fakeWatcher) Changes() <-chan struct{} { return f }
+
+func TestClipboardWatcherReplacesPolling(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model.SetHeadless(true)
+	watcher := make(fakeWatcher, 1)
+	model.SetClipboardWatcher(watcher)
+
+	watcher <- struct{}{}
+	if _, cmd := model.Update(TickMsg(time.Now())); cmd == nil {
+		t.Error("expected next tick to be scheduled")
+	}
+	if len(watcher) != 1 {
+		t.Error("expected the tick to leave the change notification pending")
+	}
+
+	_, cmd := model.Update(clipboardChangedMsg{})
+	if cmd == nil {
+		t.Fatal("expected to wait for the next change")
+	}
+	if msg := cmd(); msg != (clipboardChangedMsg{}) {
+		t.Errorf("expected clipboardChangedMsg, got %T", msg)
+	}
+}
+
+func TestCapturePrimaryEnablesSelectionCopy(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItemFrom("highlighted text", history.SelectionPrimary)
+	model := NewModel(historyManager)
+	model.height, model.width = 30, 120
+	model.previewHeight = 3
+	if contains(model.View().Content, "copy to selection") {
+		t.Error("expected no selection copy binding unless primary capture is enabled")
+	}
+	if !contains(model.View().Content, "primary selection") {
+		t.Error("expected the preview to show the entry came from the primary selection")
+	}
+
+	model.SetCapturePrimary(true)
+	if !contains(model.View().Content, "s copy to selection") {
+		t.Error("expected the selection copy binding in help")
+	}
+}
+
+type fakeClipboard struct {
+	text string
+}
+
+func (f *fakeClipboard) Read() (string, error)   { return f.text, nil }
+func (f *fakeClipboard) Write(text string) error { f.text = text; return nil }
+
+// namedClipboard is a fakeClipboard naming its backend
+type namedClipboard struct {
+	fakeClipboard
+	name string
+}
+
+func (c *namedClipboard) Name() string { return c.name }
+
+func TestStatusShowsClipboardBackend(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model.SetClipboard(&namedClipboard{name: "xsel (wl-clipboard failed)"})
+	if !contains(model.View().Content, "clipboard: xsel (wl-clipboard failed)") {
+		t.Error("expected the status line to name the backend in use")
+	}
+	model.SetClipboard(&fakeClipboard{})
+	if contains(model.View().Content, "clipboard:") {
+		t.Error("expected no backend shown when it can't be named")
+	}
+}
+
+func TestIncognitoToggle(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	useFormats(t, nil)
+	clipboard := &fakeClipboard{text: "password123"}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+	// The daemon doesn't record while incognito, so the viewer captures
+	model.SetViewer(true)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = typeText(model, "i")
+	if !historyManager.Incognito() {
+		t.Fatal("expected i to switch incognito mode on")
+	}
+	newModel, _ = model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	view := model.View().Content
+	if !contains(view, "incognito (not saved)") || !contains(view, "\u2022 not saved") {
+		t.Error("expected the status line and preview to show incognito mode")
+	}
+
+	// Nothing reaches the database
+	other, err := history.NewManagerWithPath(filepath.Join(historyManager.DataDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewManagerWithPath: %v", err)
+	}
+	defer other.Close()
+	if err := other.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB: %v", err)
+	}
+	if historyManager.Count() != 1 || other.Count() != 0 {
+		t.Errorf("expected the item only in memory, got %d in memory and %d stored",
+			historyManager.Count(), other.Count())
+	}
+
+	model = typeText(model, "i")
+	if historyManager.Incognito() {
+		t.Error("expected i to switch incognito mode off")
+	}
+}
+
+func TestClipboardCaptureAndCopy(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	useFormats(t, nil)
+	historyManager.AddItem("older entry")
+	clipboard := &fakeClipboard{text: "just copied"}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+
+	newModel, _ := model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	if historyManager.Count() != 2 {
+		t.Fatalf("expected the clipboard text to be captured, got %d items", historyManager.Count())
+	}
+
+	// The cursor starts on the oldest entry
+	hook := &fakeCopyHook{}
+	model.SetCopyHook(hook)
+	model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if clipboard.text != "older entry" {
+		t.Errorf("clipboard holds %q, want the selected entry", clipboard.text)
+	}
+	if len(hook.copied) != 1 || hook.copied[0] != "older entry" {
+		t.Errorf("copy hook saw %q, want the copied entry", hook.copied)
+	}
+}
+
+type fakeCopyHook struct {
+	copied []string
+}
+
+func (f *fakeCopyHook) Copied(item history.ClipboardHistory, text string) {
+	f.copied = append(f.copied, text)
+}
+
+func TestCaptureDebounce(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	useFormats(t, nil)
+	clipboard := &fakeClipboard{}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+	model.SetCaptureDebounce(time.Second)
+
+	start := time.Now()
+	for i, text := range []string{"10%", "50%", "100%"} {
+		clipboard.text = text
+		newModel, _ := model.Update(TickMsg(start.Add(time.Duration(i) * 500 * time.Millisecond)))
+		model = newModel.(Model)
+	}
+	if historyManager.Count() != 0 {
+		t.Fatalf("Count = %d, want nothing recorded while the clipboard keeps changing", historyManager.Count())
+	}
+	newModel, _ := model.Update(TickMsg(start.Add(2 * time.Second)))
+	model = newModel.(Model)
+	if historyManager.Count() != 1 {
+		t.Fatalf("Count = %d, want the settled value recorded", historyManager.Count())
+	}
+	if item, _ := historyManager.GetItem(0); item.Item != "100%" {
+		t.Errorf("recorded %q, want the final value", item.Item)
+	}
+}
+
+// fakeRecorder keeps what a session recorder is given
+type fakeRecorder struct {
+	private  []bool
+	captured []string
+}
+
+func (r *fakeRecorder) Input(msg tea.Msg, private bool) {
+	if _, ok := msg.(tea.KeyPressMsg); ok {
+		r.private = append(r.private, private)
+	}
+}
+func (r *fakeRecorder) Captured(text string) { r.captured = append(r.captured, text) }
+
+func TestRecorderSeesInputAndCaptures(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	recorder := &fakeRecorder{}
+	model := NewModel(historyManager)
+	model.SetClipboard(&fakeClipboard{text: "copied elsewhere"})
+	model.SetRecorder(recorder)
+
+	model = typeText(model, "/ab")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	want := []bool{false, true, true, true}
+	if !slices.Equal(recorder.private, want) {
+		t.Errorf("private = %v, want %v: only keys typed into the search are private", recorder.private, want)
+	}
+
+	model.captureClipboard(time.Now())
+	if !slices.Equal(recorder.captured, []string{"copied elsewhere"}) {
+		t.Errorf("captured = %q", recorder.captured)
+	}
+}
+
+func TestQuitOnCopy(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	model := NewModel(historyManager)
+	clipboard := &fakeClipboard{}
+	model.SetClipboard(clipboard)
+	model.SetQuitOnCopy(true)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = updated.(Model)
+
+	for _, k := range []tea.Key{{Code: tea.KeyEnter}, {Code: '2', Text: "2"}} {
+		clipboard.text = ""
+		_, cmd := model.Update(tea.KeyPressMsg(k))
+		if clipboard.text == "" {
+			t.Errorf("%s copied nothing", k)
+		}
+		if cmd == nil {
+			t.Fatalf("expected %s to quit after copying", k)
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf("expected %s to quit after copying", k)
+		}
+	}
+
+	// Other keys leave it open
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: 'j', Text: "j"})); cmd != nil {
+		if _, ok := cmd().(tea.QuitMsg); ok {
+			t.Error("expected moving the cursor not to quit")
+		}
+	}
+}
+
+func TestSetThemeFramesFitTheScreen(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	historyManager.AddItem(strings.Repeat("a long line of text ", 20))
+
+	render := func(theme styles.Theme) string {
+		model := NewModel(historyManager)
+		model.SetTheme(theme)
+		newModel, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+		return newModel.(Model).View().Content
+	}
+	theme := styles.DefaultTheme()
+	theme.Doc = theme.Doc.Margin(2, 4)
+	theme.Preview = theme.Preview.Border(lipgloss.DoubleBorder()).Padding(1, 3)
+
+	view := render(theme)
+	if !strings.Contains(view, "╔") {
+		t.Fatal("expected the preview drawn with the theme's border")
+	}
+	// The preview gives up the lines its larger frame takes
+	lines := strings.Split(view, "\n")
+	if want := strings.Count(render(styles.DefaultTheme()), "\n") + 1; len(lines) != want {
+		t.Errorf("view is %d lines, want %d like the default theme", len(lines), want)
+	}
+	for _, line := range lines {
+		if w := lipgloss.Width(line); w > 80 {
+			t.Errorf("line %q is %d wide, wider than the 80 column screen", line, w)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// doubleClick is the longest gap between two clicks on a row for them to
+// copy it
+const doubleClick = 400 * time.Millisecond
+
+// wheelRows is how many rows, or preview lines, one wheel notch scrolls
+const wheelRows = 3
+
+// SetMouse enables clicking and scrolling the table with the mouse. It
+// stops the terminal selecting text itself while clippy runs.
+func (m *Model) SetMouse(enabled bool) {
+	m.mouse = enabled
+}
+
+// updateMouse handles a mouse event in the table view: a click selects the
+// row under it, a second click on the same row copies it, and the wheel
+// moves the cursor, or scrolls the preview when it is over it.
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.mode != TableView || m.showHelp || m.findOpen || m.activeSelection() != nil ||
+		m.confirmDelete || m.confirmMarked || m.confirmCommand != nil || m.registerOp != noRegister {
+		return m, nil
+	}
+	mouse := msg.Mouse()
+	line := mouse.Y - m.tableTop()
+	switch msg := msg.(type) {
+	case tea.MouseWheelMsg:
+		delta := wheelRows
+		if msg.Button == tea.MouseWheelUp {
+			delta = -wheelRows
+		} else if msg.Button != tea.MouseWheelDown {
+			return m, nil
+		}
+		if m.previewFocus || line >= lipgloss.Height(m.tableManager.Render(true)) {
+			m.scrollPreview(delta)
+		} else {
+			m.moveCursor(m.tableManager.GetCursor() + delta)
+		}
+	case tea.MouseClickMsg:
+		if msg.Button != tea.MouseLeft {
+			return m, nil
+		}
+		row, ok := m.tableManager.RowAt(line)
+		if !ok {
+			return m, nil
+		}
+		now := time.Now()
+		again := row == m.clickRow && now.Sub(m.clickedAt) <= doubleClick
+		m.clickRow, m.clickedAt = row, now
+		m.previewFocus = false
+		if row != m.tableManager.GetCursor() {
+			m.moveCursor(row)
+		}
+		if again {
+			// A third click shouldn't copy again
+			m.clickedAt = time.Time{}
+			return m, m.copySelected()
+		}
+	}
+	return m, nil
+}
+
+// tableTop is the screen line the table's header is drawn on
+func (m Model) tableTop() int {
+	top := m.theme.Doc.GetMarginTop() + m.theme.Doc.GetPaddingTop() + lipgloss.Height(m.titleBar()) + 1
+	if banner := m.unsavedBanner(); banner != "" {
+		top += lipgloss.Height(banner) + 1
+	}
+	return top
+}
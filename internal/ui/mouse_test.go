@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// screenLine returns the line of the rendered view containing text
+func screenLine(t *testing.T, m Model, text string) int {
+	t.Helper()
+	for i, line := range strings.Split(m.View().Content, "\n") {
+		if strings.Contains(ansi.Strip(line), text) {
+			return i
+		}
+	}
+	t.Fatalf("%q is not on screen", text)
+	return 0
+}
+
+func click(m Model, y int) Model {
+	updated, _ := m.Update(tea.MouseClickMsg{Button: tea.MouseLeft, X: 10, Y: y})
+	return updated.(Model)
+}
+
+func TestMouseClickSelectsAndCopies(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for i := range 5 {
+		historyManager.AddItem(fmt.Sprintf("entry %d", i))
+	}
+	model := NewModel(historyManager)
+	model.SetMouse(true)
+	clipboard := &fakeClipboard{}
+	model.SetClipboard(clipboard)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = updated.(Model)
+	if model.View().MouseMode != tea.MouseModeCellMotion {
+		t.Error("expected the table view to ask for mouse events")
+	}
+
+	model = click(model, screenLine(t, model, "entry 3"))
+	if got := model.tableManager.GetSelectedItem().Item; got != "entry 3" {
+		t.Fatalf("click selected %q, want entry 3", got)
+	}
+	if clipboard.text != "" {
+		t.Error("expected a single click not to copy")
+	}
+
+	// Clicking the header changes nothing
+	model = click(model, model.tableTop())
+	if got := model.tableManager.GetSelectedItem().Item; got != "entry 3" {
+		t.Errorf("header click selected %q", got)
+	}
+
+	model = click(model, screenLine(t, model, "entry 3"))
+	if clipboard.text != "entry 3" {
+		t.Errorf("double click copied %q, want entry 3", clipboard.text)
+	}
+}
+
+func TestMouseWheel(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for i := range 10 {
+		historyManager.AddItem(fmt.Sprintf("entry %d", i))
+	}
+	model := NewModel(historyManager)
+	model.SetMouse(true)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = updated.(Model)
+
+	y := model.tableTop() + 2
+	updated, _ = model.Update(tea.MouseWheelMsg{Button: tea.MouseWheelDown, Y: y})
+	model = updated.(Model)
+	if got := model.GetCursor(); got != wheelRows {
+		t.Errorf("wheel down: cursor = %d, want %d", got, wheelRows)
+	}
+	updated, _ = model.Update(tea.MouseWheelMsg{Button: tea.MouseWheelUp, Y: y})
+	model = updated.(Model)
+	if got := model.GetCursor(); got != 0 {
+		t.Errorf("wheel up: cursor = %d, want 0", got)
+	}
+}
+
+func TestMouseOff(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("entry")
+	model := NewModel(historyManager)
+	if model.View().MouseMode != tea.MouseModeNone {
+		t.Error("expected no mouse events unless enabled")
+	}
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/lipgloss/v2"
+)
+
+// helpSection is one mode's bindings in the help overlay
+type helpSection struct {
+	title    string
+	bindings []key.Binding
+}
+
+// helpSections lists every mode's bindings, taken from the same lists as
+// the help line, so the overlay shows the keys actually in effect
+func (k keyMap) helpSections() []helpSection {
+	sections := []helpSection{
+		{"Table", k.tableHelp(true)},
+		{"Search", k.searchHelp()},
+		{"Preview (Tab)", append(k.previewHelp(false), k.FindNext, k.ClearFind)},
+		{"Find in preview", k.findPromptHelp()},
+		{"Selecting lines", k.selectionHelp()},
+		{"Action menu", k.actionHelp()},
+		{"Command palette (Ctrl+p)", k.paletteHelp()},
+	}
+	if k.Rules.Enabled() {
+		sections = append(sections, helpSection{"Capture rules (P)", append(k.rulesHelp(), k.ruleFormHelp()...)})
+	}
+	return sections
+}
+
+// helpOverlayGap separates the columns of the help overlay
+const helpOverlayGap = "    "
+
+// helpOverlay renders every mode's bindings as titled columns, one binding
+// a line, wrapping the columns onto further rows to fit width
+func (m *Model) helpOverlay(width int) string {
+	var blocks []string
+	for _, section := range m.keys.helpSections() {
+		var keys, descs []string
+		for _, b := range section.bindings {
+			if !b.Enabled() || b.Help().Key == "" {
+				continue
+			}
+			keys = append(keys, b.Help().Key)
+			descs = append(descs, b.Help().Desc)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		keyWidth := 0
+		for _, k := range keys {
+			keyWidth = max(keyWidth, lipgloss.Width(k))
+		}
+		lines := []string{m.theme.HelpTitle.Render(section.title)}
+		for i := range keys {
+			pad := strings.Repeat(" ", keyWidth-lipgloss.Width(keys[i]))
+			lines = append(lines, m.theme.HelpKey.Render(keys[i])+pad+"  "+descs[i])
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	var rows, row []string
+	rowWidth := 0
+	for _, block := range blocks {
+		blockWidth := lipgloss.Width(block)
+		if len(row) > 0 && width > 0 && rowWidth+len(helpOverlayGap)+blockWidth > width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, rowWidth = nil, 0
+		}
+		if len(row) > 0 {
+			row = append(row, helpOverlayGap)
+			rowWidth += len(helpOverlayGap)
+		}
+		row = append(row, block)
+		rowWidth += blockWidth
+	}
+	if len(row) > 0 {
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	}
+	return strings.Join(rows, "\n\n")
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHelpOverlay(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("entry")
+	model := NewModel(historyManager)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = updated.(Model)
+
+	model = typeText(model, "?")
+	view := ansi.Strip(model.View().Content)
+	for _, want := range []string{"Table", "Search", "Preview (Tab)", "Action menu", "u/Ctrl+r", "undo/redo delete", "Enter  apply", "?/Esc/q close"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the help overlay", want)
+		}
+	}
+	if strings.Contains(view, "copy to selection") {
+		t.Error("expected disabled bindings left out")
+	}
+
+	// Keys don't act while it is open, and q closes it rather than quitting
+	model = typeText(model, "d")
+	if model.confirmDelete {
+		t.Error("expected d to do nothing under the overlay")
+	}
+	updated, cmd := model.Update(tea.KeyPressMsg(tea.Key{Text: "q"}))
+	model = updated.(Model)
+	if cmd != nil || model.showHelp {
+		t.Error("expected q to close the overlay")
+	}
+	if strings.Contains(ansi.Strip(model.View().Content), "Action menu") {
+		t.Error("expected the table back once the overlay is closed")
+	}
+}
@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/actions"
+	"github.com/sahilm/fuzzy"
+)
+
+// paletteRows is how many commands the palette lists at once
+const paletteRows = 10
+
+// paletteCommand is one entry of the command palette: a table key, run as
+// if pressed, or a quick action for the selected item
+type paletteCommand struct {
+	name    string
+	binding key.Binding
+	action  *actions.Action
+}
+
+// palette is the state of PaletteView
+type palette struct {
+	input    textinput.Model
+	commands []paletteCommand // everything on offer, in display order
+	matches  []paletteCommand // commands matching the input, best first
+	cursor   int
+}
+
+// paletteCommands lists the table keys the palette offers by name; moving
+// around the table is left to the keys themselves
+func (k keyMap) paletteCommands() []paletteCommand {
+	named := []struct {
+		name    string
+		binding key.Binding
+	}{
+		{"Copy entry", k.Copy},
+		{"Copy to primary selection", k.CopyPrimary},
+		{"Quick actions", k.Actions},
+		{"Search history", k.Search},
+		{"Filter by type", k.Type},
+		{"Sort A-Z / newest first", k.Sort},
+		{"Pin or unpin entry", k.Pin},
+		{"Move pinned entry up", k.MoveUp},
+		{"Move pinned entry down", k.MoveDown},
+		{"Set alias", k.Alias},
+		{"Store in register", k.Register},
+		{"Copy from register", k.Recall},
+		{"Cycle expiry", k.Expire},
+		{"Edit in $EDITOR", k.Edit},
+		{"Delete entry", k.Delete},
+		{"Undo delete", k.Undo},
+		{"Redo delete", k.Redo},
+		{"Mark entry", k.Mark},
+		{"Copy marked as steps", k.CopySteps},
+		{"Copy marked joined with &&", k.CopyChain},
+		{"Copy marked joined", k.CopyJoined},
+		{"Delete marked", k.DeleteMarked},
+		{"Export marked", k.ExportMarked},
+		{"Clear history", k.ClearAll},
+		{"Edit capture rules", k.Rules},
+		{"Render or show markdown source", k.Markdown},
+		{"Highlight code or show it plain", k.Highlight},
+		{"Relative or absolute times", k.TimeFormat},
+		{"Hide or show content", k.Mask},
+		{"Incognito on/off", k.Incognito},
+		{"Refresh", k.Refresh},
+		{"Focus preview", k.FocusPreview},
+		{"Show all keys", k.Help},
+		{"Quit", k.Quit},
+	}
+	commands := make([]paletteCommand, 0, len(named))
+	for _, c := range named {
+		if c.binding.Enabled() {
+			commands = append(commands, paletteCommand{name: c.name, binding: c.binding})
+		}
+	}
+	return commands
+}
+
+// openPalette switches to PaletteView, offering the table's commands
+// followed by the quick actions for the selected item unless they are
+// disabled, as while content is masked
+func (m *Model) openPalette() {
+	commands := m.keys.paletteCommands()
+	if selected := m.selectedItem(); selected != nil && m.keys.Actions.Enabled() {
+		for _, action := range m.itemActions(*selected) {
+			commands = append(commands, paletteCommand{name: "Action: " + action.Label, action: &action})
+		}
+	}
+	input := textinput.New()
+	input.Placeholder = "Type a command..."
+	input.SetWidth(40)
+	input.Focus()
+	m.palette = &palette{input: input, commands: commands, matches: commands}
+	m.mode = PaletteView
+}
+
+// closePalette returns to the table
+func (m *Model) closePalette() {
+	m.mode = TableView
+	m.palette = nil
+}
+
+// filter ranks the commands fuzzily matching the input, listing all of
+// them in order while it is empty
+func (p *palette) filter() {
+	p.cursor = 0
+	query := strings.TrimSpace(p.input.Value())
+	if query == "" {
+		p.matches = p.commands
+		return
+	}
+	names := make([]string, len(p.commands))
+	for i, c := range p.commands {
+		names[i] = c.name
+	}
+	p.matches = nil
+	for _, match := range fuzzy.Find(query, names) {
+		p.matches = append(p.matches, p.commands[match.Index])
+	}
+}
+
+// updatePalette handles key presses while the palette is open
+func (m Model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.palette
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.PaletteClose):
+		m.closePalette()
+		return m, nil
+	case key.Matches(msg, m.keys.PaletteDown):
+		p.cursor = min(p.cursor+1, max(len(p.matches)-1, 0))
+		return m, nil
+	case key.Matches(msg, m.keys.PaletteUp):
+		p.cursor = max(p.cursor-1, 0)
+		return m, nil
+	case key.Matches(msg, m.keys.PaletteRun):
+		if len(p.matches) == 0 {
+			return m, nil
+		}
+		return m.runPaletteCommand(p.matches[p.cursor])
+	}
+	var cmd tea.Cmd
+	before := p.input.Value()
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != before {
+		p.filter()
+	}
+	return m, cmd
+}
+
+// runPaletteCommand closes the palette and runs command: a quick action
+// directly, or a key as if it had been pressed in the table
+func (m Model) runPaletteCommand(command paletteCommand) (tea.Model, tea.Cmd) {
+	m.closePalette()
+	if command.action != nil {
+		if selected := m.selectedItem(); selected != nil {
+			m.actionMenu = &actionMenu{hash: selected.Hash}
+			return m.runAction(*command.action)
+		}
+		return m, nil
+	}
+	return m.Update(keyPress(command.binding.Keys()[0]))
+}
+
+// keyPress builds the press of a key as key.Binding names it, e.g. "G",
+// "enter" or "ctrl+r"
+func keyPress(name string) tea.KeyPressMsg {
+	named := map[string]rune{
+		"enter":  tea.KeyEnter,
+		"tab":    tea.KeyTab,
+		"esc":    tea.KeyEscape,
+		"pgup":   tea.KeyPgUp,
+		"pgdown": tea.KeyPgDown,
+		"home":   tea.KeyHome,
+		"end":    tea.KeyEnd,
+	}
+	if code, ok := named[name]; ok {
+		return tea.KeyPressMsg(tea.Key{Code: code})
+	}
+	if name == "space" {
+		return tea.KeyPressMsg(tea.Key{Code: tea.KeySpace, Text: " "})
+	}
+	if rest, ok := strings.CutPrefix(name, "ctrl+"); ok {
+		r, _ := utf8.DecodeRuneInString(rest)
+		return tea.KeyPressMsg(tea.Key{Code: r, Mod: tea.ModCtrl})
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return tea.KeyPressMsg(tea.Key{Code: r, Text: name})
+}
+
+// paletteView renders the command palette box, scrolled to keep the
+// chosen command in view
+func (m Model) paletteView() string {
+	p := m.palette
+	start := max(p.cursor-paletteRows+1, 0)
+	end := min(start+paletteRows, len(p.matches))
+
+	var lines strings.Builder
+	for i := start; i < end; i++ {
+		command := p.matches[i]
+		cursor := "  "
+		if i == p.cursor {
+			cursor = "> "
+		}
+		line := cursor + command.name
+		if command.action == nil {
+			line += "  " + m.theme.Help.Render(command.binding.Keys()[0])
+		}
+		lines.WriteString(line + "\n")
+	}
+	if len(p.matches) == 0 {
+		lines.WriteString(m.theme.Help.Render("No matching commands") + "\n")
+	}
+	count := fmt.Sprintf("%d of %d", len(p.matches), len(p.commands))
+	hint := m.theme.Help.Render(count + helpSeparator + renderHelp(m.keys.paletteHelp(), 0))
+	return m.theme.Search.Render(
+		fmt.Sprintf("⌘ Commands:\n\n%s\n\n%s\n%s", p.input.View(), lines.String(), hint))
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestPaletteRunsCommandByName(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("ssh deploy@prod")
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Code: 'p', Mod: tea.ModCtrl})
+	if model.mode != PaletteView {
+		t.Fatalf("mode = %v, want PaletteView", model.mode)
+	}
+	if !contains(model.View().Content, "Copy entry") {
+		t.Error("expected the commands listed before typing")
+	}
+
+	model = typeText(model, "pin")
+	if first := model.palette.matches[0].name; first != "Pin or unpin entry" {
+		t.Errorf("best match = %q, want the pin command", first)
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.mode != TableView {
+		t.Errorf("mode = %v, want the palette closed", model.mode)
+	}
+	if !historyManager.GetItems()[0].Pinned {
+		t.Error("expected the entry to be pinned")
+	}
+}
+
+func TestPaletteNavigatesAndCloses(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("hello")
+	model := NewModel(historyManager)
+	model = pressKey(model, tea.Key{Code: 'p', Mod: tea.ModCtrl})
+
+	model = typeText(model, "zzzz")
+	if len(model.palette.matches) != 0 || !contains(model.View().Content, "No matching commands") {
+		t.Error("expected no matches for nonsense")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.mode != PaletteView {
+		t.Error("expected Enter with nothing matching to keep the palette open")
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.mode != TableView || model.palette != nil {
+		t.Error("expected Esc to close the palette")
+	}
+
+	// Keys the palette runs as pressed in the table
+	model = pressKey(model, tea.Key{Code: 'p', Mod: tea.ModCtrl})
+	model = typeText(model, "show all keys")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if !model.showHelp {
+		t.Error("expected the help overlay to open")
+	}
+}
+
+func TestPaletteOffersQuickActions(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("3f9c2a1")
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+	model = pressKey(model, tea.Key{Code: 'p', Mod: tea.ModCtrl})
+	model = typeText(model, "action git show")
+	if len(model.palette.matches) == 0 || model.palette.matches[0].name != "Action: copy as git show" {
+		t.Fatalf("matches = %v, want the git show action first", model.palette.matches)
+	}
+
+	newModel, _ := model.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	model = newModel.(Model)
+	if item, ok := model.Picked(); !ok || item.Item != "git show 3f9c2a1" {
+		t.Errorf("Picked() = %q, %v", item.Item, ok)
+	}
+}
+
+func TestPaletteHidesQuickActionsWhileMasked(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("3f9c2a1")
+	model := NewModel(historyManager)
+	model.SetMasked(true)
+	model = pressKey(model, tea.Key{Code: 'p', Mod: tea.ModCtrl})
+	for _, c := range model.palette.commands {
+		if c.action != nil || strings.Contains(c.name, "3f9c2a1") {
+			t.Errorf("palette offers %q while masked", c.name)
+		}
+	}
+}
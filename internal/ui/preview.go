@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// selectedItem returns the item under the table cursor, or nil when the
+// table is empty
+func (m *Model) selectedItem() *history.ClipboardHistory {
+	items := m.getDisplayItems()
+	cursor := m.tableManager.GetCursor()
+	if cursor >= len(items) {
+		return nil
+	}
+	return &items[cursor]
+}
+
+// previewTextWidth is the width available to text inside the preview box
+func (m *Model) previewTextWidth() int {
+	// Inside the document's margin and the preview's border and padding
+	frame := m.theme.Preview.GetHorizontalFrameSize()
+	return max(m.width-m.theme.Doc.GetHorizontalFrameSize()-frame, 10) - frame
+}
+
+// previewLines wraps item's content to the preview width
+func (m *Model) previewLines(item history.ClipboardHistory) []string {
+	rows, _ := m.previewRows(item)
+	return rows
+}
+
+// previewRows wraps item's content to the preview width like previewLines,
+// also returning the index of the source line each row was wrapped from.
+// CSV and TSV content is laid out as a table instead, markdown rendered
+// unless its source is shown (see showsMarkdown), and code highlighted
+// (see highlightLexer).
+func (m *Model) previewRows(item history.ClipboardHistory) ([]string, []int) {
+	content := item.Item
+	if table, ok := detect.ParseTable(content); ok {
+		return m.tableRows(table)
+	}
+	if m.showsMarkdown(content) {
+		if rows, ok := m.markdownRows(content); ok {
+			// Rendered rows don't map to source lines; selecting lines
+			// shows the source first
+			return rows, make([]int, len(rows))
+		}
+	}
+	lines := strings.Split(content, "\n")
+	if lexer := m.highlightLexer(item); lexer != nil {
+		if highlighted, ok := m.highlightedLines(content, lexer); ok {
+			lines = highlighted
+		}
+	}
+	var rows []string
+	var sources []int
+	for i, line := range lines {
+		for _, row := range strings.Split(lipgloss.Wrap(line, m.previewTextWidth(), ""), "\n") {
+			rows = append(rows, row)
+			sources = append(sources, i)
+		}
+	}
+	return rows, sources
+}
+
+// maxCellWidth is the widest a column of a table preview gets
+const maxCellWidth = 30
+
+// tableRows lays table out with aligned columns, a rule under the header
+// row, and each row cut to the preview width. Like previewRows it returns
+// the source line of each row.
+func (m *Model) tableRows(table detect.TableData) ([]string, []int) {
+	widths := make([]int, table.Columns())
+	for _, row := range table.Rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], min(lipgloss.Width(cell), maxCellWidth))
+		}
+	}
+	var rows []string
+	var sources []int
+	for i, row := range table.Rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cell = ansi.Truncate(cell, widths[j], "…")
+			cells[j] = cell + strings.Repeat(" ", widths[j]-lipgloss.Width(cell))
+		}
+		rows = append(rows, ansi.Truncate(strings.Join(cells, " │ "), m.previewTextWidth(), "…"))
+		sources = append(sources, i)
+		if i == 0 {
+			rules := make([]string, len(widths))
+			for j, w := range widths {
+				rules[j] = strings.Repeat("─", w)
+			}
+			rows = append(rows, ansi.Truncate(strings.Join(rules, "─┼─"), m.previewTextWidth(), ""))
+			sources = append(sources, i)
+		}
+	}
+	return rows, sources
+}
+
+// tableLabel describes CSV or TSV content, e.g. "CSV • 3 columns × 10
+// rows", or "" for other content
+func tableLabel(content string) string {
+	table, ok := detect.ParseTable(content)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s • %d columns × %d rows", table.Format(), table.Columns(), len(table.Rows))
+}
+
+// previewScroll returns the scroll offset for item clamped to its content.
+// The offset belongs to the item it was set on, so moving the selection
+// starts the next preview at the top.
+func (m *Model) previewScroll(item history.ClipboardHistory, lines int) int {
+	if item.Hash != m.previewHash {
+		return 0
+	}
+	return min(m.previewOffset, max(lines-m.previewHeight, 0))
+}
+
+// scrollPreview moves the preview of the selected item by delta lines
+func (m *Model) scrollPreview(delta int) {
+	item := m.selectedItem()
+	if item == nil || m.previewHeight <= 0 {
+		return
+	}
+	lines := len(m.previewLines(*item))
+	m.previewOffset = max(m.previewScroll(*item, lines)+delta, 0)
+	m.previewHash = item.Hash
+	m.previewOffset = m.previewScroll(*item, lines)
+}
+
+// scrollPreviewTo scrolls item's preview as little as possible to show rows
+// first through last
+func (m *Model) scrollPreviewTo(item history.ClipboardHistory, first, last int) {
+	rows := len(m.previewLines(item))
+	offset := m.previewScroll(item, rows)
+	if first < offset {
+		offset = first
+	} else if last >= offset+m.previewHeight {
+		offset = last - m.previewHeight + 1
+	}
+	m.previewOffset = offset
+	m.previewHash = item.Hash
+}
+
+// previewPage is how far PgUp/PgDn scroll, keeping one line of context
+func (m *Model) previewPage() int {
+	return max(m.previewHeight-1, 1)
+}
+
+// previewWindow returns the visible lines of item's preview and a label
+// describing the scroll position, empty when everything fits
+func (m *Model) previewWindow(item history.ClipboardHistory) (string, string) {
+	lines, sources := m.previewRows(item)
+	m.highlightMatches(item, lines)
+	if sel := m.activeSelection(); sel != nil {
+		first, last := sel.bounds()
+		for i, source := range sources {
+			if source >= first && source <= last {
+				lines[i] = m.theme.PreviewSelection.Render(lines[i])
+			}
+		}
+	}
+	if len(lines) <= m.previewHeight {
+		return strings.Join(lines, "\n"), ""
+	}
+	offset := m.previewScroll(item, len(lines))
+	end := min(offset+m.previewHeight, len(lines))
+	position := fmt.Sprintf("lines %d-%d of %d", offset+1, end, len(lines))
+	if offset > 0 {
+		position += " ↑"
+	}
+	if end < len(lines) {
+		position += " ↓"
+	}
+	return strings.Join(lines[offset:end], "\n"), position
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestPreviewScrolling(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	lines := make([]string, 50)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line-%02d", i+1)
+	}
+	historyManager.AddItem(strings.Join(lines, "\n"))
+	historyManager.AddItem("short")
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+	height := model.previewHeight
+
+	view := model.View().Content
+	if !contains(view, fmt.Sprintf("lines 1-%d of 50 ↓", height)) {
+		t.Fatalf("expected scroll position in preview label, got:\n%s", view)
+	}
+	if contains(view, fmt.Sprintf("line-%02d", height+1)) {
+		t.Error("preview should be cut to its height")
+	}
+
+	model = pressKey(model, tea.Key{Text: "J"})
+	if !contains(model.View().Content, fmt.Sprintf("lines 2-%d of 50 ↑ ↓", height+1)) {
+		t.Error("J should scroll the preview down one line")
+	}
+	if model.GetCursor() != 0 {
+		t.Error("scrolling the preview should not move the table cursor")
+	}
+
+	for range 10 {
+		model = pressKey(model, tea.Key{Code: tea.KeyPgDown})
+	}
+	if !contains(model.View().Content, fmt.Sprintf("lines %d-50 of 50 ↑", 51-height)) {
+		t.Error("PgDn should stop at the end of the content")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyPgUp})
+	model = pressKey(model, tea.Key{Text: "K"})
+	want := 51 - height - model.previewPage() - 1
+	if !contains(model.View().Content, fmt.Sprintf("lines %d-", want)) {
+		t.Errorf("PgUp and K should scroll back up to line %d", want)
+	}
+
+	// Moving the selection starts the next preview at the top
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = pressKey(model, tea.Key{Code: tea.KeyUp})
+	if !contains(model.View().Content, fmt.Sprintf("lines 1-%d of 50", height)) {
+		t.Error("expected preview to reset after changing selection")
+	}
+}
+
+func TestPreviewFocus(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	lines := make([]string, 50)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line-%02d", i+1)
+	}
+	historyManager.AddItem(strings.Join(lines, "\n"))
+	historyManager.AddItem("short")
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	if !contains(model.View().Content, "Tab/Esc back to table") {
+		t.Fatal("expected preview help once the preview has focus")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	model = pressKey(model, tea.Key{Text: "j"})
+	if model.GetCursor() != 0 {
+		t.Error("navigation keys should scroll the focused preview, not the table")
+	}
+	if !contains(model.View().Content, "lines 3-") {
+		t.Error("expected the preview to scroll two lines")
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	model = pressKey(model, tea.Key{Code: tea.KeyDown})
+	if model.GetCursor() != 1 {
+		t.Error("expected navigation to move the table again after leaving the preview")
+	}
+}
+
+func TestSearchViewShowsDimmedResults(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("alpha")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	model = pressKey(model, tea.Key{Text: "/"})
+	view := model.View().Content
+	if !contains(view, "alpha") {
+		t.Error("expected results under the search input")
+	}
+	theme := styles.DefaultTableTheme()
+	if !strings.Contains(view, "38;5;"+theme.DimFg+";48;5;"+theme.SelectedBg) {
+		t.Error("expected the table to be dimmed while typing")
+	}
+}
+
+func TestPreviewAlignsTable(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("name,role\nalexandra,admin\nbo,user")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	view := model.View().Content
+	if !contains(view, "CSV • 2 columns × 3 rows") {
+		t.Error("expected the table size in the preview label")
+	}
+	for _, row := range []string{
+		"name      │ role",
+		"──────────┼──────",
+		"alexandra │ admin",
+		"bo        │ user",
+	} {
+		if !contains(view, row) {
+			t.Errorf("expected aligned row %q in the preview, got:\n%s", row, view)
+		}
+	}
+	if !contains(view, "2 actions (x)") {
+		t.Error("expected the table conversion actions")
+	}
+}
+
+func TestPreviewRendersMarkdown(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("## Setup\n\nRun **make** first:\n\n- install\n- test")
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	view := ansi.Strip(model.View().Content)
+	if !contains(view, "markdown (R for source)") {
+		t.Error("expected the markdown note in the preview label")
+	}
+	if !contains(view, "Run make first:") || !contains(view, "• install") {
+		t.Errorf("expected rendered markdown in the preview, got:\n%s", view)
+	}
+
+	model = typeText(model, "R")
+	view = ansi.Strip(model.View().Content)
+	if !contains(view, "Run **make** first:") || !contains(view, "markdown source") {
+		t.Errorf("expected the markdown source after R, got:\n%s", view)
+	}
+
+	// The focused preview shows the source, for finding and selecting lines
+	model = typeText(model, "R")
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	if view := ansi.Strip(model.View().Content); !contains(view, "Run **make** first:") {
+		t.Errorf("expected the source in the focused preview, got:\n%s", view)
+	}
+}
+
+func TestPreviewHighlightsCode(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	code := "func main() {\n\t// say hi\n\tfmt.Println(\"hi\")\n}"
+	historyManager.AddItem(code)
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	item := model.getDisplayItems()[0]
+	rows := model.previewLines(item)
+	if len(rows) != 4 || ansi.Strip(rows[0]) != "func main() {" || rows[0] == "func main() {" {
+		t.Errorf("expected the code highlighted line for line, got %q", rows)
+	}
+	if view := ansi.Strip(model.View().Content); !contains(view, "go highlighted (S for plain)") {
+		t.Errorf("expected the highlight note in the preview label, got:\n%s", view)
+	}
+
+	model = typeText(model, "S")
+	if rows := model.previewLines(item); rows[0] != "func main() {" {
+		t.Errorf("expected plain code after S, got %q", rows[0])
+	}
+	if view := ansi.Strip(model.View().Content); !contains(view, "plain (S to highlight)") {
+		t.Errorf("expected the plain note in the preview label, got:\n%s", view)
+	}
+
+	// The focused preview is plain, for finding and selecting lines
+	model = typeText(model, "S")
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	if rows := model.previewLines(item); rows[0] != "func main() {" {
+		t.Errorf("expected plain code in the focused preview, got %q", rows[0])
+	}
+
+	model.SetSyntaxHighlight(false)
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	if rows := model.previewLines(item); rows[0] != "func main() {" {
+		t.Errorf("expected plain code with highlighting off, got %q", rows[0])
+	}
+}
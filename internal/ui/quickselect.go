@@ -0,0 +1,27 @@
+package ui
+
+import tea "charm.land/bubbletea/v2"
+
+// SetQuickSelectQuit makes clippy quit after copying a row with 1-9, as
+// launcher-style pickers do.
+func (m *Model) SetQuickSelectQuit(quit bool) {
+	m.quickQuit = quit
+}
+
+// quickSelect copies the n-th row visible in the table, n being the digit
+// pressed, or in pick mode picks it. Digits past the last visible row do
+// nothing.
+func (m *Model) quickSelect(digit string) tea.Cmd {
+	rows := m.tableManager.VisibleRows()
+	n := int(digit[0] - '1')
+	if n < 0 || n >= len(rows) {
+		return nil
+	}
+	m.moveCursor(rows[n])
+	cmd := m.copySelected()
+	// copySelected already quits with SetQuitOnCopy
+	if m.quickQuit && !m.quitOnCopy && !m.pickMode {
+		return m.quitAfterCopy(cmd)
+	}
+	return cmd
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestQuickSelectCopiesVisibleRow(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for i := range 30 {
+		historyManager.AddItem(fmt.Sprintf("entry %d", i))
+	}
+	model := NewModel(historyManager)
+	clipboard := &fakeClipboard{}
+	model.SetClipboard(clipboard)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = updated.(Model)
+
+	second := model.getDisplayItems()[1].Item
+	model = typeText(model, "2")
+	if clipboard.text != second {
+		t.Errorf("2 copied %q, want the second row, %s", clipboard.text, second)
+	}
+	if got := model.tableManager.GetSelectedItem().Item; got != second {
+		t.Errorf("2 selected %q, want %s", got, second)
+	}
+
+	// Rows are counted from the top of the screen, not of the history
+	model = typeText(model, "G")
+	rows := model.tableManager.VisibleRows()
+	if len(rows) == 0 || rows[0] == 0 {
+		t.Fatalf("visible rows after G = %v, want the table scrolled", rows)
+	}
+	model = typeText(model, "1")
+	if want := model.getDisplayItems()[rows[0]].Item; clipboard.text != want {
+		t.Errorf("1 copied %q, want the first visible row, %s", clipboard.text, want)
+	}
+}
+
+func TestQuickSelectPastLastRow(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("only")
+	model := NewModel(historyManager)
+	clipboard := &fakeClipboard{}
+	model.SetClipboard(clipboard)
+	model.SetQuickSelectQuit(true)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = updated.(Model)
+
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: '9', Text: "9"})); cmd != nil || clipboard.text != "" {
+		t.Errorf("9 with one row: copied %q, cmd %v; want nothing", clipboard.text, cmd)
+	}
+}
+
+func TestQuickSelectQuit(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	model := NewModel(historyManager)
+	clipboard := &fakeClipboard{}
+	model.SetClipboard(clipboard)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = updated.(Model)
+
+	if _, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: '1', Text: "1"})); cmd != nil {
+		t.Errorf("expected 1 to keep clippy open by default, got %v", cmd)
+	}
+
+	model.SetQuickSelectQuit(true)
+	_, cmd := model.Update(tea.KeyPressMsg(tea.Key{Code: '2', Text: "2"}))
+	if want := model.getDisplayItems()[1].Item; clipboard.text != want {
+		t.Errorf("2 copied %q, want %s", clipboard.text, want)
+	}
+	if cmd == nil {
+		t.Fatal("expected clippy to quit after quick select")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected quick select to quit")
+	}
+}
+
+func TestQuickSelectPicks(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = updated.(Model)
+
+	updated, _ = model.Update(tea.KeyPressMsg(tea.Key{Code: '2', Text: "2"}))
+	want := model.getDisplayItems()[1].Item
+	if picked, ok := updated.(Model).Picked(); !ok || picked.Item != want {
+		t.Errorf("Picked = %q, %v; want %s", picked.Item, ok, want)
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// registerOp is what the next key press does with the register it names
+type registerOp int
+
+const (
+	noRegister     registerOp = iota
+	storeRegister             // store the selected item in the register
+	recallRegister            // copy the item held in the register
+)
+
+// updateRegisterKey handles the register name typed after the store or
+// recall key; any key other than a-z cancels
+func (m Model) updateRegisterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	op := m.registerOp
+	m.registerOp = noRegister
+	name := msg.String()
+	if name == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if !history.IsRegister(name) {
+		return m, nil
+	}
+
+	if op == storeRegister {
+		selected := m.selectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		for i, item := range m.historyManager.GetItems() {
+			if item.Hash == selected.Hash {
+				if err := m.historyManager.SetRegister(name, i); err != nil {
+					log.Printf("Failed to store register: %v", err)
+				}
+				m.updateTable()
+				break
+			}
+		}
+		return m, nil
+	}
+
+	item, ok := m.historyManager.FindByRegister(name)
+	if !ok {
+		return m, nil
+	}
+	if m.pickMode {
+		return m, m.pick(item)
+	}
+	return m, m.copyItem(item)
+}
+
+// registerPrompt is the help shown while waiting for a register name
+func (m Model) registerPrompt() string {
+	if m.registerOp == storeRegister {
+		return "Store in register (a-z, Esc to cancel)"
+	}
+	return "Copy from register (a-z, Esc to cancel)"
+}
+
+// registersLabel lists the registers holding item, e.g. "registers a, c"
+func registersLabel(item history.ClipboardHistory) string {
+	if item.Registers == "" {
+		return ""
+	}
+	label := "register "
+	if len(item.Registers) > 1 {
+		label = "registers "
+	}
+	return label + strings.Join(strings.Split(item.Registers, ""), ", ")
+}
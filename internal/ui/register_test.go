@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestRegisterStoreAndRecall(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("first")
+	historyManager.AddItem("second")
+	clipboard := &fakeClipboard{}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+
+	selected := model.selectedItem().Item
+	model = pressKey(model, tea.Key{Text: "\""})
+	if !contains(model.View().Content, "Store in register") {
+		t.Error("expected register prompt in help")
+	}
+	model = pressKey(model, tea.Key{Text: "q"})
+	if item, ok := historyManager.FindByRegister("q"); !ok || item.Item != selected {
+		t.Fatalf("register q = %q, %v; want %q", item.Item, ok, selected)
+	}
+	if !contains(model.View().Content, "register q") {
+		t.Error("expected the register in the preview label")
+	}
+
+	// Recalling copies the entry, wherever the cursor is
+	move := tea.KeyDown
+	if model.GetCursor() > 0 {
+		move = tea.KeyUp
+	}
+	model = pressKey(model, tea.Key{Code: move})
+	if model.selectedItem().Item == selected {
+		t.Fatal("expected the cursor to move to the other item")
+	}
+	model = pressKey(model, tea.Key{Text: "'"})
+	model = pressKey(model, tea.Key{Text: "q"})
+	if clipboard.text != selected {
+		t.Errorf("clipboard = %q, want %q", clipboard.text, selected)
+	}
+	if model.registerOp != noRegister {
+		t.Error("expected the register key to be consumed")
+	}
+
+	// Other keys cancel without acting on them
+	model = pressKey(model, tea.Key{Text: "\""})
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	model = pressKey(model, tea.Key{Text: "'"})
+	model = pressKey(model, tea.Key{Text: "d"})
+	if historyManager.Count() != 2 {
+		t.Error("expected keys after a register prompt not to run commands")
+	}
+}
+
+func TestRegisterRecallPicks(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("kept")
+	historyManager.AddItem("other")
+	if err := historyManager.SetRegister("k", 0); err != nil {
+		t.Fatalf("SetRegister: %v", err)
+	}
+	model := NewModel(historyManager)
+	model.SetPickMode(true)
+
+	model = pressKey(model, tea.Key{Text: "'"})
+	newModel, cmd := model.Update(tea.KeyPressMsg(tea.Key{Text: "k"}))
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected the picker to quit")
+	}
+	if picked, ok := model.Picked(); !ok || picked.Item != "kept" {
+		t.Errorf("picked = %q, %v; want kept", picked.Item, ok)
+	}
+}
@@ -0,0 +1,441 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// CaptureRules are the ignore rules and per-app policies edited in
+// RulesView.
+type CaptureRules struct {
+	ExcludedApps []string            // apps nothing is recorded from
+	Policies     []history.AppPolicy // limits and tags for other apps
+}
+
+// RuleStore saves the rules edited in RulesView, e.g. to the config file,
+// and applies them to capture.
+type RuleStore interface {
+	SaveRules(rules CaptureRules) error
+}
+
+// SetRuleStore enables RulesView (P), starting from rules and saving
+// changes to store.
+func (m *Model) SetRuleStore(store RuleStore, rules CaptureRules) {
+	m.ruleStore = store
+	m.captureRules = rules
+	m.keys.Rules.SetEnabled(store != nil && !m.follower)
+}
+
+// captureRule is one line of RulesView: an excluded app, or a policy
+type captureRule struct {
+	exclude bool
+	policy  history.AppPolicy // only App is used by exclude rules
+}
+
+// label describes the rule on one line, e.g. "Slack: up to 64.0 KB, only
+// url, text, tagged chat"
+func (r captureRule) label() string {
+	if r.exclude {
+		return r.policy.App + ": never recorded"
+	}
+	var limits []string
+	if r.policy.MaxBytes > 0 {
+		limits = append(limits, "up to "+history.FormatSize(r.policy.MaxBytes))
+	}
+	if len(r.policy.Types) > 0 {
+		limits = append(limits, "only "+typeNames(r.policy.Types, ", "))
+	}
+	if len(r.policy.Tags) > 0 {
+		limits = append(limits, "tagged "+strings.Join(r.policy.Tags, ", "))
+	}
+	if len(limits) == 0 {
+		limits = append(limits, "recorded as usual")
+	}
+	return r.policy.App + ": " + strings.Join(limits, ", ")
+}
+
+// ruleEditor is the state of RulesView
+type ruleEditor struct {
+	rules  []captureRule
+	cursor int
+	dirty  bool      // rules changed since opening or saving
+	warned bool      // Esc was pressed once with unsaved changes
+	form   *ruleForm // rule being edited; nil while listing
+	err    string
+}
+
+// Fields of the rule form, in tab order
+const (
+	ruleFieldKind = iota
+	ruleFieldApp
+	ruleFieldMaxBytes
+	ruleFieldTypes
+	ruleFieldTags
+	ruleFieldSample
+	ruleFieldCount
+)
+
+// ruleFieldLabels name the fields of the rule form
+var ruleFieldLabels = [ruleFieldCount]string{"Rule", "App", "Max bytes", "Types", "Tags", "Test with"}
+
+// ruleForm edits one rule, testing it against sample content as it changes
+type ruleForm struct {
+	index   int // rule edited, or -1 for a new one
+	exclude bool
+	focus   int
+	inputs  [ruleFieldCount]textinput.Model // none for ruleFieldKind
+}
+
+// newRuleForm returns a form filled in from rule
+func newRuleForm(index int, rule captureRule, sample string) *ruleForm {
+	f := &ruleForm{index: index, exclude: rule.exclude, focus: ruleFieldApp}
+	placeholders := [ruleFieldCount]string{
+		ruleFieldApp:      "e.g. Slack",
+		ruleFieldMaxBytes: "no limit",
+		ruleFieldTypes:    "any, or e.g. url, text",
+		ruleFieldTags:     "e.g. chat",
+		ruleFieldSample:   "content to test the rules with",
+	}
+	for i := ruleFieldApp; i < ruleFieldCount; i++ {
+		input := textinput.New()
+		input.Prompt = ""
+		input.Placeholder = placeholders[i]
+		input.SetWidth(40)
+		f.inputs[i] = input
+	}
+	f.inputs[ruleFieldApp].SetValue(rule.policy.App)
+	if rule.policy.MaxBytes > 0 {
+		f.inputs[ruleFieldMaxBytes].SetValue(strconv.Itoa(rule.policy.MaxBytes))
+	}
+	f.inputs[ruleFieldTypes].SetValue(typeNames(rule.policy.Types, ", "))
+	f.inputs[ruleFieldTags].SetValue(strings.Join(rule.policy.Tags, ", "))
+	f.inputs[ruleFieldSample].SetValue(sample)
+	f.inputs[ruleFieldApp].Focus()
+	return f
+}
+
+// move moves the cursor step fields, wrapping around the form and skipping
+// the fields exclude rules don't have
+func (f *ruleForm) move(step int) {
+	if f.focus != ruleFieldKind {
+		f.inputs[f.focus].Blur()
+	}
+	for {
+		f.focus = (f.focus + step + ruleFieldCount) % ruleFieldCount
+		if !f.hidden(f.focus) {
+			break
+		}
+	}
+	if f.focus != ruleFieldKind {
+		f.inputs[f.focus].Focus()
+	}
+}
+
+// hidden reports whether field is left out of the form, as exclude rules
+// have no limits or tags
+func (f *ruleForm) hidden(field int) bool {
+	return f.exclude && field > ruleFieldApp && field < ruleFieldSample
+}
+
+// rule parses the form, reporting the first problem with it
+func (f *ruleForm) rule() (captureRule, error) {
+	app := strings.TrimSpace(f.inputs[ruleFieldApp].Value())
+	if f.exclude {
+		if app == "" {
+			return captureRule{}, fmt.Errorf("no app given")
+		}
+		return captureRule{exclude: true, policy: history.AppPolicy{App: app}}, nil
+	}
+	maxBytes := 0
+	if s := strings.TrimSpace(f.inputs[ruleFieldMaxBytes].Value()); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return captureRule{}, fmt.Errorf("max bytes %q isn't a number", s)
+		}
+		maxBytes = n
+	}
+	policy, err := history.NewAppPolicy(app, maxBytes,
+		splitList(f.inputs[ruleFieldTypes].Value()), splitList(f.inputs[ruleFieldTags].Value()))
+	if err != nil {
+		// Only the first problem fits on the line
+		first, _, _ := strings.Cut(err.Error(), "\n")
+		return captureRule{}, fmt.Errorf("%s", first)
+	}
+	return captureRule{policy: policy}, nil
+}
+
+// splitList splits a comma-separated field, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// typeNames joins types with sep, e.g. "url, text"
+func typeNames(types []detect.Type, sep string) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, sep)
+}
+
+// ruleVerdict describes what recording sample, copied from app, does under
+// rules: excluded apps first, then the first policy for the app.
+func ruleVerdict(rules []captureRule, app, sample string) string {
+	app = strings.TrimSpace(app)
+	for _, r := range rules {
+		if r.exclude && strings.EqualFold(r.policy.App, app) {
+			return fmt.Sprintf("✗ not recorded: %s is excluded", app)
+		}
+	}
+	t := detect.Detect(sample)
+	for _, r := range rules {
+		if r.exclude || !strings.EqualFold(r.policy.App, app) {
+			continue
+		}
+		if reason := r.policy.Refuses(len(sample), t); reason != "" {
+			return "✗ not recorded: " + reason
+		}
+		if len(r.policy.Tags) > 0 {
+			return fmt.Sprintf("✓ recorded as %s, tagged %s", t, strings.Join(r.policy.Tags, ", "))
+		}
+		break
+	}
+	return fmt.Sprintf("✓ recorded as %s", t)
+}
+
+// openRules switches to RulesView, listing the excluded apps and then the
+// policies
+func (m *Model) openRules() {
+	editor := &ruleEditor{}
+	for _, app := range m.captureRules.ExcludedApps {
+		editor.rules = append(editor.rules, captureRule{exclude: true, policy: history.AppPolicy{App: app}})
+	}
+	for _, policy := range m.captureRules.Policies {
+		editor.rules = append(editor.rules, captureRule{policy: policy})
+	}
+	m.rules = editor
+	m.mode = RulesView
+}
+
+// closeRules returns to the table, discarding unsaved changes
+func (m *Model) closeRules() {
+	m.rules = nil
+	m.mode = TableView
+}
+
+// editRule opens the form on rules[index], or on a new rule for the
+// selected entry's app when index is -1. The selected entry is the sample
+// to test with, unless its content is masked or sensitive.
+func (m *Model) editRule(index int) {
+	var rule captureRule
+	sample := ""
+	if selected := m.selectedItem(); selected != nil {
+		if !selected.IsBinary() && !selected.Overflow && selected.Sensitive == "" && !m.hidden(selected.Hash) {
+			sample, _, _ = strings.Cut(selected.Item, "\n")
+		}
+		rule.policy.App = selected.SourceApp
+	}
+	if index >= 0 {
+		rule = m.rules.rules[index]
+	}
+	m.rules.form = newRuleForm(index, rule, sample)
+	m.rules.err = ""
+}
+
+// saveRules saves the rules to the store, which applies them to capture
+func (m *Model) saveRules() {
+	var rules CaptureRules
+	for _, r := range m.rules.rules {
+		if r.exclude {
+			rules.ExcludedApps = append(rules.ExcludedApps, r.policy.App)
+		} else {
+			rules.Policies = append(rules.Policies, r.policy)
+		}
+	}
+	if err := m.ruleStore.SaveRules(rules); err != nil {
+		m.rules.err = err.Error()
+		return
+	}
+	m.captureRules = rules
+	m.rules.dirty, m.rules.warned, m.rules.err = false, false, ""
+	m.notice = fmt.Sprintf("saved %s", plural(len(m.rules.rules), "rule"))
+}
+
+// updateRules handles key presses in RulesView
+func (m Model) updateRules(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if m.rules.form != nil {
+		return m.updateRuleForm(msg)
+	}
+	editor := m.rules
+	if !key.Matches(msg, m.keys.RuleClose) {
+		editor.warned = false
+	}
+	switch {
+	case key.Matches(msg, m.keys.RuleClose):
+		if editor.dirty && !editor.warned {
+			editor.warned = true
+			return m, nil
+		}
+		m.closeRules()
+	case key.Matches(msg, m.keys.PreviewDown):
+		editor.cursor = min(editor.cursor+1, max(len(editor.rules)-1, 0))
+	case key.Matches(msg, m.keys.PreviewUp):
+		editor.cursor = max(editor.cursor-1, 0)
+	case key.Matches(msg, m.keys.RuleNew):
+		m.editRule(-1)
+	case key.Matches(msg, m.keys.RuleEdit):
+		if len(editor.rules) > 0 {
+			m.editRule(editor.cursor)
+		}
+	case key.Matches(msg, m.keys.RuleDelete):
+		if len(editor.rules) > 0 {
+			editor.rules = append(editor.rules[:editor.cursor], editor.rules[editor.cursor+1:]...)
+			editor.cursor = min(editor.cursor, max(len(editor.rules)-1, 0))
+			editor.dirty = true
+		}
+	case key.Matches(msg, m.keys.RuleSave):
+		m.saveRules()
+	}
+	return m, nil
+}
+
+// updateRuleForm handles key presses while a rule is being edited
+func (m Model) updateRuleForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	editor, form := m.rules, m.rules.form
+	switch {
+	case key.Matches(msg, m.keys.FormCancel):
+		editor.form, editor.err = nil, ""
+		return m, nil
+	case key.Matches(msg, m.keys.FormNext):
+		form.move(1)
+		return m, nil
+	case key.Matches(msg, m.keys.FormPrev):
+		form.move(-1)
+		return m, nil
+	case key.Matches(msg, m.keys.FormApply):
+		rule, err := form.rule()
+		if err != nil {
+			editor.err = err.Error()
+			return m, nil
+		}
+		if form.index < 0 {
+			editor.rules = append(editor.rules, rule)
+			editor.cursor = len(editor.rules) - 1
+		} else {
+			editor.rules[form.index] = rule
+		}
+		editor.form, editor.err, editor.dirty = nil, "", true
+		return m, nil
+	}
+	if form.focus == ruleFieldKind {
+		// The rule field switches between a policy and an exclude rule
+		if key.Matches(msg, m.keys.RuleKind) {
+			form.exclude = !form.exclude
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	form.inputs[form.focus], cmd = form.inputs[form.focus].Update(msg)
+	if _, err := form.rule(); err != nil {
+		editor.err = err.Error()
+	} else {
+		editor.err = ""
+	}
+	return m, cmd
+}
+
+// formRules returns the rules as they would be with the form applied, for
+// testing the sample against
+func (e *ruleEditor) formRules() []captureRule {
+	rule, err := e.form.rule()
+	if err != nil {
+		return e.rules
+	}
+	rules := append([]captureRule(nil), e.rules...)
+	if e.form.index < 0 {
+		return append(rules, rule)
+	}
+	rules[e.form.index] = rule
+	return rules
+}
+
+// rulesView renders the rule list, or the form when a rule is being edited
+func (m Model) rulesView() string {
+	editor := m.rules
+	if editor.form != nil {
+		return m.ruleFormView()
+	}
+	var lines strings.Builder
+	if len(editor.rules) == 0 {
+		lines.WriteString("  No rules yet; everything is recorded.\n")
+	}
+	for i, rule := range editor.rules {
+		cursor := "  "
+		if i == editor.cursor {
+			cursor = "> "
+		}
+		lines.WriteString(cursor + rule.label() + "\n")
+	}
+	hint := m.theme.Help.Render(renderHelp(m.keys.rulesHelp(), m.helpWidth()))
+	switch {
+	case editor.err != "":
+		hint = m.theme.Help.Render("⚠ "+editor.err) + "\n" + hint
+	case editor.warned:
+		hint = m.theme.Help.Render(fmt.Sprintf("Unsaved changes: press %s to save, Esc again to discard", m.keys.RuleSave.Help().Key)) + "\n" + hint
+	case editor.dirty:
+		hint = m.theme.Help.Render("Unsaved changes") + "\n" + hint
+	}
+	return m.theme.Search.Render(fmt.Sprintf("🛡  Capture rules:\n\n%s\n%s", lines.String(), hint))
+}
+
+// ruleFormView renders the rule form with the verdict on its sample
+func (m Model) ruleFormView() string {
+	form := m.rules.form
+	var lines strings.Builder
+	for field := range ruleFieldCount {
+		if form.hidden(field) {
+			continue
+		}
+		cursor := "  "
+		if field == form.focus {
+			cursor = "> "
+		}
+		value := ""
+		if field == ruleFieldKind {
+			value = "record with limits"
+			if form.exclude {
+				value = "never record"
+			}
+			value = "◂ " + value + " ▸"
+		} else {
+			value = form.inputs[field].View()
+		}
+		fmt.Fprintf(&lines, "%s%-10s %s\n", cursor, ruleFieldLabels[field], value)
+	}
+	verdict := ruleVerdict(m.rules.formRules(), form.inputs[ruleFieldApp].Value(), form.inputs[ruleFieldSample].Value())
+	if m.rules.err != "" {
+		verdict = "⚠ " + m.rules.err
+	}
+	title := "New rule"
+	if form.index >= 0 {
+		title = "Edit rule"
+	}
+	hint := m.theme.Help.Render(renderHelp(m.keys.ruleFormHelp(), m.helpWidth()))
+	return m.theme.Search.Render(fmt.Sprintf("🛡  %s:\n\n%s\n%s\n\n%s", title, lines.String(), verdict, hint))
+}
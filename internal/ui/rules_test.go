@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fakeRuleStore records the rules saved to it
+type fakeRuleStore struct {
+	saved []CaptureRules
+	err   error
+}
+
+func (s *fakeRuleStore) SaveRules(rules CaptureRules) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = append(s.saved, rules)
+	return nil
+}
+
+func TestRulesViewAddsPolicy(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.SetSourceApp(func() string { return "Slack" })
+	historyManager.AddItem("https://example.com")
+	model := NewModel(historyManager)
+	store := &fakeRuleStore{}
+	model.SetRuleStore(store, CaptureRules{ExcludedApps: []string{"KeePassXC"}})
+
+	model = typeText(model, "P")
+	if model.mode != RulesView {
+		t.Fatalf("mode = %v, want RulesView", model.mode)
+	}
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, "KeePassXC: never recorded") {
+		t.Errorf("expected the excluded app listed, got:\n%s", view)
+	}
+
+	// A new rule starts from the selected entry's app and content
+	model = typeText(model, "n")
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, "Slack") || !strings.Contains(view, "✓ recorded as url") {
+		t.Errorf("expected the selected entry to prefill the form, got:\n%s", view)
+	}
+
+	// The verdict follows the form as it is typed
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	model = typeText(model, "5")
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, "✗ not recorded: 19 B is over the 5 B limit") {
+		t.Errorf("expected the size limit to refuse the sample, got:\n%s", view)
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	model = typeText(model, "jsn")
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, `unknown type "jsn"`) {
+		t.Errorf("expected the unknown type reported, got:\n%s", view)
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.rules.form == nil {
+		t.Fatal("expected an invalid rule to keep the form open")
+	}
+	for range 3 {
+		model = pressKey(model, tea.Key{Code: tea.KeyBackspace})
+	}
+	model = typeText(model, "url")
+	model = pressKey(model, tea.Key{Code: tea.KeyTab})
+	model = typeText(model, "chat")
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, "✓ recorded as url, tagged chat") {
+		t.Errorf("expected the sample to be tagged, got:\n%s", view)
+	}
+
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	if model.rules.form != nil || !model.rules.dirty {
+		t.Fatal("expected Enter to add the rule to the list")
+	}
+	model = typeText(model, "w")
+	if len(store.saved) != 1 {
+		t.Fatalf("saved %d times, want 1", len(store.saved))
+	}
+	saved := store.saved[0]
+	want := history.AppPolicy{App: "Slack", Types: []detect.Type{detect.URL}, Tags: []string{"chat"}}
+	if !slices.Equal(saved.ExcludedApps, []string{"KeePassXC"}) || len(saved.Policies) != 1 ||
+		saved.Policies[0].App != want.App || !slices.Equal(saved.Policies[0].Types, want.Types) || !slices.Equal(saved.Policies[0].Tags, want.Tags) {
+		t.Errorf("saved %+v", saved)
+	}
+
+	// Saved rules are where the view starts next time
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.mode != TableView {
+		t.Fatal("expected Esc to close the saved rules")
+	}
+	model = typeText(model, "P")
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, "Slack: only url, tagged chat") {
+		t.Errorf("expected the saved policy listed, got:\n%s", view)
+	}
+}
+
+func TestRuleFormLeavesMaskedSampleOut(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.SetSourceApp(func() string { return "Slack" })
+	historyManager.AddItem("https://example.com/private")
+	model := NewModel(historyManager)
+	model.SetRuleStore(&fakeRuleStore{}, CaptureRules{})
+	model.SetMasked(true)
+
+	model = typeText(model, "P")
+	model = typeText(model, "n")
+	if model.rules.form == nil {
+		t.Fatal("expected the rule form to open")
+	}
+	if view := ansi.Strip(model.View().Content); strings.Contains(view, "example.com") || !strings.Contains(view, "Slack") {
+		t.Errorf("expected only the app prefilled while masked, got:\n%s", view)
+	}
+}
+
+func TestRulesViewExcludeAndDiscard(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.SetSourceApp(func() string { return "Bitwarden" })
+	historyManager.AddItem("hunter2")
+	model := NewModel(historyManager)
+	store := &fakeRuleStore{}
+	model.SetRuleStore(store, CaptureRules{})
+
+	model = typeText(model, "Pn")
+	model = pressKey(model, tea.Key{Code: tea.KeyTab, Mod: tea.ModShift})
+	model = pressKey(model, tea.Key{Code: tea.KeyRight})
+	view := ansi.Strip(model.View().Content)
+	if !strings.Contains(view, "never record") || !strings.Contains(view, "✗ not recorded: Bitwarden is excluded") {
+		t.Errorf("expected an exclude rule, got:\n%s", view)
+	}
+	if strings.Contains(view, "Max bytes") {
+		t.Error("expected exclude rules to have no limits")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+
+	// Closing with unsaved changes asks first, then discards them
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.mode != RulesView || !strings.Contains(ansi.Strip(model.View().Content), "Unsaved changes") {
+		t.Fatal("expected a warning before discarding changes")
+	}
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.mode != TableView || len(store.saved) != 0 {
+		t.Fatalf("mode = %v, saved = %v", model.mode, store.saved)
+	}
+	if model = typeText(model, "P"); !strings.Contains(ansi.Strip(model.View().Content), "No rules yet") {
+		t.Error("expected the discarded rule to be gone")
+	}
+}
+
+func TestRulesViewSaveError(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model.SetRuleStore(&fakeRuleStore{err: errors.New("read-only config")}, CaptureRules{ExcludedApps: []string{"KeePassXC"}})
+	model = typeText(model, "Pdw")
+	if view := ansi.Strip(model.View().Content); !strings.Contains(view, "read-only config") {
+		t.Errorf("expected the save error shown, got:\n%s", view)
+	}
+}
+
+func TestRulesKeyNeedsStore(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	if model = typeText(model, "P"); model.mode != TableView {
+		t.Error("expected P to do nothing without a rule store")
+	}
+	model.SetFollower(true)
+	model.SetRuleStore(&fakeRuleStore{}, CaptureRules{})
+	if model = typeText(model, "P"); model.mode != TableView {
+		t.Error("expected followers not to edit rules")
+	}
+}
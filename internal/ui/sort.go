@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bvdwalt/clippy/internal/history"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// getenv reads the locale settings; overridable for tests.
+var getenv = os.Getenv
+
+// locale returns the language used to sort entries alphabetically, from
+// LC_ALL, LC_COLLATE or LANG as POSIX has them take effect, or und for
+// the C locale
+func locale() language.Tag {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		// e.g. de_DE.UTF-8@euro
+		value, _, _ = strings.Cut(value, ".")
+		value, _, _ = strings.Cut(value, "@")
+		if value == "C" || value == "POSIX" {
+			return language.Und
+		}
+		tag, err := language.Parse(strings.ReplaceAll(value, "_", "-"))
+		if err != nil {
+			return language.Und
+		}
+		return tag
+	}
+	return language.Und
+}
+
+// sortAlphabetically returns items ordered by content, using the user's
+// locale so accented and non-Latin entries land where a reader expects
+// them rather than after "z". Pinned items stay on top in their order.
+func sortAlphabetically(items []history.ClipboardHistory) []history.ClipboardHistory {
+	collator := collate.New(locale())
+	var buf collate.Buffer
+	keys := make(map[string][]byte, len(items))
+	for _, item := range items {
+		if _, ok := keys[item.Hash]; !ok {
+			keys[item.Hash] = slices.Clone(collator.KeyFromString(&buf, item.Item))
+			buf.Reset()
+		}
+	}
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b history.ClipboardHistory) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		if a.Pinned {
+			return 0
+		}
+		return strings.Compare(string(keys[a.Hash]), string(keys[b.Hash]))
+	})
+	return sorted
+}
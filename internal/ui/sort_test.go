@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+	"golang.org/x/text/language"
+)
+
+// useLocale sets the locale environment read by locale
+func useLocale(t *testing.T, env map[string]string) {
+	t.Helper()
+	orig := getenv
+	t.Cleanup(func() { getenv = orig })
+	getenv = func(key string) string { return env[key] }
+}
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want language.Tag
+	}{
+		{map[string]string{"LANG": "de_DE.UTF-8"}, language.MustParse("de-DE")},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_COLLATE": "sv_SE.UTF-8"}, language.MustParse("sv-SE")},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_ALL": "C"}, language.Und},
+		{map[string]string{"LANG": "fr_FR@euro"}, language.MustParse("fr-FR")},
+		{nil, language.Und},
+	}
+	for _, tt := range tests {
+		useLocale(t, tt.env)
+		if got := locale(); got != tt.want {
+			t.Errorf("locale() with %v = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestSortToggleOrdersEntriesByLocale(t *testing.T) {
+	useLocale(t, map[string]string{"LANG": "de_DE.UTF-8"})
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	for _, text := range []string{"banana", "Äpfel", "zebra", "éclair", "apple"} {
+		historyManager.AddItem(text)
+	}
+	model := NewModel(historyManager)
+
+	model = pressKey(model, tea.Key{Code: 'o', Text: "o"})
+	if !contains(model.View().Content, "sorted A-Z") {
+		t.Error("expected the status line to show the sort")
+	}
+	var got []string
+	for _, item := range model.getDisplayItems() {
+		got = append(got, item.Item)
+	}
+	if want := []string{"Äpfel", "apple", "banana", "éclair", "zebra"}; !slices.Equal(got, want) {
+		t.Errorf("sorted = %q, want %q", got, want)
+	}
+
+	model = pressKey(model, tea.Key{Code: 'o', Text: "o"})
+	if items := model.getDisplayItems(); items[0].Hash != historyManager.GetItems()[0].Hash {
+		t.Error("expected the history order back")
+	}
+}
+
+func TestSortAlphabeticallyKeepsPinnedFirst(t *testing.T) {
+	items := []history.ClipboardHistory{
+		{Item: "b", Hash: "b"},
+		{Item: "z", Hash: "z", Pinned: true},
+		{Item: "a", Hash: "a"},
+		{Item: "y", Hash: "y", Pinned: true},
+	}
+	var got []string
+	for _, item := range sortAlphabetically(items) {
+		got = append(got, item.Item)
+	}
+	if want := []string{"z", "y", "a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("sorted = %q, want %q", got, want)
+	}
+}
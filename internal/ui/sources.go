@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/sources"
+)
+
+// sourceCaptureMsg carries text read by a source besides the clipboard
+type sourceCaptureMsg sources.Capture
+
+// SetSourceCaptures records the text arriving on captures, read by sources
+// such as the primary selection in their own goroutines, labelling each
+// entry with its source.
+func (m *Model) SetSourceCaptures(captures <-chan sources.Capture) {
+	m.captures = captures
+}
+
+// SetPollInterval reads the clipboard on the first tick after each
+// interval rather than on every tick, when it isn't watched for changes.
+func (m *Model) SetPollInterval(interval time.Duration) {
+	m.pollInterval = max(interval, 0)
+}
+
+// waitForCapture returns a command that waits for the next capture from
+// captures
+func waitForCapture(captures <-chan sources.Capture) tea.Cmd {
+	return func() tea.Msg {
+		c, ok := <-captures
+		if !ok {
+			return nil
+		}
+		return sourceCaptureMsg(c)
+	}
+}
+
+// pollClipboard captures the clipboard once the poll interval has passed
+// since it was last read
+func (m *Model) pollClipboard(now time.Time) {
+	if now.Sub(m.lastPoll) < m.pollInterval {
+		return
+	}
+	m.lastPoll = now
+	m.captureClipboard(now)
+}
+
+// captureSource records text read by a source, labelled with the source
+func (m *Model) captureSource(c sources.Capture) {
+	selection := history.Selection(c.Source)
+	if selection == history.SelectionPrimary {
+		// Text placed in the primary selection from history isn't new
+		if c.Text == m.lastPrimary {
+			return
+		}
+		m.lastPrimary = c.Text
+	}
+	m.historyManager.AddItemFrom(c.Text, selection)
+	m.updateTable()
+}
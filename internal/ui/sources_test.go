@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/sources"
+)
+
+func TestSourceCapturesAreLabelled(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	captures := make(chan sources.Capture, 1)
+	model := NewModel(historyManager)
+	model.SetSourceCaptures(captures)
+	model.height, model.width = 30, 120
+	model.previewHeight = 3
+
+	newModel, cmd := model.Update(sourceCaptureMsg{Source: "secondary", Text: "yanked"})
+	model = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected to keep waiting for captures")
+	}
+	items := historyManager.GetItems()
+	if len(items) != 1 || items[0].Item != "yanked" || items[0].Selection != "secondary" {
+		t.Fatalf("items = %+v, want the capture labelled with its source", items)
+	}
+	if !contains(model.View().Content, "captured from secondary") {
+		t.Error("expected the preview to name the source")
+	}
+
+	captures <- sources.Capture{Source: "primary", Text: "highlighted"}
+	if msg := cmd(); msg != (sourceCaptureMsg{Source: "primary", Text: "highlighted"}) {
+		t.Errorf("msg = %#v, want the next capture", msg)
+	}
+	close(captures)
+	if msg := cmd(); msg != nil {
+		t.Errorf("msg = %#v after the sources stopped, want nil", msg)
+	}
+}
+
+func TestSourceCaptureSkipsOwnPrimaryWrite(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	model := NewModel(historyManager)
+	model.lastPrimary = "placed from history"
+	newModel, _ := model.Update(sourceCaptureMsg{Source: string(history.SelectionPrimary), Text: "placed from history"})
+	model = newModel.(Model)
+	if historyManager.Count() != 0 {
+		t.Error("expected text clippy put in the primary selection not recorded again")
+	}
+	model.Update(sourceCaptureMsg{Source: string(history.SelectionPrimary), Text: "highlighted"})
+	if item, ok := historyManager.Latest(); !ok || !item.FromPrimary() {
+		t.Errorf("latest = %+v, want the highlighted text from the primary selection", item)
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	clipboard := &fakeClipboard{text: "first"}
+	model := NewModel(historyManager)
+	model.SetClipboard(clipboard)
+	model.SetPollInterval(2 * time.Second)
+
+	start := time.Now()
+	newModel, _ := model.Update(TickMsg(start))
+	model = newModel.(Model)
+	clipboard.text = "second"
+	newModel, _ = model.Update(TickMsg(start.Add(500 * time.Millisecond)))
+	model = newModel.(Model)
+	if historyManager.Count() != 1 {
+		t.Fatalf("Count = %d, want the clipboard left until the interval passes", historyManager.Count())
+	}
+	model.Update(TickMsg(start.Add(2 * time.Second)))
+	if historyManager.Count() != 2 {
+		t.Errorf("Count = %d, want the clipboard read again after the interval", historyManager.Count())
+	}
+}
@@ -0,0 +1,223 @@
+package styles
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/BurntSushi/toml"
+)
+
+// ThemeFileName is the theme file LoadTheme reads, kept in the config
+// directory beside config.toml.
+const ThemeFileName = "theme.toml"
+
+// themeFile is the layout of a theme file: a table per Theme style and
+// [table] for the TableTheme. Each only overrides what it sets.
+type themeFile struct {
+	Doc                 styleSpec `toml:"doc"`
+	Title               styleSpec `toml:"title"`
+	Help                styleSpec `toml:"help"`
+	Search              styleSpec `toml:"search"`
+	Preview             styleSpec `toml:"preview"`
+	PreviewFocused      styleSpec `toml:"preview_focused"`
+	PreviewSelection    styleSpec `toml:"preview_selection"`
+	PreviewMatch        styleSpec `toml:"preview_match"`
+	PreviewCurrentMatch styleSpec `toml:"preview_current_match"`
+	Warning             styleSpec `toml:"warning"`
+	HelpTitle           styleSpec `toml:"help_title"`
+	HelpKey             styleSpec `toml:"help_key"`
+	Table               tableSpec `toml:"table"`
+}
+
+// styleSpec overrides attributes of a style. Colors are ANSI colors 0-255
+// or hex, e.g. "#ff5f87"; paddings and margins take 1 to 4 sides, like
+// lipgloss.
+type styleSpec struct {
+	Foreground       string `toml:"foreground"`
+	Background       string `toml:"background"`
+	Bold             *bool  `toml:"bold"`
+	Italic           *bool  `toml:"italic"`
+	Underline        *bool  `toml:"underline"`
+	Border           string `toml:"border"`
+	BorderForeground string `toml:"border_foreground"`
+	Padding          []int  `toml:"padding"`
+	Margin           []int  `toml:"margin"`
+	Width            *int   `toml:"width"`
+}
+
+// tableSpec overrides colors of a TableTheme
+type tableSpec struct {
+	HeaderBorder       string `toml:"header_border"`
+	SelectedForeground string `toml:"selected_foreground"`
+	SelectedBackground string `toml:"selected_background"`
+	StripeBackground   string `toml:"stripe_background"`
+	DimForeground      string `toml:"dim_foreground"`
+}
+
+// BorderNames lists the borders a theme file may give a style.
+var BorderNames = []string{"normal", "rounded", "thick", "double", "block", "ascii", "hidden", "none"}
+
+// borders maps BorderNames to their lipgloss borders; "none" has none
+var borders = map[string]lipgloss.Border{
+	"normal":  lipgloss.NormalBorder(),
+	"rounded": lipgloss.RoundedBorder(),
+	"thick":   lipgloss.ThickBorder(),
+	"double":  lipgloss.DoubleBorder(),
+	"block":   lipgloss.BlockBorder(),
+	"ascii":   lipgloss.ASCIIBorder(),
+	"hidden":  lipgloss.HiddenBorder(),
+}
+
+// LoadTheme reads the theme file at path over DefaultTheme and
+// DefaultTableTheme, returning them unchanged if it does not exist. A file
+// that can't be used, e.g. with an unknown key or an invalid color, is an
+// error, also returning the defaults.
+func LoadTheme(path string) (Theme, TableTheme, error) {
+	var file themeFile
+	md, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultTheme(), DefaultTableTheme(), nil
+		}
+		return DefaultTheme(), DefaultTableTheme(), fmt.Errorf("error reading theme %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return DefaultTheme(), DefaultTableTheme(), fmt.Errorf("error in theme %s: unknown key %s", path, undecoded[0])
+	}
+
+	theme, tableTheme := DefaultTheme(), DefaultTableTheme()
+	for _, s := range []struct {
+		name  string
+		spec  styleSpec
+		style *lipgloss.Style
+	}{
+		{"doc", file.Doc, &theme.Doc},
+		{"title", file.Title, &theme.Title},
+		{"help", file.Help, &theme.Help},
+		{"search", file.Search, &theme.Search},
+		{"preview", file.Preview, &theme.Preview},
+		{"preview_focused", file.PreviewFocused, &theme.PreviewFocused},
+		{"preview_selection", file.PreviewSelection, &theme.PreviewSelection},
+		{"preview_match", file.PreviewMatch, &theme.PreviewMatch},
+		{"preview_current_match", file.PreviewCurrentMatch, &theme.PreviewCurrentMatch},
+		{"warning", file.Warning, &theme.Warning},
+		{"help_title", file.HelpTitle, &theme.HelpTitle},
+		{"help_key", file.HelpKey, &theme.HelpKey},
+	} {
+		style, err := s.spec.apply(*s.style)
+		if err != nil {
+			return DefaultTheme(), DefaultTableTheme(), fmt.Errorf("error in theme %s: [%s] %w", path, s.name, err)
+		}
+		*s.style = style
+	}
+	for _, c := range []struct {
+		key, value string
+		field      *string
+	}{
+		{"header_border", file.Table.HeaderBorder, &tableTheme.HeaderBorderColor},
+		{"selected_foreground", file.Table.SelectedForeground, &tableTheme.SelectedFg},
+		{"selected_background", file.Table.SelectedBackground, &tableTheme.SelectedBg},
+		{"stripe_background", file.Table.StripeBackground, &tableTheme.StripeBg},
+		{"dim_foreground", file.Table.DimForeground, &tableTheme.DimFg},
+	} {
+		if c.value == "" {
+			continue
+		}
+		if !validColor(c.value) {
+			return DefaultTheme(), DefaultTableTheme(), fmt.Errorf("error in theme %s: [table] %s: %s", path, c.key, colorError(c.value))
+		}
+		*c.field = c.value
+	}
+	return theme, tableTheme, nil
+}
+
+// apply returns style with the attributes s sets replaced
+func (s styleSpec) apply(style lipgloss.Style) (lipgloss.Style, error) {
+	for _, c := range []struct{ key, value string }{
+		{"foreground", s.Foreground}, {"background", s.Background}, {"border_foreground", s.BorderForeground},
+	} {
+		if c.value != "" && !validColor(c.value) {
+			return style, fmt.Errorf("%s: %s", c.key, colorError(c.value))
+		}
+	}
+	if s.Foreground != "" {
+		style = style.Foreground(lipgloss.Color(s.Foreground))
+	}
+	if s.Background != "" {
+		style = style.Background(lipgloss.Color(s.Background))
+	}
+	if s.Bold != nil {
+		style = style.Bold(*s.Bold)
+	}
+	if s.Italic != nil {
+		style = style.Italic(*s.Italic)
+	}
+	if s.Underline != nil {
+		style = style.Underline(*s.Underline)
+	}
+
+	switch border, ok := borders[s.Border]; {
+	case s.Border == "":
+	case s.Border == "none":
+		style = style.UnsetBorderStyle().BorderTop(false).BorderRight(false).BorderBottom(false).BorderLeft(false)
+	case !ok:
+		return style, fmt.Errorf("border: unknown border %q (want one of %s)", s.Border, strings.Join(BorderNames, ", "))
+	default:
+		style = style.Border(border)
+	}
+	if s.BorderForeground != "" {
+		style = style.BorderForeground(lipgloss.Color(s.BorderForeground))
+	}
+
+	if s.Padding != nil {
+		if err := checkSides(s.Padding); err != nil {
+			return style, fmt.Errorf("padding: %w", err)
+		}
+		style = style.Padding(s.Padding...)
+	}
+	if s.Margin != nil {
+		if err := checkSides(s.Margin); err != nil {
+			return style, fmt.Errorf("margin: %w", err)
+		}
+		style = style.Margin(s.Margin...)
+	}
+	if s.Width != nil {
+		if *s.Width < 0 {
+			return style, fmt.Errorf("width: %d is negative", *s.Width)
+		}
+		style = style.Width(*s.Width)
+	}
+	return style, nil
+}
+
+// validColor reports whether s is an ANSI color 0-255 or a hex color
+func validColor(s string) bool {
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil && (len(hex) == 3 || len(hex) == 6)
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// colorError describes why s isn't a color
+func colorError(s string) string {
+	return fmt.Sprintf("invalid color %q (want 0-255 or hex, e.g. #ff5f87)", s)
+}
+
+// checkSides checks the sides of a padding or margin
+func checkSides(sides []int) error {
+	if len(sides) == 0 || len(sides) > 4 {
+		return fmt.Errorf("%d values given (want 1 to 4)", len(sides))
+	}
+	for _, n := range sides {
+		if n < 0 {
+			return fmt.Errorf("%d is negative", n)
+		}
+	}
+	return nil
+}
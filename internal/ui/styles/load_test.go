@@ -0,0 +1,127 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTheme writes content to a theme file in a temporary directory
+func writeTheme(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ThemeFileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadThemeMissingFileUsesDefaults(t *testing.T) {
+	theme, tableTheme, err := LoadTheme(filepath.Join(t.TempDir(), ThemeFileName))
+	if err != nil {
+		t.Fatalf("LoadTheme: %v", err)
+	}
+	if tableTheme != DefaultTableTheme() {
+		t.Errorf("table theme = %+v, want the default", tableTheme)
+	}
+	if theme.Search.Render("x") != DefaultTheme().Search.Render("x") {
+		t.Error("expected the default theme")
+	}
+}
+
+func TestLoadThemeOverridesOnlyWhatIsSet(t *testing.T) {
+	path := writeTheme(t, `
+[doc]
+margin = [0, 1]
+
+[title]
+foreground = "#ff5f87"
+bold = false
+
+[preview]
+border = "double"
+padding = [1, 2]
+
+[search]
+border = "none"
+width = 30
+
+[table]
+selected_background = "24"
+stripe_background = "235"
+`)
+	theme, tableTheme, err := LoadTheme(path)
+	if err != nil {
+		t.Fatalf("LoadTheme: %v", err)
+	}
+	defaults := DefaultTheme()
+
+	if got := theme.Doc.GetHorizontalFrameSize(); got != 2 {
+		t.Errorf("doc horizontal frame = %d, want 2", got)
+	}
+	if theme.Title.GetBold() {
+		t.Error("expected title bold turned off")
+	}
+	if theme.Title.GetPaddingRight() != defaults.Title.GetPaddingRight() {
+		t.Error("expected title padding kept from the default")
+	}
+	if got := theme.Preview.GetBorderStyle().Top; got != "═" {
+		t.Errorf("preview border top = %q, want a double border", got)
+	}
+	if got := theme.Preview.GetHorizontalFrameSize(); got != 6 {
+		t.Errorf("preview horizontal frame = %d, want border 2 + padding 4", got)
+	}
+	if got := theme.Search.GetHorizontalBorderSize(); got != 0 {
+		t.Errorf("search border size = %d, want none", got)
+	}
+	if theme.Search.GetWidth() != 30 {
+		t.Errorf("search width = %d, want 30", theme.Search.GetWidth())
+	}
+	if theme.Help.Render("x") != defaults.Help.Render("x") {
+		t.Error("expected help style left at the default")
+	}
+
+	want := DefaultTableTheme()
+	want.SelectedBg, want.StripeBg = "24", "235"
+	if tableTheme != want {
+		t.Errorf("table theme = %+v, want %+v", tableTheme, want)
+	}
+}
+
+func TestLoadThemeRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"unknown key", "[title]\ncolour = \"205\"\n", "unknown key title.colour"},
+		{"invalid color", "[help]\nforeground = \"pink\"\n", "[help] foreground: invalid color \"pink\""},
+		{"out of range color", "[table]\ndim_foreground = \"300\"\n", "[table] dim_foreground: invalid color"},
+		{"unknown border", "[preview]\nborder = \"wavy\"\n", "unknown border \"wavy\""},
+		{"too many sides", "[search]\npadding = [1, 1, 1, 1, 1]\n", "padding: 5 values given"},
+		{"negative margin", "[doc]\nmargin = [-1]\n", "margin: -1 is negative"},
+		{"not toml", "[doc\n", "error reading theme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tableTheme, err := LoadTheme(writeTheme(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+			if tableTheme != DefaultTableTheme() {
+				t.Errorf("table theme = %+v, want the default after an error", tableTheme)
+			}
+		})
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for color, want := range map[string]bool{
+		"0": true, "255": true, "256": false, "-1": false,
+		"#fff": true, "#ff5f87": true, "#ff5f8": false, "#ggg": false,
+		"red": false, "": false,
+	} {
+		if got := validColor(color); got != want {
+			t.Errorf("validColor(%q) = %v, want %v", color, got, want)
+		}
+	}
+}
@@ -11,6 +11,20 @@ type Theme struct {
 	Help    lipgloss.Style
 	Search  lipgloss.Style
 	Preview lipgloss.Style
+	// PreviewFocused replaces Preview while the preview has focus.
+	PreviewFocused lipgloss.Style
+	// PreviewSelection highlights lines selected in the preview.
+	PreviewSelection lipgloss.Style
+	// PreviewMatch and PreviewCurrentMatch highlight find results in the
+	// preview.
+	PreviewMatch        lipgloss.Style
+	PreviewCurrentMatch lipgloss.Style
+	// Warning is the banner shown while history isn't being saved.
+	Warning lipgloss.Style
+	// HelpTitle and HelpKey style the section titles and keys of the
+	// help overlay.
+	HelpTitle lipgloss.Style
+	HelpKey   lipgloss.Style
 }
 
 func DefaultTheme() Theme {
@@ -36,6 +50,37 @@ func DefaultTheme() Theme {
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("240")).
 			Padding(0, 1),
+
+		PreviewFocused: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62")).
+			Padding(0, 1),
+
+		PreviewSelection: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("57")),
+
+		PreviewMatch: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("178")),
+
+		PreviewCurrentMatch: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("205")).
+			Bold(true),
+
+		Warning: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("231")).
+			Background(lipgloss.Color("160")).
+			Bold(true).
+			Padding(0, 1),
+
+		HelpTitle: lipgloss.NewStyle().
+			Bold(true).
+			Underline(true),
+
+		HelpKey: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("205")),
 	}
 }
 
@@ -43,13 +88,40 @@ type TableTheme struct {
 	HeaderBorderColor string
 	SelectedFg        string
 	SelectedBg        string
+	// StripeBg is the background of every other row; empty disables
+	// zebra striping.
+	StripeBg string
+	// DimFg is the foreground of the whole table while focus is elsewhere,
+	// e.g. in the search input or the preview.
+	DimFg string
+	// CursorIndicator marks the selected row in a way that doesn't rely on
+	// color: CursorBar or CursorReverse. Empty uses color alone.
+	CursorIndicator string
 }
 
+// Values of TableTheme.CursorIndicator.
+const (
+	// CursorBar shows the selected row in bold with a "▌" in its margin.
+	CursorBar = "bar"
+	// CursorReverse shows the selected row in reverse video.
+	CursorReverse = "reverse"
+)
+
+// CursorIndicators lists the accepted TableTheme.CursorIndicator values.
+var CursorIndicators = []string{CursorBar, CursorReverse}
+
+// CursorMark is drawn in the margin of the selected row with CursorBar.
+const CursorMark = "▌"
+
+// DefaultStripeBg is the row stripe background used when striping is enabled.
+const DefaultStripeBg = "236"
+
 func DefaultTableTheme() TableTheme {
 	return TableTheme{
 		HeaderBorderColor: "240",
 		SelectedFg:        "229",
 		SelectedBg:        "57",
+		DimFg:             "240",
 	}
 }
 
@@ -66,5 +138,13 @@ func TableStyles(t TableTheme) table.Styles {
 		Foreground(lipgloss.Color(t.SelectedFg)).
 		Background(lipgloss.Color(t.SelectedBg)).
 		Bold(false)
+	switch t.CursorIndicator {
+	case CursorBar:
+		s.Selected = s.Selected.Bold(true)
+	case CursorReverse:
+		// Swap the terminal's own colors, which stay distinct whatever
+		// colors the reader can tell apart
+		s.Selected = lipgloss.NewStyle().Reverse(true)
+	}
 	return s
 }
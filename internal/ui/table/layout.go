@@ -0,0 +1,81 @@
+package table
+
+import "charm.land/bubbles/v2/table"
+
+const (
+	// defaultContentWidth is the Content column's width before the
+	// terminal's size is known
+	defaultContentWidth = 60
+	// minContentWidth is the narrowest the Content column gets before
+	// other columns are hidden to make room for it
+	minContentWidth = 20
+	// cellPadding is the space the table style pads each cell with
+	cellPadding = 2
+	// absoluteTimeWidth and relativeTimeWidth fit the Time column's
+	// longest text in each format
+	absoluteTimeWidth = 19
+	relativeTimeWidth = 16
+)
+
+// Column positions in a row
+const (
+	numberColumn = iota
+	iconColumn
+	contentColumn
+	pinColumn
+	timeColumn
+	typeColumn
+	idColumn
+)
+
+// hiddenInOrder lists the columns given up, first to last, when the
+// terminal is too narrow for everything; # and Content always stay
+var hiddenInOrder = []int{typeColumn, idColumn, timeColumn, iconColumn, pinColumn}
+
+// layout sizes the columns to fill a table width wide, giving the Content
+// column whatever the others leave. When that is under minContentWidth,
+// columns are hidden (given a width of 0, which the table skips) until it
+// fits. A width of 0 lays out the table for defaultContentWidth.
+func (tm *Manager) layout(width int) []table.Column {
+	timeWidth := absoluteTimeWidth
+	if tm.relative {
+		timeWidth = relativeTimeWidth
+	}
+	columns := []table.Column{
+		numberColumn:  {Title: "#", Width: 5},
+		iconColumn:    {Title: "", Width: 3},
+		contentColumn: {Title: "Content", Width: defaultContentWidth},
+		pinColumn:     {Title: "Pin", Width: 5},
+		timeColumn:    {Title: "Time", Width: timeWidth},
+		typeColumn:    {Title: "Type", Width: 6},
+		idColumn:      {Title: "ID", Width: 6},
+	}
+	if !tm.showIDs {
+		columns[idColumn].Width = 0
+	}
+	if width > 0 {
+		columns[contentColumn].Width = width - fixedWidth(columns)
+		for _, hide := range hiddenInOrder {
+			if columns[contentColumn].Width >= minContentWidth {
+				break
+			}
+			columns[hide].Width = 0
+			columns[contentColumn].Width = width - fixedWidth(columns)
+		}
+		columns[contentColumn].Width = max(columns[contentColumn].Width, minContentWidth)
+	}
+	tm.contentWidth = columns[contentColumn].Width
+	return columns
+}
+
+// fixedWidth is the width taken by every column but Content, and by the
+// padding of all the cells shown
+func fixedWidth(columns []table.Column) int {
+	total := cellPadding // the Content cell's own padding
+	for i, column := range columns {
+		if i != contentColumn && column.Width > 0 {
+			total += column.Width + cellPadding
+		}
+	}
+	return total
+}
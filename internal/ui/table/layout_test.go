@@ -0,0 +1,116 @@
+package table
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/ui/styles"
+)
+
+func TestSetSizeFitsTerminal(t *testing.T) {
+	manager := NewManager(styles.DefaultTableTheme())
+	manager.UpdateRows([]history.ClipboardHistory{
+		{Item: strings.Repeat("é", 300), Hash: "hash1", TimeStamp: time.Now()},
+	})
+
+	for _, width := range []int{50, 70, 80, 120, 200} {
+		manager.SetSize(width, 10)
+		if got := lipgloss.Width(manager.View()); got != width-4 {
+			t.Errorf("width %d: table is %d wide, want %d", width, got, width-4)
+		}
+		if manager.contentWidth < minContentWidth {
+			t.Errorf("width %d: content column is %d wide", width, manager.contentWidth)
+		}
+	}
+
+	manager.SetSize(200, 10)
+	wide := manager.contentWidth
+	manager.SetSize(120, 10)
+	if manager.contentWidth != wide-80 {
+		t.Errorf("content column = %d, want it to shrink with the terminal to %d", manager.contentWidth, wide-80)
+	}
+	// Truncated by display width, not bytes
+	if row := manager.GetTable().Rows()[0][contentColumn]; row != strings.Repeat("é", manager.contentWidth-3)+"..." {
+		t.Errorf("content = %q, want it cut to the column", row)
+	}
+}
+
+func TestSetPageMargin(t *testing.T) {
+	manager := NewManager(styles.DefaultTableTheme())
+	manager.UpdateRows([]history.ClipboardHistory{{Item: "entry", Hash: "hash1", TimeStamp: time.Now()}})
+
+	manager.SetPageMargin(10)
+	manager.SetSize(100, 10)
+	if got := lipgloss.Width(manager.View()); got != 90 {
+		t.Errorf("table is %d wide, want 90 inside a 10 column margin", got)
+	}
+	manager.SetPageMargin(0)
+	manager.SetSize(100, 10)
+	if got := lipgloss.Width(manager.View()); got != 100 {
+		t.Errorf("table is %d wide, want the full 100 without a margin", got)
+	}
+}
+
+func TestLayoutHidesColumnsWhenNarrow(t *testing.T) {
+	manager := NewManager(styles.DefaultTableTheme())
+	manager.SetShowIDs(true)
+
+	columns := manager.layout(120)
+	for i, column := range columns {
+		if column.Width == 0 {
+			t.Errorf("column %d hidden at width 120", i)
+		}
+	}
+
+	columns = manager.layout(60)
+	if columns[typeColumn].Width != 0 || columns[timeColumn].Width != 0 {
+		t.Error("expected Type and Time hidden at width 60")
+	}
+	if columns[pinColumn].Width == 0 || columns[contentColumn].Width < minContentWidth {
+		t.Errorf("columns = %+v, want Pin kept and room for content", columns)
+	}
+
+	columns = manager.layout(25)
+	for _, hidden := range hiddenInOrder {
+		if columns[hidden].Width != 0 {
+			t.Errorf("column %d shown at width 25", hidden)
+		}
+	}
+	if columns[numberColumn].Width == 0 || columns[contentColumn].Width != minContentWidth {
+		t.Errorf("columns = %+v, want # and the narrowest content column", columns)
+	}
+
+	// Relative times are narrower, leaving more room for content
+	absolute := manager.layout(120)[contentColumn].Width
+	manager.SetRelativeTime(true)
+	if relative := manager.layout(120)[contentColumn].Width; relative != absolute+absoluteTimeWidth-relativeTimeWidth {
+		t.Errorf("content column = %d with relative times, want %d", relative, absolute+absoluteTimeWidth-relativeTimeWidth)
+	}
+}
+
+func TestShowIDs(t *testing.T) {
+	manager := NewManager(styles.DefaultTableTheme())
+	manager.UpdateRows([]history.ClipboardHistory{
+		{Item: "saved", Hash: "hash1", ID: 1295, TimeStamp: time.Now()},
+		{Item: "in memory", Hash: "hash2", TimeStamp: time.Now()},
+	})
+	manager.SetSize(120, 10)
+	if width := manager.GetTable().Columns()[idColumn].Width; width != 0 {
+		t.Errorf("ID column is %d wide, want it hidden until asked for", width)
+	}
+
+	manager.SetShowIDs(true)
+	if width := manager.GetTable().Columns()[idColumn].Width; width == 0 {
+		t.Error("ID column hidden after SetShowIDs(true)")
+	}
+	rows := manager.GetTable().Rows()
+	if rows[0][idColumn] != "zz" || rows[1][idColumn] != "" {
+		t.Errorf("IDs = %q, %q; want zz and none", rows[0][idColumn], rows[1][idColumn])
+	}
+	if !strings.Contains(manager.View(), "zz") {
+		t.Error("short ID not shown in the table")
+	}
+}
@@ -1,48 +1,72 @@
 package table
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/table"
+	"charm.land/lipgloss/v2"
+	"github.com/bvdwalt/clippy/internal/detect"
 	"github.com/bvdwalt/clippy/internal/history"
 	"github.com/bvdwalt/clippy/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
 )
 
+// SensitiveMask replaces the content of entries holding secrets
+const SensitiveMask = "••••••••"
+
 // Manager handles table creation and updates
 type Manager struct {
 	table        *table.Model
 	theme        styles.TableTheme
 	lastItems    []history.ClipboardHistory // lastItems holds the items currently displayed (for stable selection)
+	marks        map[string]int             // chain position of marked items by hash
 	contentWidth int
+	width        int    // width the table is laid out for; 0 until sized
+	pageMargin   int    // columns the page margin takes, both sides together
+	masked       bool   // hide every entry's content, e.g. while screen sharing
+	peek         string // hash of the entry shown while masked
+	relative     bool   // show times as "2m ago" rather than in full
+	showIDs      bool   // show each entry's short ID in the ID column
+	now          func() time.Time
 }
 
+// defaultPageMargin is the page margin of the default theme, 2 columns
+// either side
+const defaultPageMargin = 4
+
 // NewManager creates a new table manager
 func NewManager(theme styles.TableTheme) *Manager {
-	columns := []table.Column{
-		{Title: "#", Width: 5},
-		{Title: "Content", Width: 60},
-		{Title: "Pin", Width: 5},
-		{Title: "Time", Width: 19},
-	}
-
 	t := table.New(
-		table.WithColumns(columns),
 		table.WithFocused(true),
 		table.WithHeight(20),
 		table.WithWidth(80),
 	)
 
+	// The UI binds gg, G, Ctrl+d and Ctrl+u itself, and u and d to other
+	// things, so the table keeps only its keys that don't clash
+	t.KeyMap.GotoTop = key.NewBinding(key.WithKeys("home"))
+	t.KeyMap.GotoBottom = key.NewBinding(key.WithKeys("end"))
+	t.KeyMap.HalfPageUp = key.NewBinding(key.WithDisabled())
+	t.KeyMap.HalfPageDown = key.NewBinding(key.WithDisabled())
+
 	// Use centralized conversion for table styles
 	s := styles.TableStyles(theme)
 	// table.New returns a value; take its address to use pointer receivers
 	t.SetStyles(s)
-	return &Manager{
-		table:        &t,
-		theme:        theme,
-		lastItems:    nil,
-		contentWidth: 60,
+	tm := &Manager{
+		table:      &t,
+		theme:      theme,
+		lastItems:  nil,
+		pageMargin: defaultPageMargin,
+		now:        time.Now,
 	}
+	t.SetColumns(tm.layout(0))
+	return tm
 }
 
 // GetTable returns the underlying table model
@@ -56,6 +80,31 @@ func (tm *Manager) SetTable(t *table.Model) {
 	tm.lastItems = nil
 }
 
+// SetMarks shows the given chain positions, keyed by item hash, in the
+// next UpdateRows
+func (tm *Manager) SetMarks(marks map[string]int) {
+	tm.marks = marks
+}
+
+// SetMasked hides the content of every entry but the one with hash peek in
+// the next UpdateRows, showing only its length, type and time.
+func (tm *Manager) SetMasked(masked bool, peek string) {
+	tm.masked = masked
+	tm.peek = peek
+}
+
+// SetShowIDs shows or hides the ID column, holding the short ID each entry
+// can be copied by with clippy copy.
+func (tm *Manager) SetShowIDs(show bool) {
+	tm.showIDs = show
+	if tm.table != nil {
+		tm.table.SetColumns(tm.layout(tm.width))
+	}
+	if tm.lastItems != nil {
+		tm.UpdateRows(tm.lastItems)
+	}
+}
+
 // UpdateRows updates the table with clipboard history items
 func (tm *Manager) UpdateRows(items []history.ClipboardHistory) {
 	if tm.table == nil {
@@ -72,24 +121,42 @@ func (tm *Manager) UpdateRows(items []history.ClipboardHistory) {
 	rows := make([]table.Row, len(items))
 	for i, item := range items {
 		content := item.Item
+		switch {
+		case tm.masked && item.Hash != tm.peek:
+			content = maskedContent(item)
+		case item.Sensitive != "":
+			content = SensitiveMask + " (" + string(item.Sensitive) + ")"
+		}
+		if item.Alias != "" && (!tm.masked || item.Hash == tm.peek) {
+			content = "[" + item.Alias + "] " + content
+		}
 		content = strings.ReplaceAll(content, "\r\n", " ")
 		content = strings.ReplaceAll(content, "\n", " ")
 		content = strings.ReplaceAll(content, "\r", " ")
 		content = strings.ReplaceAll(content, "\t", " ")
 
-		if tm.contentWidth > 3 && len(content) > tm.contentWidth {
-			content = content[:tm.contentWidth-3] + "..."
+		if tm.contentWidth > 3 {
+			content = ansi.Truncate(content, tm.contentWidth, "...")
 		}
 
 		pin := ""
+		if n := tm.marks[item.Hash]; n > 0 {
+			pin = "✓" + strconv.Itoa(n)
+		}
 		if item.Pinned {
-			pin = "📌"
+			pin += "📌"
+		}
+		if !item.ExpiresAt.IsZero() {
+			pin += "⏳"
 		}
 		rows[i] = table.Row{
 			strconv.Itoa(i + 1),
+			typeIcon(item),
 			content,
 			pin,
-			item.TimeStamp.Format("2006-01-02 15:04:05"),
+			tm.formatTime(item.TimeStamp),
+			typeBadge(item),
+			item.ShortID(),
 		}
 	}
 
@@ -150,18 +217,9 @@ func (tm *Manager) SetSize(width, height int) {
 		return
 	}
 
-	tableWidth := width - 4
-	contentWidth := tableWidth - 29 - 4
-	contentWidth = max(contentWidth, 20)
-	tm.contentWidth = contentWidth
-
-	tm.table.SetColumns([]table.Column{
-		{Title: "#", Width: 5},
-		{Title: "Content", Width: contentWidth},
-		{Title: "Pin", Width: 5},
-		{Title: "Time", Width: 19},
-	})
-	tm.table.SetWidth(tableWidth)
+	tm.width = max(width-tm.pageMargin, 1)
+	tm.table.SetColumns(tm.layout(tm.width))
+	tm.table.SetWidth(tm.width)
 	tm.table.SetHeight(height)
 
 	if tm.lastItems != nil {
@@ -171,6 +229,12 @@ func (tm *Manager) SetSize(width, height int) {
 	tm.table.UpdateViewport()
 }
 
+// SetPageMargin sets the columns the page margin around the table takes,
+// both sides together, which SetSize leaves out of the width given
+func (tm *Manager) SetPageMargin(columns int) {
+	tm.pageMargin = max(columns, 0)
+}
+
 // GetCursor returns the current cursor position
 func (tm *Manager) GetCursor() int {
 	if tm.table == nil {
@@ -183,6 +247,46 @@ func (tm *Manager) GetCursor() int {
 	return cursor
 }
 
+// SetCursor moves the cursor to row n
+func (tm *Manager) SetCursor(n int) {
+	if tm.table != nil {
+		tm.table.SetCursor(n)
+	}
+}
+
+// RowAt returns the row shown on line of the rendered table, counting its
+// header from 0. Header and blank lines, and lines past the table, report
+// false.
+func (tm *Manager) RowAt(line int) (int, bool) {
+	if tm.table == nil || line < 0 {
+		return 0, false
+	}
+	lines := strings.Split(tm.table.View(), "\n")
+	if line >= len(lines) {
+		return 0, false
+	}
+	row, ok := rowIndex(lines[line])
+	if !ok || row >= len(tm.lastItems) {
+		return 0, false
+	}
+	return row, true
+}
+
+// VisibleRows returns the rows currently shown in the table, top to
+// bottom.
+func (tm *Manager) VisibleRows() []int {
+	if tm.table == nil {
+		return nil
+	}
+	var rows []int
+	for _, line := range strings.Split(tm.table.View(), "\n") {
+		if row, ok := rowIndex(line); ok && row < len(tm.lastItems) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
 // GetSelectedItem returns the currently selected clipboard item, or nil if none.
 func (tm *Manager) GetSelectedItem() *history.ClipboardHistory {
 	if tm.table == nil || len(tm.lastItems) == 0 {
@@ -198,8 +302,130 @@ func (tm *Manager) GetSelectedItem() *history.ClipboardHistory {
 
 // View returns the table view
 func (tm *Manager) View() string {
+	return tm.Render(true)
+}
+
+// SetTheme replaces the table theme
+func (tm *Manager) SetTheme(theme styles.TableTheme) {
+	tm.theme = theme
+	if tm.table != nil {
+		tm.table.SetStyles(styles.TableStyles(theme))
+		tm.table.UpdateViewport()
+	}
+}
+
+// Render returns the table view with zebra striping applied, dimmed when
+// focused is false
+func (tm *Manager) Render(focused bool) string {
 	if tm.table == nil {
 		return ""
 	}
-	return tm.table.View()
+	view := tm.table.View()
+	bar := tm.theme.CursorIndicator == styles.CursorBar
+	if focused && tm.theme.StripeBg == "" && !bar {
+		return view
+	}
+
+	// The bubbles table has no per-row styling, so style the rendered
+	// lines, telling rows apart by the number in the # column
+	stripe := lipgloss.NewStyle().Background(lipgloss.Color(tm.theme.StripeBg))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color(tm.theme.DimFg))
+	cursor := tm.table.Cursor()
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		row, isRow := rowIndex(line)
+		selected := isRow && row == cursor
+		striped := isRow && row%2 == 1 && tm.theme.StripeBg != ""
+		switch {
+		case focused && striped && !selected:
+			lines[i] = stripe.Render(line)
+		case focused && selected && bar:
+			lines[i] = styles.TableStyles(tm.theme).Selected.Render(withCursorMark(ansi.Strip(line)))
+		case !focused:
+			text := ansi.Strip(line)
+			style := dim
+			if selected {
+				if tm.theme.CursorIndicator == styles.CursorReverse {
+					style = style.Reverse(true)
+				} else {
+					style = style.Background(lipgloss.Color(tm.theme.SelectedBg))
+				}
+				if bar {
+					text = withCursorMark(text)
+				}
+			} else if striped {
+				style = style.Background(lipgloss.Color(tm.theme.StripeBg))
+			}
+			lines[i] = style.Render(text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withCursorMark draws styles.CursorMark in the left padding of a
+// rendered row
+func withCursorMark(line string) string {
+	if rest, ok := strings.CutPrefix(line, " "); ok {
+		return styles.CursorMark + rest
+	}
+	return styles.CursorMark + line
+}
+
+// rowIndex returns the zero-based row shown on a rendered table line, read
+// from its # column. Header, border and blank lines report false.
+func rowIndex(line string) (int, bool) {
+	fields := strings.Fields(ansi.Strip(line))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// maskedContent stands in for an entry's content while the table is
+// masked, e.g. "•••••••• 84 chars"
+func maskedContent(item history.ClipboardHistory) string {
+	if item.IsBinary() || item.Overflow {
+		return SensitiveMask + " " + history.FormatSize(item.Size)
+	}
+	return fmt.Sprintf("%s %d chars", SensitiveMask, utf8.RuneCountInString(item.Item))
+}
+
+// typeBadge is the Type column text: the language for code whose language
+// was detected, otherwise the content type
+func typeBadge(item history.ClipboardHistory) string {
+	if item.Language != "" {
+		return string(item.Language)
+	}
+	return string(item.Type)
+}
+
+// typeIcon is the icon column text, marking the content type so rows can
+// be told apart at a glance; plain text has none
+func typeIcon(item history.ClipboardHistory) string {
+	if item.Sensitive != "" {
+		return "🔑"
+	}
+	switch item.Type {
+	case detect.URL:
+		return "🔗"
+	case detect.Email:
+		return "✉"
+	case detect.Path:
+		return "📁"
+	case detect.JSON:
+		return "{}"
+	case detect.Color:
+		return "🎨"
+	case detect.Code:
+		return "</>"
+	case detect.Table:
+		return "▦"
+	case detect.Image:
+		return "🖼"
+	}
+	return ""
 }
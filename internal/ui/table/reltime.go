@@ -0,0 +1,69 @@
+package table
+
+import (
+	"fmt"
+	"time"
+)
+
+// absoluteLayout is how the Time column shows timestamps when they aren't
+// relative
+const absoluteLayout = "2006-01-02 15:04:05"
+
+// SetRelativeTime shows the Time column as "2m ago" or "yesterday 14:03"
+// rather than the full timestamp.
+func (tm *Manager) SetRelativeTime(relative bool) {
+	tm.relative = relative
+	if tm.table != nil {
+		// Relative times are shorter, leaving more room for content
+		tm.table.SetColumns(tm.layout(tm.width))
+	}
+	tm.RefreshTimes()
+}
+
+// RelativeTime reports whether the Time column is relative.
+func (tm *Manager) RelativeTime() bool {
+	return tm.relative
+}
+
+// RefreshTimes redraws the rows so relative times stay current; absolute
+// times never change, so nothing is done for them.
+func (tm *Manager) RefreshTimes() {
+	if tm.lastItems != nil {
+		tm.UpdateRows(tm.lastItems)
+	}
+}
+
+// formatTime is the Time column text for t
+func (tm *Manager) formatTime(t time.Time) string {
+	if !tm.relative {
+		return t.Format(absoluteLayout)
+	}
+	return relativeTime(t, tm.now())
+}
+
+// relativeTime describes t as seen at now, more coarsely the older it is:
+// "just now", "5m ago", "3h ago", "yesterday 14:03", "Mon 14:03", "Jan 2
+// 14:03", then the date alone
+func relativeTime(t, now time.Time) string {
+	t = t.In(now.Location())
+	age := now.Sub(t)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch {
+	case age < 0:
+		// Clocks between machines syncing history can disagree
+		return t.Format("2006-01-02 15:04")
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case !t.Before(today):
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "yesterday " + t.Format("15:04")
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return t.Format("Mon 15:04")
+	case t.Year() == now.Year():
+		return t.Format("Jan 2 15:04")
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,54 @@
+package table
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/ui/styles"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 14, 15, 30, 0, 0, time.UTC) // a Thursday
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{now.Add(-20 * time.Second), "just now"},
+		{now.Add(-2 * time.Minute), "2m ago"},
+		{now.Add(-59 * time.Minute), "59m ago"},
+		{now.Add(-3 * time.Hour), "3h ago"},
+		{time.Date(2024, 3, 14, 0, 5, 0, 0, time.UTC), "15h ago"},
+		{time.Date(2024, 3, 13, 14, 3, 0, 0, time.UTC), "yesterday 14:03"},
+		{time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC), "Sat 09:00"},
+		{time.Date(2024, 1, 2, 8, 15, 0, 0, time.UTC), "Jan 2 08:15"},
+		{time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC), "2023-12-31"},
+		{now.Add(time.Hour), "2024-03-14 16:30"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(tt.at, now); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestSetRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 14, 15, 30, 0, 0, time.UTC)
+	manager := NewManager(styles.DefaultTableTheme())
+	manager.now = func() time.Time { return now }
+	manager.UpdateRows([]history.ClipboardHistory{{Item: "a", Hash: "hash1", TimeStamp: now.Add(-5 * time.Minute)}})
+
+	if got := manager.GetTable().Rows()[0][4]; got != "2024-03-14 15:25:00" {
+		t.Errorf("Time column = %q, want the full timestamp by default", got)
+	}
+	manager.SetRelativeTime(true)
+	if got := manager.GetTable().Rows()[0][4]; got != "5m ago" {
+		t.Errorf("Time column = %q, want %q", got, "5m ago")
+	}
+
+	now = now.Add(time.Hour)
+	manager.RefreshTimes()
+	if got := manager.GetTable().Rows()[0][4]; got != "1h ago" {
+		t.Errorf("Time column after refresh = %q, want %q", got, "1h ago")
+	}
+}
@@ -0,0 +1,23 @@
+package ui
+
+import "time"
+
+// timeRefresh is how often relative times in the table are redrawn; they
+// only show minutes, so the 500ms tick would redraw far more than needed
+const timeRefresh = 10 * time.Second
+
+// SetRelativeTime shows when entries were copied as "2m ago" or
+// "yesterday 14:03" rather than the full timestamp; the toggle key
+// switches it.
+func (m *Model) SetRelativeTime(relative bool) {
+	m.tableManager.SetRelativeTime(relative)
+}
+
+// refreshTimes redraws relative times once they may have gone stale
+func (m *Model) refreshTimes(now time.Time) {
+	if !m.tableManager.RelativeTime() || now.Sub(m.timesRefreshed) < timeRefresh {
+		return
+	}
+	m.timesRefreshed = now
+	m.tableManager.RefreshTimes()
+}
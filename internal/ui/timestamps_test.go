@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormatToggle(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("hello")
+	model := NewModel(historyManager)
+	timeColumn := func() string { return model.tableManager.GetTable().Rows()[0][4] }
+
+	if got := timeColumn(); got != "just now" {
+		t.Errorf("Time column = %q, want relative by default", got)
+	}
+	model = typeText(model, "T")
+	if want := historyManager.GetItems()[0].TimeStamp.Format("2006-01-02 15:04:05"); timeColumn() != want {
+		t.Errorf("Time column = %q, want %q after T", timeColumn(), want)
+	}
+	model = typeText(model, "T")
+	if got := timeColumn(); got != "just now" {
+		t.Errorf("Time column = %q, want relative again", got)
+	}
+}
+
+func TestTickRefreshesRelativeTimes(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	historyManager.AddItem("hello")
+	model := NewModel(historyManager)
+	model.SetHeadless(true)
+
+	start := time.Now()
+	newModel, _ := model.Update(TickMsg(start))
+	model = newModel.(Model)
+	if !model.timesRefreshed.Equal(start) {
+		t.Fatalf("timesRefreshed = %v, want the first tick", model.timesRefreshed)
+	}
+	newModel, _ = model.Update(TickMsg(start.Add(timeRefresh / 2)))
+	model = newModel.(Model)
+	if !model.timesRefreshed.Equal(start) {
+		t.Error("expected no redraw before timeRefresh has passed")
+	}
+	newModel, _ = model.Update(TickMsg(start.Add(timeRefresh)))
+	model = newModel.(Model)
+	if !model.timesRefreshed.Equal(start.Add(timeRefresh)) {
+		t.Error("expected a redraw once timeRefresh has passed")
+	}
+
+	model = typeText(model, "T")
+	newModel, _ = model.Update(TickMsg(start.Add(3 * timeRefresh)))
+	model = newModel.(Model)
+	if !model.timesRefreshed.Equal(start.Add(timeRefresh)) {
+		t.Error("expected absolute times never to be redrawn")
+	}
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxBreadcrumbPath is the longest database path shown in the title before
+// leading directories are elided.
+const maxBreadcrumbPath = 40
+
+// titleBar renders the title followed by a breadcrumb of the database and
+// any active filters, so it is always clear which subset is shown.
+func (m Model) titleBar() string {
+	title := m.theme.Title.Render("📋 Clippy Clipboard History") + "  " + m.theme.Help.Margin(0).Render("version: "+m.version)
+	crumbs := strings.Join(m.breadcrumbs(), " › ")
+	line := title + "  " + m.theme.Help.Margin(0).Render(crumbs)
+	if width := m.helpWidth(); width > 0 {
+		line = ansi.Truncate(line, width, "…")
+	}
+	return line
+}
+
+// breadcrumbs lists the database followed by the active filter expression
+func (m Model) breadcrumbs() []string {
+	db := "in-memory"
+	if path := m.historyManager.DBPath(); path != "" {
+		db = abbreviatePath(path)
+	}
+	crumbs := []string{db}
+	if m.filtered != nil {
+		if query := strings.TrimSpace(m.textInput.Value()); query != "" {
+			crumbs = append(crumbs, "search: "+query)
+		}
+	}
+	if m.typeFilter != "" {
+		crumbs = append(crumbs, "type: "+string(m.typeFilter))
+	}
+	return crumbs
+}
+
+// abbreviatePath shortens path for display: the home directory becomes ~
+// and long paths keep only their last two elements.
+func abbreviatePath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = filepath.Join("~", rel)
+		}
+	}
+	if len(path) <= maxBreadcrumbPath {
+		return path
+	}
+	return filepath.Join("…", filepath.Base(filepath.Dir(path)), filepath.Base(path))
+}
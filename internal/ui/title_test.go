@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+func TestAbbreviatePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(home, ".clippy", "clippy.db"), filepath.Join("~", ".clippy", "clippy.db")},
+		{"/tmp/clippy.db", "/tmp/clippy.db"},
+		{"/var/lib/some/very/deeply/nested/location/for/clippy.db", filepath.Join("…", "for", "clippy.db")},
+	}
+	for _, tt := range tests {
+		if got := abbreviatePath(tt.path); got != tt.want {
+			t.Errorf("abbreviatePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTitleShowsDatabaseAndFilters(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+	historyManager.AddItem("https://example.com")
+	historyManager.AddItem("plain note")
+
+	model := NewModel(historyManager)
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	model = newModel.(Model)
+	if !contains(model.titleBar(), "test.db") {
+		t.Errorf("expected database in title, got %q", model.titleBar())
+	}
+
+	model = pressKey(model, tea.Key{Text: "/"})
+	model = typeText(model, "example")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	model = pressKey(model, tea.Key{Text: "t"})
+	title := model.titleBar()
+	if !contains(title, "search: example") || !contains(title, "type: "+string(model.typeFilter)) {
+		t.Errorf("expected active filters in title, got %q", title)
+	}
+
+	if crumbs := NewModel(history.NewInMemoryManager()).breadcrumbs(); crumbs[0] != "in-memory" {
+		t.Errorf("breadcrumbs for in-memory history = %v", crumbs)
+	}
+}
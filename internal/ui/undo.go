@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bvdwalt/clippy/internal/history"
+)
+
+// maxUndo is how many deletes u can undo.
+const maxUndo = 50
+
+// deleteItems deletes the items with the given hashes as one step that u
+// undoes, and forgets what was undone before
+func (m *Model) deleteItems(hashes ...string) {
+	var removed []history.Removed
+	for _, hash := range hashes {
+		if r, ok := m.deleteByHash(hash); ok {
+			removed = append(removed, r)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	m.undo = append(m.undo, removed)
+	if len(m.undo) > maxUndo {
+		m.undo = m.undo[len(m.undo)-maxUndo:]
+	}
+	m.redo = nil
+}
+
+// undoDelete restores the items of the last delete, which Ctrl+r deletes
+// again
+func (m *Model) undoDelete() {
+	if len(m.undo) == 0 {
+		m.notice = "nothing to undo"
+		return
+	}
+	removed := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+
+	var restored []string
+	for _, r := range removed {
+		if err := m.historyManager.Restore(r); err != nil {
+			log.Printf("Failed to restore clip: %v", err)
+			continue
+		}
+		restored = append(restored, r.Item.Hash)
+	}
+	if len(restored) > 0 {
+		m.redo = append(m.redo, restored)
+	}
+	if m.filtered != nil {
+		m.filterItems(m.textInput.Value())
+	}
+	m.updateTable()
+	m.notice = fmt.Sprintf("restored %s", plural(len(restored), "item"))
+	if len(restored) < len(removed) {
+		m.notice += fmt.Sprintf(", %d already back in history", len(removed)-len(restored))
+	}
+}
+
+// redoDelete deletes again the items the last undo restored
+func (m *Model) redoDelete() {
+	if len(m.redo) == 0 {
+		m.notice = "nothing to redo"
+		return
+	}
+	hashes := m.redo[len(m.redo)-1]
+	redo := m.redo[:len(m.redo)-1]
+	m.deleteItems(hashes...)
+	m.redo = redo
+	m.notice = fmt.Sprintf("deleted %s again", plural(len(hashes), "item"))
+}
+
+// plural formats a count of things, e.g. "1 item" or "3 items"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestUndoAndRedoDelete(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for _, text := range []string{"one", "two", "three"} {
+		historyManager.AddItem(text)
+	}
+	model := NewModel(historyManager)
+	model.SetInstantDelete(true)
+	model = typeText(model, "u")
+	if !contains(model.View().Content, "nothing to undo") {
+		t.Error("expected a notice when there is nothing to undo")
+	}
+
+	deleted := historyManager.GetItems()[0].Hash
+	model = typeText(model, "d")
+	model = markWithSpace(model, 2)
+	model = typeText(model, "D")
+	model = typeText(model, "y")
+	if historyManager.Count() != 0 {
+		t.Fatalf("expected every item deleted, have %d", historyManager.Count())
+	}
+
+	// The marked items come back together, then the single delete
+	model = typeText(model, "u")
+	if historyManager.Count() != 2 || !contains(model.View().Content, "restored 2 items") {
+		t.Fatalf("expected the batch delete undone, have %d items", historyManager.Count())
+	}
+	model = typeText(model, "u")
+	if historyManager.Count() != 3 || model.findByHash(deleted) == nil {
+		t.Fatalf("expected the first delete undone, have %d items", historyManager.Count())
+	}
+
+	model = pressKey(model, tea.Key{Code: 'r', Mod: tea.ModCtrl})
+	if historyManager.Count() != 2 || model.findByHash(deleted) != nil {
+		t.Errorf("expected Ctrl+r to delete %s again, have %d items", deleted, historyManager.Count())
+	}
+	model = typeText(model, "u")
+	if historyManager.Count() != 3 {
+		t.Errorf("expected the redone delete undone, have %d items", historyManager.Count())
+	}
+}
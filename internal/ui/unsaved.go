@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bvdwalt/clippy/internal/instance"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// persistRetryInterval is how often an unopenable database is retried.
+// Overridable for tests.
+var persistRetryInterval = 10 * time.Second
+
+// SetUnsaved tells the model its history is only in memory because the
+// database at dbPath couldn't be opened with err. A banner warns that
+// nothing is being saved while the database is retried in the background;
+// once it opens, what was collected meanwhile is stored there. An empty
+// dbPath is never retried.
+func (m *Model) SetUnsaved(dbPath string, err error) {
+	m.unsavedPath, m.unsavedErr = dbPath, err
+	m.nextPersist = time.Now().Add(persistRetryInterval)
+}
+
+// retryPersist tries opening the unsaved database again, if it is due
+func (m *Model) retryPersist(now time.Time) {
+	if m.unsavedPath == "" || now.Before(m.nextPersist) {
+		return
+	}
+	m.nextPersist = now.Add(persistRetryInterval)
+	stored, err := m.historyManager.Persist(m.unsavedPath)
+	if err != nil {
+		m.unsavedErr = err
+		return
+	}
+	log.Printf("History database opened; saved %d entries collected meanwhile", stored)
+	m.unsavedPath, m.unsavedErr = "", nil
+	m.takeCaptureLock()
+	m.updateTable()
+}
+
+// CaptureLocker takes the capture lock of the history, failing with an
+// *instance.HeldError while another process holds it.
+type CaptureLocker func() (Heartbeat, error)
+
+// SetCaptureLocker has the capture lock taken with locker once history
+// collected while the database couldn't be opened is stored there.
+func (m *Model) SetCaptureLocker(locker CaptureLocker) {
+	m.locker = locker
+}
+
+// takeCaptureLock takes the capture lock of the history just stored, as it
+// would have been at startup. If another clippy started capturing
+// meanwhile, this one only views what that records from now on.
+func (m *Model) takeCaptureLock() {
+	if m.locker == nil || m.viewer {
+		return
+	}
+	heartbeat, err := m.locker()
+	var held *instance.HeldError
+	switch {
+	case errors.As(err, &held) && held.Owner.Role == instance.Daemon:
+		m.SetViewer(true)
+	case errors.As(err, &held):
+		m.SetAttached(held.Owner.PID)
+	case err != nil:
+		log.Printf("Failed to take the capture lock: %v", err)
+	default:
+		m.heartbeat = heartbeat
+	}
+}
+
+// unsavedBanner warns that history isn't being saved, or is "" when it is
+func (m Model) unsavedBanner() string {
+	if m.unsavedErr == nil {
+		return ""
+	}
+	text := fmt.Sprintf("⚠ History is not being saved: %v", m.unsavedErr)
+	if m.unsavedPath != "" {
+		text += " (retrying)"
+	}
+	if width := m.helpWidth(); width > 0 {
+		text = ansi.Truncate(text, width-2, "…")
+	}
+	return m.theme.Warning.Render(text)
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bvdwalt/clippy/internal/history"
+	"github.com/bvdwalt/clippy/internal/instance"
+)
+
+func TestUnsavedHistoryIsStoredOnceTheDatabaseOpens(t *testing.T) {
+	orig := persistRetryInterval
+	t.Cleanup(func() { persistRetryInterval = orig })
+	persistRetryInterval = time.Minute
+
+	historyManager := history.NewInMemoryManager()
+	historyManager.AddItem("copied while unsaved")
+	dir := filepath.Join(t.TempDir(), "data")
+	// A file in the way of the data directory, as on a read-only mount
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	model := NewModel(historyManager)
+	model.SetHeadless(true)
+	model.SetUnsaved(filepath.Join(dir, "clippy.db"), errors.New("database is locked"))
+	newModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = newModel.(Model)
+	if !contains(model.View().Content, "History is not being saved: database is locked (retrying)") {
+		t.Fatal("expected a warning banner")
+	}
+
+	newModel, _ = model.Update(TickMsg(time.Now().Add(2 * time.Minute)))
+	model = newModel.(Model)
+	if !contains(model.View().Content, "saved: error creating directory") {
+		t.Error("expected the banner to show why the retry failed")
+	}
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	newModel, _ = model.Update(TickMsg(time.Now().Add(time.Second)))
+	model = newModel.(Model)
+	if historyManager.DBPath() != "" {
+		t.Fatal("expected no retry before the interval")
+	}
+	newModel, _ = model.Update(TickMsg(time.Now().Add(4 * time.Minute)))
+	model = newModel.(Model)
+	defer func() {
+		if err := historyManager.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	}()
+	if contains(model.View().Content, "not being saved") {
+		t.Error("expected the banner gone once the database opened")
+	}
+	if historyManager.DBPath() == "" || historyManager.Count() != 1 {
+		t.Errorf("history at %q with %d items, want the item stored", historyManager.DBPath(), historyManager.Count())
+	}
+}
+
+// unsavedModel is a model whose history is stored on the first retry
+func unsavedModel(t *testing.T, locker CaptureLocker) (Model, *history.Manager) {
+	t.Helper()
+	orig := persistRetryInterval
+	t.Cleanup(func() { persistRetryInterval = orig })
+	persistRetryInterval = time.Minute
+
+	historyManager := history.NewInMemoryManager()
+	t.Cleanup(func() {
+		if err := historyManager.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	})
+	historyManager.AddItem("copied while unsaved")
+	model := NewModel(historyManager)
+	model.SetClipboard(&fakeClipboard{text: "copied elsewhere"})
+	model.SetClipboardWatcher(make(fakeWatcher, 1))
+	model.SetUnsaved(filepath.Join(t.TempDir(), "clippy.db"), errors.New("database is locked"))
+	model.SetCaptureLocker(locker)
+	newModel, _ := model.Update(TickMsg(time.Now().Add(2 * time.Minute)))
+	model = newModel.(Model)
+	if historyManager.DBPath() == "" {
+		t.Fatal("expected the history stored")
+	}
+	return model, historyManager
+}
+
+func TestStoredHistoryTakesTheCaptureLock(t *testing.T) {
+	heartbeat := &fakeHeartbeat{}
+	model, _ := unsavedModel(t, func() (Heartbeat, error) { return heartbeat, nil })
+
+	// Refreshed from the tick that stored history on
+	newModel, _ := model.Update(TickMsg(time.Now()))
+	model = newModel.(Model)
+	if heartbeat.beats != 2 || model.viewer {
+		t.Errorf("beats = %d, viewer %v; want the lock refreshed while capturing", heartbeat.beats, model.viewer)
+	}
+}
+
+func TestStoredHistoryViewsAnotherCapturer(t *testing.T) {
+	model, historyManager := unsavedModel(t, func() (Heartbeat, error) {
+		return nil, &instance.HeldError{Owner: instance.Owner{PID: 4242, Role: instance.TUI}}
+	})
+	if !model.viewer || !contains(model.View().Content, "attached to clippy (pid 4242)") {
+		t.Fatal("expected to attach to the clippy that took the lock")
+	}
+
+	// That clippy records the copy
+	newModel, _ := model.Update(clipboardChangedMsg{})
+	model = newModel.(Model)
+	if historyManager.Count() != 1 {
+		t.Errorf("Count = %d, want the copy left to the capturing clippy", historyManager.Count())
+	}
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+)
+
+// moveCursor moves the table cursor to row, clamped to the table, starting
+// the preview from the top of the new entry
+func (m *Model) moveCursor(row int) {
+	last := len(m.getDisplayItems()) - 1
+	if last < 0 {
+		return
+	}
+	m.tableManager.SetCursor(min(max(row, 0), last))
+	m.previewOffset = 0
+}
+
+// halfPage is how many rows Ctrl+d and Ctrl+u move, as in vim
+func (m *Model) halfPage() int {
+	return max(m.tableManager.GetTable().Height()/2, 1)
+}
+
+// nextResult moves the cursor to the next entry matching the last search,
+// or with step -1 the previous one, wrapping around the table as vim does.
+// The search needn't still be applied, so results can be stepped through
+// without hiding the rest of history.
+func (m *Model) nextResult(step int) {
+	if m.lastSearch == "" {
+		m.notice = "no previous search"
+		return
+	}
+	items := m.getDisplayItems()
+	matches := make(map[string]bool)
+	for _, item := range m.matcher.Search(items, m.lastSearch) {
+		matches[item.Hash] = true
+	}
+	if len(matches) == 0 {
+		m.notice = fmt.Sprintf("no match for %q", m.lastSearch)
+		return
+	}
+	cursor := m.tableManager.GetCursor()
+	for i := 1; i <= len(items); i++ {
+		row := cursor + i*step
+		wrapped := row < 0 || row >= len(items)
+		row = (row%len(items) + len(items)) % len(items)
+		if !matches[items[row].Hash] {
+			continue
+		}
+		m.moveCursor(row)
+		switch {
+		case wrapped && step > 0:
+			m.notice = "search hit bottom, continuing at top"
+		case wrapped:
+			m.notice = "search hit top, continuing at bottom"
+		}
+		return
+	}
+}
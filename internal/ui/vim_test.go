@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestVimNavigation(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for i := range 30 {
+		historyManager.AddItem(fmt.Sprintf("entry %02d", i))
+	}
+	model := NewModel(historyManager)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = updated.(Model)
+
+	model = typeText(model, "G")
+	if got := model.GetCursor(); got != 29 {
+		t.Fatalf("G: cursor = %d, want 29", got)
+	}
+	// A single g does nothing; gg goes to the top
+	model = typeText(model, "g")
+	if got := model.GetCursor(); got != 29 {
+		t.Errorf("g: cursor = %d, want 29", got)
+	}
+	model = typeText(model, "g")
+	if got := model.GetCursor(); got != 0 {
+		t.Errorf("gg: cursor = %d, want 0", got)
+	}
+	// Another key between the gs cancels it
+	model = typeText(model, "Gg")
+	model = pressKey(model, tea.Key{Code: 'j', Text: "j"})
+	model = typeText(model, "g")
+	if got := model.GetCursor(); got != 29 {
+		t.Errorf("g j g: cursor = %d, want 29", got)
+	}
+
+	model = typeText(model, "gg")
+	half := model.halfPage()
+	model = pressKey(model, tea.Key{Code: 'd', Mod: tea.ModCtrl})
+	if got := model.GetCursor(); got != half {
+		t.Errorf("Ctrl+d: cursor = %d, want %d", got, half)
+	}
+	model = pressKey(model, tea.Key{Code: 'u', Mod: tea.ModCtrl})
+	model = pressKey(model, tea.Key{Code: 'u', Mod: tea.ModCtrl})
+	if got := model.GetCursor(); got != 0 {
+		t.Errorf("Ctrl+u: cursor = %d, want 0", got)
+	}
+	if model.confirmDelete || len(model.undo) != 0 {
+		t.Error("expected Ctrl+d and Ctrl+u not to delete or undo")
+	}
+}
+
+func TestNextResult(t *testing.T) {
+	historyManager, cleanup := setupTestHistoryManager(t)
+	defer cleanup()
+
+	for _, s := range []string{"apple", "banana", "apricot", "cherry", "avocado"} {
+		historyManager.AddItem(s)
+	}
+	model := NewModel(historyManager)
+
+	model = typeText(model, "n")
+	if model.notice != "no previous search" {
+		t.Errorf("notice = %q", model.notice)
+	}
+
+	model = typeText(model, "/ap")
+	model = pressKey(model, tea.Key{Code: tea.KeyEnter})
+	model = typeText(model, "/")
+	model = pressKey(model, tea.Key{Code: tea.KeyEscape})
+	if model.filtered != nil {
+		t.Fatal("expected / Esc to clear the search")
+	}
+
+	// n and N step through the matches in the full table, wrapping
+	var rows []int
+	for i, item := range model.getDisplayItems() {
+		if item.Item == "apple" || item.Item == "apricot" {
+			rows = append(rows, i)
+		}
+	}
+	model.moveCursor(rows[0])
+	model = typeText(model, "n")
+	if got := model.GetCursor(); got != rows[1] {
+		t.Errorf("n: cursor = %d, want %d", got, rows[1])
+	}
+	model = typeText(model, "n")
+	if got := model.GetCursor(); got != rows[0] || model.notice == "" {
+		t.Errorf("n at the last match: cursor = %d, notice %q; want to wrap to %d", got, model.notice, rows[0])
+	}
+	model = typeText(model, "N")
+	if got := model.GetCursor(); got != rows[1] || model.notice == "" {
+		t.Errorf("N at the first match: cursor = %d, notice %q; want to wrap to %d", got, model.notice, rows[1])
+	}
+}
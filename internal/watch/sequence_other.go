@@ -0,0 +1,8 @@
+//go:build !windows
+
+package watch
+
+// clipboardSequence is only available on Windows.
+func clipboardSequence() (uint32, bool) {
+	return 0, false
+}
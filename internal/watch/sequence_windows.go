@@ -0,0 +1,15 @@
+package watch
+
+import "syscall"
+
+var procGetClipboardSequenceNumber = syscall.NewLazyDLL("user32.dll").NewProc("GetClipboardSequenceNumber")
+
+// clipboardSequence returns the clipboard sequence number, which Windows
+// increments whenever the clipboard contents change.
+func clipboardSequence() (uint32, bool) {
+	if procGetClipboardSequenceNumber.Find() != nil {
+		return 0, false
+	}
+	seq, _, _ := procGetClipboardSequenceNumber.Call()
+	return uint32(seq), seq != 0
+}
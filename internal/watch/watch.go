@@ -0,0 +1,275 @@
+// Package watch delivers clipboard change notifications from the platform,
+// so the clipboard is read when it changes instead of on a fixed poll.
+//
+// Wayland uses `wl-paste --watch`, X11 uses `clipnotify` (which waits for
+// XFixes selection events) and Windows compares the clipboard sequence
+// number. Elsewhere New returns ErrUnsupported and callers keep polling.
+//
+// Some apps set the clipboard several times in quick succession, so a burst
+// of notifications is only reported once it has been quiet for the settle
+// window, and only the final value is read.
+package watch
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported is returned by New when no change notification is
+// available and the clipboard must be polled.
+var ErrUnsupported = errors.New("no clipboard change notification available")
+
+const (
+	// sequencePollInterval is how often the Windows clipboard sequence
+	// number is compared; reading it is far cheaper than reading the
+	// clipboard.
+	sequencePollInterval = 100 * time.Millisecond
+	// restartDelay throttles restarting a watch command that keeps failing.
+	restartDelay = time.Second
+	// SettleWindow is how long the clipboard must go unchanged before a
+	// change is reported.
+	SettleWindow = 150 * time.Millisecond
+)
+
+// Overridable for tests.
+var (
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+	lookPath = exec.LookPath
+	command  = exec.CommandContext
+	sequence = clipboardSequence
+	settle   = SettleWindow
+)
+
+// Watcher signals clipboard changes.
+type Watcher struct {
+	changes chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	name    string
+}
+
+// Changes receives a value once the clipboard may have changed and has
+// settled. Notifications that arrive while one is pending are coalesced.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Name describes the notification mechanism, e.g. "wl-paste".
+func (w *Watcher) Name() string {
+	return w.name
+}
+
+// Close stops watching and waits for the watcher to exit.
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+// New starts a watcher using the platform's change notification. With
+// primary set, changes to the X11 primary selection are reported too.
+func New(primary bool) (*Watcher, error) {
+	switch goos {
+	case "windows":
+		return Sequence()
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if getenv("WAYLAND_DISPLAY") != "" {
+			if w, err := Wayland(primary); err == nil {
+				return w, nil
+			}
+		}
+		if getenv("DISPLAY") != "" {
+			return Clipnotify()
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+// Wayland starts a watcher running `wl-paste --watch`, also watching the
+// primary selection with primary set.
+func Wayland(primary bool) (*Watcher, error) {
+	if _, err := lookPath("wl-paste"); err != nil {
+		return nil, ErrUnsupported
+	}
+	watch := watchLines("wl-paste", "--watch", "echo")
+	if primary {
+		watch = watchAll(watch, watchLines("wl-paste", "--primary", "--watch", "echo"))
+	}
+	return start("wl-paste", watch), nil
+}
+
+// Clipnotify starts a watcher running clipnotify, which waits for X11
+// XFixes selection events and reports both the clipboard and the primary
+// selection.
+func Clipnotify() (*Watcher, error) {
+	if _, err := lookPath("clipnotify"); err != nil {
+		return nil, ErrUnsupported
+	}
+	return start("clipnotify", watchExits("clipnotify")), nil
+}
+
+// Sequence starts a watcher comparing the Windows clipboard sequence number.
+func Sequence() (*Watcher, error) {
+	if _, ok := sequence(); !ok {
+		return nil, ErrUnsupported
+	}
+	return start("sequence number", watchSequence), nil
+}
+
+// start runs watch until Close, which cancels its context
+func start(name string, watch func(ctx context.Context, notify func())) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		changes: make(chan struct{}, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		name:    name,
+	}
+	events := make(chan struct{}, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		watch(ctx, func() { signal(events) })
+	}()
+	go func() {
+		defer wg.Done()
+		settleEvents(ctx, events, w.changes)
+	}()
+	go func() {
+		wg.Wait()
+		close(w.done)
+	}()
+	return w
+}
+
+// settleEvents forwards a burst of events to changes once none has
+// arrived for the settle window
+func settleEvents(ctx context.Context, events <-chan struct{}, changes chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		}
+		timer := time.NewTimer(settle)
+	burst:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-events:
+				timer.Reset(settle)
+			case <-timer.C:
+				break burst
+			}
+		}
+		signal(changes)
+	}
+}
+
+// signal sends on ch unless a value is already pending
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// watchAll runs several watches at once, e.g. one per selection
+func watchAll(watches ...func(context.Context, func())) func(context.Context, func()) {
+	return func(ctx context.Context, notify func()) {
+		var wg sync.WaitGroup
+		for _, watch := range watches {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				watch(ctx, notify)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// watchLines runs a long-lived command that prints a line per change,
+// restarting it if it exits
+func watchLines(name string, args ...string) func(context.Context, func()) {
+	return func(ctx context.Context, notify func()) {
+		for ctx.Err() == nil {
+			cmd := command(ctx, name, args...)
+			stdout, err := cmd.StdoutPipe()
+			if err == nil {
+				err = cmd.Start()
+			}
+			if err != nil {
+				log.Printf("Failed to start %s: %v", name, err)
+			} else {
+				scanner := bufio.NewScanner(stdout)
+				for scanner.Scan() {
+					notify()
+				}
+				// Drain so Wait doesn't block on a full pipe
+				_, _ = io.Copy(io.Discard, stdout)
+				if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+					log.Printf("%s exited: %v", name, err)
+				}
+			}
+			sleep(ctx, restartDelay)
+		}
+	}
+}
+
+// watchExits runs a command that exits once per change, such as clipnotify
+func watchExits(name string, args ...string) func(context.Context, func()) {
+	return func(ctx context.Context, notify func()) {
+		for ctx.Err() == nil {
+			if err := command(ctx, name, args...).Run(); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("%s failed: %v", name, err)
+				sleep(ctx, restartDelay)
+				continue
+			}
+			notify()
+		}
+	}
+}
+
+// watchSequence compares the Windows clipboard sequence number, which
+// changes on every clipboard write
+func watchSequence(ctx context.Context, notify func()) {
+	last, _ := sequence()
+	ticker := time.NewTicker(sequencePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if seq, ok := sequence(); ok && seq != last {
+				last = seq
+				notify()
+			}
+		}
+	}
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
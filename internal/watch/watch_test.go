@@ -0,0 +1,186 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useEnv stubs the platform, environment and installed commands.
+func useEnv(t *testing.T, os string, env map[string]string, installed ...string) {
+	t.Helper()
+	origGOOS, origGetenv, origLookPath := goos, getenv, lookPath
+	t.Cleanup(func() { goos, getenv, lookPath = origGOOS, origGetenv, origLookPath })
+	goos = os
+	getenv = func(key string) string { return env[key] }
+	lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+// useScript replaces every watch command with a shell script.
+func useScript(t *testing.T, script string) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	orig := command
+	t.Cleanup(func() { command = orig })
+	command = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", script)
+	}
+}
+
+// waitChange fails the test if no change is reported within a second.
+func waitChange(t *testing.T, w *Watcher) {
+	t.Helper()
+	select {
+	case <-w.Changes():
+	case <-time.After(time.Second):
+		t.Fatal("expected a change notification")
+	}
+}
+
+func TestNewSelectsMechanism(t *testing.T) {
+	useScript(t, "exec sleep 10")
+	tests := []struct {
+		name      string
+		os        string
+		env       map[string]string
+		installed []string
+		want      string
+	}{
+		{"wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"wl-paste", "clipnotify"}, "wl-paste"},
+		{"xwayland fallback", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"clipnotify"}, "clipnotify"},
+		{"x11", "freebsd", map[string]string{"DISPLAY": ":0"}, []string{"clipnotify"}, "clipnotify"},
+		{"x11 without clipnotify", "linux", map[string]string{"DISPLAY": ":0"}, nil, ""},
+		{"no display", "linux", nil, []string{"wl-paste", "clipnotify"}, ""},
+		{"macos", "darwin", map[string]string{"DISPLAY": ":0"}, []string{"clipnotify"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useEnv(t, tt.os, tt.env, tt.installed...)
+			w, err := New(false)
+			if tt.want == "" {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Errorf("New() error = %v, want ErrUnsupported", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer func() { _ = w.Close() }()
+			if w.Name() != tt.want {
+				t.Errorf("Name() = %q, want %q", w.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWindowsUsesSequenceNumber(t *testing.T) {
+	useEnv(t, "windows", nil)
+	orig := sequence
+	t.Cleanup(func() { sequence = orig })
+
+	sequence = func() (uint32, bool) { return 0, false }
+	if _, err := New(false); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("New() error = %v, want ErrUnsupported", err)
+	}
+
+	sequence = func() (uint32, bool) { return 1, true }
+	w, err := New(false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+	if w.Name() != "sequence number" {
+		t.Errorf("Name() = %q, want %q", w.Name(), "sequence number")
+	}
+}
+
+func TestWatchLinesNotifiesPerLine(t *testing.T) {
+	useScript(t, "echo; exec sleep 10")
+	w := start("test", watchLines("wl-paste", "--watch", "echo"))
+	waitChange(t, w)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestWatchAllRunsEveryWatch(t *testing.T) {
+	var started atomic.Int32
+	watch := func(ctx context.Context, notify func()) {
+		if started.Add(1) == 2 {
+			notify()
+		}
+		<-ctx.Done()
+	}
+	w := start("test", watchAll(watch, watch))
+	defer func() { _ = w.Close() }()
+	waitChange(t, w)
+}
+
+func TestWatchExitsNotifiesPerRun(t *testing.T) {
+	useScript(t, "sleep 0.3")
+	w := start("test", watchExits("clipnotify"))
+	defer func() { _ = w.Close() }()
+	waitChange(t, w)
+	waitChange(t, w)
+}
+
+func TestWatchSequenceNotifiesOnChange(t *testing.T) {
+	var seq atomic.Uint32
+	orig := sequence
+	t.Cleanup(func() { sequence = orig })
+	sequence = func() (uint32, bool) { return seq.Load(), true }
+
+	w := start("test", watchSequence)
+	defer func() { _ = w.Close() }()
+
+	select {
+	case <-w.Changes():
+		t.Fatal("unexpected notification before the sequence changed")
+	case <-time.After(3 * sequencePollInterval):
+	}
+	seq.Add(1)
+	waitChange(t, w)
+}
+
+func TestBurstIsReportedOnceSettled(t *testing.T) {
+	orig := settle
+	t.Cleanup(func() { settle = orig })
+	settle = 50 * time.Millisecond
+
+	notified := make(chan struct{})
+	w := start("test", func(ctx context.Context, notify func()) {
+		// Intermediate values, each within the settle window of the last
+		for range 5 {
+			notify()
+			time.Sleep(settle / 5)
+		}
+		close(notified)
+		<-ctx.Done()
+	})
+	defer func() { _ = w.Close() }()
+
+	select {
+	case <-w.Changes():
+		t.Fatal("expected no change to be reported during the burst")
+	case <-notified:
+	}
+	waitChange(t, w)
+	select {
+	case <-w.Changes():
+		t.Error("expected the burst to be reported once")
+	case <-time.After(2 * settle):
+	}
+}
@@ -0,0 +1,136 @@
+// Package wsl accesses the Windows clipboard from inside the Windows
+// Subsystem for Linux through the interop binaries clip.exe and
+// powershell.exe, converting between UTF-8 and Windows' UTF-16.
+package wsl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrUnavailable is returned when the Windows interop binaries cannot be found.
+var ErrUnavailable = errors.New("windows clipboard tools not found")
+
+// Fallback locations used when the Windows PATH is not appended inside WSL.
+const (
+	clipFallback       = "/mnt/c/Windows/System32/clip.exe"
+	powershellFallback = "/mnt/c/Windows/System32/WindowsPowerShell/v1.0/powershell.exe"
+)
+
+// readScript prints the clipboard as base64-encoded UTF-16LE, so the text
+// survives the console code page unchanged.
+const readScript = "$t = Get-Clipboard -Raw; if ($t) { [Convert]::ToBase64String([Text.Encoding]::Unicode.GetBytes($t)) }"
+
+// Overridable for tests.
+var (
+	getenv   = os.Getenv
+	readFile = os.ReadFile
+	lookPath = exec.LookPath
+	stat     = os.Stat
+	run      = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		cmd := exec.Command(name, args...)
+		if stdin != nil {
+			cmd.Stdin = bytes.NewReader(stdin)
+		}
+		return cmd.Output()
+	}
+)
+
+// Detect reports whether clippy is running inside WSL.
+func Detect() bool {
+	if getenv("WSL_DISTRO_NAME") != "" || getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	release, err := readFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// Available reports whether the Windows clipboard tools can be found.
+func Available() bool {
+	if _, err := findBinary("clip.exe", clipFallback); err != nil {
+		return false
+	}
+	_, err := findBinary("powershell.exe", powershellFallback)
+	return err == nil
+}
+
+// ReadAll returns the text on the Windows clipboard with Windows line
+// endings converted to "\n".
+func ReadAll() (string, error) {
+	powershell, err := findBinary("powershell.exe", powershellFallback)
+	if err != nil {
+		return "", err
+	}
+	out, err := run(nil, powershell, "-NoProfile", "-NonInteractive", "-Command", readScript)
+	if err != nil {
+		return "", fmt.Errorf("error reading windows clipboard: %w", err)
+	}
+	encoded := strings.TrimSpace(string(out))
+	if encoded == "" {
+		return "", nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("error decoding windows clipboard: %w", err)
+	}
+	return strings.ReplaceAll(decodeUTF16(raw), "\r\n", "\n"), nil
+}
+
+// WriteAll places text on the Windows clipboard.
+func WriteAll(text string) error {
+	clip, err := findBinary("clip.exe", clipFallback)
+	if err != nil {
+		return err
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\n", "\r\n")
+	if _, err := run(encodeUTF16(text), clip); err != nil {
+		return fmt.Errorf("error writing windows clipboard: %w", err)
+	}
+	return nil
+}
+
+// findBinary locates an interop binary on PATH or at its default location.
+func findBinary(name, fallback string) (string, error) {
+	if path, err := lookPath(name); err == nil {
+		return path, nil
+	}
+	if _, err := stat(fallback); err == nil {
+		return fallback, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnavailable, name)
+}
+
+// encodeUTF16 converts s to UTF-16LE with a byte order mark, which clip.exe
+// recognises regardless of the console code page.
+func encodeUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, 2, 2+2*len(units))
+	binary.LittleEndian.PutUint16(buf, 0xFEFF)
+	for _, u := range units {
+		buf = binary.LittleEndian.AppendUint16(buf, u)
+	}
+	return buf
+}
+
+// decodeUTF16 converts UTF-16LE bytes to a string, dropping any byte order mark.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(b[i:]))
+	}
+	if len(units) > 0 && units[0] == 0xFEFF {
+		units = units[1:]
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,118 @@
+package wsl
+
+import (
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// fakeWindows replaces the exec hooks with an in-memory Windows clipboard.
+type fakeWindows struct {
+	clipboard []byte // UTF-16LE, as Windows stores it
+	written   []byte
+	calls     []string
+}
+
+func useFake(t *testing.T, onPath bool) *fakeWindows {
+	t.Helper()
+	f := &fakeWindows{}
+	origLook, origStat, origRun := lookPath, stat, run
+	lookPath = func(name string) (string, error) {
+		if onPath {
+			return "/mnt/c/bin/" + name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	stat = func(string) (os.FileInfo, error) { return nil, fs.ErrNotExist }
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		f.calls = append(f.calls, name)
+		if stdin != nil {
+			f.written = stdin
+			return nil, nil
+		}
+		return []byte(base64.StdEncoding.EncodeToString(f.clipboard) + "\r\n"), nil
+	}
+	t.Cleanup(func() { lookPath, stat, run = origLook, origStat, origRun })
+	return f
+}
+
+func TestDetect(t *testing.T) {
+	origGetenv, origRead := getenv, readFile
+	t.Cleanup(func() { getenv, readFile = origGetenv, origRead })
+
+	tests := []struct {
+		name    string
+		env     string
+		release string
+		want    bool
+	}{
+		{"distro env", "Ubuntu", "6.1.0-generic", true},
+		{"kernel release", "", "5.15.90.1-microsoft-standard-WSL2", true},
+		{"native linux", "", "6.1.0-generic", false},
+	}
+	for _, tt := range tests {
+		getenv = func(key string) string {
+			if key == "WSL_DISTRO_NAME" {
+				return tt.env
+			}
+			return ""
+		}
+		readFile = func(string) ([]byte, error) { return []byte(tt.release), nil }
+		if got := Detect(); got != tt.want {
+			t.Errorf("%s: Detect() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadAllDecodesUTF16(t *testing.T) {
+	f := useFake(t, true)
+	f.clipboard = encodeUTF16("héllo ✓\r\nwörld 🎉")
+
+	got, err := ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if want := "héllo ✓\nwörld 🎉"; got != want {
+		t.Errorf("ReadAll() = %q, want %q", got, want)
+	}
+}
+
+func TestReadAllEmpty(t *testing.T) {
+	f := useFake(t, true)
+	f.clipboard = nil
+
+	if got, err := ReadAll(); err != nil || got != "" {
+		t.Errorf("ReadAll() = %q, %v; want empty", got, err)
+	}
+}
+
+func TestWriteAllEncodesUTF16(t *testing.T) {
+	f := useFake(t, true)
+
+	if err := WriteAll("naïve\nline 🎉"); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	if f.calls[0] != "/mnt/c/bin/clip.exe" {
+		t.Errorf("expected clip.exe to be run, got %v", f.calls)
+	}
+	if got := decodeUTF16(f.written); got != "naïve\r\nline 🎉" {
+		t.Errorf("clip.exe received %q", got)
+	}
+	if f.written[0] != 0xFF || f.written[1] != 0xFE {
+		t.Error("expected UTF-16LE byte order mark")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	useFake(t, false)
+
+	if Available() {
+		t.Error("expected tools to be unavailable")
+	}
+	if err := WriteAll("x"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+}